@@ -17,12 +17,17 @@ import (
 	"net/http"
 
 	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 )
 
 type AuditClientInterface interface {
 	SaveAuditLog(ctx context.Context, h http.Header, logs ...metadata.AuditLog) (*metadata.Response, error)
 	SearchAuditLog(ctx context.Context, h http.Header, param metadata.QueryInput) (*metadata.AuditQueryResult, error)
+	ListAuditRetentionPolicy(ctx context.Context, h http.Header) ([]metadata.AuditRetentionPolicy, errors.CCErrorCoder)
+	UpdateAuditRetentionPolicy(ctx context.Context, h http.Header, policy metadata.AuditRetentionPolicy) errors.CCErrorCoder
+	ListAuditLogArchive(ctx context.Context, h http.Header, option metadata.ListAuditLogArchiveOption) (*metadata.MultipleAuditLogArchive, errors.CCErrorCoder)
+	ImportAuditLogArchive(ctx context.Context, h http.Header, archiveID int64) (*metadata.ImportAuditLogArchiveResult, errors.CCErrorCoder)
 }
 
 func NewAuditClientInterface(client rest.ClientInterface) AuditClientInterface {
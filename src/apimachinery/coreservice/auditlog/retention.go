@@ -0,0 +1,119 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+func (inst *auditlog) ListAuditRetentionPolicy(ctx context.Context, h http.Header) ([]metadata.AuditRetentionPolicy, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              []metadata.AuditRetentionPolicy `json:"data"`
+	}{}
+
+	err := inst.client.Post().
+		WithContext(ctx).
+		SubResourcef("/findmany/auditlog/retention_policy").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("ListAuditRetentionPolicy failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (inst *auditlog) UpdateAuditRetentionPolicy(ctx context.Context, h http.Header, policy metadata.AuditRetentionPolicy) errors.CCErrorCoder {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+	}{}
+
+	err := inst.client.Put().
+		WithContext(ctx).
+		Body(policy).
+		SubResourcef("/update/auditlog/retention_policy").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("UpdateAuditRetentionPolicy failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return nil
+}
+
+func (inst *auditlog) ListAuditLogArchive(ctx context.Context, h http.Header, option metadata.ListAuditLogArchiveOption) (*metadata.MultipleAuditLogArchive, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.MultipleAuditLogArchive `json:"data"`
+	}{}
+
+	err := inst.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/auditlog/archive").
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("ListAuditLogArchive failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (inst *auditlog) ImportAuditLogArchive(ctx context.Context, h http.Header, archiveID int64) (*metadata.ImportAuditLogArchiveResult, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.ImportAuditLogArchiveResult `json:"data"`
+	}{}
+
+	err := inst.client.Post().
+		WithContext(ctx).
+		SubResourcef("/import/auditlog/archive/%d", archiveID).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("ImportAuditLogArchive failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
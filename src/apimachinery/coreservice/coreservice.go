@@ -27,6 +27,7 @@ import (
 	"configcenter/src/apimachinery/coreservice/model"
 	"configcenter/src/apimachinery/coreservice/operation"
 	"configcenter/src/apimachinery/coreservice/process"
+	"configcenter/src/apimachinery/coreservice/recyclebin"
 	"configcenter/src/apimachinery/coreservice/settemplate"
 	"configcenter/src/apimachinery/coreservice/synchronize"
 	ccSystem "configcenter/src/apimachinery/coreservice/system"
@@ -54,6 +55,7 @@ type CoreServiceClientInterface interface {
 	Txn() transaction.Interface
 	Count() count.CountClientInterface
 	Cache() cache.Interface
+	RecycleBin() recyclebin.RecycleBinInterface
 }
 
 func NewCoreServiceClient(c *util.Capability, version string) CoreServiceClientInterface {
@@ -135,3 +137,7 @@ func (c *coreService) Count() count.CountClientInterface {
 func (c *coreService) Cache() cache.Interface {
 	return cache.NewCacheClient(c.restCli)
 }
+
+func (c *coreService) RecycleBin() recyclebin.RecycleBinInterface {
+	return recyclebin.NewRecycleBinClient(c.restCli)
+}
@@ -245,3 +245,126 @@ func (p *hostApplyRule) RunHostApplyOnHosts(ctx context.Context, header http.Hea
 	}
 	return ret.Data, nil
 }
+
+func (p *hostApplyRule) GetHostApplyDriftConfig(ctx context.Context, header http.Header, bizID int64) (metadata.HostApplyDriftConfig, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.HostApplyDriftConfig `json:"data"`
+	}{}
+
+	err := p.client.Get().
+		WithContext(ctx).
+		SubResourcef("/find/host_apply_drift_config/bk_biz_id/%d/", bizID).
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("GetHostApplyDriftConfig failed, http request failed, err: %+v", err)
+		return ret.Data, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return ret.Data, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (p *hostApplyRule) UpdateHostApplyDriftConfig(ctx context.Context, header http.Header, bizID int64, option metadata.UpdateHostApplyDriftConfigOption) (metadata.HostApplyDriftConfig, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.HostApplyDriftConfig `json:"data"`
+	}{}
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/update/host_apply_drift_config/bk_biz_id/%d/", bizID).
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("UpdateHostApplyDriftConfig failed, http request failed, err: %+v", err)
+		return ret.Data, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return ret.Data, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (p *hostApplyRule) ListHostApplyDriftConfig(ctx context.Context, header http.Header, option metadata.ListHostApplyDriftConfigOption) ([]metadata.HostApplyDriftConfig, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              []metadata.HostApplyDriftConfig `json:"data"`
+	}{}
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/host_apply_drift_config").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("ListHostApplyDriftConfig failed, http request failed, err: %+v", err)
+		return ret.Data, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return ret.Data, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (p *hostApplyRule) SaveHostApplyDrift(ctx context.Context, header http.Header, option metadata.SaveHostApplyDriftOption) errors.CCErrorCoder {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+	}{}
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/updatemany/host_apply_drift").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("SaveHostApplyDrift failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return nil
+}
+
+func (p *hostApplyRule) ListHostApplyDrift(ctx context.Context, header http.Header, bizID int64, option metadata.ListHostApplyDriftOption) (metadata.MultipleHostApplyDrift, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              metadata.MultipleHostApplyDrift `json:"data"`
+	}{}
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/host_apply_drift/bk_biz_id/%d/", bizID).
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("ListHostApplyDrift failed, http request failed, err: %+v", err)
+		return ret.Data, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return ret.Data, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
@@ -31,6 +31,12 @@ type HostApplyRuleInterface interface {
 	SearchRuleRelatedModules(ctx context.Context, header http.Header, bizID int64, option metadata.SearchRuleRelatedModulesOption) ([]metadata.Module, errors.CCErrorCoder)
 	BatchUpdateHostApplyRule(ctx context.Context, header http.Header, bizID int64, option metadata.BatchCreateOrUpdateApplyRuleOption) (metadata.BatchCreateOrUpdateHostApplyRuleResult, errors.CCErrorCoder)
 	RunHostApplyOnHosts(ctx context.Context, header http.Header, bizID int64, option metadata.UpdateHostByHostApplyRuleOption) (metadata.MultipleHostApplyResult, errors.CCErrorCoder)
+
+	GetHostApplyDriftConfig(ctx context.Context, header http.Header, bizID int64) (metadata.HostApplyDriftConfig, errors.CCErrorCoder)
+	UpdateHostApplyDriftConfig(ctx context.Context, header http.Header, bizID int64, option metadata.UpdateHostApplyDriftConfigOption) (metadata.HostApplyDriftConfig, errors.CCErrorCoder)
+	ListHostApplyDriftConfig(ctx context.Context, header http.Header, option metadata.ListHostApplyDriftConfigOption) ([]metadata.HostApplyDriftConfig, errors.CCErrorCoder)
+	SaveHostApplyDrift(ctx context.Context, header http.Header, option metadata.SaveHostApplyDriftOption) errors.CCErrorCoder
+	ListHostApplyDrift(ctx context.Context, header http.Header, bizID int64, option metadata.ListHostApplyDriftOption) (metadata.MultipleHostApplyDrift, errors.CCErrorCoder)
 }
 
 func NewHostApplyRuleClient(client rest.ClientInterface) HostApplyRuleInterface {
@@ -16,6 +16,8 @@ import (
 	"context"
 	"net/http"
 
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 )
 
@@ -116,3 +118,30 @@ func (inst *instance) DeleteInstanceCascade(ctx context.Context, h http.Header,
 		Into(resp)
 	return
 }
+
+func (inst *instance) RevealSecret(ctx context.Context, h http.Header, objID string, instID int64,
+	propertyID string) (*metadata.RevealSecretResult, errors.CCErrorCoder) {
+
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.RevealSecretResult `json:"data"`
+	}{}
+	subPath := "/read/model/%s/instance/%d/secret/%s"
+
+	err := inst.client.Post().
+		WithContext(ctx).
+		SubResourcef(subPath, objID, instID, propertyID).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("RevealSecret failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
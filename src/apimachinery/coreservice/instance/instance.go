@@ -17,6 +17,7 @@ import (
 	"net/http"
 
 	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 )
 
@@ -28,6 +29,7 @@ type InstanceClientInterface interface {
 	ReadInstance(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (resp *metadata.QueryConditionResult, err error)
 	DeleteInstance(ctx context.Context, h http.Header, objID string, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
 	DeleteInstanceCascade(ctx context.Context, h http.Header, objID string, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
+	RevealSecret(ctx context.Context, h http.Header, objID string, instID int64, propertyID string) (*metadata.RevealSecretResult, errors.CCErrorCoder)
 }
 
 func NewInstanceClientInterface(client rest.ClientInterface) InstanceClientInterface {
@@ -24,6 +24,13 @@ import (
 type MainlineClientInterface interface {
 	SearchMainlineModelTopo(ctx context.Context, h http.Header, withDetail bool) (*metadata.TopoModelNode, errors.CCErrorCoder)
 	SearchMainlineInstanceTopo(ctx context.Context, h http.Header, bkBizID int64, withDetail bool) (resp *metadata.TopoInstanceNode, err errors.CCErrorCoder)
+
+	CreateTopoSnapshot(ctx context.Context, h http.Header, bizID int64, option metadata.CreateTopoSnapshotOption) (*metadata.TopoSnapshot, errors.CCErrorCoder)
+	ListTopoSnapshot(ctx context.Context, h http.Header, bizID int64, option metadata.ListTopoSnapshotOption) (*metadata.MultipleTopoSnapshot, errors.CCErrorCoder)
+	DeleteTopoSnapshot(ctx context.Context, h http.Header, bizID int64, snapshotID int64) errors.CCErrorCoder
+	DiffTopoSnapshot(ctx context.Context, h http.Header, bizID int64, option metadata.DiffTopoSnapshotOption) (*metadata.TopoSnapshotDiff, errors.CCErrorCoder)
+	GetTopoSnapshotSchedule(ctx context.Context, h http.Header, bizID int64) (*metadata.TopoSnapshotSchedule, errors.CCErrorCoder)
+	UpdateTopoSnapshotSchedule(ctx context.Context, h http.Header, bizID int64, schedule metadata.TopoSnapshotSchedule) errors.CCErrorCoder
 }
 
 func NewMainlineClientInterface(client rest.ClientInterface) MainlineClientInterface {
@@ -0,0 +1,168 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mainline
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+func (m *mainline) CreateTopoSnapshot(ctx context.Context, h http.Header, bizID int64, option metadata.CreateTopoSnapshotOption) (*metadata.TopoSnapshot, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.TopoSnapshot `json:"data"`
+	}{}
+
+	err := m.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/create/topo/snapshot/bk_biz_id/%d", bizID).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("CreateTopoSnapshot failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (m *mainline) ListTopoSnapshot(ctx context.Context, h http.Header, bizID int64, option metadata.ListTopoSnapshotOption) (*metadata.MultipleTopoSnapshot, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.MultipleTopoSnapshot `json:"data"`
+	}{}
+
+	err := m.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/topo/snapshot/bk_biz_id/%d", bizID).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("ListTopoSnapshot failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (m *mainline) DeleteTopoSnapshot(ctx context.Context, h http.Header, bizID int64, snapshotID int64) errors.CCErrorCoder {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+	}{}
+
+	err := m.client.Delete().
+		WithContext(ctx).
+		SubResourcef("/delete/topo/snapshot/%d/bk_biz_id/%d", snapshotID, bizID).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("DeleteTopoSnapshot failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return nil
+}
+
+func (m *mainline) DiffTopoSnapshot(ctx context.Context, h http.Header, bizID int64, option metadata.DiffTopoSnapshotOption) (*metadata.TopoSnapshotDiff, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.TopoSnapshotDiff `json:"data"`
+	}{}
+
+	err := m.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/find/topo/snapshot/diff/bk_biz_id/%d", bizID).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("DiffTopoSnapshot failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (m *mainline) GetTopoSnapshotSchedule(ctx context.Context, h http.Header, bizID int64) (*metadata.TopoSnapshotSchedule, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.TopoSnapshotSchedule `json:"data"`
+	}{}
+
+	err := m.client.Get().
+		WithContext(ctx).
+		SubResourcef("/find/topo/snapshot/schedule/bk_biz_id/%d", bizID).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("GetTopoSnapshotSchedule failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (m *mainline) UpdateTopoSnapshotSchedule(ctx context.Context, h http.Header, bizID int64, schedule metadata.TopoSnapshotSchedule) errors.CCErrorCoder {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+	}{}
+
+	err := m.client.Put().
+		WithContext(ctx).
+		Body(schedule).
+		SubResourcef("/update/topo/snapshot/schedule/bk_biz_id/%d", bizID).
+		WithHeaders(h).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("UpdateTopoSnapshotSchedule failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return nil
+}
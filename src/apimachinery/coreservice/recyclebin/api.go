@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recyclebin
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+func (r *recycleBin) ListRecycleBin(ctx context.Context, header http.Header, option metadata.ListRecycleBinOption) (*metadata.MultipleRecycleBinItem, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.MultipleRecycleBinItem `json:"data"`
+	}{}
+
+	err := r.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/findmany/recycle_bin").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("ListRecycleBin failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (r *recycleBin) RestoreRecycleBin(ctx context.Context, header http.Header, id int64) (*metadata.RecycleBinItem, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.RecycleBinItem `json:"data"`
+	}{}
+
+	err := r.client.Post().
+		WithContext(ctx).
+		SubResourcef("/restore/recycle_bin/%d", id).
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("RestoreRecycleBin failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (r *recycleBin) PurgeRecycleBin(ctx context.Context, header http.Header, option metadata.PurgeRecycleBinOption) (*metadata.PurgeRecycleBinResult, errors.CCErrorCoder) {
+	ret := struct {
+		metadata.BaseResp `json:",inline"`
+		Data              *metadata.PurgeRecycleBinResult `json:"data"`
+	}{}
+
+	err := r.client.Delete().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef("/delete/recycle_bin").
+		WithHeaders(header).
+		Do().
+		Into(&ret)
+
+	if err != nil {
+		blog.Errorf("PurgeRecycleBin failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.NewCCError(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
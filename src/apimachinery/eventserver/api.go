@@ -104,8 +104,8 @@ func (e *eventServer) Rebook(ctx context.Context, ownerID string, appID string,
 	return
 }
 
-func (e *eventServer) Watch(ctx context.Context, h http.Header, opts *watch.WatchEventOptions) (resp []*watch.WatchEventDetail, err error) {
-	resp = make([]*watch.WatchEventDetail, 0)
+func (e *eventServer) Watch(ctx context.Context, h http.Header, opts *watch.WatchEventOptions) (resp *metadata.WatchEventResult, err error) {
+	resp = new(metadata.WatchEventResult)
 	err = e.client.Post().
 		WithContext(ctx).
 		Body(opts).
@@ -115,3 +115,45 @@ func (e *eventServer) Watch(ctx context.Context, h http.Header, opts *watch.Watc
 		Into(resp)
 	return
 }
+
+func (e *eventServer) ListDeadLetters(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterSearch) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/subscribe/deadletter/search/%s/%s/%s"
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResourcef(subPath, ownerID, appID, subscribeID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) ReplayDeadLetters(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, opt *metadata.DeadLetterOption) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/subscribe/deadletter/replay/%s/%s/%s"
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath, ownerID, appID, subscribeID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) PurgeDeadLetters(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, opt *metadata.DeadLetterOption) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/subscribe/deadletter/%s/%s/%s"
+
+	err = e.client.Delete().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef(subPath, ownerID, appID, subscribeID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -30,7 +30,10 @@ type EventServerClientInterface interface {
 	Subscribe(ctx context.Context, ownerID string, appID string, h http.Header, subscription *metadata.Subscription) (resp *metadata.Response, err error)
 	UnSubscribe(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header) (resp *metadata.Response, err error)
 	Rebook(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, subscription *metadata.Subscription) (resp *metadata.Response, err error)
-	Watch(ctx context.Context, h http.Header, opts *watch.WatchEventOptions) (resp []*watch.WatchEventDetail, err error)
+	Watch(ctx context.Context, h http.Header, opts *watch.WatchEventOptions) (resp *metadata.WatchEventResult, err error)
+	ListDeadLetters(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterSearch) (resp *metadata.Response, err error)
+	ReplayDeadLetters(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, opt *metadata.DeadLetterOption) (resp *metadata.Response, err error)
+	PurgeDeadLetters(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, opt *metadata.DeadLetterOption) (resp *metadata.Response, err error)
 }
 
 func NewEventServerClientInterface(c *util.Capability, version string) EventServerClientInterface {
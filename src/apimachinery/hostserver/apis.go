@@ -523,6 +523,48 @@ func (hs *hostServer) GetUserCustomQueryResult(ctx context.Context, businessID,
 	return
 }
 
+func (hs *hostServer) CreateDynamicGroup(ctx context.Context, h http.Header, option *metadata.DynamicGroupOption) (resp *metadata.IDResult, err error) {
+	resp = new(metadata.IDResult)
+	subPath := "/dynamicgroup"
+
+	err = hs.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (hs *hostServer) UpdateDynamicGroup(ctx context.Context, businessID string, id string, h http.Header, option *metadata.DynamicGroupOption) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := "/dynamicgroup/%s/%s"
+
+	err = hs.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath, businessID, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (hs *hostServer) ExecuteDynamicGroup(ctx context.Context, businessID string, id string, h http.Header, option *metadata.ExecuteDynamicGroupOption) (resp *metadata.DynamicGroupResultResponse, err error) {
+	resp = new(metadata.DynamicGroupResultResponse)
+	subPath := "/dynamicgroup/data/%s/%s"
+
+	err = hs.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath, businessID, id).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 func (hs *hostServer) HostSearch(ctx context.Context, h http.Header, params *metadata.HostCommonSearch) (resp *metadata.QueryInstResult, err error) {
 
 	resp = new(metadata.QueryInstResult)
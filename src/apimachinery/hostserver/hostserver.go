@@ -65,6 +65,9 @@ type HostServerClientInterface interface {
 	GetUserCustomQuery(ctx context.Context, businessID string, h http.Header, dat *metadata.QueryInput) (resp *metadata.Response, err error)
 	GetUserCustomQueryDetail(ctx context.Context, businessID string, id string, h http.Header) (resp *metadata.UserCustomQueryDetailResult, err error)
 	GetUserCustomQueryResult(ctx context.Context, businessID, id, start, limit string, h http.Header) (resp *metadata.Response, err error)
+	CreateDynamicGroup(ctx context.Context, h http.Header, option *metadata.DynamicGroupOption) (resp *metadata.IDResult, err error)
+	UpdateDynamicGroup(ctx context.Context, businessID string, id string, h http.Header, option *metadata.DynamicGroupOption) (resp *metadata.Response, err error)
+	ExecuteDynamicGroup(ctx context.Context, businessID string, id string, h http.Header, option *metadata.ExecuteDynamicGroupOption) (resp *metadata.DynamicGroupResultResponse, err error)
 	HostSearch(ctx context.Context, h http.Header, params *metadata.HostCommonSearch) (resp *metadata.QueryInstResult, err error)
 	ListBizHostsTopo(ctx context.Context, h http.Header, bizID int64, params *metadata.ListHostsWithNoBizParameter) (resp *metadata.SuccessResponse, err error)
 }
@@ -21,7 +21,8 @@ import (
 
 //ServerOption define option of server in flags
 type ServerOption struct {
-	ServConf *config.CCAPIConfig
+	ServConf  *config.CCAPIConfig
+	EnableTxn bool
 }
 
 //NewServerOption create a ServerOption object
@@ -38,5 +39,6 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181")
 	fs.StringVar(&s.ServConf.ExConfig, "config", "", "The config path. e.g ")
 	fs.StringVar(&s.ServConf.RegisterIP, "register-ip", "", "the ip address registered on zookeeper, it can be domain")
+	fs.BoolVar(&s.EnableTxn, "enable-txn", true, "enable transaction of the batch operation or not")
 	fs.Var(auth.EnableAuthFlag, "enable-auth", "The auth center enable status, true for enabled, false for disabled")
 }
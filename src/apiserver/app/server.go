@@ -66,7 +66,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	if err != nil {
 		return err
 	}
-	authorize, err := auth.NewAuthorize(nil, authConf, engine.Metric().Registry())
+	authorize, err := auth.NewAuthorize(ctx, nil, authConf, engine.Metric().Registry())
 	if err != nil {
 		return fmt.Errorf("new authorize failed, err: %v", err)
 	}
@@ -88,7 +88,8 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		return err
 	}
 
-	svc.SetConfig(engine, client, engine.Discovery(), authorize, cache, limiter)
+	blog.Infof("enableTxn is %t", op.EnableTxn)
+	svc.SetConfig(engine, client, engine.Discovery(), authorize, cache, limiter, op.EnableTxn)
 
 	ctnr := restful.NewContainer()
 	ctnr.Router(restful.CurlyRouter{})
@@ -17,7 +17,9 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/builtin"
 	"configcenter/src/auth/meta"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
@@ -83,6 +85,92 @@ func (s *service) AuthVerify(req *restful.Request, resp *restful.Response) {
 	resp.WriteEntity(metadata.NewSuccessResp(resources))
 }
 
+// AuthExplain dry run the authorization of the resources, and explains which rule decides
+// each of the decisions, it's only supported by the authorizers which implements auth.Explainer.
+// explaining for another user requires the config admin's find permission.
+func (s *service) AuthExplain(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+	rid := util.GetHTTPCCRequestID(pheader)
+
+	if s.authorizer.Enabled() == false {
+		blog.Errorf("inappropriate calling, auth is disabled, rid: %s", rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommInappropriateVisitToIAM)})
+		return
+	}
+
+	explainer, ok := s.authorizer.(auth.Explainer)
+	if !ok {
+		blog.Errorf("explain authorize decisions, but the authorizer does not support it, rid: %s", rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrAPIAuthExplainNotSupported)})
+		return
+	}
+
+	body := metadata.AuthExplainRequest{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&body); err != nil {
+		blog.Errorf("explain authorize decisions, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+	caller := meta.UserInfo{
+		UserName:        util.GetUser(pheader),
+		SupplierAccount: ownerID,
+	}
+	user := caller
+	if len(body.UserName) != 0 && body.UserName != caller.UserName {
+		adminAttr := &meta.AuthAttribute{
+			User: caller,
+			Resources: []meta.ResourceAttribute{{
+				Basic:           meta.Basic{Type: meta.ConfigAdmin, Action: meta.Find},
+				SupplierAccount: ownerID,
+			}},
+		}
+		decision, err := s.authorizer.Authorize(ctx, adminAttr)
+		if err != nil {
+			blog.Errorf("explain authorize decisions, but authorize caller %s failed, err: %v, rid: %s", caller.UserName, err, rid)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommCheckAuthorizeFailed)})
+			return
+		}
+		if !decision.Authorized {
+			blog.Errorf("user %s has no permission to explain the authorize decisions of user %s, rid: %s", caller.UserName,
+				body.UserName, rid)
+			resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: defErr.Error(common.CCErrCommAuthNotHavePermission)})
+			return
+		}
+		user.UserName = body.UserName
+	}
+
+	attrs := make([]meta.ResourceAttribute, len(body.Resources))
+	for i, res := range body.Resources {
+		attrs[i].BusinessID = res.BizID
+		attrs[i].SupplierAccount = ownerID
+		attrs[i].Type = meta.ResourceType(res.ResourceType)
+		attrs[i].InstanceID = res.ResourceID
+		attrs[i].InstanceIDEx = res.ResourceIDEx
+		attrs[i].Name = res.ResourceName
+		attrs[i].Action = meta.Action(res.Action)
+		for _, item := range res.ParentLayers {
+			attrs[i].Layers = append(attrs[i].Layers, meta.Item{Type: meta.ResourceType(item.ResourceType), InstanceID: item.ResourceID})
+		}
+	}
+
+	explanations, err := explainer.Explain(ctx, user, attrs...)
+	if err != nil {
+		blog.Errorf("explain authorize decisions for user %s failed, err: %v, rid: %s", user.UserName, err, rid)
+		if err == builtin.ErrExplainNotSupported {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrAPIAuthExplainNotSupported)})
+			return
+		}
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrAPIAuthExplainFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(explanations))
+}
+
 func (s *service) GetAdminEntrance(req *restful.Request, resp *restful.Response) {
 	pheader := req.Request.Header
 	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
@@ -0,0 +1,330 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// BatchOperation runs the operations of the request one by one in a single transaction. It stops
+// at the first failed operation and rolls back all the operations that have been run, the results
+// of the operations run so far are returned either way.
+// Only the data written to db is rolled back by the transaction, the side effects of the operations
+// outside of db, like the resources registered to iam, are kept. The operations are not rolled back
+// at all if the transaction is disabled by the enable-txn flag.
+func (s *service) BatchOperation(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+
+	input := new(metadata.BatchOperationRequest)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("batch operation, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if field, ok := input.Validate(); !ok {
+		blog.Errorf("batch operation, but request is invalid, field: %s, rid: %s", field, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, field)})
+		return
+	}
+
+	// the operations join the transaction by carrying its header to the scene servers,
+	// which pass it on to coreservice.
+	txnHeader := util.CloneHeader(header)
+	ctx := context.WithValue(req.Request.Context(), common.ContextRequestIDField, rid)
+	txn, err := s.engine.CoreAPI.CoreService().Txn().NewTransaction(s.enableTxn, txnHeader)
+	if err != nil {
+		blog.Errorf("batch operation, but start transaction failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommStartTransactionFailed)})
+		return
+	}
+
+	results := make([]metadata.BatchOperationResult, 0, len(input.Operations))
+	datas := make([]interface{}, 0, len(input.Operations))
+	for idx, op := range input.Operations {
+		result := s.runBatchOperation(req, txnHeader, idx, op, datas, defErr)
+		results = append(results, result)
+		if !result.Result {
+			blog.Errorf("batch operation %d %s %s failed, code: %d, err: %s, rid: %s", idx, op.Method, op.Path,
+				result.Code, result.ErrMsg, rid)
+			if err := txn.AbortTransaction(ctx, txnHeader); err != nil {
+				blog.Errorf("batch operation, but abort transaction failed, err: %v, rid: %s", err, rid)
+			}
+			resp.WriteEntity(metadata.BatchOperationResponse{BaseResp: result.BaseResp, Data: results})
+			return
+		}
+		datas = append(datas, result.Data)
+	}
+
+	if err := txn.CommitTransaction(ctx, txnHeader); err != nil {
+		blog.Errorf("batch operation, but commit transaction failed, err: %v, rid: %s", err, rid)
+		ccErr := defErr.CCError(common.CCErrCommCommitTransactionFailed)
+		resp.WriteEntity(metadata.BatchOperationResponse{
+			BaseResp: metadata.BaseResp{Result: false, Code: ccErr.GetCode(), ErrMsg: ccErr.Error()},
+			Data:     results,
+		})
+		return
+	}
+
+	resp.WriteEntity(metadata.BatchOperationResponse{BaseResp: metadata.SuccessBaseResp, Data: results})
+}
+
+// runBatchOperation authorizes the operation and proxies it to the scene server it belongs to.
+// datas are the data returned by the operations before it, which its references are resolved with.
+func (s *service) runBatchOperation(req *restful.Request, header http.Header, idx int, op metadata.BatchOperation,
+	datas []interface{}, defErr errors.DefaultCCErrorIf) metadata.BatchOperationResult {
+
+	rid := util.GetHTTPCCRequestID(header)
+	result := metadata.BatchOperationResult{Index: idx}
+	failWith := func(ccErr errors.CCErrorCoder) metadata.BatchOperationResult {
+		result.BaseResp = metadata.BaseResp{Result: false, Code: ccErr.GetCode(), ErrMsg: ccErr.Error()}
+		return result
+	}
+
+	path, err := resolveBatchPath(op.Path, datas)
+	if err != nil {
+		return failWith(batchRefCCError(defErr, idx, err))
+	}
+	body, err := resolveBatchBody(op.Body, datas)
+	if err != nil {
+		return failWith(batchRefCCError(defErr, idx, err))
+	}
+
+	method := strings.ToUpper(op.Method)
+	uri := rootPath + path
+	opReq, err := http.NewRequest(method, uri, bytes.NewReader(body))
+	if err != nil {
+		blog.Errorf("new request of batch operation %d failed, uri: %s, err: %v, rid: %s", idx, uri, err, rid)
+		return failWith(defErr.CCErrorf(common.CCErrCommParamsInvalid, "path"))
+	}
+	opReq = opReq.WithContext(req.Request.Context())
+	opReq.RequestURI = uri
+	opReq.RemoteAddr = req.Request.RemoteAddr
+	opReq.Header = util.CloneHeader(header)
+	restReq := restful.NewRequest(opReq)
+
+	// the operations are limited and authorized one by one as if they were requested separately
+	if rsp := s.limitRequest(restReq); rsp != nil {
+		result.BaseResp = *rsp
+		return result
+	}
+	if s.authorizer.Enabled() {
+		if rsp := s.authorizeRequest(restReq, func() errors.CCErrorIf { return s.engine.CCErr }); rsp != nil {
+			result.BaseResp = *rsp
+			return result
+		}
+	}
+
+	kind, err := URLPath(opReq.RequestURI).FilterChain(restReq)
+	if err != nil {
+		blog.Errorf("rewrite url of batch operation %d failed, uri: %s, err: %v, rid: %s", idx, uri, err, rid)
+		return failWith(defErr.CCError(common.CCErrRewriteRequestUriFailed))
+	}
+	servers, err := s.getServers(kind)
+	if err != nil {
+		blog.Errorf("get %s servers for batch operation %d failed, err: %v, rid: %s", kind, idx, err, rid)
+		return failWith(defErr.CCError(common.CCErrRewriteRequestUriFailed))
+	}
+
+	proxyReq, err := http.NewRequest(method, servers[0]+opReq.RequestURI, bytes.NewReader(body))
+	if err != nil {
+		blog.Errorf("new proxy request of batch operation %d failed, err: %v, rid: %s", idx, err, rid)
+		return failWith(defErr.CCError(common.CCErrProxyRequestFailed))
+	}
+	proxyReq = proxyReq.WithContext(req.Request.Context())
+	proxyReq.Header = opReq.Header
+
+	response, err := s.client.Do(proxyReq)
+	if err != nil {
+		blog.Errorf("do batch operation %d failed, url: %s, err: %v, rid: %s", idx, proxyReq.URL, err, rid)
+		return failWith(defErr.CCError(common.CCErrProxyRequestFailed))
+	}
+	defer response.Body.Close()
+
+	reply := new(metadata.Response)
+	decoder := json.NewDecoder(response.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(reply); err != nil {
+		blog.Errorf("decode reply of batch operation %d failed, status: %d, err: %v, rid: %s", idx,
+			response.StatusCode, err, rid)
+		return failWith(defErr.CCError(common.CCErrCommJSONUnmarshalFailed))
+	}
+
+	result.BaseResp = reply.BaseResp
+	result.Data = reply.Data
+	return result
+}
+
+// batchRefRegexp matches the references to the data of earlier operations, like $ops[0].bk_set_id
+var batchRefRegexp = regexp.MustCompile(`\$ops\[(\d+)\]((?:\.[A-Za-z_][A-Za-z0-9_]*|\[\d+\])*)`)
+
+var batchRefFieldRegexp = regexp.MustCompile(`\.([A-Za-z_][A-Za-z0-9_]*)|\[(\d+)\]`)
+
+type batchRefError struct {
+	ref    string
+	reason string
+}
+
+func (e *batchRefError) Error() string {
+	return fmt.Sprintf("reference %s is invalid: %s", e.ref, e.reason)
+}
+
+func batchRefCCError(defErr errors.DefaultCCErrorIf, idx int, err error) errors.CCErrorCoder {
+	if refErr, ok := err.(*batchRefError); ok {
+		return defErr.CCErrorf(common.CCErrCommBatchOperationRefInvalid, refErr.ref, idx, refErr.reason)
+	}
+	return defErr.CCErrorf(common.CCErrCommBatchOperationRefInvalid, "", idx, err.Error())
+}
+
+// resolveBatchRef returns the value the reference points to in the data of the earlier operations
+func resolveBatchRef(ref string, datas []interface{}) (interface{}, error) {
+	match := batchRefRegexp.FindStringSubmatch(ref)
+	if len(match) == 0 || match[0] != ref {
+		return nil, &batchRefError{ref: ref, reason: "malformed reference"}
+	}
+
+	opIdx, err := strconv.Atoi(match[1])
+	if err != nil || opIdx >= len(datas) {
+		return nil, &batchRefError{ref: ref, reason: "not an earlier operation"}
+	}
+
+	value := datas[opIdx]
+	for _, field := range batchRefFieldRegexp.FindAllStringSubmatch(match[2], -1) {
+		if field[1] != "" {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, &batchRefError{ref: ref, reason: fmt.Sprintf("%s is not an object", field[1])}
+			}
+			if value, ok = object[field[1]]; !ok {
+				return nil, &batchRefError{ref: ref, reason: fmt.Sprintf("%s does not exist", field[1])}
+			}
+			continue
+		}
+
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil, &batchRefError{ref: ref, reason: fmt.Sprintf("[%s] is not in an array", field[2])}
+		}
+		elemIdx, err := strconv.Atoi(field[2])
+		if err != nil || elemIdx >= len(array) {
+			return nil, &batchRefError{ref: ref, reason: fmt.Sprintf("[%s] is out of range", field[2])}
+		}
+		value = array[elemIdx]
+	}
+	return value, nil
+}
+
+// resolveBatchString replaces the references in s with their values, which must be scalars
+func resolveBatchString(s string, datas []interface{}, escape func(string) string) (string, error) {
+	var resolveErr error
+	resolved := batchRefRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		if resolveErr != nil {
+			return ref
+		}
+		value, err := resolveBatchRef(ref, datas)
+		if err != nil {
+			resolveErr = err
+			return ref
+		}
+		switch v := value.(type) {
+		case string:
+			return escape(v)
+		case json.Number, bool:
+			return fmt.Sprint(v)
+		default:
+			resolveErr = &batchRefError{ref: ref, reason: "not a string, number or bool"}
+			return ref
+		}
+	})
+	return resolved, resolveErr
+}
+
+// resolveBatchPath replaces the references in the path of an operation with their values
+func resolveBatchPath(path string, datas []interface{}) (string, error) {
+	return resolveBatchString(path, datas, url.PathEscape)
+}
+
+// resolveBatchBody replaces the references in the string values of the body of an operation with
+// their values. a string that is a reference as a whole is replaced by the value itself, so that
+// numbers and objects keep their types.
+func resolveBatchBody(body json.RawMessage, datas []interface{}) ([]byte, error) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	resolved, err := resolveBatchValue(value, datas)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(resolved)
+}
+
+func resolveBatchValue(value interface{}, datas []interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			resolved, err := resolveBatchValue(elem, datas)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = resolved
+		}
+		return v, nil
+
+	case []interface{}:
+		for i, elem := range v {
+			resolved, err := resolveBatchValue(elem, datas)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+
+	case string:
+		if !strings.Contains(v, "$ops[") {
+			return v, nil
+		}
+		if batchRefRegexp.FindString(v) == v {
+			return resolveBatchRef(v, datas)
+		}
+		return resolveBatchString(v, datas, func(s string) string { return s })
+
+	default:
+		return v, nil
+	}
+}
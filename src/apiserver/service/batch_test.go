@@ -0,0 +1,82 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testBatchDatas(t *testing.T) []interface{} {
+	raw := `[{"bk_set_id": 3, "bk_set_name": "set a"}, {"count": 1, "info": [{"bk_host_id": 7}]}]`
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var datas []interface{}
+	if err := decoder.Decode(&datas); err != nil {
+		t.Fatal(err)
+	}
+	return datas
+}
+
+func TestResolveBatchPath(t *testing.T) {
+	datas := testBatchDatas(t)
+
+	path, err := resolveBatchPath("/create/module/biz/2/set/$ops[0].bk_set_id", datas)
+	if err != nil || path != "/create/module/biz/2/set/3" {
+		t.Fatalf("unexpected path: %s, err: %v", path, err)
+	}
+
+	path, err = resolveBatchPath("/find/set/$ops[0].bk_set_name", datas)
+	if err != nil || path != "/find/set/set%20a" {
+		t.Fatalf("unexpected path: %s, err: %v", path, err)
+	}
+
+	invalid := []string{
+		"/find/$ops[2].bk_set_id",
+		"/find/$ops[0].bk_module_id",
+		"/find/$ops[1].info",
+		"/find/$ops[1].info[1].bk_host_id",
+		"/find/$ops[0][0]",
+	}
+	for _, p := range invalid {
+		if _, err := resolveBatchPath(p, datas); err == nil {
+			t.Errorf("path %s should be invalid", p)
+		}
+	}
+}
+
+func TestResolveBatchBody(t *testing.T) {
+	datas := testBatchDatas(t)
+
+	body := `{"bk_parent_id": "$ops[0].bk_set_id", "bk_host_id": ["$ops[1].info[0].bk_host_id"],` +
+		`"bk_module_name": "$ops[0].bk_set_name-module", "info": "$ops[1].info", "limit": 10}`
+	resolved, err := resolveBatchBody(json.RawMessage(body), datas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"bk_host_id":[7],"bk_module_name":"set a-module","bk_parent_id":3,"info":[{"bk_host_id":7}],"limit":10}`
+	if string(resolved) != expected {
+		t.Fatalf("unexpected body: %s", resolved)
+	}
+
+	if _, err := resolveBatchBody(json.RawMessage(`{"id": "$ops[0].bk_biz_id"}`), datas); err == nil {
+		t.Fatal("reference to absent field should be invalid")
+	}
+
+	resolved, err = resolveBatchBody(nil, datas)
+	if err != nil || resolved != nil {
+		t.Fatalf("unexpected empty body: %s, err: %v", resolved, err)
+	}
+}
@@ -77,46 +77,51 @@ func (s *service) URLFilterChan(req *restful.Request, resp *restful.Response, ch
 		}
 	}()
 
-	servers := make([]string, 0)
+	var servers []string
+	servers, err = s.getServers(kind)
+	if err != nil {
+		return
+	}
+
+	if strings.HasPrefix(servers[0], "https://") {
+		req.Request.URL.Host = servers[0][8:]
+		req.Request.URL.Scheme = "https"
+	} else {
+		req.Request.URL.Host = servers[0][7:]
+		req.Request.URL.Scheme = "http"
+	}
+
+	chain.ProcessFilter(req, resp)
+}
+
+// getServers returns the servers of the scene server that serves the request type
+func (s *service) getServers(kind RequestType) ([]string, error) {
 	switch kind {
 	case TopoType:
-		servers, err = s.discovery.TopoServer().GetServers()
+		return s.discovery.TopoServer().GetServers()
 
 	case ProcType:
-		servers, err = s.discovery.ProcServer().GetServers()
+		return s.discovery.ProcServer().GetServers()
 
 	case EventType:
-		servers, err = s.discovery.EventServer().GetServers()
+		return s.discovery.EventServer().GetServers()
 
 	case HostType:
-		servers, err = s.discovery.HostServer().GetServers()
+		return s.discovery.HostServer().GetServers()
 
 	case DataCollectType:
-		servers, err = s.discovery.DataCollect().GetServers()
+		return s.discovery.DataCollect().GetServers()
 
 	case OperationType:
-		servers, err = s.discovery.OperationServer().GetServers()
+		return s.discovery.OperationServer().GetServers()
 
 	case TaskType:
-		servers, err = s.discovery.TaskServer().GetServers()
+		return s.discovery.TaskServer().GetServers()
 
 	case AdminType:
-		servers, err = s.discovery.MigrateServer().GetServers()
-	}
-
-	if err != nil {
-		return
-	}
-
-	if strings.HasPrefix(servers[0], "https://") {
-		req.Request.URL.Host = servers[0][8:]
-		req.Request.URL.Scheme = "https"
-	} else {
-		req.Request.URL.Host = servers[0][7:]
-		req.Request.URL.Scheme = "http"
+		return s.discovery.MigrateServer().GetServers()
 	}
-
-	chain.ProcessFilter(req, resp)
+	return nil, fmt.Errorf("no server for request type %s", kind)
 }
 
 func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
@@ -136,6 +141,11 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 			return
 		}
 
+		if path == "/api/v3/auth/explain" {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
 		if path == "/api/v3/auth/business_list" {
 			fchain.ProcessFilter(req, resp)
 			return
@@ -166,73 +176,87 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 		// 	return
 		// }
 
-		language := util.GetLanguage(req.Request.Header)
-		attribute, err := parser.ParseAttribute(req, s.engine)
-		if err != nil {
-			blog.Errorf("authFilter failed, caller: %s, parse auth attribute for %s %s failed, err: %v, rid: %s", req.Request.RemoteAddr, req.Request.Method, req.Request.URL.Path, err, rid)
-			rsp := metadata.BaseResp{
-				Code:   common.CCErrCommParseAuthAttributeFailed,
-				ErrMsg: errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommParseAuthAttributeFailed).Error(),
-				Result: false,
-			}
-			resp.WriteAsJson(rsp)
+		if path == rootPath+"/batch/operation" {
+			// every operation of the batch is authorized on its own when it's executed
+			fchain.ProcessFilter(req, resp)
 			return
 		}
 
-		// check if authorize is nil or not, which means to check if the authorize instance has
-		// already been initialized or not. if not, api server should not be used.
-		if nil == s.authorizer {
-			blog.Errorf("authorize instance has not been initialized, rid: %s", rid)
-			rsp := metadata.BaseResp{
-				Code:   common.CCErrCommCheckAuthorizeFailed,
-				ErrMsg: errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommCheckAuthorizeFailed).Error(),
-				Result: false,
-			}
+		if rsp := s.authorizeRequest(req, errFunc); rsp != nil {
 			resp.WriteAsJson(rsp)
 			return
 		}
 
-		blog.V(7).Infof("auth filter parse attribute result: %v, rid: %s", attribute, rid)
-		decision, err := s.authorizer.Authorize(req.Request.Context(), attribute)
+		blog.V(7).Infof("authFilter authorize on url:%s success, rid: %s", path, rid)
+		fchain.ProcessFilter(req, resp)
+		return
+	}
+}
+
+// authorizeRequest authorizes the api request, it returns the response to reply with if the
+// request is not authorized, or nil if it's authorized.
+func (s *service) authorizeRequest(req *restful.Request, errFunc func() errors.CCErrorIf) *metadata.BaseResp {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	path := req.Request.URL.Path
+
+	language := util.GetLanguage(req.Request.Header)
+	attribute, err := parser.ParseAttribute(req, s.engine)
+	if err != nil {
+		blog.Errorf("authFilter failed, caller: %s, parse auth attribute for %s %s failed, err: %v, rid: %s", req.Request.RemoteAddr, req.Request.Method, req.Request.URL.Path, err, rid)
+		rsp := &metadata.BaseResp{
+			Code:   common.CCErrCommParseAuthAttributeFailed,
+			ErrMsg: errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommParseAuthAttributeFailed).Error(),
+			Result: false,
+		}
+		return rsp
+	}
+
+	// check if authorize is nil or not, which means to check if the authorize instance has
+	// already been initialized or not. if not, api server should not be used.
+	if nil == s.authorizer {
+		blog.Errorf("authorize instance has not been initialized, rid: %s", rid)
+		rsp := &metadata.BaseResp{
+			Code:   common.CCErrCommCheckAuthorizeFailed,
+			ErrMsg: errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommCheckAuthorizeFailed).Error(),
+			Result: false,
+		}
+		return rsp
+	}
+
+	blog.V(7).Infof("auth filter parse attribute result: %v, rid: %s", attribute, rid)
+	decision, err := s.authorizer.Authorize(req.Request.Context(), attribute)
+	if err != nil {
+		blog.Errorf("authFilter failed, authorized request failed, url: %s, err: %v, rid: %s", path, err, rid)
+		rsp := &metadata.BaseResp{
+			Code:   common.CCErrCommCheckAuthorizeFailed,
+			ErrMsg: errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommCheckAuthorizeFailed).Error(),
+			Result: false,
+		}
+		return rsp
+	}
+
+	if !decision.Authorized {
+		blog.V(4).Infof("authcenter.AdoptPermissions attribute: %+v, rid: %s", attribute, rid)
+		permissions, err := authcenter.AdoptPermissions(req.Request.Header, s.engine.CoreAPI, attribute.Resources)
 		if err != nil {
-			blog.Errorf("authFilter failed, authorized request failed, url: %s, err: %v, rid: %s", path, err, rid)
-			rsp := metadata.BaseResp{
+			blog.Errorf("adopt permission failed, err: %v, rid: %s", err, rid)
+			rsp := &metadata.BaseResp{
 				Code:   common.CCErrCommCheckAuthorizeFailed,
 				ErrMsg: errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommCheckAuthorizeFailed).Error(),
 				Result: false,
 			}
-			resp.WriteAsJson(rsp)
-			return
+			return rsp
 		}
-
-		if !decision.Authorized {
-			blog.V(4).Infof("authcenter.AdoptPermissions attribute: %+v, rid: %s", attribute, rid)
-			permissions, err := authcenter.AdoptPermissions(req.Request.Header, s.engine.CoreAPI, attribute.Resources)
-			if err != nil {
-				blog.Errorf("adopt permission failed, err: %v, rid: %s", err, rid)
-				rsp := metadata.BaseResp{
-					Code:   common.CCErrCommCheckAuthorizeFailed,
-					ErrMsg: errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommCheckAuthorizeFailed).Error(),
-					Result: false,
-				}
-				resp.WriteAsJson(rsp)
-				return
-			}
-			blog.Warnf("authFilter failed, url: %s, reason: %+v, permissions: %+v, rid: %s", path, decision, permissions, rid)
-			rsp := metadata.BaseResp{
-				Code:        common.CCNoPermission,
-				ErrMsg:      errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommAuthNotHavePermission).Error(),
-				Result:      false,
-				Permissions: permissions,
-			}
-			resp.WriteAsJson(rsp)
-			return
+		blog.Warnf("authFilter failed, url: %s, reason: %+v, permissions: %+v, rid: %s", path, decision, permissions, rid)
+		rsp := &metadata.BaseResp{
+			Code:        common.CCNoPermission,
+			ErrMsg:      errFunc().CreateDefaultCCErrorIf(language).Error(common.CCErrCommAuthNotHavePermission).Error(),
+			Result:      false,
+			Permissions: permissions,
 		}
-
-		blog.V(7).Infof("authFilter authorize on url:%s success, rid: %s", path, rid)
-		fchain.ProcessFilter(req, resp)
-		return
+		return rsp
 	}
+	return nil
 }
 
 // KEYS[1] is the redis key to incr and expire
@@ -265,55 +289,55 @@ return cnt
 // LimiterFilter limit on a api request according to limiter rules
 func (s *service) LimiterFilter() func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
 	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
-		rid := util.GetHTTPCCRequestID(req.Request.Header)
-		if s.limiter.LenOfRules() == 0 {
-			fchain.ProcessFilter(req, resp)
+		if rsp := s.limitRequest(req); rsp != nil {
+			resp.WriteAsJson(rsp)
 			return
 		}
+		fchain.ProcessFilter(req, resp)
+	}
+}
 
-		rule := s.limiter.GetMatchedRule(req)
-		if rule == nil {
-			fchain.ProcessFilter(req, resp)
-			return
-		}
+// limitRequest counts the request by the matched limiter rule, it returns the response to reply with if the
+// request is limited, or nil if it can go on.
+func (s *service) limitRequest(req *restful.Request) *metadata.BaseResp {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	if s.limiter.LenOfRules() == 0 {
+		return nil
+	}
 
-		if rule.DenyAll {
-			blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
-			rsp := metadata.BaseResp{
-				Code:   common.CCErrTooManyRequestErr,
-				ErrMsg: "too many requests",
-				Result: false,
-			}
-			resp.WriteAsJson(rsp)
-			return
-		}
+	rule := s.limiter.GetMatchedRule(req)
+	if rule == nil {
+		return nil
+	}
 
-		key := common.ApiCacheLimiterRulePrefix + rule.RuleName
-		result, err := s.cache.Eval(setRequestCntTTLScript, []string{key}, rule.TTL).Result()
-		if err != nil {
-			blog.Errorf("redis Eval failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, err, rid)
-			fchain.ProcessFilter(req, resp)
-			return
-		}
-		cnt, ok := result.(int64)
-		if !ok {
-			blog.Errorf("execute setRequestCntTTLScript failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, result, rid)
-			fchain.ProcessFilter(req, resp)
-			return
+	if rule.DenyAll {
+		blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
+		return &metadata.BaseResp{
+			Code:   common.CCErrTooManyRequestErr,
+			ErrMsg: "too many requests",
+			Result: false,
 		}
+	}
 
-		if cnt > rule.Limit {
-			blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
-			rsp := metadata.BaseResp{
-				Code:   common.CCErrTooManyRequestErr,
-				ErrMsg: "too many requests",
-				Result: false,
-			}
-			resp.WriteAsJson(rsp)
-			return
-		}
+	key := common.ApiCacheLimiterRulePrefix + rule.RuleName
+	result, err := s.cache.Eval(setRequestCntTTLScript, []string{key}, rule.TTL).Result()
+	if err != nil {
+		blog.Errorf("redis Eval failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, err, rid)
+		return nil
+	}
+	cnt, ok := result.(int64)
+	if !ok {
+		blog.Errorf("execute setRequestCntTTLScript failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, result, rid)
+		return nil
+	}
 
-		fchain.ProcessFilter(req, resp)
-		return
+	if cnt > rule.Limit {
+		blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
+		return &metadata.BaseResp{
+			Code:   common.CCErrTooManyRequestErr,
+			ErrMsg: "too many requests",
+			Result: false,
+		}
 	}
+	return nil
 }
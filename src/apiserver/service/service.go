@@ -27,7 +27,7 @@ import (
 // Service service methods
 type Service interface {
 	WebServices(auth authcenter.AuthConfig) []*restful.WebService
-	SetConfig(engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize, cache *redis.Client, limiter *Limiter)
+	SetConfig(engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize, cache *redis.Client, limiter *Limiter, enableTxn bool)
 }
 
 // NewService create a new service instance
@@ -42,15 +42,17 @@ type service struct {
 	authorizer auth.Authorizer
 	cache      *redis.Client
 	limiter    *Limiter
+	enableTxn  bool
 }
 
-func (s *service) SetConfig(engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize, cache *redis.Client, limiter *Limiter) {
+func (s *service) SetConfig(engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize, cache *redis.Client, limiter *Limiter, enableTxn bool) {
 	s.engine = engine
 	s.client = httpClient
 	s.discovery = discovery
 	s.authorizer = authorize
 	s.cache = cache
 	s.limiter = limiter
+	s.enableTxn = enableTxn
 }
 
 func (s *service) WebServices(auth authcenter.AuthConfig) []*restful.WebService {
@@ -69,10 +71,12 @@ func (s *service) WebServices(auth authcenter.AuthConfig) []*restful.WebService
 		ws.Filter(s.authFilter(getErrFun))
 	}
 	ws.Route(ws.POST("/auth/verify").To(s.AuthVerify))
+	ws.Route(ws.POST("/auth/explain").To(s.AuthExplain))
 	ws.Route(ws.GET("/auth/business_list").To(s.GetAnyAuthorizedAppList))
 	ws.Route(ws.GET("/auth/admin_entrance").To(s.GetAdminEntrance))
 	ws.Route(ws.POST("/auth/skip_url").To(s.GetUserNoAuthSkipURL))
 	ws.Route(ws.POST("/auth/convert").To(s.GetCmdbConvertResources))
+	ws.Route(ws.POST("/batch/operation").To(s.BatchOperation))
 	ws.Route(ws.GET("{.*}").Filter(s.URLFilterChan).To(s.Get))
 	ws.Route(ws.POST("{.*}").Filter(s.URLFilterChan).To(s.Post))
 	ws.Route(ws.PUT("{.*}").Filter(s.URLFilterChan).To(s.Put))
@@ -15,12 +15,18 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/builtin"
 	"configcenter/src/auth/meta"
+	"configcenter/src/auth/policy"
+	"configcenter/src/auth/rbac"
 	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/mongo/local"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -32,6 +38,12 @@ type Authorize interface {
 
 var NoAuthorizeError = errors.New("no authorize")
 
+// Explainer is implemented by the authorizers which can explain which rule decides the decisions,
+// it's used to dry run the authorization.
+type Explainer interface {
+	Explain(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Explanation, error)
+}
+
 type Authorizer interface {
 	// Authorize works to check if a user has the authority to operate resources.
 	Authorize(ctx context.Context, a *meta.AuthAttribute) (decision meta.Decision, err error)
@@ -78,6 +90,29 @@ type ResourceHandler interface {
 // This allows bk-cmdb to support other kind of auth center.
 // tls can be nil if it is not care.
 // authConfig is a way to parse configuration info for the connection to a auth center.
-func NewAuthorize(tls *util.TLSClientConfig, authConfig authcenter.AuthConfig, reg prometheus.Registerer) (Authorize, error) {
+// the built-in rbac or policy authorizer is used when the auth type is local or policy, the policy
+// authorizer stops watching the policy file when the ctx is done.
+func NewAuthorize(ctx context.Context, tls *util.TLSClientConfig, authConfig authcenter.AuthConfig,
+	reg prometheus.Registerer) (Authorize, error) {
+
+	switch authConfig.Type {
+	case authcenter.AuthTypeLocal:
+		db, err := local.NewMgo(authConfig.Mongo.GetMongoConf(), time.Minute)
+		if err != nil {
+			return nil, fmt.Errorf("connect mongo server for local authorizer failed, err: %v", err)
+		}
+		return builtin.NewAuthorizer(rbac.NewRBAC(authConfig, db), db), nil
+	case authcenter.AuthTypePolicy:
+		db, err := local.NewMgo(authConfig.Mongo.GetMongoConf(), time.Minute)
+		if err != nil {
+			return nil, fmt.Errorf("connect mongo server for policy authorizer failed, err: %v", err)
+		}
+		decider, err := policy.NewDecider(ctx, authConfig.PolicyFile, builtin.NewInstanceNameResolver(db))
+		if err != nil {
+			return nil, err
+		}
+		return builtin.NewAuthorizer(decider, db), nil
+	}
+
 	return authcenter.NewAuthCenter(tls, authConfig, reg)
 }
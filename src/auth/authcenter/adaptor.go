@@ -258,12 +258,18 @@ const (
 	HostTransferAcrossBiz ActionID = "host_transfer_across_biz"
 	BindModule            ActionID = "bind_module"
 	AdminEntrance         ActionID = "admin_entrance"
-
-	WatchHost         ActionID = "host"
-	WatchHostRelation ActionID = "host_relation"
-	WatchBiz          ActionID = "biz"
-	WatchSet          ActionID = "set"
-	WatchModule       ActionID = "module"
+	// reveal the secret attribute values of an instance
+	RevealSecret ActionID = "reveal_secret"
+
+	WatchHost            ActionID = "host"
+	WatchHostRelation    ActionID = "host_relation"
+	WatchBiz             ActionID = "biz"
+	WatchSet             ActionID = "set"
+	WatchModule          ActionID = "module"
+	WatchObjectInstance  ActionID = "object_instance"
+	WatchInstAsst        ActionID = "inst_asst"
+	WatchServiceInstance ActionID = "service_instance"
+	WatchProcess         ActionID = "process"
 )
 
 var ActionIDNameMap = map[ActionID]string{
@@ -273,12 +279,17 @@ var ActionIDNameMap = map[ActionID]string{
 	Get:                    "查询",
 	Delete:                 "删除",
 	Archive:                "归档",
+	RevealSecret:           "查看密文",
 	ModelTopologyOperation: "编辑业务层级",
 	WatchHost:              "主机",
 	WatchHostRelation:      "主机关系",
 	WatchBiz:               "业务",
 	WatchSet:               "集群",
 	WatchModule:            "模块",
+	WatchObjectInstance:    "模型实例",
+	WatchInstAsst:          "实例关联",
+	WatchServiceInstance:   "服务实例",
+	WatchProcess:           "进程",
 }
 
 func AdaptorAction(r *meta.ResourceAttribute) (ActionID, error) {
@@ -386,6 +397,8 @@ func AdaptorAction(r *meta.ResourceAttribute) (ActionID, error) {
 		return ModelTopologyOperation, nil
 	case meta.AdminEntrance:
 		return AdminEntrance, nil
+	case meta.RevealSecret:
+		return RevealSecret, nil
 	case meta.WatchHost:
 		return WatchHost, nil
 	case meta.WatchHostRelation:
@@ -396,6 +409,14 @@ func AdaptorAction(r *meta.ResourceAttribute) (ActionID, error) {
 		return WatchSet, nil
 	case meta.WatchModule:
 		return WatchModule, nil
+	case meta.WatchObjectInstance:
+		return WatchObjectInstance, nil
+	case meta.WatchInstAsst:
+		return WatchInstAsst, nil
+	case meta.WatchServiceInstance:
+		return WatchServiceInstance, nil
+	case meta.WatchProcess:
+		return WatchProcess, nil
 	}
 
 	return Unknown, fmt.Errorf("unsupported action: %s", r.Action)
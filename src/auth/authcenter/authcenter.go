@@ -49,6 +49,33 @@ func ParseConfigFromKV(prefix string, configmap map[string]string) (AuthConfig,
 	if !auth.IsAuthed() {
 		return AuthConfig{}, nil
 	}
+
+	cfg.Type = AuthTypeAuthCenter
+	authType, exist := configmap[prefix+".type"]
+	if exist && len(authType) > 0 {
+		cfg.Type = AuthType(authType)
+	}
+
+	switch cfg.Type {
+	case AuthTypeAuthCenter:
+	case AuthTypeLocal:
+		// local authorizer do not need the auth center configurations.
+		admins, exist := configmap[prefix+".admins"]
+		if exist && len(admins) > 0 {
+			cfg.Admins = strings.Split(strings.Replace(admins, " ", "", -1), ",")
+		}
+		return cfg, nil
+	case AuthTypePolicy:
+		policyFile, exist := configmap[prefix+".policyFile"]
+		if !exist || len(policyFile) == 0 {
+			return cfg, errors.New(`auth "policyFile" is required when auth type is policy`)
+		}
+		cfg.PolicyFile = policyFile
+		return cfg, nil
+	default:
+		return cfg, fmt.Errorf(`invalid auth "type" value: %s`, authType)
+	}
+
 	enableSync, exist := configmap[prefix+".enableSync"]
 	if exist && len(enableSync) > 0 {
 		cfg.EnableSync, err = strconv.ParseBool(enableSync)
@@ -480,6 +507,7 @@ func convertAction(resourceType meta.ResourceType, action meta.Action) (ActionID
 		meta.ModelTopologyView:           ModelTopologyView,
 		meta.ModelTopologyOperation:      ModelTopologyOperation,
 		meta.AdminEntrance:               AdminEntrance,
+		meta.RevealSecret:                RevealSecret,
 	}
 	resourceSpecifiedActionMap := map[meta.ResourceType]map[meta.Action]ActionID{
 		meta.ModelInstance: {
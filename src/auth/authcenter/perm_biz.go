@@ -135,6 +135,11 @@ var expectBizResourceType = []ResourceType{
 				ActionName:        "删除/归还",
 				IsRelatedResource: true,
 			},
+			{
+				ActionID:          RevealSecret,
+				ActionName:        "查看密文",
+				IsRelatedResource: true,
+			},
 		},
 	},
 	{
@@ -158,6 +163,11 @@ var expectBizResourceType = []ResourceType{
 				ActionName:        "删除",
 				IsRelatedResource: false,
 			},
+			{
+				ActionID:          RevealSecret,
+				ActionName:        "查看密文",
+				IsRelatedResource: true,
+			},
 		},
 	},
 	{
@@ -93,6 +93,11 @@ var expectSystemResourceType = []ResourceType{
 				ActionName:        "删除",
 				IsRelatedResource: true,
 			},
+			{
+				ActionID:          RevealSecret,
+				ActionName:        "查看密文",
+				IsRelatedResource: true,
+			},
 		},
 	},
 	{
@@ -144,6 +149,11 @@ var expectSystemResourceType = []ResourceType{
 				ActionName:        "查询",
 				IsRelatedResource: true,
 			},
+			{
+				ActionID:          RevealSecret,
+				ActionName:        "查看密文",
+				IsRelatedResource: true,
+			},
 		},
 	},
 	{
@@ -213,6 +223,11 @@ var expectSystemResourceType = []ResourceType{
 				ActionName:        "删除",
 				IsRelatedResource: true,
 			},
+			{
+				ActionID:          RevealSecret,
+				ActionName:        "查看密文",
+				IsRelatedResource: true,
+			},
 		},
 	},
 	{
@@ -277,6 +292,26 @@ var expectSystemResourceType = []ResourceType{
 				ActionName:        "模块",
 				IsRelatedResource: false,
 			},
+			{
+				ActionID:          WatchObjectInstance,
+				ActionName:        "模型实例",
+				IsRelatedResource: false,
+			},
+			{
+				ActionID:          WatchInstAsst,
+				ActionName:        "实例关联",
+				IsRelatedResource: false,
+			},
+			{
+				ActionID:          WatchServiceInstance,
+				ActionName:        "服务实例",
+				IsRelatedResource: false,
+			},
+			{
+				ActionID:          WatchProcess,
+				ActionName:        "进程",
+				IsRelatedResource: false,
+			},
 		},
 	},
 	{
@@ -285,6 +320,11 @@ var expectSystemResourceType = []ResourceType{
 		ParentResourceTypeID: "",
 		Share:                false,
 		Actions: []Action{
+			{
+				ActionID:          Get,
+				ActionName:        "查询",
+				IsRelatedResource: false,
+			},
 			{
 				ActionID:          Edit,
 				ActionName:        "编辑",
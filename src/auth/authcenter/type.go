@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"configcenter/src/auth/meta"
+	"configcenter/src/storage/dal/mongo"
 )
 
 // system constant
@@ -21,7 +22,23 @@ const (
 	ScopeTypeIDBizName = "业务"
 )
 
+// AuthType is the type of the authorizer which is used to authorize the requests.
+type AuthType string
+
+const (
+	// AuthTypeAuthCenter means using blueking's auth center to authorize, it's the default one.
+	AuthTypeAuthCenter AuthType = "authcenter"
+	// AuthTypeLocal means using cmdb's built-in rbac authorizer, which stores the
+	// roles and role bindings in mongodb, so that no external auth center is needed.
+	AuthTypeLocal AuthType = "local"
+	// AuthTypePolicy means using cmdb's built-in policy authorizer, which authorizes
+	// the requests with the rules in a policy file.
+	AuthTypePolicy AuthType = "policy"
+)
+
 type AuthConfig struct {
+	// the authorizer type, default is auth center.
+	Type AuthType
 	// blueking's auth center addresses
 	Address []string
 	// app code is used for authorize used.
@@ -34,6 +51,14 @@ type AuthConfig struct {
 	EnableSync          bool
 	SyncWorkerCount     int
 	SyncIntervalMinutes int
+
+	// the following configurations is used by the built-in authorizers only.
+	// the users who have all the permissions, used to initialize the roles.
+	Admins []string
+	// the yaml or json policy file used by the policy authorizer.
+	PolicyFile string
+	// the mongodb which stores the roles and role bindings, and is used to list the resource instances.
+	Mongo mongo.Config
 }
 
 type RegisterInfo struct {
@@ -0,0 +1,412 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package builtin implements the authorizer interfaces with a Decider, so that cmdb can
+// authorize the requests by itself without the blueking's auth center.
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/auth"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// ErrExplainNotSupported is returned when the decider can not explain it's decisions.
+var ErrExplainNotSupported = errors.New("the authorizer does not support explaining the decisions")
+
+// Decider decides the permissions of the users, it's implemented by the built-in authorizers.
+type Decider interface {
+	// Decide decides whether the user is authorized to operate each of the resources.
+	Decide(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Decision, error)
+	// AuthorizedInstances returns the scope of the resource instances which the user is authorized
+	// to do the action in the business.
+	AuthorizedInstances(ctx context.Context, user meta.UserInfo, bizID int64, resourceType meta.ResourceType,
+		action meta.Action) (*InstanceScope, error)
+	// AnyAuthorizedBusinesses returns the businesses which the user has any permission in it,
+	// all is true when the user has permissions in all the businesses.
+	AnyAuthorizedBusinesses(ctx context.Context, user meta.UserInfo) (bizIDs []int64, all bool, err error)
+	// GroupMembers returns the members of the user groups in the business.
+	GroupMembers(ctx context.Context, header http.Header, bizID int64, groups []string) ([]authcenter.UserGroupMembers, error)
+}
+
+// InstanceScope is the scope of the resource instances which a user is authorized to.
+type InstanceScope struct {
+	// All is true when the user is authorized to all the instances except the excluded ones.
+	All      bool
+	Excluded []string
+	// the authorized instance ids when it's not all.
+	IDs []string
+}
+
+// Explainer is implemented by the deciders which can explain which rule decides the decisions.
+type Explainer interface {
+	Explain(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Explanation, error)
+}
+
+// instanceTable is the table and the id and name field of the instances of a resource type, which is used
+// to list all the instances when a user is authorized to all of them, and to resolve the instance names.
+type instanceTable struct {
+	table     string
+	idField   string
+	nameField string
+}
+
+var instanceTables = map[meta.ResourceType]instanceTable{
+	meta.Business: {table: common.BKTableNameBaseApp, idField: common.BKAppIDField, nameField: common.BKAppNameField},
+	meta.Plat:     {table: common.BKTableNameBasePlat, idField: common.BKCloudIDField, nameField: common.BKCloudNameField},
+	// audit log is authorized with model's object id.
+	meta.AuditLog: {table: common.BKTableNameObjDes, idField: common.BKObjIDField, nameField: common.BKObjNameField},
+}
+
+// InstanceNameResolver resolves the instance names of the resource type to their ids, it's used by the deciders
+// whose rules are limited by instance names to list the authorized instances.
+type InstanceNameResolver func(ctx context.Context, supplierAccount string, resourceType meta.ResourceType,
+	names []string) ([]string, error)
+
+// NewInstanceNameResolver creates an InstanceNameResolver which searches the instances in db, only the resource
+// types in instanceTables are supported.
+func NewInstanceNameResolver(db dal.RDB) InstanceNameResolver {
+	return func(ctx context.Context, supplierAccount string, resourceType meta.ResourceType, names []string) (
+		[]string, error) {
+
+		instTable, exist := instanceTables[resourceType]
+		if !exist {
+			return nil, fmt.Errorf("resolve the instance names of resource type %s is not supported", resourceType)
+		}
+
+		cond := map[string]interface{}{
+			instTable.nameField: map[string]interface{}{common.BKDBIN: names},
+		}
+		if len(supplierAccount) != 0 {
+			cond = util.SetQueryOwner(cond, supplierAccount)
+		}
+
+		instances := make([]map[string]interface{}, 0)
+		err := db.Table(instTable.table).Find(cond).Fields(instTable.idField).All(ctx, &instances)
+		if err != nil {
+			blog.Errorf("resolve the instance names of resource type %s failed, err: %v, rid: %s", resourceType, err,
+				util.ExtractRequestIDFromContext(ctx))
+			return nil, err
+		}
+
+		ids := make([]string, len(instances))
+		for idx, inst := range instances {
+			ids[idx] = util.GetStrByInterface(inst[instTable.idField])
+		}
+		return ids, nil
+	}
+}
+
+// NewAuthorizer create a built-in authorizer with the decider, db is used to list the resource instances.
+func NewAuthorizer(decider Decider, db dal.RDB) *Authorizer {
+	return &Authorizer{
+		decider: decider,
+		db:      db,
+	}
+}
+
+// Authorizer implements the auth.Authorize interface with a decider.
+type Authorizer struct {
+	decider Decider
+	db      dal.RDB
+}
+
+func (a *Authorizer) Enabled() bool {
+	return auth.IsAuthed()
+}
+
+func (a *Authorizer) Authorize(ctx context.Context, attr *meta.AuthAttribute) (decision meta.Decision, err error) {
+	if !auth.IsAuthed() {
+		return meta.Decision{Authorized: true}, nil
+	}
+
+	decisions, err := a.AuthorizeBatch(ctx, attr.User, attr.Resources...)
+	if err != nil {
+		return meta.Decision{}, err
+	}
+
+	noAuth := make([]string, 0)
+	for i, item := range decisions {
+		if !item.Authorized {
+			noAuth = append(noAuth, fmt.Sprintf("resource [%v] permission deny by reason: %s", attr.Resources[i].Type, item.Reason))
+		}
+	}
+
+	if len(noAuth) > 0 {
+		return meta.Decision{
+			Authorized: false,
+			Reason:     fmt.Sprintf("%v", noAuth),
+		}, nil
+	}
+
+	return meta.Decision{Authorized: true}, nil
+}
+
+func (a *Authorizer) AuthorizeBatch(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) (decisions []meta.Decision, err error) {
+	decisions = make([]meta.Decision, len(resources))
+	if !auth.IsAuthed() {
+		for i := range decisions {
+			decisions[i].Authorized = true
+		}
+		return decisions, nil
+	}
+
+	// filter out SkipAction, which set by api server to skip authorization
+	indexes := make([]int, 0)
+	noSkipResources := make([]meta.ResourceAttribute, 0)
+	for i, resource := range resources {
+		if resource.Action == meta.SkipAction {
+			decisions[i].Authorized = true
+			continue
+		}
+		indexes = append(indexes, i)
+		noSkipResources = append(noSkipResources, resource)
+	}
+
+	if len(noSkipResources) == 0 {
+		return decisions, nil
+	}
+
+	results, err := a.decider.Decide(ctx, user, noSkipResources...)
+	if err != nil {
+		blog.Errorf("decide user %s's permissions failed, err: %v, rid: %s", user.UserName, err,
+			util.ExtractRequestIDFromContext(ctx))
+		return nil, err
+	}
+
+	for i, result := range results {
+		decisions[indexes[i]] = result
+	}
+	return decisions, nil
+}
+
+// Explain explains which rule decides the decision of each resource, it's only supported
+// when the decider is an Explainer.
+func (a *Authorizer) Explain(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Explanation, error) {
+	explainer, ok := a.decider.(Explainer)
+	if !ok {
+		return nil, ErrExplainNotSupported
+	}
+	return explainer.Explain(ctx, user, resources...)
+}
+
+// GetAnyAuthorizedBusinessList returns the businesses which the user has any permission in it.
+func (a *Authorizer) GetAnyAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	if !auth.IsAuthed() {
+		return make([]int64, 0), nil
+	}
+
+	businessIDs, all, err := a.decider.AnyAuthorizedBusinesses(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if all {
+		return a.listAllBusinesses(ctx, user.SupplierAccount)
+	}
+	return businessIDs, nil
+}
+
+// GetExactAuthorizedBusinessList returns the businesses which the user is authorized to read.
+func (a *Authorizer) GetExactAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	if !auth.IsAuthed() {
+		return make([]int64, 0), nil
+	}
+
+	ids, err := a.listAuthorizedInstances(ctx, user, 0, meta.Business, meta.Find)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseBusinessIDs(ids)
+}
+
+func (a *Authorizer) ListAuthorizedResources(ctx context.Context, username string, bizID int64,
+	resourceType meta.ResourceType, action meta.Action) ([]authcenter.IamResource, error) {
+
+	iamResourceType, err := authcenter.ConvertResourceType(resourceType, bizID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := a.listAuthorizedInstances(ctx, meta.UserInfo{UserName: username}, bizID, resourceType, action)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]authcenter.IamResource, len(ids))
+	for idx, id := range ids {
+		// keep the same resource id format with the auth center, so that the callers can parse it.
+		if resourceType == meta.Plat {
+			id = "plat:" + id
+		}
+		resources[idx] = authcenter.IamResource{{ResourceType: *iamResourceType, ResourceID: id}}
+	}
+
+	return resources, nil
+}
+
+// AdminEntrance returns the system that the user can access it's global management.
+func (a *Authorizer) AdminEntrance(ctx context.Context, user meta.UserInfo) ([]string, error) {
+	if !auth.IsAuthed() {
+		return make([]string, 0), nil
+	}
+
+	decisions, err := a.AuthorizeBatch(ctx, user, meta.ResourceAttribute{
+		Basic: meta.Basic{
+			Type:   meta.SystemBase,
+			Action: meta.AdminEntrance,
+		},
+		SupplierAccount: user.SupplierAccount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !decisions[0].Authorized {
+		return make([]string, 0), nil
+	}
+	return []string{authcenter.SystemIDCMDB}, nil
+}
+
+func (a *Authorizer) GetAuthorizedAuditList(ctx context.Context, user meta.UserInfo, businessID int64) ([]authcenter.AuthorizedResource, error) {
+	if !auth.IsAuthed() {
+		return make([]authcenter.AuthorizedResource, 0), nil
+	}
+
+	iamResourceType, err := authcenter.ConvertResourceType(meta.AuditLog, businessID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := a.listAuthorizedInstances(ctx, user, businessID, meta.AuditLog, meta.Find)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return make([]authcenter.AuthorizedResource, 0), nil
+	}
+
+	resource := authcenter.AuthorizedResource{
+		ActionID:     authcenter.Get,
+		ResourceType: *iamResourceType,
+		ResourceIDs:  make([]authcenter.IamResource, len(ids)),
+	}
+	for idx, id := range ids {
+		resource.ResourceIDs[idx] = authcenter.IamResource{{ResourceType: *iamResourceType, ResourceID: id}}
+	}
+
+	return []authcenter.AuthorizedResource{resource}, nil
+}
+
+// GetNoAuthSkipUrl is not supported, because there is no auth center to apply for permissions.
+// users should ask the administrator to grant the permissions for them.
+func (a *Authorizer) GetNoAuthSkipUrl(ctx context.Context, header http.Header, permission []metadata.Permission) (skipUrl string, err error) {
+	return "", errors.New("built-in authorizer do not support applying permissions, please contact the administrator")
+}
+
+func (a *Authorizer) GetUserGroupMembers(ctx context.Context, header http.Header, bizID int64, groups []string) ([]authcenter.UserGroupMembers, error) {
+	return a.decider.GroupMembers(ctx, header, bizID, groups)
+}
+
+func (a *Authorizer) listAuthorizedInstances(ctx context.Context, user meta.UserInfo, bizID int64,
+	resourceType meta.ResourceType, action meta.Action) ([]string, error) {
+
+	scope, err := a.decider.AuthorizedInstances(ctx, user, bizID, resourceType, action)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scope.All {
+		return scope.IDs, nil
+	}
+
+	all, err := a.listAllInstances(ctx, user.SupplierAccount, resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scope.Excluded) == 0 {
+		return all, nil
+	}
+
+	excluded := make(map[string]bool)
+	for _, id := range scope.Excluded {
+		excluded[id] = true
+	}
+	ids := make([]string, 0)
+	for _, id := range all {
+		if !excluded[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// listAllInstances list all the instances of the resource type, this is only supported
+// for the resource types in instanceTables.
+func (a *Authorizer) listAllInstances(ctx context.Context, supplierAccount string, resourceType meta.ResourceType) ([]string, error) {
+	instTable, exist := instanceTables[resourceType]
+	if !exist {
+		return nil, fmt.Errorf("list all the instances of resource type %s is not supported", resourceType)
+	}
+
+	cond := make(map[string]interface{})
+	if len(supplierAccount) != 0 {
+		cond = util.SetQueryOwner(cond, supplierAccount)
+	}
+
+	instances := make([]map[string]interface{}, 0)
+	err := a.db.Table(instTable.table).Find(cond).Fields(instTable.idField).All(ctx, &instances)
+	if err != nil {
+		blog.Errorf("list all the instances of resource type %s failed, err: %v, rid: %s", resourceType, err,
+			util.ExtractRequestIDFromContext(ctx))
+		return nil, err
+	}
+
+	ids := make([]string, len(instances))
+	for idx, inst := range instances {
+		ids[idx] = util.GetStrByInterface(inst[instTable.idField])
+	}
+	return ids, nil
+}
+
+func (a *Authorizer) listAllBusinesses(ctx context.Context, supplierAccount string) ([]int64, error) {
+	ids, err := a.listAllInstances(ctx, supplierAccount, meta.Business)
+	if err != nil {
+		return nil, err
+	}
+	return parseBusinessIDs(ids)
+}
+
+func parseBusinessIDs(ids []string) ([]int64, error) {
+	businessIDs := make([]int64, len(ids))
+	for idx, id := range ids {
+		bizID, err := util.GetInt64ByInterface(id)
+		if err != nil {
+			return nil, fmt.Errorf("parse business id %s failed, err: %v", id, err)
+		}
+		businessIDs[idx] = bizID
+	}
+	return businessIDs, nil
+}
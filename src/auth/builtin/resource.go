@@ -0,0 +1,68 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package builtin
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/meta"
+)
+
+// the built-in authorizers authorize with the resource instances in cmdb directly, so that
+// the resources do not need to be registered, all the resource handle operations do nothing.
+
+func (a *Authorizer) RegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	return nil
+}
+
+func (a *Authorizer) DryRunRegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) (*authcenter.RegisterInfo, error) {
+	return new(authcenter.RegisterInfo), nil
+}
+
+func (a *Authorizer) DeregisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	return nil
+}
+
+func (a *Authorizer) RawDeregisterResource(ctx context.Context, scope authcenter.ScopeInfo, rs ...meta.BackendResource) error {
+	return nil
+}
+
+func (a *Authorizer) UpdateResource(ctx context.Context, rs *meta.ResourceAttribute) error {
+	return nil
+}
+
+func (a *Authorizer) Get(ctx context.Context) error {
+	return nil
+}
+
+func (a *Authorizer) ListResources(ctx context.Context, r *meta.ResourceAttribute) ([]meta.BackendResource, error) {
+	return make([]meta.BackendResource, 0), nil
+}
+
+func (a *Authorizer) RawListResources(ctx context.Context, header http.Header, searchCondition authcenter.SearchCondition) ([]meta.BackendResource, error) {
+	return make([]meta.BackendResource, 0), nil
+}
+
+func (a *Authorizer) ListPageResources(ctx context.Context, r *meta.ResourceAttribute, limit, offset int64) (authcenter.PageBackendResource, error) {
+	return authcenter.PageBackendResource{Results: make([]meta.BackendResource, 0)}, nil
+}
+
+func (a *Authorizer) RawPageListResources(ctx context.Context, header http.Header, searchCondition authcenter.SearchCondition, limit, offset int64) (authcenter.PageBackendResource, error) {
+	return authcenter.PageBackendResource{Results: make([]meta.BackendResource, 0)}, nil
+}
+
+func (a *Authorizer) Init(ctx context.Context, config meta.InitConfig) error {
+	return nil
+}
@@ -133,14 +133,48 @@ const (
 	ModelTopologyOperation Action = "modelTopologyOperation"
 	AdminEntrance          Action = "adminEntrance"
 
+	// reveal the plaintext of the instance's secret attribute value
+	RevealSecret Action = "revealSecret"
+
 	// event watch
-	WatchHost         Action = "host"
-	WatchHostRelation Action = "host_relation"
-	WatchBiz          Action = "biz"
-	WatchSet          Action = "set"
-	WatchModule       Action = "module"
+	WatchHost            Action = "host"
+	WatchHostRelation    Action = "host_relation"
+	WatchBiz             Action = "biz"
+	WatchSet             Action = "set"
+	WatchModule          Action = "module"
+	WatchObjectInstance  Action = "object_instance"
+	WatchInstAsst        Action = "inst_asst"
+	WatchServiceInstance Action = "service_instance"
+	WatchProcess         Action = "process"
 )
 
+// batchActions is used to convert the batch actions to it's single one.
+var batchActions = map[Action]Action{
+	CreateMany: Create,
+	UpdateMany: Update,
+	DeleteMany: Delete,
+	FindMany:   Find,
+}
+
+// SingleAction returns the single action of a batch action, such as "find" for "findMany",
+// the second return value is false if the action is not a batch action.
+func SingleAction(a Action) (Action, bool) {
+	single, exist := batchActions[a]
+	return single, exist
+}
+
+// Explanation explains which rule decides the authorize decision of a resource.
+type Explanation struct {
+	Resource ResourceAttribute `json:"resource"`
+	// whether the user is authorized or not.
+	Authorized bool `json:"authorized"`
+	// the name of the rule which decides the decision, empty when no rule is matched.
+	Rule string `json:"rule"`
+	// the effect of the rule, such as allow or deny.
+	Effect string `json:"effect"`
+	Reason string `json:"reason"`
+}
+
 type InitConfig struct {
 	Bizs             []metadata.BizInst
 	Models           []metadata.Object
@@ -14,6 +14,7 @@ package parser
 
 import (
 	"net/http"
+	"regexp"
 
 	"configcenter/src/auth/meta"
 )
@@ -23,7 +24,8 @@ func (ps *parseStream) adminRelated() *parseStream {
 		return ps
 	}
 
-	ps.ConfigAdmin()
+	ps.ConfigAdmin().
+		authRole()
 
 	return ps
 }
@@ -49,3 +51,61 @@ var ConfigAdminConfigs = []AuthConfig{
 func (ps *parseStream) ConfigAdmin() *parseStream {
 	return ParseStreamWithFramework(ps, ConfigAdminConfigs)
 }
+
+// the roles of the local authorizer is managed as part of the config admin.
+var AuthRoleConfigs = []AuthConfig{
+	{
+		Name:           "createAuthRole",
+		Description:    "创建权限角色",
+		Pattern:        "/api/v3/admin/auth/role",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateAuthRole",
+		Description:    "更新权限角色",
+		Regex:          regexp.MustCompile(`^/api/v3/admin/auth/role/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteAuthRole",
+		Description:    "删除权限角色",
+		Regex:          regexp.MustCompile(`^/api/v3/admin/auth/role/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "searchAuthRoles",
+		Description:    "查询权限角色",
+		Pattern:        "/api/v3/admin/auth/role/search",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "createAuthRoleBindings",
+		Description:    "绑定权限角色",
+		Pattern:        "/api/v3/admin/auth/rolebinding",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteAuthRoleBinding",
+		Description:    "解绑权限角色",
+		Regex:          regexp.MustCompile(`^/api/v3/admin/auth/rolebinding/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "searchAuthRoleBindings",
+		Description:    "查询权限角色绑定",
+		Pattern:        "/api/v3/admin/auth/rolebinding/search",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	},
+}
+
+func (ps *parseStream) authRole() *parseStream {
+	return ParseStreamWithFramework(ps, AuthRoleConfigs)
+}
@@ -19,6 +19,7 @@ import (
 	"strconv"
 
 	"configcenter/src/auth/meta"
+	"configcenter/src/common/watch"
 )
 
 func (ps *parseStream) eventRelated() *parseStream {
@@ -37,6 +38,10 @@ var (
 	updateSubscribeRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/\S+/\d+/\d+/?$`)
 	deleteSubscribeRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/\S+/\d+/\d+/?$`)
 	watchResourceRegexp   = regexp.MustCompile(`^/api/v3/event/watch/resource/\S+/?$`)
+
+	findDeadLetterRegexp   = regexp.MustCompile(`^/api/v3/event/subscribe/deadletter/search/[^\s/]+/\d+/\d+/?$`)
+	replayDeadLetterRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/deadletter/replay/[^\s/]+/\d+/\d+/?$`)
+	purgeDeadLetterRegexp  = regexp.MustCompile(`^/api/v3/event/subscribe/deadletter/[^\s/]+/\d+/\d+/?$`)
 )
 
 const (
@@ -49,6 +54,62 @@ func (ps *parseStream) subscribe() *parseStream {
 		return ps
 	}
 
+	// dead letter operations must be matched before the subscription's operations,
+	// because they are also matched with the subscription's url regexp.
+	if ps.hitRegexp(findDeadLetterRegexp, http.MethodPost) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("search dead letters, but got invalid subscription id: %s", ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Find,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
+	if ps.hitRegexp(replayDeadLetterRegexp, http.MethodPost) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("replay dead letters, but got invalid subscription id: %s", ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Update,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
+	if ps.hitRegexp(purgeDeadLetterRegexp, http.MethodDelete) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[7], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("purge dead letters, but got invalid subscription id: %s", ps.RequestCtx.Elements[7])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Update,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
 	// find all the subscription
 	if ps.hitRegexp(findSubscribeRegexp, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
@@ -146,6 +207,10 @@ func (ps *parseStream) subscribe() *parseStream {
 			ps.err = fmt.Errorf("watch event resource, but got empty resource: %s", ps.RequestCtx.Elements[5])
 			return ps
 		}
+		if watch.CursorType(resource).ToInt() <= watch.NoEvent.ToInt() {
+			ps.err = fmt.Errorf("watch event resource, but got unsupported resource: %s", resource)
+			return ps
+		}
 
 		ps.Attribute.Resources = []meta.ResourceAttribute{
 			{
@@ -0,0 +1,73 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"net/http"
+	"testing"
+
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/watch"
+)
+
+// parseWatch runs the event parser only, the other parsers may request the core service with the engine
+func parseWatch(t *testing.T, resource watch.CursorType) *parseStream {
+	uri := "/api/v3/event/watch/resource/" + string(resource)
+	elements, err := urlParse(uri)
+	if err != nil {
+		t.Fatalf("parse url %s failed, err: %v", uri, err)
+	}
+	header := http.Header{}
+	header.Set(common.BKHTTPHeaderUser, "admin")
+	header.Set(common.BKHTTPOwnerID, common.BKDefaultOwnerID)
+
+	ps, err := newParseStream(&RequestContext{Header: header, Method: http.MethodPost, URI: uri,
+		Elements: elements}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ps.validateAPI().validateVersion().validateUserAndSupplier().eventRelated().finalizer()
+}
+
+func TestParseWatchResource(t *testing.T) {
+	resources := []watch.CursorType{watch.Host, watch.ModuleHostRelation, watch.Biz, watch.Set, watch.Module,
+		watch.ObjectBase, watch.InstAsst, watch.ServiceInstance, watch.Process}
+
+	for _, resource := range resources {
+		ps := parseWatch(t, resource)
+		if ps.err != nil {
+			t.Fatalf("parse watch %s failed, err: %v", resource, ps.err)
+		}
+		if len(ps.Attribute.Resources) != 1 || ps.Attribute.Resources[0].Type != meta.EventWatch {
+			t.Fatalf("watch %s got unexpected resources: %+v", resource, ps.Attribute.Resources)
+		}
+
+		action, err := authcenter.AdaptorAction(&ps.Attribute.Resources[0])
+		if err != nil {
+			t.Fatalf("adapt watch %s action failed, err: %v", resource, err)
+		}
+		if string(action) != string(resource) {
+			t.Fatalf("watch %s got action %s", resource, action)
+		}
+	}
+}
+
+func TestParseWatchUnsupportedResource(t *testing.T) {
+	for _, resource := range []watch.CursorType{watch.NoEvent, "not_exist"} {
+		if ps := parseWatch(t, resource); ps.err == nil {
+			t.Fatalf("watch %s should be rejected", resource)
+		}
+	}
+}
@@ -25,6 +25,7 @@ func (ps *parseStream) hostRelated() *parseStream {
 
 	ps.host().
 		userAPI().
+		dynamicGroup().
 		userCustom().
 		hostFavorite().
 		cloudResourceSync().
@@ -206,6 +207,114 @@ func (ps *parseStream) userAPI() *parseStream {
 	return ps
 }
 
+var (
+	createDynamicGroupPattern   = "/api/v3/dynamicgroup"
+	dynamicGroupRegexp          = regexp.MustCompile(`^/api/v3/dynamicgroup/[0-9]+/[^\s/]+/?$`)
+	findDynamicGroupRegexp      = regexp.MustCompile(`^/api/v3/dynamicgroup/search/[0-9]+/?$`)
+	executeDynamicGroupRegexp   = regexp.MustCompile(`^/api/v3/dynamicgroup/data/[0-9]+/[^\s/]+/?$`)
+	dynamicGroupResourceActions = map[string]meta.Action{
+		http.MethodPut:    meta.Update,
+		http.MethodDelete: meta.Delete,
+		http.MethodGet:    meta.Find,
+	}
+)
+
+// dynamicGroup parse the dynamic group apis, which share the same auth resource with the user custom query.
+func (ps *parseStream) dynamicGroup() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(createDynamicGroupPattern, http.MethodPost) {
+		bizID, err := ps.parseBusinessID()
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.DynamicGrouping,
+					Action: meta.Create,
+				},
+			},
+		}
+		return ps
+	}
+
+	// update, delete and find details of a dynamic group.
+	if action, exist := dynamicGroupResourceActions[ps.RequestCtx.Method]; exist && ps.hitRegexp(dynamicGroupRegexp, ps.RequestCtx.Method) {
+		if len(ps.RequestCtx.Elements) != 5 {
+			ps.err = errors.New("dynamic group operation, but got invalid uri")
+			return ps
+		}
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[3], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("dynamic group operation failed, err: %v", err)
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:         meta.DynamicGrouping,
+					Action:       action,
+					InstanceIDEx: ps.RequestCtx.Elements[4],
+				},
+			},
+		}
+		return ps
+	}
+
+	if ps.hitRegexp(findDynamicGroupRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 5 {
+			ps.err = errors.New("find dynamic groups, but got invalid uri")
+			return ps
+		}
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[4], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("find dynamic groups failed, err: %v", err)
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:   meta.DynamicGrouping,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	if ps.hitRegexp(executeDynamicGroupRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 6 {
+			ps.err = errors.New("execute dynamic group, but got invalid uri")
+			return ps
+		}
+		bizID, err := strconv.ParseInt(ps.RequestCtx.Elements[4], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("execute dynamic group failed, err: %v", err)
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				BusinessID: bizID,
+				Basic: meta.Basic{
+					Type:         meta.DynamicGrouping,
+					Action:       meta.Execute,
+					InstanceIDEx: ps.RequestCtx.Elements[5],
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
 var (
 	saveUserCustomPattern         = `/api/v3/usercustom`
 	searchUserCustomPattern       = `/api/v3/usercustom/user/search`
@@ -92,6 +92,30 @@ var HostApplyAuthConfigs = []AuthConfig{
 		BizIDGetter:    BizIDFromURLGetter,
 		ResourceType:   meta.MainlineInstanceTopology,
 		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "GetHostApplyDriftConfigRegex",
+		Description:    "获取业务的主机属性自动应用偏离处理配置",
+		Regex:          regexp.MustCompile(`^/api/v3/find/host_apply_drift_config/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodGet,
+		BizIDGetter:    BizIDFromURLGetter,
+		ResourceType:   meta.MainlineInstanceTopology,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "UpdateHostApplyDriftConfigRegex",
+		Description:    "更新业务的主机属性自动应用偏离处理配置",
+		Regex:          regexp.MustCompile(`^/api/v3/update/host_apply_drift_config/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    BizIDFromURLGetter,
+		ResourceType:   meta.HostApply,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "ListHostApplyDriftRegex",
+		Description:    "查询不符合主机属性自动应用规则的主机",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/host_apply_drift/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		ResourceType:   meta.MainlineInstanceTopology,
+		ResourceAction: meta.SkipAction,
 	},
 }
 
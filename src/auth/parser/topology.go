@@ -51,6 +51,10 @@ func (ps *parseStream) topology() *parseStream {
 		//objectUnique().
 		audit().
 		instanceAudit().
+		auditRetention().
+		topoSnapshot().
+		schemaBundle().
+		recycleBin().
 		fullTextSearch().
 		cloudArea()
 
@@ -765,6 +769,8 @@ const (
 	findObjectBatchRegexp = `/api/v3/object/search/batch`
 )
 
+var revealInstanceSecretRegexp = regexp.MustCompile(`^/api/v3/find/inst/secret/object/[^\s/]+/inst_id/[0-9]+/property/[^\s/]+/?$`)
+
 //var (
 //	createObjectInstanceRegexp          = regexp.MustCompile(`^/api/v3/inst/[^\s/]+/[^\s/]+/?$`)
 //	findObjectInstanceRegexp            = regexp.MustCompile(`^/api/v3/inst/association/search/owner/[^\s/]+/object/[^\s/]+/?$`)
@@ -1169,6 +1175,19 @@ func (ps *parseStream) objectInstance() *parseStream {
 	//	return ps
 	//}
 
+	// reveal instance secret authorization by instance in topo scene layer
+	if ps.hitRegexp(revealInstanceSecretRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ModelInstance,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
 	if ps.hitPattern(findObjectBatchRegexp, http.MethodPost) {
 		bizID, err := ps.parseBusinessID()
 		if err != nil && err != metadata.LabelKeyNotExistError {
@@ -2469,6 +2488,8 @@ func (ps *parseStream) objectSet() *parseStream {
 var (
 	searchAuditLog               = `/api/v3/audit/search`
 	searchInstanceAuditLogRegexp = regexp.MustCompile(`^/api/v3/object/[^\s/]+/audit/search/?$`)
+	instanceAuditHistoryRegexp   = regexp.MustCompile(`^/api/v3/object/[^\s/]+/audit/history/?$`)
+	restoreInstanceAuditRegexp   = regexp.MustCompile(`^/api/v3/object/[^\s/]+/audit/restore/?$`)
 )
 
 func (ps *parseStream) audit() *parseStream {
@@ -2511,9 +2532,115 @@ func (ps *parseStream) instanceAudit() *parseStream {
 		return ps
 	}
 
+	// instance history and restoration authorization by instance in topo scene layer
+	if ps.hitRegexp(instanceAuditHistoryRegexp, http.MethodPost) || ps.hitRegexp(restoreInstanceAuditRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.AuditLog,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
 	return ps
 }
 
+// the audit retention policies and archives are managed as part of the config admin.
+var AuditRetentionConfigs = []AuthConfig{
+	{
+		Name:           "findAuditRetentionPolicies",
+		Description:    "查询审计保留策略",
+		Pattern:        "/api/v3/findmany/audit/retention_policy",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "updateAuditRetentionPolicy",
+		Description:    "更新审计保留策略",
+		Pattern:        "/api/v3/update/audit/retention_policy",
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "findAuditArchives",
+		Description:    "查询审计归档",
+		Pattern:        "/api/v3/findmany/audit/archive",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "importAuditArchive",
+		Description:    "导入审计归档",
+		Regex:          regexp.MustCompile(`^/api/v3/import/audit/archive/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	},
+}
+
+func (ps *parseStream) auditRetention() *parseStream {
+	return ParseStreamWithFramework(ps, AuditRetentionConfigs)
+}
+
+var TopoSnapshotConfigs = []AuthConfig{
+	{
+		Name:           "createTopoSnapshot",
+		Description:    "创建业务拓扑快照",
+		Regex:          regexp.MustCompile(`^/api/v3/create/topo/snapshot/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		ResourceType:   meta.MainlineInstanceTopology,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "findTopoSnapshots",
+		Description:    "查询业务拓扑快照",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/topo/snapshot/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		ResourceType:   meta.MainlineInstanceTopology,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "deleteTopoSnapshot",
+		Description:    "删除业务拓扑快照",
+		Regex:          regexp.MustCompile(`^/api/v3/delete/topo/snapshot/([0-9]+)/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		BizIDGetter:    BizIDFromURLGetter,
+		ResourceType:   meta.MainlineInstanceTopology,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "diffTopoSnapshot",
+		Description:    "对比业务拓扑快照",
+		Regex:          regexp.MustCompile(`^/api/v3/find/topo/snapshot/diff/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    BizIDFromURLGetter,
+		ResourceType:   meta.MainlineInstanceTopology,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "findTopoSnapshotSchedule",
+		Description:    "查询业务拓扑快照计划",
+		Regex:          regexp.MustCompile(`^/api/v3/find/topo/snapshot/schedule/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodGet,
+		BizIDGetter:    BizIDFromURLGetter,
+		ResourceType:   meta.MainlineInstanceTopology,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "updateTopoSnapshotSchedule",
+		Description:    "更新业务拓扑快照计划",
+		Regex:          regexp.MustCompile(`^/api/v3/update/topo/snapshot/schedule/bk_biz_id/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    BizIDFromURLGetter,
+		ResourceType:   meta.MainlineInstanceTopology,
+		ResourceAction: meta.Update,
+	},
+}
+
+func (ps *parseStream) topoSnapshot() *parseStream {
+	return ParseStreamWithFramework(ps, TopoSnapshotConfigs)
+}
+
 var (
 	fullTextSearchPattern = "/api/v3/find/full_text"
 )
@@ -2612,3 +2739,62 @@ func (ps *parseStream) cloudArea() *parseStream {
 
 	return ps
 }
+
+var SchemaBundleConfigs = []AuthConfig{
+	{
+		Name:           "exportSchemaBundle",
+		Description:    "导出模型定义包",
+		Pattern:        "/api/v3/find/schema/bundle",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "planSchemaBundle",
+		Description:    "预览模型定义包变更",
+		Pattern:        "/api/v3/find/schema/bundle/plan",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:           "applySchemaBundle",
+		Description:    "应用模型定义包",
+		Pattern:        "/api/v3/update/schema/bundle",
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	},
+}
+
+func (ps *parseStream) schemaBundle() *parseStream {
+	return ParseStreamWithFramework(ps, SchemaBundleConfigs)
+}
+
+// the recycle bin holds the deleted resources of all kinds, it's managed as part of the config admin.
+var RecycleBinConfigs = []AuthConfig{
+	{
+		Name:           "findRecycleBinItems",
+		Description:    "查询回收站",
+		Pattern:        "/api/v3/findmany/recycle_bin/item",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "restoreRecycleBinItem",
+		Description:    "从回收站恢复实例",
+		Regex:          regexp.MustCompile(`^/api/v3/restore/recycle_bin/item/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "purgeRecycleBinItems",
+		Description:    "清理回收站",
+		Pattern:        "/api/v3/deletemany/recycle_bin/item",
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	},
+}
+
+func (ps *parseStream) recycleBin() *parseStream {
+	return ParseStreamWithFramework(ps, RecycleBinConfigs)
+}
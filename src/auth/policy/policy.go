@@ -0,0 +1,301 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package policy is a built-in decider which decides the permissions with the rules in a policy file,
+// the policy file is reloaded automatically when it's changed.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/builtin"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/util"
+
+	"gopkg.in/yaml.v2"
+)
+
+// reloadInterval is the interval to check whether the policy file is changed.
+const reloadInterval = 10 * time.Second
+
+// NewDecider load the policy file and create a decider with it, the policy file
+// is watched and reloaded when it's changed. resolveNames is used to list the instances of the rules
+// which are limited by instance names.
+func NewDecider(ctx context.Context, file string, resolveNames builtin.InstanceNameResolver) (*Decider, error) {
+	d := &Decider{file: file, resolveNames: resolveNames}
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+
+	go d.watch(ctx)
+	return d, nil
+}
+
+// Decider decides the permissions with the rules in the policy file.
+type Decider struct {
+	file         string
+	resolveNames builtin.InstanceNameResolver
+
+	lock   sync.RWMutex
+	policy *Policy
+	// modTime and size of the loaded policy file, which is used to check if the file is changed.
+	modTime time.Time
+	size    int64
+}
+
+// load read and validate the policy file, the policy is replaced only when the new one is valid.
+func (d *Decider) load() error {
+	info, err := os.Stat(d.file)
+	if err != nil {
+		return fmt.Errorf("stat policy file %s failed, err: %v", d.file, err)
+	}
+
+	content, err := ioutil.ReadFile(d.file)
+	if err != nil {
+		return fmt.Errorf("read policy file %s failed, err: %v", d.file, err)
+	}
+
+	policy := new(Policy)
+	if strings.ToLower(filepath.Ext(d.file)) == ".json" {
+		err = json.Unmarshal(content, policy)
+	} else {
+		err = yaml.Unmarshal(content, policy)
+	}
+	if err != nil {
+		return fmt.Errorf("unmarshal policy file %s failed, err: %v", d.file, err)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("policy file %s is invalid, %v", d.file, err)
+	}
+
+	d.lock.Lock()
+	d.policy = policy
+	d.modTime = info.ModTime()
+	d.size = info.Size()
+	d.lock.Unlock()
+
+	blog.Infof("load policy file %s success, %d rules is loaded", d.file, len(policy.Rules))
+	return nil
+}
+
+// watch reloads the policy file when it's changed, until the context is done.
+func (d *Decider) watch(ctx context.Context) {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			blog.Infof("stop watching policy file %s", d.file)
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(d.file)
+		if err != nil {
+			blog.Errorf("check policy file %s failed, err: %v", d.file, err)
+			continue
+		}
+
+		d.lock.RLock()
+		changed := !info.ModTime().Equal(d.modTime) || info.Size() != d.size
+		d.lock.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err := d.load(); err != nil {
+			blog.Errorf("reload policy file failed, the previous policy is still used, err: %v", err)
+		}
+	}
+}
+
+func (d *Decider) getPolicy() *Policy {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.policy
+}
+
+func (d *Decider) Decide(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Decision, error) {
+	explanations, err := d.Explain(ctx, user, resources...)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]meta.Decision, len(explanations))
+	for idx, explanation := range explanations {
+		decisions[idx] = meta.Decision{Authorized: explanation.Authorized, Reason: explanation.Reason}
+	}
+	return decisions, nil
+}
+
+// Explain returns which rule decides the decision of each of the resources.
+func (d *Decider) Explain(_ context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Explanation, error) {
+	policy := d.getPolicy()
+
+	explanations := make([]meta.Explanation, len(resources))
+	for idx := range resources {
+		explanations[idx].Resource = resources[idx]
+
+		rule := policy.evaluate(user.UserName, &resources[idx])
+		if rule == nil {
+			explanations[idx].Effect = string(Deny)
+			explanations[idx].Reason = fmt.Sprintf("no rule allows user %s to %s %s", user.UserName,
+				resources[idx].Action, resources[idx].Type)
+			continue
+		}
+
+		explanations[idx].Rule = rule.Name
+		explanations[idx].Effect = string(rule.Effect)
+		if rule.Effect == Allow {
+			explanations[idx].Authorized = true
+			continue
+		}
+		explanations[idx].Reason = fmt.Sprintf("user %s is denied to %s %s by rule %s", user.UserName,
+			resources[idx].Action, resources[idx].Type, rule.Name)
+	}
+
+	return explanations, nil
+}
+
+// AuthorizedInstances returns the instances which is allowed by any of the allow rules and not denied by the
+// deny rules, the instance names of the rules are resolved to ids, because the instances are listed by ids.
+// no instance is authorized if the names can't be resolved, otherwise the instances denied by them are leaked.
+func (d *Decider) AuthorizedInstances(ctx context.Context, user meta.UserInfo, bizID int64, resourceType meta.ResourceType,
+	action meta.Action) (*builtin.InstanceScope, error) {
+
+	policy := d.getPolicy()
+
+	all := false
+	allowed := make([]string, 0)
+	denied := make(map[string]bool)
+	for idx := range policy.Rules {
+		rule := &policy.Rules[idx]
+		if !policy.matchSubject(rule, user.UserName) || !rule.matchResource(resourceType, action) {
+			continue
+		}
+
+		var nameIDs []string
+		if len(rule.InstanceNames) != 0 && !hasAny(rule.InstanceNames) {
+			var err error
+			nameIDs, err = d.resolveInstanceNames(ctx, user, resourceType, rule.InstanceNames)
+			if err != nil {
+				blog.Errorf("resolve the instance names of rule %s failed, no %s instance is authorized, err: %v, "+
+					"rid: %s", rule.Name, resourceType, err, util.ExtractRequestIDFromContext(ctx))
+				return &builtin.InstanceScope{IDs: make([]string, 0)}, nil
+			}
+		}
+
+		ids, allInstances, ok := rule.instances(resourceType, bizID, nameIDs)
+		if !ok {
+			continue
+		}
+
+		if rule.Effect == Deny {
+			if allInstances {
+				return &builtin.InstanceScope{IDs: make([]string, 0)}, nil
+			}
+			for _, id := range ids {
+				denied[id] = true
+			}
+			continue
+		}
+
+		if allInstances {
+			all = true
+			continue
+		}
+		allowed = append(allowed, ids...)
+	}
+
+	excluded := make([]string, 0)
+	for id := range denied {
+		excluded = append(excluded, id)
+	}
+
+	if all {
+		return &builtin.InstanceScope{All: true, Excluded: excluded}, nil
+	}
+
+	exist := make(map[string]bool)
+	ids := make([]string, 0)
+	for _, id := range allowed {
+		if !exist[id] && !denied[id] {
+			exist[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return &builtin.InstanceScope{IDs: ids}, nil
+}
+
+// resolveInstanceNames resolves the instance names to ids with the resolver of the decider
+func (d *Decider) resolveInstanceNames(ctx context.Context, user meta.UserInfo, resourceType meta.ResourceType,
+	names []string) ([]string, error) {
+
+	if d.resolveNames == nil {
+		return nil, fmt.Errorf("no instance name resolver is configured")
+	}
+	return d.resolveNames(ctx, user.SupplierAccount, resourceType, names)
+}
+
+func (d *Decider) AnyAuthorizedBusinesses(_ context.Context, user meta.UserInfo) ([]int64, bool, error) {
+	policy := d.getPolicy()
+
+	exist := make(map[int64]bool)
+	businessIDs := make([]int64, 0)
+	for idx := range policy.Rules {
+		rule := &policy.Rules[idx]
+		if rule.Effect != Allow || !policy.matchSubject(rule, user.UserName) {
+			continue
+		}
+
+		if len(rule.BizIDs) == 0 {
+			// a rule which is not limited to businesses works for all the businesses.
+			return nil, true, nil
+		}
+
+		for _, bizID := range rule.BizIDs {
+			if !exist[bizID] {
+				exist[bizID] = true
+				businessIDs = append(businessIDs, bizID)
+			}
+		}
+	}
+	return businessIDs, false, nil
+}
+
+// GroupMembers returns the members of the groups defined in the policy file, groups are not business
+// related, so the business id is not used.
+func (d *Decider) GroupMembers(_ context.Context, _ http.Header, _ int64, groups []string) ([]authcenter.UserGroupMembers, error) {
+	policy := d.getPolicy()
+
+	members := make([]authcenter.UserGroupMembers, 0)
+	for _, group := range groups {
+		users, exist := policy.Groups[group]
+		if !exist {
+			continue
+		}
+		members = append(members, authcenter.UserGroupMembers{Name: group, Users: users})
+	}
+	return members, nil
+}
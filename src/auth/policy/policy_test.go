@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"configcenter/src/auth/meta"
+)
+
+func TestAuthorizedInstancesWithNameLimitedDeny(t *testing.T) {
+	policy := &Policy{
+		Rules: []Rule{
+			{
+				Name:          "read-all-business",
+				Effect:        Allow,
+				Users:         []string{Any},
+				ResourceTypes: []string{string(meta.Business)},
+				Actions:       []string{string(meta.Find)},
+			},
+			{
+				Name:          "no-read-core-business",
+				Effect:        Deny,
+				Users:         []string{Any},
+				ResourceTypes: []string{string(meta.Business)},
+				Actions:       []string{string(meta.Find)},
+				InstanceNames: []string{"core"},
+			},
+		},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("validate policy failed, err: %v", err)
+	}
+
+	user := meta.UserInfo{UserName: "alice", SupplierAccount: "0"}
+	resolver := func(_ context.Context, _ string, resourceType meta.ResourceType, names []string) ([]string, error) {
+		if resourceType != meta.Business || len(names) != 1 || names[0] != "core" {
+			t.Fatalf("unexpected names %v of resource type %s are resolved", names, resourceType)
+		}
+		return []string{"3"}, nil
+	}
+
+	d := &Decider{policy: policy, resolveNames: resolver}
+	scope, err := d.AuthorizedInstances(context.Background(), user, 0, meta.Business, meta.Find)
+	if err != nil {
+		t.Fatalf("get authorized instances failed, err: %v", err)
+	}
+	if !scope.All || len(scope.Excluded) != 1 || scope.Excluded[0] != "3" {
+		t.Errorf("the business denied by name is not excluded, scope: %+v", scope)
+	}
+
+	// the allowed businesses are limited by ids, the denied one is removed from them
+	policy.Rules[0].InstanceIDs = []string{"2", "3"}
+	scope, err = d.AuthorizedInstances(context.Background(), user, 0, meta.Business, meta.Find)
+	if err != nil {
+		t.Fatalf("get authorized instances failed, err: %v", err)
+	}
+	if scope.All || len(scope.IDs) != 1 || scope.IDs[0] != "2" {
+		t.Errorf("the business denied by name is authorized, scope: %+v", scope)
+	}
+
+	// fail closed when the names can't be resolved
+	for _, failed := range []*Decider{
+		{policy: policy},
+		{policy: policy, resolveNames: func(context.Context, string, meta.ResourceType, []string) ([]string, error) {
+			return nil, errors.New("db is down")
+		}},
+	} {
+		scope, err = failed.AuthorizedInstances(context.Background(), user, 0, meta.Business, meta.Find)
+		if err != nil {
+			t.Fatalf("get authorized instances failed, err: %v", err)
+		}
+		if scope.All || len(scope.IDs) != 0 {
+			t.Errorf("the instances are authorized while the names can't be resolved, scope: %+v", scope)
+		}
+	}
+}
+
+func TestAuthorizedInstancesWithNameLimitedAllow(t *testing.T) {
+	policy := &Policy{
+		Rules: []Rule{
+			{
+				Name:          "read-named-business",
+				Effect:        Allow,
+				Users:         []string{Any},
+				ResourceTypes: []string{string(meta.Business)},
+				Actions:       []string{string(meta.Find)},
+				InstanceIDs:   []string{"2"},
+				InstanceNames: []string{"web"},
+			},
+		},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("validate policy failed, err: %v", err)
+	}
+
+	resolver := func(context.Context, string, meta.ResourceType, []string) ([]string, error) {
+		return []string{"5"}, nil
+	}
+	d := &Decider{policy: policy, resolveNames: resolver}
+	scope, err := d.AuthorizedInstances(context.Background(), meta.UserInfo{UserName: "alice"}, 0, meta.Business,
+		meta.Find)
+	if err != nil {
+		t.Fatalf("get authorized instances failed, err: %v", err)
+	}
+	sort.Strings(scope.IDs)
+	if scope.All || len(scope.IDs) != 2 || scope.IDs[0] != "2" || scope.IDs[1] != "5" {
+		t.Errorf("the businesses allowed by id and name are not authorized, scope: %+v", scope)
+	}
+}
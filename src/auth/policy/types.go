@@ -0,0 +1,265 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"configcenter/src/auth/meta"
+)
+
+// Any is a wildcard which can be used as a rule's user, resource type or action.
+const Any = "*"
+
+// Effect is the effect of a rule when it's matched.
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	// Deny takes precedence over Allow, a request is denied when any deny rule is matched.
+	Deny Effect = "deny"
+)
+
+// Policy is the content of a policy file, a request is allowed only when it matches
+// at least one allow rule and none of the deny rules.
+type Policy struct {
+	// Groups is the user groups, key is the group name, value is the group members.
+	Groups map[string][]string `json:"groups" yaml:"groups"`
+	Rules  []Rule              `json:"rules" yaml:"rules"`
+
+	// members is the group members set, key is the group name.
+	members map[string]map[string]bool
+}
+
+// Rule describes which users or groups is allowed or denied to do the actions on the resources.
+type Rule struct {
+	Name   string `json:"name" yaml:"name"`
+	Effect Effect `json:"effect" yaml:"effect"`
+
+	// the users and groups this rule applies to, "*" in users means all the users.
+	Users  []string `json:"users" yaml:"users"`
+	Groups []string `json:"groups" yaml:"groups"`
+
+	// resource types and actions defined in auth/meta, "*" means all of them.
+	ResourceTypes []string `json:"resource_types" yaml:"resource_types"`
+	Actions       []string `json:"actions" yaml:"actions"`
+
+	// the businesses this rule is limited to, empty means all the businesses and global resources.
+	BizIDs []int64 `json:"biz_ids" yaml:"biz_ids"`
+	// the resource instances this rule is limited to, which is matched with the instance's id or
+	// name, empty means all the instances.
+	InstanceIDs   []string `json:"instance_ids" yaml:"instance_ids"`
+	InstanceNames []string `json:"instance_names" yaml:"instance_names"`
+}
+
+// Validate validate the rules, and initialize the group members.
+func (p *Policy) Validate() error {
+	p.members = make(map[string]map[string]bool)
+	for group, users := range p.Groups {
+		p.members[group] = make(map[string]bool)
+		for _, user := range users {
+			p.members[group][user] = true
+		}
+	}
+
+	names := make(map[string]bool)
+	for idx := range p.Rules {
+		rule := &p.Rules[idx]
+		if len(rule.Name) == 0 {
+			return fmt.Errorf("rules[%d] name can not be empty", idx)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule name %s is duplicated", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule %s is invalid, %v", rule.Name, err)
+		}
+
+		for _, group := range rule.Groups {
+			if _, exist := p.members[group]; !exist {
+				return fmt.Errorf("rule %s is invalid, group %s is not defined", rule.Name, group)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Rule) validate() error {
+	if r.Effect != Allow && r.Effect != Deny {
+		return fmt.Errorf("invalid effect %s", r.Effect)
+	}
+
+	if len(r.Users) == 0 && len(r.Groups) == 0 {
+		return errors.New("users and groups can not be both empty")
+	}
+
+	if len(r.ResourceTypes) == 0 {
+		return errors.New("resource_types can not be empty")
+	}
+
+	if len(r.Actions) == 0 {
+		return errors.New("actions can not be empty")
+	}
+	return nil
+}
+
+// evaluate returns the rule which decides the decision of the resource, nil means no rule is matched.
+func (p *Policy) evaluate(user string, r *meta.ResourceAttribute) *Rule {
+	var allowed *Rule
+	for idx := range p.Rules {
+		rule := &p.Rules[idx]
+		if !p.matchSubject(rule, user) || !rule.matchResource(r.Type, r.Action) || !rule.matchInstance(r) {
+			continue
+		}
+
+		if rule.Effect == Deny {
+			return rule
+		}
+
+		if allowed == nil {
+			allowed = rule
+		}
+	}
+	return allowed
+}
+
+func (p *Policy) matchSubject(rule *Rule, user string) bool {
+	for _, one := range rule.Users {
+		if one == Any || one == user {
+			return true
+		}
+	}
+
+	for _, group := range rule.Groups {
+		if p.members[group][user] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchResource(resourceType meta.ResourceType, action meta.Action) bool {
+	if !inOrAny(string(resourceType), r.ResourceTypes) {
+		return false
+	}
+
+	if inOrAny(string(action), r.Actions) {
+		return true
+	}
+
+	// a rule with action "update" also works for "updateMany".
+	single, isBatch := meta.SingleAction(action)
+	return isBatch && inOrAny(string(single), r.Actions)
+}
+
+// matchInstance check if the resource is in the rule's business and instance scope.
+func (r *Rule) matchInstance(res *meta.ResourceAttribute) bool {
+	bizID := res.BusinessID
+	if bizID == 0 && res.Type == meta.Business {
+		// the business itself is in the business's scope.
+		bizID = res.InstanceID
+	}
+
+	if len(r.BizIDs) != 0 && !containsInt64(r.BizIDs, bizID) {
+		return false
+	}
+
+	if len(r.InstanceIDs) == 0 && len(r.InstanceNames) == 0 {
+		return true
+	}
+
+	id := instanceID(res)
+	if len(id) != 0 && inOrAny(id, r.InstanceIDs) {
+		return true
+	}
+
+	return len(res.Name) != 0 && inOrAny(res.Name, r.InstanceNames)
+}
+
+// instances returns the instance ids of the resource type in the business which the rule is limited to,
+// all is true when the rule is not limited to instances, ok is false when the rule does not apply.
+// nameIDs is the ids of the instances whose names are in the rule's instance names.
+func (r *Rule) instances(resourceType meta.ResourceType, bizID int64, nameIDs []string) (ids []string, all bool,
+	ok bool) {
+
+	all = (len(r.InstanceIDs) == 0 && len(r.InstanceNames) == 0) || hasAny(r.InstanceIDs) || hasAny(r.InstanceNames)
+	if !all {
+		ids = make([]string, 0, len(r.InstanceIDs)+len(nameIDs))
+		ids = append(ids, r.InstanceIDs...)
+		ids = append(ids, nameIDs...)
+	}
+
+	if resourceType == meta.Business {
+		// the businesses is limited by both biz ids and instance ids.
+		if len(r.BizIDs) == 0 {
+			return ids, all, true
+		}
+
+		bizIDs := make([]string, 0)
+		for _, id := range r.BizIDs {
+			idStr := strconv.FormatInt(id, 10)
+			if all || inOrAny(idStr, ids) {
+				bizIDs = append(bizIDs, idStr)
+			}
+		}
+		return bizIDs, false, true
+	}
+
+	if len(r.BizIDs) != 0 && !containsInt64(r.BizIDs, bizID) {
+		return nil, false, false
+	}
+	return ids, all, true
+}
+
+// instanceID returns the string form of the resource's instance id.
+func instanceID(r *meta.ResourceAttribute) string {
+	if len(r.InstanceIDEx) != 0 {
+		return r.InstanceIDEx
+	}
+	if r.InstanceID > 0 {
+		return strconv.FormatInt(r.InstanceID, 10)
+	}
+	return ""
+}
+
+func inOrAny(target string, list []string) bool {
+	for _, item := range list {
+		if item == Any || item == target {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAny returns true if the list contains Any, which matches all the items.
+func hasAny(list []string) bool {
+	for _, item := range list {
+		if item == Any {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt64(list []int64, target int64) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
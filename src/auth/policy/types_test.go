@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package policy
+
+import (
+	"testing"
+
+	"configcenter/src/auth/meta"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := &Policy{
+		Groups: map[string][]string{"ops": {"alice", "bob"}},
+		Rules: []Rule{
+			{
+				Name:          "ops-manage-hosts",
+				Effect:        Allow,
+				Groups:        []string{"ops"},
+				ResourceTypes: []string{string(meta.HostInstance)},
+				Actions:       []string{Any},
+				BizIDs:        []int64{2},
+			},
+			{
+				Name:          "no-delete-core-host",
+				Effect:        Deny,
+				Users:         []string{Any},
+				ResourceTypes: []string{string(meta.HostInstance)},
+				Actions:       []string{string(meta.Delete)},
+				InstanceNames: []string{"core"},
+			},
+			{
+				Name:          "bob-read-business",
+				Effect:        Allow,
+				Users:         []string{"bob"},
+				ResourceTypes: []string{string(meta.Business)},
+				Actions:       []string{string(meta.Find)},
+				InstanceIDs:   []string{"3"},
+			},
+		},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("validate policy failed, err: %v", err)
+	}
+
+	host := func(action meta.Action, bizID int64, name string) *meta.ResourceAttribute {
+		return &meta.ResourceAttribute{
+			Basic:      meta.Basic{Type: meta.HostInstance, Action: action, Name: name, InstanceID: 1},
+			BusinessID: bizID,
+		}
+	}
+
+	cases := []struct {
+		user     string
+		resource *meta.ResourceAttribute
+		rule     string
+	}{
+		{user: "alice", resource: host(meta.Update, 2, "web"), rule: "ops-manage-hosts"},
+		{user: "alice", resource: host(meta.UpdateMany, 2, "web"), rule: "ops-manage-hosts"},
+		{user: "alice", resource: host(meta.Update, 3, "web"), rule: ""},
+		{user: "alice", resource: host(meta.Delete, 2, "core"), rule: "no-delete-core-host"},
+		{user: "carol", resource: host(meta.Update, 2, "web"), rule: ""},
+		{user: "bob", resource: &meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Business, Action: meta.Find, InstanceID: 3}},
+			rule: "bob-read-business"},
+		{user: "bob", resource: &meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Business, Action: meta.Find, InstanceID: 4}},
+			rule: ""},
+	}
+
+	for idx, c := range cases {
+		rule := policy.evaluate(c.user, c.resource)
+		name := ""
+		if rule != nil {
+			name = rule.Name
+		}
+		if name != c.rule {
+			t.Errorf("case %d: expect decided by rule %q, but got %q", idx, c.rule, name)
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	policy := &Policy{
+		Rules: []Rule{{
+			Name:          "undefined-group",
+			Effect:        Allow,
+			Groups:        []string{"ops"},
+			ResourceTypes: []string{Any},
+			Actions:       []string{Any},
+		}},
+	}
+	if err := policy.Validate(); err == nil {
+		t.Errorf("expect undefined group is invalid, but validate success")
+	}
+}
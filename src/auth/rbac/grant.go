@@ -0,0 +1,97 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"strconv"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/metadata"
+)
+
+// grant is a permission which is granted to a user by a role binding.
+type grant struct {
+	// the business that this permission is limited to, 0 means it's a global permission.
+	bizID      int64
+	permission metadata.AuthPermission
+}
+
+type grants []grant
+
+// allow check if the resource can be operated with these grants.
+func (g grants) allow(r *meta.ResourceAttribute) bool {
+	bizID := r.BusinessID
+	if bizID == 0 && r.Type == meta.Business {
+		// the business itself is in the business's scope.
+		bizID = r.InstanceID
+	}
+
+	for _, one := range g {
+		if one.bizID != 0 && one.bizID != bizID {
+			continue
+		}
+
+		if !one.matchResource(r.Type, r.Action) {
+			continue
+		}
+
+		if one.matchInstance(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchResource check if the grant's resource type and actions matches with the resource type and action.
+func (g *grant) matchResource(resourceType meta.ResourceType, action meta.Action) bool {
+	if g.permission.ResourceType != metadata.AuthPermissionAny && g.permission.ResourceType != string(resourceType) {
+		return false
+	}
+
+	// a permission with action "update" also works for "updateMany".
+	single, isBatch := meta.SingleAction(action)
+	for _, act := range g.permission.Actions {
+		if act == metadata.AuthPermissionAny || act == string(action) {
+			return true
+		}
+
+		if isBatch && act == string(single) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchInstance check if the resource instance is in the grant's instance list.
+// a resource without instance id can only be matched with a grant which is not
+// limited to instances, such as creating a new instance.
+func (g *grant) matchInstance(r *meta.ResourceAttribute) bool {
+	if len(g.permission.InstanceIDs) == 0 {
+		return true
+	}
+
+	id := r.InstanceIDEx
+	if len(id) == 0 && r.InstanceID > 0 {
+		id = strconv.FormatInt(r.InstanceID, 10)
+	}
+	if len(id) == 0 {
+		return false
+	}
+
+	for _, instanceID := range g.permission.InstanceIDs {
+		if instanceID == id {
+			return true
+		}
+	}
+	return false
+}
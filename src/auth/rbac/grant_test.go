@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"testing"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/metadata"
+)
+
+func TestGrantsAllow(t *testing.T) {
+	userGrants := grants{
+		{
+			bizID: 0,
+			permission: metadata.AuthPermission{
+				ResourceType: string(meta.Model),
+				Actions:      []string{string(meta.Find)},
+			},
+		},
+		{
+			bizID: 2,
+			permission: metadata.AuthPermission{
+				ResourceType: string(meta.HostInstance),
+				Actions:      []string{metadata.AuthPermissionAny},
+				InstanceIDs:  []string{"10", "11"},
+			},
+		},
+		{
+			bizID: 3,
+			permission: metadata.AuthPermission{
+				ResourceType: string(meta.Business),
+				Actions:      []string{string(meta.Update)},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		resource meta.ResourceAttribute
+		allow    bool
+	}{
+		{
+			name:     "global find",
+			resource: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.Find}, BusinessID: 5},
+			allow:    true,
+		},
+		{
+			name:     "batch action",
+			resource: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.FindMany}},
+			allow:    true,
+		},
+		{
+			name:     "action not granted",
+			resource: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Model, Action: meta.Delete}},
+			allow:    false,
+		},
+		{
+			name:     "instance in business",
+			resource: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update, InstanceID: 10}, BusinessID: 2},
+			allow:    true,
+		},
+		{
+			name:     "instance not granted",
+			resource: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update, InstanceID: 12}, BusinessID: 2},
+			allow:    false,
+		},
+		{
+			name:     "instance in other business",
+			resource: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update, InstanceID: 10}, BusinessID: 3},
+			allow:    false,
+		},
+		{
+			name:     "no instance with limited grant",
+			resource: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Create}, BusinessID: 2},
+			allow:    false,
+		},
+		{
+			name:     "business itself",
+			resource: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Business, Action: meta.Update, InstanceID: 3}},
+			allow:    true,
+		},
+		{
+			name:     "other business",
+			resource: meta.ResourceAttribute{Basic: meta.Basic{Type: meta.Business, Action: meta.Update, InstanceID: 4}},
+			allow:    false,
+		},
+	}
+
+	for _, test := range tests {
+		if allow := userGrants.allow(&test.resource); allow != test.allow {
+			t.Errorf("%s: expect allow %v, but got %v", test.name, test.allow, allow)
+		}
+	}
+}
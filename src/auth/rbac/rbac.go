@@ -0,0 +1,245 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package rbac is a built-in role based access control decider, which stores the roles and
+// role bindings in mongodb, and it can be used when the blueking's auth center is not deployed.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/builtin"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// NewRBAC create a role based access control decider.
+func NewRBAC(cfg authcenter.AuthConfig, db dal.RDB) *RBAC {
+	admins := make(map[string]bool)
+	for _, admin := range cfg.Admins {
+		admins[admin] = true
+	}
+	if len(admins) == 0 {
+		blog.Warnf("local authorizer is used, but no admins is configured, only the users with role bindings can access cmdb")
+	}
+
+	return &RBAC{
+		db:     db,
+		admins: admins,
+	}
+}
+
+// RBAC decides a user's permissions by the roles bound to it.
+type RBAC struct {
+	db dal.RDB
+	// the users who have all the permissions.
+	admins map[string]bool
+}
+
+func (r *RBAC) Decide(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Decision, error) {
+	decisions := make([]meta.Decision, len(resources))
+	if r.admins[user.UserName] {
+		for i := range decisions {
+			decisions[i].Authorized = true
+		}
+		return decisions, nil
+	}
+
+	userGrants, err := r.getGrants(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range resources {
+		if userGrants.allow(&resources[i]) {
+			decisions[i].Authorized = true
+			continue
+		}
+		decisions[i].Reason = fmt.Sprintf("user %s has no permission to %s %s", user.UserName,
+			resources[i].Action, resources[i].Type)
+	}
+
+	return decisions, nil
+}
+
+func (r *RBAC) AuthorizedInstances(ctx context.Context, user meta.UserInfo, bizID int64, resourceType meta.ResourceType,
+	action meta.Action) (*builtin.InstanceScope, error) {
+
+	if r.admins[user.UserName] {
+		return &builtin.InstanceScope{All: true}, nil
+	}
+
+	userGrants, err := r.getGrants(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	exist := make(map[string]bool)
+	ids := make([]string, 0)
+	for _, one := range userGrants {
+		if !one.matchResource(resourceType, action) {
+			continue
+		}
+
+		instanceIDs := one.permission.InstanceIDs
+		switch {
+		case resourceType == meta.Business && one.bizID != 0:
+			// a business scope role binding can only access the business it's bound to.
+			instanceIDs = []string{util.GetStrByInterface(one.bizID)}
+			if len(one.permission.InstanceIDs) != 0 && !util.InArray(instanceIDs[0], one.permission.InstanceIDs) {
+				continue
+			}
+		case one.bizID != 0 && one.bizID != bizID:
+			continue
+		case len(instanceIDs) == 0:
+			return &builtin.InstanceScope{All: true}, nil
+		}
+
+		for _, id := range instanceIDs {
+			if !exist[id] {
+				exist[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return &builtin.InstanceScope{IDs: ids}, nil
+}
+
+func (r *RBAC) AnyAuthorizedBusinesses(ctx context.Context, user meta.UserInfo) ([]int64, bool, error) {
+	if r.admins[user.UserName] {
+		return nil, true, nil
+	}
+
+	bindings, err := r.getRoleBindings(ctx, user)
+	if err != nil {
+		return nil, false, err
+	}
+
+	exist := make(map[int64]bool)
+	businessIDs := make([]int64, 0)
+	for _, binding := range bindings {
+		if binding.BizID == 0 {
+			// a global role binding works for all the businesses.
+			return nil, true, nil
+		}
+
+		if !exist[binding.BizID] {
+			exist[binding.BizID] = true
+			businessIDs = append(businessIDs, binding.BizID)
+		}
+	}
+
+	return businessIDs, false, nil
+}
+
+// GroupMembers returns the users which is bound to the roles in the business, the groups is the role names.
+func (r *RBAC) GroupMembers(ctx context.Context, header http.Header, bizID int64, groups []string) ([]authcenter.UserGroupMembers, error) {
+	roles := make([]metadata.AuthRole, 0)
+	roleCond := map[string]interface{}{
+		common.BKFieldName: map[string]interface{}{common.BKDBIN: groups},
+	}
+	roleCond = util.SetModOwner(roleCond, util.GetOwnerID(header))
+	if err := r.db.Table(common.BKTableNameAuthRole).Find(roleCond).All(ctx, &roles); err != nil {
+		return nil, fmt.Errorf("get roles by name failed, err: %v", err)
+	}
+
+	members := make([]authcenter.UserGroupMembers, 0)
+	for _, role := range roles {
+		bindings := make([]metadata.AuthRoleBinding, 0)
+		bindingCond := map[string]interface{}{
+			common.BKRoleIDField: role.ID,
+			common.BKAppIDField:  bizID,
+		}
+		if err := r.db.Table(common.BKTableNameAuthRoleBinding).Find(bindingCond).All(ctx, &bindings); err != nil {
+			return nil, fmt.Errorf("get role %d bindings failed, err: %v", role.ID, err)
+		}
+
+		users := make([]string, len(bindings))
+		for idx, binding := range bindings {
+			users[idx] = binding.UserName
+		}
+		members = append(members, authcenter.UserGroupMembers{ID: role.ID, Name: role.Name, Users: users})
+	}
+
+	return members, nil
+}
+
+// getRoleBindings get the user's role bindings, user's supplier account is not
+// used as a condition when it's empty.
+func (r *RBAC) getRoleBindings(ctx context.Context, user meta.UserInfo) ([]metadata.AuthRoleBinding, error) {
+	cond := map[string]interface{}{
+		common.BKUserNameField: user.UserName,
+	}
+	if len(user.SupplierAccount) != 0 {
+		cond = util.SetModOwner(cond, user.SupplierAccount)
+	}
+
+	bindings := make([]metadata.AuthRoleBinding, 0)
+	if err := r.db.Table(common.BKTableNameAuthRoleBinding).Find(cond).All(ctx, &bindings); err != nil {
+		blog.Errorf("get user %s role bindings failed, err: %v, rid: %s", user.UserName, err,
+			util.ExtractRequestIDFromContext(ctx))
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// getGrants get all the permissions which is granted to the user with it's role bindings.
+func (r *RBAC) getGrants(ctx context.Context, user meta.UserInfo) (grants, error) {
+	bindings, err := r.getRoleBindings(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bindings) == 0 {
+		return make(grants, 0), nil
+	}
+
+	roleIDs := make([]int64, len(bindings))
+	for idx, binding := range bindings {
+		roleIDs[idx] = binding.RoleID
+	}
+
+	roles := make([]metadata.AuthRole, 0)
+	cond := map[string]interface{}{
+		common.BKFieldID: map[string]interface{}{common.BKDBIN: roleIDs},
+	}
+	if err := r.db.Table(common.BKTableNameAuthRole).Find(cond).All(ctx, &roles); err != nil {
+		blog.Errorf("get user %s roles failed, role ids: %v, err: %v, rid: %s", user.UserName, roleIDs, err,
+			util.ExtractRequestIDFromContext(ctx))
+		return nil, err
+	}
+
+	roleMap := make(map[int64]metadata.AuthRole)
+	for _, role := range roles {
+		roleMap[role.ID] = role
+	}
+
+	userGrants := make(grants, 0)
+	for _, binding := range bindings {
+		role, exist := roleMap[binding.RoleID]
+		if !exist {
+			continue
+		}
+		for _, permission := range role.Permissions {
+			userGrants = append(userGrants, grant{bizID: binding.BizID, permission: permission})
+		}
+	}
+	return userGrants, nil
+}
@@ -352,10 +352,18 @@ func (e *Engine) WithAuth(prefixes ...string) (authcenter.AuthConfig, error) {
 		blog.Errorf("parse common config failed, err: %s, data: %s", err.Error(), data)
 		return authcenter.AuthConfig{}, err
 	}
-	authConf[prefix], err = authcenter.ParseConfigFromKV(prefix, conf.ConfigMap)
+	authConfig, err := authcenter.ParseConfigFromKV(prefix, conf.ConfigMap)
 	if err != nil {
 		blog.Errorf("parse auth center config failed: %s", err.Error())
 		return authcenter.AuthConfig{}, err
 	}
+	if authConfig.Type == authcenter.AuthTypeLocal || authConfig.Type == authcenter.AuthTypePolicy {
+		// built-in authorizers use mongodb to store roles and list resource instances.
+		authConfig.Mongo, err = e.WithMongo()
+		if err != nil {
+			return authcenter.AuthConfig{}, err
+		}
+	}
+	authConf[prefix] = authConfig
 	return authConf[prefix], nil
 }
@@ -329,6 +329,12 @@ const (
 	// BKSubscriptionNameField the subscription name field
 	BKSubscriptionNameField = "subscription_name"
 
+	// BKRoleIDField the role id field of the local authorizer's role binding
+	BKRoleIDField = "role_id"
+
+	// BKUserNameField the user name field
+	BKUserNameField = "bk_username"
+
 	// BKOSTypeField the os type field
 	BKOSTypeField = "bk_os_type"
 
@@ -773,6 +779,28 @@ const (
 	// FieldTypeOrganization the organization field type
 	FieldTypeOrganization string = "organization"
 
+	// FieldTypeIPv4 the ipv4 address field type
+	FieldTypeIPv4 string = "ipv4"
+
+	// FieldTypeIPv6 the ipv6 address field type
+	FieldTypeIPv6 string = "ipv6"
+
+	// FieldTypeCIDR the ip network field type, like 10.0.0.0/8
+	FieldTypeCIDR string = "cidr"
+
+	// FieldTypeMultiEnum the enum field type which can select multiple options
+	FieldTypeMultiEnum string = "multi_enum"
+
+	// FieldTypeTable the table field type, the sub-columns of the table is defined in option
+	FieldTypeTable string = "table"
+
+	// FieldTypeComputed the computed field type, the value is evaluated from the expression defined in option
+	FieldTypeComputed string = "computed"
+
+	// FieldTypeSecret the secret field type, like passwords and tokens, the value is encrypted at rest
+	// and masked when it's returned
+	FieldTypeSecret string = "secret"
+
 	// FieldTypeSingleLenChar the single char length limit
 	FieldTypeSingleLenChar int = 256
 
@@ -938,6 +966,9 @@ const (
 	BKHTTPCCRequestID = "Cc_Request_Id"
 	// BKHTTPOtherRequestID esb request id  X-Bkapi-Request-Id
 	BKHTTPOtherRequestID = "X-Bkapi-Request-Id"
+	// BKHTTPCCAuditSourceID the id of the audit log that the request restores from, the audit logs
+	// saved in the request are linked to it.
+	BKHTTPCCAuditSourceID = "Cc_Audit_Source_Id"
 )
 
 // transaction related
@@ -1128,6 +1159,8 @@ const (
 	AttributeUnitMaxLength        = 20
 	AttributeOptionValueMaxLength = 128
 	AttributeOptionArrayMaxLength = 200
+	AttributeTableColumnMaxCount  = 20
+	AttributeTableRowMaxCount     = 200
 	ServiceCategoryMaxLength      = 128
 )
 
@@ -226,6 +226,18 @@ const (
 	// CCErrArrayLengthWrong the length of the array is wrong
 	CCErrArrayLengthWrong = 1199089
 
+	// CCErrCommQueryInvalid the text query is invalid
+	CCErrCommQueryInvalid = 1199090
+
+	// CCErrCommBatchOperationRefInvalid the reference %s of batch operation %d is invalid: %s
+	CCErrCommBatchOperationRefInvalid = 1199091
+
+	// CCErrCommValidationRuleRequired the field %s is required when %s
+	CCErrCommValidationRuleRequired = 1199092
+
+	// CCErrCommValidationRuleFailed the field %s does not satisfy the rule: %s
+	CCErrCommValidationRuleFailed = 1199093
+
 	// too many requests
 	CCErrTooManyRequestErr = 1199997
 
@@ -239,6 +251,8 @@ const (
 	CCErrAPIGetAuthorizedAppListFromAuthFailed = 1100001
 	CCErrAPIGetUserResourceAuthStatusFailed    = 1100002
 	CCErrAPINoObjectInstancesIsFound           = 1100003
+	CCErrAPIAuthExplainNotSupported            = 1100004
+	CCErrAPIAuthExplainFailed                  = 1100005
 
 	// toposerver 1101XXX
 	// CCErrTopoInstCreateFailed unable to create the instance
@@ -466,6 +480,11 @@ const (
 	CCErrorTopoSearchModelAttriFailedPleaseRefresh = 1101101
 
 	CCErrorModelNotFound = 1101102
+
+	// CCErrTopoSchemaBundleInvalid the schema bundle is invalid: %s
+	CCErrTopoSchemaBundleInvalid = 1101103
+	// CCErrTopoSchemaBundleConflict the schema bundle has conflicts with the current models
+	CCErrTopoSchemaBundleConflict = 1101104
 	// object controller 1102XXX
 
 	// CCErrObjectPropertyGroupInsertFailed failed to save the property group
@@ -505,6 +524,14 @@ const (
 	CCErrEventSubscribeTelnetFailed = 1103005
 	// CCErrEventOperateSuccessBUtSentEventFailed failed to sent event
 	CCErrEventPushEventFailed = 1103006
+	// CCErrEventDeadLetterSelectFailed failed to select the event dead letters
+	CCErrEventDeadLetterSelectFailed = 1103007
+	// CCErrEventDeadLetterReplayFailed failed to replay the event dead letters
+	CCErrEventDeadLetterReplayFailed = 1103008
+	// CCErrEventDeadLetterPurgeFailed failed to purge the event dead letters
+	CCErrEventDeadLetterPurgeFailed = 1103009
+	// CCErrEventWatchCursorNotExist the watch cursor is expired or invalid, watch from now on instead
+	CCErrEventWatchCursorNotExist = 1103010
 
 	// host 1104XXX
 	CCErrHostModuleRelationAddFailed = 1104000
@@ -513,6 +540,10 @@ const (
 	//  CCErrCommMigrateFailed failed to migrate
 	CCErrCommMigrateFailed        = 1105000
 	CCErrCommInitAuthCenterFailed = 1105001
+	// CCErrAuthRoleNotExist auth role [%d] does not exist
+	CCErrAuthRoleNotExist = 1105002
+	// CCErrAuthRoleNameDuplicated auth role name [%s] is duplicated
+	CCErrAuthRoleNameDuplicated = 1105003
 
 	// host controller 1106XXX
 	CCErrHostSelectInst                  = 1106000
@@ -672,6 +703,8 @@ const (
 	CCErrHostSetNotBelongBusinessErr                          = 1110060
 	CCErrHostModuleNotBelongBusinessErr                       = 1110061
 	CCErrHostModuleNotBelongSetErr                            = 1110062
+	CCErrHostDynamicGroupNotFound                             = 1110063
+	CCErrHostDynamicGroupObjectNotMatch                       = 1110064
 
 	// web 1111XXX
 	CCErrWebFileNoFound                 = 1111001
@@ -712,6 +745,8 @@ const (
 	CCErrCollectNetHistorySearchFail           = 1112016
 	CCErrCollectNetDeviceUpdateFail            = 1112017
 	CCErrCollectNetPropertyUpdateFail          = 1112018
+	CCErrCollectPushSourceNotFound             = 1112019
+	CCErrCollectPushMessageFail                = 1112020
 
 	// coreservice 1113xxx
 	// CCErrorModelAttributeGroupHasSomeAttributes the group has some attributes
@@ -771,6 +806,27 @@ const (
 	CCErrCoreServiceOnlyNodeServiceCategoryAvailable = 1113032
 	// SearchTopoTreeScanTooManyData means hit too many data, we return directly.
 	SearchTopoTreeScanTooManyData = 1113033
+	// CCErrCoreServiceRecycleBinItemNotFound 回收站记录[%d]不存在
+	CCErrCoreServiceRecycleBinItemNotFound = 1113034
+	// CCErrCoreServiceRecycleBinInstExist 待恢复的实例[%s]已存在
+	CCErrCoreServiceRecycleBinInstExist = 1113035
+	// CCErrCoreServiceRecycleBinParentNotExist 待恢复实例的父节点[%s]不存在
+	CCErrCoreServiceRecycleBinParentNotExist = 1113036
+
+	// CCErrCoreServiceAttributeReferencedByComputed 属性[%s]被计算字段[%s]引用，不允许删除
+	CCErrCoreServiceAttributeReferencedByComputed = 1113037
+
+	// CCErrCoreServiceAttributeUsedByValidationRule 属性[%s]被模型[%s]的校验规则使用，不允许删除
+	CCErrCoreServiceAttributeUsedByValidationRule = 1113038
+
+	// CCErrCoreServiceSecretKeyNotConfigured 密文字段的加密密钥未配置
+	CCErrCoreServiceSecretKeyNotConfigured = 1113039
+
+	// CCErrCoreServiceSecretEncryptFailed 加密密文字段[%s]失败
+	CCErrCoreServiceSecretEncryptFailed = 1113040
+
+	// CCErrCoreServiceSecretDecryptFailed 解密密文字段[%s]失败
+	CCErrCoreServiceSecretDecryptFailed = 1113041
 
 	// CCERrrCoreServiceUniqueRuleExist 模型唯一校验规则已经存在
 	CCERrrCoreServiceSameUniqueCheckRuleExist = 1113050
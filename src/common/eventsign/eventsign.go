@@ -0,0 +1,115 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package eventsign signs the event callbacks sent by cmdb event server, and helps the
+// subscribers to verify that the callback is really sent by cmdb.
+//
+// the signature is a hex encoded HMAC-SHA256 of "{timestamp}.{body}" with the subscription's secret,
+// the timestamp is the unix seconds when the callback is sent, it's carried by the TimestampHeader,
+// and the signature is carried by the SignatureHeader with a "sha256=" prefix.
+//
+// a subscriber written in go can verify the callback request like this:
+//
+//	body, _ := ioutil.ReadAll(req.Body)
+//	if err := eventsign.VerifyRequest(secret, req.Header, body, 5*time.Minute); err != nil {
+//		// this is a forged callback, reject it.
+//	}
+//
+// this package only depends on the go standard library, so that it can be imported without
+// introducing other cmdb's dependencies.
+package eventsign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TimestampHeader is the http header which carries the unix seconds when the callback is sent.
+	TimestampHeader = "X-Bkcmdb-Timestamp"
+	// SignatureHeader is the http header which carries the callback's signature.
+	SignatureHeader = "X-Bkcmdb-Signature"
+	// signaturePrefix is the prefix of the signature, which describes the signature algorithm.
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrMissingTimestamp = errors.New("missing event callback timestamp header")
+	ErrInvalidTimestamp = errors.New("invalid event callback timestamp")
+	ErrExpiredTimestamp = errors.New("event callback timestamp is out of the tolerance")
+	ErrMissingSignature = errors.New("missing event callback signature header")
+	ErrInvalidSignature = errors.New("invalid event callback signature")
+)
+
+// Sign returns the signature of the body with the timestamp and secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetHeader sign the body with the secret, and set the timestamp and signature to the header.
+func SetHeader(header http.Header, secret string, timestamp int64, body []byte) {
+	header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	header.Set(SignatureHeader, Sign(secret, timestamp, body))
+}
+
+// Verify checks whether the signature is matched with the body, timestamp and secret.
+func Verify(secret string, timestamp int64, body []byte, signature string) error {
+	if len(signature) == 0 {
+		return ErrMissingSignature
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// VerifyRequest checks the callback request's timestamp and signature headers with the request body.
+// the tolerance is the max allowed time difference between the callback timestamp and now, which is
+// used to avoid replay attacks, a zero tolerance means do not check the timestamp.
+func VerifyRequest(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	ts := header.Get(TimestampHeader)
+	if len(ts) == 0 {
+		return ErrMissingTimestamp
+	}
+
+	timestamp, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	if tolerance > 0 {
+		diff := time.Since(time.Unix(timestamp, 0))
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > tolerance {
+			return ErrExpiredTimestamp
+		}
+	}
+
+	return Verify(secret, timestamp, body, header.Get(SignatureHeader))
+}
@@ -0,0 +1,55 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eventsign
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := "secret"
+	body := []byte(`{"event_type":"instdata"}`)
+	now := time.Now().Unix()
+
+	header := http.Header{}
+	SetHeader(header, secret, now, body)
+
+	if err := VerifyRequest(secret, header, body, time.Minute); err != nil {
+		t.Errorf("verify signed request failed, err: %v", err)
+		return
+	}
+
+	if err := VerifyRequest("another", header, body, time.Minute); err != ErrInvalidSignature {
+		t.Errorf("verify request with wrong secret, expect invalid signature, but got: %v", err)
+		return
+	}
+
+	if err := VerifyRequest(secret, header, []byte(`{}`), time.Minute); err != ErrInvalidSignature {
+		t.Errorf("verify request with modified body, expect invalid signature, but got: %v", err)
+		return
+	}
+
+	expired := http.Header{}
+	SetHeader(expired, secret, now-3600, body)
+	if err := VerifyRequest(secret, expired, body, time.Minute); err != ErrExpiredTimestamp {
+		t.Errorf("verify expired request, expect expired timestamp, but got: %v", err)
+		return
+	}
+
+	if err := VerifyRequest(secret, http.Header{}, body, time.Minute); err != ErrMissingTimestamp {
+		t.Errorf("verify request without header, expect missing timestamp, but got: %v", err)
+		return
+	}
+}
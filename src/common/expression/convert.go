@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"fmt"
+	"math"
+
+	"configcenter/src/common"
+)
+
+// resultTypes is the property types that the evaluated value can be converted to
+var resultTypes = map[string]bool{
+	common.FieldTypeSingleChar: true,
+	common.FieldTypeLongChar:   true,
+	common.FieldTypeInt:        true,
+	common.FieldTypeFloat:      true,
+	common.FieldTypeBool:       true,
+}
+
+// IsResultType returns whether the evaluated value can be converted to the property type
+func IsResultType(propertyType string) bool {
+	return resultTypes[propertyType]
+}
+
+// Convert converts the evaluated value to the value of the property type, number is rounded to int64
+// for int type, null value is kept as nil for all the types.
+func Convert(value interface{}, propertyType string) (interface{}, error) {
+	value = normalize(value)
+	if value == nil {
+		return nil, nil
+	}
+	if isList(value) {
+		return nil, fmt.Errorf("array value can not be converted to %s, use the functions like sum or join instead",
+			propertyType)
+	}
+
+	switch propertyType {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar:
+		return toString(value), nil
+	case common.FieldTypeInt:
+		number, err := toNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("%v is not a valid int", number)
+		}
+		return int64(math.Round(number)), nil
+	case common.FieldTypeFloat:
+		number, err := toNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		return number, nil
+	case common.FieldTypeBool:
+		return truthy(value), nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %s", propertyType)
+	}
+}
@@ -0,0 +1,514 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type node interface {
+	eval(resolve Resolver) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(Resolver) (interface{}, error) {
+	return n.value, nil
+}
+
+type referenceNode struct {
+	ref Reference
+}
+
+func (n *referenceNode) eval(resolve Resolver) (interface{}, error) {
+	value, err := resolve(n.ref)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(value), nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(resolve Resolver) (interface{}, error) {
+	value, err := n.operand.eval(resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "!":
+		return !truthy(value), nil
+	default:
+		if value == nil {
+			return nil, nil
+		}
+		number, err := toNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		return -number, nil
+	}
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) eval(resolve Resolver) (interface{}, error) {
+	left, err := n.left.eval(resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	// logic operators are short-circuit evaluated
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(resolve)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(resolve)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	}
+
+	right, err := n.right.eval(resolve)
+	if err != nil {
+		return nil, err
+	}
+	if isList(left) || isList(right) {
+		return nil, fmt.Errorf("operator %s can not be used on array value, use the functions like sum instead", n.op)
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compareOperator(n.op, left, right)
+	case "+":
+		_, leftIsStr := left.(string)
+		_, rightIsStr := right.(string)
+		if leftIsStr || rightIsStr {
+			return toString(left) + toString(right), nil
+		}
+	}
+
+	// the arithmetic of null value is null
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	x, err := toNumber(left)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toNumber(right)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/":
+		if y == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return x / y, nil
+	case "%":
+		if y == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(x, y), nil
+	default:
+		return nil, fmt.Errorf("unknown operator %s", n.op)
+	}
+}
+
+type callNode struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (n *callNode) eval(resolve Resolver) (interface{}, error) {
+	if n.fn.lazy != nil {
+		return n.fn.lazy(n.args, resolve)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for idx, arg := range n.args {
+		value, err := arg.eval(resolve)
+		if err != nil {
+			return nil, err
+		}
+		args[idx] = value
+	}
+	value, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("function %s failed, %v", n.name, err)
+	}
+	return value, nil
+}
+
+type function struct {
+	minArgs int
+	// maxArgs is the max argument count, -1 means no limit
+	maxArgs int
+	call    func(args []interface{}) (interface{}, error)
+	// lazy is used by the functions whose arguments are evaluated on demand, like if
+	lazy func(args []node, resolve Resolver) (interface{}, error)
+}
+
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		"sum":      {minArgs: 1, maxArgs: -1, call: sumFunc},
+		"avg":      {minArgs: 1, maxArgs: -1, call: avgFunc},
+		"min":      {minArgs: 1, maxArgs: -1, call: minMaxFunc(-1)},
+		"max":      {minArgs: 1, maxArgs: -1, call: minMaxFunc(1)},
+		"count":    {minArgs: 1, maxArgs: -1, call: countFunc},
+		"concat":   {minArgs: 1, maxArgs: -1, call: concatFunc},
+		"join":     {minArgs: 2, maxArgs: 2, call: joinFunc},
+		"upper":    {minArgs: 1, maxArgs: 1, call: stringFunc(strings.ToUpper)},
+		"lower":    {minArgs: 1, maxArgs: 1, call: stringFunc(strings.ToLower)},
+		"trim":     {minArgs: 1, maxArgs: 1, call: stringFunc(strings.TrimSpace)},
+		"round":    {minArgs: 1, maxArgs: 2, call: roundFunc},
+		"if":       {minArgs: 3, maxArgs: 3, lazy: ifFunc},
+		"coalesce": {minArgs: 1, maxArgs: -1, lazy: coalesceFunc},
+	}
+}
+
+// flatten flattens the array arguments, the null values are skipped
+func flatten(args []interface{}) []interface{} {
+	values := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if list, ok := arg.([]interface{}); ok {
+			values = append(values, flatten(list)...)
+			continue
+		}
+		if arg != nil {
+			values = append(values, arg)
+		}
+	}
+	return values
+}
+
+func sumFunc(args []interface{}) (interface{}, error) {
+	sum := float64(0)
+	for _, value := range flatten(args) {
+		number, err := toNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		sum += number
+	}
+	return sum, nil
+}
+
+func avgFunc(args []interface{}) (interface{}, error) {
+	values := flatten(args)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	sum, err := sumFunc(values)
+	if err != nil {
+		return nil, err
+	}
+	return sum.(float64) / float64(len(values)), nil
+}
+
+func minMaxFunc(sign float64) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		var result interface{}
+		for _, value := range flatten(args) {
+			number, err := toNumber(value)
+			if err != nil {
+				return nil, err
+			}
+			if result == nil || (number-result.(float64))*sign > 0 {
+				result = number
+			}
+		}
+		return result, nil
+	}
+}
+
+func countFunc(args []interface{}) (interface{}, error) {
+	return float64(len(flatten(args))), nil
+}
+
+func concatFunc(args []interface{}) (interface{}, error) {
+	var builder strings.Builder
+	for _, value := range flatten(args) {
+		builder.WriteString(toString(value))
+	}
+	return builder.String(), nil
+}
+
+func joinFunc(args []interface{}) (interface{}, error) {
+	separator, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("separator should be string")
+	}
+	values := flatten(args[:1])
+	items := make([]string, len(values))
+	for idx, value := range values {
+		items[idx] = toString(value)
+	}
+	return strings.Join(items, separator), nil
+}
+
+func stringFunc(convert func(string) string) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		if isList(args[0]) {
+			return nil, fmt.Errorf("argument should not be array")
+		}
+		return convert(toString(args[0])), nil
+	}
+}
+
+func roundFunc(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	number, err := toNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	places := float64(0)
+	if len(args) > 1 {
+		if places, err = toNumber(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	pow := math.Pow(10, math.Trunc(places))
+	return math.Round(number*pow) / pow, nil
+}
+
+func ifFunc(args []node, resolve Resolver) (interface{}, error) {
+	cond, err := args[0].eval(resolve)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(cond) {
+		return args[1].eval(resolve)
+	}
+	return args[2].eval(resolve)
+}
+
+func coalesceFunc(args []node, resolve Resolver) (interface{}, error) {
+	for _, arg := range args {
+		value, err := arg.eval(resolve)
+		if err != nil {
+			return nil, err
+		}
+		if !isEmpty(value) {
+			return value, nil
+		}
+	}
+	return nil, nil
+}
+
+// normalize converts the field value to the value types used in evaluation, which are nil, float64, string,
+// bool and []interface{}. the values of the other types are kept as they are.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, float64, string, bool:
+		return value
+	case json.Number:
+		if number, err := v.Float64(); err == nil {
+			return number
+		}
+		return v.String()
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			list[i] = normalize(rv.Index(i).Interface())
+		}
+		return list
+	}
+	return value
+}
+
+func isList(value interface{}) bool {
+	_, ok := value.([]interface{})
+	return ok
+}
+
+func isEmpty(value interface{}) bool {
+	if value == nil || value == "" {
+		return true
+	}
+	list, ok := value.([]interface{})
+	return ok && len(list) == 0
+}
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) != 0
+	default:
+		return true
+	}
+}
+
+func toNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", v)
+		}
+		return number, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%v is not a number", value)
+	}
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if result, err := compare(left, right); err == nil {
+		return result == 0
+	}
+	return false
+}
+
+func compare(left, right interface{}) (int, error) {
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			break
+		}
+		switch {
+		case l < r:
+			return -1, nil
+		case l > r:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			break
+		}
+		return strings.Compare(l, r), nil
+	case bool:
+		r, ok := right.(bool)
+		if !ok || l != r {
+			break
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("%v and %v can not be compared", left, right)
+}
+
+func compareOperator(op string, left, right interface{}) (interface{}, error) {
+	// comparing with null value is always false
+	if left == nil || right == nil {
+		return false, nil
+	}
+	result, err := compare(left, right)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	default:
+		return result >= 0, nil
+	}
+}
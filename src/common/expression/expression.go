@@ -0,0 +1,154 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package expression implements the expression of the computed attribute, the value of a computed
+// attribute is evaluated from the expression over the instance's own fields, and the fields of its
+// parent mainline instance, its business or its associated instances.
+//
+// an expression is like `bk_host_name + "-" + $biz.bk_biz_name` or `sum(disks.size)`, it supports:
+//   - literals: numbers, strings in double or single quotes, true, false and null
+//   - field references: `field` refers to the instance's own field, `table.column` refers to the column
+//     values of all rows of a table field, `$parent.field` refers to the field of the parent mainline
+//     instance, `$biz.field` refers to the field of the business that the instance belongs to, and
+//     `$asst.obj_id.field` refers to the field values of all the associated instances of the object
+//   - operators: + - * / % for numbers, + for string concatenation, == != < <= > >= for comparison,
+//     && || ! for logic, and parentheses
+//   - functions: sum, avg, min, max, count, concat, join, upper, lower, trim, round, if and coalesce
+package expression
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxDepth is the max nesting depth of an expression
+	MaxDepth = 32
+)
+
+// Scope is the scope of the field that a reference refers to
+type Scope string
+
+const (
+	// ScopeSelf refers to the instance's own field
+	ScopeSelf Scope = "self"
+	// ScopeParent refers to the field of the parent mainline instance
+	ScopeParent Scope = "parent"
+	// ScopeBiz refers to the field of the business that the instance belongs to
+	ScopeBiz Scope = "biz"
+	// ScopeAsst refers to the field of the associated instances of an object
+	ScopeAsst Scope = "asst"
+)
+
+// Reference is a field referenced by the expression
+type Reference struct {
+	Scope Scope
+	// ObjectID is the associated object's id, only used by asst scope
+	ObjectID string
+	// Field is the referenced field, the self scope field can be a table column like "disks.size"
+	Field string
+}
+
+// String returns the reference as it's written in expression
+func (r Reference) String() string {
+	switch r.Scope {
+	case ScopeParent, ScopeBiz:
+		return fmt.Sprintf("$%s.%s", r.Scope, r.Field)
+	case ScopeAsst:
+		return fmt.Sprintf("$%s.%s.%s", r.Scope, r.ObjectID, r.Field)
+	default:
+		return r.Field
+	}
+}
+
+// PropertyID returns the property id of the referenced field, which is the table field of a column reference
+func (r Reference) PropertyID() string {
+	if r.Scope != ScopeSelf {
+		return r.Field
+	}
+	return strings.SplitN(r.Field, ".", 2)[0]
+}
+
+// parseReference parse the reference text into Reference
+func parseReference(text string) (Reference, error) {
+	if !strings.HasPrefix(text, "$") {
+		return Reference{Scope: ScopeSelf, Field: text}, nil
+	}
+
+	parts := strings.Split(text[1:], ".")
+	for _, part := range parts {
+		if part == "" {
+			return Reference{}, fmt.Errorf("invalid reference %s", text)
+		}
+	}
+	switch Scope(parts[0]) {
+	case ScopeParent, ScopeBiz:
+		if len(parts) != 2 {
+			return Reference{}, fmt.Errorf("invalid reference %s, should be like $%s.field", text, parts[0])
+		}
+		return Reference{Scope: Scope(parts[0]), Field: parts[1]}, nil
+	case ScopeAsst:
+		if len(parts) != 3 {
+			return Reference{}, fmt.Errorf("invalid reference %s, should be like $asst.obj_id.field", text)
+		}
+		return Reference{Scope: ScopeAsst, ObjectID: parts[1], Field: parts[2]}, nil
+	default:
+		return Reference{}, fmt.Errorf("unknown reference %s, should be $parent, $biz or $asst", text)
+	}
+}
+
+// Resolver returns the value of the referenced field, the value of a table column or associated instances'
+// field should be returned as an array, and nil should be returned if the field does not exist.
+type Resolver func(ref Reference) (interface{}, error)
+
+// Expression is a parsed expression
+type Expression struct {
+	raw  string
+	root node
+	refs []Reference
+}
+
+// Parse parse the expression text
+func Parse(text string) (*Expression, error) {
+	p := &parser{lexer: newLexer(text)}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.token.kind == tokenEOF {
+		return nil, &Error{Line: 1, Column: 1, Msg: "empty expression"}
+	}
+
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != tokenEOF {
+		return nil, p.errorf(p.token, "unexpected %s, expect an operator", p.token)
+	}
+
+	return &Expression{raw: text, root: root, refs: p.refs}, nil
+}
+
+// String returns the expression text
+func (e *Expression) String() string {
+	return e.raw
+}
+
+// References returns the distinct fields referenced by the expression, in the order they appear
+func (e *Expression) References() []Reference {
+	return e.refs
+}
+
+// Evaluate evaluates the expression with the referenced field values returned by resolve
+func (e *Expression) Evaluate(resolve Resolver) (interface{}, error) {
+	return e.root.eval(resolve)
+}
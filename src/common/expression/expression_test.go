@@ -0,0 +1,152 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"errors"
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReferences(t *testing.T) {
+	expr, err := Parse(`bk_host_name + "-" + $biz.bk_biz_name + concat($parent.bk_set_name, $asst.switch.bk_inst_name, disks.size, bk_host_name)`)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []Reference{
+		{Scope: ScopeSelf, Field: "bk_host_name"},
+		{Scope: ScopeBiz, Field: "bk_biz_name"},
+		{Scope: ScopeParent, Field: "bk_set_name"},
+		{Scope: ScopeAsst, ObjectID: "switch", Field: "bk_inst_name"},
+		{Scope: ScopeSelf, Field: "disks.size"},
+	}, expr.References())
+	assert.Equal(t, "disks", expr.References()[4].PropertyID())
+	assert.Equal(t, "$asst.switch.bk_inst_name", expr.References()[3].String())
+
+	invalid := []string{
+		``,
+		`a +`,
+		`(a + b`,
+		`$parent.a.b`,
+		`$asst.switch`,
+		`$host.name`,
+		`unknown(a)`,
+		`join(a)`,
+		`"unterminated`,
+		`a # b`,
+		`a b`,
+	}
+	for _, text := range invalid {
+		_, err := Parse(text)
+		assert.NotNil(t, err, text)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	fields := map[string]interface{}{
+		"bk_host_name":         "web",
+		"bk_cpu":               int64(8),
+		"bk_mem":               float64(2048.5),
+		"disks.size":           []interface{}{int64(100), int64(500), nil},
+		"$parent.bk_set_name":  "set-1",
+		"$biz.bk_biz_operator": "admin",
+		"$asst.switch.port":    []interface{}{"eth0", "eth1"},
+	}
+	resolve := func(ref Reference) (interface{}, error) {
+		return fields[ref.String()], nil
+	}
+
+	cases := map[string]interface{}{
+		`bk_host_name + "-prod"`:  "web-prod",
+		`bk_cpu * 2 + 1`:          float64(17),
+		`(bk_cpu + 2) * 2`:        float64(20),
+		`bk_cpu % 3`:              float64(2),
+		`-bk_cpu`:                 float64(-8),
+		`sum(disks.size)`:         float64(600),
+		`avg(disks.size)`:         float64(300),
+		`max(disks.size, bk_cpu)`: float64(500),
+		`min(disks.size)`:         float64(100),
+		`count(disks.size)`:       float64(2),
+		`bk_host_name + bk_cpu`:   "web8",
+		`concat(bk_host_name, "@", $parent.bk_set_name)`:             "web@set-1",
+		`join($asst.switch.port, ";")`:                               "eth0;eth1",
+		`upper(bk_host_name)`:                                        "WEB",
+		`round(bk_mem / 3, 2)`:                                       float64(682.83),
+		`if(bk_cpu >= 8 && bk_host_name == "web", "large", "small")`: "large",
+		`coalesce(bk_comment, $biz.bk_biz_operator)`:                 "admin",
+		`bk_comment + 1`:                                             nil,
+		`bk_comment == null`:                                         true,
+		`!(bk_cpu > 4) || false`:                                     false,
+		`bk_comment < 1`:                                             false,
+	}
+	for text, expect := range cases {
+		expr, err := Parse(text)
+		if !assert.Nil(t, err, text) {
+			continue
+		}
+		value, err := expr.Evaluate(resolve)
+		assert.Nil(t, err, text)
+		assert.Equal(t, expect, value, text)
+	}
+
+	failed := []string{
+		`bk_cpu / 0`,
+		`disks.size + 1`,
+		`bk_host_name * 2`,
+		`bk_host_name > 1`,
+	}
+	for _, text := range failed {
+		expr, err := Parse(text)
+		if !assert.Nil(t, err, text) {
+			continue
+		}
+		_, err = expr.Evaluate(resolve)
+		assert.NotNil(t, err, text)
+	}
+
+	expr, _ := Parse(`$biz.bk_biz_name`)
+	_, err := expr.Evaluate(func(Reference) (interface{}, error) { return nil, errors.New("db error") })
+	assert.NotNil(t, err)
+}
+
+func TestConvert(t *testing.T) {
+	value, err := Convert(float64(2.6), common.FieldTypeInt)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), value)
+
+	value, err = Convert(float64(16), common.FieldTypeSingleChar)
+	assert.Nil(t, err)
+	assert.Equal(t, "16", value)
+
+	value, err = Convert("1.5", common.FieldTypeFloat)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1.5), value)
+
+	value, err = Convert("", common.FieldTypeBool)
+	assert.Nil(t, err)
+	assert.Equal(t, false, value)
+
+	value, err = Convert(nil, common.FieldTypeInt)
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	_, err = Convert([]interface{}{1}, common.FieldTypeSingleChar)
+	assert.NotNil(t, err)
+	_, err = Convert("a", common.FieldTypeInt)
+	assert.NotNil(t, err)
+	_, err = Convert("a", common.FieldTypeEnum)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,336 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error is the error of expression parsing, with the position where the error occurs.
+type Error struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	line   int
+	column int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+type lexer struct {
+	input  string
+	pos    int
+	line   int
+	column int
+}
+
+func newLexer(input string) *lexer {
+	return &lexer{input: input, line: 1, column: 1}
+}
+
+func (l *lexer) peek() rune {
+	if l.pos >= len(l.input) {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.pos:])
+	return r
+}
+
+func (l *lexer) advance() rune {
+	r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+	l.pos += size
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return r
+}
+
+// twoCharSymbols is the symbols with two characters, the single character symbols are in oneCharSymbols
+var twoCharSymbols = map[string]bool{"==": true, "!=": true, "<=": true, ">=": true, "&&": true, "||": true}
+
+const oneCharSymbols = "+-*/%<>!(),"
+
+func (l *lexer) nextToken() (token, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(l.peek()) {
+		l.advance()
+	}
+
+	tok := token{line: l.line, column: l.column}
+	if l.pos >= len(l.input) {
+		tok.kind = tokenEOF
+		return tok, nil
+	}
+
+	start := l.pos
+	r := l.advance()
+	switch {
+	case r == '"' || r == '\'':
+		var builder strings.Builder
+		for {
+			if l.pos >= len(l.input) {
+				return tok, &Error{Line: tok.line, Column: tok.column, Msg: "unterminated string"}
+			}
+			c := l.advance()
+			if c == r {
+				break
+			}
+			if c == '\\' {
+				if l.pos >= len(l.input) {
+					return tok, &Error{Line: tok.line, Column: tok.column, Msg: "unterminated string"}
+				}
+				c = l.advance()
+				switch c {
+				case 'n':
+					c = '\n'
+				case 't':
+					c = '\t'
+				}
+			}
+			builder.WriteRune(c)
+		}
+		tok.kind = tokenString
+		tok.text = builder.String()
+	case unicode.IsDigit(r):
+		for l.pos < len(l.input) && (unicode.IsDigit(l.peek()) || l.peek() == '.') {
+			l.advance()
+		}
+		tok.kind = tokenNumber
+		tok.text = l.input[start:l.pos]
+	case unicode.IsLetter(r) || r == '_' || r == '$':
+		for l.pos < len(l.input) && isIdentRune(l.peek()) {
+			l.advance()
+		}
+		tok.kind = tokenIdent
+		tok.text = l.input[start:l.pos]
+	case l.pos < len(l.input) && twoCharSymbols[string(r)+string(l.peek())]:
+		l.advance()
+		tok.kind = tokenSymbol
+		tok.text = l.input[start:l.pos]
+	case strings.ContainsRune(oneCharSymbols, r):
+		tok.kind = tokenSymbol
+		tok.text = string(r)
+	default:
+		return tok, &Error{Line: tok.line, Column: tok.column, Msg: fmt.Sprintf("unexpected character %q", r)}
+	}
+	return tok, nil
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// binaryPrecedence is the precedence of the binary operators, the greater binds tighter
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+type parser struct {
+	lexer *lexer
+	token token
+	depth int
+	refs  []Reference
+}
+
+func (p *parser) next() error {
+	tok, err := p.lexer.nextToken()
+	if err != nil {
+		return err
+	}
+	p.token = tok
+	return nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &Error{Line: tok.line, Column: tok.column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isSymbol(symbol string) bool {
+	return p.token.kind == tokenSymbol && p.token.text == symbol
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	if !p.isSymbol(symbol) {
+		return p.errorf(p.token, "unexpected %s, expect %q", p.token, symbol)
+	}
+	return p.next()
+}
+
+// parseExpr parse the binary expression whose operators bind tighter than minPrecedence
+func (p *parser) parseExpr(minPrecedence int) (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > MaxDepth {
+		return nil, p.errorf(p.token, "exceed max expression depth %d", MaxDepth)
+	}
+
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.token.kind == tokenSymbol {
+		op := p.token.text
+		precedence, ok := binaryPrecedence[op]
+		if !ok || precedence <= minPrecedence {
+			break
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseExpr(precedence)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isSymbol("!") || p.isSymbol("-") {
+		op := p.token.text
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.token
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %s", tok)
+		}
+		return &literalNode{value: value}, p.next()
+	case tokenString:
+		return &literalNode{value: tok.text}, p.next()
+	case tokenIdent:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if p.isSymbol("(") {
+			return p.parseCall(tok)
+		}
+		ref, err := parseReference(tok.text)
+		if err != nil {
+			return nil, p.errorf(tok, "%v", err)
+		}
+		p.addReference(ref)
+		return &referenceNode{ref: ref}, nil
+	case tokenSymbol:
+		if tok.text == "(" {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			expr, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			return expr, p.expectSymbol(")")
+		}
+	}
+	return nil, p.errorf(tok, "unexpected %s, expect a value", tok)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, exists := functions[name.text]
+	if !exists {
+		return nil, p.errorf(name, "unknown function %s", name.text)
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+
+	args := make([]node, 0)
+	for !p.isSymbol(")") {
+		if len(args) > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, p.errorf(name, "invalid argument count %d of function %s", len(args), name.text)
+	}
+	return &callNode{name: name.text, fn: fn, args: args}, nil
+}
+
+func (p *parser) addReference(ref Reference) {
+	for _, exist := range p.refs {
+		if exist == ref {
+			return
+		}
+	}
+	p.refs = append(p.refs, ref)
+}
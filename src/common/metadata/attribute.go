@@ -23,7 +23,10 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/expression"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/querybuilder"
+	"configcenter/src/common/secret"
 	"configcenter/src/common/util"
 
 	"github.com/tidwall/gjson"
@@ -49,6 +52,7 @@ const (
 	AttributeFieldIsAPI           = "bk_isapi"
 	AttributeFieldPropertyType    = "bk_property_type"
 	AttributeFieldOption          = "option"
+	AttributeFieldDefault         = "default"
 	AttributeFieldDescription     = "description"
 	AttributeFieldCreator         = "creator"
 	AttributeFieldCreateTime      = "create_time"
@@ -77,6 +81,7 @@ type Attribute struct {
 	IsAPI             bool        `field:"bk_isapi" json:"bk_isapi" bson:"bk_isapi"`
 	PropertyType      string      `field:"bk_property_type" json:"bk_property_type" bson:"bk_property_type"`
 	Option            interface{} `field:"option" json:"option" bson:"option"`
+	Default           interface{} `field:"default" json:"default" bson:"default"`
 	Description       string      `field:"description" json:"description" bson:"description"`
 	BizID             int64       `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
 
@@ -117,6 +122,8 @@ func (attribute *Attribute) ToMapStr() mapstr.MapStr {
 type ObjAttDes struct {
 	Attribute         `json:",inline" bson:",inline"`
 	PropertyGroupName string `json:"bk_property_group_name"`
+	// ComputedDependencies is the fields that the computed attribute depends on, only set for computed attribute
+	ComputedDependencies *ComputedDependencies `json:"bk_computed_dependencies,omitempty"`
 }
 
 type HostObjAttDes struct {
@@ -131,12 +138,24 @@ func (attribute *Attribute) Validate(ctx context.Context, data interface{}, key
 		rawError = attribute.validChar(ctx, data, key)
 	case common.FieldTypeLongChar:
 		rawError = attribute.validLongChar(ctx, data, key)
+	case common.FieldTypeSecret:
+		rawError = attribute.validSecret(ctx, data, key)
 	case common.FieldTypeInt:
 		rawError = attribute.validInt(ctx, data, key)
 	case common.FieldTypeFloat:
 		rawError = attribute.validFloat(ctx, data, key)
 	case common.FieldTypeEnum:
 		rawError = attribute.validEnum(ctx, data, key)
+	case common.FieldTypeMultiEnum:
+		rawError = attribute.validMultiEnum(ctx, data, key)
+	case common.FieldTypeTable:
+		rawError = attribute.validTable(ctx, data, key)
+	case common.FieldTypeComputed:
+		// the value of computed attribute is evaluated by server, it can not be set
+		rawError = errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
 	case common.FieldTypeDate:
 		rawError = attribute.validDate(ctx, data, key)
 	case common.FieldTypeTime:
@@ -151,6 +170,8 @@ func (attribute *Attribute) Validate(ctx context.Context, data interface{}, key
 		rawError = attribute.validList(ctx, data, key)
 	case common.FieldTypeOrganization:
 		rawError = attribute.validOrganization(ctx, data, key)
+	case common.FieldTypeIPv4, common.FieldTypeIPv6, common.FieldTypeCIDR:
+		rawError = attribute.validNetwork(ctx, data, key)
 	case "foreignkey", "singleasst", "multiasst":
 		// TODO what validation should do on these types
 	default:
@@ -503,6 +524,19 @@ func (attribute *Attribute) validLongChar(ctx context.Context, val interface{},
 	return errors.RawErrorInfo{}
 }
 
+// validSecret valid object attribute that is secret type, the value is limited as long char, and it can't be
+// a ciphertext, which is only generated by server.
+func (attribute *Attribute) validSecret(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	if secret.IsEncrypted(val) {
+		blog.Errorf("secret value can not be a ciphertext, rid: %s", util.ExtractRequestIDFromContext(ctx))
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+	return attribute.validLongChar(ctx, val, key)
+}
+
 // validChar valid object attribute that is char type
 func (attribute *Attribute) validChar(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
 	rid := util.ExtractRequestIDFromContext(ctx)
@@ -710,6 +744,168 @@ func (attribute *Attribute) validOrganization(ctx context.Context, val interface
 	return errors.RawErrorInfo{}
 }
 
+// validNetwork valid object attribute that is ipv4, ipv6 or cidr type
+func (attribute *Attribute) validNetwork(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if nil == val || "" == val {
+		if attribute.IsRequired {
+			blog.Errorf("params can not be null, rid: %s", rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	valStr, ok := val.(string)
+	if !ok {
+		blog.Errorf("params %s should be string, rid: %s", key, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsShouldBeString,
+			Args:    []interface{}{key},
+		}
+	}
+
+	if _, err := util.NormalizeNetworkValue(attribute.PropertyType, valStr); err != nil {
+		blog.Errorf("params %s not valid, err: %v, rid: %s", key, err, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// validMultiEnum valid object attribute that is multi_enum type, the value is a list of enum option ids
+func (attribute *Attribute) validMultiEnum(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	values, ok := toInterfaceSlice(val)
+	if !ok {
+		blog.Errorf("params %s should be array, rid: %s", key, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+
+	if len(values) == 0 {
+		if attribute.IsRequired {
+			blog.Errorf("params can not be null, rid: %s", rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	enumOption, err := ParseEnumOption(ctx, attribute.Option)
+	if err != nil {
+		blog.Warnf("ParseEnumOption failed: %v, rid: %s", err, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+	optionIDs := make(map[string]bool)
+	for _, k := range enumOption {
+		optionIDs[k.ID] = true
+	}
+
+	selected := make(map[string]bool)
+	for _, value := range values {
+		valStr, ok := value.(string)
+		if !ok || !optionIDs[valStr] || selected[valStr] {
+			blog.Errorf("params %s not valid, multi enum value: %#v, rid: %s", key, val, rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{key},
+			}
+		}
+		selected[valStr] = true
+	}
+	return errors.RawErrorInfo{}
+}
+
+// validTable valid object attribute that is table type, the value is a list of rows,
+// each row is validated with the sub-columns defined in the option
+func (attribute *Attribute) validTable(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	rows, ok := toInterfaceSlice(val)
+	if !ok {
+		blog.Errorf("params %s should be array, rid: %s", key, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+
+	if len(rows) == 0 {
+		if attribute.IsRequired {
+			blog.Errorf("params can not be null, rid: %s", rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	if len(rows) > common.AttributeTableRowMaxCount {
+		blog.Errorf("params %s row count %d exceeds max count %d, rid: %s", key, len(rows), common.AttributeTableRowMaxCount, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommValExceedMaxFailed,
+			Args:    []interface{}{key, common.AttributeTableRowMaxCount},
+		}
+	}
+
+	columns, err := ParseTableOption(ctx, attribute.Option)
+	if err != nil {
+		blog.Warnf("ParseTableOption failed: %v, rid: %s", err, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+	columnMap := make(map[string]TableColumn)
+	for _, column := range columns {
+		columnMap[column.PropertyID] = column
+	}
+
+	for idx, item := range rows {
+		rowKey := fmt.Sprintf("%s[%d]", key, idx)
+		row, ok := toMapStr(item)
+		if !ok {
+			blog.Errorf("params %s should be object, rid: %s", rowKey, rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{rowKey},
+			}
+		}
+
+		for columnID := range row {
+			if _, exists := columnMap[columnID]; !exists {
+				blog.Errorf("params %s has unknown column %s, rid: %s", rowKey, columnID, rid)
+				return errors.RawErrorInfo{
+					ErrCode: common.CCErrCommParamsInvalid,
+					Args:    []interface{}{rowKey + "." + columnID},
+				}
+			}
+		}
+
+		for _, column := range columns {
+			columnAttr := column.ToAttribute()
+			rawErr := columnAttr.Validate(ctx, row[column.PropertyID], rowKey+"."+column.PropertyID)
+			if rawErr.ErrCode != 0 {
+				return rawErr
+			}
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
 // parseFloatOption  parse float data in option
 func parseFloatOption(ctx context.Context, val interface{}) FloatOption {
 	rid := util.ExtractRequestIDFromContext(ctx)
@@ -890,6 +1086,276 @@ func parseEnumOption(options []interface{}, enumOptions *[]EnumVal) error {
 	return nil
 }
 
+// TableOption table option, which is the definition list of the table's sub-columns
+type TableOption []TableColumn
+
+// TableColumn the sub-column definition of the table attribute
+type TableColumn struct {
+	PropertyID   string      `bson:"bk_property_id"   json:"bk_property_id"`
+	PropertyName string      `bson:"bk_property_name" json:"bk_property_name"`
+	PropertyType string      `bson:"bk_property_type" json:"bk_property_type"`
+	IsRequired   bool        `bson:"isrequired"       json:"isrequired"`
+	Option       interface{} `bson:"option"           json:"option"`
+}
+
+// ToAttribute converts the sub-column to an attribute, so that the column value can be validated like a property
+func (column TableColumn) ToAttribute() Attribute {
+	return Attribute{
+		PropertyID:   column.PropertyID,
+		PropertyName: column.PropertyName,
+		PropertyType: column.PropertyType,
+		IsRequired:   column.IsRequired,
+		Option:       column.Option,
+	}
+}
+
+// ParseTableOption convert val to []TableColumn
+func ParseTableOption(ctx context.Context, val interface{}) (TableOption, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	tableOption := TableOption{}
+	if nil == val || "" == val {
+		return tableOption, nil
+	}
+
+	switch options := val.(type) {
+	case TableOption:
+		return options, nil
+	case []TableColumn:
+		return options, nil
+	case string:
+		if err := json.Unmarshal([]byte(options), &tableOption); nil != err {
+			blog.Errorf("ParseTableOption error : %s, rid: %s", err.Error(), rid)
+			return nil, err
+		}
+		return tableOption, nil
+	}
+
+	options, ok := toInterfaceSlice(val)
+	if !ok {
+		return nil, fmt.Errorf("unknow val type: %#v", val)
+	}
+	for _, optionVal := range options {
+		option, ok := toMapStr(optionVal)
+		if !ok {
+			return nil, fmt.Errorf("unknow optionVal type: %#v", optionVal)
+		}
+		column := TableColumn{
+			PropertyID:   getString(option[common.BKPropertyIDField]),
+			PropertyName: getString(option[common.BKPropertyNameField]),
+			PropertyType: getString(option[common.BKPropertyTypeField]),
+			IsRequired:   getBool(option["isrequired"]),
+			Option:       option["option"],
+		}
+		if column.PropertyID == "" || column.PropertyType == "" {
+			return nil, fmt.Errorf("table column %#v id or type is empty", option)
+		}
+		tableOption = append(tableOption, column)
+	}
+	return tableOption, nil
+}
+
+// GetTableColumnValues returns the values of the column in all rows of the table value, the missing column is nil
+func GetTableColumnValues(val interface{}, column string) []interface{} {
+	rows, ok := toInterfaceSlice(val)
+	if !ok {
+		return []interface{}{}
+	}
+	values := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		rowValue, ok := toMapStr(row)
+		if !ok {
+			continue
+		}
+		values = append(values, rowValue[column])
+	}
+	return values
+}
+
+// ComputedOption computed attribute option
+type ComputedOption struct {
+	// Expression is the expression that the value is evaluated from
+	Expression string `bson:"expression" json:"expression"`
+	// ResultType is the property type of the evaluated value
+	ResultType string `bson:"result_type" json:"result_type"`
+}
+
+// ParseComputedOption convert val to ComputedOption
+func ParseComputedOption(ctx context.Context, val interface{}) (ComputedOption, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	computedOption := ComputedOption{}
+	switch option := val.(type) {
+	case ComputedOption:
+		return option, nil
+	case string:
+		if err := json.Unmarshal([]byte(option), &computedOption); nil != err {
+			blog.Errorf("ParseComputedOption error : %s, rid: %s", err.Error(), rid)
+			return computedOption, err
+		}
+		return computedOption, nil
+	}
+
+	option, ok := toMapStr(val)
+	if !ok {
+		return computedOption, fmt.Errorf("unknow val type: %#v", val)
+	}
+	computedOption.Expression = getString(option["expression"])
+	computedOption.ResultType = getString(option["result_type"])
+	return computedOption, nil
+}
+
+// ParseComputedExpression parse the option of computed attribute, and returns the option with the parsed expression
+func (attribute Attribute) ParseComputedExpression(ctx context.Context) (ComputedOption, *expression.Expression, error) {
+	option, err := ParseComputedOption(ctx, attribute.Option)
+	if err != nil {
+		return option, nil, err
+	}
+	if !expression.IsResultType(option.ResultType) {
+		return option, nil, fmt.Errorf("computed attribute %s result type %s is invalid", attribute.PropertyID, option.ResultType)
+	}
+	expr, err := expression.Parse(option.Expression)
+	if err != nil {
+		return option, nil, err
+	}
+	return option, expr, nil
+}
+
+// ComputedDependencies the fields that a computed attribute depends on
+type ComputedDependencies struct {
+	// Fields is the instance's own fields, the table column is like "disks.size"
+	Fields []string `json:"fields"`
+	// Parent is the fields of the parent mainline instance
+	Parent []string `json:"parent"`
+	// Biz is the fields of the business that the instance belongs to
+	Biz []string `json:"biz"`
+	// Associations is the fields of the associated instances, the key is the associated object's id
+	Associations map[string][]string `json:"associations"`
+}
+
+// NewComputedDependencies generate the dependencies from the references of the expression
+func NewComputedDependencies(refs []expression.Reference) *ComputedDependencies {
+	dependencies := &ComputedDependencies{
+		Fields:       make([]string, 0),
+		Parent:       make([]string, 0),
+		Biz:          make([]string, 0),
+		Associations: make(map[string][]string),
+	}
+	for _, ref := range refs {
+		switch ref.Scope {
+		case expression.ScopeParent:
+			dependencies.Parent = append(dependencies.Parent, ref.Field)
+		case expression.ScopeBiz:
+			dependencies.Biz = append(dependencies.Biz, ref.Field)
+		case expression.ScopeAsst:
+			dependencies.Associations[ref.ObjectID] = append(dependencies.Associations[ref.ObjectID], ref.Field)
+		default:
+			dependencies.Fields = append(dependencies.Fields, ref.Field)
+		}
+	}
+	return dependencies
+}
+
+// defaultTemplateRegexp matches the placeholders like {{bk_inst_name}} in the default value template
+var defaultTemplateRegexp = regexp.MustCompile(`{{\s*([A-Za-z0-9_]+)\s*}}`)
+
+// IsDefaultTemplate returns whether the default value is a template with the placeholders of other fields
+func IsDefaultTemplate(val interface{}) bool {
+	value, ok := val.(string)
+	return ok && defaultTemplateRegexp.MatchString(value)
+}
+
+// ValidDefault check if the default value of the attribute is valid, the template is only allowed for
+// character attributes, and the literal value must be a valid value of the attribute.
+func (attribute *Attribute) ValidDefault(ctx context.Context) errors.RawErrorInfo {
+	if attribute.Default == nil {
+		return errors.RawErrorInfo{}
+	}
+
+	switch attribute.PropertyType {
+	case common.FieldTypeComputed, common.FieldTypeSecret:
+		// the value of computed attribute is always evaluated, and the default of secret would be readable by
+		// everyone who can see the model
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{AttributeFieldDefault},
+		}
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar:
+		if IsDefaultTemplate(attribute.Default) {
+			return errors.RawErrorInfo{}
+		}
+	default:
+		if IsDefaultTemplate(attribute.Default) {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{AttributeFieldDefault},
+			}
+		}
+	}
+
+	return attribute.Validate(ctx, attribute.Default, AttributeFieldDefault)
+}
+
+// GetDefaultValue returns the default value of the attribute for the instance, the placeholders in the template
+// are replaced with the instance's field values, the missing field is replaced with empty string.
+func (attribute Attribute) GetDefaultValue(inst mapstr.MapStr) interface{} {
+	if !IsDefaultTemplate(attribute.Default) {
+		return attribute.Default
+	}
+	return defaultTemplateRegexp.ReplaceAllStringFunc(attribute.Default.(string), func(placeholder string) string {
+		field := defaultTemplateRegexp.FindStringSubmatch(placeholder)[1]
+		value, exists := inst[field]
+		if !exists || value == nil {
+			return ""
+		}
+		return fmt.Sprintf("%v", value)
+	})
+}
+
+// toInterfaceSlice converts the array value decoded from json or bson to []interface{}, nil is treated as empty array
+func toInterfaceSlice(val interface{}) ([]interface{}, bool) {
+	switch value := val.(type) {
+	case nil:
+		return []interface{}{}, true
+	case []interface{}:
+		return value, true
+	case bson.A:
+		return value, true
+	case []string:
+		result := make([]interface{}, len(value))
+		for idx := range value {
+			result[idx] = value[idx]
+		}
+		return result, true
+	case []map[string]interface{}:
+		result := make([]interface{}, len(value))
+		for idx := range value {
+			result[idx] = value[idx]
+		}
+		return result, true
+	case []mapstr.MapStr:
+		result := make([]interface{}, len(value))
+		for idx := range value {
+			result[idx] = value[idx]
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// toMapStr converts the object value decoded from json or bson to mapstr.MapStr
+func toMapStr(val interface{}) (mapstr.MapStr, bool) {
+	switch value := val.(type) {
+	case mapstr.MapStr:
+		return value, true
+	case map[string]interface{}:
+		return value, true
+	case bson.M:
+		return mapstr.MapStr(value), true
+	case bson.D:
+		return mapstr.MapStr(value.Map()), true
+	}
+	return nil, false
+}
+
 // parseFloatOption  parse float data in option
 func ParseFloatOption(ctx context.Context, val interface{}) FloatOption {
 	rid := util.ExtractRequestIDFromContext(ctx)
@@ -930,6 +1396,9 @@ func (attribute Attribute) PrettyValue(ctx context.Context, val interface{}) (st
 			return "", fmt.Errorf("invalid value type for %s, value: %+v", fieldType, val)
 		}
 		return value, nil
+	case common.FieldTypeSecret:
+		// the secret is never exported, even if the value is not masked by the caller
+		return secret.Mask, nil
 	case common.FieldTypeInt:
 		var value int64
 		value, err := util.GetInt64ByInterface(val)
@@ -960,6 +1429,42 @@ func (attribute Attribute) PrettyValue(ctx context.Context, val interface{}) (st
 			}
 		}
 		return "", fmt.Errorf("invalid value for %s, value: %s", fieldType, valStr)
+	case common.FieldTypeMultiEnum:
+		values, ok := toInterfaceSlice(val)
+		if !ok {
+			return "", fmt.Errorf("invalid value type for %s, value: %+v", fieldType, val)
+		}
+		enumOption, err := ParseEnumOption(ctx, attribute.Option)
+		if err != nil {
+			return "", fmt.Errorf("parse options for multi enum type failed, err: %+v", err)
+		}
+		names := make([]string, 0, len(values))
+		for _, value := range values {
+			valStr, ok := value.(string)
+			if !ok {
+				return "", fmt.Errorf("invalid value type for %s, value: %+v", fieldType, val)
+			}
+			name := ""
+			for _, k := range enumOption {
+				if k.ID == valStr {
+					name = k.Name
+					break
+				}
+			}
+			if name == "" {
+				return "", fmt.Errorf("invalid value for %s, value: %s", fieldType, valStr)
+			}
+			names = append(names, name)
+		}
+		return strings.Join(names, ","), nil
+	case common.FieldTypeComputed:
+		return fmt.Sprintf("%v", val), nil
+	case common.FieldTypeTable:
+		value, err := json.Marshal(val)
+		if err != nil {
+			return "", fmt.Errorf("invalid value for %s, value: %+v, err: %+v", fieldType, val, err)
+		}
+		return string(value), nil
 	case common.FieldTypeDate:
 		valStr, ok := val.(string)
 		if ok == false {
@@ -979,6 +1484,12 @@ func (attribute Attribute) PrettyValue(ctx context.Context, val interface{}) (st
 		default:
 			return "", fmt.Errorf("invalid value type for %s, value: %+v", fieldType, val)
 		}
+	case common.FieldTypeIPv4, common.FieldTypeIPv6, common.FieldTypeCIDR:
+		value, ok := val.(string)
+		if ok == false {
+			return "", fmt.Errorf("invalid value type for %s, value: %+v", fieldType, val)
+		}
+		return value, nil
 	case common.FieldTypeBool:
 		value, ok := val.(bool)
 		if ok == false {
@@ -1059,3 +1570,93 @@ func CheckAllowHostApplyOnField(field string) bool {
 	}
 	return true
 }
+
+// CheckAllowHostApplyOnAttribute 检查属性是否能用于主机属性自动应用, the secret value can not be applied, or its plaintext
+// would be stored in the rules, and the computed value is always evaluated by server.
+func CheckAllowHostApplyOnAttribute(attribute Attribute) bool {
+	if attribute.PropertyType == common.FieldTypeSecret || attribute.PropertyType == common.FieldTypeComputed {
+		return false
+	}
+	return CheckAllowHostApplyOnField(attribute.PropertyID)
+}
+
+// GetSecretPropertyIDs returns the property ids of the secret attributes, their values are masked by them.
+func GetSecretPropertyIDs(attributes []Attribute) []string {
+	propertyIDs := make([]string, 0)
+	for _, attribute := range attributes {
+		if attribute.PropertyType == common.FieldTypeSecret {
+			propertyIDs = append(propertyIDs, attribute.PropertyID)
+		}
+	}
+	return propertyIDs
+}
+
+// DenormalizeNetworkValues converts the stored values of the ipv4, ipv6 and cidr attributes in the instance read
+// from db back to their common forms, the ones in the table columns are converted too.
+func DenormalizeNetworkValues(attributes []Attribute, inst map[string]interface{}) {
+	for _, attribute := range attributes {
+		switch attribute.PropertyType {
+		case common.FieldTypeIPv4, common.FieldTypeIPv6, common.FieldTypeCIDR:
+			if value, ok := inst[attribute.PropertyID].(string); ok {
+				inst[attribute.PropertyID] = util.DenormalizeNetworkValue(attribute.PropertyType, value)
+			}
+		case common.FieldTypeTable:
+			rows, ok := inst[attribute.PropertyID].([]interface{})
+			if !ok || len(rows) == 0 {
+				continue
+			}
+			columns, err := ParseTableOption(context.Background(), attribute.Option)
+			if err != nil {
+				continue
+			}
+			columnAttrs := make([]Attribute, len(columns))
+			for idx, column := range columns {
+				columnAttrs[idx] = column.ToAttribute()
+			}
+			for _, row := range rows {
+				switch rowData := row.(type) {
+				case map[string]interface{}:
+					DenormalizeNetworkValues(columnAttrs, rowData)
+				case mapstr.MapStr:
+					DenormalizeNetworkValues(columnAttrs, rowData)
+				}
+			}
+		}
+	}
+}
+
+// GetQueryFieldTypes returns the property types of the attributes, which is used to validate and convert
+// the querybuilder rules. create_time and last_time is defined as time attributes, but they are stored as time.
+// the sub-columns of table attribute can be queried with dot separator, like "disks.size", the computed
+// attribute is queried as its result type, and the secret attribute can't be queried.
+func GetQueryFieldTypes(attributes []Attribute) querybuilder.FieldTypes {
+	fieldTypes := make(querybuilder.FieldTypes)
+	for _, attribute := range attributes {
+		if attribute.PropertyType == common.FieldTypeSecret {
+			// only the ciphertext of secret is stored, it can't be queried
+			continue
+		}
+		fieldTypes[attribute.PropertyID] = attribute.PropertyType
+		if attribute.PropertyType == common.FieldTypeComputed {
+			// computed value is stored as the value of its result type
+			if option, err := ParseComputedOption(context.Background(), attribute.Option); err == nil {
+				fieldTypes[attribute.PropertyID] = option.ResultType
+			}
+			continue
+		}
+		if attribute.PropertyType != common.FieldTypeTable {
+			continue
+		}
+		columns, err := ParseTableOption(context.Background(), attribute.Option)
+		if err != nil {
+			blog.Warnf("parse table option of attribute %s failed, err: %v", attribute.PropertyID, err)
+			continue
+		}
+		for _, column := range columns {
+			fieldTypes[attribute.PropertyID+"."+column.PropertyID] = column.PropertyType
+		}
+	}
+	fieldTypes[common.CreateTimeField] = querybuilder.FieldTypeDatetime
+	fieldTypes[common.LastTimeField] = querybuilder.FieldTypeDatetime
+	return fieldTypes
+}
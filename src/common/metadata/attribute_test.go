@@ -0,0 +1,330 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/secret"
+	"configcenter/src/common/util"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestValidMultiEnum(t *testing.T) {
+	attribute := Attribute{
+		PropertyID:   "os",
+		PropertyType: common.FieldTypeMultiEnum,
+		Option: []interface{}{
+			map[string]interface{}{"id": "linux", "name": "Linux", "type": "text"},
+			map[string]interface{}{"id": "windows", "name": "Windows", "type": "text"},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		required bool
+		value    interface{}
+		errCode  int
+	}{
+		{name: "one option", value: []interface{}{"linux"}},
+		{name: "all options", value: []interface{}{"linux", "windows"}},
+		{name: "string slice", value: []string{"windows"}},
+		{name: "bson array", value: bson.A{"linux"}},
+		{name: "nil", value: nil},
+		{name: "empty", value: []interface{}{}},
+		{name: "nil but required", required: true, value: nil, errCode: common.CCErrCommParamsNeedSet},
+		{name: "empty but required", required: true, value: []interface{}{}, errCode: common.CCErrCommParamsNeedSet},
+		{name: "not array", value: "linux", errCode: common.CCErrCommParamsInvalid},
+		{name: "unknown option", value: []interface{}{"mac"}, errCode: common.CCErrCommParamsInvalid},
+		{name: "duplicated option", value: []interface{}{"linux", "linux"}, errCode: common.CCErrCommParamsInvalid},
+		{name: "not string option", value: []interface{}{1}, errCode: common.CCErrCommParamsInvalid},
+	}
+
+	for _, testCase := range testCases {
+		attribute.IsRequired = testCase.required
+		rawErr := attribute.Validate(context.Background(), testCase.value, attribute.PropertyID)
+		require.Equal(t, testCase.errCode, rawErr.ErrCode, testCase.name)
+	}
+
+	attribute.Option = "not an enum option"
+	rawErr := attribute.Validate(context.Background(), []interface{}{"linux"}, attribute.PropertyID)
+	require.Equal(t, common.CCErrCommParamsInvalid, rawErr.ErrCode)
+}
+
+func testTableAttribute() Attribute {
+	return Attribute{
+		PropertyID:   "disks",
+		PropertyType: common.FieldTypeTable,
+		Option: []interface{}{
+			map[string]interface{}{
+				common.BKPropertyIDField:   "name",
+				common.BKPropertyNameField: "Name",
+				common.BKPropertyTypeField: common.FieldTypeSingleChar,
+				"isrequired":               true,
+			},
+			map[string]interface{}{
+				common.BKPropertyIDField:   "size",
+				common.BKPropertyNameField: "Size",
+				common.BKPropertyTypeField: common.FieldTypeInt,
+				"option":                   map[string]interface{}{"min": "0", "max": "1024"},
+			},
+			map[string]interface{}{
+				common.BKPropertyIDField:   "ip",
+				common.BKPropertyNameField: "IP",
+				common.BKPropertyTypeField: common.FieldTypeIPv4,
+			},
+		},
+	}
+}
+
+func TestValidTable(t *testing.T) {
+	attribute := testTableAttribute()
+
+	tooManyRows := make([]interface{}, common.AttributeTableRowMaxCount+1)
+	for idx := range tooManyRows {
+		tooManyRows[idx] = map[string]interface{}{"name": "sda"}
+	}
+
+	testCases := []struct {
+		name     string
+		required bool
+		value    interface{}
+		errCode  int
+		errArgs  []interface{}
+	}{
+		{
+			name: "rows",
+			value: []interface{}{
+				map[string]interface{}{"name": "sda", "size": 100, "ip": "10.0.0.1"},
+				mapstr.MapStr{"name": "sdb"},
+			},
+		},
+		{name: "bson rows", value: bson.A{bson.M{"name": "sda", "size": 100}}},
+		{name: "nil", value: nil},
+		{name: "empty", value: []interface{}{}},
+		{name: "empty but required", required: true, value: []interface{}{}, errCode: common.CCErrCommParamsNeedSet},
+		{name: "not array", value: "sda", errCode: common.CCErrCommParamsInvalid, errArgs: []interface{}{"disks"}},
+		{
+			name:    "not object row",
+			value:   []interface{}{"sda"},
+			errCode: common.CCErrCommParamsInvalid,
+			errArgs: []interface{}{"disks[0]"},
+		},
+		{
+			name:    "unknown column",
+			value:   []interface{}{map[string]interface{}{"name": "sda", "type": "ssd"}},
+			errCode: common.CCErrCommParamsInvalid,
+			errArgs: []interface{}{"disks[0].type"},
+		},
+		{
+			name:    "required column is missing",
+			value:   []interface{}{map[string]interface{}{"name": "sda"}, map[string]interface{}{"size": 100}},
+			errCode: common.CCErrCommParamsNeedSet,
+			errArgs: []interface{}{"disks[1].name"},
+		},
+		{
+			name:    "column value out of range",
+			value:   []interface{}{map[string]interface{}{"name": "sda", "size": 2048}},
+			errCode: common.CCErrCommParamsInvalid,
+			errArgs: []interface{}{"disks[0].size"},
+		},
+		{
+			name:    "invalid network column",
+			value:   []interface{}{map[string]interface{}{"name": "sda", "ip": "10.0.0.256"}},
+			errCode: common.CCErrCommParamsInvalid,
+			errArgs: []interface{}{"disks[0].ip"},
+		},
+		{
+			name:    "too many rows",
+			value:   tooManyRows,
+			errCode: common.CCErrCommValExceedMaxFailed,
+			errArgs: []interface{}{"disks", common.AttributeTableRowMaxCount},
+		},
+	}
+
+	for _, testCase := range testCases {
+		attribute.IsRequired = testCase.required
+		rawErr := attribute.Validate(context.Background(), testCase.value, attribute.PropertyID)
+		require.Equal(t, testCase.errCode, rawErr.ErrCode, testCase.name)
+		if testCase.errArgs != nil {
+			require.Equal(t, testCase.errArgs, rawErr.Args, testCase.name)
+		}
+	}
+}
+
+func TestParseTableOption(t *testing.T) {
+	expected := TableOption{
+		{PropertyID: "name", PropertyName: "Name", PropertyType: common.FieldTypeSingleChar, IsRequired: true},
+		{PropertyID: "size", PropertyName: "Size", PropertyType: common.FieldTypeInt,
+			Option: map[string]interface{}{"min": "0", "max": "1024"}},
+	}
+
+	testCases := []struct {
+		name   string
+		option interface{}
+	}{
+		{name: "table option", option: expected},
+		{name: "column slice", option: []TableColumn(expected)},
+		{
+			name: "json string",
+			option: `[{"bk_property_id":"name","bk_property_name":"Name","bk_property_type":"singlechar","isrequired":true},
+				{"bk_property_id":"size","bk_property_name":"Size","bk_property_type":"int","option":{"min":"0","max":"1024"}}]`,
+		},
+		{
+			name: "interface slice",
+			option: []interface{}{
+				map[string]interface{}{"bk_property_id": "name", "bk_property_name": "Name",
+					"bk_property_type": "singlechar", "isrequired": true},
+				map[string]interface{}{"bk_property_id": "size", "bk_property_name": "Size",
+					"bk_property_type": "int", "option": map[string]interface{}{"min": "0", "max": "1024"}},
+			},
+		},
+		{
+			name: "bson document read from db",
+			option: bson.A{
+				bson.D{
+					{Key: "bk_property_id", Value: "name"},
+					{Key: "bk_property_name", Value: "Name"},
+					{Key: "bk_property_type", Value: "singlechar"},
+					{Key: "isrequired", Value: true},
+				},
+				bson.M{"bk_property_id": "size", "bk_property_name": "Size", "bk_property_type": "int",
+					"option": map[string]interface{}{"min": "0", "max": "1024"}},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		option, err := ParseTableOption(context.Background(), testCase.option)
+		require.NoError(t, err, testCase.name)
+		require.Equal(t, expected, option, testCase.name)
+	}
+
+	option, err := ParseTableOption(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, option)
+
+	for _, invalid := range []interface{}{
+		"[{",
+		123,
+		[]interface{}{"name"},
+		[]interface{}{map[string]interface{}{"bk_property_name": "Name", "bk_property_type": "singlechar"}},
+		[]interface{}{map[string]interface{}{"bk_property_id": "name", "bk_property_name": "Name"}},
+	} {
+		_, err := ParseTableOption(context.Background(), invalid)
+		require.Error(t, err, "%#v", invalid)
+	}
+}
+
+// the test of util.ValidFieldTypeTableOption lives here, because the test of util package depends on
+// the packages that are not vendored.
+func TestValidFieldTypeTableOption(t *testing.T) {
+	errFactory, err := errors.NewFactory("../../../resources/errors/")
+	require.NoError(t, err)
+	errProxy := errFactory.CreateDefaultCCErrorIf("en")
+
+	column := func(id, propertyType string) map[string]interface{} {
+		return map[string]interface{}{
+			common.BKPropertyIDField:   id,
+			common.BKPropertyNameField: id,
+			common.BKPropertyTypeField: propertyType,
+		}
+	}
+	tooManyColumns := make([]interface{}, common.AttributeTableColumnMaxCount+1)
+	for idx := range tooManyColumns {
+		tooManyColumns[idx] = column("c"+util.GetStrByInterface(idx), common.FieldTypeSingleChar)
+	}
+
+	valid := []interface{}{
+		[]interface{}{column("name", common.FieldTypeSingleChar), column("ip", common.FieldTypeIPv4)},
+		[]interface{}{
+			map[string]interface{}{
+				common.BKPropertyIDField:   "os",
+				common.BKPropertyNameField: "OS",
+				common.BKPropertyTypeField: common.FieldTypeEnum,
+				"isrequired":               true,
+				"option":                   []interface{}{map[string]interface{}{"id": "linux", "name": "Linux", "type": "text"}},
+			},
+		},
+	}
+	for _, option := range valid {
+		require.NoError(t, util.ValidFieldTypeTableOption(option, errProxy), "%#v", option)
+	}
+
+	invalid := []interface{}{
+		nil,
+		"name",
+		[]interface{}{},
+		tooManyColumns,
+		[]interface{}{"name"},
+		[]interface{}{column("", common.FieldTypeSingleChar)},
+		[]interface{}{column("1name", common.FieldTypeSingleChar)},
+		[]interface{}{column("name", common.FieldTypeSingleChar), column("name", common.FieldTypeInt)},
+		[]interface{}{map[string]interface{}{common.BKPropertyIDField: "name", common.BKPropertyTypeField: "singlechar"}},
+		[]interface{}{column("disks", common.FieldTypeTable)},
+		[]interface{}{column("password", common.FieldTypeSecret)},
+		[]interface{}{column("os", common.FieldTypeEnum)},
+		[]interface{}{
+			map[string]interface{}{
+				common.BKPropertyIDField:   "name",
+				common.BKPropertyNameField: "Name",
+				common.BKPropertyTypeField: common.FieldTypeSingleChar,
+				"isrequired":               "true",
+			},
+		},
+	}
+	for _, option := range invalid {
+		require.Error(t, util.ValidFieldTypeTableOption(option, errProxy), "%#v", option)
+	}
+}
+
+func TestCheckAllowHostApplyOnAttribute(t *testing.T) {
+	require.True(t, CheckAllowHostApplyOnAttribute(Attribute{
+		PropertyID:   common.BKOperatorField,
+		PropertyType: common.FieldTypeUser,
+	}))
+	require.True(t, CheckAllowHostApplyOnAttribute(Attribute{PropertyID: "custom", PropertyType: common.FieldTypeSingleChar}))
+	require.False(t, CheckAllowHostApplyOnAttribute(Attribute{
+		PropertyID:   common.BKHostInnerIPField,
+		PropertyType: common.FieldTypeSingleChar,
+	}))
+	require.False(t, CheckAllowHostApplyOnAttribute(Attribute{PropertyID: "password", PropertyType: common.FieldTypeSecret}))
+	require.False(t, CheckAllowHostApplyOnAttribute(Attribute{PropertyID: "score", PropertyType: common.FieldTypeComputed}))
+}
+
+func TestMaskHostSecretValues(t *testing.T) {
+	attributes := []Attribute{
+		{PropertyID: common.BKHostInnerIPField, PropertyType: common.FieldTypeSingleChar},
+		{PropertyID: "bmc_password", PropertyType: common.FieldTypeSecret},
+		{PropertyID: "agent_token", PropertyType: common.FieldTypeSecret},
+	}
+	secretFields := GetSecretPropertyIDs(attributes)
+	require.Equal(t, []string{"bmc_password", "agent_token"}, secretFields)
+	require.Empty(t, GetSecretPropertyIDs(attributes[:1]))
+
+	host := HostMapStr{
+		common.BKHostInnerIPField: "127.0.0.1",
+		"bmc_password":            "{secret}v1:abc",
+		"agent_token":             nil,
+	}
+	secret.MaskMapStr(host, secretFields...)
+	require.Equal(t, "127.0.0.1", host[common.BKHostInnerIPField])
+	require.Equal(t, secret.Mask, host["bmc_password"])
+	require.Nil(t, host["agent_token"])
+}
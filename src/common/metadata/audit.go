@@ -48,6 +48,8 @@ type AuditQueryCondition struct {
 }
 
 type AuditLog struct {
+	// ID is the unique id of the audit log, it's generated when the audit log is saved.
+	ID int64 `json:"id" bson:"id"`
 	// AuditType is a high level abstract of the resource managed by this cmdb.
 	// Each kind of concept, resource must belongs to one of the resource type.
 	AuditType AuditType `json:"audit_type" bson:"audit_type"`
@@ -69,9 +71,13 @@ type AuditLog struct {
 	OperationTime Time `json:"operation_time" bson:"operation_time"`
 	// for special scene like categorize if the resource belongs to biz topo or service instance
 	Label map[string]string `json:"label" bson:"label"`
+	// SourceAuditID is the id of the audit log that this operation comes from, such as the audit log
+	// that an instance is restored with.
+	SourceAuditID int64 `json:"source_audit_id,omitempty" bson:"source_audit_id,omitempty"`
 }
 
 type bsonAuditLog struct {
+	ID              int64             `json:"id" bson:"id"`
 	AuditType       AuditType         `json:"audit_type" bson:"audit_type"`
 	SupplierAccount string            `json:"bk_supplier_account" bson:"bk_supplier_account"`
 	User            string            `json:"user" bson:"user"`
@@ -80,6 +86,7 @@ type bsonAuditLog struct {
 	OperateFrom     OperateFromType   `json:"operate_from" bson:"operate_from"`
 	OperationTime   Time              `json:"operation_time" bson:"operation_time"`
 	Label           map[string]string `json:"label" bson:"label"`
+	SourceAuditID   int64             `json:"source_audit_id,omitempty" bson:"source_audit_id,omitempty"`
 	OperationDetail bson.Raw          `json:"operation_detail" bson:"operation_detail"`
 }
 
@@ -89,6 +96,7 @@ type DetailFactory interface {
 
 func (auditLog *AuditLog) UnmarshalJSON(data []byte) error {
 	type jsonAuditLog struct {
+		ID              int64             `json:"id" bson:"id"`
 		AuditType       AuditType         `json:"audit_type" bson:"audit_type"`
 		SupplierAccount string            `json:"bk_supplier_account" bson:"bk_supplier_account"`
 		User            string            `json:"user" bson:"user"`
@@ -97,12 +105,14 @@ func (auditLog *AuditLog) UnmarshalJSON(data []byte) error {
 		OperateFrom     OperateFromType   `json:"operate_from" bson:"operate_from"`
 		OperationTime   Time              `json:"operation_time" bson:"operation_time"`
 		Label           map[string]string `json:"label" bson:"label"`
+		SourceAuditID   int64             `json:"source_audit_id,omitempty" bson:"source_audit_id,omitempty"`
 		OperationDetail json.RawMessage   `json:"operation_detail" bson:"operation_detail"`
 	}
 	audit := jsonAuditLog{}
 	if err := json.Unmarshal(data, &audit); err != nil {
 		return err
 	}
+	auditLog.ID = audit.ID
 	auditLog.AuditType = audit.AuditType
 	auditLog.SupplierAccount = audit.SupplierAccount
 	auditLog.User = audit.User
@@ -111,6 +121,7 @@ func (auditLog *AuditLog) UnmarshalJSON(data []byte) error {
 	auditLog.OperateFrom = audit.OperateFrom
 	auditLog.OperationTime = audit.OperationTime
 	auditLog.Label = audit.Label
+	auditLog.SourceAuditID = audit.SourceAuditID
 	if audit.Action == AuditTransferHostModule || audit.Action == AuditAssignHost || audit.Action == AuditUnassignHost {
 		operationDetail := new(HostTransferOpDetail)
 		if err := json.Unmarshal(audit.OperationDetail, &operationDetail); err != nil {
@@ -159,6 +170,7 @@ func (auditLog *AuditLog) UnmarshalBSON(data []byte) error {
 	if err := bson.Unmarshal(data, &audit); err != nil {
 		return err
 	}
+	auditLog.ID = audit.ID
 	auditLog.AuditType = audit.AuditType
 	auditLog.SupplierAccount = audit.SupplierAccount
 	auditLog.User = audit.User
@@ -167,6 +179,7 @@ func (auditLog *AuditLog) UnmarshalBSON(data []byte) error {
 	auditLog.OperateFrom = audit.OperateFrom
 	auditLog.OperationTime = audit.OperationTime
 	auditLog.Label = audit.Label
+	auditLog.SourceAuditID = audit.SourceAuditID
 	if audit.Action == AuditTransferHostModule || audit.Action == AuditAssignHost || audit.Action == AuditUnassignHost {
 		operationDetail := new(HostTransferOpDetail)
 		if err := bson.Unmarshal(audit.OperationDetail, &operationDetail); err != nil {
@@ -212,6 +225,7 @@ func (auditLog *AuditLog) UnmarshalBSON(data []byte) error {
 
 func (auditLog AuditLog) MarshalBSON() ([]byte, error) {
 	audit := bsonAuditLog{}
+	audit.ID = auditLog.ID
 	audit.AuditType = auditLog.AuditType
 	audit.SupplierAccount = auditLog.SupplierAccount
 	audit.User = auditLog.User
@@ -220,6 +234,7 @@ func (auditLog AuditLog) MarshalBSON() ([]byte, error) {
 	audit.OperateFrom = auditLog.OperateFrom
 	audit.OperationTime = auditLog.OperationTime
 	audit.Label = auditLog.Label
+	audit.SourceAuditID = auditLog.SourceAuditID
 	var err error
 	switch val := auditLog.OperationDetail.(type) {
 	default:
@@ -377,6 +392,10 @@ const (
 	// - cloud synchronize job
 	// - others
 	CloudResourceType AuditType = "cloud_resource"
+
+	// AuditLogType represent the operation audit of the audit logs themselves, such as archiving the expired
+	// audit logs and importing the archived audit logs.
+	AuditLogType AuditType = "audit_log"
 )
 
 type ResourceType string
@@ -412,6 +431,9 @@ const (
 
 	// host related operation type
 	HostRes ResourceType = "host"
+
+	// audit log related operation type
+	AuditArchiveRes ResourceType = "audit_archive"
 )
 
 type OperateFromType string
@@ -425,6 +447,8 @@ const (
 	FromDataCollection OperateFromType = "data_collection"
 	// FromSynchronizer means this audit is created by the data synchronizer.
 	FromSynchronizer OperateFromType = "synchronizer"
+	// FromHostApply means this audit is created by the host apply drift reconciler.
+	FromHostApply OperateFromType = "host_apply"
 )
 
 // ActionType defines all the user's operation type
@@ -447,6 +471,10 @@ const (
 	AuditArchive ActionType = "archive"
 	// recover a resource
 	AuditRecover ActionType = "recover"
+	// a host drifted away from its host apply rules
+	AuditHostApplyDrift ActionType = "host_apply_drift"
+	// reveal the plaintext of a secret attribute value
+	AuditRevealSecret ActionType = "reveal_secret"
 )
 
 const (
@@ -511,7 +539,7 @@ func GetAuditTypesByCategory(category string) []AuditType {
 	case "resource":
 		return []AuditType{BusinessType, ModelInstanceType, CloudResourceType}
 	case "other":
-		return []AuditType{ModelType, AssociationKindType, EventPushType}
+		return []AuditType{ModelType, AssociationKindType, EventPushType, AuditLogType}
 	}
 	return []AuditType{}
 }
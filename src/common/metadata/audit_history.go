@@ -0,0 +1,61 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+// InstanceHistoryOption reconstructs the state of an instance at a past time with its audit logs
+type InstanceHistoryOption struct {
+	InstID int64 `json:"bk_inst_id"`
+	// Timestamp is the unix time that the state is reconstructed at, the current state is returned if it's not set
+	Timestamp int64 `json:"timestamp"`
+}
+
+// InstanceFieldChange is the change of a field in an audit log
+type InstanceFieldChange struct {
+	PropertyID string      `json:"bk_property_id"`
+	PreValue   interface{} `json:"pre_value"`
+	CurValue   interface{} `json:"cur_value"`
+}
+
+// InstanceHistoryEntry is an audit log of the instance with its field changes
+type InstanceHistoryEntry struct {
+	AuditID       int64                 `json:"id"`
+	Action        ActionType            `json:"action"`
+	User          string                `json:"user"`
+	OperateFrom   OperateFromType       `json:"operate_from"`
+	OperationTime Time                  `json:"operation_time"`
+	SourceAuditID int64                 `json:"source_audit_id,omitempty"`
+	Changes       []InstanceFieldChange `json:"changes"`
+}
+
+type InstanceHistoryResult struct {
+	// Exist shows whether the instance exists at the time
+	Exist bool `json:"exist"`
+	// State is the attributes of the instance at the time
+	State map[string]interface{} `json:"state"`
+	// Timeline is the changes of the instance until the time, sorted by operation time
+	Timeline []InstanceHistoryEntry `json:"timeline"`
+}
+
+// RestoreInstanceOption restores an instance to the version recorded in an audit log
+type RestoreInstanceOption struct {
+	InstID  int64 `json:"bk_inst_id"`
+	AuditID int64 `json:"audit_id"`
+	// Before restores the instance to the version before the audit log's operation,
+	// otherwise to the version after it.
+	Before bool `json:"before"`
+}
+
+type RestoreInstanceResult struct {
+	// Changes is the fields that are restored
+	Changes []InstanceFieldChange `json:"changes"`
+}
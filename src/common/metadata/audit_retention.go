@@ -0,0 +1,102 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+)
+
+// AuditRetentionPolicy is the retention policy of an audit type, the audit logs older than the retention days
+// are archived or deleted by the audit retention job of coreservice.
+type AuditRetentionPolicy struct {
+	AuditType AuditType `json:"audit_type" bson:"audit_type"`
+	// RetentionDays is the days the audit logs are kept, the policy is removed if it's 0.
+	RetentionDays int64 `json:"retention_days" bson:"retention_days"`
+	// Archive archives the expired audit logs before they are removed, otherwise they are deleted directly.
+	Archive         bool   `json:"archive" bson:"archive"`
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Modifier        string `json:"modifier" bson:"modifier"`
+	LastTime        Time   `json:"last_time" bson:"last_time"`
+}
+
+// retentionAuditTypes is the audit types that can have retention policies
+var retentionAuditTypes = map[AuditType]bool{
+	BusinessType:         true,
+	BusinessResourceType: true,
+	HostType:             true,
+	ModelType:            true,
+	ModelInstanceType:    true,
+	AssociationKindType:  true,
+	EventPushType:        true,
+	CloudResourceType:    true,
+	AuditLogType:         true,
+}
+
+// Validate validates the policy, it returns the invalid field if it's not valid
+func (p *AuditRetentionPolicy) Validate() (string, bool) {
+	if !retentionAuditTypes[p.AuditType] {
+		return "audit_type", false
+	}
+	if p.RetentionDays < 0 {
+		return "retention_days", false
+	}
+	return "", true
+}
+
+// AuditLogArchive is a batch of expired audit logs of an audit type, the audit logs are compressed and kept
+// in the archive collection, and they can be imported back to the audit logs.
+type AuditLogArchive struct {
+	ID int64 `json:"id" bson:"id"`
+	// Key identifies the archived audit logs, it is derived from the first and last audit log of the archive,
+	// so that an archive is not saved twice if the archived audit logs failed to be deleted.
+	Key       string    `json:"key" bson:"key"`
+	AuditType AuditType `json:"audit_type" bson:"audit_type"`
+	// StartTime and EndTime is the operation time range of the archived audit logs
+	StartTime Time  `json:"start_time" bson:"start_time"`
+	EndTime   Time  `json:"end_time" bson:"end_time"`
+	Count     int64 `json:"count" bson:"count"`
+	// Size is the size of the compressed audit logs
+	Size       int64 `json:"size" bson:"size"`
+	CreateTime Time  `json:"create_time" bson:"create_time"`
+	// Imported shows whether the archived audit logs are imported, the imported audit logs are removed again
+	// when the retention days have passed since the ImportTime.
+	Imported        bool   `json:"imported" bson:"imported"`
+	ImportTime      *Time  `json:"import_time,omitempty" bson:"import_time,omitempty"`
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+type ListAuditLogArchiveOption struct {
+	AuditType AuditType `json:"audit_type"`
+	Page      BasePage  `json:"page"`
+}
+
+// Validate validates the option, it returns the invalid field if it's not valid
+func (o *ListAuditLogArchiveOption) Validate() (string, bool) {
+	if o.Page.Limit == 0 {
+		o.Page.Limit = common.BKDefaultLimit
+	}
+	if o.Page.IsIllegal() {
+		return "page.limit", false
+	}
+	return "", true
+}
+
+type MultipleAuditLogArchive struct {
+	Count int64             `json:"count"`
+	Info  []AuditLogArchive `json:"info"`
+}
+
+type ImportAuditLogArchiveResult struct {
+	// Count is the number of the imported audit logs
+	Count int64 `json:"count"`
+}
@@ -27,6 +27,20 @@ type AuthResource struct {
 	} `json:"parent_layers"`
 }
 
+// AuthExplainRequest is the request to dry run the authorization of the resources, the
+// request user is explained when the user name is not set.
+type AuthExplainRequest struct {
+	UserName  string                `json:"bk_username"`
+	Resources []AuthExplainResource `json:"resources"`
+}
+
+type AuthExplainResource struct {
+	AuthResource
+	// the resource's string id and name, which can be used by the rules as the instance id or name.
+	ResourceIDEx string `json:"resource_id_ex"`
+	ResourceName string `json:"resource_name"`
+}
+
 type AuthBathVerifyResult struct {
 	AuthResource
 	// the authorize decision, whether a user has been authorized or not.
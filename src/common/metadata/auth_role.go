@@ -0,0 +1,138 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AuthPermissionAny is a wildcard, which can be used as a permission's resource type or action,
+// means any resource type or any action.
+const AuthPermissionAny = "*"
+
+// AuthRole is a role of the local authorizer, which is a set of permissions.
+// a role can be bound to users with a business scope by AuthRoleBinding.
+type AuthRole struct {
+	ID          int64            `json:"id" bson:"id"`
+	Name        string           `json:"name" bson:"name"`
+	Description string           `json:"description" bson:"description"`
+	Permissions []AuthPermission `json:"permissions" bson:"permissions"`
+
+	Creator         string    `json:"creator" bson:"creator"`
+	Modifier        string    `json:"modifier" bson:"modifier"`
+	CreateTime      time.Time `json:"create_time" bson:"create_time"`
+	LastTime        time.Time `json:"last_time" bson:"last_time"`
+	SupplierAccount string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// Validate validate the role's name and permissions
+func (r *AuthRole) Validate() error {
+	if len(r.Name) == 0 {
+		return errors.New("role name can not be empty")
+	}
+
+	for idx := range r.Permissions {
+		if err := r.Permissions[idx].Validate(); err != nil {
+			return fmt.Errorf("permissions[%d] is invalid, %v", idx, err)
+		}
+	}
+	return nil
+}
+
+// AuthPermission describe which actions can be done with the resources of a resource type.
+type AuthPermission struct {
+	// resource type defined in auth/meta, or "*" which means all the resource types.
+	ResourceType string `json:"resource_type" bson:"resource_type"`
+	// actions defined in auth/meta, or "*" which means all the actions.
+	Actions []string `json:"actions" bson:"actions"`
+	// the resource instances this permission is limited to, empty means all the instances.
+	InstanceIDs []string `json:"instance_ids" bson:"instance_ids"`
+}
+
+// Validate validate the permission's resource type and actions
+func (p *AuthPermission) Validate() error {
+	if len(p.ResourceType) == 0 {
+		return errors.New("resource_type can not be empty")
+	}
+
+	if len(p.Actions) == 0 {
+		return errors.New("actions can not be empty")
+	}
+
+	for _, action := range p.Actions {
+		if len(action) == 0 {
+			return errors.New("action can not be empty")
+		}
+	}
+	return nil
+}
+
+// AuthRoleBinding bind a role to a user. the role's permissions is limited to the
+// business when the business id is set, otherwise the permissions is global.
+type AuthRoleBinding struct {
+	ID       int64  `json:"id" bson:"id"`
+	RoleID   int64  `json:"role_id" bson:"role_id"`
+	UserName string `json:"bk_username" bson:"bk_username"`
+	BizID    int64  `json:"bk_biz_id" bson:"bk_biz_id"`
+
+	Creator         string    `json:"creator" bson:"creator"`
+	CreateTime      time.Time `json:"create_time" bson:"create_time"`
+	SupplierAccount string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// CreateAuthRoleBindingOption bind a role to users.
+type CreateAuthRoleBindingOption struct {
+	RoleID    int64    `json:"role_id"`
+	UserNames []string `json:"bk_usernames"`
+	BizID     int64    `json:"bk_biz_id"`
+}
+
+// Validate validate the role binding option
+func (o *CreateAuthRoleBindingOption) Validate() error {
+	if o.RoleID <= 0 {
+		return errors.New("role_id is invalid")
+	}
+
+	if len(o.UserNames) == 0 {
+		return errors.New("bk_usernames can not be empty")
+	}
+
+	for _, user := range o.UserNames {
+		if len(user) == 0 {
+			return errors.New("bk_username can not be empty")
+		}
+	}
+
+	if o.BizID < 0 {
+		return errors.New("bk_biz_id is invalid")
+	}
+	return nil
+}
+
+// ParamAuthRoleSearch is the option to search roles or role bindings.
+type ParamAuthRoleSearch struct {
+	Condition map[string]interface{} `json:"condition"`
+	Page      BasePage               `json:"page"`
+}
+
+type RspAuthRoleSearch struct {
+	Count uint64     `json:"count"`
+	Info  []AuthRole `json:"info"`
+}
+
+type RspAuthRoleBindingSearch struct {
+	Count uint64            `json:"count"`
+	Info  []AuthRoleBinding `json:"info"`
+}
@@ -0,0 +1,65 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// BatchOperationMaxCount is the max number of operations a batch request can carry
+const BatchOperationMaxCount = 100
+
+// BatchOperation is one api call of a batch request. Path is relative to /api/v3, both path and
+// body can refer to the data returned by an earlier operation with $ops[index].field, e.g.
+// $ops[0].bk_set_id or $ops[1].info[0].bk_host_id.
+type BatchOperation struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body"`
+}
+
+type BatchOperationRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// Validate validates the request, it returns the invalid field if it's not valid
+func (r *BatchOperationRequest) Validate() (string, bool) {
+	if len(r.Operations) == 0 || len(r.Operations) > BatchOperationMaxCount {
+		return "operations", false
+	}
+	for _, op := range r.Operations {
+		switch strings.ToUpper(op.Method) {
+		case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete:
+		default:
+			return "method", false
+		}
+		if !strings.HasPrefix(op.Path, "/") || strings.HasPrefix(op.Path, "/batch/") {
+			return "path", false
+		}
+	}
+	return "", true
+}
+
+// BatchOperationResult is the response of one operation of a batch request
+type BatchOperationResult struct {
+	Index    int `json:"index"`
+	BaseResp `json:",inline"`
+	Data     interface{} `json:"data"`
+}
+
+type BatchOperationResponse struct {
+	BaseResp `json:",inline"`
+	Data     []BatchOperationResult `json:"data"`
+}
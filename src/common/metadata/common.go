@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/util"
 
 	"github.com/coccyx/timeparser"
@@ -113,6 +114,8 @@ type QueryInput struct {
 	Start     int                    `json:"start,omitempty"`
 	Limit     int                    `json:"limit,omitempty"`
 	Sort      string                 `json:"sort,omitempty"`
+	// Filter is combined with the condition, it's converted by coreservice with the model's attribute types.
+	Filter *querybuilder.QueryFilter `json:"filter,omitempty"`
 }
 
 // Validate validates the input param
@@ -18,6 +18,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/selector"
 	"configcenter/src/common/util"
 )
@@ -368,7 +369,9 @@ type ListServiceInstanceOption struct {
 	SearchKey          *string            `json:"search_key"`
 	ServiceInstanceIDs []int64            `json:"service_instance_ids"`
 	Selectors          selector.Selectors `json:"selectors"`
-	Page               BasePage           `json:"page"`
+	// Filter is used to filter the service instances with their fields, see ServiceInstanceFieldTypes
+	Filter *querybuilder.QueryFilter `json:"filter,omitempty"`
+	Page   BasePage                  `json:"page"`
 }
 
 type ListServiceInstanceDetailOption struct {
@@ -0,0 +1,102 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/querybuilder"
+)
+
+// ServiceInstanceObjID is used as the object id of service instance in dynamic group,
+// service instance is not a model, so it's attributes are fixed.
+const ServiceInstanceObjID = "service_instance"
+
+// ServiceInstanceFieldTypes is the field types of the service instance which can be used in dynamic group filter.
+var ServiceInstanceFieldTypes = querybuilder.FieldTypes{
+	common.BKFieldID:                common.FieldTypeInt,
+	common.BKFieldName:              common.FieldTypeSingleChar,
+	common.BKServiceTemplateIDField: common.FieldTypeInt,
+	common.BKHostIDField:            common.FieldTypeInt,
+	common.BKModuleIDField:          common.FieldTypeInt,
+	common.CreatorField:             common.FieldTypeSingleChar,
+	common.ModifierField:            common.FieldTypeSingleChar,
+	common.CreateTimeField:          querybuilder.FieldTypeDatetime,
+	common.LastTimeField:            querybuilder.FieldTypeDatetime,
+}
+
+// IsDynamicGroupObject check if the object's instances can be grouped by a dynamic group,
+// business is excluded because the dynamic group itself belongs to a business.
+func IsDynamicGroupObject(objID string) bool {
+	switch objID {
+	case "", common.BKInnerObjIDApp, common.BKInnerObjIDPlat, common.BKInnerObjIDProc:
+		return false
+	}
+	return true
+}
+
+// DynamicGroupOption is the option to create or update a dynamic group, the filter can be set
+// with typed querybuilder rules or with a text query, but not both.
+type DynamicGroupOption struct {
+	AppID  int64                     `json:"bk_biz_id"`
+	Name   string                    `json:"name"`
+	ObjID  string                    `json:"bk_obj_id"`
+	Filter *querybuilder.QueryFilter `json:"filter"`
+	Query  string                    `json:"query"`
+}
+
+// ValidateFilter validate the filter of the dynamic group, the fields is not checked here.
+func (option *DynamicGroupOption) ValidateFilter() (string, error) {
+	if option.Filter != nil && len(option.Query) != 0 {
+		return "query", errors.New("filter and query can not be set at the same time")
+	}
+	if option.Filter == nil {
+		return "", nil
+	}
+	if option.Filter.Rule == nil {
+		return "filter", errors.New("filter has no rules")
+	}
+	if key, err := option.Filter.Validate(); err != nil {
+		return "filter." + key, err
+	}
+	return "", nil
+}
+
+// ExecuteDynamicGroupOption is the option to get the instances matched by a dynamic group.
+type ExecuteDynamicGroupOption struct {
+	Fields []string `json:"fields"`
+	Page   BasePage `json:"page"`
+}
+
+func (option *ExecuteDynamicGroupOption) Validate() (string, error) {
+	if option.Page.Limit <= 0 {
+		return "page.limit", errors.New("page limit must be set")
+	}
+	if key, err := option.Page.Validate(false); err != nil {
+		return "page." + key, err
+	}
+	return "", nil
+}
+
+// DynamicGroupResult is the instances matched by a dynamic group.
+type DynamicGroupResult struct {
+	Count int             `json:"count"`
+	Info  []mapstr.MapStr `json:"info"`
+}
+
+type DynamicGroupResultResponse struct {
+	BaseResp `json:",inline"`
+	Data     DynamicGroupResult `json:"data"`
+}
@@ -16,9 +16,13 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 	"time"
+
+	"configcenter/src/common/watch"
 )
 
 type RspSubscriptionCreate struct {
@@ -62,11 +66,23 @@ type Subscription struct {
 	ConfirmPattern   string `bson:"confirm_pattern" json:"confirm_pattern"`
 	TimeOutSeconds   int64  `bson:"time_out" json:"time_out"` // second
 	// SubscriptionForm is a list of event types split by comma
-	SubscriptionForm string      `bson:"subscription_form" json:"subscription_form"`
-	Operator         string      `bson:"operator" json:"operator"`
-	OwnerID          string      `bson:"bk_supplier_account" json:"bk_supplier_account"`
-	LastTime         Time        `bson:"last_time" json:"last_time"`
-	Statistics       *Statistics `bson:"-" json:"statistics"`
+	SubscriptionForm string `bson:"subscription_form" json:"subscription_form"`
+	Operator         string `bson:"operator" json:"operator"`
+	OwnerID          string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	LastTime         Time   `bson:"last_time" json:"last_time"`
+	// Secret is used to sign the event callback, so that the subscriber can verify the callback
+	// is sent by cmdb. the callback will not be signed if the secret is empty.
+	Secret string `bson:"secret" json:"secret,omitempty"`
+	// Auth is the static authentication info carried by each event callback.
+	Auth *SubscriptionAuth `bson:"auth" json:"auth,omitempty"`
+	// RetryPolicy defines how to retry the failed event callback, use the default policy if not set.
+	RetryPolicy *SubscriptionRetryPolicy `bson:"retry_policy" json:"retry_policy"`
+	// ClearSecret and ClearAuth are only used when updating the subscription, an empty secret or auth
+	// keeps the old one since they are not returned to the user, so they are removed explicitly with these.
+	ClearSecret     bool                  `bson:"-" json:"clear_secret,omitempty"`
+	ClearAuth       bool                  `bson:"-" json:"clear_auth,omitempty"`
+	Statistics      *Statistics           `bson:"-" json:"statistics"`
+	DeliveryHistory []EventDeliveryRecord `bson:"-" json:"delivery_history"`
 }
 
 // Report define sending statistic
@@ -75,6 +91,180 @@ type Statistics struct {
 	Failure int64 `json:"failure"`
 }
 
+// SubscriptionAuthType define
+type SubscriptionAuthType string
+
+// SubscriptionAuthType enumeration
+const (
+	SubscriptionAuthBearer SubscriptionAuthType = "bearer"
+	SubscriptionAuthBasic  SubscriptionAuthType = "basic"
+)
+
+// SubscriptionAuth is the authentication info the event server sends with each event callback.
+type SubscriptionAuth struct {
+	Type SubscriptionAuthType `bson:"type" json:"type"`
+	// Token is used when the auth type is bearer.
+	Token string `bson:"token" json:"token,omitempty"`
+	// Username and Password is used when the auth type is basic.
+	Username string `bson:"username" json:"username,omitempty"`
+	Password string `bson:"password" json:"password,omitempty"`
+}
+
+func (a *SubscriptionAuth) Validate() error {
+	switch a.Type {
+	case SubscriptionAuthBearer:
+		if len(a.Token) == 0 {
+			return errors.New("bearer auth token can not be empty")
+		}
+	case SubscriptionAuthBasic:
+		if len(a.Username) == 0 {
+			return errors.New("basic auth username can not be empty")
+		}
+	default:
+		return fmt.Errorf("unsupported auth type: %s", a.Type)
+	}
+	return nil
+}
+
+// SetHeader set the authentication header to the callback request.
+func (a *SubscriptionAuth) SetHeader(req *http.Request) {
+	switch a.Type {
+	case SubscriptionAuthBearer:
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	case SubscriptionAuthBasic:
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+}
+
+// Desensitize removes the sensitive information of the subscription, so that it can be returned to the user.
+func (s *Subscription) Desensitize() {
+	s.Secret = ""
+	if s.Auth != nil {
+		s.Auth.Token = ""
+		s.Auth.Password = ""
+	}
+}
+
+const (
+	// SubscriptionMaxRetryAttempts the max attempts can be set in a subscription's retry policy.
+	SubscriptionMaxRetryAttempts = 20
+	// EventDeliveryHistoryLimit the max delivery records of each subscription is reserved.
+	EventDeliveryHistoryLimit = 100
+)
+
+// SubscriptionRetryPolicy define the retry policy of the event callback with exponential backoff.
+type SubscriptionRetryPolicy struct {
+	// MaxAttempts is the max times to send an event callback, including the first attempt.
+	MaxAttempts int64 `bson:"max_attempts" json:"max_attempts"`
+	// InitialInterval is the seconds to wait before the first retry.
+	InitialInterval int64 `bson:"initial_interval" json:"initial_interval"`
+	// MaxInterval is the max seconds to wait between two attempts.
+	MaxInterval int64 `bson:"max_interval" json:"max_interval"`
+	// Multiplier is the factor to multiply the interval by after each retry.
+	Multiplier float64 `bson:"multiplier" json:"multiplier"`
+}
+
+// DefaultSubscriptionRetryPolicy is used when subscription do not set a retry policy.
+var DefaultSubscriptionRetryPolicy = SubscriptionRetryPolicy{
+	MaxAttempts:     3,
+	InitialInterval: 1,
+	MaxInterval:     60,
+	Multiplier:      2,
+}
+
+func (p *SubscriptionRetryPolicy) Validate() error {
+	if p.MaxAttempts <= 0 || p.MaxAttempts > SubscriptionMaxRetryAttempts {
+		return fmt.Errorf("max_attempts should be in range [1, %d]", SubscriptionMaxRetryAttempts)
+	}
+
+	if p.InitialInterval < 0 {
+		return errors.New("initial_interval can not be negative")
+	}
+
+	if p.MaxInterval < p.InitialInterval {
+		return errors.New("max_interval can not be less than initial_interval")
+	}
+
+	if p.Multiplier < 1 {
+		return errors.New("multiplier can not be less than 1")
+	}
+
+	return nil
+}
+
+// Backoff returns the duration to wait before the next attempt, attempt starts from 1.
+func (p SubscriptionRetryPolicy) Backoff(attempt int64) time.Duration {
+	interval := float64(p.InitialInterval)
+	for i := int64(1); i < attempt; i++ {
+		interval *= p.Multiplier
+		if interval >= float64(p.MaxInterval) {
+			interval = float64(p.MaxInterval)
+			break
+		}
+	}
+	return time.Duration(interval * float64(time.Second))
+}
+
+// GetRetryPolicy returns the subscription's retry policy, or the default one if not set.
+func (s Subscription) GetRetryPolicy() SubscriptionRetryPolicy {
+	if s.RetryPolicy == nil {
+		return DefaultSubscriptionRetryPolicy
+	}
+	return *s.RetryPolicy
+}
+
+// EventDeliveryRecord is the delivery result of an event sent to a subscription.
+type EventDeliveryRecord struct {
+	DistID    int64  `json:"distribution_id"`
+	EventID   int64  `json:"event_id"`
+	EventType string `json:"event_type"`
+	Action    string `json:"action"`
+	ObjType   string `json:"obj_type"`
+	Attempts  int64  `json:"attempts"`
+	Success   bool   `json:"success"`
+	// DeadLetter is true when all the attempts is failed and the event has been moved to the dead letter collection.
+	DeadLetter bool   `json:"dead_letter"`
+	Error      string `json:"error"`
+	StartTime  Time   `json:"start_time"`
+	EndTime    Time   `json:"end_time"`
+}
+
+// EventDeadLetter is an event which is failed to send to the subscriber after all the retry attempts.
+type EventDeadLetter struct {
+	ID             int64  `bson:"id" json:"id"`
+	SubscriptionID int64  `bson:"subscription_id" json:"subscription_id"`
+	DistID         int64  `bson:"distribution_id" json:"distribution_id"`
+	EventID        int64  `bson:"event_id" json:"event_id"`
+	EventType      string `bson:"event_type" json:"event_type"`
+	Action         string `bson:"action" json:"action"`
+	ObjType        string `bson:"obj_type" json:"obj_type"`
+	// Data is the raw distribution instance sent to the subscriber.
+	Data       string `bson:"data" json:"data"`
+	Attempts   int64  `bson:"attempts" json:"attempts"`
+	LastError  string `bson:"last_error" json:"last_error"`
+	OwnerID    string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	CreateTime Time   `bson:"create_time" json:"create_time"`
+}
+
+type ParamDeadLetterSearch struct {
+	Condition map[string]interface{} `json:"condition"`
+	Page      BasePage               `json:"page"`
+}
+
+type RspDeadLetterSearch struct {
+	Count uint64            `json:"count"`
+	Info  []EventDeadLetter `json:"info"`
+}
+
+// DeadLetterOption is used to replay or purge the dead letters, empty ids means all the subscription's dead letters.
+type DeadLetterOption struct {
+	IDs []int64 `json:"ids"`
+}
+
+type RspDeadLetterOperate struct {
+	Count uint64 `json:"count"`
+}
+
 func (Subscription) TableName() string {
 	return "cc_Subscription"
 }
@@ -90,6 +280,9 @@ func (s Subscription) GetCacheKey() string {
 		ConfirmPattern:   s.ConfirmPattern,
 		SubscriptionForm: s.SubscriptionForm,
 		TimeOutSeconds:   s.TimeOutSeconds,
+		RetryPolicy:      s.RetryPolicy,
+		Secret:           s.Secret,
+		Auth:             s.Auth,
 	}
 	b, _ := json.Marshal(ns)
 	return string(b)
@@ -198,3 +391,22 @@ func (n *ConfirmMode) Scan(value interface{}) error {
 func (n ConfirmMode) Value() (driver.Value, error) {
 	return string(n), nil
 }
+
+// WatchEventResult is the result of the watch api used by the api clients,
+// the event details are kept as raw json so that they can be decoded by the watchers.
+type WatchEventResult struct {
+	BaseResp `json:",inline"`
+	Data     WatchEventData `json:"data"`
+}
+
+type WatchEventData struct {
+	Watched bool               `json:"bk_watched"`
+	Events  []*WatchEventEntry `json:"bk_events"`
+}
+
+type WatchEventEntry struct {
+	Cursor    string           `json:"bk_cursor"`
+	Resource  watch.CursorType `json:"bk_resource"`
+	EventType watch.EventType  `json:"bk_event_type"`
+	Detail    json.RawMessage  `json:"bk_detail"`
+}
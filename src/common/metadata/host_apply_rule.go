@@ -12,8 +12,10 @@
 package metadata
 
 import (
+	"fmt"
 	"time"
 
+	"configcenter/src/common"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/querybuilder"
 )
@@ -24,14 +26,61 @@ const (
 	HostUpdateWithoutHostApplyFiled = true
 )
 
+// HostApplyRuleLevel is the topology level that a host apply rule is attached to, the rules of service template
+// and set template are inherited by the modules created from them, and can be overridden by the module's rules.
+type HostApplyRuleLevel string
+
+const (
+	HostApplyRuleLevelModule          HostApplyRuleLevel = "module"
+	HostApplyRuleLevelServiceTemplate HostApplyRuleLevel = "service_template"
+	HostApplyRuleLevelSetTemplate     HostApplyRuleLevel = "set_template"
+)
+
+// HostApplyRuleLevels is the levels ordered by precedence, the rule of the former level overrides the latter's.
+var HostApplyRuleLevels = []HostApplyRuleLevel{
+	HostApplyRuleLevelModule,
+	HostApplyRuleLevelServiceTemplate,
+	HostApplyRuleLevelSetTemplate,
+}
+
+// GetHostApplyRuleLevel returns the level of the rule by its target, exactly one of the targets should be set.
+func GetHostApplyRuleLevel(moduleID, serviceTemplateID, setTemplateID int64) (HostApplyRuleLevel, string, error) {
+	levels := make([]HostApplyRuleLevel, 0)
+	if moduleID != 0 {
+		levels = append(levels, HostApplyRuleLevelModule)
+	}
+	if serviceTemplateID != 0 {
+		levels = append(levels, HostApplyRuleLevelServiceTemplate)
+	}
+	if setTemplateID != 0 {
+		levels = append(levels, HostApplyRuleLevelSetTemplate)
+	}
+	if len(levels) != 1 {
+		return "", common.BKModuleIDField, errors.New(common.CCErrCommParamsInvalid,
+			"exactly one of bk_module_id, service_template_id and set_template_id should be set")
+	}
+	return levels[0], "", nil
+}
+
 // HostApplyRule represent one rule of host property auto apply
 type HostApplyRule struct {
 	ID       int64 `field:"id" json:"id" bson:"id" mapstructure:"id"`
 	BizID    int64 `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id" mapstructure:"bk_biz_id"`
 	ModuleID int64 `field:"bk_module_id" json:"bk_module_id" bson:"bk_module_id" mapstructure:"bk_module_id"`
+	// the rules can also be attached to service template or set template, and inherited by the modules
+	ServiceTemplateID int64              `field:"service_template_id" json:"service_template_id" bson:"service_template_id" mapstructure:"service_template_id"`
+	SetTemplateID     int64              `field:"set_template_id" json:"set_template_id" bson:"set_template_id" mapstructure:"set_template_id"`
+	Level             HostApplyRuleLevel `field:"level" json:"level" bson:"level" mapstructure:"level"`
 	// `id` field of table: `cc_AsstDes`, not the same with bk_property_id
 	AttributeID   int64       `field:"bk_attribute_id" json:"bk_attribute_id" bson:"bk_attribute_id" mapstructure:"bk_attribute_id"`
 	PropertyValue interface{} `field:"bk_property_value" json:"bk_property_value" bson:"bk_property_value" mapstructure:"bk_property_value"`
+	// ValueTemplate compute the property value from the host and its topology, like "${bk_host_innerip}-${set.bk_set_name}",
+	// PropertyValue is ignored if it is set.
+	ValueTemplate string `field:"bk_value_template" json:"bk_value_template" bson:"bk_value_template" mapstructure:"bk_value_template"`
+	// Condition is the filter of the hosts that the rule is applied to, the rule is applied to all hosts if it is empty.
+	Condition *querybuilder.QueryFilter `field:"bk_condition" json:"bk_condition,omitempty" bson:"bk_condition,omitempty" mapstructure:"bk_condition"`
+	// Priority decides which rule wins when the rules of a host's modules conflict, the bigger the higher.
+	Priority int64 `field:"bk_priority" json:"bk_priority" bson:"bk_priority" mapstructure:"bk_priority"`
 
 	// 通用字段
 	Creator         string    `field:"creator" json:"creator" bson:"creator" mapstructure:"creator"`
@@ -42,17 +91,43 @@ type HostApplyRule struct {
 }
 
 func (h *HostApplyRule) Validate() (string, error) {
+	return validateHostApplyRuleCondition(h.Condition)
+}
+
+// IsTemplate returns whether the property value of the rule is computed from the value template.
+func (h *HostApplyRule) IsTemplate() bool {
+	return len(h.ValueTemplate) > 0
+}
+
+func validateHostApplyRuleCondition(condition *querybuilder.QueryFilter) (string, error) {
+	if condition == nil || condition.Rule == nil {
+		return "", nil
+	}
+	if key, err := condition.Validate(); err != nil {
+		return "bk_condition." + key, err
+	}
 	return "", nil
 }
 
 type CreateHostApplyRuleOption struct {
-	AttributeID   int64       `field:"bk_attribute_id" json:"bk_attribute_id" bson:"bk_attribute_id" mapstructure:"bk_attribute_id"`
-	ModuleID      int64       `field:"bk_module_id" json:"bk_module_id" bson:"bk_module_id" mapstructure:"bk_module_id"`
-	PropertyValue interface{} `field:"bk_property_value" json:"bk_property_value" bson:"bk_property_value" mapstructure:"bk_property_value"`
+	AttributeID       int64                     `field:"bk_attribute_id" json:"bk_attribute_id" bson:"bk_attribute_id" mapstructure:"bk_attribute_id"`
+	ModuleID          int64                     `field:"bk_module_id" json:"bk_module_id" bson:"bk_module_id" mapstructure:"bk_module_id"`
+	ServiceTemplateID int64                     `field:"service_template_id" json:"service_template_id" bson:"service_template_id" mapstructure:"service_template_id"`
+	SetTemplateID     int64                     `field:"set_template_id" json:"set_template_id" bson:"set_template_id" mapstructure:"set_template_id"`
+	PropertyValue     interface{}               `field:"bk_property_value" json:"bk_property_value" bson:"bk_property_value" mapstructure:"bk_property_value"`
+	ValueTemplate     string                    `field:"bk_value_template" json:"bk_value_template" bson:"bk_value_template" mapstructure:"bk_value_template"`
+	Condition         *querybuilder.QueryFilter `field:"bk_condition" json:"bk_condition,omitempty" bson:"bk_condition,omitempty" mapstructure:"bk_condition"`
+	Priority          int64                     `field:"bk_priority" json:"bk_priority" bson:"bk_priority" mapstructure:"bk_priority"`
 }
 
+// UpdateHostApplyRuleOption update the rule's fields that are set, the others keep unchanged
 type UpdateHostApplyRuleOption struct {
-	PropertyValue interface{} `field:"bk_property_value" json:"bk_property_value" bson:"bk_property_value" mapstructure:"bk_property_value"`
+	PropertyValue interface{}               `field:"bk_property_value" json:"bk_property_value,omitempty" bson:"bk_property_value" mapstructure:"bk_property_value"`
+	ValueTemplate *string                   `field:"bk_value_template" json:"bk_value_template,omitempty" bson:"bk_value_template" mapstructure:"bk_value_template"`
+	Condition     *querybuilder.QueryFilter `field:"bk_condition" json:"bk_condition,omitempty" bson:"bk_condition,omitempty" mapstructure:"bk_condition"`
+	Priority      *int64                    `field:"bk_priority" json:"bk_priority,omitempty" bson:"bk_priority" mapstructure:"bk_priority"`
+	// ClearCondition removes the condition of the rule, it can't be used together with condition
+	ClearCondition bool `field:"clear_condition" json:"clear_condition,omitempty" bson:"-" mapstructure:"clear_condition"`
 }
 
 type MultipleHostApplyRuleResult struct {
@@ -61,9 +136,15 @@ type MultipleHostApplyRuleResult struct {
 }
 
 type ListHostApplyRuleOption struct {
-	ModuleIDs    []int64  `field:"bk_module_ids" json:"bk_module_ids" bson:"bk_module_ids" mapstructure:"bk_module_ids"`
-	AttributeIDs []int64  `field:"bk_attribute_ids" json:"bk_attribute_ids" bson:"bk_attribute_ids" mapstructure:"bk_attribute_ids"`
-	Page         BasePage `field:"page" json:"page" bson:"page" mapstructure:"page"`
+	ModuleIDs          []int64  `field:"bk_module_ids" json:"bk_module_ids" bson:"bk_module_ids" mapstructure:"bk_module_ids"`
+	ServiceTemplateIDs []int64  `field:"service_template_ids" json:"service_template_ids" bson:"service_template_ids" mapstructure:"service_template_ids"`
+	SetTemplateIDs     []int64  `field:"set_template_ids" json:"set_template_ids" bson:"set_template_ids" mapstructure:"set_template_ids"`
+	AttributeIDs       []int64  `field:"bk_attribute_ids" json:"bk_attribute_ids" bson:"bk_attribute_ids" mapstructure:"bk_attribute_ids"`
+	Page               BasePage `field:"page" json:"page" bson:"page" mapstructure:"page"`
+	// WithInherited returns the effective rules of the modules, including the rules inherited from the service
+	// templates and set templates of the modules, the inherited rules' bk_module_id is set to the module they are
+	// applied to and the level shows where they come from. page is not supported in this case.
+	WithInherited bool `field:"with_inherited" json:"with_inherited" bson:"with_inherited" mapstructure:"with_inherited"`
 }
 
 type ListHostRelatedApplyRuleOption struct {
@@ -84,9 +165,14 @@ type UpdateHostByHostApplyRuleOption struct {
 }
 
 type CreateOrUpdateApplyRuleOption struct {
-	AttributeID   int64       `field:"bk_attribute_id" json:"bk_attribute_id" bson:"bk_attribute_id" mapstructure:"bk_attribute_id"`
-	ModuleID      int64       `field:"bk_module_id" json:"bk_module_id" bson:"bk_module_id" mapstructure:"bk_module_id"`
-	PropertyValue interface{} `field:"bk_property_value" json:"bk_property_value" bson:"bk_property_value" mapstructure:"bk_property_value"`
+	AttributeID       int64                     `field:"bk_attribute_id" json:"bk_attribute_id" bson:"bk_attribute_id" mapstructure:"bk_attribute_id"`
+	ModuleID          int64                     `field:"bk_module_id" json:"bk_module_id" bson:"bk_module_id" mapstructure:"bk_module_id"`
+	ServiceTemplateID int64                     `field:"service_template_id" json:"service_template_id" bson:"service_template_id" mapstructure:"service_template_id"`
+	SetTemplateID     int64                     `field:"set_template_id" json:"set_template_id" bson:"set_template_id" mapstructure:"set_template_id"`
+	PropertyValue     interface{}               `field:"bk_property_value" json:"bk_property_value" bson:"bk_property_value" mapstructure:"bk_property_value"`
+	ValueTemplate     string                    `field:"bk_value_template" json:"bk_value_template" bson:"bk_value_template" mapstructure:"bk_value_template"`
+	Condition         *querybuilder.QueryFilter `field:"bk_condition" json:"bk_condition,omitempty" bson:"bk_condition,omitempty" mapstructure:"bk_condition"`
+	Priority          int64                     `field:"bk_priority" json:"bk_priority" bson:"bk_priority" mapstructure:"bk_priority"`
 }
 
 type BatchCreateOrUpdateHostApplyRuleResult struct {
@@ -141,6 +227,12 @@ type HostApplyUpdateField struct {
 	AttributeID   int64       `field:"bk_attribute_id" json:"bk_attribute_id" bson:"bk_attribute_id" mapstructure:"bk_attribute_id"`
 	PropertyID    string      `field:"bk_property_id" json:"bk_property_id" mapstructure:"bk_property_id"`
 	PropertyValue interface{} `field:"bk_property_value" json:"bk_property_value" mapstructure:"bk_property_value"`
+	// RuleID and Level is the rule that the value comes from, they are empty if the value comes from conflict resolver
+	RuleID int64              `field:"host_apply_rule_id" json:"host_apply_rule_id" mapstructure:"host_apply_rule_id"`
+	Level  HostApplyRuleLevel `field:"level" json:"level" mapstructure:"level"`
+	// OriginalValue is the value of the host before apply, Drifted shows whether it is different from PropertyValue
+	OriginalValue interface{} `field:"original_value" json:"original_value" mapstructure:"original_value"`
+	Drifted       bool        `field:"drifted" json:"drifted" mapstructure:"drifted"`
 }
 
 type OneHostApplyPlan struct {
@@ -164,6 +256,17 @@ func (plan OneHostApplyPlan) GetUpdateData() map[string]interface{} {
 	return updateData
 }
 
+// GetDriftedFields returns the fields whose host value is different from the value expected by the rules
+func (plan OneHostApplyPlan) GetDriftedFields() []HostApplyUpdateField {
+	fields := make([]HostApplyUpdateField, 0)
+	for _, field := range plan.UpdateFields {
+		if field.Drifted {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
 type HostApplyPlanResult struct {
 	Plans []OneHostApplyPlan `field:"plans" json:"plans" bson:"plans" mapstructure:"plans"`
 	// 未解决的冲突主机数
@@ -222,3 +325,101 @@ type UpdateModuleHostApplyEnableStatusOption struct {
 	Enable     bool `json:"enable" mapstructure:"enable"`
 	ClearRules bool `json:"clear_rules" mapstructure:"clear_rules"`
 }
+
+// HostApplyDriftMode defines how the host apply reconciler deals with the hosts that drifted away from
+// the host apply rules of their modules.
+type HostApplyDriftMode string
+
+const (
+	// HostApplyDriftModeReport only records the drifted hosts as non-compliant hosts
+	HostApplyDriftModeReport HostApplyDriftMode = "report"
+	// HostApplyDriftModeAutoCorrect updates the drifted hosts with the values of the rules
+	HostApplyDriftModeAutoCorrect HostApplyDriftMode = "auto_correct"
+)
+
+// DefaultHostApplyDriftMode is used by the businesses that have not configured the drift mode
+const DefaultHostApplyDriftMode = HostApplyDriftModeReport
+
+func (m HostApplyDriftMode) Validate() error {
+	switch m {
+	case HostApplyDriftModeReport, HostApplyDriftModeAutoCorrect:
+		return nil
+	default:
+		return fmt.Errorf("invalid host apply drift mode: %s", m)
+	}
+}
+
+// HostApplyDriftConfig is the business level config of the host apply drift reconciler
+type HostApplyDriftConfig struct {
+	BizID           int64              `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id" mapstructure:"bk_biz_id"`
+	Mode            HostApplyDriftMode `field:"mode" json:"mode" bson:"mode" mapstructure:"mode"`
+	Modifier        string             `field:"modifier" json:"modifier" bson:"modifier" mapstructure:"modifier"`
+	LastTime        time.Time          `field:"last_time" json:"last_time" bson:"last_time" mapstructure:"last_time"`
+	SupplierAccount string             `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account" mapstructure:"bk_supplier_account"`
+}
+
+type UpdateHostApplyDriftConfigOption struct {
+	Mode HostApplyDriftMode `field:"mode" json:"mode" mapstructure:"mode"`
+}
+
+type ListHostApplyDriftConfigOption struct {
+	BizIDs []int64 `field:"bk_biz_ids" json:"bk_biz_ids" mapstructure:"bk_biz_ids"`
+}
+
+// HostApplyDriftField is a host field whose value is different from the value expected by the host apply rules
+type HostApplyDriftField struct {
+	AttributeID int64              `field:"bk_attribute_id" json:"bk_attribute_id" bson:"bk_attribute_id" mapstructure:"bk_attribute_id"`
+	PropertyID  string             `field:"bk_property_id" json:"bk_property_id" bson:"bk_property_id" mapstructure:"bk_property_id"`
+	ExpectValue interface{}        `field:"expect_value" json:"expect_value" bson:"expect_value" mapstructure:"expect_value"`
+	ActualValue interface{}        `field:"actual_value" json:"actual_value" bson:"actual_value" mapstructure:"actual_value"`
+	RuleID      int64              `field:"host_apply_rule_id" json:"host_apply_rule_id" bson:"host_apply_rule_id" mapstructure:"host_apply_rule_id"`
+	Level       HostApplyRuleLevel `field:"level" json:"level" bson:"level" mapstructure:"level"`
+}
+
+// HostApplyDrift is a non-compliant host found by the host apply drift reconciler
+type HostApplyDrift struct {
+	BizID           int64                 `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id" mapstructure:"bk_biz_id"`
+	HostID          int64                 `field:"bk_host_id" json:"bk_host_id" bson:"bk_host_id" mapstructure:"bk_host_id"`
+	ModuleIDs       []int64               `field:"bk_module_ids" json:"bk_module_ids" bson:"bk_module_ids" mapstructure:"bk_module_ids"`
+	Fields          []HostApplyDriftField `field:"fields" json:"fields" bson:"fields" mapstructure:"fields"`
+	DetectTime      time.Time             `field:"detect_time" json:"detect_time" bson:"detect_time" mapstructure:"detect_time"`
+	SupplierAccount string                `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account" mapstructure:"bk_supplier_account"`
+}
+
+// NewHostApplyDrift generates the drift of the host with the drifted fields of the host's apply plan
+func NewHostApplyDrift(bizID int64, plan OneHostApplyPlan) HostApplyDrift {
+	drift := HostApplyDrift{
+		BizID:      bizID,
+		HostID:     plan.HostID,
+		ModuleIDs:  plan.ModuleIDs,
+		Fields:     make([]HostApplyDriftField, 0),
+		DetectTime: time.Now(),
+	}
+	for _, field := range plan.GetDriftedFields() {
+		drift.Fields = append(drift.Fields, HostApplyDriftField{
+			AttributeID: field.AttributeID,
+			PropertyID:  field.PropertyID,
+			ExpectValue: field.PropertyValue,
+			ActualValue: field.OriginalValue,
+			RuleID:      field.RuleID,
+			Level:       field.Level,
+		})
+	}
+	return drift
+}
+
+// SaveHostApplyDriftOption saves the drifts of the hosts, and removes the drift records of the compliant hosts
+type SaveHostApplyDriftOption struct {
+	Drifts           []HostApplyDrift `field:"drifts" json:"drifts" mapstructure:"drifts"`
+	CompliantHostIDs []int64          `field:"compliant_host_ids" json:"compliant_host_ids" mapstructure:"compliant_host_ids"`
+}
+
+type ListHostApplyDriftOption struct {
+	HostIDs []int64  `field:"bk_host_ids" json:"bk_host_ids" mapstructure:"bk_host_ids"`
+	Page    BasePage `field:"page" json:"page" mapstructure:"page"`
+}
+
+type MultipleHostApplyDrift struct {
+	Count int64            `field:"count" json:"count" bson:"count" mapstructure:"count"`
+	Info  []HostApplyDrift `field:"info" json:"info" bson:"info" mapstructure:"info"`
+}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/querybuilder"
 )
 
 type ID struct {
@@ -148,15 +149,19 @@ type ModuleHostConfigParams struct {
 	OwnerID       string  `json:"bk_supplier_account" bson:"bk_supplier_account"`
 }
 
+// UserConfig is the saved user custom query, which is also used as the dynamic group.
+// the old host custom query only has the Info, while the dynamic group has the ObjID and Filter.
 type UserConfig struct {
-	Info       string    `json:"info" bson:"info"`
-	Name       string    `json:"name" bson:"name"`
-	ID         string    `json:"id" bson:"id"`
-	CreateTime time.Time `json:"create_time" bson:"create_time"`
-	UpdateTime time.Time `json:"last_time" bson:"last_time"`
-	AppID      int64     `json:"bk_biz_id" bson:"bk_biz_id"`
-	CreateUser string    `json:"create_user" bson:"create_user"`
-	ModifyUser string    `json:"modify_user" bson:"modify_user"`
+	Info       string                    `json:"info" bson:"info"`
+	Name       string                    `json:"name" bson:"name"`
+	ObjID      string                    `json:"bk_obj_id,omitempty" bson:"bk_obj_id,omitempty"`
+	Filter     *querybuilder.QueryFilter `json:"filter,omitempty" bson:"filter,omitempty"`
+	ID         string                    `json:"id" bson:"id"`
+	CreateTime time.Time                 `json:"create_time" bson:"create_time"`
+	UpdateTime time.Time                 `json:"last_time" bson:"last_time"`
+	AppID      int64                     `json:"bk_biz_id" bson:"bk_biz_id"`
+	CreateUser string                    `json:"create_user" bson:"create_user"`
+	ModifyUser string                    `json:"modify_user" bson:"modify_user"`
 }
 
 type UserConfigResult struct {
@@ -203,22 +208,26 @@ type GetUserConfigDetailResult struct {
 }
 
 type UserConfigMeta struct {
-	AppID      int64     `json:"bk_biz_id,omitempty" bson:"bk_biz_id,omitempty"`
-	Info       string    `json:"info,omitempty" bson:"info,omitempty"`
-	Name       string    `json:"name,omitempty" bson:"name,omitempty"`
-	ID         string    `json:"id,omitempty" bson:"id,omitempty"`
-	CreateTime time.Time `json:"create_time" bson:"create_time,omitempty"`
-	CreateUser string    `json:"create_user" bson:"create_user,omitempty"`
-	ModifyUser string    `json:"modify_user" bson:"modify_user,omitempty"`
-	UpdateTime time.Time `json:"last_time" bson:"last_time,omitempty"`
-	OwnerID    string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	AppID      int64                     `json:"bk_biz_id,omitempty" bson:"bk_biz_id,omitempty"`
+	Info       string                    `json:"info,omitempty" bson:"info,omitempty"`
+	Name       string                    `json:"name,omitempty" bson:"name,omitempty"`
+	ObjID      string                    `json:"bk_obj_id,omitempty" bson:"bk_obj_id,omitempty"`
+	Filter     *querybuilder.QueryFilter `json:"filter,omitempty" bson:"filter,omitempty"`
+	ID         string                    `json:"id,omitempty" bson:"id,omitempty"`
+	CreateTime time.Time                 `json:"create_time" bson:"create_time,omitempty"`
+	CreateUser string                    `json:"create_user" bson:"create_user,omitempty"`
+	ModifyUser string                    `json:"modify_user" bson:"modify_user,omitempty"`
+	UpdateTime time.Time                 `json:"last_time" bson:"last_time,omitempty"`
+	OwnerID    string                    `json:"bk_supplier_account" bson:"bk_supplier_account"`
 }
 
 type AddConfigQuery struct {
-	AppID      int64  `json:"bk_biz_id,omitempty"`
-	Info       string `json:"info,omitempty"`
-	Name       string `json:"name,omitempty"`
-	CreateUser string `json:"create_user,omitempty"`
+	AppID      int64                     `json:"bk_biz_id,omitempty"`
+	Info       string                    `json:"info,omitempty"`
+	Name       string                    `json:"name,omitempty"`
+	ObjID      string                    `json:"bk_obj_id,omitempty"`
+	Filter     *querybuilder.QueryFilter `json:"filter,omitempty"`
+	CreateUser string                    `json:"create_user,omitempty"`
 }
 
 // TransferHostToInnerModule transfer host to inner module eg:idle module ,fault module
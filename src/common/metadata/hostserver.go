@@ -118,6 +118,8 @@ type HostCommonSearch struct {
 	Condition []SearchCondition `json:"condition"`
 	Page      BasePage          `json:"page"`
 	Pattern   string            `json:"pattern,omitempty"`
+	// HostPropertyQuery is a text query of host properties, see querybuilder.ParseQuery for the syntax.
+	HostPropertyQuery string `json:"host_property_query,omitempty"`
 }
 
 type HostModuleFind struct {
@@ -267,8 +269,12 @@ type ListHostsParameter struct {
 	SetCond            []ConditionItem           `json:"set_cond"`
 	ModuleIDs          []int64                   `json:"bk_module_ids"`
 	HostPropertyFilter *querybuilder.QueryFilter `json:"host_property_filter"`
-	Fields             []string                  `json:"fields"`
-	Page               BasePage                  `json:"page"`
+	// HostPropertyQuery is a text query of host properties, it can't be used with host_property_filter.
+	HostPropertyQuery string `json:"host_property_query"`
+	// DynamicGroupID is the id of a host dynamic group of the business, the hosts must match it's filter.
+	DynamicGroupID string   `json:"dynamic_group_id"`
+	Fields         []string `json:"fields"`
+	Page           BasePage `json:"page"`
 }
 
 func (option ListHostsParameter) Validate() (string, error) {
@@ -285,6 +291,10 @@ func (option ListHostsParameter) Validate() (string, error) {
 		}
 	}
 
+	if option.HostPropertyFilter != nil && len(option.HostPropertyQuery) != 0 {
+		return "host_property_query", fmt.Errorf("host_property_filter and host_property_query can't both be set")
+	}
+
 	if len(option.SetIDs) > 200 {
 		return "bk_set_ids", fmt.Errorf("exceed max length: 200")
 	}
@@ -298,8 +308,10 @@ func (option ListHostsParameter) Validate() (string, error) {
 
 type ListHostsWithNoBizParameter struct {
 	HostPropertyFilter *querybuilder.QueryFilter `json:"host_property_filter"`
-	Fields             []string                  `json:"fields"`
-	Page               BasePage                  `json:"page"`
+	// HostPropertyQuery is a text query of host properties, it can't be used with host_property_filter.
+	HostPropertyQuery string   `json:"host_property_query"`
+	Fields            []string `json:"fields"`
+	Page              BasePage `json:"page"`
 }
 
 func (option ListHostsWithNoBizParameter) Validate() (string, error) {
@@ -316,6 +328,10 @@ func (option ListHostsWithNoBizParameter) Validate() (string, error) {
 		}
 	}
 
+	if option.HostPropertyFilter != nil && len(option.HostPropertyQuery) != 0 {
+		return "host_property_query", fmt.Errorf("host_property_filter and host_property_query can't both be set")
+	}
+
 	return "", nil
 }
 
@@ -357,9 +373,11 @@ func (option ListHosts) Validate() (errKey string, err error) {
 	return "", nil
 }
 
-func (option ListHosts) GetHostPropertyFilter(ctx context.Context) (map[string]interface{}, error) {
+// GetHostPropertyFilter generate the mongo filter of the host property filter, fieldTypes is the host
+// properties' types, which is used to convert the datetime and range values.
+func (option ListHosts) GetHostPropertyFilter(ctx context.Context, fieldTypes querybuilder.FieldTypes) (map[string]interface{}, error) {
 	if option.HostPropertyFilter != nil {
-		mgoFilter, key, err := option.HostPropertyFilter.ToMgo()
+		mgoFilter, key, err := option.HostPropertyFilter.ToMgoWithFieldTypes(fieldTypes)
 		if err != nil {
 			return nil, fmt.Errorf("invalid key:host_property_filter.%s, err: %s", key, err)
 		}
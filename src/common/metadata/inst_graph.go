@@ -0,0 +1,168 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"errors"
+	"fmt"
+
+	"configcenter/src/common/util"
+)
+
+// instance association graph traversal directions, the source of an association is
+// the bk_obj_id/bk_inst_id side, and the target is the bk_asst_obj_id/bk_asst_inst_id side.
+const (
+	// InstGraphDirectionBoth walk the associations in both directions
+	InstGraphDirectionBoth = "both"
+	// InstGraphDirectionOut walk from the source instance to the target instance
+	InstGraphDirectionOut = "out"
+	// InstGraphDirectionIn walk from the target instance to the source instance
+	InstGraphDirectionIn = "in"
+)
+
+const (
+	InstGraphDefaultDepth = 3
+	InstGraphMaxDepth     = 10
+	InstGraphDefaultNodes = 1000
+	InstGraphMaxNodes     = 10000
+)
+
+// InstNode identify an instance of any model in the association graph.
+type InstNode struct {
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+}
+
+func (node InstNode) Validate() (string, error) {
+	if len(node.ObjectID) == 0 {
+		return "bk_obj_id", errors.New("bk_obj_id is required")
+	}
+	if node.InstID <= 0 {
+		return "bk_inst_id", errors.New("bk_inst_id must be a positive integer")
+	}
+	return "", nil
+}
+
+// InstGraphFilter limits how the instance association graph is walked.
+type InstGraphFilter struct {
+	// Direction is one of both, out and in, default is both.
+	Direction string `json:"direction"`
+	// AsstKindIDs only walk through the associations of these kinds, empty means all kinds.
+	AsstKindIDs []string `json:"bk_asst_ids"`
+	// ObjectIDs only walk to the instances of these models, empty means all models.
+	ObjectIDs []string `json:"bk_obj_ids"`
+	// MaxDepth is the max hops from the start instance.
+	MaxDepth int `json:"max_depth"`
+	// MaxNodes is the max instances in the result, the walk stops when it's exhausted.
+	MaxNodes int `json:"max_nodes"`
+}
+
+// Validate check the filter and set the default values.
+func (f *InstGraphFilter) Validate() (string, error) {
+	switch f.Direction {
+	case "":
+		f.Direction = InstGraphDirectionBoth
+	case InstGraphDirectionBoth, InstGraphDirectionOut, InstGraphDirectionIn:
+	default:
+		return "direction", fmt.Errorf("direction must be one of %s, %s, %s", InstGraphDirectionBoth,
+			InstGraphDirectionOut, InstGraphDirectionIn)
+	}
+
+	if f.MaxDepth == 0 {
+		f.MaxDepth = InstGraphDefaultDepth
+	}
+	if f.MaxDepth < 0 || f.MaxDepth > InstGraphMaxDepth {
+		return "max_depth", fmt.Errorf("max_depth must be in range [1, %d]", InstGraphMaxDepth)
+	}
+
+	if f.MaxNodes == 0 {
+		f.MaxNodes = InstGraphDefaultNodes
+	}
+	if f.MaxNodes < 0 || f.MaxNodes > InstGraphMaxNodes {
+		return "max_nodes", fmt.Errorf("max_nodes must be in range [1, %d]", InstGraphMaxNodes)
+	}
+	return "", nil
+}
+
+// WalkOut check if the associations can be walked from the source to the target.
+func (f *InstGraphFilter) WalkOut() bool {
+	return f.Direction != InstGraphDirectionIn
+}
+
+// WalkIn check if the associations can be walked from the target to the source.
+func (f *InstGraphFilter) WalkIn() bool {
+	return f.Direction != InstGraphDirectionOut
+}
+
+// AllowObject check if the instances of the model can be walked to.
+func (f *InstGraphFilter) AllowObject(objID string) bool {
+	return len(f.ObjectIDs) == 0 || util.InStrArr(f.ObjectIDs, objID)
+}
+
+// AllowAsstKind check if the associations of the kind can be walked through.
+func (f *InstGraphFilter) AllowAsstKind(asstKindID string) bool {
+	return len(f.AsstKindIDs) == 0 || util.InStrArr(f.AsstKindIDs, asstKindID)
+}
+
+// SearchInstGraphRequest search the association graph around an instance.
+type SearchInstGraphRequest struct {
+	InstNode        `json:",inline"`
+	InstGraphFilter `json:",inline"`
+}
+
+func (r *SearchInstGraphRequest) Validate() (string, error) {
+	if key, err := r.InstNode.Validate(); err != nil {
+		return key, err
+	}
+	return r.InstGraphFilter.Validate()
+}
+
+// SearchInstShortestPathRequest search the shortest association path between two instances.
+type SearchInstShortestPathRequest struct {
+	Source          InstNode `json:"source"`
+	Target          InstNode `json:"target"`
+	InstGraphFilter `json:",inline"`
+}
+
+func (r *SearchInstShortestPathRequest) Validate() (string, error) {
+	if key, err := r.Source.Validate(); err != nil {
+		return "source." + key, err
+	}
+	if key, err := r.Target.Validate(); err != nil {
+		return "target." + key, err
+	}
+	return r.InstGraphFilter.Validate()
+}
+
+// InstGraphNode is an instance in the association graph, depth is the hops from the start instance.
+type InstGraphNode struct {
+	InstNode `json:",inline"`
+	InstName string `json:"bk_inst_name"`
+	Depth    int    `json:"depth"`
+}
+
+// InstGraphResult is the sub graph around the start instance.
+type InstGraphResult struct {
+	Nodes []InstGraphNode `json:"nodes"`
+	Edges []InstAsst      `json:"edges"`
+	// Truncated is true if some instances are dropped because max_nodes is exhausted.
+	Truncated bool `json:"truncated"`
+}
+
+// InstShortestPathResult is the shortest path from the source instance to the target instance,
+// nodes and edges are in the order of the path.
+type InstShortestPathResult struct {
+	Found     bool            `json:"found"`
+	Nodes     []InstGraphNode `json:"nodes"`
+	Edges     []InstAsst      `json:"edges"`
+	Truncated bool            `json:"truncated"`
+}
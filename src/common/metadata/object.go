@@ -32,6 +32,8 @@ const (
 	ModelFieldModifier    = "modifier"
 	ModelFieldCreateTime  = "create_time"
 	ModelFieldLastTime    = "last_time"
+
+	ModelFieldValidationRules = "bk_validation_rules"
 )
 
 // Object object metadata definition
@@ -51,6 +53,9 @@ type Object struct {
 	Modifier    string `field:"modifier" json:"modifier" bson:"modifier"`
 	CreateTime  *Time  `field:"create_time" json:"create_time" bson:"create_time"`
 	LastTime    *Time  `field:"last_time" json:"last_time" bson:"last_time"`
+
+	// ValidationRules is the rules that validate the fields of the model's instances together
+	ValidationRules []ValidationRule `json:"bk_validation_rules,omitempty" bson:"bk_validation_rules,omitempty"`
 }
 
 // GetDefaultInstPropertyName get default inst
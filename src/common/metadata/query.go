@@ -17,6 +17,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/querybuilder"
 )
 
 // Deprecated: SearchLimit sub condition
@@ -36,6 +37,9 @@ type QueryCondition struct {
 	Fields    []string      `json:"fields"`
 	Page      BasePage      `json:"page"`
 	Condition mapstr.MapStr `json:"condition"`
+	// Filter is combined with the condition, it's converted with the model's attribute types,
+	// so that the datetime values are compared correctly.
+	Filter *querybuilder.QueryFilter `json:"filter,omitempty"`
 }
 
 // IsIllegal  limit is illegal, if limit = 0; change to default page size
@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+// RecycleBinItem is an instance, host or topology node moved into the recycle bin when it's deleted, the
+// associations of the instance are captured along with it so that they can be restored together.
+type RecycleBinItem struct {
+	ID       int64  `json:"id" bson:"id"`
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id" bson:"bk_inst_id"`
+	InstName string `json:"bk_inst_name" bson:"bk_inst_name"`
+	// BizID is the business the instance belongs to, it's 0 for the instances not in a business
+	BizID int64 `json:"bk_biz_id" bson:"bk_biz_id"`
+	// Data is the deleted instance document
+	Data         mapstr.MapStr `json:"data" bson:"data"`
+	Associations []InstAsst    `json:"associations" bson:"associations"`
+	Operator     string        `json:"operator" bson:"operator"`
+	DeleteTime   Time          `json:"delete_time" bson:"delete_time"`
+	// ExpireTime is the time the item is purged by the recycle bin job of coreservice
+	ExpireTime      Time   `json:"expire_time" bson:"expire_time"`
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+type ListRecycleBinOption struct {
+	ObjectID string   `json:"bk_obj_id"`
+	BizID    int64    `json:"bk_biz_id"`
+	Page     BasePage `json:"page"`
+}
+
+// Validate validates the option, it returns the invalid field if it's not valid
+func (o *ListRecycleBinOption) Validate() (string, bool) {
+	if o.Page.Limit == 0 {
+		o.Page.Limit = common.BKDefaultLimit
+	}
+	if o.Page.IsIllegal() {
+		return "page.limit", false
+	}
+	if len(o.Page.Sort) == 0 {
+		return "", true
+	}
+	// the sort is in the form of the db sort, like "-delete_time,id" or "delete_time:-1"
+	for _, item := range strings.Split(o.Page.Sort, ",") {
+		field := strings.TrimLeft(strings.TrimSpace(strings.Split(item, ":")[0]), "+-")
+		if !recycleBinSortFields[field] {
+			return "page.sort", false
+		}
+	}
+	return "", true
+}
+
+// recycleBinSortFields are the fields the recycle bin items can be sorted by, the data of an item is not
+// sortable, because it's the deleted document of any kind of instance
+var recycleBinSortFields = map[string]bool{
+	common.BKFieldID:       true,
+	common.BKObjIDField:    true,
+	common.BKInstIDField:   true,
+	common.BKInstNameField: true,
+	common.BKAppIDField:    true,
+	"operator":             true,
+	"delete_time":          true,
+	"expire_time":          true,
+}
+
+type MultipleRecycleBinItem struct {
+	Count int64            `json:"count"`
+	Info  []RecycleBinItem `json:"info"`
+}
+
+type PurgeRecycleBinOption struct {
+	IDs []int64 `json:"ids"`
+}
+
+// Validate validates the option, it returns the invalid field if it's not valid
+func (o *PurgeRecycleBinOption) Validate() (string, bool) {
+	if len(o.IDs) == 0 || len(o.IDs) > common.BKMaxPageSize {
+		return "ids", false
+	}
+	return "", true
+}
+
+type PurgeRecycleBinResult struct {
+	// Count is the number of the purged items
+	Count int64 `json:"count"`
+}
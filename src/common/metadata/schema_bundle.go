@@ -0,0 +1,218 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+
+	"configcenter/src/common"
+)
+
+// SchemaBundleAPIVersion is the version of the schema bundle format
+const SchemaBundleAPIVersion = "v1"
+
+// SchemaBundle is the declarative definition of the global models, it can be exported as json or yaml, kept in
+// git, and applied to another cmdb. Applying a bundle only creates and updates, nothing is deleted.
+type SchemaBundle struct {
+	APIVersion string `json:"api_version" yaml:"api_version"`
+	// Version is the version of the bundle content, it's set by the user and not interpreted by cmdb.
+	Version         string                 `json:"version" yaml:"version"`
+	Classifications []SchemaClassification `json:"classifications" yaml:"classifications"`
+	Objects         []SchemaObject         `json:"objects" yaml:"objects"`
+	Associations    []SchemaAssociation    `json:"associations" yaml:"associations"`
+}
+
+type SchemaClassification struct {
+	ID   string `json:"bk_classification_id" yaml:"bk_classification_id"`
+	Name string `json:"bk_classification_name" yaml:"bk_classification_name"`
+	Type string `json:"bk_classification_type" yaml:"bk_classification_type"`
+	Icon string `json:"bk_classification_icon" yaml:"bk_classification_icon"`
+}
+
+type SchemaObject struct {
+	ID               string                 `json:"bk_obj_id" yaml:"bk_obj_id"`
+	Name             string                 `json:"bk_obj_name" yaml:"bk_obj_name"`
+	ClassificationID string                 `json:"bk_classification_id" yaml:"bk_classification_id"`
+	Icon             string                 `json:"bk_obj_icon" yaml:"bk_obj_icon"`
+	Groups           []SchemaAttributeGroup `json:"groups" yaml:"groups"`
+	Attributes       []SchemaAttribute      `json:"attributes" yaml:"attributes"`
+	Uniques          []SchemaUnique         `json:"uniques" yaml:"uniques"`
+}
+
+type SchemaAttributeGroup struct {
+	ID         string `json:"bk_group_id" yaml:"bk_group_id"`
+	Name       string `json:"bk_group_name" yaml:"bk_group_name"`
+	Index      int64  `json:"bk_group_index" yaml:"bk_group_index"`
+	IsCollapse bool   `json:"is_collapse" yaml:"is_collapse"`
+}
+
+type SchemaAttribute struct {
+	ID           string      `json:"bk_property_id" yaml:"bk_property_id"`
+	Name         string      `json:"bk_property_name" yaml:"bk_property_name"`
+	GroupID      string      `json:"bk_property_group" yaml:"bk_property_group"`
+	Index        int64       `json:"bk_property_index" yaml:"bk_property_index"`
+	PropertyType string      `json:"bk_property_type" yaml:"bk_property_type"`
+	Unit         string      `json:"unit" yaml:"unit"`
+	Placeholder  string      `json:"placeholder" yaml:"placeholder"`
+	IsEditable   bool        `json:"editable" yaml:"editable"`
+	IsRequired   bool        `json:"isrequired" yaml:"isrequired"`
+	Option       interface{} `json:"option" yaml:"option"`
+	Description  string      `json:"description" yaml:"description"`
+}
+
+// SchemaUnique is a unique constraint of a model, its keys are the property ids of the model attributes
+type SchemaUnique struct {
+	Keys      []string `json:"keys" yaml:"keys"`
+	MustCheck bool     `json:"must_check" yaml:"must_check"`
+}
+
+type SchemaAssociation struct {
+	ID        string                    `json:"bk_obj_asst_id" yaml:"bk_obj_asst_id"`
+	Name      string                    `json:"bk_obj_asst_name" yaml:"bk_obj_asst_name"`
+	ObjectID  string                    `json:"bk_obj_id" yaml:"bk_obj_id"`
+	AsstObjID string                    `json:"bk_asst_obj_id" yaml:"bk_asst_obj_id"`
+	AsstKind  string                    `json:"bk_asst_id" yaml:"bk_asst_id"`
+	Mapping   AssociationMapping        `json:"mapping" yaml:"mapping"`
+	OnDelete  AssociationOnDeleteAction `json:"on_delete" yaml:"on_delete"`
+}
+
+// Validate checks the bundle itself, the references to the existing models are checked when it's planned
+func (b *SchemaBundle) Validate() error {
+	if b.APIVersion != SchemaBundleAPIVersion {
+		return fmt.Errorf("api_version %s is not supported, it should be %s", b.APIVersion, SchemaBundleAPIVersion)
+	}
+
+	classifications := make(map[string]bool)
+	for _, cls := range b.Classifications {
+		if len(cls.ID) == 0 || len(cls.Name) == 0 {
+			return fmt.Errorf("bk_classification_id or bk_classification_name of classification is not set")
+		}
+		if classifications[cls.ID] {
+			return fmt.Errorf("classification %s is duplicated", cls.ID)
+		}
+		classifications[cls.ID] = true
+	}
+
+	objects := make(map[string]bool)
+	for _, obj := range b.Objects {
+		if len(obj.ID) == 0 || len(obj.Name) == 0 || len(obj.ClassificationID) == 0 {
+			return fmt.Errorf("bk_obj_id, bk_obj_name or bk_classification_id of object is not set")
+		}
+		if objects[obj.ID] {
+			return fmt.Errorf("object %s is duplicated", obj.ID)
+		}
+		objects[obj.ID] = true
+
+		groups := make(map[string]bool)
+		for _, grp := range obj.Groups {
+			if len(grp.ID) == 0 || len(grp.Name) == 0 {
+				return fmt.Errorf("bk_group_id or bk_group_name of object %s group is not set", obj.ID)
+			}
+			if groups[grp.ID] {
+				return fmt.Errorf("group %s of object %s is duplicated", grp.ID, obj.ID)
+			}
+			groups[grp.ID] = true
+		}
+
+		attributes := make(map[string]bool)
+		for _, attr := range obj.Attributes {
+			if len(attr.ID) == 0 || len(attr.Name) == 0 || len(attr.PropertyType) == 0 {
+				return fmt.Errorf("bk_property_id, bk_property_name or bk_property_type of object %s attribute is not set",
+					obj.ID)
+			}
+			if attributes[attr.ID] {
+				return fmt.Errorf("attribute %s of object %s is duplicated", attr.ID, obj.ID)
+			}
+			attributes[attr.ID] = true
+		}
+
+		for _, unique := range obj.Uniques {
+			if len(unique.Keys) == 0 {
+				return fmt.Errorf("keys of object %s unique is not set", obj.ID)
+			}
+		}
+	}
+
+	associations := make(map[string]bool)
+	for _, asst := range b.Associations {
+		if len(asst.ID) == 0 || len(asst.ObjectID) == 0 || len(asst.AsstObjID) == 0 || len(asst.AsstKind) == 0 ||
+			len(asst.Mapping) == 0 {
+			return fmt.Errorf("bk_obj_asst_id, bk_obj_id, bk_asst_obj_id, bk_asst_id or mapping of association is not set")
+		}
+		if asst.AsstKind == common.AssociationKindMainline {
+			return fmt.Errorf("association %s is a mainline association, it can't be applied by bundle", asst.ID)
+		}
+		if associations[asst.ID] {
+			return fmt.Errorf("association %s is duplicated", asst.ID)
+		}
+		associations[asst.ID] = true
+	}
+	return nil
+}
+
+type ExportSchemaBundleOption struct {
+	// ObjectIDs is the objects to export, all the global objects are exported if it's empty
+	ObjectIDs []string `json:"bk_obj_ids"`
+	Version   string   `json:"version"`
+	// Format is json or yaml, the yaml bundle is returned as a string
+	Format string `json:"format"`
+}
+
+const (
+	SchemaBundleFormatJSON = "json"
+	SchemaBundleFormatYAML = "yaml"
+)
+
+// SchemaBundleRequest is the bundle to plan or apply, it's either the bundle itself or its json or yaml content
+type SchemaBundleRequest struct {
+	Bundle  *SchemaBundle `json:"bundle"`
+	Content string        `json:"content"`
+}
+
+type SchemaBundleKind string
+
+const (
+	SchemaBundleKindClassification SchemaBundleKind = "classification"
+	SchemaBundleKindObject         SchemaBundleKind = "object"
+	SchemaBundleKindGroup          SchemaBundleKind = "attribute_group"
+	SchemaBundleKindAttribute      SchemaBundleKind = "attribute"
+	SchemaBundleKindUnique         SchemaBundleKind = "unique"
+	SchemaBundleKindAssociation    SchemaBundleKind = "association"
+)
+
+const (
+	SchemaBundleActionCreate = "create"
+	SchemaBundleActionUpdate = "update"
+)
+
+// SchemaBundlePlan is the changes needed to apply a bundle, the bundle can't be applied if it has conflicts
+type SchemaBundlePlan struct {
+	Changes   []SchemaBundleChange   `json:"changes"`
+	Conflicts []SchemaBundleConflict `json:"conflicts"`
+}
+
+type SchemaBundleChange struct {
+	Kind     SchemaBundleKind `json:"kind"`
+	Action   string           `json:"action"`
+	ObjectID string           `json:"bk_obj_id,omitempty"`
+	ID       string           `json:"id"`
+	// Fields is the changed fields of an update
+	Fields []string `json:"fields,omitempty"`
+}
+
+type SchemaBundleConflict struct {
+	Kind     SchemaBundleKind `json:"kind"`
+	ObjectID string           `json:"bk_obj_id,omitempty"`
+	ID       string           `json:"id"`
+	Reason   string           `json:"reason"`
+}
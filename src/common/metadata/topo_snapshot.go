@@ -0,0 +1,166 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+)
+
+// TopoSnapshotTrigger is how a topology snapshot is taken
+type TopoSnapshotTrigger string
+
+const (
+	// TopoSnapshotManual means the snapshot is taken on demand by a user
+	TopoSnapshotManual TopoSnapshotTrigger = "manual"
+	// TopoSnapshotScheduled means the snapshot is taken by the schedule of the business
+	TopoSnapshotScheduled TopoSnapshotTrigger = "scheduled"
+)
+
+// TopoSnapshot is the business mainline topology and its hosts at a point in time, the nodes of the
+// snapshot are saved separately, so that a large business is not limited by the document size.
+type TopoSnapshot struct {
+	ID              int64               `json:"id" bson:"id"`
+	BizID           int64               `json:"bk_biz_id" bson:"bk_biz_id"`
+	Name            string              `json:"name" bson:"name"`
+	Trigger         TopoSnapshotTrigger `json:"trigger" bson:"trigger"`
+	NodeCount       int64               `json:"node_count" bson:"node_count"`
+	Creator         string              `json:"creator" bson:"creator"`
+	CreateTime      Time                `json:"create_time" bson:"create_time"`
+	SupplierAccount string              `json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// TopoSnapshotNode is a business, custom level, set, module or host instance in a topology snapshot
+type TopoSnapshotNode struct {
+	SnapshotID int64  `json:"-" bson:"snapshot_id"`
+	ObjectID   string `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID     int64  `json:"bk_inst_id" bson:"bk_inst_id"`
+	InstName   string `json:"bk_inst_name" bson:"bk_inst_name"`
+	// ParentObjectID and ParentInstIDs is where the node is in the topology, a host may be in several modules,
+	// the other nodes have only one parent, and the business has none.
+	ParentObjectID string                 `json:"parent_obj_id" bson:"parent_obj_id"`
+	ParentInstIDs  []int64                `json:"parent_inst_ids" bson:"parent_inst_ids"`
+	Data           map[string]interface{} `json:"data" bson:"data"`
+}
+
+type CreateTopoSnapshotOption struct {
+	Name string `json:"name"`
+}
+
+// Validate validates the option, it returns the invalid field if it's not valid
+func (o *CreateTopoSnapshotOption) Validate() (string, bool) {
+	if len(o.Name) > common.NameFieldMaxLength {
+		return "name", false
+	}
+	return "", true
+}
+
+type ListTopoSnapshotOption struct {
+	Trigger TopoSnapshotTrigger `json:"trigger"`
+	Page    BasePage            `json:"page"`
+}
+
+// Validate validates the option, it returns the invalid field if it's not valid
+func (o *ListTopoSnapshotOption) Validate() (string, bool) {
+	if len(o.Trigger) > 0 && o.Trigger != TopoSnapshotManual && o.Trigger != TopoSnapshotScheduled {
+		return "trigger", false
+	}
+	if o.Page.Limit == 0 {
+		o.Page.Limit = common.BKDefaultLimit
+	}
+	if o.Page.IsIllegal() {
+		return "page.limit", false
+	}
+	return "", true
+}
+
+type MultipleTopoSnapshot struct {
+	Count int64          `json:"count"`
+	Info  []TopoSnapshot `json:"info"`
+}
+
+// DiffTopoSnapshotOption is the two snapshots to compare, the current topology is used if To is 0
+type DiffTopoSnapshotOption struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// Validate validates the option, it returns the invalid field if it's not valid
+func (o *DiffTopoSnapshotOption) Validate() (string, bool) {
+	if o.From <= 0 {
+		return "from", false
+	}
+	if o.To < 0 || o.To == o.From {
+		return "to", false
+	}
+	return "", true
+}
+
+// TopoSnapshotDiff is the difference of the topology between two snapshots, a node can be both moved
+// and modified.
+type TopoSnapshotDiff struct {
+	From     int64                    `json:"from"`
+	To       int64                    `json:"to"`
+	Added    []TopoSnapshotNode       `json:"added"`
+	Removed  []TopoSnapshotNode       `json:"removed"`
+	Moved    []TopoSnapshotNodeMove   `json:"moved"`
+	Modified []TopoSnapshotNodeChange `json:"modified"`
+}
+
+type TopoSnapshotNodeMove struct {
+	ObjectID           string  `json:"bk_obj_id"`
+	InstID             int64   `json:"bk_inst_id"`
+	InstName           string  `json:"bk_inst_name"`
+	FromParentObjectID string  `json:"from_parent_obj_id"`
+	FromParentInstIDs  []int64 `json:"from_parent_inst_ids"`
+	ToParentObjectID   string  `json:"to_parent_obj_id"`
+	ToParentInstIDs    []int64 `json:"to_parent_inst_ids"`
+}
+
+type TopoSnapshotNodeChange struct {
+	ObjectID string                    `json:"bk_obj_id"`
+	InstID   int64                     `json:"bk_inst_id"`
+	InstName string                    `json:"bk_inst_name"`
+	Fields   []TopoSnapshotFieldChange `json:"fields"`
+}
+
+type TopoSnapshotFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// TopoSnapshotSchedule takes snapshots of a business every interval hours, and keeps the latest
+// MaxSnapshots scheduled snapshots, the snapshots taken on demand are kept until they are deleted.
+type TopoSnapshotSchedule struct {
+	BizID int64 `json:"bk_biz_id" bson:"bk_biz_id"`
+	// IntervalHours is the hours between two snapshots, the schedule is removed if it's 0.
+	IntervalHours   int64  `json:"interval_hours" bson:"interval_hours"`
+	MaxSnapshots    int64  `json:"max_snapshots" bson:"max_snapshots"`
+	SupplierAccount string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Modifier        string `json:"modifier" bson:"modifier"`
+	LastTime        Time   `json:"last_time" bson:"last_time"`
+}
+
+// TopoSnapshotMaxKept is the max count of the scheduled snapshots a business can keep
+const TopoSnapshotMaxKept = 1000
+
+// Validate validates the schedule, it returns the invalid field if it's not valid
+func (s *TopoSnapshotSchedule) Validate() (string, bool) {
+	if s.IntervalHours < 0 {
+		return "interval_hours", false
+	}
+	if s.IntervalHours > 0 && (s.MaxSnapshots <= 0 || s.MaxSnapshots > TopoSnapshotMaxKept) {
+		return "max_snapshots", false
+	}
+	return "", true
+}
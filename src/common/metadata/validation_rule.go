@@ -0,0 +1,215 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+// ValidationRule is a model level rule that validates the fields of an instance together, for example,
+// {"condition": "bk_state == 'Production'", "required": ["bk_bak_operator"]} or
+// {"assert": "end_date > start_date", "field": "end_date"}.
+type ValidationRule struct {
+	// Condition is the expression that decides whether the rule applies, the rule always applies if it's empty
+	Condition string `json:"condition" bson:"condition"`
+	// Required is the fields that must be set when the rule applies
+	Required []string `json:"required" bson:"required"`
+	// Assert is the expression that must be true when the rule applies, it's skipped if any of
+	// the fields it references is empty, which can be checked by Required.
+	Assert string `json:"assert" bson:"assert"`
+	// Field is the field that the assert error is reported on, default to the first field referenced by Assert
+	Field string `json:"field" bson:"field"`
+}
+
+// ParseValidationRules convert val to validation rules
+func ParseValidationRules(val interface{}) ([]ValidationRule, error) {
+	rules := make([]ValidationRule, 0)
+	if val == nil {
+		return rules, nil
+	}
+	if value, ok := val.([]ValidationRule); ok {
+		return value, nil
+	}
+
+	js, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(js, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Validate check if the rule is valid, returns the invalid key and the error
+func (r ValidationRule) Validate() (string, error) {
+	if len(r.Required) == 0 && r.Assert == "" {
+		return "required", fmt.Errorf("either required or assert should be set")
+	}
+
+	if r.Condition != "" {
+		if _, err := parseRuleExpression(r.Condition); err != nil {
+			return "condition", err
+		}
+	}
+
+	for _, field := range r.Required {
+		if field == "" {
+			return "required", fmt.Errorf("required field can not be empty")
+		}
+	}
+
+	if r.Assert != "" {
+		if _, err := parseRuleExpression(r.Assert); err != nil {
+			return "assert", err
+		}
+	}
+	return "", nil
+}
+
+// Fields returns the property ids of the fields that the rule uses
+func (r ValidationRule) Fields() []string {
+	fields := make([]string, 0)
+	for _, text := range []string{r.Condition, r.Assert} {
+		if text == "" {
+			continue
+		}
+		expr, err := parseRuleExpression(text)
+		if err != nil {
+			continue
+		}
+		for _, ref := range expr.References() {
+			fields = append(fields, ref.PropertyID())
+		}
+	}
+	fields = append(fields, r.Required...)
+	if r.Field != "" {
+		fields = append(fields, r.Field)
+	}
+	return util.StrArrayUnique(fields)
+}
+
+// Check validates the instance with the rule
+func (r ValidationRule) Check(ctx context.Context, inst mapstr.MapStr) errors.RawErrorInfo {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	resolve := func(ref expression.Reference) (interface{}, error) {
+		return GetInstanceFieldValue(inst, ref.Field), nil
+	}
+
+	condition := "true"
+	if r.Condition != "" {
+		condition = r.Condition
+		expr, err := parseRuleExpression(r.Condition)
+		if err != nil {
+			blog.Errorf("validation rule condition %s is invalid, skip it, err: %v, rid: %s", r.Condition, err, rid)
+			return errors.RawErrorInfo{}
+		}
+		result, err := expr.Evaluate(resolve)
+		if err == nil {
+			result, err = expression.Convert(result, common.FieldTypeBool)
+		}
+		if err != nil {
+			blog.V(4).Infof("evaluate validation rule condition %s failed, skip it, err: %v, rid: %s", r.Condition, err, rid)
+			return errors.RawErrorInfo{}
+		}
+		if result != true {
+			return errors.RawErrorInfo{}
+		}
+	}
+
+	for _, field := range r.Required {
+		if isEmptyFieldValue(GetInstanceFieldValue(inst, field)) {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommValidationRuleRequired,
+				Args:    []interface{}{field, condition},
+			}
+		}
+	}
+
+	if r.Assert == "" {
+		return errors.RawErrorInfo{}
+	}
+	expr, err := parseRuleExpression(r.Assert)
+	if err != nil {
+		blog.Errorf("validation rule assert %s is invalid, skip it, err: %v, rid: %s", r.Assert, err, rid)
+		return errors.RawErrorInfo{}
+	}
+	field := r.Field
+	for _, ref := range expr.References() {
+		if isEmptyFieldValue(GetInstanceFieldValue(inst, ref.Field)) {
+			return errors.RawErrorInfo{}
+		}
+		if field == "" {
+			field = ref.PropertyID()
+		}
+	}
+	result, err := expr.Evaluate(resolve)
+	if err == nil {
+		result, err = expression.Convert(result, common.FieldTypeBool)
+	}
+	if err != nil || result != true {
+		blog.Errorf("instance does not satisfy the validation rule %s, result: %v, err: %v, rid: %s", r.Assert, result, err, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommValidationRuleFailed,
+			Args:    []interface{}{field, r.Assert},
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// parseRuleExpression parse the expression of validation rule, which can only reference the instance's own fields
+func parseRuleExpression(text string) (*expression.Expression, error) {
+	expr, err := expression.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range expr.References() {
+		if ref.Scope != expression.ScopeSelf {
+			return nil, fmt.Errorf("validation rule can only reference the instance's own fields, but got %s", ref)
+		}
+	}
+	return expr, nil
+}
+
+// GetInstanceFieldValue returns the field value of the instance, the table column like "disks.size" is returned as
+// the column values of all the rows.
+func GetInstanceFieldValue(inst mapstr.MapStr, field string) interface{} {
+	if inst == nil {
+		return nil
+	}
+	if idx := strings.Index(field, "."); idx > 0 {
+		return GetTableColumnValues(inst[field[:idx]], field[idx+1:])
+	}
+	return inst[field]
+}
+
+func isEmptyFieldValue(val interface{}) bool {
+	switch value := val.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(value) == ""
+	}
+	values, ok := toInterfaceSlice(val)
+	return ok && len(values) == 0
+}
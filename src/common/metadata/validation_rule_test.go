@@ -0,0 +1,197 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationRuleValidate(t *testing.T) {
+	testCases := []struct {
+		name string
+		rule ValidationRule
+		key  string
+	}{
+		{name: "required", rule: ValidationRule{Required: []string{"bk_bak_operator"}}},
+		{name: "assert", rule: ValidationRule{Assert: "end_date > start_date"}},
+		{name: "condition", rule: ValidationRule{Condition: `bk_state == "Production"`, Required: []string{"bk_bak_operator"}}},
+		{name: "nothing to check", rule: ValidationRule{Condition: `bk_state == "Production"`}, key: "required"},
+		{name: "empty required field", rule: ValidationRule{Required: []string{""}}, key: "required"},
+		{name: "invalid condition", rule: ValidationRule{Condition: "bk_state ==", Required: []string{"a"}}, key: "condition"},
+		{name: "invalid assert", rule: ValidationRule{Assert: "(end_date"}, key: "assert"},
+		{name: "reference parent", rule: ValidationRule{Assert: "$parent.bk_set_name != null"}, key: "assert"},
+		{name: "reference business", rule: ValidationRule{Condition: "$biz.bk_biz_name", Required: []string{"a"}}, key: "condition"},
+	}
+
+	for _, testCase := range testCases {
+		key, err := testCase.rule.Validate()
+		require.Equal(t, testCase.key, key, testCase.name)
+		require.Equal(t, testCase.key != "", err != nil, testCase.name)
+	}
+}
+
+func TestValidationRuleCheck(t *testing.T) {
+	requiredRule := ValidationRule{Condition: `bk_state == "Production"`, Required: []string{"bk_bak_operator"}}
+	assertRule := ValidationRule{Assert: "end_date > start_date"}
+	fieldRule := ValidationRule{Assert: "sum(disks.size) <= 1024", Field: "disks"}
+
+	testCases := []struct {
+		name    string
+		rule    ValidationRule
+		inst    mapstr.MapStr
+		errCode int
+		errArgs []interface{}
+	}{
+		{
+			name: "required is set",
+			rule: requiredRule,
+			inst: mapstr.MapStr{"bk_state": "Production", "bk_bak_operator": "admin"},
+		},
+		{
+			name: "condition is not satisfied",
+			rule: requiredRule,
+			inst: mapstr.MapStr{"bk_state": "Testing"},
+		},
+		{
+			name: "condition can not be evaluated",
+			rule: ValidationRule{Condition: "bk_state * 2", Required: []string{"bk_bak_operator"}},
+			inst: mapstr.MapStr{"bk_state": "Production"},
+		},
+		{
+			name:    "required is missing",
+			rule:    requiredRule,
+			inst:    mapstr.MapStr{"bk_state": "Production"},
+			errCode: common.CCErrCommValidationRuleRequired,
+			errArgs: []interface{}{"bk_bak_operator", `bk_state == "Production"`},
+		},
+		{
+			name:    "required is blank",
+			rule:    requiredRule,
+			inst:    mapstr.MapStr{"bk_state": "Production", "bk_bak_operator": "  "},
+			errCode: common.CCErrCommValidationRuleRequired,
+			errArgs: []interface{}{"bk_bak_operator", `bk_state == "Production"`},
+		},
+		{
+			name:    "required without condition",
+			rule:    ValidationRule{Required: []string{"bk_bak_operator"}},
+			inst:    mapstr.MapStr{"bk_bak_operator": []interface{}{}},
+			errCode: common.CCErrCommValidationRuleRequired,
+			errArgs: []interface{}{"bk_bak_operator", "true"},
+		},
+		{
+			name: "assert is satisfied",
+			rule: assertRule,
+			inst: mapstr.MapStr{"start_date": int64(1), "end_date": int64(2)},
+		},
+		{
+			name: "assert is skipped when the field is empty",
+			rule: assertRule,
+			inst: mapstr.MapStr{"start_date": int64(1)},
+		},
+		{
+			name:    "assert is not satisfied",
+			rule:    assertRule,
+			inst:    mapstr.MapStr{"start_date": int64(2), "end_date": int64(1)},
+			errCode: common.CCErrCommValidationRuleFailed,
+			errArgs: []interface{}{"end_date", "end_date > start_date"},
+		},
+		{
+			name:    "assert can not be evaluated",
+			rule:    ValidationRule{Assert: "bk_host_name > 1"},
+			inst:    mapstr.MapStr{"bk_host_name": "web"},
+			errCode: common.CCErrCommValidationRuleFailed,
+			errArgs: []interface{}{"bk_host_name", "bk_host_name > 1"},
+		},
+		{
+			name: "table column",
+			rule: fieldRule,
+			inst: mapstr.MapStr{"disks": []interface{}{map[string]interface{}{"size": 512}, map[string]interface{}{"size": 512}}},
+		},
+		{
+			name:    "table column with the error field",
+			rule:    fieldRule,
+			inst:    mapstr.MapStr{"disks": []interface{}{map[string]interface{}{"size": 1000}, map[string]interface{}{"size": 100}}},
+			errCode: common.CCErrCommValidationRuleFailed,
+			errArgs: []interface{}{"disks", "sum(disks.size) <= 1024"},
+		},
+		{
+			name: "invalid rule is skipped",
+			rule: ValidationRule{Assert: "(end_date"},
+			inst: mapstr.MapStr{"end_date": int64(1)},
+		},
+	}
+
+	for _, testCase := range testCases {
+		rawErr := testCase.rule.Check(context.Background(), testCase.inst)
+		require.Equal(t, testCase.errCode, rawErr.ErrCode, testCase.name)
+		require.Equal(t, testCase.errArgs, rawErr.Args, testCase.name)
+	}
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	templates := map[interface{}]bool{
+		"{{bk_inst_name}}":          true,
+		"{{ bk_inst_name }}-{{id}}": true,
+		"host-{{bk_host_innerip}}":  true,
+		"{{}}":                      false,
+		"{{bk-inst-name}}":          false,
+		"{bk_inst_name}":            false,
+		"bk_inst_name":              false,
+		1:                           false,
+		nil:                         false,
+	}
+	for value, expected := range templates {
+		require.Equal(t, expected, IsDefaultTemplate(value), "%v", value)
+	}
+
+	inst := mapstr.MapStr{"bk_inst_name": "web", "bk_cpu": int64(8), "bk_comment": nil}
+	values := map[interface{}]interface{}{
+		"{{bk_inst_name}}-{{ bk_cpu }}": "web-8",
+		"{{bk_comment}}{{bk_unknown}}!": "!",
+		"literal":                       "literal",
+		int64(1):                        int64(1),
+	}
+	for defaultValue, expected := range values {
+		attribute := Attribute{PropertyType: common.FieldTypeSingleChar, Default: defaultValue}
+		require.Equal(t, expected, attribute.GetDefaultValue(inst), "%v", defaultValue)
+	}
+}
+
+func TestValidDefault(t *testing.T) {
+	testCases := []struct {
+		name      string
+		attribute Attribute
+		valid     bool
+	}{
+		{name: "no default", attribute: Attribute{PropertyType: common.FieldTypeInt}, valid: true},
+		{name: "char template", attribute: Attribute{PropertyType: common.FieldTypeSingleChar, Default: "{{bk_inst_name}}"}, valid: true},
+		{name: "long char template", attribute: Attribute{PropertyType: common.FieldTypeLongChar, Default: "{{a}}-{{b}}"}, valid: true},
+		{name: "char literal", attribute: Attribute{PropertyType: common.FieldTypeSingleChar, Default: "web"}, valid: true},
+		{name: "int literal", attribute: Attribute{PropertyType: common.FieldTypeInt, Default: 1}, valid: true},
+		{name: "int template", attribute: Attribute{PropertyType: common.FieldTypeInt, Default: "{{bk_cpu}}"}},
+		{name: "invalid int literal", attribute: Attribute{PropertyType: common.FieldTypeInt, Default: "a"}},
+		{name: "computed", attribute: Attribute{PropertyType: common.FieldTypeComputed, Default: "a"}},
+		{name: "secret", attribute: Attribute{PropertyType: common.FieldTypeSecret, Default: "a"}},
+	}
+
+	for _, testCase := range testCases {
+		rawErr := testCase.attribute.ValidDefault(context.Background())
+		require.Equal(t, testCase.valid, rawErr.ErrCode == 0, testCase.name)
+	}
+}
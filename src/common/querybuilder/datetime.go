@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+)
+
+const (
+	// dateLayout and timeLayout is the format of the values of date and time type attributes.
+	dateLayout = "2006-01-02"
+	timeLayout = "2006-01-02 15:04:05"
+)
+
+// relativeTimeRegexp matches the relative time expression, like "now", "now-7d" and "now+1h".
+var relativeTimeRegexp = regexp.MustCompile(`^now(([+-])(\d+)([smhdw]))?$`)
+
+var relativeTimeUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ParseDatetime parse the datetime value of the rules, which can be a RFC3339 time, a date or time
+// in the format of the date and time attributes in local time zone, or a relative expression
+// based on now, such as "now-7d", supported units are s(second), m(minute), h(hour), d(day), w(week).
+func ParseDatetime(value string, now time.Time) (time.Time, error) {
+	if matches := relativeTimeRegexp.FindStringSubmatch(value); matches != nil {
+		if len(matches[1]) == 0 {
+			return now, nil
+		}
+
+		num, err := strconv.ParseInt(matches[3], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative time %s, err: %v", value, err)
+		}
+		offset := time.Duration(num) * relativeTimeUnits[matches[4]]
+		if matches[2] == "-" {
+			offset = -offset
+		}
+		return now.Add(offset), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation(timeLayout, value, time.Local); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation(dateLayout, value, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid datetime %s, should be RFC3339 format, %s, %s or relative time like now-7d",
+		value, dateLayout, timeLayout)
+}
+
+// datetimeToMgo convert the datetime value to the value stored in db according to the field type,
+// the date and time attributes is stored as strings which can be compared in lexical order,
+// the others such as create_time is stored as time.
+func datetimeToMgo(value string, fieldType string, now time.Time) (interface{}, error) {
+	t, err := ParseDatetime(value, now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch fieldType {
+	case common.FieldTypeDate:
+		return t.In(time.Local).Format(dateLayout), nil
+	case common.FieldTypeTime:
+		return t.In(time.Local).Format(timeLayout), nil
+	default:
+		return t.UTC(), nil
+	}
+}
@@ -0,0 +1,323 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/util"
+)
+
+// the network operators work in two ways according to the type of the field. the values of ipv4, ipv6
+// and cidr fields are stored in normalized forms which can be compared as strings, so the operators
+// are converted to range filters. the other string fields, like bk_host_innerip of host, store the
+// ipv4 addresses as they are, separated by comma if there are multiple ones, the operators are
+// converted to regular expressions on them, only ipv4 is supported in this way.
+
+func isNetworkFieldType(fieldType string) bool {
+	return fieldType == common.FieldTypeIPv4 || fieldType == common.FieldTypeIPv6 || fieldType == common.FieldTypeCIDR
+}
+
+func validateCIDRType(value interface{}) error {
+	if err := validateStringType(value); err != nil {
+		return err
+	}
+	if _, err := util.ParseCIDR(value.(string)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateIPRangeType validate the ip range value, which should be [start, end] of the same ip version.
+func validateIPRangeType(value interface{}) error {
+	_, _, err := parseIPRange(value)
+	return err
+}
+
+func parseIPRange(value interface{}) (start, end net.IP, err error) {
+	values, ok := value.([]interface{})
+	if !ok || len(values) != 2 {
+		return nil, nil, fmt.Errorf("ip range value should be [start, end]")
+	}
+	ips := make([]net.IP, 2)
+	for idx, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("ip range value should be ip addresses, but got %v", v)
+		}
+		if ips[idx], err = util.ParseIP(s); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(ips[0]) != len(ips[1]) {
+		return nil, nil, fmt.Errorf("ip range start %s and end %s are of different ip versions", values[0], values[1])
+	}
+	if bytes.Compare(ips[0], ips[1]) > 0 {
+		return nil, nil, fmt.Errorf("ip range start %s is greater than end %s", values[0], values[1])
+	}
+	return ips[0], ips[1], nil
+}
+
+// fieldIPLen returns the length of ip stored in the field, 0 means it's unknown, which is a cidr field
+func fieldIPLen(fieldType string) int {
+	switch fieldType {
+	case common.FieldTypeIPv4:
+		return net.IPv4len
+	case common.FieldTypeIPv6:
+		return net.IPv6len
+	default:
+		return 0
+	}
+}
+
+// normalizeNetworkValue normalize the values of equal and in operators on ipv4, ipv6 and cidr fields,
+// so that they can be compared with the stored values, the values that can not be normalized are kept.
+func normalizeNetworkValue(value interface{}, fieldType string) interface{} {
+	switch v := value.(type) {
+	case string:
+		if normalized, err := util.NormalizeNetworkValue(fieldType, v); err == nil {
+			return normalized
+		}
+		return v
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for idx := range v {
+			values[idx] = normalizeNetworkValue(v[idx], fieldType)
+		}
+		return values
+	default:
+		return value
+	}
+}
+
+// subnetToMgo generate the mongo filter of in_subnet operator
+func (r AtomRule) subnetToMgo(fieldType string) (map[string]interface{}, error) {
+	ipNet, err := util.ParseCIDR(r.Value.(string))
+	if err != nil {
+		return nil, err
+	}
+	first, last := util.IPNetRange(ipNet)
+
+	switch {
+	case isNetworkFieldType(fieldType):
+		if ipLen := fieldIPLen(fieldType); ipLen != 0 && ipLen != len(ipNet.IP) {
+			return nil, fmt.Errorf("subnet %s is not of the ip version of %s field %s", r.Value, fieldType, r.Field)
+		}
+		if fieldType != common.FieldTypeCIDR {
+			return map[string]interface{}{
+				common.BKDBGTE: util.FormatIP(first),
+				common.BKDBLTE: util.FormatIP(last),
+			}, nil
+		}
+
+		// a cidr is in the subnet if its network address is in the subnet, and its prefix is not shorter
+		ones, bits := ipNet.Mask.Size()
+		prefixes := make([]string, 0)
+		for prefix := ones; prefix <= bits; prefix++ {
+			prefixes = append(prefixes, strconv.Itoa(prefix))
+		}
+		return map[string]interface{}{
+			common.BKDBGTE:  util.FormatIP(first) + "/",
+			common.BKDBLTE:  util.FormatIP(last) + "/999",
+			common.BKDBLIKE: fmt.Sprintf("/(%s)$", strings.Join(prefixes, "|")),
+		}, nil
+
+	case isStringFieldType(fieldType) || fieldType == "":
+		if len(ipNet.IP) != net.IPv4len {
+			return nil, fmt.Errorf("only ipv4 subnet can be used on %s field %s", fieldType, r.Field)
+		}
+		return map[string]interface{}{
+			common.BKDBLIKE: ipv4NetsRegexp([]*net.IPNet{ipNet}),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("operator %s can not be used on %s field %s", r.Operator, fieldType, r.Field)
+	}
+}
+
+// ipRangeToMgo generate the mongo filter of ip_range operator
+func (r AtomRule) ipRangeToMgo(fieldType string) (map[string]interface{}, error) {
+	start, end, err := parseIPRange(r.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case fieldType == common.FieldTypeIPv4 || fieldType == common.FieldTypeIPv6:
+		if fieldIPLen(fieldType) != len(start) {
+			return nil, fmt.Errorf("ip range %v is not of the ip version of %s field %s", r.Value, fieldType, r.Field)
+		}
+		return map[string]interface{}{
+			common.BKDBGTE: util.FormatIP(start),
+			common.BKDBLTE: util.FormatIP(end),
+		}, nil
+
+	case isStringFieldType(fieldType) || fieldType == "":
+		if len(start) != net.IPv4len {
+			return nil, fmt.Errorf("only ipv4 range can be used on %s field %s", fieldType, r.Field)
+		}
+		nets := ipv4RangeToNets(binary.BigEndian.Uint32(start), binary.BigEndian.Uint32(end))
+		return map[string]interface{}{
+			common.BKDBLIKE: ipv4NetsRegexp(nets),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("operator %s can not be used on %s field %s", r.Operator, fieldType, r.Field)
+	}
+}
+
+// ipv4RangeToNets split the ipv4 range into the least networks which cover it exactly
+func ipv4RangeToNets(start, end uint32) []*net.IPNet {
+	nets := make([]*net.IPNet, 0)
+	for current := uint64(start); current <= uint64(end); {
+		// the largest network that starts from current and doesn't exceed end
+		size := uint(0)
+		for size < 32 {
+			blockSize := uint64(1) << (size + 1)
+			if current%blockSize != 0 || current+blockSize-1 > uint64(end) {
+				break
+			}
+			size++
+		}
+
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, uint32(current))
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(32-int(size), 32)})
+		current += uint64(1) << size
+	}
+	return nets
+}
+
+// ipv4NetsRegexp returns the regular expression that matches the comma separated ipv4 addresses in
+// dotted decimal notation, which any one of them is in any of the networks.
+func ipv4NetsRegexp(nets []*net.IPNet) string {
+	patterns := make([]string, len(nets))
+	for idx, ipNet := range nets {
+		ones, _ := ipNet.Mask.Size()
+		octets := make([]string, net.IPv4len)
+		for i := range octets {
+			switch bits := ones - i*8; {
+			case bits >= 8:
+				octets[i] = strconv.Itoa(int(ipNet.IP[i]))
+			case bits <= 0:
+				octets[i] = `\d{1,3}`
+			default:
+				low := int(ipNet.IP[i])
+				octets[i] = numberRangePattern(low, low+1<<uint(8-bits)-1)
+			}
+		}
+		patterns[idx] = strings.Join(octets, `\.`)
+	}
+	return fmt.Sprintf(`(^|,)(%s)(,|$)`, strings.Join(patterns, "|"))
+}
+
+// numberRangePattern returns the regular expression that matches the decimal numbers in [low, high]
+// without leading zeros, like (1[6-9]|2\d|3[01]) for [16, 31].
+func numberRangePattern(low, high int) string {
+	patterns := make([]string, 0)
+	// numbers of different digits are matched separately, 0~9, 10~99, 100~999...
+	for digitLow := 1; low <= high; digitLow *= 10 {
+		digitHigh := digitLow*10 - 1
+		if low > digitHigh {
+			continue
+		}
+		end := high
+		if end > digitHigh {
+			end = digitHigh
+		}
+
+		for low <= end {
+			// the numbers that share the same prefix and varies in the last digits, like 2[0-4]\d
+			step := 1
+			for step*10 <= digitLow && low%(step*10) == 0 && low+step*10-1 <= end {
+				step *= 10
+			}
+			count := (end - low + 1) / step
+			if rest := 10 - (low/step)%10; count > rest {
+				count = rest
+			}
+
+			prefix := ""
+			if low/step/10 > 0 {
+				prefix = strconv.Itoa(low / step / 10)
+			}
+			first, last := (low/step)%10, (low/step)%10+count-1
+			digit := strconv.Itoa(first)
+			if first != last {
+				digit = fmt.Sprintf("[%d-%d]", first, last)
+			}
+			patterns = append(patterns, prefix+digit+strings.Repeat(`\d`, len(strconv.Itoa(step))-1))
+			low += count * step
+		}
+	}
+	return fmt.Sprintf("(%s)", strings.Join(patterns, "|"))
+}
+
+// matchNetwork check if the value stored in the field matches the network operator in memory
+func (r AtomRule) matchNetwork(value interface{}, fieldType string) bool {
+	var contains func(s string) bool
+	switch r.Operator {
+	case OperatorInSubnet:
+		ipNet, err := util.ParseCIDR(r.Value.(string))
+		if err != nil {
+			return false
+		}
+		subnetOnes, _ := ipNet.Mask.Size()
+		contains = func(s string) bool {
+			if fieldType == common.FieldTypeCIDR {
+				valueNet, err := util.ParseCIDR(s)
+				if err != nil {
+					return false
+				}
+				ones, _ := valueNet.Mask.Size()
+				return len(valueNet.IP) == len(ipNet.IP) && ones >= subnetOnes && ipNet.Contains(valueNet.IP)
+			}
+			ip, err := util.ParseIP(s)
+			return err == nil && len(ip) == len(ipNet.IP) && ipNet.Contains(ip)
+		}
+	case OperatorIPRange:
+		start, end, err := parseIPRange(r.Value)
+		if err != nil {
+			return false
+		}
+		contains = func(s string) bool {
+			ip, err := util.ParseIP(s)
+			return err == nil && len(ip) == len(start) && bytes.Compare(ip, start) >= 0 && bytes.Compare(ip, end) <= 0
+		}
+	default:
+		return false
+	}
+
+	return matchAny(value, func(v interface{}) bool {
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		if isNetworkFieldType(fieldType) {
+			return contains(s)
+		}
+		for _, item := range strings.Split(s, ",") {
+			if contains(strings.TrimSpace(item)) {
+				return true
+			}
+		}
+		return false
+	})
+}
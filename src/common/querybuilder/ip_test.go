@@ -0,0 +1,154 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder_test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/querybuilder"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var networkFieldTypes = querybuilder.FieldTypes{
+	"bk_host_innerip": common.FieldTypeSingleChar,
+	"vip":             common.FieldTypeIPv4,
+	"vip6":            common.FieldTypeIPv6,
+	"subnet":          common.FieldTypeCIDR,
+	"bk_cpu":          common.FieldTypeInt,
+}
+
+func TestNetworkRuleToMgo(t *testing.T) {
+	rule := querybuilder.AtomRule{Field: "vip", Operator: querybuilder.OperatorInSubnet, Value: "10.1.0.0/16"}
+	filter, _, err := rule.ToMgoWithFieldTypes(networkFieldTypes)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"vip": map[string]interface{}{
+		common.BKDBGTE: "010.001.000.000",
+		common.BKDBLTE: "010.001.255.255",
+	}}, filter)
+
+	rule = querybuilder.AtomRule{Field: "vip6", Operator: querybuilder.OperatorIPRange,
+		Value: []interface{}{"2001:db8::1", "2001:db8::ff"}}
+	filter, _, err = rule.ToMgoWithFieldTypes(networkFieldTypes)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"vip6": map[string]interface{}{
+		common.BKDBGTE: "2001:0db8:0000:0000:0000:0000:0000:0001",
+		common.BKDBLTE: "2001:0db8:0000:0000:0000:0000:0000:00ff",
+	}}, filter)
+
+	rule = querybuilder.AtomRule{Field: "vip", Operator: querybuilder.OperatorIn, Value: []interface{}{"10.0.0.1", "bad"}}
+	filter, _, err = rule.ToMgoWithFieldTypes(networkFieldTypes)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"vip": map[string]interface{}{
+		common.BKDBIN: []interface{}{"010.000.000.001", "bad"},
+	}}, filter)
+
+	invalid := []querybuilder.AtomRule{
+		{Field: "vip", Operator: querybuilder.OperatorInSubnet, Value: "2001:db8::/32"},
+		{Field: "vip", Operator: querybuilder.OperatorIPRange, Value: []interface{}{"10.0.0.9", "10.0.0.1"}},
+		{Field: "vip", Operator: querybuilder.OperatorIPRange, Value: []interface{}{"10.0.0.1", "::1"}},
+		{Field: "subnet", Operator: querybuilder.OperatorIPRange, Value: []interface{}{"10.0.0.1", "10.0.0.2"}},
+		{Field: "bk_host_innerip", Operator: querybuilder.OperatorInSubnet, Value: "2001:db8::/32"},
+		{Field: "bk_cpu", Operator: querybuilder.OperatorInSubnet, Value: "10.0.0.0/8"},
+		{Field: "vip", Operator: querybuilder.OperatorInSubnet, Value: "10.0.0.0"},
+	}
+	for _, rule := range invalid {
+		_, _, err := rule.ToMgoWithFieldTypes(networkFieldTypes)
+		assert.Error(t, err, fmt.Sprintf("%+v", rule))
+	}
+}
+
+func TestNetworkRuleOnStringField(t *testing.T) {
+	cases := []struct {
+		rule    querybuilder.AtomRule
+		matches []string
+		misses  []string
+	}{
+		{
+			rule:    querybuilder.AtomRule{Operator: querybuilder.OperatorInSubnet, Value: "172.16.0.0/12"},
+			matches: []string{"172.16.0.1", "172.31.255.255", "10.0.0.1,172.20.1.1"},
+			misses:  []string{"172.15.0.1", "172.32.0.1", "1172.16.0.1", "172.160.0.1", "10.0.0.1"},
+		},
+		{
+			rule:    querybuilder.AtomRule{Operator: querybuilder.OperatorInSubnet, Value: "10.0.0.0/8"},
+			matches: []string{"10.0.0.0", "10.255.1.200"},
+			misses:  []string{"100.0.0.1", "110.1.1.1"},
+		},
+		{
+			rule:    querybuilder.AtomRule{Operator: querybuilder.OperatorIPRange, Value: []interface{}{"192.168.0.250", "192.168.2.7"}},
+			matches: []string{"192.168.0.250", "192.168.0.255", "192.168.1.0", "192.168.1.99", "192.168.2.7", "1.1.1.1,192.168.2.0"},
+			misses:  []string{"192.168.0.249", "192.168.0.25", "192.168.2.8", "192.168.2.70", "192.168.3.1"},
+		},
+	}
+
+	for _, c := range cases {
+		c.rule.Field = "bk_host_innerip"
+		filter, _, err := c.rule.ToMgoWithFieldTypes(networkFieldTypes)
+		require.NoError(t, err)
+		pattern := filter["bk_host_innerip"].(map[string]interface{})[common.BKDBLIKE].(string)
+		reg := regexp.MustCompile(pattern)
+
+		for _, ip := range c.matches {
+			assert.True(t, reg.MatchString(ip), "%v should match %s", c.rule.Value, ip)
+			assert.True(t, querybuilder.MatchDocument(c.rule, map[string]interface{}{"bk_host_innerip": ip},
+				networkFieldTypes), "%v should match %s", c.rule.Value, ip)
+		}
+		for _, ip := range c.misses {
+			assert.False(t, reg.MatchString(ip), "%v should not match %s", c.rule.Value, ip)
+			assert.False(t, querybuilder.MatchDocument(c.rule, map[string]interface{}{"bk_host_innerip": ip},
+				networkFieldTypes), "%v should not match %s", c.rule.Value, ip)
+		}
+	}
+}
+
+func TestMatchNetworkDocument(t *testing.T) {
+	doc := map[string]interface{}{
+		"vip":    "010.000.000.001",
+		"vip6":   "2001:0db8:0000:0000:0000:0000:0000:0001",
+		"subnet": "010.001.000.000/16",
+	}
+
+	cases := map[string]bool{
+		`vip = "10.0.0.1"`:                             true,
+		`vip in_subnet "10.0.0.0/24"`:                  true,
+		`vip ip_range ["10.0.0.2", "10.0.0.9"]`:        false,
+		`vip6 in_subnet "2001:db8::/32"`:               true,
+		`vip6 ip_range ["2001:db8::", "2001:db8::1"]`:  true,
+		`subnet in_subnet "10.0.0.0/8"`:                true,
+		`subnet in_subnet "10.1.0.0/24"`:               false,
+		`subnet = "10.1.2.3/16"`:                       true,
+		`vip in_subnet "10.0.0.0/24" AND vip6 = "::1"`: false,
+	}
+	for query, expect := range cases {
+		filter, err := querybuilder.ParseQuery(query, networkFieldTypes)
+		if !assert.Nil(t, err, query) {
+			continue
+		}
+		assert.Equal(t, expect, querybuilder.MatchDocument(filter.Rule, doc, networkFieldTypes), query)
+	}
+
+	// cidr field filter checks the prefix length besides the network address
+	rule := querybuilder.AtomRule{Field: "subnet", Operator: querybuilder.OperatorInSubnet, Value: "10.0.0.0/8"}
+	filter, _, err := rule.ToMgoWithFieldTypes(networkFieldTypes)
+	require.NoError(t, err)
+	subnetFilter := filter["subnet"].(map[string]interface{})
+	assert.Equal(t, "010.000.000.000/", subnetFilter[common.BKDBGTE])
+	assert.Equal(t, "010.255.255.255/999", subnetFilter[common.BKDBLTE])
+	reg := regexp.MustCompile(subnetFilter[common.BKDBLIKE].(string))
+	assert.True(t, reg.MatchString("010.001.000.000/16"))
+	assert.False(t, reg.MatchString("010.000.000.000/7"))
+}
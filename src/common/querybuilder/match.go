@@ -0,0 +1,324 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MatchDocument check whether the document matches the rule in memory, the semantic is the
+// same as the mongo filter generated by ToMgoWithFieldTypes, so that a rule can be evaluated
+// against the data which is already loaded, like the hosts that a host apply rule is applied to.
+func MatchDocument(rule Rule, doc map[string]interface{}, fieldTypes FieldTypes) bool {
+	if rule == nil {
+		return true
+	}
+	return rule.Match(NewDocumentMatcher(doc, fieldTypes))
+}
+
+// NewDocumentMatcher returns a matcher that matches the atom rules with the document.
+func NewDocumentMatcher(doc map[string]interface{}, fieldTypes FieldTypes) Matcher {
+	now := time.Now()
+	return func(r AtomRule) bool {
+		if _, err := r.Validate(); err != nil {
+			return false
+		}
+		value, found := lookupField(doc, r.Field)
+		return r.matchValue(value, found, fieldTypes[r.Field], now)
+	}
+}
+
+func (r AtomRule) matchValue(value interface{}, found bool, fieldType string, now time.Time) bool {
+	if isNetworkFieldType(fieldType) {
+		switch r.Operator {
+		case OperatorEqual, OperatorNotEqual, OperatorIn, OperatorNotIn:
+			r.Value = normalizeNetworkValue(r.Value, fieldType)
+		}
+	}
+
+	switch r.Operator {
+	case OperatorEqual:
+		return found && matchAny(value, func(v interface{}) bool { return equalValues(v, r.Value) })
+	case OperatorNotEqual:
+		return !(found && matchAny(value, func(v interface{}) bool { return equalValues(v, r.Value) }))
+	case OperatorIn:
+		return found && matchAny(value, func(v interface{}) bool { return inValues(v, r.Value) })
+	case OperatorNotIn:
+		return !(found && matchAny(value, func(v interface{}) bool { return inValues(v, r.Value) }))
+	case OperatorLess, OperatorLessOrEqual, OperatorGreater, OperatorGreaterOrEqual:
+		return found && matchCompare(value, r.Value, r.Operator)
+	case OperatorDatetimeLess, OperatorDatetimeLessOrEqual, OperatorDatetimeGreater, OperatorDatetimeGreaterOrEqual:
+		target, err := datetimeToMgo(r.Value.(string), fieldType, now)
+		if err != nil {
+			return false
+		}
+		return found && matchCompare(value, target, r.Operator)
+	case OperatorBetween:
+		start, end, err := r.rangeToMgo(fieldType, now)
+		if err != nil {
+			return false
+		}
+		return found && matchAny(value, func(v interface{}) bool {
+			lower, ok := compareValues(v, start)
+			if !ok || lower < 0 {
+				return false
+			}
+			upper, ok := compareValues(v, end)
+			return ok && upper <= 0
+		})
+	case OperatorInSubnet, OperatorIPRange:
+		return found && r.matchNetwork(value, fieldType)
+	case OperatorBeginsWith:
+		return found && matchRegexp(value, fmt.Sprintf("^%s", r.Value))
+	case OperatorNotBeginsWith:
+		return !(found && matchRegexp(value, fmt.Sprintf("^%s", r.Value)))
+	case OperatorContains:
+		return found && matchRegexp(value, fmt.Sprintf("%s", r.Value))
+	case OperatorNotContains:
+		return !(found && matchRegexp(value, fmt.Sprintf("%s", r.Value)))
+	case OperatorsEndsWith:
+		return found && matchRegexp(value, fmt.Sprintf("%s$", r.Value))
+	case OperatorNotEndsWith:
+		return !(found && matchRegexp(value, fmt.Sprintf("%s$", r.Value)))
+	case OperatorIsEmpty:
+		return found && isEmptyArray(value)
+	case OperatorIsNotEmpty:
+		return !(found && isEmptyArray(value))
+	case OperatorIsNull:
+		return !found || value == nil
+	case OperatorIsNotNull:
+		return found && value != nil
+	case OperatorExist:
+		return found
+	case OperatorNotExist:
+		return !found
+	default:
+		return false
+	}
+}
+
+// lookupField get the field's value from the document, the embedded fields can be accessed with dot
+// separator, like "metadata.label.bk_biz_id". like mongo does, if an embedded value is an array of
+// documents, like the rows of table attribute, the field is looked up in each of the documents and
+// the found values are returned as an array, so "disks.size" gets the size column of all the rows.
+func lookupField(doc map[string]interface{}, field string) (interface{}, bool) {
+	if value, ok := doc[field]; ok {
+		return value, true
+	}
+	return lookupPath(doc, strings.Split(field, "."))
+}
+
+func lookupPath(current interface{}, keys []string) (interface{}, bool) {
+	if len(keys) == 0 {
+		return current, true
+	}
+
+	v := reflect.ValueOf(current)
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		item := v.MapIndex(reflect.ValueOf(keys[0]).Convert(v.Type().Key()))
+		if !item.IsValid() {
+			return nil, false
+		}
+		return lookupPath(item.Interface(), keys[1:])
+	case reflect.Slice, reflect.Array:
+		values := make([]interface{}, 0)
+		for i := 0; i < v.Len(); i++ {
+			value, found := lookupPath(v.Index(i).Interface(), keys)
+			if !found {
+				continue
+			}
+			// flatten the values so that each of them can be matched as array element
+			if inner := reflect.ValueOf(value); inner.Kind() == reflect.Slice || inner.Kind() == reflect.Array {
+				for j := 0; j < inner.Len(); j++ {
+					values = append(values, inner.Index(j).Interface())
+				}
+				continue
+			}
+			values = append(values, value)
+		}
+		return values, len(values) > 0
+	default:
+		return nil, false
+	}
+}
+
+// matchAny matches the value like mongo does, an array value matches if the array itself or any
+// of its elements matches.
+func matchAny(value interface{}, match func(v interface{}) bool) bool {
+	if match(value) {
+		return true
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < v.Len(); i++ {
+		if match(v.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCompare(value interface{}, target interface{}, op Operator) bool {
+	return matchAny(value, func(v interface{}) bool {
+		result, ok := compareValues(v, target)
+		if !ok {
+			return false
+		}
+		switch op {
+		case OperatorLess, OperatorDatetimeLess:
+			return result < 0
+		case OperatorLessOrEqual, OperatorDatetimeLessOrEqual:
+			return result <= 0
+		case OperatorGreater, OperatorDatetimeGreater:
+			return result > 0
+		case OperatorGreaterOrEqual, OperatorDatetimeGreaterOrEqual:
+			return result >= 0
+		default:
+			return false
+		}
+	})
+}
+
+func matchRegexp(value interface{}, pattern string) bool {
+	reg, err := regexp.Compile(pattern)
+	if err != nil {
+		return false
+	}
+	return matchAny(value, func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && reg.MatchString(s)
+	})
+}
+
+func inValues(value interface{}, values interface{}) bool {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+	for i := 0; i < v.Len(); i++ {
+		if equalValues(value, v.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyArray(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Len() == 0
+}
+
+func equalValues(a, b interface{}) bool {
+	if result, ok := compareValues(a, b); ok {
+		return result == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues compare the values of the same kind, numeric values are compared as float64,
+// strings are compared in lexical order, and times are compared in time order. the second
+// return value is false if the values can not be compared.
+func compareValues(a, b interface{}) (int, bool) {
+	if target, ok := b.(time.Time); ok {
+		t, ok := toTime(a)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case t.Before(target):
+			return -1, true
+		case t.After(target):
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	typeA, typeB := getType(a), getType(b)
+	if typeA != typeB {
+		return 0, false
+	}
+
+	switch typeA {
+	case TypeNumeric:
+		x, err := toFloat64(a)
+		if err != nil {
+			return 0, false
+		}
+		y, err := toFloat64(b)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case TypeString:
+		return strings.Compare(a.(string), b.(string)), true
+	case TypeBoolean:
+		if a.(bool) == b.(bool) {
+			return 0, true
+		}
+		return 0, false
+	default:
+		return 0, false
+	}
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch t := value.(type) {
+	case time.Time:
+		return t, true
+	case interface{ Time() time.Time }:
+		// the time types of the db drivers, like primitive.DateTime
+		return t.Time(), true
+	case string:
+		parsed, err := ParseDatetime(t, time.Now())
+		return parsed, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+func toFloat64(value interface{}) (float64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		// json.Number and jsoniter.Number
+		return strconv.ParseFloat(v.String(), 64)
+	default:
+		return 0, fmt.Errorf("%v is not numeric", value)
+	}
+}
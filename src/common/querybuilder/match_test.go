@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder_test
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/querybuilder"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchDocument(t *testing.T) {
+	doc := map[string]interface{}{
+		"bk_os_type":      "Linux",
+		"bk_cpu":          int64(8),
+		"bk_mem":          float64(2048),
+		"bk_host_innerip": "10.1.0.1,10.1.0.2",
+		"bk_tags":         []interface{}{"db", "prod"},
+		"bk_comment":      nil,
+		"create_time":     time.Now().Add(-24 * time.Hour),
+		"bk_start_date":   "2020-01-02",
+		"metadata":        map[string]interface{}{"label": map[string]interface{}{"bk_biz_id": "2"}},
+	}
+	fieldTypes := querybuilder.FieldTypes{"bk_start_date": common.FieldTypeDate}
+
+	cases := map[string]bool{
+		`bk_os_type = "Linux" AND bk_cpu >= 8`:                   true,
+		`bk_os_type != "Linux"`:                                  false,
+		`bk_cpu in [4, 8]`:                                       true,
+		`bk_cpu not_in [4, 8]`:                                   false,
+		`bk_cpu > 8 OR bk_mem between [1024, 4096]`:              true,
+		`bk_host_innerip begins_with "10.1."`:                    true,
+		`bk_host_innerip ends_with "0.3"`:                        false,
+		`bk_tags = "db"`:                                         true,
+		`bk_tags not_contains "test"`:                            true,
+		`create_time >= "now-7d"`:                                true,
+		`create_time between ["now-1h", "now"]`:                  false,
+		`bk_start_date < "2020-01-03"`:                           true,
+		`metadata.label.bk_biz_id = "2"`:                         true,
+		`bk_not_exist = "a"`:                                     false,
+		`bk_not_exist != "a"`:                                    true,
+		`bk_os_type = "Linux" AND (bk_cpu < 4 OR bk_mem > 1024)`: true,
+	}
+	for query, expect := range cases {
+		filter, err := querybuilder.ParseQuery(query, nil)
+		if !assert.Nil(t, err, query) {
+			continue
+		}
+		assert.Equal(t, expect, querybuilder.MatchDocument(filter.Rule, doc, fieldTypes), query)
+	}
+
+	exist := querybuilder.AtomRule{Field: "bk_comment", Operator: querybuilder.OperatorExist, Value: true}
+	assert.True(t, querybuilder.MatchDocument(exist, doc, nil))
+	assert.True(t, querybuilder.MatchDocument(nil, doc, nil))
+}
+
+func TestMatchTableColumn(t *testing.T) {
+	doc := map[string]interface{}{
+		"disks": []interface{}{
+			map[string]interface{}{"name": "sda", "size": int64(100), "tags": []interface{}{"ssd"}},
+			map[string]interface{}{"name": "sdb", "size": int64(500), "tags": []interface{}{"hdd", "backup"}},
+		},
+		"bk_nics": []interface{}{},
+	}
+
+	cases := map[string]bool{
+		`disks.name = "sdb"`:                       true,
+		`disks.name = "sdc"`:                       false,
+		`disks.size > 400`:                         true,
+		`disks.size > 500`:                         false,
+		`disks.tags = "backup"`:                    true,
+		`disks.tags in ["nvme", "ssd"]`:            true,
+		`disks.name not_in ["sda", "sdb"]`:         false,
+		`disks.size between [200, 300]`:            false,
+		`disks.name begins_with "sd"`:              true,
+		`disks.name = "sda" AND disks.size >= 500`: true,
+	}
+	for query, expect := range cases {
+		filter, err := querybuilder.ParseQuery(query, nil)
+		if !assert.Nil(t, err, query) {
+			continue
+		}
+		assert.Equal(t, expect, querybuilder.MatchDocument(filter.Rule, doc, nil), query)
+	}
+
+	exist := querybuilder.AtomRule{Field: "disks.size", Operator: querybuilder.OperatorExist, Value: true}
+	assert.True(t, querybuilder.MatchDocument(exist, doc, nil))
+	exist.Field = "disks.serial"
+	assert.False(t, querybuilder.MatchDocument(exist, doc, nil))
+	exist.Field = "bk_nics.mac"
+	assert.False(t, querybuilder.MatchDocument(exist, doc, nil))
+}
@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common/mapstr"
 
 	"github.com/mitchellh/mapstructure"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type RuleGroup struct {
@@ -103,6 +104,69 @@ func (qf *QueryFilter) UnmarshalJSON(raw []byte) error {
 	return nil
 }
 
+// AndFilters combine the query filters with AND condition, nil filters are ignored. the rules of the
+// filters which are combined with AND are flattened, so that the deep is not increased by them.
+func AndFilters(filters ...*QueryFilter) *QueryFilter {
+	rules := make([]Rule, 0)
+	for _, filter := range filters {
+		if filter == nil || filter.Rule == nil {
+			continue
+		}
+		if combined, ok := filter.Rule.(CombinedRule); ok && combined.Condition == ConditionAnd {
+			rules = append(rules, combined.Rules...)
+			continue
+		}
+		rules = append(rules, filter.Rule)
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+	return &QueryFilter{Rule: CombinedRule{Condition: ConditionAnd, Rules: rules}}
+}
+
+// MarshalBSON save the query filter with the same structure as json, so that it can be stored in db.
+func (qf *QueryFilter) MarshalBSON() ([]byte, error) {
+	if qf.Rule == nil {
+		return bson.Marshal(map[string]interface{}{})
+	}
+
+	// rules are interfaces which can not be encoded by bson directly, so we convert them with json.
+	data, err := json.Marshal(qf.Rule)
+	if err != nil {
+		return nil, fmt.Errorf("MarshalBSON failed, err: %+v", err)
+	}
+	doc := bson.D{}
+	if err := bson.UnmarshalExtJSON(data, false, &doc); err != nil {
+		return nil, fmt.Errorf("MarshalBSON failed, err: %+v", err)
+	}
+	return bson.Marshal(doc)
+}
+
+func (qf *QueryFilter) UnmarshalBSON(raw []byte) error {
+	elements, err := bson.Raw(raw).Elements()
+	if err != nil {
+		return fmt.Errorf("UnmarshalBSON failed, err: %+v", err)
+	}
+	if len(elements) == 0 {
+		qf.Rule = nil
+		return nil
+	}
+
+	// convert to relaxed extended json, so that numbers are kept as they are.
+	data, err := bson.MarshalExtJSON(bson.Raw(raw), false, false)
+	if err != nil {
+		return fmt.Errorf("UnmarshalBSON failed, err: %+v", err)
+	}
+
+	rule, errKey, err := ParseRuleFromBytes(data)
+	if err != nil {
+		return fmt.Errorf("UnmarshalBSON failed, key: %s, err: %+v", errKey, err)
+	}
+	qf.Rule = rule
+	return nil
+}
+
 func MapToQueryFilterHookFunc() mapstructure.DecodeHookFunc {
 	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
 		if t != reflect.TypeOf(QueryFilter{}) {
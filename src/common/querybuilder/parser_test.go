@@ -14,11 +14,13 @@ package querybuilder_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 
 	"configcenter/src/common/querybuilder"
 
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 func TestNormalParser(t *testing.T) {
@@ -85,3 +87,60 @@ func TestAsStructField(t *testing.T) {
 	assert.Nil(t, err)
 	t.Logf("output: %s", output)
 }
+
+func TestBSONRoundTrip(t *testing.T) {
+	type Foo struct {
+		QueryFilter *querybuilder.QueryFilter `bson:"query_filter"`
+		Key         string                    `bson:"key"`
+	}
+	foo := Foo{
+		Key: "test",
+		QueryFilter: &querybuilder.QueryFilter{
+			Rule: querybuilder.CombinedRule{
+				Condition: querybuilder.ConditionAnd,
+				Rules: []querybuilder.Rule{
+					querybuilder.AtomRule{Field: "bk_cpu", Operator: querybuilder.OperatorGreater, Value: 4},
+					querybuilder.AtomRule{Field: "bk_host_name", Operator: querybuilder.OperatorIn, Value: []interface{}{"a", "b"}},
+				},
+			},
+		},
+	}
+	data, err := bson.Marshal(foo)
+	assert.Nil(t, err)
+
+	output := Foo{}
+	err = bson.Unmarshal(data, &output)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", output.Key)
+
+	_, err = output.QueryFilter.Validate()
+	assert.Nil(t, err)
+	expect, _, _ := foo.QueryFilter.ToMgo()
+	actual, _, err := output.QueryFilter.ToMgo()
+	assert.Nil(t, err)
+	assert.Equal(t, fmt.Sprint(expect), fmt.Sprint(actual))
+}
+
+func TestAndFilters(t *testing.T) {
+	assert.Nil(t, querybuilder.AndFilters(nil, &querybuilder.QueryFilter{}))
+
+	and := &querybuilder.QueryFilter{Rule: querybuilder.CombinedRule{
+		Condition: querybuilder.ConditionAnd,
+		Rules: []querybuilder.Rule{
+			querybuilder.AtomRule{Field: "a", Operator: querybuilder.OperatorEqual, Value: 1},
+		},
+	}}
+	or := &querybuilder.QueryFilter{Rule: querybuilder.CombinedRule{
+		Condition: querybuilder.ConditionOr,
+		Rules: []querybuilder.Rule{
+			querybuilder.AtomRule{Field: "b", Operator: querybuilder.OperatorEqual, Value: 1},
+			querybuilder.AtomRule{Field: "c", Operator: querybuilder.OperatorEqual, Value: 1},
+		},
+	}}
+	filter := querybuilder.AndFilters(and, nil, or)
+	combined, ok := filter.Rule.(querybuilder.CombinedRule)
+	assert.True(t, ok)
+	assert.Equal(t, querybuilder.ConditionAnd, combined.Condition)
+	assert.Len(t, combined.Rules, 2)
+	assert.Equal(t, or.GetDeep()+1, filter.GetDeep())
+}
@@ -0,0 +1,514 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"configcenter/src/common"
+)
+
+// FieldTypeDatetime is the type of the fields which is stored as time in db, such as create_time.
+const FieldTypeDatetime = "datetime"
+
+// QueryError is the error of a text query, with the position where the error occurs.
+type QueryError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ParseQuery parse a text query like `bk_os_type = "Linux" AND (bk_cpu >= 8 OR bk_host_innerip begins_with "10.1.")`
+// into a query filter. the comparison is `field operator value`, operator can be one of =, !=, <, <=, >, >= or
+// the operators of querybuilder such as in and begins_with, the compare operators are used as datetime operators
+// when value is a string. value can be a string in double quotes, a number, true, false or a list like [1, 2].
+// if fieldTypes is not nil, the fields and values are validated with the fields' property types.
+func ParseQuery(query string, fieldTypes FieldTypes) (*QueryFilter, error) {
+	p := &queryParser{lexer: newQueryLexer(query), fieldTypes: fieldTypes}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	rule, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.token.kind != tokenEOF {
+		return nil, p.errorf(p.token, "unexpected %s, expect AND or OR", p.token)
+	}
+
+	combined, ok := rule.(CombinedRule)
+	if !ok {
+		combined = CombinedRule{Condition: ConditionAnd, Rules: []Rule{rule}}
+	}
+
+	if combined.GetDeep() > MaxDeep {
+		return nil, &QueryError{Line: 1, Column: 1, Msg: fmt.Sprintf("exceed max query condition deepth: %d", MaxDeep)}
+	}
+
+	return &QueryFilter{Rule: combined}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type queryToken struct {
+	kind   tokenKind
+	text   string
+	line   int
+	column int
+}
+
+func (t queryToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+type queryLexer struct {
+	input  string
+	pos    int
+	line   int
+	column int
+}
+
+func newQueryLexer(input string) *queryLexer {
+	return &queryLexer{input: input, line: 1, column: 1}
+}
+
+func (l *queryLexer) peek() rune {
+	if l.pos >= len(l.input) {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.pos:])
+	return r
+}
+
+func (l *queryLexer) advance() rune {
+	r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+	l.pos += size
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return r
+}
+
+func (l *queryLexer) nextToken() (queryToken, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(l.peek()) {
+		l.advance()
+	}
+
+	token := queryToken{line: l.line, column: l.column}
+	if l.pos >= len(l.input) {
+		token.kind = tokenEOF
+		return token, nil
+	}
+
+	start := l.pos
+	r := l.advance()
+	switch {
+	case r == '"':
+		escaped := false
+		for {
+			if l.pos >= len(l.input) {
+				return token, &QueryError{Line: token.line, Column: token.column, Msg: "unterminated string"}
+			}
+			c := l.advance()
+			if escaped {
+				escaped = false
+				continue
+			}
+			if c == '\\' {
+				escaped = true
+				continue
+			}
+			if c == '"' {
+				break
+			}
+		}
+		value, err := strconv.Unquote(l.input[start:l.pos])
+		if err != nil {
+			return token, &QueryError{Line: token.line, Column: token.column, Msg: fmt.Sprintf("invalid string, %v", err)}
+		}
+		token.kind = tokenString
+		token.text = value
+	case r == '-' || unicode.IsDigit(r):
+		for l.pos < len(l.input) && (unicode.IsDigit(l.peek()) || l.peek() == '.') {
+			l.advance()
+		}
+		token.kind = tokenNumber
+		token.text = l.input[start:l.pos]
+	case unicode.IsLetter(r) || r == '_':
+		for l.pos < len(l.input) && isIdentRune(l.peek()) {
+			l.advance()
+		}
+		token.kind = tokenIdent
+		token.text = l.input[start:l.pos]
+	case r == '!' || r == '<' || r == '>':
+		if l.peek() == '=' {
+			l.advance()
+		} else if r == '!' {
+			return token, &QueryError{Line: token.line, Column: token.column, Msg: `unexpected "!", do you mean "!="`}
+		}
+		token.kind = tokenSymbol
+		token.text = l.input[start:l.pos]
+	case strings.ContainsRune("=()[],", r):
+		token.kind = tokenSymbol
+		token.text = string(r)
+	default:
+		return token, &QueryError{Line: token.line, Column: token.column, Msg: fmt.Sprintf("unexpected character %q", r)}
+	}
+	return token, nil
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
+
+// symbolOperators is the compare symbols and their operators, the second one is used when the value is a string.
+var symbolOperators = map[string][2]Operator{
+	"=":  {OperatorEqual, OperatorEqual},
+	"!=": {OperatorNotEqual, OperatorNotEqual},
+	"<":  {OperatorLess, OperatorDatetimeLess},
+	"<=": {OperatorLessOrEqual, OperatorDatetimeLessOrEqual},
+	">":  {OperatorGreater, OperatorDatetimeGreater},
+	">=": {OperatorGreaterOrEqual, OperatorDatetimeGreaterOrEqual},
+}
+
+// noValueOperators is the operators which do not need a value.
+var noValueOperators = map[Operator]bool{
+	OperatorIsEmpty:    true,
+	OperatorIsNotEmpty: true,
+	OperatorIsNull:     true,
+	OperatorIsNotNull:  true,
+	OperatorExist:      true,
+	OperatorNotExist:   true,
+}
+
+type queryParser struct {
+	lexer      *queryLexer
+	token      queryToken
+	fieldTypes FieldTypes
+}
+
+func (p *queryParser) next() error {
+	token, err := p.lexer.nextToken()
+	if err != nil {
+		return err
+	}
+	p.token = token
+	return nil
+}
+
+func (p *queryParser) errorf(token queryToken, format string, args ...interface{}) error {
+	return &QueryError{Line: token.line, Column: token.column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) isKeyword(keyword string) bool {
+	return p.token.kind == tokenIdent && strings.EqualFold(p.token.text, keyword)
+}
+
+func (p *queryParser) isSymbol(symbol string) bool {
+	return p.token.kind == tokenSymbol && p.token.text == symbol
+}
+
+func (p *queryParser) parseOr() (Rule, error) {
+	return p.parseCombined(ConditionOr, "OR", p.parseAnd)
+}
+
+func (p *queryParser) parseAnd() (Rule, error) {
+	return p.parseCombined(ConditionAnd, "AND", p.parsePrimary)
+}
+
+// parseCombined parse the rules joined by the keyword, the nested rules with the same condition are flattened.
+func (p *queryParser) parseCombined(condition Condition, keyword string, parseChild func() (Rule, error)) (Rule, error) {
+	rules := make([]Rule, 0)
+	for {
+		rule, err := parseChild()
+		if err != nil {
+			return nil, err
+		}
+
+		if combined, ok := rule.(CombinedRule); ok && combined.Condition == condition {
+			rules = append(rules, combined.Rules...)
+		} else {
+			rules = append(rules, rule)
+		}
+
+		if !p.isKeyword(keyword) {
+			break
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rules) == 1 {
+		return rules[0], nil
+	}
+	return CombinedRule{Condition: condition, Rules: rules}, nil
+}
+
+func (p *queryParser) parsePrimary() (Rule, error) {
+	if p.isSymbol("(") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		rule, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isSymbol(")") {
+			return nil, p.errorf(p.token, "unexpected %s, expect \")\"", p.token)
+		}
+		return rule, p.next()
+	}
+
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (Rule, error) {
+	fieldToken := p.token
+	if fieldToken.kind != tokenIdent || p.isKeyword("AND") || p.isKeyword("OR") {
+		return nil, p.errorf(fieldToken, "unexpected %s, expect a field", fieldToken)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	opToken := p.token
+	var operator Operator
+	symbolOps, isSymbol := symbolOperators[opToken.text]
+	switch {
+	case opToken.kind == tokenSymbol && isSymbol:
+		operator = symbolOps[0]
+	case opToken.kind == tokenIdent:
+		operator = Operator(strings.ToLower(opToken.text))
+		if _, exist := SupportOperators[operator]; !exist {
+			return nil, p.errorf(opToken, "unknown operator %s", opToken)
+		}
+	default:
+		return nil, p.errorf(opToken, "unexpected %s, expect an operator", opToken)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	rule := AtomRule{Field: fieldToken.text, Operator: operator}
+	if !noValueOperators[operator] {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, isString := value.(string); isString && isSymbol {
+			rule.Operator = symbolOps[1]
+		}
+		rule.Value = value
+	}
+
+	if key, err := rule.Validate(); err != nil {
+		token := opToken
+		if key == "field" {
+			token = fieldToken
+		}
+		return nil, p.errorf(token, "invalid %s, %v", key, err)
+	}
+
+	if p.fieldTypes != nil {
+		fieldType, exist := p.fieldTypes[rule.Field]
+		if !exist {
+			return nil, p.errorf(fieldToken, "unknown field %s", rule.Field)
+		}
+		if err := validateFieldType(rule, fieldType); err != nil {
+			return nil, p.errorf(opToken, "%v", err)
+		}
+	}
+
+	return rule, nil
+}
+
+func (p *queryParser) parseValue() (interface{}, error) {
+	if !p.isSymbol("[") {
+		return p.parseLiteral()
+	}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0)
+	for !p.isSymbol("]") {
+		if len(values) > 0 {
+			if !p.isSymbol(",") {
+				return nil, p.errorf(p.token, "unexpected %s, expect \",\" or \"]\"", p.token)
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, p.next()
+}
+
+func (p *queryParser) parseLiteral() (interface{}, error) {
+	token := p.token
+	var value interface{}
+	switch {
+	case token.kind == tokenString:
+		value = token.text
+	case token.kind == tokenNumber:
+		if intVal, err := strconv.ParseInt(token.text, 10, 64); err == nil {
+			value = intVal
+		} else if floatVal, err := strconv.ParseFloat(token.text, 64); err == nil {
+			value = floatVal
+		} else {
+			return nil, p.errorf(token, "invalid number %s", token.text)
+		}
+	case p.isKeyword("true"):
+		value = true
+	case p.isKeyword("false"):
+		value = false
+	default:
+		return nil, p.errorf(token, "unexpected %s, expect a value", token)
+	}
+	return value, p.next()
+}
+
+// ValidateFieldTypes validate that all the fields used in the rule are known fields, and the operators
+// and values can be used on the type of the fields. it returns the key of the invalid rule if failed.
+func ValidateFieldTypes(rule Rule, fieldTypes FieldTypes) (string, error) {
+	switch r := rule.(type) {
+	case AtomRule:
+		fieldType, exist := fieldTypes[r.Field]
+		if !exist {
+			return r.Field, fmt.Errorf("unknown field %s", r.Field)
+		}
+		if err := validateFieldType(r, fieldType); err != nil {
+			return r.Field, err
+		}
+	case CombinedRule:
+		for idx, child := range r.Rules {
+			if key, err := ValidateFieldTypes(child, fieldTypes); err != nil {
+				return fmt.Sprintf("rules[%d].%s", idx, key), err
+			}
+		}
+	}
+	return "", nil
+}
+
+// validateFieldType validate if the rule's operator and value can be used on the type of field.
+func validateFieldType(rule AtomRule, fieldType string) error {
+	if noValueOperators[rule.Operator] {
+		return nil
+	}
+
+	switch rule.Operator {
+	case OperatorDatetimeLess, OperatorDatetimeLessOrEqual, OperatorDatetimeGreater, OperatorDatetimeGreaterOrEqual:
+		if !isDatetimeFieldType(fieldType) {
+			return fmt.Errorf("operator %s can not be used on %s field %s", rule.Operator, fieldType, rule.Field)
+		}
+		return nil
+	case OperatorBeginsWith, OperatorNotBeginsWith, OperatorContains, OperatorNotContains, OperatorsEndsWith,
+		OperatorNotEndsWith:
+		if !isStringFieldType(fieldType) {
+			return fmt.Errorf("operator %s can not be used on %s field %s", rule.Operator, fieldType, rule.Field)
+		}
+		return nil
+	case OperatorInSubnet:
+		if !isNetworkFieldType(fieldType) && !isStringFieldType(fieldType) {
+			return fmt.Errorf("operator %s can not be used on %s field %s", rule.Operator, fieldType, rule.Field)
+		}
+		return nil
+	case OperatorIPRange:
+		if fieldType == common.FieldTypeCIDR || !isNetworkFieldType(fieldType) && !isStringFieldType(fieldType) {
+			return fmt.Errorf("operator %s can not be used on %s field %s", rule.Operator, fieldType, rule.Field)
+		}
+		return nil
+	}
+
+	values, isList := rule.Value.([]interface{})
+	if !isList {
+		values = []interface{}{rule.Value}
+	}
+
+	for _, value := range values {
+		valueType := getType(value)
+		var err error
+		switch {
+		case fieldType == common.FieldTypeInt || fieldType == common.FieldTypeFloat:
+			if valueType != TypeNumeric {
+				err = fmt.Errorf("%s field %s requires numeric value, but got %v", fieldType, rule.Field, value)
+			}
+		case fieldType == common.FieldTypeBool:
+			if valueType != TypeBoolean {
+				err = fmt.Errorf("%s field %s requires boolean value, but got %v", fieldType, rule.Field, value)
+			}
+		case isDatetimeFieldType(fieldType):
+			if valueType != TypeString {
+				err = fmt.Errorf("%s field %s requires datetime value, but got %v", fieldType, rule.Field, value)
+			} else if _, parseErr := ParseDatetime(value.(string), time.Now()); parseErr != nil {
+				err = parseErr
+			}
+		case isStringFieldType(fieldType):
+			if valueType != TypeString {
+				err = fmt.Errorf("%s field %s requires string value, but got %v", fieldType, rule.Field, value)
+			}
+		case isNetworkFieldType(fieldType):
+			if valueType != TypeString {
+				err = fmt.Errorf("%s field %s requires string value, but got %v", fieldType, rule.Field, value)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isDatetimeFieldType(fieldType string) bool {
+	return fieldType == common.FieldTypeDate || fieldType == common.FieldTypeTime || fieldType == FieldTypeDatetime
+}
+
+func isStringFieldType(fieldType string) bool {
+	switch fieldType {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeEnum, common.FieldTypeUser,
+		common.FieldTypeTimeZone, common.FieldTypeList, common.FieldTypeMultiEnum:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	query := `bk_os_type = "Linux" AND bk_cpu >= 8 AND bk_host_innerip begins_with "10.1."`
+	filter, err := querybuilder.ParseQuery(query, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, querybuilder.CombinedRule{
+		Condition: querybuilder.ConditionAnd,
+		Rules: []querybuilder.Rule{
+			querybuilder.AtomRule{Field: "bk_os_type", Operator: querybuilder.OperatorEqual, Value: "Linux"},
+			querybuilder.AtomRule{Field: "bk_cpu", Operator: querybuilder.OperatorGreaterOrEqual, Value: int64(8)},
+			querybuilder.AtomRule{Field: "bk_host_innerip", Operator: querybuilder.OperatorBeginsWith, Value: "10.1."},
+		},
+	}, filter.Rule)
+
+	query = "bk_cpu in [4, 8]\nOR (create_time >= \"now-7d\" and bk_mem between [1024, 4096])"
+	filter, err = querybuilder.ParseQuery(query, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, querybuilder.CombinedRule{
+		Condition: querybuilder.ConditionOr,
+		Rules: []querybuilder.Rule{
+			querybuilder.AtomRule{Field: "bk_cpu", Operator: querybuilder.OperatorIn, Value: []interface{}{int64(4), int64(8)}},
+			querybuilder.CombinedRule{
+				Condition: querybuilder.ConditionAnd,
+				Rules: []querybuilder.Rule{
+					querybuilder.AtomRule{Field: "create_time", Operator: querybuilder.OperatorDatetimeGreaterOrEqual, Value: "now-7d"},
+					querybuilder.AtomRule{Field: "bk_mem", Operator: querybuilder.OperatorBetween,
+						Value: []interface{}{int64(1024), int64(4096)}},
+				},
+			},
+		},
+	}, filter.Rule)
+
+	_, _, err = filter.ToMgo()
+	assert.Nil(t, err)
+}
+
+// the text query is parsed by topo server and converted by coreservice, the relative time must be
+// compared as a time after the filter is sent through the api.
+func TestParseQueryRelativeTimeRoundTrip(t *testing.T) {
+	filter, err := querybuilder.ParseQuery(`bk_inst_name begins_with "test" AND create_time >= "now-7d"`, nil)
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(metadata.QueryInput{Condition: map[string]interface{}{}, Filter: filter})
+	assert.Nil(t, err)
+	input := metadata.QueryCondition{}
+	assert.Nil(t, json.Unmarshal(data, &input))
+	if !assert.NotNil(t, input.Filter) {
+		return
+	}
+
+	_, err = input.Filter.Validate()
+	assert.Nil(t, err)
+	cond, _, err := input.Filter.ToMgoWithFieldTypes(querybuilder.FieldTypes{"bk_inst_name": common.FieldTypeSingleChar})
+	assert.Nil(t, err)
+
+	rules := cond[common.BKDBAND].([]map[string]interface{})
+	assert.Len(t, rules, 2)
+	createTime, ok := rules[1][common.CreateTimeField].(map[string]interface{})[common.BKDBGTE].(time.Time)
+	if assert.True(t, ok, "relative time should be converted to time.Time") {
+		assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), createTime, time.Minute)
+	}
+}
+
+func TestParseQueryError(t *testing.T) {
+	fieldTypes := querybuilder.FieldTypes{
+		"bk_os_type":           common.FieldTypeEnum,
+		"bk_cpu":               common.FieldTypeInt,
+		"bk_host_innerip":      common.FieldTypeSingleChar,
+		common.CreateTimeField: querybuilder.FieldTypeDatetime,
+	}
+
+	cases := []struct {
+		query  string
+		line   int
+		column int
+	}{
+		{query: `bk_os_type = "Linux" AND`, line: 1, column: 25},
+		{query: `bk_os_type = "Linux`, line: 1, column: 14},
+		{query: `bk_os_type == "Linux"`, line: 1, column: 13},
+		{query: `bk_os_type like "Linux"`, line: 1, column: 12},
+		{query: "bk_cpu >= 8 AND\n  (bk_os_type = \"Linux\"", line: 2, column: 24},
+		{query: `bk_cpu >= 8 bk_os_type = "Linux"`, line: 1, column: 13},
+		{query: "bk_cpu >= 8 AND\nbk_unknown = 1", line: 2, column: 1},
+		{query: `bk_cpu = "8"`, line: 1, column: 8},
+		{query: `bk_host_innerip >= 1`, line: 1, column: 17},
+		{query: `bk_cpu begins_with "1"`, line: 1, column: 8},
+		{query: `create_time >= "yesterday"`, line: 1, column: 13},
+		{query: `bk_cpu between [1]`, line: 1, column: 8},
+	}
+
+	for _, c := range cases {
+		_, err := querybuilder.ParseQuery(c.query, fieldTypes)
+		queryErr, ok := err.(*querybuilder.QueryError)
+		if !assert.True(t, ok, "query %q should be failed, but got %v", c.query, err) {
+			continue
+		}
+		assert.Equal(t, c.line, queryErr.Line, "query %q, err: %v", c.query, err)
+		assert.Equal(t, c.column, queryErr.Column, "query %q, err: %v", c.query, err)
+	}
+}
+
+func TestValidateFieldTypes(t *testing.T) {
+	fieldTypes := querybuilder.FieldTypes{
+		"bk_cpu":      common.FieldTypeInt,
+		"create_time": querybuilder.FieldTypeDatetime,
+	}
+	rule := querybuilder.CombinedRule{
+		Condition: querybuilder.ConditionAnd,
+		Rules: []querybuilder.Rule{
+			querybuilder.AtomRule{Field: "bk_cpu", Operator: querybuilder.OperatorGreater, Value: 4},
+			querybuilder.AtomRule{Field: "create_time", Operator: querybuilder.OperatorDatetimeGreater, Value: "now-1d"},
+		},
+	}
+	_, err := querybuilder.ValidateFieldTypes(rule, fieldTypes)
+	assert.Nil(t, err)
+
+	rule.Rules = append(rule.Rules, querybuilder.AtomRule{Field: "bk_cpu", Operator: querybuilder.OperatorEqual, Value: "4"})
+	key, err := querybuilder.ValidateFieldTypes(rule, fieldTypes)
+	assert.NotNil(t, err)
+	assert.Equal(t, "rules[2].bk_cpu", key)
+
+	rule.Rules = []querybuilder.Rule{querybuilder.AtomRule{Field: "unknown", Operator: querybuilder.OperatorEqual, Value: 1}}
+	key, err = querybuilder.ValidateFieldTypes(rule, fieldTypes)
+	assert.NotNil(t, err)
+	assert.Equal(t, "rules[0].unknown", key)
+}
@@ -14,6 +14,7 @@ package querybuilder
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
 	"time"
 
@@ -24,9 +25,16 @@ type Rule interface {
 	GetDeep() int
 	Validate() (string, error)
 	ToMgo() (mgoFilter map[string]interface{}, errKey string, err error)
+	// ToMgoWithFieldTypes generate mongo filter with the fields' property types, so that the
+	// datetime and range values can be converted to the type which the field is stored in db.
+	ToMgoWithFieldTypes(fieldTypes FieldTypes) (mgoFilter map[string]interface{}, errKey string, err error)
 	Match(matcher Matcher) bool
 }
 
+// FieldTypes is the property types of the fields, key is the field, value is the property
+// type defined in the model attributes, such as common.FieldTypeDate.
+type FieldTypes map[string]string
+
 // *************** define condition ************************
 type Condition string
 
@@ -76,6 +84,13 @@ var (
 	OperatorDatetimeGreater        = Operator("datetime_greater")
 	OperatorDatetimeGreaterOrEqual = Operator("datetime_greater_or_equal")
 
+	// range operator, value is [start, end], both of them are included
+	OperatorBetween = Operator("between")
+
+	// network operator, in_subnet value is a cidr like 10.0.0.0/8, ip_range value is [start, end] of ip addresses
+	OperatorInSubnet = Operator("in_subnet")
+	OperatorIPRange  = Operator("ip_range")
+
 	// string operator
 	OperatorBeginsWith    = Operator("begins_with")
 	OperatorNotBeginsWith = Operator("not_begins_with")
@@ -109,10 +124,15 @@ var SupportOperators = map[Operator]bool{
 	OperatorGreater:        true,
 	OperatorGreaterOrEqual: true,
 
-	OperatorDatetimeLess:           false,
-	OperatorDatetimeLessOrEqual:    false,
-	OperatorDatetimeGreater:        false,
-	OperatorDatetimeGreaterOrEqual: false,
+	OperatorDatetimeLess:           true,
+	OperatorDatetimeLessOrEqual:    true,
+	OperatorDatetimeGreater:        true,
+	OperatorDatetimeGreaterOrEqual: true,
+
+	OperatorBetween: true,
+
+	OperatorInSubnet: true,
+	OperatorIPRange:  true,
 
 	OperatorBeginsWith:    true,
 	OperatorNotBeginsWith: true,
@@ -190,6 +210,12 @@ func (r AtomRule) validateValue() error {
 		return validateNumericType(r.Value)
 	case OperatorDatetimeLess, OperatorDatetimeLessOrEqual, OperatorDatetimeGreater, OperatorDatetimeGreaterOrEqual:
 		return validateDatetimeStringType(r.Value)
+	case OperatorBetween:
+		return validateRangeType(r.Value)
+	case OperatorInSubnet:
+		return validateCIDRType(r.Value)
+	case OperatorIPRange:
+		return validateIPRangeType(r.Value)
 	case OperatorBeginsWith, OperatorNotBeginsWith, OperatorContains, OperatorNotContains, OperatorsEndsWith, OperatorNotEndsWith:
 		return validateNotEmptyStringType(r.Value)
 	case OperatorIsEmpty, OperatorIsNotEmpty:
@@ -205,11 +231,25 @@ func (r AtomRule) validateValue() error {
 
 // ToMgo generate mongo filter from rule
 func (r AtomRule) ToMgo() (mgoFiler map[string]interface{}, key string, err error) {
+	return r.ToMgoWithFieldTypes(nil)
+}
+
+// ToMgoWithFieldTypes generate mongo filter from rule, the datetime and range values is converted
+// according to the field's property type.
+func (r AtomRule) ToMgoWithFieldTypes(fieldTypes FieldTypes) (mgoFiler map[string]interface{}, key string, err error) {
 	if key, err := r.Validate(); err != nil {
 		return nil, key, fmt.Errorf("validate failed, key: %s, err: %s", key, err)
 	}
 
+	now := time.Now()
 	filter := make(map[string]interface{})
+	if isNetworkFieldType(fieldTypes[r.Field]) {
+		switch r.Operator {
+		case OperatorEqual, OperatorNotEqual, OperatorIn, OperatorNotIn:
+			r.Value = normalizeNetworkValue(r.Value, fieldTypes[r.Field])
+		}
+	}
+
 	switch r.Operator {
 	case OperatorEqual:
 		filter[r.Field] = map[string]interface{}{
@@ -244,7 +284,7 @@ func (r AtomRule) ToMgo() (mgoFiler map[string]interface{}, key string, err erro
 			common.BKDBGTE: r.Value,
 		}
 	case OperatorDatetimeLess:
-		t, err := time.Parse(time.RFC3339, r.Value.(string))
+		t, err := datetimeToMgo(r.Value.(string), fieldTypes[r.Field], now)
 		if err != nil {
 			return nil, "value", err
 		}
@@ -252,7 +292,7 @@ func (r AtomRule) ToMgo() (mgoFiler map[string]interface{}, key string, err erro
 			common.BKDBLT: t,
 		}
 	case OperatorDatetimeLessOrEqual:
-		t, err := time.Parse(time.RFC3339, r.Value.(string))
+		t, err := datetimeToMgo(r.Value.(string), fieldTypes[r.Field], now)
 		if err != nil {
 			return nil, "value", err
 		}
@@ -260,7 +300,7 @@ func (r AtomRule) ToMgo() (mgoFiler map[string]interface{}, key string, err erro
 			common.BKDBLTE: t,
 		}
 	case OperatorDatetimeGreater:
-		t, err := time.Parse(time.RFC3339, r.Value.(string))
+		t, err := datetimeToMgo(r.Value.(string), fieldTypes[r.Field], now)
 		if err != nil {
 			return nil, "value", err
 		}
@@ -268,13 +308,34 @@ func (r AtomRule) ToMgo() (mgoFiler map[string]interface{}, key string, err erro
 			common.BKDBGT: t,
 		}
 	case OperatorDatetimeGreaterOrEqual:
-		t, err := time.Parse(time.RFC3339, r.Value.(string))
+		t, err := datetimeToMgo(r.Value.(string), fieldTypes[r.Field], now)
 		if err != nil {
 			return nil, "value", err
 		}
 		filter[r.Field] = map[string]interface{}{
 			common.BKDBGTE: t,
 		}
+	case OperatorBetween:
+		start, end, err := r.rangeToMgo(fieldTypes[r.Field], now)
+		if err != nil {
+			return nil, "value", err
+		}
+		filter[r.Field] = map[string]interface{}{
+			common.BKDBGTE: start,
+			common.BKDBLTE: end,
+		}
+	case OperatorInSubnet:
+		subnetFilter, err := r.subnetToMgo(fieldTypes[r.Field])
+		if err != nil {
+			return nil, "value", err
+		}
+		filter[r.Field] = subnetFilter
+	case OperatorIPRange:
+		rangeFilter, err := r.ipRangeToMgo(fieldTypes[r.Field])
+		if err != nil {
+			return nil, "value", err
+		}
+		filter[r.Field] = rangeFilter
 	case OperatorBeginsWith:
 		filter[r.Field] = map[string]interface{}{
 			common.BKDBLIKE: fmt.Sprintf("^%s", r.Value),
@@ -331,6 +392,41 @@ func (r AtomRule) ToMgo() (mgoFiler map[string]interface{}, key string, err erro
 	return filter, "", nil
 }
 
+// rangeToMgo convert the range values according to the field type, numeric field's range is compared
+// directly, date, time and datetime field's range is compared as datetime, if the field type is unknown,
+// a range of strings is considered as a datetime range, like ["now-7d", "now"].
+func (r AtomRule) rangeToMgo(fieldType string, now time.Time) (start, end interface{}, err error) {
+	values := reflect.ValueOf(r.Value)
+	start, end = values.Index(0).Interface(), values.Index(1).Interface()
+
+	isDatetime := false
+	switch fieldType {
+	case common.FieldTypeInt, common.FieldTypeFloat:
+		if getType(start) != TypeNumeric {
+			return nil, nil, fmt.Errorf("range of %s field %s should be numeric", fieldType, r.Field)
+		}
+	case common.FieldTypeDate, common.FieldTypeTime, FieldTypeDatetime:
+		if getType(start) != TypeString {
+			return nil, nil, fmt.Errorf("range of %s field %s should be datetime string", fieldType, r.Field)
+		}
+		isDatetime = true
+	case "":
+		isDatetime = getType(start) == TypeString
+	}
+
+	if !isDatetime {
+		return start, end, nil
+	}
+
+	if start, err = datetimeToMgo(start.(string), fieldType, now); err != nil {
+		return nil, nil, err
+	}
+	if end, err = datetimeToMgo(end.(string), fieldType, now); err != nil {
+		return nil, nil, err
+	}
+	return start, end, nil
+}
+
 // *************** define query ************************
 type CombinedRule struct {
 	Condition Condition `json:"condition"`
@@ -369,6 +465,10 @@ func (r CombinedRule) Validate() (string, error) {
 }
 
 func (r CombinedRule) ToMgo() (mgoFilter map[string]interface{}, key string, err error) {
+	return r.ToMgoWithFieldTypes(nil)
+}
+
+func (r CombinedRule) ToMgoWithFieldTypes(fieldTypes FieldTypes) (mgoFilter map[string]interface{}, key string, err error) {
 	if err := r.Condition.Validate(); err != nil {
 		return nil, "condition", err
 	}
@@ -377,7 +477,7 @@ func (r CombinedRule) ToMgo() (mgoFilter map[string]interface{}, key string, err
 	}
 	filters := make([]map[string]interface{}, 0)
 	for idx, rule := range r.Rules {
-		filter, key, err := rule.ToMgo()
+		filter, key, err := rule.ToMgoWithFieldTypes(fieldTypes)
 		if err != nil {
 			return nil, fmt.Sprintf("rules[%d].%s", idx, key), err
 		}
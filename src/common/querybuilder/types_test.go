@@ -14,6 +14,9 @@ package querybuilder_test
 
 import (
 	"testing"
+	"time"
+
+	"configcenter/src/common"
 
 	"configcenter/src/common/querybuilder"
 
@@ -114,6 +117,22 @@ func TestNormalAtomRule(t *testing.T) {
 			Operator: querybuilder.OperatorDatetimeGreaterOrEqual,
 			Field:    "field",
 			Value:    "2019-08-04T14:08:00.00Z",
+		}, {
+			Operator: querybuilder.OperatorDatetimeGreater,
+			Field:    "field",
+			Value:    "now-7d",
+		}, {
+			Operator: querybuilder.OperatorDatetimeLess,
+			Field:    "field",
+			Value:    "2019-08-04 14:08:00",
+		}, {
+			Operator: querybuilder.OperatorBetween,
+			Field:    "field",
+			Value:    []int64{1, 10},
+		}, {
+			Operator: querybuilder.OperatorBetween,
+			Field:    "field",
+			Value:    []string{"now-7d", "now"},
 		}, {
 			Operator: querybuilder.OperatorBeginsWith,
 			Field:    "field",
@@ -180,6 +199,80 @@ func TestNormalAtomRule(t *testing.T) {
 	}
 }
 
+func TestAtomRuleToMgoWithFieldTypes(t *testing.T) {
+	fieldTypes := querybuilder.FieldTypes{
+		"date_field": common.FieldTypeDate,
+		"time_field": common.FieldTypeTime,
+		"int_field":  common.FieldTypeInt,
+	}
+
+	rule := querybuilder.AtomRule{
+		Operator: querybuilder.OperatorBetween,
+		Field:    "date_field",
+		Value:    []string{"2020-07-01", "2020-07-08T10:00:00+08:00"},
+	}
+	filter, errKey, err := rule.ToMgoWithFieldTypes(fieldTypes)
+	assert.Nil(t, err)
+	assert.Empty(t, errKey)
+	cond := filter["date_field"].(map[string]interface{})
+	assert.Equal(t, "2020-07-01", cond[common.BKDBGTE])
+	assert.Equal(t, time.Date(2020, 7, 8, 10, 0, 0, 0, time.FixedZone("", 8*3600)).In(time.Local).Format("2006-01-02"),
+		cond[common.BKDBLTE])
+
+	rule = querybuilder.AtomRule{
+		Operator: querybuilder.OperatorDatetimeGreaterOrEqual,
+		Field:    "time_field",
+		Value:    "2020-07-01 08:00:00",
+	}
+	filter, _, err = rule.ToMgoWithFieldTypes(fieldTypes)
+	assert.Nil(t, err)
+	assert.Equal(t, "2020-07-01 08:00:00", filter["time_field"].(map[string]interface{})[common.BKDBGTE])
+
+	// fields without property type, such as create_time, is compared as time.
+	rule = querybuilder.AtomRule{
+		Operator: querybuilder.OperatorBetween,
+		Field:    common.CreateTimeField,
+		Value:    []string{"now-7d", "now"},
+	}
+	filter, _, err = rule.ToMgoWithFieldTypes(fieldTypes)
+	assert.Nil(t, err)
+	cond = filter[common.CreateTimeField].(map[string]interface{})
+	start, end := cond[common.BKDBGTE].(time.Time), cond[common.BKDBLTE].(time.Time)
+	assert.Equal(t, 7*24*time.Hour, end.Sub(start))
+
+	rule = querybuilder.AtomRule{
+		Operator: querybuilder.OperatorBetween,
+		Field:    "int_field",
+		Value:    []string{"now-7d", "now"},
+	}
+	_, errKey, err = rule.ToMgoWithFieldTypes(fieldTypes)
+	assert.NotNil(t, err)
+	assert.Equal(t, "value", errKey)
+}
+
+func TestParseDatetime(t *testing.T) {
+	now := time.Date(2020, 7, 8, 10, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"now":                  now,
+		"now-7d":               now.Add(-7 * 24 * time.Hour),
+		"now+2h":               now.Add(2 * time.Hour),
+		"now-1w":               now.Add(-7 * 24 * time.Hour),
+		"2020-07-01T00:00:00Z": time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC),
+		"2020-07-01":           time.Date(2020, 7, 1, 0, 0, 0, 0, time.Local),
+		"2020-07-01 12:30:00":  time.Date(2020, 7, 1, 12, 30, 0, 0, time.Local),
+	}
+	for value, expect := range cases {
+		parsed, err := querybuilder.ParseDatetime(value, now)
+		assert.Nil(t, err)
+		assert.True(t, expect.Equal(parsed), "parse %s, expect %s, but got %s", value, expect, parsed)
+	}
+
+	for _, value := range []string{"", "now-", "now-7", "now*7d", "yesterday", "2020/07/01"} {
+		_, err := querybuilder.ParseDatetime(value, now)
+		assert.NotNil(t, err, "parse %s should be failed", value)
+	}
+}
+
 func TestInvalidateFieldAtomRule(t *testing.T) {
 	rules := []querybuilder.AtomRule{
 		{
@@ -263,6 +356,22 @@ func TestInvalidateValueAtomRule(t *testing.T) {
 			Operator: querybuilder.OperatorDatetimeLess,
 			Field:    "field",
 			Value:    []string{"2019-08-04T14:08:00.00Z"},
+		}, {
+			Operator: querybuilder.OperatorDatetimeLess,
+			Field:    "field",
+			Value:    "now-7y",
+		}, {
+			Operator: querybuilder.OperatorBetween,
+			Field:    "field",
+			Value:    []int64{1},
+		}, {
+			Operator: querybuilder.OperatorBetween,
+			Field:    "field",
+			Value:    []bool{true, false},
+		}, {
+			Operator: querybuilder.OperatorBetween,
+			Field:    "field",
+			Value:    []interface{}{1, "now"},
 		}, {
 			Operator: querybuilder.OperatorBeginsWith,
 			Field:    "field",
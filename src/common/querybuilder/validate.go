@@ -83,12 +83,29 @@ func validateDatetimeStringType(value interface{}) error {
 	if err := validateStringType(value); err != nil {
 		return err
 	}
-	if _, err := time.Parse(time.RFC3339, value.(string)); err != nil {
+	if _, err := ParseDatetime(value.(string), time.Now()); err != nil {
 		return err
 	}
 	return nil
 }
 
+// validateRangeType validate the range value, which should be [start, end] of numeric or strings.
+func validateRangeType(value interface{}) error {
+	if err := validateSliceOfBasicType(value, true); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Len() != 2 {
+		return fmt.Errorf("range value should be [start, end], but got %d elements", v.Len())
+	}
+
+	if t := getType(v.Index(0).Interface()); t != TypeNumeric && t != TypeString {
+		return fmt.Errorf("range value should be numeric or string, but got %s", t)
+	}
+	return nil
+}
+
 func validateSliceOfBasicType(value interface{}, requireSameType bool) error {
 	t := reflect.TypeOf(value)
 	if t.Kind() != reflect.Array && t.Kind() != reflect.Slice {
@@ -0,0 +1,319 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package secret encrypts the values of the secret attributes before they are saved, and masks them
+// when they are returned to the users.
+//
+// a secret value is encrypted with AES-256-GCM by the current key of the Keyring, the stored ciphertext
+// looks like "{secret}<key id>:<base64 of nonce and sealed data>". the key id is kept in the ciphertext,
+// so that the keys can be rotated: a new key is added and marked as the current one, the old keys are
+// still used to decrypt the values saved before, until they are re-encrypted by Keyring.Rotate.
+//
+// the values are masked with MaskMapStr or MaskJSON at the places where the instances leave cmdb, such as
+// the searches, the event callbacks, the caches and the watch events. MaskMapStr masks the fields of the
+// model's secret attributes, and the ciphertexts can be recognized by their prefix, so they are masked
+// wherever they are in the data, even the model's attributes are not known.
+//
+// this package only depends on the go standard library.
+package secret
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	// Mask is the value returned instead of the secret value.
+	Mask = "******"
+	// CipherPrefix is the prefix of all the encrypted values.
+	CipherPrefix = "{secret}"
+	// keySize is the size of the AES-256 key.
+	keySize = 32
+)
+
+var (
+	ErrNoKey         = errors.New("secret key is not configured")
+	ErrInvalidCipher = errors.New("invalid secret ciphertext")
+	ErrUnknownKey    = errors.New("secret ciphertext is encrypted by an unknown key")
+)
+
+// Keyring holds the keys used to encrypt and decrypt the secret values.
+type Keyring struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring with the keys, which is a map of key id to the base64 encoded
+// 32 bytes key, current is the id of the key used to encrypt the new values.
+func NewKeyring(current string, keys map[string]string) (*Keyring, error) {
+	if _, exist := keys[current]; !exist {
+		return nil, fmt.Errorf("invalid secret key config: current key %s is not in the keys", current)
+	}
+
+	k := &Keyring{current: current, aeads: make(map[string]cipher.AEAD)}
+	for id, encoded := range keys {
+		if len(id) == 0 || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid secret key config: invalid key id %q", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret key config: key %s is not base64 encoded", id)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("invalid secret key config: key %s must be %d bytes", id, keySize)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.aeads[id] = aead
+	}
+	return k, nil
+}
+
+// ParseKeys parses the keys config like "v1:<base64 key>,v2:<base64 key>" to a map of key id to key.
+func ParseKeys(config string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, item := range strings.Split(config, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		idx := strings.Index(item, ":")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid secret key config: %q should be like <key id>:<base64 key>", item)
+		}
+		keys[item[:idx]] = item[idx+1:]
+	}
+	return keys, nil
+}
+
+// CurrentKey returns the id of the key used to encrypt the new values.
+func (k *Keyring) CurrentKey() string {
+	if k == nil {
+		return ""
+	}
+	return k.current
+}
+
+// Encrypt encrypts the plaintext with the current key.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if k == nil {
+		return "", ErrNoKey
+	}
+
+	aead := k.aeads[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	// the key id is used as the additional data, so that the ciphertext can't be moved to another key id.
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.current))
+	return CipherPrefix + k.current + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts the ciphertext with the key it's encrypted by.
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	if k == nil {
+		return "", ErrNoKey
+	}
+
+	id, data, err := split(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	aead, exist := k.aeads[id]
+	if !exist {
+		return "", ErrUnknownKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCipher
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", ErrInvalidCipher
+	}
+	return string(plaintext), nil
+}
+
+// NeedRotate returns true if the ciphertext is not encrypted by the current key.
+func (k *Keyring) NeedRotate(ciphertext string) bool {
+	id, _, err := split(ciphertext)
+	if err != nil {
+		return false
+	}
+	return id != k.CurrentKey()
+}
+
+// Rotate re-encrypts the ciphertext with the current key, the ciphertext is returned as it is if
+// it's already encrypted by the current key.
+func (k *Keyring) Rotate(ciphertext string) (string, error) {
+	if !k.NeedRotate(ciphertext) {
+		return ciphertext, nil
+	}
+
+	plaintext, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return k.Encrypt(plaintext)
+}
+
+// split splits the ciphertext into the key id and the base64 encoded sealed data.
+func split(ciphertext string) (string, string, error) {
+	if !strings.HasPrefix(ciphertext, CipherPrefix) {
+		return "", "", ErrInvalidCipher
+	}
+
+	body := ciphertext[len(CipherPrefix):]
+	idx := strings.Index(body, ":")
+	if idx <= 0 {
+		return "", "", ErrInvalidCipher
+	}
+	return body[:idx], body[idx+1:], nil
+}
+
+// IsEncrypted returns true if the value is a secret ciphertext.
+func IsEncrypted(value interface{}) bool {
+	str, ok := value.(string)
+	return ok && strings.HasPrefix(str, CipherPrefix)
+}
+
+// MaskValue returns the Mask if the value is a secret ciphertext, or the value itself.
+func MaskValue(value interface{}) interface{} {
+	if IsEncrypted(value) {
+		return Mask
+	}
+	return value
+}
+
+// MaskMapStr replaces the values of the secret fields in the data with the Mask, the secret fields are the
+// property ids of the model's secret attributes, so the plaintexts which are not saved yet are masked too.
+// the ciphertexts in the data and in its nested maps and arrays are masked by their prefix.
+func MaskMapStr(data map[string]interface{}, secretFields ...string) {
+	for _, field := range secretFields {
+		if value, exist := data[field]; exist && value != nil && value != "" {
+			data[field] = Mask
+		}
+	}
+
+	for key, value := range data {
+		data[key] = maskNestedValue(value)
+	}
+}
+
+var (
+	mapType   = reflect.TypeOf(map[string]interface{}{})
+	sliceType = reflect.TypeOf([]interface{}{})
+)
+
+// maskNestedValue masks the ciphertexts in the value, the maps and arrays are masked in place.
+func maskNestedValue(value interface{}) interface{} {
+	switch val := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return MaskValue(val)
+	case map[string]interface{}:
+		MaskMapStr(val)
+		return val
+	case []interface{}:
+		for idx := range val {
+			val[idx] = maskNestedValue(val[idx])
+		}
+		return val
+	}
+
+	// the named types, such as mapstr.MapStr and bson.A, still share the data after they are converted
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().ConvertibleTo(mapType):
+		MaskMapStr(rv.Convert(mapType).Interface().(map[string]interface{}))
+	case rv.Kind() == reflect.Slice && rv.Type().ConvertibleTo(sliceType):
+		maskNestedValue(rv.Convert(sliceType).Interface())
+	case rv.Kind() == reflect.Slice:
+		for idx := 0; idx < rv.Len(); idx++ {
+			elem := rv.Index(idx)
+			if elem.Kind() == reflect.String {
+				if strings.HasPrefix(elem.String(), CipherPrefix) {
+					elem.SetString(Mask)
+				}
+				continue
+			}
+			maskNestedValue(elem.Interface())
+		}
+	}
+	return value
+}
+
+// MaskJSON replaces the secret ciphertexts in the json object document with the Mask, the document is
+// returned as it is if it doesn't contain any ciphertext or it's not a json object.
+func MaskJSON(doc []byte) []byte {
+	if !bytes.Contains(doc, []byte(CipherPrefix)) {
+		return doc
+	}
+
+	data := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return doc
+	}
+
+	MaskMapStr(data)
+	masked, err := json.Marshal(data)
+	if err != nil {
+		return doc
+	}
+	return masked
+}
+
+var (
+	defaultKeyring *Keyring
+	defaultLock    sync.RWMutex
+)
+
+// SetDefault sets the keyring used by the process.
+func SetDefault(k *Keyring) {
+	defaultLock.Lock()
+	defaultKeyring = k
+	defaultLock.Unlock()
+}
+
+// Default returns the keyring used by the process, it's nil if the keys are not configured.
+func Default() *Keyring {
+	defaultLock.RLock()
+	defer defaultLock.RUnlock()
+	return defaultKeyring
+}
@@ -0,0 +1,180 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package secret
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestEncryptAndRotate(t *testing.T) {
+	oldKey := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	newKey := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", 32)))
+
+	old, err := NewKeyring("v1", map[string]string{"v1": oldKey})
+	if err != nil {
+		t.Errorf("create keyring failed, err: %v", err)
+		return
+	}
+
+	ciphertext, err := old.Encrypt("bmc-password")
+	if err != nil {
+		t.Errorf("encrypt failed, err: %v", err)
+		return
+	}
+	if !IsEncrypted(ciphertext) || strings.Contains(ciphertext, "bmc-password") {
+		t.Errorf("invalid ciphertext %s", ciphertext)
+		return
+	}
+
+	keys, err := ParseKeys("v1:" + oldKey + ", v2:" + newKey)
+	if err != nil {
+		t.Errorf("parse keys failed, err: %v", err)
+		return
+	}
+	rotated, err := NewKeyring("v2", keys)
+	if err != nil {
+		t.Errorf("create rotated keyring failed, err: %v", err)
+		return
+	}
+
+	if !rotated.NeedRotate(ciphertext) {
+		t.Errorf("ciphertext encrypted by the old key should be rotated")
+		return
+	}
+	newCiphertext, err := rotated.Rotate(ciphertext)
+	if err != nil {
+		t.Errorf("rotate failed, err: %v", err)
+		return
+	}
+	if rotated.NeedRotate(newCiphertext) {
+		t.Errorf("rotated ciphertext should not be rotated again")
+		return
+	}
+
+	plaintext, err := rotated.Decrypt(newCiphertext)
+	if err != nil || plaintext != "bmc-password" {
+		t.Errorf("decrypt rotated ciphertext failed, plaintext: %s, err: %v", plaintext, err)
+		return
+	}
+
+	if _, err := old.Decrypt(newCiphertext); err != ErrUnknownKey {
+		t.Errorf("decrypt with the old keyring, expect unknown key, but got: %v", err)
+		return
+	}
+
+	tampered := strings.Replace(newCiphertext, CipherPrefix+"v2:", CipherPrefix+"v1:", 1)
+	if _, err := rotated.Decrypt(tampered); err != ErrInvalidCipher {
+		t.Errorf("decrypt tampered ciphertext, expect invalid cipher, but got: %v", err)
+		return
+	}
+}
+
+func TestMaskMapStr(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	keyring, err := NewKeyring("v1", map[string]string{"v1": key})
+	if err != nil {
+		t.Errorf("create keyring failed, err: %v", err)
+		return
+	}
+
+	ciphertext, _ := keyring.Encrypt("token")
+	data := map[string]interface{}{
+		"bk_inst_name": "server",
+		"token":        ciphertext,
+		"detail":       map[string]interface{}{"token": ciphertext},
+	}
+	MaskMapStr(data)
+
+	if data["bk_inst_name"] != "server" || data["token"] != Mask ||
+		data["detail"].(map[string]interface{})["token"] != Mask {
+		t.Errorf("mask data failed, data: %v", data)
+		return
+	}
+
+	// the secret fields are masked even they are not encrypted, the empty values are kept
+	data = map[string]interface{}{
+		"bk_inst_name": "server",
+		"password":     "plaintext",
+		"token":        "",
+	}
+	MaskMapStr(data, "password", "token", "not_exist")
+	if data["bk_inst_name"] != "server" || data["password"] != Mask || data["token"] != "" {
+		t.Errorf("mask secret fields failed, data: %v", data)
+		return
+	}
+	if _, exist := data["not_exist"]; exist {
+		t.Errorf("mask secret fields failed, the not exist field is added, data: %v", data)
+		return
+	}
+
+	doc := MaskJSON([]byte(`{"bk_inst_id":1,"token":"` + ciphertext + `"}`))
+	if string(doc) != `{"bk_inst_id":1,"token":"******"}` {
+		t.Errorf("mask json document failed, doc: %s", doc)
+		return
+	}
+}
+
+type namedMap map[string]interface{}
+
+type namedArray []interface{}
+
+func TestMaskNestedData(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	keyring, err := NewKeyring("v1", map[string]string{"v1": key})
+	if err != nil {
+		t.Errorf("create keyring failed, err: %v", err)
+		return
+	}
+
+	ciphertext, _ := keyring.Encrypt("token")
+	rows := []interface{}{
+		map[string]interface{}{"name": "a", "token": ciphertext},
+		namedMap{"name": "b", "token": ciphertext},
+		ciphertext,
+	}
+	typedRows := []namedMap{{"token": ciphertext}}
+	strs := []string{"plain", ciphertext}
+	data := namedMap{
+		"rows":       rows,
+		"typed_rows": typedRows,
+		"strs":       strs,
+		"detail":     namedMap{"array": namedArray{ciphertext, namedMap{"token": ciphertext}}},
+		"count":      1,
+	}
+	MaskMapStr(data)
+
+	if rows[0].(map[string]interface{})["token"] != Mask || rows[0].(map[string]interface{})["name"] != "a" ||
+		rows[1].(namedMap)["token"] != Mask || rows[2] != Mask {
+		t.Errorf("mask the nested array failed, rows: %v", rows)
+		return
+	}
+	if typedRows[0]["token"] != Mask {
+		t.Errorf("mask the nested typed array failed, rows: %v", typedRows)
+		return
+	}
+	if strs[0] != "plain" || strs[1] != Mask {
+		t.Errorf("mask the nested string array failed, strs: %v", strs)
+		return
+	}
+	array := data["detail"].(namedMap)["array"].(namedArray)
+	if array[0] != Mask || array[1].(namedMap)["token"] != Mask {
+		t.Errorf("mask the nested map failed, array: %v", array)
+		return
+	}
+	if data["count"] != 1 {
+		t.Errorf("mask data failed, the other values are changed, data: %v", data)
+		return
+	}
+}
@@ -65,6 +65,7 @@ const (
 	BKTableNameHostFavorite     = "cc_HostFavourite"
 	BKTableNameAuditLog         = "cc_AuditLog"
 	BKTableNameSubscription     = "cc_Subscription"
+	BKTableNameEventDeadLetter  = "cc_EventDeadLetter"
 	BKTableNameUserAPI          = "cc_UserAPI"
 	BKTableNameUserCustom       = "cc_UserCustom"
 	BKTableNameObjAsst          = "cc_ObjAsst"
@@ -101,6 +102,28 @@ const (
 
 	// rule for host property auto apply
 	BKTableNameHostApplyRule = "cc_HostApplyRule"
+	// non-compliant hosts of host apply rules and the drift config of businesses
+	BKTableNameHostApplyDrift       = "cc_HostApplyDrift"
+	BKTableNameHostApplyDriftConfig = "cc_HostApplyDriftConfig"
+
+	// retention policies of audit types and the archives of the expired audit logs
+	BKTableNameAuditRetentionPolicy = "cc_AuditRetentionPolicy"
+	BKTableNameAuditArchive         = "cc_AuditArchive"
+
+	// snapshots of the business topology, their nodes and the schedules to take them
+	BKTableNameTopoSnapshot         = "cc_TopoSnapshot"
+	BKTableNameTopoSnapshotNode     = "cc_TopoSnapshotNode"
+	BKTableNameTopoSnapshotSchedule = "cc_TopoSnapshotSchedule"
+
+	// deleted instances, hosts and topology nodes waiting to be restored or purged
+	BKTableNameRecycleBin = "cc_RecycleBin"
+
+	// queued tasks to re-evaluate the computed attributes' values in background
+	BKTableNameComputedRefreshTask = "cc_ComputedRefreshTask"
+
+	// roles and role bindings of the local authorizer
+	BKTableNameAuthRole        = "cc_AuthRole"
+	BKTableNameAuthRoleBinding = "cc_AuthRoleBinding"
 )
 
 // AllTables alltables
@@ -130,6 +153,7 @@ var AllTables = []string{
 	BKTableNameHostFavorite,
 	BKTableNameAuditLog,
 	BKTableNameSubscription,
+	BKTableNameEventDeadLetter,
 	BKTableNameUserAPI,
 	BKTableNameUserCustom,
 	BKTableNameObjAsst,
@@ -155,9 +179,20 @@ var AllTables = []string{
 	BKTableNameChartPosition,
 	BKTableNameChartData,
 	BKTableNameHostApplyRule,
+	BKTableNameHostApplyDrift,
+	BKTableNameHostApplyDriftConfig,
+	BKTableNameAuditRetentionPolicy,
+	BKTableNameAuditArchive,
+	BKTableNameTopoSnapshot,
+	BKTableNameTopoSnapshotNode,
+	BKTableNameTopoSnapshotSchedule,
+	BKTableNameRecycleBin,
+	BKTableNameComputedRefreshTask,
 	BKTableNameAPITask,
 	BKTableNameSetTemplateSyncStatus,
 	BKTableNameSetTemplateSyncHistory,
+	BKTableNameAuthRole,
+	BKTableNameAuthRoleBinding,
 }
 
 // GetInstTableName returns inst data table name
@@ -0,0 +1,187 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+)
+
+// the values of ipv4, ipv6 and cidr attributes are stored in normalized forms of fixed width, so
+// that they can be compared and sorted as strings. an ipv4 address is zero padded like 010.000.000.001,
+// an ipv6 address is fully expanded in lower case like 2001:0db8:0000:0000:0000:0000:0000:0001, and
+// a cidr is its network address in the normalized form with the prefix length, like 010.000.000.000/8.
+// the stored values are converted back to the common forms by DenormalizeNetworkValue when they are read.
+
+// ParseIPv4 parse an ipv4 address in dotted decimal notation, the octets can be zero padded.
+// the returned ip is of 4 bytes.
+func ParseIPv4(s string) (net.IP, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != net.IPv4len {
+		return nil, fmt.Errorf("invalid ipv4 address %s", s)
+	}
+
+	ip := make(net.IP, net.IPv4len)
+	for i, part := range parts {
+		if len(part) == 0 || len(part) > 3 {
+			return nil, fmt.Errorf("invalid ipv4 address %s", s)
+		}
+		octet, err := strconv.ParseUint(part, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ipv4 address %s", s)
+		}
+		ip[i] = byte(octet)
+	}
+	return ip, nil
+}
+
+// ParseIPv6 parse an ipv6 address, an ipv4 address is not accepted. the returned ip is of 16 bytes.
+func ParseIPv6(s string) (net.IP, error) {
+	if !strings.Contains(s, ":") {
+		return nil, fmt.Errorf("invalid ipv6 address %s", s)
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid ipv6 address %s", s)
+	}
+	return ip.To16(), nil
+}
+
+// ParseIP parse an ipv4 or ipv6 address, ipv4 address is returned in 4 bytes and ipv6 in 16 bytes.
+func ParseIP(s string) (net.IP, error) {
+	if strings.Contains(s, ":") {
+		return ParseIPv6(s)
+	}
+	return ParseIPv4(s)
+}
+
+// ParseCIDR parse a cidr like 10.0.0.0/8 or 2001:db8::/32, the ip of the returned network is the
+// network address, so 10.0.0.1/8 is parsed as 10.0.0.0/8.
+func ParseCIDR(s string) (*net.IPNet, error) {
+	idx := strings.LastIndex(s, "/")
+	if idx < 0 {
+		return nil, fmt.Errorf("invalid cidr %s, prefix length is missing", s)
+	}
+
+	ip, err := ParseIP(s[:idx])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cidr %s, %v", s, err)
+	}
+	bits := len(ip) * 8
+	ones, err := strconv.ParseUint(s[idx+1:], 10, 8)
+	if err != nil || int(ones) > bits {
+		return nil, fmt.Errorf("invalid cidr %s, prefix length should be in range 0~%d", s, bits)
+	}
+
+	mask := net.CIDRMask(int(ones), bits)
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}, nil
+}
+
+// FormatIP format the ip in the normalized form, an ip of 4 bytes is formatted as ipv4 address,
+// otherwise it's formatted as ipv6 address.
+func FormatIP(ip net.IP) string {
+	if len(ip) == net.IPv4len {
+		return fmt.Sprintf("%03d.%03d.%03d.%03d", ip[0], ip[1], ip[2], ip[3])
+	}
+
+	hexIP := hex.EncodeToString(ip.To16())
+	groups := make([]string, 0, 8)
+	for i := 0; i < len(hexIP); i += 4 {
+		groups = append(groups, hexIP[i:i+4])
+	}
+	return strings.Join(groups, ":")
+}
+
+// FormatCIDR format the network in the normalized form.
+func FormatCIDR(ipNet *net.IPNet) string {
+	ones, _ := ipNet.Mask.Size()
+	return FormatIP(ipNet.IP) + "/" + strconv.Itoa(ones)
+}
+
+// NormalizeIPv4 convert an ipv4 address to the normalized form.
+func NormalizeIPv4(s string) (string, error) {
+	ip, err := ParseIPv4(s)
+	if err != nil {
+		return "", err
+	}
+	return FormatIP(ip), nil
+}
+
+// NormalizeIPv6 convert an ipv6 address to the normalized form.
+func NormalizeIPv6(s string) (string, error) {
+	ip, err := ParseIPv6(s)
+	if err != nil {
+		return "", err
+	}
+	return FormatIP(ip), nil
+}
+
+// NormalizeCIDR convert a cidr to the normalized form.
+func NormalizeCIDR(s string) (string, error) {
+	ipNet, err := ParseCIDR(s)
+	if err != nil {
+		return "", err
+	}
+	return FormatCIDR(ipNet), nil
+}
+
+// NormalizeNetworkValue convert the value of ipv4, ipv6 or cidr attribute to the normalized form.
+func NormalizeNetworkValue(propertyType string, value string) (string, error) {
+	switch propertyType {
+	case common.FieldTypeIPv4:
+		return NormalizeIPv4(value)
+	case common.FieldTypeIPv6:
+		return NormalizeIPv6(value)
+	case common.FieldTypeCIDR:
+		return NormalizeCIDR(value)
+	default:
+		return "", fmt.Errorf("%s is not a network type", propertyType)
+	}
+}
+
+// DenormalizeNetworkValue convert the stored value of ipv4, ipv6 or cidr attribute in the normalized form back
+// to the common form, like 10.0.0.1, 2001:db8::1 and 10.0.0.0/8. the value is returned as it is if it's
+// not a valid value of the type.
+func DenormalizeNetworkValue(propertyType string, value string) string {
+	switch propertyType {
+	case common.FieldTypeIPv4:
+		if ip, err := ParseIPv4(value); err == nil {
+			return ip.String()
+		}
+	case common.FieldTypeIPv6:
+		if ip, err := ParseIPv6(value); err == nil {
+			return ip.String()
+		}
+	case common.FieldTypeCIDR:
+		if ipNet, err := ParseCIDR(value); err == nil {
+			return ipNet.String()
+		}
+	}
+	return value
+}
+
+// IPNetRange returns the first and the last address of the network.
+func IPNetRange(ipNet *net.IPNet) (first, last net.IP) {
+	first = make(net.IP, len(ipNet.IP))
+	last = make(net.IP, len(ipNet.IP))
+	for i := range ipNet.IP {
+		first[i] = ipNet.IP[i] & ipNet.Mask[i]
+		last[i] = ipNet.IP[i] | ^ipNet.Mask[i]
+	}
+	return first, last
+}
@@ -0,0 +1,115 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeIP(t *testing.T) {
+	ipv4, err := NormalizeIPv4("10.0.12.1")
+	require.NoError(t, err)
+	require.Equal(t, "010.000.012.001", ipv4)
+
+	ipv4, err = NormalizeIPv4(ipv4)
+	require.NoError(t, err)
+	require.Equal(t, "010.000.012.001", ipv4)
+
+	for _, invalid := range []string{"10.0.0", "10.0.0.256", "10.0.0.0001", "10..0.1", "::1", "+1.0.0.1"} {
+		_, err := NormalizeIPv4(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	ipv6, err := NormalizeIPv6("2001:DB8::1")
+	require.NoError(t, err)
+	require.Equal(t, "2001:0db8:0000:0000:0000:0000:0000:0001", ipv6)
+
+	ipv6, err = NormalizeIPv6(ipv6)
+	require.NoError(t, err)
+	require.Equal(t, "2001:0db8:0000:0000:0000:0000:0000:0001", ipv6)
+
+	for _, invalid := range []string{"10.0.0.1", "2001:db8::1::2", "2001:db8::g"} {
+		_, err := NormalizeIPv6(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	// the normalized forms are sorted in address order
+	require.True(t, "009.255.255.255" < "010.000.000.000")
+}
+
+func TestNormalizeCIDR(t *testing.T) {
+	cidr, err := NormalizeCIDR("172.16.3.4/12")
+	require.NoError(t, err)
+	require.Equal(t, "172.016.000.000/12", cidr)
+
+	cidr, err = NormalizeCIDR("2001:db8::1/32")
+	require.NoError(t, err)
+	require.Equal(t, "2001:0db8:0000:0000:0000:0000:0000:0000/32", cidr)
+
+	for _, invalid := range []string{"10.0.0.0", "10.0.0.0/33", "10.0.0.0/-1", "2001:db8::/129", "10.0.0/8"} {
+		_, err := NormalizeCIDR(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestIPNetRange(t *testing.T) {
+	ipNet, err := ParseCIDR("192.168.1.0/23")
+	require.NoError(t, err)
+	first, last := IPNetRange(ipNet)
+	require.Equal(t, "192.168.000.000", FormatIP(first))
+	require.Equal(t, "192.168.001.255", FormatIP(last))
+
+	ipNet, err = ParseCIDR("2001:db8::/126")
+	require.NoError(t, err)
+	first, last = IPNetRange(ipNet)
+	require.Equal(t, "2001:0db8:0000:0000:0000:0000:0000:0000", FormatIP(first))
+	require.Equal(t, "2001:0db8:0000:0000:0000:0000:0000:0003", FormatIP(last))
+}
+
+func TestDenormalizeNetworkValue(t *testing.T) {
+	testCases := []struct {
+		propertyType string
+		value        string
+		expected     string
+	}{
+		{common.FieldTypeIPv4, "010.000.012.001", "10.0.12.1"},
+		{common.FieldTypeIPv4, "10.0.12.1", "10.0.12.1"},
+		{common.FieldTypeIPv6, "2001:0db8:0000:0000:0000:0000:0000:0001", "2001:db8::1"},
+		{common.FieldTypeCIDR, "172.016.000.000/12", "172.16.0.0/12"},
+		{common.FieldTypeCIDR, "2001:0db8:0000:0000:0000:0000:0000:0000/32", "2001:db8::/32"},
+		// the invalid values are kept
+		{common.FieldTypeIPv4, "", ""},
+		{common.FieldTypeIPv6, "010.000.012.001", "010.000.012.001"},
+		{common.FieldTypeSingleChar, "010.000.012.001", "010.000.012.001"},
+	}
+
+	for _, testCase := range testCases {
+		require.Equal(t, testCase.expected, DenormalizeNetworkValue(testCase.propertyType, testCase.value),
+			testCase.value)
+
+		// the denormalized value is normalized to the stored value again
+		if normalized, err := NormalizeNetworkValue(testCase.propertyType, testCase.value); err == nil {
+			require.Equal(t, normalized, mustNormalizeNetworkValue(t, testCase.propertyType, testCase.expected))
+		}
+	}
+}
+
+func mustNormalizeNetworkValue(t *testing.T, propertyType, value string) string {
+	normalized, err := NormalizeNetworkValue(propertyType, value)
+	require.NoError(t, err)
+	return normalized
+}
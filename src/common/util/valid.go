@@ -14,18 +14,24 @@ package util
 
 import (
 	"encoding/json"
+	"regexp"
 	"unicode/utf8"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/expression"
 )
 
 // ValidPropertyOption valid property field option
 func ValidPropertyOption(propertyType string, option interface{}, errProxy errors.DefaultCCErrorIf) error {
 	switch propertyType {
-	case common.FieldTypeEnum:
+	case common.FieldTypeEnum, common.FieldTypeMultiEnum:
 		return ValidFieldTypeEnumOption(option, errProxy)
+	case common.FieldTypeTable:
+		return ValidFieldTypeTableOption(option, errProxy)
+	case common.FieldTypeComputed:
+		return ValidFieldTypeComputedOption(option, errProxy)
 	case common.FieldTypeInt:
 		return ValidFieldTypeIntOption(option, errProxy)
 	case common.FieldTypeList:
@@ -193,6 +199,143 @@ func ValidFieldTypeListOption(option interface{}, errProxy errors.DefaultCCError
 	return nil
 }
 
+// tableColumnTypes the property types which can be used as the sub-column of a table
+var tableColumnTypes = map[string]bool{
+	common.FieldTypeSingleChar: true,
+	common.FieldTypeLongChar:   true,
+	common.FieldTypeInt:        true,
+	common.FieldTypeFloat:      true,
+	common.FieldTypeEnum:       true,
+	common.FieldTypeMultiEnum:  true,
+	common.FieldTypeDate:       true,
+	common.FieldTypeTime:       true,
+	common.FieldTypeBool:       true,
+	common.FieldTypeUser:       true,
+	common.FieldTypeIPv4:       true,
+	common.FieldTypeIPv6:       true,
+	common.FieldTypeCIDR:       true,
+}
+
+// IsTableColumnType returns whether the property type can be used as the sub-column of a table
+func IsTableColumnType(propertyType string) bool {
+	return tableColumnTypes[propertyType]
+}
+
+// ValidFieldTypeTableOption valid table option, which is the definition list of the table's sub-columns, like:
+// [{"bk_property_id": "size", "bk_property_name": "Size", "bk_property_type": "int", "isrequired": true, "option": {}}]
+func ValidFieldTypeTableOption(option interface{}, errProxy errors.DefaultCCErrorIf) error {
+	if nil == option {
+		return errProxy.Errorf(common.CCErrCommParamsLostField, "option")
+	}
+
+	arrOption, ok := option.([]interface{})
+	if false == ok || len(arrOption) == 0 {
+		blog.Errorf(" option %v not table option", option)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+
+	if len(arrOption) > common.AttributeTableColumnMaxCount {
+		blog.Errorf(" table column count %d exceeds max count %d", len(arrOption), common.AttributeTableColumnMaxCount)
+		return errProxy.Errorf(common.CCErrCommValExceedMaxFailed, "option", common.AttributeTableColumnMaxCount)
+	}
+
+	columnIDs := make(map[string]bool)
+	for _, o := range arrOption {
+		column, ok := o.(map[string]interface{})
+		if false == ok || column == nil {
+			blog.Errorf(" table column %v is invalid", o)
+			return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+		}
+
+		columnID, ok := column[common.BKPropertyIDField].(string)
+		if !ok || columnID == "" {
+			return errProxy.Errorf(common.CCErrCommParamsNeedSet, "option bk_property_id")
+		}
+		if common.AttributeIDMaxLength < utf8.RuneCountInString(columnID) {
+			return errProxy.Errorf(common.CCErrCommValExceedMaxFailed, "option bk_property_id", common.AttributeIDMaxLength)
+		}
+		if match, _ := regexp.MatchString(common.FieldTypeStrictCharRegexp, columnID); !match {
+			blog.Errorf(" table column id %s is invalid", columnID)
+			return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option bk_property_id")
+		}
+		if columnIDs[columnID] {
+			blog.Errorf(" table column id %s is duplicated", columnID)
+			return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option bk_property_id")
+		}
+		columnIDs[columnID] = true
+
+		columnName, ok := column[common.BKPropertyNameField].(string)
+		if !ok || columnName == "" {
+			return errProxy.Errorf(common.CCErrCommParamsNeedSet, "option bk_property_name")
+		}
+		if common.AttributeNameMaxLength < utf8.RuneCountInString(columnName) {
+			return errProxy.Errorf(common.CCErrCommValExceedMaxFailed, "option bk_property_name", common.AttributeNameMaxLength)
+		}
+
+		if required, exists := column["isrequired"]; exists {
+			if _, ok := required.(bool); !ok {
+				return errProxy.Errorf(common.CCErrCommParamsNeedBool, "option isrequired")
+			}
+		}
+
+		columnType, _ := column[common.BKPropertyTypeField].(string)
+		if !IsTableColumnType(columnType) {
+			blog.Errorf(" table column %s type %s is not supported", columnID, columnType)
+			return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option bk_property_type")
+		}
+
+		columnOption, exists := column["option"]
+		switch columnType {
+		case common.FieldTypeEnum, common.FieldTypeMultiEnum:
+			if err := ValidPropertyOption(columnType, columnOption, errProxy); err != nil {
+				return err
+			}
+		case common.FieldTypeInt:
+			if exists && columnOption != nil {
+				if err := ValidPropertyOption(columnType, columnOption, errProxy); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidFieldTypeComputedOption valid computed option, which is like {"expression": "sum(disks.size)", "result_type": "int"},
+// the referenced fields is validated by coreservice with the model's attributes.
+func ValidFieldTypeComputedOption(option interface{}, errProxy errors.DefaultCCErrorIf) error {
+	if nil == option {
+		return errProxy.Errorf(common.CCErrCommParamsLostField, "option")
+	}
+
+	mapOption, ok := option.(map[string]interface{})
+	if false == ok || mapOption == nil {
+		blog.Errorf(" option %v not computed option", option)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+
+	resultType, _ := mapOption["result_type"].(string)
+	if !expression.IsResultType(resultType) {
+		blog.Errorf(" computed option result type %v is invalid", mapOption["result_type"])
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option result_type")
+	}
+
+	expr, ok := mapOption["expression"].(string)
+	if !ok || expr == "" {
+		return errProxy.Errorf(common.CCErrCommParamsNeedSet, "option expression")
+	}
+	if common.AttributeOptionMaxLength < utf8.RuneCountInString(expr) {
+		return errProxy.Errorf(common.CCErrCommValExceedMaxFailed, "option expression", common.AttributeOptionMaxLength)
+	}
+	if _, err := expression.Parse(expr); err != nil {
+		blog.Errorf(" computed option expression %s is invalid, err: %v", expr, err)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option expression")
+	}
+
+	return nil
+}
+
 // IsStrProperty  is string property
 func IsStrProperty(propertyType string) bool {
 	if common.FieldTypeLongChar == propertyType || common.FieldTypeSingleChar == propertyType {
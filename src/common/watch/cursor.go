@@ -57,6 +57,10 @@ const (
 	Biz                CursorType = "biz"
 	Set                CursorType = "set"
 	Module             CursorType = "module"
+	ObjectBase         CursorType = "object_instance"
+	InstAsst           CursorType = "inst_asst"
+	ServiceInstance    CursorType = "service_instance"
+	Process            CursorType = "process"
 )
 
 func (ct CursorType) ToInt() int {
@@ -73,6 +77,14 @@ func (ct CursorType) ToInt() int {
 		return 5
 	case Module:
 		return 6
+	case ObjectBase:
+		return 7
+	case InstAsst:
+		return 8
+	case ServiceInstance:
+		return 9
+	case Process:
+		return 10
 	default:
 		return -1
 	}
@@ -92,6 +104,14 @@ func (ct *CursorType) ParseInt(typ int) {
 		*ct = Set
 	case 6:
 		*ct = Module
+	case 7:
+		*ct = ObjectBase
+	case 8:
+		*ct = InstAsst
+	case 9:
+		*ct = ServiceInstance
+	case 10:
+		*ct = Process
 	default:
 		*ct = UnknownType
 	}
@@ -228,6 +248,14 @@ func GetEventCursor(coll string, e *types.Event) (string, error) {
 		curType = Set
 	case common.BKTableNameBaseModule:
 		curType = Module
+	case common.BKTableNameBaseInst:
+		curType = ObjectBase
+	case common.BKTableNameInstAsst:
+		curType = InstAsst
+	case common.BKTableNameServiceInstance:
+		curType = ServiceInstance
+	case common.BKTableNameBaseProcess:
+		curType = Process
 	default:
 		blog.Errorf("unsupported cursor type collection: %s, oid: %s", e.Oid)
 		return "", fmt.Errorf("unsupported cursor type collection: %s", coll)
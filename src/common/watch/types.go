@@ -56,6 +56,9 @@ type ChainNode struct {
 	Oid string `json:"oid"`
 	// event's type
 	EventType EventType `json:"type"`
+	// the object id of the event's document, only set when the event is an object instance event,
+	// so that we can filter the events with object id without getting the event's detail.
+	ObjectID string `json:"bk_obj_id,omitempty"`
 	// event's resume token, if token is "", then it's a head, and no tail
 	Token string `json:"token"`
 	// the current node's cursor
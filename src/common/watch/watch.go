@@ -29,6 +29,15 @@ type WatchEventOptions struct {
 	Cursor string `json:"bk_cursor"`
 	// the resource kind you want to watch
 	Resource CursorType `json:"bk_resource"`
+	// the filter conditions of the watched resource, only the events matched with
+	// this filter will be returned.
+	Filter WatchEventFilter `json:"bk_filter"`
+}
+
+type WatchEventFilter struct {
+	// the object id of the instances you want to watch,
+	// it's only valid when the resource is object_instance, empty means all.
+	ObjectID string `json:"bk_obj_id"`
 }
 
 func (w *WatchEventOptions) Validate() error {
@@ -42,12 +51,16 @@ func (w *WatchEventOptions) Validate() error {
 	}
 
 	switch w.Resource {
-	case Host, Biz, Set, Module:
+	case Host, Biz, Set, Module, ObjectBase, ServiceInstance, Process:
 		if len(w.Fields) == 0 {
 			return fmt.Errorf("%s event must have fields", w.Resource)
 		}
 	}
 
+	if len(w.Filter.ObjectID) != 0 && w.Resource != ObjectBase {
+		return fmt.Errorf("bk_obj_id filter is not supported by %s event", w.Resource)
+	}
+
 	// use either StartFrom or Cursor.
 	if w.StartFrom != 0 && len(w.Cursor) != 0 {
 		return errors.New("bk_start_from and bk_cursor can not use at the same time")
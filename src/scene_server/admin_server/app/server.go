@@ -69,6 +69,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	if err != nil && auth.IsAuthed() {
 		blog.Errorf("parse authcenter error: %v, config: %+v", err, config.ConfigMap)
 	}
+	process.Config.AuthCenter.Mongo = mongoConf
 	service := svc.NewService(ctx)
 
 	input := &backbone.BackboneParameter{
@@ -117,7 +118,10 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		process.Service.SetCache(cache)
 		process.Service.SetApiSrvAddr(process.Config.ProcSrvConfig.CCApiSrvAddr)
 
-		if auth.IsAuthed() {
+		authType := process.Config.AuthCenter.Type
+		if auth.IsAuthed() && (authType == authcenter.AuthTypeLocal || authType == authcenter.AuthTypePolicy) {
+			blog.Infof("enable auth with built-in %s authorizer, auth center access is disabled.", authType)
+		} else if auth.IsAuthed() {
 			blog.Info("enable auth center access.")
 			authCli, err := authcenter.NewAuthCenter(nil, process.Config.AuthCenter, engine.Metric().Registry())
 			if err != nil {
@@ -94,4 +94,13 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202006241144"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202006281530"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007011748"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007081500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007131000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007201000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007211000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007221000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007231000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007241000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007251000"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.8.202007261000"
 )
@@ -0,0 +1,382 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// CreateAuthRole create a role for the local authorizer.
+func (s *Service) CreateAuthRole(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	ownerID := util.GetOwnerID(rHeader)
+
+	role := new(metadata.AuthRole)
+	if err := json.NewDecoder(req.Request.Body).Decode(role); err != nil {
+		blog.Errorf("create auth role, but decode body failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := role.Validate(); err != nil {
+		blog.Errorf("create auth role, but role is invalid, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	if ccErr := s.checkAuthRoleName(defErr, role.Name, ownerID, 0, rid); ccErr != nil {
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: ccErr})
+		return
+	}
+
+	id, err := s.db.NextSequence(s.ctx, common.BKTableNameAuthRole)
+	if err != nil {
+		blog.Errorf("create auth role, but generate id failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	now := time.Now()
+	role.ID = int64(id)
+	role.Creator = util.GetUser(rHeader)
+	role.Modifier = role.Creator
+	role.CreateTime = now
+	role.LastTime = now
+	role.SupplierAccount = ownerID
+	if role.Permissions == nil {
+		role.Permissions = make([]metadata.AuthPermission, 0)
+	}
+
+	if err := s.db.Table(common.BKTableNameAuthRole).Insert(s.ctx, role); err != nil {
+		blog.Errorf("create auth role failed, role: %+v, err: %v, rid: %s", role, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+		return
+	}
+
+	_ = resp.WriteEntity(metadata.NewSuccessResp(role))
+}
+
+// UpdateAuthRole update a role's name, description and permissions.
+func (s *Service) UpdateAuthRole(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	ownerID := util.GetOwnerID(rHeader)
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "id")})
+		return
+	}
+
+	role := new(metadata.AuthRole)
+	if err := json.NewDecoder(req.Request.Body).Decode(role); err != nil {
+		blog.Errorf("update auth role, but decode body failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := role.Validate(); err != nil {
+		blog.Errorf("update auth role, but role is invalid, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	cond := util.SetModOwner(map[string]interface{}{common.BKFieldID: id}, ownerID)
+	count, err := s.db.Table(common.BKTableNameAuthRole).Find(cond).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("update auth role, but get role failed, id: %d, err: %v, rid: %s", id, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	if count == 0 {
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Errorf(common.CCErrAuthRoleNotExist, id)})
+		return
+	}
+
+	if ccErr := s.checkAuthRoleName(defErr, role.Name, ownerID, id, rid); ccErr != nil {
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: ccErr})
+		return
+	}
+
+	if role.Permissions == nil {
+		role.Permissions = make([]metadata.AuthPermission, 0)
+	}
+	data := map[string]interface{}{
+		common.BKFieldName:        role.Name,
+		common.BKDescriptionField: role.Description,
+		"permissions":             role.Permissions,
+		common.ModifierField:      util.GetUser(rHeader),
+		common.LastTimeField:      time.Now(),
+	}
+	if err := s.db.Table(common.BKTableNameAuthRole).Update(s.ctx, cond, data); err != nil {
+		blog.Errorf("update auth role failed, id: %d, err: %v, rid: %s", id, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBUpdateFailed)})
+		return
+	}
+
+	_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// DeleteAuthRole delete a role, and all the bindings of this role.
+func (s *Service) DeleteAuthRole(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	ownerID := util.GetOwnerID(rHeader)
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "id")})
+		return
+	}
+
+	// delete the bindings first, so that the bindings will not be left when the role deletion is failed.
+	bindingCond := util.SetModOwner(map[string]interface{}{common.BKRoleIDField: id}, ownerID)
+	if err := s.db.Table(common.BKTableNameAuthRoleBinding).Delete(s.ctx, bindingCond); err != nil {
+		blog.Errorf("delete auth role, but delete role bindings failed, id: %d, err: %v, rid: %s", id, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+
+	cond := util.SetModOwner(map[string]interface{}{common.BKFieldID: id}, ownerID)
+	if err := s.db.Table(common.BKTableNameAuthRole).Delete(s.ctx, cond); err != nil {
+		blog.Errorf("delete auth role failed, id: %d, err: %v, rid: %s", id, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+
+	_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// SearchAuthRoles search the roles of the local authorizer.
+func (s *Service) SearchAuthRoles(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	ownerID := util.GetOwnerID(rHeader)
+
+	option := metadata.ParamAuthRoleSearch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); err != nil {
+		blog.Errorf("search auth roles, but decode body failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	cond := util.SetModOwner(option.Condition, ownerID)
+	count, err := s.db.Table(common.BKTableNameAuthRole).Find(cond).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("count auth roles failed, condition: %+v, err: %v, rid: %s", cond, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	roles := make([]metadata.AuthRole, 0)
+	err = s.db.Table(common.BKTableNameAuthRole).Find(cond).Sort(authRoleSort(option.Page)).
+		Start(uint64(option.Page.Start)).Limit(authRoleLimit(option.Page)).All(s.ctx, &roles)
+	if err != nil {
+		blog.Errorf("search auth roles failed, condition: %+v, err: %v, rid: %s", cond, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	_ = resp.WriteEntity(metadata.NewSuccessResp(metadata.RspAuthRoleSearch{Count: count, Info: roles}))
+}
+
+// CreateAuthRoleBindings bind a role to users, the users who is already bound to the role
+// with the same business is skipped.
+func (s *Service) CreateAuthRoleBindings(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	ownerID := util.GetOwnerID(rHeader)
+
+	option := new(metadata.CreateAuthRoleBindingOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(option); err != nil {
+		blog.Errorf("create auth role bindings, but decode body failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := option.Validate(); err != nil {
+		blog.Errorf("create auth role bindings, but option is invalid, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	roleCond := util.SetModOwner(map[string]interface{}{common.BKFieldID: option.RoleID}, ownerID)
+	count, err := s.db.Table(common.BKTableNameAuthRole).Find(roleCond).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("create auth role bindings, but get role failed, id: %d, err: %v, rid: %s", option.RoleID, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	if count == 0 {
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Errorf(common.CCErrAuthRoleNotExist, option.RoleID)})
+		return
+	}
+
+	existCond := map[string]interface{}{
+		common.BKRoleIDField:   option.RoleID,
+		common.BKAppIDField:    option.BizID,
+		common.BKUserNameField: map[string]interface{}{common.BKDBIN: option.UserNames},
+	}
+	existCond = util.SetModOwner(existCond, ownerID)
+	existBindings := make([]metadata.AuthRoleBinding, 0)
+	if err := s.db.Table(common.BKTableNameAuthRoleBinding).Find(existCond).All(s.ctx, &existBindings); err != nil {
+		blog.Errorf("create auth role bindings, but get exist bindings failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	boundUsers := make(map[string]bool)
+	for _, binding := range existBindings {
+		boundUsers[binding.UserName] = true
+	}
+
+	now := time.Now()
+	bindings := make([]metadata.AuthRoleBinding, 0)
+	for _, user := range option.UserNames {
+		if boundUsers[user] {
+			continue
+		}
+		boundUsers[user] = true
+
+		id, err := s.db.NextSequence(s.ctx, common.BKTableNameAuthRoleBinding)
+		if err != nil {
+			blog.Errorf("create auth role bindings, but generate id failed, err: %v, rid: %s", err, rid)
+			_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+			return
+		}
+		bindings = append(bindings, metadata.AuthRoleBinding{
+			ID:              int64(id),
+			RoleID:          option.RoleID,
+			UserName:        user,
+			BizID:           option.BizID,
+			Creator:         util.GetUser(rHeader),
+			CreateTime:      now,
+			SupplierAccount: ownerID,
+		})
+	}
+
+	if len(bindings) != 0 {
+		if err := s.db.Table(common.BKTableNameAuthRoleBinding).Insert(s.ctx, bindings); err != nil {
+			blog.Errorf("create auth role bindings failed, bindings: %+v, err: %v, rid: %s", bindings, err, rid)
+			_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBInsertFailed)})
+			return
+		}
+	}
+
+	_ = resp.WriteEntity(metadata.NewSuccessResp(bindings))
+}
+
+// DeleteAuthRoleBinding unbind a role from a user.
+func (s *Service) DeleteAuthRoleBinding(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	ownerID := util.GetOwnerID(rHeader)
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "id")})
+		return
+	}
+
+	cond := util.SetModOwner(map[string]interface{}{common.BKFieldID: id}, ownerID)
+	if err := s.db.Table(common.BKTableNameAuthRoleBinding).Delete(s.ctx, cond); err != nil {
+		blog.Errorf("delete auth role binding failed, id: %d, err: %v, rid: %s", id, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBDeleteFailed)})
+		return
+	}
+
+	_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// SearchAuthRoleBindings search the role bindings of the local authorizer.
+func (s *Service) SearchAuthRoleBindings(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	ownerID := util.GetOwnerID(rHeader)
+
+	option := metadata.ParamAuthRoleSearch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); err != nil {
+		blog.Errorf("search auth role bindings, but decode body failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	cond := util.SetModOwner(option.Condition, ownerID)
+	count, err := s.db.Table(common.BKTableNameAuthRoleBinding).Find(cond).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("count auth role bindings failed, condition: %+v, err: %v, rid: %s", cond, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	bindings := make([]metadata.AuthRoleBinding, 0)
+	err = s.db.Table(common.BKTableNameAuthRoleBinding).Find(cond).Sort(authRoleSort(option.Page)).
+		Start(uint64(option.Page.Start)).Limit(authRoleLimit(option.Page)).All(s.ctx, &bindings)
+	if err != nil {
+		blog.Errorf("search auth role bindings failed, condition: %+v, err: %v, rid: %s", cond, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	_ = resp.WriteEntity(metadata.NewSuccessResp(metadata.RspAuthRoleBindingSearch{Count: count, Info: bindings}))
+}
+
+// checkAuthRoleName check if the role name is already used by another role.
+func (s *Service) checkAuthRoleName(defErr errors.DefaultCCErrorIf, name, ownerID string, id int64, rid string) error {
+	cond := map[string]interface{}{
+		common.BKFieldName: name,
+		common.BKFieldID:   map[string]interface{}{common.BKDBNE: id},
+	}
+	cond = util.SetModOwner(cond, ownerID)
+	count, err := s.db.Table(common.BKTableNameAuthRole).Find(cond).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("check auth role name %s failed, err: %v, rid: %s", name, err, rid)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if count > 0 {
+		return defErr.Errorf(common.CCErrAuthRoleNameDuplicated, name)
+	}
+	return nil
+}
+
+func authRoleSort(page metadata.BasePage) string {
+	if len(page.Sort) == 0 {
+		return common.BKFieldID
+	}
+	return page.Sort
+}
+
+func authRoleLimit(page metadata.BasePage) uint64 {
+	if page.Limit <= 0 {
+		return common.BKNoLimit
+	}
+	return uint64(page.Limit)
+}
@@ -29,7 +29,7 @@ func (s *Service) InitAuthCenter(req *restful.Request, resp *restful.Response) {
 	rHeader := req.Request.Header
 	rid := util.GetHTTPCCRequestID(rHeader)
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
-	if !auth.IsAuthed() {
+	if !auth.IsAuthed() || s.authCenter == nil {
 		blog.Errorf("received auth center initialization request, but auth center not enabled, rid: %s", rid)
 		result := &metadata.RespError{
 			Msg: defErr.Error(common.CCErrCommAuthCenterIsNotEnabled),
@@ -80,6 +80,15 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/migrate/system/user_config/{key}/{can}").To(s.UserConfigSwitch))
 	api.Route(api.GET("/find/system/config_admin").To(s.SearchConfigAdmin))
 	api.Route(api.PUT("/update/system/config_admin").To(s.UpdateConfigAdmin))
+
+	// roles of the local authorizer
+	api.Route(api.POST("/auth/role").To(s.CreateAuthRole))
+	api.Route(api.PUT("/auth/role/{id}").To(s.UpdateAuthRole))
+	api.Route(api.DELETE("/auth/role/{id}").To(s.DeleteAuthRole))
+	api.Route(api.POST("/auth/role/search").To(s.SearchAuthRoles))
+	api.Route(api.POST("/auth/rolebinding").To(s.CreateAuthRoleBindings))
+	api.Route(api.DELETE("/auth/rolebinding/{id}").To(s.DeleteAuthRoleBinding))
+	api.Route(api.POST("/auth/rolebinding/search").To(s.SearchAuthRoleBindings))
 	api.Route(api.GET("/healthz").To(s.Healthz))
 
 	container.Add(api)
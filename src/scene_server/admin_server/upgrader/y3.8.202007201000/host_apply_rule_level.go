@@ -0,0 +1,87 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_8_202007201000
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// upgradeHostApplyRuleLevel make the host apply rules can be attached to service templates and set templates,
+// the existing rules are module rules, and the unique index of module and attribute is replaced with the one
+// of all the targets and attribute.
+func upgradeHostApplyRuleLevel(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	filter := map[string]interface{}{
+		"level": map[string]interface{}{
+			common.BKDBExists: false,
+		},
+	}
+	doc := map[string]interface{}{
+		"level":                         metadata.HostApplyRuleLevelModule,
+		common.BKServiceTemplateIDField: 0,
+		common.BKSetTemplateIDField:     0,
+	}
+	if err := db.Table(common.BKTableNameHostApplyRule).Update(ctx, filter, doc); err != nil {
+		blog.Errorf("upgradeHostApplyRuleLevel failed, update rules failed, filter: %+v, doc: %+v, err: %s", filter, doc, err.Error())
+		return fmt.Errorf("update host apply rules failed, err: %s", err.Error())
+	}
+
+	if err := db.Table(common.BKTableNameHostApplyRule).DropIndex(ctx, "host_property_under_module"); err != nil &&
+		!strings.Contains(err.Error(), "not found") {
+		blog.Errorf("upgradeHostApplyRuleLevel failed, drop index failed, err: %s", err.Error())
+		return fmt.Errorf("drop index host_property_under_module failed, err: %s", err.Error())
+	}
+
+	indexes := []types.Index{
+		{
+			Keys: map[string]int32{
+				common.BKModuleIDField:          1,
+				common.BKServiceTemplateIDField: 1,
+				common.BKSetTemplateIDField:     1,
+				common.BKAttributeIDField:       1,
+			},
+			Name:       "host_property_under_target",
+			Unique:     true,
+			Background: true,
+		}, {
+			Keys: map[string]int32{
+				common.BKServiceTemplateIDField: 1,
+			},
+			Name:       common.BKServiceTemplateIDField,
+			Unique:     false,
+			Background: true,
+		}, {
+			Keys: map[string]int32{
+				common.BKSetTemplateIDField: 1,
+			},
+			Name:       common.BKSetTemplateIDField,
+			Unique:     false,
+			Background: true,
+		},
+	}
+	for _, index := range indexes {
+		if err := db.Table(common.BKTableNameHostApplyRule).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			blog.Errorf("upgradeHostApplyRuleLevel failed, add index failed, index: %+v, err: %s", index, err.Error())
+			return fmt.Errorf("add index failed, index: %s, err: %s", index.Name, err.Error())
+		}
+	}
+	return nil
+}
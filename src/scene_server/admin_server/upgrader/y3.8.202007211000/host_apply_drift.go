@@ -0,0 +1,80 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_8_202007211000
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// createHostApplyDriftTables creates the tables of the non-compliant hosts found by the host apply drift
+// reconciler and the drift config of the businesses.
+func createHostApplyDriftTables(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tableIndexes := map[string][]types.Index{
+		common.BKTableNameHostApplyDrift: {
+			{
+				Keys: map[string]int32{
+					common.BKHostIDField: 1,
+				},
+				Name:       common.BKHostIDField,
+				Unique:     true,
+				Background: true,
+			}, {
+				Keys: map[string]int32{
+					common.BKAppIDField: 1,
+				},
+				Name:       common.BKAppIDField,
+				Unique:     false,
+				Background: true,
+			},
+		},
+		common.BKTableNameHostApplyDriftConfig: {
+			{
+				Keys: map[string]int32{
+					common.BKAppIDField: 1,
+				},
+				Name:       common.BKAppIDField,
+				Unique:     true,
+				Background: true,
+			},
+		},
+	}
+
+	for table, indexes := range tableIndexes {
+		exists, err := db.HasTable(ctx, table)
+		if err != nil {
+			blog.Errorf("createHostApplyDriftTables failed, check table exist failed, table: %s, err: %s", table, err.Error())
+			return fmt.Errorf("check table exist failed, table: %s, err: %s", table, err.Error())
+		}
+		if !exists {
+			if err := db.CreateTable(ctx, table); err != nil && !db.IsDuplicatedError(err) {
+				blog.Errorf("createHostApplyDriftTables failed, create table failed, table: %s, err: %s", table, err.Error())
+				return fmt.Errorf("create table failed, table: %s, err: %s", table, err.Error())
+			}
+		}
+
+		for _, index := range indexes {
+			if err := db.Table(table).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+				blog.Errorf("createHostApplyDriftTables failed, add index failed, table: %s, index: %+v, err: %s", table, index, err.Error())
+				return fmt.Errorf("add index failed, table: %s, index: %s, err: %s", table, index.Name, err.Error())
+			}
+		}
+	}
+	return nil
+}
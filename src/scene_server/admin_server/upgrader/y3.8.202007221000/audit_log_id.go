@@ -0,0 +1,64 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_8_202007221000
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const auditLogIDBatchSize = 500
+
+// backfillAuditLogID sets the id of the audit logs created before this version, so that they can be got by id.
+func backfillAuditLogID(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	filter := map[string]interface{}{
+		common.BKFieldID: map[string]interface{}{
+			common.BKDBExists: false,
+		},
+	}
+
+	for {
+		logs := make([]struct {
+			ObjectID primitive.ObjectID `bson:"_id"`
+		}, 0)
+		err := db.Table(common.BKTableNameAuditLog).Find(filter).Fields("_id").Limit(auditLogIDBatchSize).All(ctx, &logs)
+		if err != nil {
+			blog.Errorf("find audit logs without id failed, err: %v", err)
+			return err
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+
+		for _, log := range logs {
+			id, err := db.NextSequence(ctx, common.BKTableNameAuditLog)
+			if err != nil {
+				blog.Errorf("generate audit log id failed, err: %v", err)
+				return err
+			}
+
+			logFilter := map[string]interface{}{"_id": log.ObjectID}
+			doc := map[string]interface{}{common.BKFieldID: id}
+			if err := db.Table(common.BKTableNameAuditLog).Update(ctx, logFilter, doc); err != nil {
+				blog.Errorf("set audit log %s id %d failed, err: %v", log.ObjectID.Hex(), id, err)
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_8_202007221000
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// addAuditLogInstanceIndex adds the indexes used to search the audit logs of an instance and to get an
+// audit log by its id, the id of the audit logs created before this version is backfilled before.
+func addAuditLogInstanceIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	idxArr, err := db.Table(common.BKTableNameAuditLog).Indexes(ctx)
+	if err != nil {
+		blog.Errorf("get table %s index error. err:%s", common.BKTableNameAuditLog, err.Error())
+		return err
+	}
+
+	createIdxArr := []types.Index{
+		{Name: "index_id", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true},
+		{
+			Name: "index_resource_id",
+			Keys: map[string]int32{
+				common.BKOperationDetailField + "." + common.BKResourceIDField: 1,
+			},
+			Background: true,
+		},
+	}
+	for _, idx := range createIdxArr {
+		exist := false
+		for _, existIdx := range idxArr {
+			if existIdx.Name == idx.Name {
+				exist = true
+				break
+			}
+		}
+		if exist {
+			continue
+		}
+		if err := db.Table(common.BKTableNameAuditLog).CreateIndex(ctx, idx); err != nil && !db.IsDuplicatedError(err) {
+			blog.ErrorJSON("create index to BKTableNameAuditLog error, err:%s, current index:%s, all create index:%s", err.Error(), idx, createIdxArr)
+			return err
+		}
+	}
+
+	return nil
+}
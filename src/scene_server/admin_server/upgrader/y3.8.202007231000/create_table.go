@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_8_202007231000
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// createAuditRetentionTables creates the audit retention policy table and the audit archive table
+func createAuditRetentionTables(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tables := map[string][]types.Index{
+		common.BKTableNameAuditRetentionPolicy: {
+			{
+				Name: "idx_supplierAccount_auditType",
+				Keys: map[string]int32{
+					common.BkSupplierAccount: 1,
+					common.BKAuditTypeField:  1,
+				},
+				Unique:     true,
+				Background: true,
+			},
+		},
+		common.BKTableNameAuditArchive: {
+			{
+				Name:       common.BKFieldID,
+				Keys:       map[string]int32{common.BKFieldID: 1},
+				Unique:     true,
+				Background: true,
+			},
+			{
+				Name:       "idx_auditType",
+				Keys:       map[string]int32{common.BKAuditTypeField: 1},
+				Background: true,
+			},
+			{
+				Name:       "idx_key",
+				Keys:       map[string]int32{"key": 1},
+				Unique:     true,
+				Background: true,
+			},
+		},
+	}
+
+	for tableName, indexes := range tables {
+		exists, err := db.HasTable(ctx, tableName)
+		if err != nil {
+			blog.Errorf("check table %s exist failed, err: %v", tableName, err)
+			return err
+		}
+		if !exists {
+			if err = db.CreateTable(ctx, tableName); err != nil && !db.IsDuplicatedError(err) {
+				blog.Errorf("create table %s failed, err: %v", tableName, err)
+				return err
+			}
+		}
+
+		for _, index := range indexes {
+			if err = db.Table(tableName).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+				blog.Errorf("create index %s for table %s failed, err: %v", index.Name, tableName, err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addAuditLogRetentionIndex adds the indexes used by the retention job to find the expired audit logs of an
+// audit type and to tell apart the audit logs imported from archives.
+func addAuditLogRetentionIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	indexes := []types.Index{
+		{
+			Name: "index_auditType_operationTime",
+			Keys: map[string]int32{
+				common.BKAuditTypeField:     1,
+				common.BKOperationTimeField: 1,
+			},
+			Background: true,
+		},
+		{
+			Name:       "index_archive_id",
+			Keys:       map[string]int32{"archive_id": 1},
+			Background: true,
+		},
+	}
+
+	for _, index := range indexes {
+		if err := db.Table(common.BKTableNameAuditLog).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			blog.Errorf("create index %s for table %s failed, err: %v", index.Name, common.BKTableNameAuditLog, err)
+			return err
+		}
+	}
+
+	return nil
+}
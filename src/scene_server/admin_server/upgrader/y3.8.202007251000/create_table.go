@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_8_202007251000
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// createRecycleBinTable creates the table of the deleted instances, hosts and topology nodes
+func createRecycleBinTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	indexes := []types.Index{
+		{
+			Name:       common.BKFieldID,
+			Keys:       map[string]int32{common.BKFieldID: 1},
+			Unique:     true,
+			Background: true,
+		},
+		{
+			Name: "idx_objID_instID",
+			Keys: map[string]int32{
+				common.BKObjIDField:  1,
+				common.BKInstIDField: 1,
+			},
+			Background: true,
+		},
+		{
+			Name:       "idx_bizID",
+			Keys:       map[string]int32{common.BKAppIDField: 1},
+			Background: true,
+		},
+		{
+			Name:       "idx_expireTime",
+			Keys:       map[string]int32{"expire_time": 1},
+			Background: true,
+		},
+	}
+
+	exists, err := db.HasTable(ctx, common.BKTableNameRecycleBin)
+	if err != nil {
+		blog.Errorf("check table %s exist failed, err: %v", common.BKTableNameRecycleBin, err)
+		return err
+	}
+	if !exists {
+		if err = db.CreateTable(ctx, common.BKTableNameRecycleBin); err != nil && !db.IsDuplicatedError(err) {
+			blog.Errorf("create table %s failed, err: %v", common.BKTableNameRecycleBin, err)
+			return err
+		}
+	}
+
+	for _, index := range indexes {
+		if err = db.Table(common.BKTableNameRecycleBin).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			blog.Errorf("create index %s for table %s failed, err: %v", index.Name, common.BKTableNameRecycleBin, err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -71,6 +71,9 @@ const (
 
 	// defaultAppInitWaitDuration is default wait duration for app db init.
 	defaultAppInitWaitDuration = 10 * time.Second
+
+	// defaultStreamGroup is default consumer group name of stream source.
+	defaultStreamGroup = "cmdb_datacollection"
 )
 
 // DataCollectionConfig is configs for DataCollection app.
@@ -95,6 +98,9 @@ type DataCollectionConfig struct {
 
 	// AuthConfig auth configs.
 	AuthConfig authcenter.AuthConfig
+
+	// Sources porter source configs, porter name -> source configs.
+	Sources map[string]collections.SourceConfig
 }
 
 // DataCollection is data collection server.
@@ -105,6 +111,9 @@ type DataCollection struct {
 	defaultAppID    string
 	snapshotBizName string
 
+	// serverAddr is address of this DataCollection server, it's used as the default stream consumer name.
+	serverAddr string
+
 	// config for this DataCollection app.
 	config *DataCollectionConfig
 
@@ -152,7 +161,7 @@ func NewDataCollection(ctx context.Context, op *options.ServerOption) (*DataColl
 	}
 
 	// new DataCollection instance.
-	newDataCollection := &DataCollection{ctx: ctx}
+	newDataCollection := &DataCollection{ctx: ctx, serverAddr: fmt.Sprintf("%s:%d", svrInfo.RegisterIP, svrInfo.Port)}
 
 	engine, err := backbone.NewBackbone(ctx, &backbone.BackboneParameter{
 		ConfigUpdate: newDataCollection.OnHostConfigUpdate,
@@ -209,7 +218,31 @@ func (c *DataCollection) OnHostConfigUpdate(prev, curr cc.ProcessConfig) {
 		c.config.Esb.Addrs = curr.ConfigMap["esb.addr"]
 		c.config.Esb.AppCode = curr.ConfigMap["esb.appCode"]
 		c.config.Esb.AppSecret = curr.ConfigMap["esb.appSecret"]
+
+		// porter source configs.
+		c.config.Sources = make(map[string]collections.SourceConfig)
+		for _, porterName := range []string{snapPorterName, middlewarePorterName, netCollectPorterName} {
+			c.config.Sources[porterName] = c.parseSourceConfig(porterName, curr.ConfigMap)
+		}
+	}
+}
+
+// parseSourceConfig parses source configs of the porter, eg hostsnap.source.
+func (c *DataCollection) parseSourceConfig(porterName string, configMap map[string]string) collections.SourceConfig {
+	conf := collections.SourceConfig{
+		Type:           configMap[porterName+".source"],
+		StreamGroup:    configMap[porterName+".streamGroup"],
+		StreamConsumer: configMap[porterName+".streamConsumer"],
+		SpoolDir:       configMap[porterName+".spoolDir"],
+	}
+
+	if len(conf.StreamGroup) == 0 {
+		conf.StreamGroup = defaultStreamGroup
+	}
+	if len(conf.StreamConsumer) == 0 {
+		conf.StreamConsumer = c.serverAddr
 	}
+	return conf
 }
 
 // initConfigs inits configs for new DataCollection server.
@@ -274,6 +307,13 @@ func (c *DataCollection) initConfigs() error {
 		return fmt.Errorf("init authorization configs, %+v", err)
 	}
 
+	// porter sources.
+	for porterName, sourceConfig := range c.config.Sources {
+		if err := sourceConfig.Validate(); err != nil {
+			return fmt.Errorf("init %s source configs, %+v", porterName, err)
+		}
+	}
+
 	return nil
 }
 
@@ -343,7 +383,7 @@ func (c *DataCollection) initModules() error {
 
 	// handle authorize.
 	if enableauth.IsAuthed() {
-		authorize, err := auth.NewAuthorize(nil, c.config.AuthConfig, c.engine.Metric().Registry())
+		authorize, err := auth.NewAuthorize(c.ctx, nil, c.config.AuthConfig, c.engine.Metric().Registry())
 		if err != nil {
 			return fmt.Errorf("create new authorize failed, %+v", err)
 		}
@@ -403,6 +443,34 @@ func (c *DataCollection) netcollectMessageTopic(defaultAppID string) []string {
 	}
 }
 
+// newPorterSource creates the source of porter base on the source configs,
+// redisCli and topics are only used by redis sources.
+func (c *DataCollection) newPorterSource(porterName string, redisCli *redis.Client, topics []string) collections.Source {
+	sourceConfig := c.config.Sources[porterName]
+
+	switch sourceConfig.Type {
+	case collections.SourceTypeStream:
+		return collections.NewStreamSource(porterName, redisCli, topics, sourceConfig.StreamGroup, sourceConfig.StreamConsumer)
+
+	case collections.SourceTypeHTTP:
+		source := collections.NewHTTPSource(porterName)
+		c.service.SetPushSource(porterName, source)
+		return source
+
+	case collections.SourceTypeSpool:
+		return collections.NewSpoolSource(porterName, sourceConfig.SpoolDir)
+
+	default:
+		return collections.NewPubSubSource(porterName, redisCli, topics)
+	}
+}
+
+// isPorterEnabled returns if the porter could run, the porter with redis source needs the redis client.
+func (c *DataCollection) isPorterEnabled(porterName string, redisCli *redis.Client) bool {
+	sourceConfig := c.config.Sources[porterName]
+	return redisCli != nil || !sourceConfig.NeedRedis()
+}
+
 // runCollectPorters runs porters for collections.
 func (c *DataCollection) runCollectPorters() {
 	// create porters manager.
@@ -425,29 +493,32 @@ func (c *DataCollection) runCollectPorters() {
 	blog.Info("DataCollection| get default appid id success[%s]", c.defaultAppID)
 
 	// create and add new porters.
-	if c.snapCli != nil {
+	if c.isPorterEnabled(snapPorterName, c.snapCli) {
 		topic := c.snapMessageTopic(c.defaultAppID)
 		analyzer := hostsnap.NewHostSnap(c.ctx, c.redisCli, c.db, c.engine, c.authManager)
+		source := c.newPorterSource(snapPorterName, c.snapCli, topic)
 
-		porter := collections.NewSimplePorter(snapPorterName, c.engine, c.hash, analyzer, c.snapCli, topic, c.registry)
+		porter := collections.NewSimplePorter(snapPorterName, c.engine, c.hash, analyzer, source, c.registry)
 		c.porterManager.AddPorter(porter)
 		blog.Info("DataCollection| create hostsnap analyzer with target porter[%s] on topic[%s] success", snapPorterName, topic)
 	}
 
-	if c.disCli != nil {
+	if c.isPorterEnabled(middlewarePorterName, c.disCli) {
 		topic := c.discoverMessageTopic(c.defaultAppID)
 		analyzer := middleware.NewDiscover(c.ctx, c.redisCli, c.engine, c.authManager)
+		source := c.newPorterSource(middlewarePorterName, c.disCli, topic)
 
-		porter := collections.NewSimplePorter(middlewarePorterName, c.engine, c.hash, analyzer, c.disCli, topic, c.registry)
+		porter := collections.NewSimplePorter(middlewarePorterName, c.engine, c.hash, analyzer, source, c.registry)
 		c.porterManager.AddPorter(porter)
 		blog.Info("DataCollection| create discover analyzer with target porter[%s] on topic[%s] success", middlewarePorterName, topic)
 	}
 
-	if c.netCli != nil {
+	if c.isPorterEnabled(netCollectPorterName, c.netCli) {
 		topic := c.netcollectMessageTopic(c.defaultAppID)
 		analyzer := netcollect.NewNetCollect(c.ctx, c.db, c.authManager)
+		source := c.newPorterSource(netCollectPorterName, c.netCli, topic)
 
-		porter := collections.NewSimplePorter(netCollectPorterName, c.engine, c.hash, analyzer, c.netCli, topic, c.registry)
+		porter := collections.NewSimplePorter(netCollectPorterName, c.engine, c.hash, analyzer, source, c.registry)
 		c.porterManager.AddPorter(porter)
 		blog.Info("DataCollection| create netcollect analyzer with target porter[%s] on topic[%s] success", netCollectPorterName, topic)
 	}
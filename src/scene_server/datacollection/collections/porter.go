@@ -15,6 +15,7 @@ package collections
 import (
 	"fmt"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"configcenter/src/common/backbone"
@@ -23,7 +24,6 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/tidwall/gjson"
-	"gopkg.in/redis.v5"
 )
 
 const (
@@ -48,6 +48,9 @@ const (
 	// defaultReSubscribeWaitDuration is default wait duration before re-subscribe.
 	defaultReSubscribeWaitDuration = time.Second
 
+	// defaultPausedCheckInterval is default internal for checking if the paused porter could resume.
+	defaultPausedCheckInterval = time.Second
+
 	// defaultDebugInterval is default internal for debuging.
 	defaultDebugInterval = 10 * time.Second
 
@@ -71,14 +74,14 @@ type SimplePorter struct {
 	analyzer Analyzer
 
 	// msgChan is message channel that analyzer consumes from.
-	msgChan chan *string
+	msgChan chan *Message
 
-	// message channel redis, read collector data
-	// from it by subscribe target topics.
-	redisCli *redis.Client
+	// source is the input that collector data received from.
+	source Source
 
-	// collectors message channels redis topics.
-	topics []string
+	// isPaused marks that porter stops receiving from durable source, it's set by fusing
+	// when message channel is stacked, and 1 means paused.
+	isPaused int32
 
 	// metrics.
 	// receiveTotal is message received total stat.
@@ -102,6 +105,9 @@ type SimplePorter struct {
 	// fusingTotal is message channel fused count total stat.
 	fusingTotal prometheus.Counter
 
+	// ackTotal is message acked total stat with status labels.
+	ackTotal *prometheus.CounterVec
+
 	// registry is prometheus register.
 	registry prometheus.Registerer
 
@@ -110,16 +116,15 @@ type SimplePorter struct {
 
 // NewSimplePorter creates a new SimplePorter object.
 func NewSimplePorter(name string, engine *backbone.Engine, hash *Hash, analyzer Analyzer,
-	redisCli *redis.Client, topics []string, registry prometheus.Registerer) *SimplePorter {
+	source Source, registry prometheus.Registerer) *SimplePorter {
 
 	return &SimplePorter{
 		name:      name,
 		engine:    engine,
 		hash:      hash,
 		analyzer:  analyzer,
-		msgChan:   make(chan *string, defaultMessageChanSize),
-		redisCli:  redisCli,
-		topics:    topics,
+		msgChan:   make(chan *Message, defaultMessageChanSize),
+		source:    source,
 		registry:  registry,
 		needDebug: needInternalDebug,
 	}
@@ -198,6 +203,15 @@ func (p *SimplePorter) registerMetrics() {
 	)
 
 	p.registry.MustRegister(p.analyzeDuration)
+
+	p.ackTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_%s_ack_total", metricsNamespacePrefix, p.name),
+			Help: "total number of acked message.",
+		},
+		[]string{"status"},
+	)
+	p.registry.MustRegister(p.ackTotal)
 }
 
 // Name returns name of this porter.
@@ -214,14 +228,14 @@ func (p *SimplePorter) Mock() error {
 	return nil
 }
 
-// Mock handles a mock message.
+// AddMessage adds a message to analyze, the message is not acked after analyzed.
 func (p *SimplePorter) AddMessage(message *string) error {
 	if message == nil {
 		return fmt.Errorf("message nil")
 	}
 
 	select {
-	case p.msgChan <- message:
+	case p.msgChan <- &Message{Payload: *message}:
 
 	case <-time.After(defaultMessageChanTimeout):
 		return fmt.Errorf("channel timeout")
@@ -238,7 +252,7 @@ func (p *SimplePorter) analyzeLoop() {
 		cost := time.Now()
 
 		// analyze message from collectors.
-		if err := p.analyzer.Analyze(msg); err != nil {
+		if err := p.analyzer.Analyze(&msg.Payload); err != nil {
 			blog.Errorf("SimplePorter[%s]| analyze message failed, %+v", p.name, err)
 
 			// metrics stats for analyze failed.
@@ -250,83 +264,128 @@ func (p *SimplePorter) analyzeLoop() {
 
 		// metrics stats for analyze duration.
 		p.analyzeDuration.Observe(time.Since(cost).Seconds())
+
+		// ack the message whether it's analyzed success or not, otherwise the
+		// invalid message would be delivered again and again.
+		p.ack(msg)
 	}
 }
 
-// collectLoop keeps subscribe redis topic and collecting messages from collectors.
+// ack acknowledges the message to source, the message without id is mocked
+// or from a source that has no offset, there is nothing to ack.
+func (p *SimplePorter) ack(msg *Message) {
+	if len(msg.ID) == 0 {
+		return
+	}
+
+	if err := p.source.Ack(msg); err != nil {
+		blog.Errorf("SimplePorter[%s]| ack message[%s] on topic[%s] failed, %+v", p.name, msg.ID, msg.Topic, err)
+
+		// metrics stats for ack failed.
+		p.ackTotal.WithLabelValues("failed").Inc()
+		return
+	}
+
+	// metrics stats for ack success.
+	p.ackTotal.WithLabelValues("success").Inc()
+}
+
+// collectLoop keeps receiving messages from collectors by the source.
 func (p *SimplePorter) collectLoop() error {
 	for {
-		// subscribe target topics and handle message base on the redis pubsun channel.
-		subChan, err := p.redisCli.Subscribe(p.topics...)
+		// porter is paused by fusing, wait until the stacked messages are analyzed.
+		if p.paused() {
+			if p.stackedPercent() >= defaultFusingPercent {
+				time.Sleep(defaultPausedCheckInterval)
+				continue
+			}
+			atomic.StoreInt32(&p.isPaused, 0)
+			blog.Infof("SimplePorter[%s]| resume receiving from source[%s] now!", p.name, p.source.Name())
+		}
+
+		messages, err := p.source.Receive()
 		if err != nil {
-			blog.Errorf("SimplePorter[%s]| subscribe topics[%+v] failed, %+v", p.name, p.topics, err)
+			blog.Errorf("SimplePorter[%s]| receive message from source[%s] failed, %+v", p.name, p.source.Name(), err)
 			time.Sleep(defaultReSubscribeWaitDuration)
 			continue
 		}
-		blog.Errorf("SimplePorter[%s]| subscribe topics[%+v] success, receiving message now!", p.name, p.topics)
-
-		// receiving message.
-		for {
-			// ReceiveMessage returns a Message or error ignoring Subscription or Pong
-			// messages. It automatically reconnects to Redis Server and resubscribes
-			// to topics in case of network errors.
-			newMsg, err := subChan.ReceiveMessage()
-			if err != nil {
-				blog.Errorf("SimplePorter[%s]| receive topics[%+v] message failed, %+v", p.name, p.topics, err)
-
-				// internal errors, unsubscribe and try to sub-recv again.
-				subChan.Unsubscribe(p.topics...)
-				subChan.Close()
-				break
-			}
-			// metrics stats for message receiving.
-			p.receiveTotal.Inc()
 
-			// ignoring invalid payloads.
-			if len(newMsg.Payload) == 0 {
-				blog.Errorf("SimplePorter[%s]| recved a message with empty payload!", p.name)
+		for _, msg := range messages {
+			p.handleMessage(msg)
+		}
+	}
 
-				// metrics stats for invalid message.
-				p.receiveInvalidTotal.Inc()
-				continue
-			}
+	// should no-reach.
+	return nil
+}
 
-			// message data sharding hashring check.
-			hashKey, err := p.analyzer.Hash(gjson.Get(newMsg.Payload, "cloudid").String(), gjson.Get(newMsg.Payload, "ip").String())
-			if err != nil {
-				blog.Errorf("SimplePorter[%s]| calculates message hash key failed, %+v", p.name, err)
+// handleMessage checks the received message and adds it to analyze channel.
+func (p *SimplePorter) handleMessage(msg *Message) {
+	// metrics stats for message receiving.
+	p.receiveTotal.Inc()
 
-				// metrics stats for invalid message.
-				p.receiveInvalidTotal.Inc()
-				continue
-			}
-
-			if !p.hash.IsMatch(hashKey) {
-				// ignore message.
-				continue
-			}
+	// ignoring invalid payloads.
+	if len(msg.Payload) == 0 {
+		blog.Errorf("SimplePorter[%s]| recved a message with empty payload!", p.name)
 
-			// metrics stats for suitable sharding message.
-			p.receiveShardingTotal.Inc()
+		// metrics stats for invalid message.
+		p.receiveInvalidTotal.Inc()
+		p.ack(msg)
+		return
+	}
 
-			if err := p.AddMessage(&newMsg.Payload); err != nil {
-				blog.Errorf("SimplePorter[%s]| add message to analyze, %+v", p.name, err)
+	// message data sharding hashring check, the messages of the source that
+	// are not broadcast are already sharded by the source itself.
+	if p.source.IsBroadcast() {
+		hashKey, err := p.analyzer.Hash(gjson.Get(msg.Payload, "cloudid").String(), gjson.Get(msg.Payload, "ip").String())
+		if err != nil {
+			blog.Errorf("SimplePorter[%s]| calculates message hash key failed, %+v", p.name, err)
 
-				// metrics stats for message sending timeout.
-				p.receiveTimeoutTotal.Inc()
-			}
+			// metrics stats for invalid message.
+			p.receiveInvalidTotal.Inc()
+			return
+		}
 
-			// end of once message receiving loop.
-			continue
+		if !p.hash.IsMatch(hashKey) {
+			// ignore message.
+			return
 		}
 	}
 
-	// should no-reach.
-	return nil
+	// metrics stats for suitable sharding message.
+	p.receiveShardingTotal.Inc()
+
+	// message of durable source must not be dropped, wait until the analyze channel is available.
+	if p.source.IsDurable() {
+		p.msgChan <- msg
+		return
+	}
+
+	select {
+	case p.msgChan <- msg:
+
+	case <-time.After(defaultMessageChanTimeout):
+		blog.Errorf("SimplePorter[%s]| add message to analyze, channel timeout", p.name)
+
+		// metrics stats for message sending timeout.
+		p.receiveTimeoutTotal.Inc()
+	}
+}
+
+// paused returns if the porter is paused by fusing.
+func (p *SimplePorter) paused() bool {
+	return atomic.LoadInt32(&p.isPaused) == 1
+}
+
+// stackedPercent returns the stacked percent of message channel.
+func (p *SimplePorter) stackedPercent() int {
+	return (len(p.msgChan) * 100) / defaultMessageChanSize
 }
 
 // fusing is fuse controller, it would weed out the stacked message in channel,
-// in order to keep the newest message could be analyzed in time.
+// in order to keep the newest message could be analyzed in time. Messages of durable
+// source are not weeded out, the porter is paused instead until the channel drains to
+// the fusing percent, and the messages are kept in the source meanwhile.
 func (p *SimplePorter) fusing() {
 	blog.Infof("SimplePorter[%s]| fusing running now!", p.name)
 
@@ -339,7 +398,7 @@ func (p *SimplePorter) fusing() {
 	// keep checking message channel status, and ctrl the fusing.
 	for now := range ticker.C {
 		stackedN := len(p.msgChan)
-		percent := (stackedN * 100) / defaultMessageChanSize
+		percent := p.stackedPercent()
 
 		// check threshold percent.
 		if percent < defaultFusingThresholdPercent {
@@ -359,6 +418,16 @@ func (p *SimplePorter) fusing() {
 			continue
 		}
 
+		// durable source, pause receiving instead of fusing.
+		if p.source.IsDurable() {
+			atomic.StoreInt32(&p.isPaused, 1)
+			isStackedInLastCheck = false
+
+			blog.Warnf("SimplePorter[%s]| time[%+v] pause receiving from source[%s] now! stackedNum[%d] chanSize[%d] threshold[%d]",
+				p.name, now, p.source.Name(), stackedN, defaultMessageChanSize, defaultFusingThresholdPercent)
+			continue
+		}
+
 		// need to fuse stacked message channel.
 		fuseCount := 0
 		fuseMaxCount := (defaultMessageChanSize * defaultFusingPercent) / 100
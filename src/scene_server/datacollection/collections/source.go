@@ -0,0 +1,112 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package collections
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// SourceTypePubSub is redis pub/sub source, messages are dropped if no porter is subscribing.
+	SourceTypePubSub = "pubsub"
+
+	// SourceTypeStream is durable redis stream source consumed with consumer group.
+	SourceTypeStream = "stream"
+
+	// SourceTypeHTTP is http push source, the pusher gets response after the message is analyzed.
+	SourceTypeHTTP = "http"
+
+	// SourceTypeSpool is local spool directory source, each json file in the directory is a message.
+	SourceTypeSpool = "spool"
+
+	// defaultSourceBatchSize is default max number of messages received from source at one time.
+	defaultSourceBatchSize = 100
+
+	// defaultSourceWaitDuration is default wait duration when there is no message in source.
+	defaultSourceWaitDuration = time.Second
+)
+
+// Message is message received from source.
+type Message struct {
+	// Topic is the topic that message received from, eg redis channel or stream key.
+	Topic string
+
+	// ID is the offset of the message in source, eg redis stream entry id or spool file name.
+	ID string
+
+	// Payload is message content.
+	Payload string
+}
+
+// Source is the input of porter.
+type Source interface {
+	// Name returns name of the Source.
+	Name() string
+
+	// Receive receives a batch of messages, it blocks for a while if there is no message,
+	// and returns an empty batch after that.
+	Receive() ([]*Message, error)
+
+	// Ack acknowledges the message has been analyzed, a durable source would not deliver it again.
+	Ack(msg *Message) error
+
+	// IsDurable returns if the source keeps messages until they are acked. Messages from durable source
+	// are never weeded out by fusing, porter stops receiving from the source instead.
+	IsDurable() bool
+
+	// IsBroadcast returns if the same messages are received by all datacollection nodes,
+	// in which case the messages are sharded by hashring.
+	IsBroadcast() bool
+}
+
+// SourceConfig is configs of porter source.
+type SourceConfig struct {
+	// Type is source type, pubsub is used if it's empty.
+	Type string
+
+	// StreamGroup is consumer group name of stream source.
+	StreamGroup string
+
+	// StreamConsumer is consumer name of stream source, it should be unique in group and
+	// stable across restarts, so that the pending messages could be claimed back.
+	StreamConsumer string
+
+	// SpoolDir is directory of spool source.
+	SpoolDir string
+}
+
+// Validate validates the source configs.
+func (c *SourceConfig) Validate() error {
+	switch c.Type {
+	case "", SourceTypePubSub, SourceTypeHTTP:
+	case SourceTypeStream:
+		if len(c.StreamGroup) == 0 {
+			return fmt.Errorf("stream group not set")
+		}
+		if len(c.StreamConsumer) == 0 {
+			return fmt.Errorf("stream consumer not set")
+		}
+	case SourceTypeSpool:
+		if len(c.SpoolDir) == 0 {
+			return fmt.Errorf("spool dir not set")
+		}
+	default:
+		return fmt.Errorf("unknown source type %s", c.Type)
+	}
+	return nil
+}
+
+// NeedRedis returns if the source reads messages from redis.
+func (c *SourceConfig) NeedRedis() bool {
+	return c.Type == "" || c.Type == SourceTypePubSub || c.Type == SourceTypeStream
+}
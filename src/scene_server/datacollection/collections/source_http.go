@@ -0,0 +1,125 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package collections
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HTTPSource receives messages pushed by http requests. The push request is responded after
+// the message is analyzed, so the pusher should retry if the request is failed or timeout.
+type HTTPSource struct {
+	name string
+
+	// msgChan is the pushed messages that waiting to be received.
+	msgChan chan *Message
+
+	// seq is used to generate message id.
+	seq uint64
+
+	// acks is the ack notify channels of the pushed messages, message id -> channel.
+	acks   map[string]chan struct{}
+	acksMu sync.Mutex
+}
+
+// NewHTTPSource creates a new HTTPSource object.
+func NewHTTPSource(name string) *HTTPSource {
+	return &HTTPSource{
+		name:    name,
+		msgChan: make(chan *Message, defaultSourceBatchSize),
+		acks:    make(map[string]chan struct{}),
+	}
+}
+
+// Name returns name of the source.
+func (s *HTTPSource) Name() string {
+	return s.name
+}
+
+// Push pushes a message and waits until it's analyzed, an error is returned
+// if the message is not analyzed within the timeout.
+func (s *HTTPSource) Push(payload string, timeout time.Duration) error {
+	ack := make(chan struct{})
+
+	s.acksMu.Lock()
+	s.seq++
+	msg := &Message{Topic: s.name, ID: strconv.FormatUint(s.seq, 10), Payload: payload}
+	s.acks[msg.ID] = ack
+	s.acksMu.Unlock()
+
+	defer func() {
+		s.acksMu.Lock()
+		delete(s.acks, msg.ID)
+		s.acksMu.Unlock()
+	}()
+
+	deadline := time.After(timeout)
+	select {
+	case s.msgChan <- msg:
+	case <-deadline:
+		return fmt.Errorf("source is busy, push again later")
+	}
+
+	select {
+	case <-ack:
+	case <-deadline:
+		return fmt.Errorf("wait message analyzed timeout")
+	}
+	return nil
+}
+
+// Receive receives a batch of pushed messages.
+func (s *HTTPSource) Receive() ([]*Message, error) {
+	messages := make([]*Message, 0)
+
+	select {
+	case msg := <-s.msgChan:
+		messages = append(messages, msg)
+	case <-time.After(defaultSourceWaitDuration):
+		return messages, nil
+	}
+
+	for len(messages) < defaultSourceBatchSize {
+		select {
+		case msg := <-s.msgChan:
+			messages = append(messages, msg)
+		default:
+			return messages, nil
+		}
+	}
+	return messages, nil
+}
+
+// Ack notifies the pusher that the message is analyzed.
+func (s *HTTPSource) Ack(msg *Message) error {
+	s.acksMu.Lock()
+	defer s.acksMu.Unlock()
+
+	if ack, ok := s.acks[msg.ID]; ok {
+		close(ack)
+		delete(s.acks, msg.ID)
+	}
+	return nil
+}
+
+// IsDurable returns true, the pusher keeps the message until it's acked.
+func (s *HTTPSource) IsDurable() bool {
+	return true
+}
+
+// IsBroadcast returns false, each message is pushed to one datacollection node.
+func (s *HTTPSource) IsBroadcast() bool {
+	return false
+}
@@ -0,0 +1,81 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package collections
+
+import (
+	"fmt"
+
+	"configcenter/src/common/blog"
+
+	"gopkg.in/redis.v5"
+)
+
+// PubSubSource receives messages from redis pub/sub channels, messages published
+// when no porter is subscribing are lost.
+type PubSubSource struct {
+	name     string
+	redisCli *redis.Client
+	topics   []string
+	subChan  *redis.PubSub
+}
+
+// NewPubSubSource creates a new PubSubSource object.
+func NewPubSubSource(name string, redisCli *redis.Client, topics []string) *PubSubSource {
+	return &PubSubSource{name: name, redisCli: redisCli, topics: topics}
+}
+
+// Name returns name of the source.
+func (s *PubSubSource) Name() string {
+	return s.name
+}
+
+// Receive receives one message from the subscribed channels.
+func (s *PubSubSource) Receive() ([]*Message, error) {
+	if s.subChan == nil {
+		// subscribe target topics and handle message base on the redis pubsub channel.
+		subChan, err := s.redisCli.Subscribe(s.topics...)
+		if err != nil {
+			return nil, fmt.Errorf("subscribe topics[%+v] failed, %+v", s.topics, err)
+		}
+		s.subChan = subChan
+		blog.Infof("PubSubSource[%s]| subscribe topics[%+v] success, receiving message now!", s.name, s.topics)
+	}
+
+	// ReceiveMessage returns a Message or error ignoring Subscription or Pong
+	// messages. It automatically reconnects to Redis Server and resubscribes
+	// to topics in case of network errors.
+	newMsg, err := s.subChan.ReceiveMessage()
+	if err != nil {
+		// internal errors, unsubscribe and try to sub-recv again.
+		s.subChan.Unsubscribe(s.topics...)
+		s.subChan.Close()
+		s.subChan = nil
+		return nil, fmt.Errorf("receive topics[%+v] message failed, %+v", s.topics, err)
+	}
+
+	return []*Message{{Topic: newMsg.Channel, Payload: newMsg.Payload}}, nil
+}
+
+// Ack does nothing, pub/sub message can't be delivered again.
+func (s *PubSubSource) Ack(msg *Message) error {
+	return nil
+}
+
+// IsDurable returns false, pub/sub channel doesn't keep messages.
+func (s *PubSubSource) IsDurable() bool {
+	return false
+}
+
+// IsBroadcast returns true, all subscribers receive the same messages.
+func (s *PubSubSource) IsBroadcast() bool {
+	return true
+}
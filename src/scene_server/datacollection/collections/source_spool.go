@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package collections
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+// spoolFileSuffix is suffix of message files in spool directory, the writer should write
+// the message into a temporary file and then rename it, so that partial files are never received.
+const spoolFileSuffix = ".json"
+
+// SpoolSource receives messages from a local spool directory, each json file is a message,
+// and it's removed after the message is analyzed. Files are received in the order of file name.
+type SpoolSource struct {
+	name string
+	dir  string
+
+	// inflight is the files that received but not acked yet.
+	inflight   map[string]bool
+	inflightMu sync.Mutex
+}
+
+// NewSpoolSource creates a new SpoolSource object.
+func NewSpoolSource(name, dir string) *SpoolSource {
+	return &SpoolSource{name: name, dir: dir, inflight: make(map[string]bool)}
+}
+
+// Name returns name of the source.
+func (s *SpoolSource) Name() string {
+	return s.name
+}
+
+// Receive receives a batch of message files that not received yet.
+func (s *SpoolSource) Receive() ([]*Message, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read spool dir[%s] failed, %+v", s.dir, err)
+	}
+
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+
+	messages := make([]*Message, 0)
+	for _, file := range files {
+		if len(messages) >= defaultSourceBatchSize {
+			break
+		}
+		if file.IsDir() || !strings.HasSuffix(file.Name(), spoolFileSuffix) || s.inflight[file.Name()] {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			blog.Errorf("SpoolSource[%s]| read spool file[%s] failed, %+v", s.name, file.Name(), err)
+			continue
+		}
+		s.inflight[file.Name()] = true
+		messages = append(messages, &Message{Topic: s.dir, ID: file.Name(), Payload: string(content)})
+	}
+
+	if len(messages) == 0 {
+		time.Sleep(defaultSourceWaitDuration)
+	}
+	return messages, nil
+}
+
+// Ack removes the message file from spool directory.
+func (s *SpoolSource) Ack(msg *Message) error {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+
+	if err := os.Remove(filepath.Join(s.dir, msg.ID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove spool file[%s] failed, %+v", msg.ID, err)
+	}
+	delete(s.inflight, msg.ID)
+	return nil
+}
+
+// IsDurable returns true, message file is kept until it's acked.
+func (s *SpoolSource) IsDurable() bool {
+	return true
+}
+
+// IsBroadcast returns false, spool directory is local to the datacollection node.
+func (s *SpoolSource) IsBroadcast() bool {
+	return false
+}
@@ -0,0 +1,215 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package collections
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/src/common/blog"
+
+	"gopkg.in/redis.v5"
+)
+
+const (
+	// streamPayloadField is the field of stream entry that keeps message content.
+	streamPayloadField = "data"
+
+	// streamBlockMilliseconds is block duration of reading stream, it must be less
+	// than the read timeout of redis client.
+	streamBlockMilliseconds = 1000
+)
+
+// StreamSource receives messages from redis streams with consumer group, each message is delivered
+// to one datacollection node of the group, and is kept in the pending list until it's acked.
+type StreamSource struct {
+	name     string
+	redisCli *redis.Client
+	topics   []string
+	group    string
+	consumer string
+
+	// isPendingDone marks that the pending messages delivered to this consumer
+	// before restart are all received, and it's time to read new messages.
+	isPendingDone bool
+
+	// pendingCursors is the last received pending message id of each stream.
+	pendingCursors map[string]string
+
+	isGroupCreated bool
+}
+
+// NewStreamSource creates a new StreamSource object, topics are the stream keys.
+func NewStreamSource(name string, redisCli *redis.Client, topics []string, group, consumer string) *StreamSource {
+	return &StreamSource{
+		name:     name,
+		redisCli: redisCli,
+		topics:   topics,
+		group:    group,
+		consumer: consumer,
+
+		pendingCursors: make(map[string]string),
+	}
+}
+
+// Name returns name of the source.
+func (s *StreamSource) Name() string {
+	return s.name
+}
+
+// createGroups creates consumer group on streams, the stream is created if it's not exist.
+func (s *StreamSource) createGroups() error {
+	for _, topic := range s.topics {
+		cmd := redis.NewStatusCmd("XGROUP", "CREATE", topic, s.group, "$", "MKSTREAM")
+		if err := s.redisCli.Process(cmd); err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("create consumer group[%s] on stream[%s] failed, %+v", s.group, topic, err)
+		}
+	}
+	s.isGroupCreated = true
+	return nil
+}
+
+// Receive receives a batch of messages, the pending messages of this consumer is received first,
+// so that the messages not acked before restart are analyzed again.
+func (s *StreamSource) Receive() ([]*Message, error) {
+	if !s.isGroupCreated {
+		if err := s.createGroups(); err != nil {
+			return nil, err
+		}
+	}
+
+	args := []interface{}{"XREADGROUP", "GROUP", s.group, s.consumer, "COUNT", defaultSourceBatchSize}
+	if s.isPendingDone {
+		args = append(args, "BLOCK", streamBlockMilliseconds)
+	}
+	args = append(args, "STREAMS")
+	for _, topic := range s.topics {
+		args = append(args, topic)
+	}
+	for _, topic := range s.topics {
+		args = append(args, s.readID(topic))
+	}
+
+	cmd := redis.NewCmd(args...)
+	if err := s.redisCli.Process(cmd); err != nil {
+		if err == redis.Nil {
+			return []*Message{}, nil
+		}
+		if strings.HasPrefix(err.Error(), "NOGROUP") {
+			// the stream is deleted, create the group again.
+			s.isGroupCreated = false
+		}
+		return nil, fmt.Errorf("read streams[%+v] with group[%s] failed, %+v", s.topics, s.group, err)
+	}
+
+	messages, err := parseStreamMessages(cmd.Val())
+	if err != nil {
+		return nil, fmt.Errorf("parse streams[%+v] messages failed, %+v", s.topics, err)
+	}
+
+	if !s.isPendingDone {
+		if len(messages) == 0 {
+			s.isPendingDone = true
+			blog.Infof("StreamSource[%s]| all pending messages of consumer[%s] received, receiving new message now!",
+				s.name, s.consumer)
+		}
+		for _, msg := range messages {
+			s.pendingCursors[msg.Topic] = msg.ID
+		}
+	}
+	return messages, nil
+}
+
+// readID returns the id to read stream from, ">" means new messages that never delivered to any consumer,
+// otherwise it reads pending messages of this consumer after the id.
+func (s *StreamSource) readID(topic string) string {
+	if s.isPendingDone {
+		return ">"
+	}
+	if cursor, ok := s.pendingCursors[topic]; ok {
+		return cursor
+	}
+	return "0"
+}
+
+// parseStreamMessages parses XREADGROUP reply, which is in form of
+// [[stream, [[id, [field, value, ...]], ...]], ...].
+func parseStreamMessages(reply interface{}) ([]*Message, error) {
+	messages := make([]*Message, 0)
+	if reply == nil {
+		return messages, nil
+	}
+
+	streams, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid streams reply type %T", reply)
+	}
+
+	for _, stream := range streams {
+		streamItems, ok := stream.([]interface{})
+		if !ok || len(streamItems) != 2 {
+			return nil, fmt.Errorf("invalid stream reply %+v", stream)
+		}
+		topic, ok := streamItems[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid stream key %+v", streamItems[0])
+		}
+		entries, ok := streamItems[1].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid stream entries %+v", streamItems[1])
+		}
+
+		for _, entry := range entries {
+			entryItems, ok := entry.([]interface{})
+			if !ok || len(entryItems) != 2 {
+				return nil, fmt.Errorf("invalid stream entry %+v", entry)
+			}
+			id, ok := entryItems[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid stream entry id %+v", entryItems[0])
+			}
+
+			msg := &Message{Topic: topic, ID: id}
+
+			// the entry is deleted when it's pending, the fields is nil,
+			// message with empty payload is acked and ignored by porter.
+			fields, _ := entryItems[1].([]interface{})
+			for i := 0; i+1 < len(fields); i += 2 {
+				if field, _ := fields[i].(string); field == streamPayloadField {
+					msg.Payload, _ = fields[i+1].(string)
+					break
+				}
+			}
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// Ack acknowledges the message, it's removed from the pending list of the consumer group.
+func (s *StreamSource) Ack(msg *Message) error {
+	cmd := redis.NewIntCmd("XACK", msg.Topic, s.group, msg.ID)
+	if err := s.redisCli.Process(cmd); err != nil {
+		return fmt.Errorf("ack stream[%s] message[%s] failed, %+v", msg.Topic, msg.ID, err)
+	}
+	return nil
+}
+
+// IsDurable returns true, stream keeps messages until they are acked.
+func (s *StreamSource) IsDurable() bool {
+	return true
+}
+
+// IsBroadcast returns false, consumer group delivers each message to one consumer.
+func (s *StreamSource) IsBroadcast() bool {
+	return false
+}
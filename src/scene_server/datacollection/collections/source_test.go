@@ -0,0 +1,137 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package collections
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseStreamMessages(t *testing.T) {
+	reply := []interface{}{
+		[]interface{}{
+			"snapshot3",
+			[]interface{}{
+				[]interface{}{"1-0", []interface{}{"data", `{"ip":"127.0.0.1"}`}},
+				[]interface{}{"2-0", nil},
+			},
+		},
+		[]interface{}{
+			"3_snapshot",
+			[]interface{}{
+				[]interface{}{"1-1", []interface{}{"other", "x", "data", "y"}},
+			},
+		},
+	}
+
+	messages, err := parseStreamMessages(reply)
+	if err != nil {
+		t.Fatalf("parse stream messages failed, %+v", err)
+	}
+
+	expects := []Message{
+		{Topic: "snapshot3", ID: "1-0", Payload: `{"ip":"127.0.0.1"}`},
+		{Topic: "snapshot3", ID: "2-0", Payload: ""},
+		{Topic: "3_snapshot", ID: "1-1", Payload: "y"},
+	}
+	if len(messages) != len(expects) {
+		t.Fatalf("expect %d messages, got %d", len(expects), len(messages))
+	}
+	for idx, msg := range messages {
+		if *msg != expects[idx] {
+			t.Errorf("expect message %+v, got %+v", expects[idx], *msg)
+		}
+	}
+
+	if messages, err := parseStreamMessages(nil); err != nil || len(messages) != 0 {
+		t.Errorf("expect no messages for nil reply, got %+v, %+v", messages, err)
+	}
+
+	if _, err := parseStreamMessages([]interface{}{"invalid"}); err == nil {
+		t.Errorf("expect error for invalid reply")
+	}
+}
+
+func TestSpoolSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatalf("create spool dir failed, %+v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{"2.json": "b", "1.json": "a", "3.json.tmp": "c"}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write spool file failed, %+v", err)
+		}
+	}
+
+	source := NewSpoolSource("hostsnap", dir)
+	messages, err := source.Receive()
+	if err != nil {
+		t.Fatalf("receive failed, %+v", err)
+	}
+	if len(messages) != 2 || messages[0].Payload != "a" || messages[1].Payload != "b" {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+
+	// the inflight messages are not received again until they are acked.
+	if err := source.Ack(messages[0]); err != nil {
+		t.Fatalf("ack failed, %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1.json")); !os.IsNotExist(err) {
+		t.Errorf("expect acked file removed, got %+v", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "4.json"), []byte("d"), 0644); err != nil {
+		t.Fatalf("write spool file failed, %+v", err)
+	}
+	messages, err = source.Receive()
+	if err != nil {
+		t.Fatalf("receive failed, %+v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != "4.json" {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	source := NewHTTPSource("middleware")
+
+	done := make(chan error)
+	go func() {
+		done <- source.Push("a", time.Second)
+	}()
+
+	messages, err := source.Receive()
+	if err != nil || len(messages) != 1 || messages[0].Payload != "a" {
+		t.Fatalf("unexpected messages %+v, %+v", messages, err)
+	}
+
+	if err := source.Ack(messages[0]); err != nil {
+		t.Fatalf("ack failed, %+v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("push failed, %+v", err)
+	}
+
+	// the message that never acked is failed after timeout.
+	go func() {
+		done <- source.Push("b", 10*time.Millisecond)
+	}()
+	if err := <-done; err == nil {
+		t.Errorf("expect push timeout")
+	}
+}
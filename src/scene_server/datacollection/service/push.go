@@ -0,0 +1,63 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// defaultPushTimeout is default timeout of waiting the pushed message analyzed.
+const defaultPushTimeout = 10 * time.Second
+
+// PushMessage receives a message pushed to the porter whose source is http, the request is responded after the
+// message is analyzed, the pusher should push it again if the request is failed, so that no message is lost.
+func (s *Service) PushMessage(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+
+	porterName := req.PathParameter("porter")
+	source, ok := s.pushSources[porterName]
+	if !ok {
+		blog.Errorf("push message failed, porter %s has no http source, rid: %s", porterName, rid)
+		resp.WriteError(http.StatusNotFound, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCollectPushSourceNotFound, porterName)})
+		return
+	}
+
+	payload, err := ioutil.ReadAll(req.Request.Body)
+	if err != nil {
+		blog.Errorf("push message failed, read body err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommHTTPReadBodyFailed)})
+		return
+	}
+	if len(payload) == 0 {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommHTTPBodyEmpty)})
+		return
+	}
+
+	if err := source.Push(string(payload), defaultPushTimeout); err != nil {
+		blog.Errorf("push message to porter %s failed, err: %v, rid: %s", porterName, err, rid)
+		resp.WriteError(http.StatusServiceUnavailable, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCollectPushMessageFail, err.Error())})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common/metric"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
+	"configcenter/src/scene_server/datacollection/collections"
 	"configcenter/src/scene_server/datacollection/logics"
 	"configcenter/src/storage/dal"
 	"configcenter/src/thirdpartyclient/esbserver"
@@ -43,11 +44,14 @@ type Service struct {
 	netCli  *redis.Client
 
 	logics *logics.Logics
+
+	// pushSources is the http sources that receive pushed messages, porter name -> source.
+	pushSources map[string]*collections.HTTPSource
 }
 
 // NewService creates a new Service object.
 func NewService(ctx context.Context, engine *backbone.Engine) *Service {
-	return &Service{ctx: ctx, engine: engine}
+	return &Service{ctx: ctx, engine: engine, pushSources: make(map[string]*collections.HTTPSource)}
 }
 
 // SetLogics setups logics comm.
@@ -80,6 +84,11 @@ func (s *Service) SetNetCollectCli(db *redis.Client) {
 	s.netCli = db
 }
 
+// SetPushSource setups http source of the porter.
+func (s *Service) SetPushSource(porterName string, source *collections.HTTPSource) {
+	s.pushSources[porterName] = source
+}
+
 // WebService setups a new restful web service.
 func (s *Service) WebService() *restful.Container {
 	container := restful.NewContainer()
@@ -112,6 +121,8 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/netcollect/collector/action/update").To(s.UpdateCollector))
 	api.Route(api.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice))
 
+	api.Route(api.POST("/push/{porter}").To(s.PushMessage))
+
 	container.Add(api)
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
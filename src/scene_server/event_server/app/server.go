@@ -19,8 +19,8 @@ import (
 	"sync"
 	"time"
 
-	"configcenter/src/auth/authcenter"
-	"configcenter/src/common/auth"
+	"configcenter/src/auth"
+	ccAuth "configcenter/src/common/auth"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
@@ -93,12 +93,12 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 			return fmt.Errorf("connect subcli redis server failed, err: %s", err.Error())
 		}
 
-		authCli, err := authcenter.NewAuthCenter(nil, process.Config.Auth, engine.Metric().Registry())
+		authCli, err := auth.NewAuthorize(ctx, nil, process.Config.Auth, engine.Metric().Registry())
 		if err != nil {
-			return fmt.Errorf("new authcenter failed: %v, config: %+v", err, process.Config.Auth)
+			return fmt.Errorf("new authorize failed: %v", err)
 		}
 		process.Service.SetAuth(authCli)
-		blog.Infof("enable auth center: %v", auth.IsAuthed())
+		blog.Infof("enable auth center: %v", ccAuth.IsAuthed())
 
 		go func() {
 			errCh <- distribution.SubscribeChannel(subCli)
@@ -14,6 +14,7 @@ package distribution
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -23,26 +24,28 @@ import (
 
 	"gopkg.in/redis.v5"
 
+	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/eventsign"
 	"configcenter/src/common/http/httpclient"
 	"configcenter/src/common/metadata"
 	"configcenter/src/scene_server/event_server/types"
 )
 
+// SendCallback send the event to the subscriber once, the callback statistics is counted by the caller,
+// so that the retries of one delivery are counted only once.
 func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event string) (callbackErr error) {
-	increaseTotal(dh.cache, receiver.SubscriptionID)
-
-	defer func() {
-		if callbackErr != nil {
-			increaseFailure(dh.cache, receiver.SubscriptionID)
-		}
-	}()
-
 	body := bytes.NewBufferString(event)
 	req, err := http.NewRequest("POST", receiver.CallbackURL, body)
 	if err != nil {
 		return fmt.Errorf("event distribute fail, build request error: %v, data=[%s]", err, event)
 	}
+	if len(receiver.Secret) != 0 {
+		eventsign.SetHeader(req.Header, receiver.Secret, time.Now().Unix(), []byte(event))
+	}
+	if receiver.Auth != nil {
+		receiver.Auth.SetHeader(req)
+	}
 	var duration time.Duration
 	if receiver.TimeOutSeconds == 0 {
 		duration = timeout
@@ -76,8 +79,124 @@ func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event strin
 	return
 }
 
+// SendCallbackWithRetry send the event callback with the subscription's retry policy.
+// if all the attempts are failed, the event will be saved to the dead letter collection,
+// and the delivery result is always recorded to the subscription's delivery history.
+func (dh *DistHandler) SendCallbackWithRetry(receiver *metadata.Subscription, dist *metadata.DistInstCtx) (err error) {
+	policy := receiver.GetRetryPolicy()
+	record := metadata.EventDeliveryRecord{
+		DistID:    dist.DstbID,
+		EventID:   dist.ID,
+		EventType: dist.EventType,
+		Action:    dist.Action,
+		ObjType:   dist.ObjType,
+		StartTime: metadata.Now(),
+	}
+
+retryLoop:
+	for attempt := int64(1); attempt <= policy.MaxAttempts; attempt++ {
+		record.Attempts = attempt
+		if err = dh.SendCallback(receiver, dist.Raw); err == nil {
+			break
+		}
+		blog.Errorf("send callback to subscription %d failed, attempt: %d/%d, dist id: %d, err: %v",
+			receiver.SubscriptionID, attempt, policy.MaxAttempts, dist.DstbID, err)
+
+		if attempt == policy.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(policy.Backoff(attempt)):
+		case <-dh.ctx.Done():
+			blog.Warnf("send callback to subscription %d, but context is done, stop retry, dist id: %d",
+				receiver.SubscriptionID, dist.DstbID)
+			break retryLoop
+		}
+	}
+	record.EndTime = metadata.Now()
+
+	increaseTotal(dh.cache, receiver.SubscriptionID)
+	if err != nil {
+		increaseFailure(dh.cache, receiver.SubscriptionID)
+	}
+
+	if err == nil {
+		record.Success = true
+	} else {
+		record.Error = err.Error()
+		if dlErr := dh.saveDeadLetter(receiver, dist, &record); dlErr != nil {
+			blog.Errorf("save event to dead letter failed, subscription: %d, dist: %s, err: %v", receiver.SubscriptionID,
+				dist.Raw, dlErr)
+		} else {
+			record.DeadLetter = true
+		}
+	}
+
+	if recordErr := saveDeliveryRecord(dh.cache, receiver.SubscriptionID, &record); recordErr != nil {
+		blog.Errorf("save event delivery record failed, subscription: %d, record: %+v, err: %v", receiver.SubscriptionID,
+			record, recordErr)
+	}
+	return err
+}
+
+func (dh *DistHandler) saveDeadLetter(receiver *metadata.Subscription, dist *metadata.DistInstCtx,
+	record *metadata.EventDeliveryRecord) error {
+
+	id, err := dh.db.NextSequence(dh.ctx, common.BKTableNameEventDeadLetter)
+	if err != nil {
+		return err
+	}
+
+	deadLetter := metadata.EventDeadLetter{
+		ID:             int64(id),
+		SubscriptionID: receiver.SubscriptionID,
+		DistID:         dist.DstbID,
+		EventID:        dist.ID,
+		EventType:      dist.EventType,
+		Action:         dist.Action,
+		ObjType:        dist.ObjType,
+		Data:           dist.Raw,
+		Attempts:       record.Attempts,
+		LastError:      record.Error,
+		OwnerID:        receiver.OwnerID,
+		CreateTime:     metadata.Now(),
+	}
+	return dh.db.Table(common.BKTableNameEventDeadLetter).Insert(dh.ctx, deadLetter)
+}
+
+// maxDeliveryDuration returns the max duration to deliver an event to the subscriber with all the retry attempts.
+func maxDeliveryDuration(receiver *metadata.Subscription) time.Duration {
+	callbackTimeout := timeout
+	if receiver.TimeOutSeconds != 0 {
+		callbackTimeout = receiver.GetTimeout()
+	}
+
+	policy := receiver.GetRetryPolicy()
+	duration := time.Duration(policy.MaxAttempts) * callbackTimeout
+	for attempt := int64(1); attempt < policy.MaxAttempts; attempt++ {
+		duration += policy.Backoff(attempt)
+	}
+	return duration
+}
+
 var httpCli = httpclient.NewHttpClient()
 
+func saveDeliveryRecord(cache *redis.Client, subscriptionID int64, record *metadata.EventDeliveryRecord) error {
+	js, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	key := types.EventCacheDistHistoryPrefix + strconv.FormatInt(subscriptionID, 10)
+	_, err = cache.Pipelined(func(pipe *redis.Pipeline) error {
+		pipe.LPush(key, string(js))
+		pipe.LTrim(key, 0, metadata.EventDeliveryHistoryLimit-1)
+		return nil
+	})
+	return err
+}
+
 func increaseTotal(cache *redis.Client, subscriptionID int64) error {
 	return increase(cache, subscriptionID, "total")
 }
@@ -87,11 +87,12 @@ func (dh *DistHandler) StartDistribute() (err error) {
 			msgBody := extractChangeBody(msg)
 
 			subscriber := metadata.Subscription{}
-			blog.Infof("msg: action:%s, body:%s", msgAction, msgBody)
 			if err := json.Unmarshal([]byte(msgBody), &subscriber); err != nil {
 				chErr <- err
 				return
 			}
+			// do not log the message body, it contains the subscription's secret.
+			blog.Infof("msg: action:%s, subscription id:%d", msgAction, subscriber.SubscriptionID)
 			switch msgAction {
 			case "create":
 				blog.Infof("starting subscribers process %d", subscriber.SubscriptionID)
@@ -150,9 +151,9 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 		case nsub := <-chNew:
 			if nsub.GetCacheKey() != sub.GetCacheKey() {
 				sub = nsub
-				blog.Infof("refreshed subscriber %v", sub.GetCacheKey())
+				blog.Infof("refreshed subscriber %d", sub.SubscriptionID)
 			} else {
-				blog.Infof("refresh ignore, subscriber cache key not change\nold:%s\nnew:%s ", sub.GetCacheKey(), nsub.GetCacheKey())
+				blog.Infof("refresh ignore, subscriber %d cache key not change", sub.SubscriptionID)
 			}
 		case <-ticker.C:
 			filter := map[string]interface{}{
@@ -170,10 +171,16 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 		case <-done:
 			return
 		default:
-			dist := dh.popDistInst(sub.SubscriptionID)
+			dist, replay := dh.popDistInst(sub.SubscriptionID)
 			if dist == nil {
 				continue
 			}
+			if replay {
+				if err = dh.handleReplayDist(&sub, dist); err != nil {
+					blog.Errorf("error handle replayed dist: %v, %v", err, dist)
+				}
+				continue
+			}
 			if err = dh.handleDist(&sub, dist); err != nil {
 				blog.Errorf("error handle dist: %v, %v", err, dist)
 			}
@@ -186,7 +193,7 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 	distID := fmt.Sprint(dist.DstbID - 1)
 	subscriberID := fmt.Sprint(dist.SubscriptionID)
 	runningKey := types.EventCacheDistRunningPrefix + subscriberID + "_" + distID
-	if err = saveRunning(dh.cache, runningKey, timeout+sub.GetTimeout()); err != nil {
+	if err = saveRunning(dh.cache, runningKey, timeout+maxDeliveryDuration(sub)); err != nil {
 		if ErrProcessExists == err {
 			blog.Infof("process exist, continue")
 			return nil
@@ -217,7 +224,7 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 		if running {
 
 			blog.Infof("waiting previous id: " + previousID)
-			if checkErr = waitPreviousDone(dh.cache, types.EventCacheDistDonePrefix+subscriberID, previousID, sub.GetTimeout()); checkErr != nil && checkErr != ErrWaitTimeout {
+			if checkErr = waitPreviousDone(dh.cache, types.EventCacheDistDonePrefix+subscriberID, previousID, maxDeliveryDuration(sub)); checkErr != nil && checkErr != ErrWaitTimeout {
 				return checkErr
 			}
 			if checkErr == ErrWaitTimeout {
@@ -233,7 +240,7 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 		blog.Infof("done event dist : %v", dist.DstbID)
 	}()
 
-	if err = dh.SendCallback(sub, dist.Raw); err != nil {
+	if err = dh.SendCallbackWithRetry(sub, dist); err != nil {
 		blog.Errorf("send callback error: %v", err)
 		return
 	}
@@ -241,21 +248,41 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 	return
 }
 
-func (dh *DistHandler) popDistInst(subID int64) *metadata.DistInstCtx {
-	eventSlice := dh.cache.BLPop(time.Second*10, types.EventCacheDistQueuePrefix+fmt.Sprint(subID)).Val()
+// handleReplayDist delivers a replayed dead letter. it's not a part of the distribution chain, so it neither waits
+// for the previous distribution nor is marked as done, the running key only prevents it from being sent twice.
+func (dh *DistHandler) handleReplayDist(sub *metadata.Subscription, dist *metadata.DistInstCtx) (err error) {
+	blog.Infof("handling replayed dist %s", dist.Raw)
+	runningKey := types.EventCacheDistReplayRunningPrefix + fmt.Sprintf("%d_%d", dist.SubscriptionID, dist.DstbID)
+	if err = saveRunning(dh.cache, runningKey, timeout+maxDeliveryDuration(sub)); err != nil {
+		if ErrProcessExists == err {
+			blog.Infof("replayed dist %d is being sent, skip", dist.DstbID)
+			return nil
+		}
+		return err
+	}
+	defer dh.cache.Del(runningKey)
+
+	return dh.SendCallbackWithRetry(sub, dist)
+}
+
+// popDistInst pops a distribution from the subscription's queue, the live queue is popped before the replay
+// queue, the second return value is true if it's a replayed dead letter.
+func (dh *DistHandler) popDistInst(subID int64) (*metadata.DistInstCtx, bool) {
+	replayQueue := types.EventCacheDistReplayQueuePrefix + fmt.Sprint(subID)
+	eventSlice := dh.cache.BLPop(time.Second*10, types.EventCacheDistQueuePrefix+fmt.Sprint(subID), replayQueue).Val()
 
 	if len(eventSlice) <= 0 {
-		return nil
+		return nil, false
 	}
 
 	eventBytes := []byte(eventSlice[1])
 	event := metadata.DistInst{}
 	if err := json.Unmarshal(eventBytes, &event); err != nil {
 		blog.Errorf("event distribute fail, unmarshal error: %v, data=[%s]", err, eventBytes)
-		return nil
+		return nil, false
 	}
 
-	return &metadata.DistInstCtx{DistInst: event, Raw: eventSlice[1]}
+	return &metadata.DistInstCtx{DistInst: event, Raw: eventSlice[1]}, eventSlice[0] == replayQueue
 }
 
 func (dh *DistHandler) saveDistDone(dist *metadata.DistInstCtx) (err error) {
@@ -0,0 +1,201 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/types"
+
+	"github.com/emicklei/go-restful"
+)
+
+// ListDeadLetters list the events which is failed to send to the subscriber after all the retry attempts.
+func (s *Service) ListDeadLetters(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	ownerID := util.GetOwnerID(header)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "subscribeID")})
+		return
+	}
+
+	data := metadata.ParamDeadLetterSearch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&data); err != nil {
+		blog.Errorf("search dead letters, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := data.Condition
+	if condition == nil {
+		condition = make(map[string]interface{})
+	}
+	condition[common.BKSubscriptionIDField] = id
+	condition = util.SetModOwner(condition, ownerID)
+
+	limit := data.Page.Limit
+	if limit <= 0 {
+		limit = common.BKNoLimit
+	}
+	sortOption := data.Page.Sort
+	if len(sortOption) == 0 {
+		sortOption = common.BKFieldID
+	}
+
+	count, err := s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("count dead letters failed, condition: %+v, err: %v, rid: %s", condition, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	deadLetters := make([]metadata.EventDeadLetter, 0)
+	err = s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Sort(sortOption).
+		Start(uint64(data.Page.Start)).Limit(uint64(limit)).All(s.ctx, &deadLetters)
+	if err != nil {
+		blog.Errorf("search dead letters failed, condition: %+v, err: %v, rid: %s", condition, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterSearch{Count: count, Info: deadLetters}))
+}
+
+// ReplayDeadLetters push the dead letters to the subscription's replay queue, and remove them
+// from the dead letter collection. the replay queue is delivered apart from the live events,
+// if they are still failed to deliver, they will be moved to the dead letter collection again.
+func (s *Service) ReplayDeadLetters(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	ownerID := util.GetOwnerID(header)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "subscribeID")})
+		return
+	}
+
+	opt := metadata.DeadLetterOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&opt); err != nil {
+		blog.Errorf("replay dead letters, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	// only one replay of a subscription runs at the same time, otherwise the dead letters may be pushed twice.
+	lockKey := types.EventCacheDistReplayLockPrefix + strconv.FormatInt(id, 10)
+	locked, err := s.cache.SetNX(lockKey, rid, time.Minute).Result()
+	if err != nil || !locked {
+		blog.Errorf("replay dead letters, but subscription %d is being replayed, err: %v, rid: %s", id, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+		return
+	}
+	defer s.cache.Del(lockKey)
+
+	condition := deadLetterCondition(id, ownerID, opt.IDs)
+	deadLetters := make([]metadata.EventDeadLetter, 0)
+	err = s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Sort(common.BKFieldID).All(s.ctx, &deadLetters)
+	if err != nil {
+		blog.Errorf("replay dead letters, but get dead letters failed, condition: %+v, err: %v, rid: %s", condition, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	if len(deadLetters) == 0 {
+		resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterOperate{Count: 0}))
+		return
+	}
+
+	// the dead letters are pushed in one command, so that they are either all pushed or none is pushed, and a
+	// failed replay can be retried without delivering some of them twice.
+	queueKey := types.EventCacheDistReplayQueuePrefix + strconv.FormatInt(id, 10)
+	ids := make([]int64, len(deadLetters))
+	data := make([]interface{}, len(deadLetters))
+	for idx, deadLetter := range deadLetters {
+		ids[idx] = deadLetter.ID
+		data[idx] = deadLetter.Data
+	}
+	if err := s.cache.RPush(queueKey, data...).Err(); err != nil {
+		blog.Errorf("replay dead letters, but push to queue failed, ids: %v, err: %v, rid: %s", ids, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+		return
+	}
+
+	delCond := deadLetterCondition(id, ownerID, ids)
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, delCond); err != nil {
+		blog.Errorf("replay dead letters, but delete replayed dead letters failed, ids: %v, err: %v, rid: %s", ids, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterOperate{Count: uint64(len(ids))}))
+}
+
+// PurgeDeadLetters delete the subscription's dead letters permanently.
+func (s *Service) PurgeDeadLetters(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	ownerID := util.GetOwnerID(header)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "subscribeID")})
+		return
+	}
+
+	opt := metadata.DeadLetterOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&opt); err != nil {
+		blog.Errorf("purge dead letters, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := deadLetterCondition(id, ownerID, opt.IDs)
+	count, err := s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("purge dead letters, but count dead letters failed, condition: %+v, err: %v, rid: %s", condition, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterPurgeFailed)})
+		return
+	}
+
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, condition); err != nil {
+		blog.Errorf("purge dead letters failed, condition: %+v, err: %v, rid: %s", condition, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterPurgeFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterOperate{Count: count}))
+}
+
+func deadLetterCondition(subscriptionID int64, ownerID string, ids []int64) map[string]interface{} {
+	condition := map[string]interface{}{
+		common.BKSubscriptionIDField: subscriptionID,
+	}
+	if len(ids) != 0 {
+		condition[common.BKFieldID] = map[string]interface{}{common.BKDBIN: ids}
+	}
+	return util.SetModOwner(condition, ownerID)
+}
@@ -82,6 +82,9 @@ func (s *Service) getNodesFromCursor(count int, startCursor string, key event.Ke
 		if strings.Contains(err.Error(), startCursorNotExistError) && startCursor == key.HeadKey() {
 			return nil, HeadNodeNotExistError
 		}
+		if strings.Contains(err.Error(), startCursorNotExistError) {
+			return nil, StartCursorNotExistError
+		}
 
 		return nil, err
 	}
@@ -200,6 +203,8 @@ var (
 	HeadNodeNotExistError       = errors.New(headNodeNotExistError)
 	TailNodeNotExistError       = errors.New(tailNodeNotExistError)
 	TailNodeTargetNotExistError = errors.New(tailNodeTargetNotExistError)
+	// StartCursorNotExistError means the cursor is expired or invalid, it should not be watched any more
+	StartCursorNotExistError = errors.New(startCursorNotExistError)
 )
 
 const (
@@ -72,6 +72,9 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/subscribe/{ownerID}/{appID}").To(s.Subscribe))
 	api.Route(api.DELETE("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.UnSubscribe))
 	api.Route(api.PUT("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.UpdateSubscription))
+	api.Route(api.POST("/subscribe/deadletter/search/{ownerID}/{appID}/{subscribeID}").To(s.ListDeadLetters))
+	api.Route(api.POST("/subscribe/deadletter/replay/{ownerID}/{appID}/{subscribeID}").To(s.ReplayDeadLetters))
+	api.Route(api.DELETE("/subscribe/deadletter/{ownerID}/{appID}/{subscribeID}").To(s.PurgeDeadLetters))
 
 	api.Route(api.POST("/subscribe/ping").To(s.Ping))
 	api.Route(api.POST("/subscribe/telnet").To(s.Telnet))
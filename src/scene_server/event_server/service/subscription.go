@@ -70,6 +70,20 @@ func (s *Service) Subscribe(req *restful.Request, resp *restful.Response) {
 	if sub.ConfirmMode == metadata.ConfirmModeHTTPStatus && sub.ConfirmPattern == "" {
 		sub.ConfirmPattern = strconv.FormatInt(http.StatusOK, 10)
 	}
+	if sub.RetryPolicy != nil {
+		if err := sub.RetryPolicy.Validate(); err != nil {
+			blog.Errorf("got invalid subscription retry policy: %+v, err: %v, rid: %s", *sub.RetryPolicy, err, rid)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "retry_policy")})
+			return
+		}
+	}
+	if sub.Auth != nil {
+		if err := sub.Auth.Validate(); err != nil {
+			blog.Errorf("got invalid subscription auth, type: %s, err: %v, rid: %s", sub.Auth.Type, err, rid)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "auth")})
+			return
+		}
+	}
 	now := metadata.Now()
 	sub.LastTime = now
 	sub.OwnerID = ownerID
@@ -217,8 +231,16 @@ func (s *Service) UnSubscribe(req *restful.Request, resp *restful.Response) {
 
 	s.cache.Del(types.EventCacheDistIDPrefix+subID,
 		types.EventCacheDistQueuePrefix+subID,
+		types.EventCacheDistReplayQueuePrefix+subID,
 		types.EventCacheDistDonePrefix+subID,
-		types.EventCacheDistCallBackCountPrefix+subID)
+		types.EventCacheDistCallBackCountPrefix+subID,
+		types.EventCacheDistHistoryPrefix+subID)
+
+	// the dead letters is useless when the subscription is deleted.
+	deadLetterCond := map[string]interface{}{common.BKSubscriptionIDField: id}
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, deadLetterCond); err != nil {
+		blog.Errorf("delete subscription %d dead letters failed, err: %v, rid: %s", id, err, rid)
+	}
 
 	msg, _ := json.Marshal(&sub)
 	s.cache.Publish(types.EventCacheProcessChannel, "delete"+string(msg))
@@ -283,6 +305,28 @@ func (s *Service) UpdateSubscription(req *restful.Request, resp *restful.Respons
 	if sub.ConfirmMode == metadata.ConfirmModeHTTPStatus && sub.ConfirmPattern == "" {
 		sub.ConfirmPattern = strconv.FormatInt(http.StatusOK, 10)
 	}
+	if sub.RetryPolicy != nil {
+		if err := sub.RetryPolicy.Validate(); err != nil {
+			blog.Errorf("got invalid subscription retry policy: %+v, err: %v, rid: %s", *sub.RetryPolicy, err, rid)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "retry_policy")})
+			return
+		}
+	}
+	if sub.Auth != nil {
+		if err := sub.Auth.Validate(); err != nil {
+			blog.Errorf("got invalid subscription auth, type: %s, err: %v, rid: %s", sub.Auth.Type, err, rid)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "auth")})
+			return
+		}
+	}
+	if sub.ClearSecret && len(sub.Secret) != 0 {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "clear_secret")})
+		return
+	}
+	if sub.ClearAuth && sub.Auth != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "clear_auth")})
+		return
+	}
 	sub.Operator = util.GetUser(req.Request.Header)
 	if err = s.updateSubscription(header, id, ownerID, sub); err != nil {
 		result := &metadata.RespError{
@@ -336,6 +380,18 @@ func (s *Service) updateSubscription(header http.Header, id int64, ownerID strin
 	}
 
 	sub.SubscriptionID = oldSub.SubscriptionID
+	// the secret and auth info is not returned to the user, so keep the old ones if they are not set,
+	// rotate them if new ones are set, and remove them only if they are cleared explicitly.
+	if sub.ClearSecret {
+		sub.Secret = ""
+	} else if len(sub.Secret) == 0 {
+		sub.Secret = oldSub.Secret
+	}
+	if sub.ClearAuth {
+		sub.Auth = nil
+	} else if sub.Auth == nil {
+		sub.Auth = oldSub.Auth
+	}
 	if sub.TimeOutSeconds <= 0 {
 		sub.TimeOutSeconds = 10
 	}
@@ -447,6 +503,8 @@ func (s *Service) ListSubscriptions(req *restful.Request, resp *restful.Response
 			Total:   total,
 			Failure: failure,
 		}
+		results[index].DeliveryHistory = s.getDeliveryHistory(results[index].SubscriptionID, rid)
+		results[index].Desensitize()
 	}
 
 	info := make(map[string]interface{})
@@ -459,6 +517,27 @@ func (s *Service) ListSubscriptions(req *restful.Request, resp *restful.Response
 	resp.WriteEntity(metadata.NewSuccessResp(result))
 }
 
+// getDeliveryHistory get the subscription's latest event delivery records.
+func (s *Service) getDeliveryHistory(subscriptionID int64, rid string) []metadata.EventDeliveryRecord {
+	records := make([]metadata.EventDeliveryRecord, 0)
+	key := types.EventCacheDistHistoryPrefix + strconv.FormatInt(subscriptionID, 10)
+	vals, err := s.cache.LRange(key, 0, metadata.EventDeliveryHistoryLimit-1).Result()
+	if err != nil {
+		blog.Warnf("get subscription %d delivery history failed, err: %v, rid: %s", subscriptionID, err, rid)
+		return records
+	}
+
+	for _, val := range vals {
+		record := metadata.EventDeliveryRecord{}
+		if err := json.Unmarshal([]byte(val), &record); err != nil {
+			blog.Warnf("unmarshal subscription %d delivery record %s failed, err: %v, rid: %s", subscriptionID, val, err, rid)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
 func (s *Service) Ping(req *restful.Request, resp *restful.Response) {
 	header := req.Request.Header
 	rid := util.GetHTTPCCRequestID(header)
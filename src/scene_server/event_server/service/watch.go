@@ -62,6 +62,9 @@ func (s *Service) WatchEvent(req *restful.Request, resp *restful.Response) {
 		events, err := s.watchWithCursor(key, options, rid)
 		if err != nil {
 			blog.Errorf("watch event with cursor failed, cursor: %s, err: %v, rid: %s", options.Cursor, err, rid)
+			if err == StartCursorNotExistError {
+				err = defErr.Error(common.CCErrEventWatchCursorNotExist)
+			}
 			resp.WriteError(http.StatusOK, &metadata.RespError{Msg: err})
 			return
 		}
@@ -242,7 +245,7 @@ func (s *Service) watchWithStartFrom(key event.Key, opts *watch.WatchEventOption
 			return []*watch.WatchEventDetail{resp}, nil
 		}
 
-		hitNodes := getHitNodeWithEventType(nodes, opts.EventTypes)
+		hitNodes := getHitNodeWithObjectID(getHitNodeWithEventType(nodes, opts.EventTypes), opts.Filter.ObjectID)
 		matchedNodes := make([]*watch.ChainNode, 0)
 		for _, node := range hitNodes {
 			// find node that cluster time is larger than the start from seconds.
@@ -260,19 +263,13 @@ func (s *Service) watchWithStartFrom(key event.Key, opts *watch.WatchEventOption
 		// check if nodes has already scan to the end
 		lastNode := nodes[len(nodes)-1]
 		if lastNode.NextCursor == key.TailKey() {
-			// has already scan to the end, no need to scan anymore
-			// get event detail.
-			detail, err := s.cache.Get(key.DetailKey(lastNode.Cursor)).Result()
-			if err != nil {
-				blog.Errorf("get cursor: %s detail failed, err: %v, rid: %s", lastNode.Cursor, err, rid)
-				return nil, err
-			}
-
+			// has already scan to the end, no need to scan anymore. the last node is not matched with
+			// the options, so only it's cursor is returned to move the user's cursor forward.
 			resp := &watch.WatchEventDetail{
 				Cursor:    lastNode.Cursor,
 				Resource:  opts.Resource,
-				EventType: lastNode.EventType,
-				Detail:    watch.JsonString(detail),
+				EventType: "",
+				Detail:    nil,
 			}
 			return []*watch.WatchEventDetail{resp}, nil
 		}
@@ -335,7 +332,7 @@ func (s *Service) watchFromNow(key event.Key, opts *watch.WatchEventOptions, rid
 		return nil, err
 	}
 
-	hit := getHitNodeWithEventType([]*watch.ChainNode{node}, opts.EventTypes)
+	hit := getHitNodeWithObjectID(getHitNodeWithEventType([]*watch.ChainNode{node}, opts.EventTypes), opts.Filter.ObjectID)
 	if len(hit) == 0 {
 		// not matched, set to no event cursor with empty detail
 		return &watch.WatchEventDetail{
@@ -420,7 +417,7 @@ func (s *Service) watchWithCursor(key event.Key, opts *watch.WatchEventOptions,
 			continue
 		}
 
-		hitNodes := getHitNodeWithEventType(nodes, opts.EventTypes)
+		hitNodes := getHitNodeWithObjectID(getHitNodeWithEventType(nodes, opts.EventTypes), opts.Filter.ObjectID)
 		if len(hitNodes) != 0 {
 			if hitNodes[0].Cursor == key.TailKey() {
 				// to the end
@@ -449,7 +446,7 @@ func (s *Service) watchWithCursor(key event.Key, opts *watch.WatchEventOptions,
 			resp := &watch.WatchEventDetail{
 				Cursor:    lastNode.Cursor,
 				Resource:  opts.Resource,
-				EventType: lastNode.EventType,
+				EventType: "",
 				Detail:    nil,
 			}
 
@@ -457,6 +454,15 @@ func (s *Service) watchWithCursor(key event.Key, opts *watch.WatchEventOptions,
 			blog.V(5).Infof("watch with cursor %s, but no event matched in the chain, rid: %s", opts.Cursor, rid)
 			return []*watch.WatchEventDetail{resp}, nil
 		}
+
+		// the scanned events are not what the user want, watch from the last scanned event in the next round,
+		// otherwise the events after them can never be reached if the whole step of events is not matched.
+		startCursor = nodes[len(nodes)-1].Cursor
+		if len(nodes) >= eventStep {
+			// there may be more events after the scanned ones, scan them immediately
+			continue
+		}
+
 		// not event one event is hit, sleep a little, and then try to continue the loop watch
 		time.Sleep(loopInternal)
 		blog.V(5).Infof("watch key: %s with resource: %s, hit nothing, try next round. rid: %s", key.Namespace(), opts.Resource, rid)
@@ -488,3 +494,17 @@ func getHitNodeWithEventType(nodes []*watch.ChainNode, typs []watch.EventType) [
 	}
 	return hitNodes
 }
+
+func getHitNodeWithObjectID(nodes []*watch.ChainNode, objID string) []*watch.ChainNode {
+	if len(objID) == 0 {
+		return nodes
+	}
+
+	hitNodes := make([]*watch.ChainNode, 0)
+	for _, node := range nodes {
+		if node.ObjectID == objID {
+			hitNodes = append(hitNodes, node)
+		}
+	}
+	return hitNodes
+}
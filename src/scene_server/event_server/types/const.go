@@ -35,7 +35,14 @@ const (
 	EventCacheDistTimeoutPrefix = common.BKCacheKeyV3Prefix + "event:dist_timeout_"
 	EventCacheDistDonePrefix    = common.BKCacheKeyV3Prefix + "event:dist_done_"
 
+	// the replayed dead letters are delivered with their own queue and running keys, so that they do not
+	// take part in the ordering of the live distributions.
+	EventCacheDistReplayQueuePrefix   = common.BKCacheKeyV3Prefix + "event:dist_replay_queue_"
+	EventCacheDistReplayRunningPrefix = common.BKCacheKeyV3Prefix + "event:dist_replay_running_"
+	EventCacheDistReplayLockPrefix    = common.BKCacheKeyV3Prefix + "event:dist_replay_lock_"
+
 	EventCacheDistCallBackCountPrefix = common.BKCacheKeyV3Prefix + "event:dist_callback_"
+	EventCacheDistHistoryPrefix       = common.BKCacheKeyV3Prefix + "event:dist_history_"
 
 	// EventCacheSubscribeFormKey the key prefix in cache
 	EventCacheSubscribeFormKey = common.BKCacheKeyV3Prefix + "event:subscribeform:"
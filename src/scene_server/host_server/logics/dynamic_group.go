@@ -0,0 +1,216 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
+)
+
+// GetDynamicGroup get the dynamic group of the business by id.
+func (lgc *Logics) GetDynamicGroup(ctx context.Context, bizID int64, id string) (*meta.UserConfigMeta, errors.CCErrorCoder) {
+	result, err := lgc.CoreAPI.CoreService().Host().GetUserConfigDetail(ctx, strconv.FormatInt(bizID, 10), id, lgc.header)
+	if err != nil {
+		blog.Errorf("get dynamic group failed, bizID: %d, id: %s, err: %v, rid: %s", bizID, id, err, lgc.rid)
+		return nil, lgc.ccErr.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("get dynamic group failed, bizID: %d, id: %s, err code: %d, err msg: %s, rid: %s", bizID, id,
+			result.Code, result.ErrMsg, lgc.rid)
+		return nil, errors.New(result.Code, result.ErrMsg)
+	}
+	if len(result.Data.ID) == 0 {
+		blog.Errorf("dynamic group not found, bizID: %d, id: %s, rid: %s", bizID, id, lgc.rid)
+		return nil, lgc.ccErr.CCErrorf(common.CCErrHostDynamicGroupNotFound, id)
+	}
+	return &result.Data, nil
+}
+
+// DynamicGroupObjID returns the object of the dynamic group's instances, the old host
+// custom query has no object id, it's a dynamic group of hosts.
+func DynamicGroupObjID(group *meta.UserConfigMeta) string {
+	if len(group.ObjID) == 0 {
+		return common.BKInnerObjIDHost
+	}
+	return group.ObjID
+}
+
+// GetDynamicGroupFieldTypes get the types of the fields which can be used in the object's dynamic group filter.
+func (lgc *Logics) GetDynamicGroupFieldTypes(ctx context.Context, objID string) (querybuilder.FieldTypes, errors.CCErrorCoder) {
+	if objID == meta.ServiceInstanceObjID {
+		return meta.ServiceInstanceFieldTypes, nil
+	}
+
+	attributes, err := lgc.GetObjectAttributes(ctx, lgc.ownerID, objID, meta.BasePage{})
+	if err != nil {
+		return nil, errors.NewFromStdError(err, common.CCErrCommHTTPDoRequestFailed)
+	}
+	if len(attributes) == 0 {
+		blog.Errorf("object %s has no attributes, it may not exist, rid: %s", objID, lgc.rid)
+		return nil, lgc.ccErr.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+
+	fieldTypes := meta.GetQueryFieldTypes(attributes)
+	instIDField := common.GetInstIDField(objID)
+	if _, exist := fieldTypes[instIDField]; !exist {
+		fieldTypes[instIDField] = common.FieldTypeInt
+	}
+	return fieldTypes, nil
+}
+
+// ParseDynamicGroupFilter validate the dynamic group's filter with the fields of the object,
+// the text query is parsed to the filter, so that only the typed filter is saved.
+func (lgc *Logics) ParseDynamicGroupFilter(ctx context.Context, option *meta.DynamicGroupOption) errors.CCErrorCoder {
+	if key, err := option.ValidateFilter(); err != nil {
+		blog.Errorf("dynamic group filter is invalid, key: %s, err: %v, rid: %s", key, err, lgc.rid)
+		return lgc.ccErr.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+	if option.Filter == nil && len(option.Query) == 0 {
+		return nil
+	}
+
+	fieldTypes, ccErr := lgc.GetDynamicGroupFieldTypes(ctx, option.ObjID)
+	if ccErr != nil {
+		return ccErr
+	}
+
+	if len(option.Query) != 0 {
+		filter, err := querybuilder.ParseQuery(option.Query, fieldTypes)
+		if err != nil {
+			blog.Errorf("parse dynamic group query failed, query: %s, err: %v, rid: %s", option.Query, err, lgc.rid)
+			return lgc.ccErr.CCErrorf(common.CCErrCommQueryInvalid, "query", err.Error())
+		}
+		option.Filter = filter
+		option.Query = ""
+		return nil
+	}
+
+	if key, err := querybuilder.ValidateFieldTypes(option.Filter.Rule, fieldTypes); err != nil {
+		blog.Errorf("dynamic group filter is invalid, key: %s, err: %v, rid: %s", key, err, lgc.rid)
+		return lgc.ccErr.CCErrorf(common.CCErrCommParamsInvalid, "filter."+key)
+	}
+	return nil
+}
+
+// GetDynamicGroupFilter get the filter of the business's dynamic group, which is used as a target of other
+// apis, the dynamic group must be a group of the object.
+func (lgc *Logics) GetDynamicGroupFilter(ctx context.Context, bizID int64, id string, objID string) (
+	*querybuilder.QueryFilter, errors.CCErrorCoder) {
+
+	group, ccErr := lgc.GetDynamicGroup(ctx, bizID, id)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+	if DynamicGroupObjID(group) != objID || group.Filter == nil {
+		blog.Errorf("dynamic group %s is not a group of %s, rid: %s", id, objID, lgc.rid)
+		return nil, lgc.ccErr.CCErrorf(common.CCErrHostDynamicGroupObjectNotMatch, id, objID)
+	}
+	return group.Filter, nil
+}
+
+// ExecuteDynamicGroup get the instances which match the dynamic group's filter at this moment.
+func (lgc *Logics) ExecuteDynamicGroup(ctx context.Context, group *meta.UserConfigMeta,
+	option *meta.ExecuteDynamicGroupOption) (*meta.DynamicGroupResult, errors.CCErrorCoder) {
+
+	// the old host custom query saves a host search condition in info
+	if group.Filter == nil {
+		return lgc.executeHostCustomQuery(ctx, group, option)
+	}
+
+	objID := DynamicGroupObjID(group)
+	switch objID {
+	case common.BKInnerObjIDHost:
+		listOption := &meta.ListHosts{
+			BizID:              group.AppID,
+			HostPropertyFilter: group.Filter,
+			Fields:             option.Fields,
+			Page:               option.Page,
+		}
+		hosts, err := lgc.CoreAPI.CoreService().Host().ListHosts(ctx, lgc.header, listOption)
+		if err != nil {
+			blog.Errorf("execute dynamic group %s, list hosts failed, err: %v, rid: %s", group.ID, err, lgc.rid)
+			return nil, lgc.ccErr.CCError(common.CCErrHostGetFail)
+		}
+		result := &meta.DynamicGroupResult{Count: hosts.Count, Info: make([]mapstr.MapStr, len(hosts.Info))}
+		for idx, host := range hosts.Info {
+			result.Info[idx] = host
+		}
+		return result, nil
+
+	case meta.ServiceInstanceObjID:
+		listOption := &meta.ListServiceInstanceOption{
+			BusinessID: group.AppID,
+			Filter:     group.Filter,
+			Page:       option.Page,
+		}
+		instances, ccErr := lgc.CoreAPI.CoreService().Process().ListServiceInstance(ctx, lgc.header, listOption)
+		if ccErr != nil {
+			blog.Errorf("execute dynamic group %s, list service instances failed, err: %v, rid: %s", group.ID, ccErr, lgc.rid)
+			return nil, ccErr
+		}
+		result := &meta.DynamicGroupResult{Count: int(instances.Count), Info: make([]mapstr.MapStr, len(instances.Info))}
+		for idx, instance := range instances.Info {
+			result.Info[idx] = mapstr.NewFromStruct(instance, "field")
+		}
+		return result, nil
+	}
+
+	cond := mapstr.MapStr{}
+	if objID == common.BKInnerObjIDSet || objID == common.BKInnerObjIDModule {
+		cond[common.BKAppIDField] = group.AppID
+	}
+	query := &meta.QueryCondition{
+		Condition: cond,
+		Filter:    group.Filter,
+		Fields:    option.Fields,
+		Page:      option.Page,
+	}
+	instances, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, objID, query)
+	if err != nil {
+		blog.Errorf("execute dynamic group %s, read %s instances failed, err: %v, rid: %s", group.ID, objID, err, lgc.rid)
+		return nil, lgc.ccErr.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !instances.Result {
+		blog.Errorf("execute dynamic group %s, read %s instances failed, err code: %d, err msg: %s, rid: %s",
+			group.ID, objID, instances.Code, instances.ErrMsg, lgc.rid)
+		return nil, errors.New(instances.Code, instances.ErrMsg)
+	}
+	return &meta.DynamicGroupResult{Count: instances.Data.Count, Info: instances.Data.Info}, nil
+}
+
+func (lgc *Logics) executeHostCustomQuery(ctx context.Context, group *meta.UserConfigMeta,
+	option *meta.ExecuteDynamicGroupOption) (*meta.DynamicGroupResult, errors.CCErrorCoder) {
+
+	input := meta.HostCommonSearch{}
+	if err := json.Unmarshal([]byte(group.Info), &input); err != nil {
+		blog.Errorf("execute host custom query %s, unmarshal info failed, err: %v, rid: %s", group.ID, err, lgc.rid)
+		return nil, lgc.ccErr.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	input.AppID = group.AppID
+	input.Page = option.Page
+
+	hosts, err := lgc.SearchHost(ctx, &input, false)
+	if err != nil {
+		blog.Errorf("execute host custom query %s, search host failed, err: %v, rid: %s", group.ID, err, lgc.rid)
+		return nil, lgc.ccErr.CCErrorf(common.CCErrGetUserCustomQueryDetailFailed, err.Error())
+	}
+	return &meta.DynamicGroupResult{Count: hosts.Count, Info: hosts.Info}, nil
+}
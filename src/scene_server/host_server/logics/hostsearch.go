@@ -673,6 +673,16 @@ func (sh *searchHost) searchByHostConds() errors.CCError {
 		return err
 	}
 
+	if len(sh.hostSearchParam.HostPropertyQuery) != 0 {
+		queryFilter, err := sh.lgc.HostPropertyQueryToMgo(sh.ctx, "host_property_query", sh.hostSearchParam.HostPropertyQuery)
+		if err != nil {
+			return err
+		}
+		condition = map[string]interface{}{
+			common.BKDBAND: []map[string]interface{}{condition, queryFilter},
+		}
+	}
+
 	query := &metadata.QueryInput{
 		Condition: condition,
 		Start:     sh.hostSearchParam.Page.Start,
@@ -780,7 +790,8 @@ func (sh *searchHost) appendHostTopoConds() errors.CCError {
 	sh.totalHostCnt = len(respHostIDInfo.Data.IDArr)
 	// 当有根据主机实例内容查询的时候的时候，无法在程序中完成分页
 	hasHostCond := false
-	if len(sh.hostSearchParam.Ip.Data) > 0 || len(sh.conds.hostCond.Condition) > 0 {
+	if len(sh.hostSearchParam.Ip.Data) > 0 || len(sh.conds.hostCond.Condition) > 0 ||
+		len(sh.hostSearchParam.HostPropertyQuery) > 0 {
 		hasHostCond = true
 	}
 	if !hasHostCond && sh.hostSearchParam.Page.Limit > 0 {
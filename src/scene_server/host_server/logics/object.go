@@ -21,6 +21,7 @@ import (
 	"configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
 	parse "configcenter/src/common/paraparse"
+	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/util"
 	hutil "configcenter/src/scene_server/host_server/util"
 )
@@ -44,6 +45,46 @@ func (lgc *Logics) GetObjectAttributes(ctx context.Context, ownerID, objID strin
 	return result.Data.Info, nil
 }
 
+// ParseHostPropertyQuery parse the text query of host properties, the fields and values in
+// the query is validated with the host attributes.
+func (lgc *Logics) ParseHostPropertyQuery(ctx context.Context, key, query string) (*querybuilder.QueryFilter, errors.CCErrorCoder) {
+	filter, _, err := lgc.parseHostPropertyQuery(ctx, key, query)
+	return filter, err
+}
+
+// HostPropertyQueryToMgo parse the text query of host properties, and convert it to mongo filter.
+func (lgc *Logics) HostPropertyQueryToMgo(ctx context.Context, key, query string) (map[string]interface{}, errors.CCErrorCoder) {
+	filter, fieldTypes, ccErr := lgc.parseHostPropertyQuery(ctx, key, query)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+
+	mgoFilter, errKey, err := filter.ToMgoWithFieldTypes(fieldTypes)
+	if err != nil {
+		blog.Errorf("convert host property query to mongo filter failed, query: %s, key: %s, err: %v, rid: %s", query,
+			errKey, err, lgc.rid)
+		return nil, lgc.ccErr.CCErrorf(common.CCErrCommQueryInvalid, key, err.Error())
+	}
+	return mgoFilter, nil
+}
+
+func (lgc *Logics) parseHostPropertyQuery(ctx context.Context, key, query string) (*querybuilder.QueryFilter,
+	querybuilder.FieldTypes, errors.CCErrorCoder) {
+
+	attributes, err := lgc.GetObjectAttributes(ctx, util.GetOwnerID(lgc.header), common.BKInnerObjIDHost, meta.BasePage{})
+	if err != nil {
+		return nil, nil, errors.NewFromStdError(err, common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	fieldTypes := meta.GetQueryFieldTypes(attributes)
+	filter, parseErr := querybuilder.ParseQuery(query, fieldTypes)
+	if parseErr != nil {
+		blog.Errorf("parse host property query failed, query: %s, err: %v, rid: %s", query, parseErr, lgc.rid)
+		return nil, nil, lgc.ccErr.CCErrorf(common.CCErrCommQueryInvalid, key, parseErr.Error())
+	}
+	return filter, fieldTypes, nil
+}
+
 func (lgc *Logics) GetTopoIDByName(ctx context.Context, c *meta.HostToAppModule) (int64, int64, int64, errors.CCError) {
 	if "" == c.AppName || "" == c.SetName || "" == c.ModuleName {
 		return 0, 0, 0, nil
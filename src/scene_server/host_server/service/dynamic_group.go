@@ -0,0 +1,201 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/host_server/logics"
+
+	"github.com/emicklei/go-restful"
+)
+
+// CreateDynamicGroup create a dynamic group of the model's instances, which is saved with the user custom query,
+// the dynamic group is registered to auth center as the same resource type as the user custom query.
+func (s *Service) CreateDynamicGroup(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	option := new(meta.DynamicGroupOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(option); err != nil {
+		blog.Errorf("create dynamic group failed, decode body failed, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if len(option.Name) == 0 {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "name")})
+		return
+	}
+	if option.AppID <= 0 {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, common.BKAppIDField)})
+		return
+	}
+	if !meta.IsDynamicGroupObject(option.ObjID) {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, common.BKObjIDField)})
+		return
+	}
+	if option.Filter == nil && len(option.Query) == 0 {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "filter")})
+		return
+	}
+
+	if err := srvData.lgc.ParseDynamicGroupFilter(srvData.ctx, option); err != nil {
+		_ = resp.WriteError(http.StatusOK, &meta.RespError{Msg: err})
+		return
+	}
+
+	group := &meta.UserConfig{
+		Name:       option.Name,
+		AppID:      option.AppID,
+		ObjID:      option.ObjID,
+		Filter:     option.Filter,
+		CreateUser: srvData.user,
+	}
+
+	var result *meta.IDResult
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(srvData.ctx, s.EnableTxn, srvData.header, func() error {
+		var err error
+		result, err = s.CoreAPI.CoreService().Host().AddUserConfig(srvData.ctx, srvData.header, group)
+		if err != nil {
+			blog.Errorf("create dynamic group failed, err: %v, input: %+v, rid: %s", err, option, srvData.rid)
+			return srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("create dynamic group failed, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code,
+				result.ErrMsg, option, srvData.rid)
+			return srvData.ccErr.New(result.Code, result.ErrMsg)
+		}
+		if err := s.AuthManager.RegisterDynamicGroupByID(srvData.ctx, srvData.header, result.Data.ID); err != nil {
+			blog.Errorf("create dynamic group, but register to auth center failed, err: %v, rid: %s", err, srvData.rid)
+			return srvData.ccErr.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		_ = resp.WriteError(http.StatusOK, &meta.RespError{Msg: txnErr})
+		return
+	}
+	_ = resp.WriteEntity(meta.NewSuccessResp(result.Data))
+}
+
+// UpdateDynamicGroup update the name or the filter of a dynamic group, the object of the dynamic group
+// can not be changed. an old host custom query can be updated to a dynamic group of hosts with a filter.
+func (s *Service) UpdateDynamicGroup(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	bizID, err := util.GetInt64ByInterface(req.PathParameter("bk_biz_id"))
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedInt, common.BKAppIDField)})
+		return
+	}
+	id := req.PathParameter("id")
+
+	option := new(meta.DynamicGroupOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(option); err != nil {
+		blog.Errorf("update dynamic group failed, decode body failed, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	group, ccErr := srvData.lgc.GetDynamicGroup(srvData.ctx, bizID, id)
+	if ccErr != nil {
+		_ = resp.WriteError(http.StatusOK, &meta.RespError{Msg: ccErr})
+		return
+	}
+	option.ObjID = logics.DynamicGroupObjID(group)
+	if err := srvData.lgc.ParseDynamicGroupFilter(srvData.ctx, option); err != nil {
+		_ = resp.WriteError(http.StatusOK, &meta.RespError{Msg: err})
+		return
+	}
+
+	params := map[string]interface{}{
+		"modify_user":        srvData.user,
+		common.LastTimeField: time.Now().UTC(),
+	}
+	if len(option.Name) != 0 {
+		params["name"] = option.Name
+	}
+	if option.Filter != nil {
+		params[common.BKObjIDField] = option.ObjID
+		params["filter"] = option.Filter
+	}
+
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(srvData.ctx, s.EnableTxn, srvData.header, func() error {
+		result, err := s.CoreAPI.CoreService().Host().UpdateUserConfig(srvData.ctx, req.PathParameter("bk_biz_id"), id,
+			srvData.header, params)
+		if err != nil {
+			blog.Errorf("update dynamic group %s failed, err: %v, rid: %s", id, err, srvData.rid)
+			return srvData.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("update dynamic group %s failed, err code: %d, err msg: %s, rid: %s", id, result.Code,
+				result.ErrMsg, srvData.rid)
+			return srvData.ccErr.New(result.Code, result.ErrMsg)
+		}
+		if err := s.AuthManager.UpdateRegisteredDynamicGroupByID(srvData.ctx, srvData.header, id); err != nil {
+			blog.Errorf("update dynamic group %s, but update it in auth center failed, err: %v, rid: %s", id, err, srvData.rid)
+			return srvData.ccErr.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		_ = resp.WriteError(http.StatusOK, &meta.RespError{Msg: txnErr})
+		return
+	}
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// ExecuteDynamicGroup get the instances which match the dynamic group's filter at this moment with paging.
+func (s *Service) ExecuteDynamicGroup(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	bizID, err := util.GetInt64ByInterface(req.PathParameter("bk_biz_id"))
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedInt, common.BKAppIDField)})
+		return
+	}
+	id := req.PathParameter("id")
+
+	option := new(meta.ExecuteDynamicGroupOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(option); err != nil {
+		blog.Errorf("execute dynamic group failed, decode body failed, err: %v, rid: %s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if key, err := option.Validate(); err != nil {
+		blog.Errorf("execute dynamic group failed, option is invalid, key: %s, err: %v, rid: %s", key, err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, key)})
+		return
+	}
+
+	group, ccErr := srvData.lgc.GetDynamicGroup(srvData.ctx, bizID, id)
+	if ccErr != nil {
+		_ = resp.WriteError(http.StatusOK, &meta.RespError{Msg: ccErr})
+		return
+	}
+
+	result, ccErr := srvData.lgc.ExecuteDynamicGroup(srvData.ctx, group, option)
+	if ccErr != nil {
+		_ = resp.WriteError(http.StatusOK, &meta.RespError{Msg: ccErr})
+		return
+	}
+	_ = resp.WriteEntity(meta.NewSuccessResp(result))
+}
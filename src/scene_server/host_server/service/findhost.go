@@ -25,6 +25,7 @@ import (
 	"configcenter/src/common/mapstruct"
 	meta "configcenter/src/common/metadata"
 	parse "configcenter/src/common/paraparse"
+	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/util"
 
 	"github.com/emicklei/go-restful"
@@ -588,6 +589,23 @@ func (s *Service) listBizHosts(header http.Header, bizID int64, parameter meta.L
 		setIDList = append(setIDList, parameter.SetIDs...)
 	}
 
+	if len(parameter.HostPropertyQuery) != 0 {
+		filter, err := srvData.lgc.ParseHostPropertyQuery(ctx, "host_property_query", parameter.HostPropertyQuery)
+		if err != nil {
+			return nil, err
+		}
+		parameter.HostPropertyFilter = filter
+	}
+
+	// the dynamic group only narrows the hosts of the business down, so no more permission is required.
+	if len(parameter.DynamicGroupID) != 0 {
+		groupFilter, err := srvData.lgc.GetDynamicGroupFilter(ctx, bizID, parameter.DynamicGroupID, common.BKInnerObjIDHost)
+		if err != nil {
+			return nil, err
+		}
+		parameter.HostPropertyFilter = querybuilder.AndFilters(parameter.HostPropertyFilter, groupFilter)
+	}
+
 	option := &meta.ListHosts{
 		BizID:              bizID,
 		SetIDs:             setIDList,
@@ -625,6 +643,15 @@ func (s *Service) ListHostsWithNoBiz(req *restful.Request, resp *restful.Respons
 		return
 	}
 
+	if len(parameter.HostPropertyQuery) != 0 {
+		filter, err := srvData.lgc.ParseHostPropertyQuery(ctx, "host_property_query", parameter.HostPropertyQuery)
+		if err != nil {
+			_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+			return
+		}
+		parameter.HostPropertyFilter = filter
+	}
+
 	option := &meta.ListHosts{
 		HostPropertyFilter: parameter.HostPropertyFilter,
 		Fields:             parameter.Fields,
@@ -677,6 +704,15 @@ func (s *Service) ListBizHostsTopo(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	if len(parameter.HostPropertyQuery) != 0 {
+		filter, err := srvData.lgc.ParseHostPropertyQuery(ctx, "host_property_query", parameter.HostPropertyQuery)
+		if err != nil {
+			_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+			return
+		}
+		parameter.HostPropertyFilter = filter
+	}
+
 	// search all hosts
 	option := &meta.ListHosts{
 		BizID:              bizID,
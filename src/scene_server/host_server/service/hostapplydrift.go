@@ -0,0 +1,487 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstruct"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/common/watch"
+
+	"github.com/emicklei/go-restful"
+	"gopkg.in/redis.v5"
+)
+
+const (
+	// hostApplyDriftCursorKey is the cursor of the last host event that the drift reconciler has handled
+	hostApplyDriftCursorKey = common.BKCacheKeyV3Prefix + "host_apply:drift_cursor"
+	// hostApplyDriftRetryInterval is the interval that the drift reconciler waits before the next round
+	// when it's not master or an error occurred
+	hostApplyDriftRetryInterval = 5 * time.Second
+)
+
+func (s *Service) GetHostApplyDriftConfig(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+	rid := srvData.rid
+
+	bizIDStr := req.PathParameter(common.BKAppIDField)
+	bizID, err := strconv.ParseInt(bizIDStr, 10, 64)
+	if err != nil {
+		blog.Errorf("GetHostApplyDriftConfig failed, parse biz id failed, bizIDStr: %s, err: %v, rid: %s", bizIDStr, err, rid)
+		result := &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField)}
+		_ = resp.WriteError(http.StatusBadRequest, result)
+		return
+	}
+
+	config, ccErr := s.CoreAPI.CoreService().HostApplyRule().GetHostApplyDriftConfig(srvData.ctx, srvData.header, bizID)
+	if ccErr != nil {
+		blog.Errorf("GetHostApplyDriftConfig failed, core service GetHostApplyDriftConfig failed, bizID: %d, err: %v, rid: %s", bizID, ccErr, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: ccErr})
+		return
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(config))
+}
+
+func (s *Service) UpdateHostApplyDriftConfig(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+	rid := srvData.rid
+
+	bizIDStr := req.PathParameter(common.BKAppIDField)
+	bizID, err := strconv.ParseInt(bizIDStr, 10, 64)
+	if err != nil {
+		blog.Errorf("UpdateHostApplyDriftConfig failed, parse biz id failed, bizIDStr: %s, err: %v, rid: %s", bizIDStr, err, rid)
+		result := &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField)}
+		_ = resp.WriteError(http.StatusBadRequest, result)
+		return
+	}
+
+	option := metadata.UpdateHostApplyDriftConfigOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); err != nil {
+		blog.Errorf("UpdateHostApplyDriftConfig failed, decode request body failed, err: %v, rid: %s", err, rid)
+		result := &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)}
+		_ = resp.WriteError(http.StatusBadRequest, result)
+		return
+	}
+
+	config, ccErr := s.CoreAPI.CoreService().HostApplyRule().UpdateHostApplyDriftConfig(srvData.ctx, srvData.header, bizID, option)
+	if ccErr != nil {
+		blog.Errorf("UpdateHostApplyDriftConfig failed, core service UpdateHostApplyDriftConfig failed, bizID: %d, option: %+v, err: %v, rid: %s", bizID, option, ccErr, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: ccErr})
+		return
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(config))
+}
+
+// ListHostApplyDrift returns the non-compliant hosts of the business found by the drift reconciler
+func (s *Service) ListHostApplyDrift(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+	rid := srvData.rid
+
+	bizIDStr := req.PathParameter(common.BKAppIDField)
+	bizID, err := strconv.ParseInt(bizIDStr, 10, 64)
+	if err != nil {
+		blog.Errorf("ListHostApplyDrift failed, parse biz id failed, bizIDStr: %s, err: %v, rid: %s", bizIDStr, err, rid)
+		result := &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField)}
+		_ = resp.WriteError(http.StatusBadRequest, result)
+		return
+	}
+
+	option := metadata.ListHostApplyDriftOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); err != nil {
+		blog.Errorf("ListHostApplyDrift failed, decode request body failed, err: %v, rid: %s", err, rid)
+		result := &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)}
+		_ = resp.WriteError(http.StatusBadRequest, result)
+		return
+	}
+
+	drifts, ccErr := s.CoreAPI.CoreService().HostApplyRule().ListHostApplyDrift(srvData.ctx, srvData.header, bizID, option)
+	if ccErr != nil {
+		blog.Errorf("ListHostApplyDrift failed, core service ListHostApplyDrift failed, bizID: %d, option: %+v, err: %v, rid: %s", bizID, option, ccErr, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: ccErr})
+		return
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(drifts))
+}
+
+// ReconcileHostApplyDrift watches the host events, and checks whether the changed hosts still comply with
+// the host apply rules of their modules. The drifted hosts are corrected or recorded as non-compliant hosts
+// according to the drift mode of their business. Only the master host server does the reconciliation.
+func (s *Service) ReconcileHostApplyDrift(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if !s.Engine.ServiceManageInterface.IsMaster() {
+			time.Sleep(hostApplyDriftRetryInterval)
+			continue
+		}
+
+		if err := s.reconcileHostApplyDriftOnce(ctx); err != nil {
+			time.Sleep(hostApplyDriftRetryInterval)
+		}
+	}
+}
+
+func newHostApplyDriftHeader(ownerID string) http.Header {
+	header := make(http.Header)
+	header.Set(common.BKHTTPOwnerID, ownerID)
+	header.Set(common.BKHTTPHeaderUser, common.CCSystemOperatorUserName)
+	header.Set(common.BKHTTPCCRequestID, util.GenerateRID())
+	return header
+}
+
+// reconcileHostApplyDriftOnce handles a batch of host events, the cursor is saved only after the hosts are
+// reconciled, so that the events are watched again if the reconciliation failed.
+func (s *Service) reconcileHostApplyDriftOnce(ctx context.Context) error {
+	header := newHostApplyDriftHeader(common.BKSuperOwnerID)
+	rid := util.GetHTTPCCRequestID(header)
+
+	cursor, err := s.CacheDB.Get(hostApplyDriftCursorKey).Result()
+	if err != nil && err != redis.Nil {
+		blog.Errorf("reconcile host apply drift, get watch cursor failed, err: %v, rid: %s", err, rid)
+		return err
+	}
+
+	opts := &watch.WatchEventOptions{
+		EventTypes: []watch.EventType{watch.Create, watch.Update, watch.Delete},
+		Fields:     []string{common.BKHostIDField},
+		Resource:   watch.Host,
+		Cursor:     cursor,
+	}
+	result, err := s.CoreAPI.EventServer().Watch(ctx, header, opts)
+	if err != nil {
+		blog.Errorf("reconcile host apply drift, watch host events failed, cursor: %s, err: %v, rid: %s", cursor, err, rid)
+		return err
+	}
+	if !result.Result || result.Code != 0 {
+		blog.Errorf("reconcile host apply drift, watch host events failed, cursor: %s, code: %d, err: %s, rid: %s",
+			cursor, result.Code, result.ErrMsg, rid)
+		// the events after an expired cursor are lost, watch from now on. the cursor is kept on the other errors,
+		// so that the events are watched again when retry.
+		if result.Code == common.CCErrEventWatchCursorNotExist {
+			if err := s.CacheDB.Del(hostApplyDriftCursorKey).Err(); err != nil {
+				blog.Errorf("reconcile host apply drift, reset watch cursor failed, err: %v, rid: %s", err, rid)
+			}
+		}
+		return errors.New(result.Code, result.ErrMsg)
+	}
+	if len(result.Data.Events) == 0 {
+		return nil
+	}
+
+	// the events are not matched if not watched, but the cursor of the last scanned event is still returned,
+	// it's saved so that the scanned events are not watched again.
+	if result.Data.Watched {
+		hostIDs := make([]int64, 0)
+		for _, event := range result.Data.Events {
+			host := struct {
+				HostID int64 `json:"bk_host_id"`
+			}{}
+			if err := json.Unmarshal(event.Detail, &host); err != nil {
+				blog.Errorf("reconcile host apply drift, decode host event failed, detail: %s, err: %v, rid: %s", event.Detail, err, rid)
+				continue
+			}
+			if host.HostID != 0 {
+				hostIDs = append(hostIDs, host.HostID)
+			}
+		}
+
+		if len(hostIDs) > 0 {
+			if err := s.reconcileHostApplyDrift(ctx, header, util.IntArrayUnique(hostIDs)); err != nil {
+				return err
+			}
+		}
+	}
+
+	lastCursor := result.Data.Events[len(result.Data.Events)-1].Cursor
+	if len(lastCursor) == 0 {
+		return nil
+	}
+	if err := s.CacheDB.Set(hostApplyDriftCursorKey, lastCursor, 0).Err(); err != nil {
+		blog.Errorf("reconcile host apply drift, save watch cursor failed, cursor: %s, err: %v, rid: %s", lastCursor, err, rid)
+		return err
+	}
+	return nil
+}
+
+// hostApplyDriftScope is the hosts of a business that belongs to the modules with host apply enabled
+type hostApplyDriftScope struct {
+	ownerID   string
+	bizID     int64
+	hostIDs   []int64
+	moduleIDs []int64
+}
+
+func (s *Service) reconcileHostApplyDrift(ctx context.Context, header http.Header, hostIDs []int64) error {
+	rid := util.GetHTTPCCRequestID(header)
+
+	relationRequest := &metadata.HostModuleRelationRequest{
+		HostIDArr: hostIDs,
+		Page: metadata.BasePage{
+			Limit: common.BKNoLimit,
+		},
+		Fields: []string{common.BKAppIDField, common.BKModuleIDField, common.BKHostIDField, common.BkSupplierAccount},
+	}
+	relations, err := s.CoreAPI.CoreService().Host().GetHostModuleRelation(ctx, header, relationRequest)
+	if err != nil {
+		blog.Errorf("reconcile host apply drift, get host module relation failed, hostIDs: %v, err: %v, rid: %s", hostIDs, err, rid)
+		return err
+	}
+	if ccErr := relations.CCError(); ccErr != nil {
+		blog.Errorf("reconcile host apply drift, get host module relation failed, hostIDs: %v, err: %v, rid: %s", hostIDs, ccErr, rid)
+		return ccErr
+	}
+
+	moduleIDs := make([]int64, 0)
+	for _, relation := range relations.Data.Info {
+		moduleIDs = append(moduleIDs, relation.ModuleID)
+	}
+	enabledModules, err := s.getHostApplyEnabledModules(ctx, header, util.IntArrayUnique(moduleIDs))
+	if err != nil {
+		return err
+	}
+
+	scopes := make(map[int64]*hostApplyDriftScope)
+	inScopeHosts := make(map[int64]bool)
+	for _, relation := range relations.Data.Info {
+		if !enabledModules[relation.ModuleID] {
+			continue
+		}
+		scope, exist := scopes[relation.AppID]
+		if !exist {
+			scope = &hostApplyDriftScope{ownerID: relation.OwnerID, bizID: relation.AppID}
+			scopes[relation.AppID] = scope
+		}
+		if !inScopeHosts[relation.HostID] {
+			scope.hostIDs = append(scope.hostIDs, relation.HostID)
+			inScopeHosts[relation.HostID] = true
+		}
+		scope.moduleIDs = append(scope.moduleIDs, relation.ModuleID)
+	}
+
+	for _, scope := range scopes {
+		if err := s.reconcileBizHostApplyDrift(ctx, scope); err != nil {
+			return err
+		}
+	}
+
+	// the hosts that are not under any module with host apply enabled are always compliant
+	compliantHostIDs := make([]int64, 0)
+	for _, hostID := range hostIDs {
+		if !inScopeHosts[hostID] {
+			compliantHostIDs = append(compliantHostIDs, hostID)
+		}
+	}
+	if len(compliantHostIDs) == 0 {
+		return nil
+	}
+	option := metadata.SaveHostApplyDriftOption{CompliantHostIDs: compliantHostIDs}
+	if ccErr := s.CoreAPI.CoreService().HostApplyRule().SaveHostApplyDrift(ctx, header, option); ccErr != nil {
+		blog.Errorf("reconcile host apply drift, remove drift of compliant hosts failed, hostIDs: %v, err: %v, rid: %s", compliantHostIDs, ccErr, rid)
+		return ccErr
+	}
+	return nil
+}
+
+func (s *Service) getHostApplyEnabledModules(ctx context.Context, header http.Header, moduleIDs []int64) (map[int64]bool, error) {
+	rid := util.GetHTTPCCRequestID(header)
+	enabledModules := make(map[int64]bool)
+	if len(moduleIDs) == 0 {
+		return enabledModules, nil
+	}
+
+	moduleFilter := &metadata.QueryCondition{
+		Fields: []string{common.BKModuleIDField},
+		Condition: map[string]interface{}{
+			common.BKModuleIDField: map[string]interface{}{
+				common.BKDBIN: moduleIDs,
+			},
+			common.HostApplyEnabledField: true,
+		},
+	}
+	moduleResult, err := s.CoreAPI.CoreService().Instance().ReadInstance(ctx, header, common.BKInnerObjIDModule, moduleFilter)
+	if err != nil {
+		blog.ErrorJSON("reconcile host apply drift, read modules failed, filter: %s, err: %s, rid: %s", moduleFilter, err.Error(), rid)
+		return nil, err
+	}
+	if ccErr := moduleResult.CCError(); ccErr != nil {
+		blog.ErrorJSON("reconcile host apply drift, read modules failed, filter: %s, result: %s, rid: %s", moduleFilter, moduleResult, rid)
+		return nil, ccErr
+	}
+	for _, item := range moduleResult.Data.Info {
+		module := metadata.ModuleInst{}
+		if err := mapstruct.Decode2Struct(item, &module); err != nil {
+			blog.ErrorJSON("reconcile host apply drift, parse module failed, module: %s, err: %s, rid: %s", item, err.Error(), rid)
+			return nil, err
+		}
+		enabledModules[module.ModuleID] = true
+	}
+	return enabledModules, nil
+}
+
+// reconcileBizHostApplyDrift regenerates the apply plan of the hosts, and corrects or records the drifted
+// hosts according to the drift mode of the business.
+func (s *Service) reconcileBizHostApplyDrift(ctx context.Context, scope *hostApplyDriftScope) error {
+	srvData := s.newSrvComm(newHostApplyDriftHeader(scope.ownerID))
+	rid := srvData.rid
+	bizID := scope.bizID
+
+	config, ccErr := s.CoreAPI.CoreService().HostApplyRule().GetHostApplyDriftConfig(ctx, srvData.header, bizID)
+	if ccErr != nil {
+		blog.Errorf("reconcile host apply drift, get drift config failed, bizID: %d, err: %v, rid: %s", bizID, ccErr, rid)
+		return ccErr
+	}
+
+	planRequest := metadata.HostApplyPlanRequest{
+		ModuleIDs: util.IntArrayUnique(scope.moduleIDs),
+		HostIDs:   scope.hostIDs,
+	}
+	planResult, ccErr := s.generateApplyPlan(srvData, bizID, planRequest)
+	if ccErr != nil {
+		blog.ErrorJSON("reconcile host apply drift, generate apply plan failed, bizID: %s, request: %s, err: %s, rid: %s", bizID, planRequest, ccErr, rid)
+		return ccErr
+	}
+
+	bizName, err := auditlog.NewAudit(s.CoreAPI, srvData.header).GetInstNameByID(ctx, common.BKInnerObjIDApp, bizID)
+	if err != nil {
+		blog.Errorf("reconcile host apply drift, get business name failed, bizID: %d, err: %v, rid: %s", bizID, err, rid)
+		return err
+	}
+
+	saveOption := metadata.SaveHostApplyDriftOption{
+		Drifts:           make([]metadata.HostApplyDrift, 0),
+		CompliantHostIDs: make([]int64, 0),
+	}
+	auditLogs := make([]metadata.AuditLog, 0)
+	plannedHosts := make(map[int64]bool)
+	for _, plan := range planResult.Plans {
+		plannedHosts[plan.HostID] = true
+		if err := plan.GetError(); err != nil {
+			blog.Errorf("reconcile host apply drift, apply plan of host %d has error, bizID: %d, err: %v, rid: %s", plan.HostID, bizID, err, rid)
+			continue
+		}
+
+		drift := metadata.NewHostApplyDrift(bizID, plan)
+		if len(drift.Fields) == 0 {
+			saveOption.CompliantHostIDs = append(saveOption.CompliantHostIDs, plan.HostID)
+			continue
+		}
+
+		if config.Mode == metadata.HostApplyDriftModeAutoCorrect {
+			if ccErr := s.correctHostApplyDrift(srvData, drift); ccErr == nil {
+				saveOption.CompliantHostIDs = append(saveOption.CompliantHostIDs, plan.HostID)
+				auditLogs = append(auditLogs, newHostApplyDriftAuditLog(bizName, plan, drift, metadata.AuditUpdate))
+				continue
+			}
+		}
+
+		saveOption.Drifts = append(saveOption.Drifts, drift)
+		auditLogs = append(auditLogs, newHostApplyDriftAuditLog(bizName, plan, drift, metadata.AuditHostApplyDrift))
+	}
+
+	// the hosts without apply plan have no rules to comply with
+	for _, hostID := range scope.hostIDs {
+		if !plannedHosts[hostID] {
+			saveOption.CompliantHostIDs = append(saveOption.CompliantHostIDs, hostID)
+		}
+	}
+
+	if ccErr := s.CoreAPI.CoreService().HostApplyRule().SaveHostApplyDrift(ctx, srvData.header, saveOption); ccErr != nil {
+		blog.ErrorJSON("reconcile host apply drift, save drift failed, bizID: %s, option: %s, err: %s, rid: %s", bizID, saveOption, ccErr, rid)
+		return ccErr
+	}
+
+	if len(auditLogs) == 0 {
+		return nil
+	}
+	auditResult, err := s.CoreAPI.CoreService().Audit().SaveAuditLog(ctx, srvData.header, auditLogs...)
+	if err != nil {
+		blog.Errorf("reconcile host apply drift, save audit log failed, bizID: %d, err: %v, rid: %s", bizID, err, rid)
+		return err
+	}
+	if ccErr := auditResult.CCError(); ccErr != nil {
+		blog.Errorf("reconcile host apply drift, save audit log failed, bizID: %d, err: %v, rid: %s", bizID, ccErr, rid)
+		return ccErr
+	}
+	return nil
+}
+
+// correctHostApplyDrift updates the drifted fields of the host with the values expected by the rules
+func (s *Service) correctHostApplyDrift(srvData *srvComm, drift metadata.HostApplyDrift) errors.CCErrorCoder {
+	data := make(map[string]interface{})
+	for _, field := range drift.Fields {
+		data[field.PropertyID] = field.ExpectValue
+	}
+	updateOption := &metadata.UpdateOption{
+		Data: data,
+		Condition: map[string]interface{}{
+			common.BKHostIDField: drift.HostID,
+		},
+	}
+	updateResult, err := s.CoreAPI.CoreService().Instance().UpdateInstance(srvData.ctx, srvData.header, common.BKInnerObjIDHost, updateOption)
+	if err != nil {
+		blog.ErrorJSON("correct host apply drift, update host failed, option: %s, err: %s, rid: %s", updateOption, err.Error(), srvData.rid)
+		return srvData.ccErr.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if ccErr := updateResult.CCError(); ccErr != nil {
+		blog.ErrorJSON("correct host apply drift, update host failed, option: %s, result: %s, rid: %s", updateOption, updateResult, srvData.rid)
+		return ccErr
+	}
+	return nil
+}
+
+// newHostApplyDriftAuditLog records the drifted fields, the pre data is the actual values of the host,
+// and the current data is the values expected by the rules.
+func newHostApplyDriftAuditLog(bizName string, plan metadata.OneHostApplyPlan, drift metadata.HostApplyDrift,
+	action metadata.ActionType) metadata.AuditLog {
+
+	content := &metadata.BasicContent{
+		PreData: make(map[string]interface{}),
+		CurData: make(map[string]interface{}),
+	}
+	for _, field := range drift.Fields {
+		content.PreData[field.PropertyID] = field.ActualValue
+		content.CurData[field.PropertyID] = field.ExpectValue
+	}
+	hostIP, _ := plan.ExpectHost[common.BKHostInnerIPField].(string)
+
+	return metadata.AuditLog{
+		AuditType:    metadata.HostType,
+		ResourceType: metadata.HostRes,
+		Action:       action,
+		OperateFrom:  metadata.FromHostApply,
+		OperationDetail: &metadata.InstanceOpDetail{
+			BasicOpDetail: metadata.BasicOpDetail{
+				BusinessID:   drift.BizID,
+				BusinessName: bizName,
+				ResourceID:   drift.HostID,
+				ResourceName: hostIP,
+				Details:      content,
+			},
+			ModelID: common.BKInnerObjIDHost,
+		},
+	}
+}
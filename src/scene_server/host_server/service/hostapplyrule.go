@@ -212,7 +212,7 @@ func (s *Service) ListHostApplyRule(req *restful.Request, resp *restful.Response
 		return
 	}
 
-	if len(option.ModuleIDs) == 0 {
+	if len(option.ModuleIDs) == 0 && len(option.ServiceTemplateIDs) == 0 && len(option.SetTemplateIDs) == 0 {
 		blog.Errorf("ListHostApplyRule failed, parameter bk_module_ids empty, rid:%s", err, rid)
 		result := &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, "bk_module_ids")}
 		_ = resp.WriteError(http.StatusBadRequest, result)
@@ -392,6 +392,7 @@ func (s *Service) generateApplyPlan(srvData *srvComm, bizID int64, planRequest m
 		Page: metadata.BasePage{
 			Limit: common.BKNoLimit,
 		},
+		WithInherited: true,
 	}
 	rules, ccErr := s.CoreAPI.CoreService().HostApplyRule().ListHostApplyRule(srvData.ctx, srvData.header, bizID, ruleOption)
 	if ccErr != nil {
@@ -405,7 +406,17 @@ func (s *Service) generateApplyPlan(srvData *srvComm, bizID int64, planRequest m
 		for _, item := range planRequest.AdditionalRules {
 			for index, rule := range rules.Info {
 				if item.ModuleID == rule.ModuleID && item.AttributeID == rule.AttributeID {
+					// the inherited rule is overridden by a new module rule
+					if rule.Level != metadata.HostApplyRuleLevelModule {
+						rules.Info[index].ID = 0
+						rules.Info[index].ServiceTemplateID = 0
+						rules.Info[index].SetTemplateID = 0
+						rules.Info[index].Level = metadata.HostApplyRuleLevelModule
+					}
 					rules.Info[index].PropertyValue = item.PropertyValue
+					rules.Info[index].ValueTemplate = item.ValueTemplate
+					rules.Info[index].Condition = item.Condition
+					rules.Info[index].Priority = item.Priority
 					continue OuterLoop
 				}
 			}
@@ -414,7 +425,11 @@ func (s *Service) generateApplyPlan(srvData *srvComm, bizID int64, planRequest m
 				BizID:           bizID,
 				ModuleID:        item.ModuleID,
 				AttributeID:     item.AttributeID,
+				Level:           metadata.HostApplyRuleLevelModule,
 				PropertyValue:   item.PropertyValue,
+				ValueTemplate:   item.ValueTemplate,
+				Condition:       item.Condition,
+				Priority:        item.Priority,
 				Creator:         srvData.user,
 				Modifier:        srvData.user,
 				CreateTime:      now,
@@ -449,7 +464,7 @@ func (s *Service) generateApplyPlan(srvData *srvComm, bizID int64, planRequest m
 	}
 
 	planResult, ccErr = s.CoreAPI.CoreService().HostApplyRule().GenerateApplyPlan(srvData.ctx, srvData.header, bizID, planOption)
-	if err != nil {
+	if ccErr != nil {
 		blog.ErrorJSON("generateApplyPlan failed, core service GenerateApplyPlan failed, bizID: %s, option: %s, err: %s, rid: %s", bizID, planOption, ccErr.Error(), rid)
 		return planResult, ccErr
 	}
@@ -508,13 +523,21 @@ func (s *Service) RunHostApplyRule(req *restful.Request, resp *restful.Response)
 			return ccErr
 		}
 
-		// save rules to database
+		// save rules to database, the inherited rules are saved with their service template or set template
 		rulesOption := make([]metadata.CreateOrUpdateApplyRuleOption, 0)
+		moduleRuleIDs := make([]int64, 0)
 		for _, rule := range planResult.Rules {
+			if rule.Level != metadata.HostApplyRuleLevelModule {
+				continue
+			}
+			moduleRuleIDs = append(moduleRuleIDs, rule.ID)
 			rulesOption = append(rulesOption, metadata.CreateOrUpdateApplyRuleOption{
 				AttributeID:   rule.AttributeID,
 				ModuleID:      rule.ModuleID,
 				PropertyValue: rule.PropertyValue,
+				ValueTemplate: rule.ValueTemplate,
+				Condition:     rule.Condition,
+				Priority:      rule.Priority,
 			})
 		}
 		saveRuleOption := metadata.BatchCreateOrUpdateApplyRuleOption{
@@ -525,10 +548,16 @@ func (s *Service) RunHostApplyRule(req *restful.Request, resp *restful.Response)
 			return ccErr
 		}
 
-		// delete rules
-		if len(planRequest.RemoveRuleIDs) > 0 {
+		// delete rules, only the module's own rules can be removed here
+		removeRuleIDs := make([]int64, 0)
+		for _, ruleID := range planRequest.RemoveRuleIDs {
+			if util.InArray(ruleID, moduleRuleIDs) {
+				removeRuleIDs = append(removeRuleIDs, ruleID)
+			}
+		}
+		if len(removeRuleIDs) > 0 {
 			deleteRuleOption := metadata.DeleteHostApplyRuleOption{
-				RuleIDs: planRequest.RemoveRuleIDs,
+				RuleIDs: removeRuleIDs,
 			}
 			if ccErr := s.CoreAPI.CoreService().HostApplyRule().DeleteHostApplyRule(srvData.ctx, srvData.header, bizID, deleteRuleOption); ccErr != nil {
 				blog.ErrorJSON("GenerateApplyPlan failed, DeleteHostApplyRule failed, bizID: %s, request: %s, err: %v, rid:%s", bizID, deleteRuleOption, ccErr, rid)
@@ -692,6 +721,7 @@ func (s *Service) listHostRelatedApplyRule(srvData *srvComm, bizID int64, option
 		Page: metadata.BasePage{
 			Limit: common.BKNoLimit,
 		},
+		WithInherited: true,
 	}
 	ruleResult, ccErr := s.CoreAPI.CoreService().HostApplyRule().ListHostApplyRule(srvData.ctx, srvData.header, bizID, ruleOption)
 	if ccErr != nil {
@@ -142,6 +142,13 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.GET("/userapi/detail/{bk_biz_id}/{id}").To(s.GetUserCustomQueryDetail))
 	api.Route(api.GET("/userapi/data/{bk_biz_id}/{id}/{start}/{limit}").To(s.GetUserCustomQueryResult))
 
+	api.Route(api.POST("/dynamicgroup").To(s.CreateDynamicGroup))
+	api.Route(api.PUT("/dynamicgroup/{bk_biz_id}/{id}").To(s.UpdateDynamicGroup))
+	api.Route(api.DELETE("/dynamicgroup/{bk_biz_id}/{id}").To(s.DeleteUserCustomQuery))
+	api.Route(api.POST("/dynamicgroup/search/{bk_biz_id}").To(s.GetUserCustomQuery))
+	api.Route(api.GET("/dynamicgroup/{bk_biz_id}/{id}").To(s.GetUserCustomQueryDetail))
+	api.Route(api.POST("/dynamicgroup/data/{bk_biz_id}/{id}").To(s.ExecuteDynamicGroup))
+
 	api.Route(api.POST("/host/lock").To(s.LockHost))
 	api.Route(api.DELETE("/host/lock").To(s.UnlockHost))
 	api.Route(api.POST("/host/lock/search").To(s.QueryHostLock))
@@ -172,6 +179,9 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/createmany/host_apply_plan/bk_biz_id/{bk_biz_id}/preview").To(s.GenerateApplyPlan))
 	api.Route(api.POST("/updatemany/host_apply_plan/bk_biz_id/{bk_biz_id}/run").To(s.RunHostApplyRule))
 	api.Route(api.POST("/findmany/host_apply_rule/bk_biz_id/{bk_biz_id}/host_related_rules").To(s.ListHostRelatedApplyRule))
+	api.Route(api.GET("/find/host_apply_drift_config/bk_biz_id/{bk_biz_id}").To(s.GetHostApplyDriftConfig))
+	api.Route(api.PUT("/update/host_apply_drift_config/bk_biz_id/{bk_biz_id}").To(s.UpdateHostApplyDriftConfig))
+	api.Route(api.POST("/findmany/host_apply_drift/bk_biz_id/{bk_biz_id}").To(s.ListHostApplyDrift))
 
 	api.Route(api.PUT("/hosts/update").To(s.UpdateImportHosts))
 	container.Add(api)
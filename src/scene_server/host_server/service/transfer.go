@@ -580,6 +580,7 @@ func (s *Service) generateTransferPlans(srvData *srvComm, bizID int64, withHostA
 		Page: metadata.BasePage{
 			Limit: common.BKNoLimit,
 		},
+		WithInherited: true,
 	}
 	rules, ccErr := s.CoreAPI.CoreService().HostApplyRule().ListHostApplyRule(srvData.ctx, srvData.header, bizID, ruleOption)
 	if ccErr != nil {
@@ -301,6 +301,26 @@ func (s *Service) GetUserCustomQueryResult(req *restful.Request, resp *restful.R
 		return
 	}
 
+	// the dynamic group of any model is executed with its filter
+	if result.Data.Filter != nil {
+		start, startErr := util.GetIntByInterface(req.PathParameter("start"))
+		limit, limitErr := util.GetIntByInterface(req.PathParameter("limit"))
+		if startErr != nil || limitErr != nil {
+			blog.Errorf("UserAPIResult page invalid, start: %s, limit: %s, rid: %s", req.PathParameter("start"),
+				req.PathParameter("limit"), srvData.rid)
+			_ = resp.WriteError(http.StatusOK, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, "page")})
+			return
+		}
+		option := &meta.ExecuteDynamicGroupOption{Page: meta.BasePage{Start: start, Limit: limit}}
+		groupResult, ccErr := srvData.lgc.ExecuteDynamicGroup(srvData.ctx, &result.Data, option)
+		if ccErr != nil {
+			_ = resp.WriteError(http.StatusOK, &meta.RespError{Msg: ccErr})
+			return
+		}
+		_ = resp.WriteEntity(meta.NewSuccessResp(groupResult))
+		return
+	}
+
 	var input meta.HostCommonSearch
 	input.AppID = intAppID
 
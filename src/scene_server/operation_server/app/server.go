@@ -66,7 +66,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		return err
 	}
 
-	authorize, err := auth.NewAuthorize(nil, operationSvr.Config.Auth, engine.Metric().Registry())
+	authorize, err := auth.NewAuthorize(ctx, nil, operationSvr.Config.Auth, engine.Metric().Registry())
 	if err != nil {
 		return fmt.Errorf("new authorize failed, err: %v", err)
 	}
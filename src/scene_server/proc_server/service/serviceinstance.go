@@ -384,6 +384,7 @@ func (ps *ProcServer) createServiceInstancesPreview(ctx *rest.Contexts, input me
 		Page: metadata.BasePage{
 			Limit: common.BKNoLimit,
 		},
+		WithInherited: true,
 	}
 	rules, ccErr := ps.CoreAPI.CoreService().HostApplyRule().ListHostApplyRule(ctx.Kit.Ctx, ctx.Kit.Header, bizID, ruleOption)
 	if ccErr != nil {
@@ -1698,6 +1699,7 @@ func (ps *ProcServer) DeleteServiceInstancePreview(ctx *rest.Contexts) {
 		Page: metadata.BasePage{
 			Limit: common.BKNoLimit,
 		},
+		WithInherited: true,
 	}
 	ruleResult, err := ps.CoreAPI.CoreService().HostApplyRule().ListHostApplyRule(ctx.Kit.Ctx, ctx.Kit.Header, bizID, listRuleOption)
 	if err != nil {
@@ -19,7 +19,7 @@ import (
 	"net/http"
 	"time"
 
-	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth"
 	"configcenter/src/auth/extensions"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
@@ -121,7 +121,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		return err
 	}
 
-	authorize, err := authcenter.NewAuthCenter(nil, server.Config.Auth, engine.Metric().Registry())
+	authorize, err := auth.NewAuthorize(ctx, nil, server.Config.Auth, engine.Metric().Registry())
 	if err != nil {
 		blog.Errorf("it is failed to create a new auth API, err:%s", err.Error())
 		return err
@@ -38,6 +38,7 @@ type Core interface {
 	AuditOperation() operation.AuditOperationInterface
 	UniqueOperation() operation.UniqueOperationInterface
 	SetTemplateOperation() settemplate.SetTemplate
+	SchemaBundleOperation() operation.SchemaBundleOperationInterface
 }
 
 type core struct {
@@ -55,6 +56,7 @@ type core struct {
 	identifier     operation.IdentifierOperationInterface
 	unique         operation.UniqueOperationInterface
 	setTemplate    settemplate.SetTemplate
+	schemaBundle   operation.SchemaBundleOperationInterface
 }
 
 // New create a logics manager
@@ -74,6 +76,7 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 	audit := operation.NewAuditOperation(client)
 	unique := operation.NewUniqueOperation(client, authManager)
 	setTemplate := settemplate.NewSetTemplate(client)
+	schemaBundle := operation.NewSchemaBundleOperation(client, authManager)
 
 	targetModel := model.New(client, languageIf)
 	targetInst := inst.New(client)
@@ -89,8 +92,10 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 	moduleOperation.SetProxy(instOperation)
 	setOperation.SetProxy(objectOperation, instOperation, moduleOperation)
 	businessOperation.SetProxy(setOperation, moduleOperation, instOperation, objectOperation)
+	audit.SetProxy(objectOperation, instOperation, businessOperation, setOperation, moduleOperation)
 
 	graphics.SetProxy(objectOperation, associationOperation)
+	schemaBundle.SetProxy(classificationOperation, objectOperation, groupOperation, attributeOperation, unique, associationOperation)
 
 	return &core{
 		set:            setOperation,
@@ -107,6 +112,7 @@ func New(client apimachinery.ClientSetInterface, authManager *extensions.AuthMan
 		identifier:     identifier,
 		unique:         unique,
 		setTemplate:    setTemplate,
+		schemaBundle:   schemaBundle,
 	}
 }
 
@@ -155,3 +161,6 @@ func (c *core) UniqueOperation() operation.UniqueOperationInterface {
 func (c *core) SetTemplateOperation() settemplate.SetTemplate {
 	return c.setTemplate
 }
+func (c *core) SchemaBundleOperation() operation.SchemaBundleOperationInterface {
+	return c.schemaBundle
+}
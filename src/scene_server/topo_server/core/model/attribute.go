@@ -152,8 +152,10 @@ func (a *attribute) IsValid(isUpdate bool, data mapstr.MapStr) error {
 			return a.kit.CCError.New(common.CCErrCommParamsIsInvalid, err.Error())
 		}
 
+		// table attribute must define its sub-columns in option, and computed attribute must define its expression
 		option, exists := data.Get(metadata.AttributeFieldOption)
-		if exists && a.isPropertyTypeIntEnumList(propertyType) {
+		optionRequired := propertyType == common.FieldTypeTable || propertyType == common.FieldTypeComputed
+		if (exists || optionRequired) && a.isPropertyTypeWithOption(propertyType) {
 			if err := util.ValidPropertyOption(propertyType, option, a.kit.CCError); nil != err {
 				return err
 			}
@@ -343,9 +345,10 @@ func (a *attribute) SetSupplierAccount(supplierAccount string) {
 	a.attr.OwnerID = supplierAccount
 }
 
-func (a *attribute) isPropertyTypeIntEnumList(propertyType string) bool {
+func (a *attribute) isPropertyTypeWithOption(propertyType string) bool {
 	switch propertyType {
-	case common.FieldTypeInt, common.FieldTypeEnum, common.FieldTypeList:
+	case common.FieldTypeInt, common.FieldTypeEnum, common.FieldTypeList, common.FieldTypeMultiEnum, common.FieldTypeTable,
+		common.FieldTypeComputed:
 		return true
 	default:
 		return false
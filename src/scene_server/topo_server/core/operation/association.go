@@ -53,6 +53,8 @@ type AssociationOperationInterface interface {
 	CreateCommonInstAssociation(kit *rest.Kit, data *metadata.InstAsst) error
 	DeleteInstAssociation(kit *rest.Kit, cond condition.Condition) error
 	CheckAssociation(kit *rest.Kit, obj model.Object, objectID string, instID int64) error
+	SearchInstGraph(kit *rest.Kit, request *metadata.SearchInstGraphRequest) (*metadata.InstGraphResult, error)
+	SearchInstShortestPath(kit *rest.Kit, request *metadata.SearchInstShortestPathRequest) (*metadata.InstShortestPathResult, error)
 
 	// 关联关系改造后的接口
 	SearchObjectAssocWithAssocKindList(kit *rest.Kit, asstKindIDs []string) (resp *metadata.AssociationList, err error)
@@ -0,0 +1,288 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package operation
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// instEdgeFetcher get all the instance associations that connect to the frontier instances.
+type instEdgeFetcher func(frontier []metadata.InstNode) ([]metadata.InstAsst, error)
+
+// instGraph is the result of a breadth first walk on the instance association graph.
+type instGraph struct {
+	nodes     []metadata.InstGraphNode
+	edges     []metadata.InstAsst
+	depth     map[metadata.InstNode]int
+	parent    map[metadata.InstNode]metadata.InstAsst
+	truncated bool
+	found     bool
+}
+
+// walkInstGraph walk the instance association graph from the start instance hop by hop, an instance is
+// expanded only once, so the cycles in the graph is walked through safely. the walk stops when the max depth
+// is reached, or the target instance is found if it's set.
+func walkInstGraph(start metadata.InstNode, target *metadata.InstNode, filter *metadata.InstGraphFilter,
+	fetch instEdgeFetcher) (*instGraph, error) {
+
+	graph := &instGraph{
+		nodes:  []metadata.InstGraphNode{{InstNode: start}},
+		edges:  make([]metadata.InstAsst, 0),
+		depth:  map[metadata.InstNode]int{start: 0},
+		parent: make(map[metadata.InstNode]metadata.InstAsst),
+	}
+	if target != nil && *target == start {
+		graph.found = true
+		return graph, nil
+	}
+
+	edgeIDs := make(map[int64]bool)
+	frontier := []metadata.InstNode{start}
+	for depth := 1; depth <= filter.MaxDepth && len(frontier) > 0; depth++ {
+		assts, err := fetch(frontier)
+		if err != nil {
+			return nil, err
+		}
+
+		inFrontier := make(map[metadata.InstNode]bool, len(frontier))
+		for _, node := range frontier {
+			inFrontier[node] = true
+		}
+
+		next := make([]metadata.InstNode, 0)
+		for _, asst := range assts {
+			if edgeIDs[asst.ID] || !filter.AllowAsstKind(asst.AssociationKindID) {
+				continue
+			}
+
+			src := metadata.InstNode{ObjectID: asst.ObjectID, InstID: asst.InstID}
+			dest := metadata.InstNode{ObjectID: asst.AsstObjectID, InstID: asst.AsstInstID}
+			neighbors := make([]metadata.InstNode, 0)
+			if filter.WalkOut() && inFrontier[src] {
+				neighbors = append(neighbors, dest)
+			}
+			if filter.WalkIn() && inFrontier[dest] {
+				neighbors = append(neighbors, src)
+			}
+
+			walked := false
+			for _, node := range neighbors {
+				if !filter.AllowObject(node.ObjectID) {
+					continue
+				}
+
+				if _, exist := graph.depth[node]; !exist {
+					if len(graph.nodes) >= filter.MaxNodes {
+						graph.truncated = true
+						continue
+					}
+					graph.depth[node] = depth
+					graph.parent[node] = asst
+					graph.nodes = append(graph.nodes, metadata.InstGraphNode{InstNode: node, Depth: depth})
+					next = append(next, node)
+				}
+				walked = true
+
+				if target != nil && *target == node {
+					graph.edges = append(graph.edges, asst)
+					graph.found = true
+					return graph, nil
+				}
+			}
+
+			if walked {
+				edgeIDs[asst.ID] = true
+				graph.edges = append(graph.edges, asst)
+			}
+		}
+		frontier = next
+	}
+
+	return graph, nil
+}
+
+// pathTo returns the nodes and edges from the start instance to the node along the walked tree.
+func (g *instGraph) pathTo(node metadata.InstNode) ([]metadata.InstGraphNode, []metadata.InstAsst) {
+	nodes := []metadata.InstGraphNode{{InstNode: node, Depth: g.depth[node]}}
+	edges := make([]metadata.InstAsst, 0)
+	for {
+		asst, exist := g.parent[node]
+		if !exist {
+			break
+		}
+		if asst.ObjectID == node.ObjectID && asst.InstID == node.InstID {
+			node = metadata.InstNode{ObjectID: asst.AsstObjectID, InstID: asst.AsstInstID}
+		} else {
+			node = metadata.InstNode{ObjectID: asst.ObjectID, InstID: asst.InstID}
+		}
+		nodes = append(nodes, metadata.InstGraphNode{InstNode: node, Depth: g.depth[node]})
+		edges = append(edges, asst)
+	}
+
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	for i, j := 0, len(edges)-1; i < j; i, j = i+1, j-1 {
+		edges[i], edges[j] = edges[j], edges[i]
+	}
+	return nodes, edges
+}
+
+// SearchInstGraph search the association graph around an instance within the max depth and max nodes.
+func (assoc *association) SearchInstGraph(kit *rest.Kit, request *metadata.SearchInstGraphRequest) (
+	*metadata.InstGraphResult, error) {
+
+	graph, err := walkInstGraph(request.InstNode, nil, &request.InstGraphFilter,
+		assoc.instEdgeFetcher(kit, &request.InstGraphFilter))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := assoc.fillInstGraphNodeName(kit, graph.nodes); err != nil {
+		return nil, err
+	}
+
+	return &metadata.InstGraphResult{
+		Nodes:     graph.nodes,
+		Edges:     graph.edges,
+		Truncated: graph.truncated,
+	}, nil
+}
+
+// SearchInstShortestPath search the path with the fewest associations from the source instance to the target instance.
+func (assoc *association) SearchInstShortestPath(kit *rest.Kit, request *metadata.SearchInstShortestPathRequest) (
+	*metadata.InstShortestPathResult, error) {
+
+	graph, err := walkInstGraph(request.Source, &request.Target, &request.InstGraphFilter,
+		assoc.instEdgeFetcher(kit, &request.InstGraphFilter))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &metadata.InstShortestPathResult{
+		Found:     graph.found,
+		Nodes:     make([]metadata.InstGraphNode, 0),
+		Edges:     make([]metadata.InstAsst, 0),
+		Truncated: graph.truncated,
+	}
+	if !graph.found {
+		return result, nil
+	}
+
+	result.Nodes, result.Edges = graph.pathTo(request.Target)
+	if err := assoc.fillInstGraphNodeName(kit, result.Nodes); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// instEdgeFetcher returns a fetcher which read the associations of the frontier instances from core service,
+// the direction, association kind and model filters are pushed down to the query.
+func (assoc *association) instEdgeFetcher(kit *rest.Kit, filter *metadata.InstGraphFilter) instEdgeFetcher {
+	return func(frontier []metadata.InstNode) ([]metadata.InstAsst, error) {
+		objInstIDs := make(map[string][]int64)
+		for _, node := range frontier {
+			objInstIDs[node.ObjectID] = append(objInstIDs[node.ObjectID], node.InstID)
+		}
+
+		orCond := make([]mapstr.MapStr, 0)
+		for objID, instIDs := range objInstIDs {
+			if filter.WalkOut() {
+				cond := mapstr.MapStr{
+					common.BKObjIDField:  objID,
+					common.BKInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+				}
+				if len(filter.ObjectIDs) > 0 {
+					cond[common.BKAsstObjIDField] = mapstr.MapStr{common.BKDBIN: filter.ObjectIDs}
+				}
+				orCond = append(orCond, cond)
+			}
+			if filter.WalkIn() {
+				cond := mapstr.MapStr{
+					common.BKAsstObjIDField:  objID,
+					common.BKAsstInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+				}
+				if len(filter.ObjectIDs) > 0 {
+					cond[common.BKObjIDField] = mapstr.MapStr{common.BKDBIN: filter.ObjectIDs}
+				}
+				orCond = append(orCond, cond)
+			}
+		}
+
+		cond := mapstr.MapStr{common.BKDBOR: orCond}
+		if len(filter.AsstKindIDs) > 0 {
+			cond[common.AssociationKindIDField] = mapstr.MapStr{common.BKDBIN: filter.AsstKindIDs}
+		}
+
+		query := &metadata.QueryCondition{
+			Condition: cond,
+			Page:      metadata.BasePage{Limit: common.BKNoLimit},
+		}
+		rsp, err := assoc.clientSet.CoreService().Association().ReadInstAssociation(kit.Ctx, kit.Header, query)
+		if err != nil {
+			blog.Errorf("read instance association failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !rsp.Result {
+			blog.Errorf("read instance association failed, cond: %#v, err: %s, rid: %s", cond, rsp.ErrMsg, kit.Rid)
+			return nil, kit.CCError.New(rsp.Code, rsp.ErrMsg)
+		}
+		return rsp.Data.Info, nil
+	}
+}
+
+// fillInstGraphNodeName set the instance name of the graph nodes, the instances are read by model.
+func (assoc *association) fillInstGraphNodeName(kit *rest.Kit, nodes []metadata.InstGraphNode) error {
+	objInstIDs := make(map[string][]int64)
+	for _, node := range nodes {
+		objInstIDs[node.ObjectID] = append(objInstIDs[node.ObjectID], node.InstID)
+	}
+
+	instNames := make(map[metadata.InstNode]string)
+	for objID, instIDs := range objInstIDs {
+		idField := metadata.GetInstIDFieldByObjID(objID)
+		nameField := metadata.GetInstNameFieldName(objID)
+		query := &metadata.QueryCondition{
+			Condition: mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: instIDs}},
+			Page:      metadata.BasePage{Limit: common.BKNoLimit},
+			Fields:    []string{idField, nameField},
+		}
+		rsp, err := assoc.clientSet.CoreService().Instance().ReadInstance(kit.Ctx, kit.Header, objID, query)
+		if err != nil {
+			blog.Errorf("read %s instances failed, ids: %v, err: %v, rid: %s", objID, instIDs, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !rsp.Result {
+			blog.Errorf("read %s instances failed, ids: %v, err: %s, rid: %s", objID, instIDs, rsp.ErrMsg, kit.Rid)
+			return kit.CCError.New(rsp.Code, rsp.ErrMsg)
+		}
+
+		for _, inst := range rsp.Data.Info {
+			instID, err := inst.Int64(idField)
+			if err != nil {
+				blog.Errorf("get %s instance id failed, inst: %#v, err: %v, rid: %s", objID, inst, err, kit.Rid)
+				return kit.CCError.CCErrorf(common.CCErrCommInstFieldConvertFail, objID, idField, "int", err.Error())
+			}
+			name, _ := inst.String(nameField)
+			instNames[metadata.InstNode{ObjectID: objID, InstID: instID}] = name
+		}
+	}
+
+	for idx := range nodes {
+		nodes[idx].InstName = instNames[nodes[idx].InstNode]
+	}
+	return nil
+}
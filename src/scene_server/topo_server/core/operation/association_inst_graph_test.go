@@ -0,0 +1,225 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package operation
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+// a -> b -> c -> a is a cycle, c -> d is a connect association, e -> a points to the start instance.
+var testInstAssts = []metadata.InstAsst{
+	{ID: 1, ObjectID: "a", InstID: 1, AsstObjectID: "b", AsstInstID: 1, AssociationKindID: "run"},
+	{ID: 2, ObjectID: "b", InstID: 1, AsstObjectID: "c", AsstInstID: 1, AssociationKindID: "run"},
+	{ID: 3, ObjectID: "c", InstID: 1, AsstObjectID: "a", AsstInstID: 1, AssociationKindID: "run"},
+	{ID: 4, ObjectID: "c", InstID: 1, AsstObjectID: "d", AsstInstID: 1, AssociationKindID: "connect"},
+	{ID: 5, ObjectID: "e", InstID: 1, AsstObjectID: "a", AsstInstID: 1, AssociationKindID: "run"},
+}
+
+func testInstEdgeFetcher(calls *int) instEdgeFetcher {
+	return func(frontier []metadata.InstNode) ([]metadata.InstAsst, error) {
+		*calls++
+		result := make([]metadata.InstAsst, 0)
+		for _, asst := range testInstAssts {
+			for _, node := range frontier {
+				if (asst.ObjectID == node.ObjectID && asst.InstID == node.InstID) ||
+					(asst.AsstObjectID == node.ObjectID && asst.AsstInstID == node.InstID) {
+					result = append(result, asst)
+					break
+				}
+			}
+		}
+		return result, nil
+	}
+}
+
+func testNode(objID string) metadata.InstNode {
+	return metadata.InstNode{ObjectID: objID, InstID: 1}
+}
+
+func TestWalkInstGraph(t *testing.T) {
+	testCases := []struct {
+		name      string
+		filter    metadata.InstGraphFilter
+		nodes     map[string]int
+		edges     []int64
+		truncated bool
+	}{
+		{
+			name:   "both directions",
+			filter: metadata.InstGraphFilter{},
+			nodes:  map[string]int{"a": 0, "b": 1, "c": 1, "e": 1, "d": 2},
+			edges:  []int64{1, 3, 5, 2, 4},
+		},
+		{
+			name:   "out direction walks through the cycle only once",
+			filter: metadata.InstGraphFilter{Direction: metadata.InstGraphDirectionOut, MaxDepth: 10},
+			nodes:  map[string]int{"a": 0, "b": 1, "c": 2, "d": 3},
+			edges:  []int64{1, 2, 3, 4},
+		},
+		{
+			name:   "in direction",
+			filter: metadata.InstGraphFilter{Direction: metadata.InstGraphDirectionIn, MaxDepth: 1},
+			nodes:  map[string]int{"a": 0, "c": 1, "e": 1},
+			edges:  []int64{3, 5},
+		},
+		{
+			name:   "association kind filter",
+			filter: metadata.InstGraphFilter{Direction: metadata.InstGraphDirectionOut, AsstKindIDs: []string{"run"}},
+			nodes:  map[string]int{"a": 0, "b": 1, "c": 2},
+			edges:  []int64{1, 2, 3},
+		},
+		{
+			name:   "model filter",
+			filter: metadata.InstGraphFilter{ObjectIDs: []string{"c", "d"}},
+			nodes:  map[string]int{"a": 0, "c": 1, "d": 2},
+			edges:  []int64{3, 4},
+		},
+		{
+			name:      "node budget",
+			filter:    metadata.InstGraphFilter{MaxNodes: 3},
+			nodes:     map[string]int{"a": 0, "b": 1, "c": 1},
+			edges:     []int64{1, 3, 2},
+			truncated: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		filter := testCase.filter
+		if key, err := filter.Validate(); err != nil {
+			t.Fatalf("%s: validate filter failed, key: %s, err: %v", testCase.name, key, err)
+		}
+
+		calls := 0
+		graph, err := walkInstGraph(testNode("a"), nil, &filter, testInstEdgeFetcher(&calls))
+		if err != nil {
+			t.Fatalf("%s: walk graph failed, err: %v", testCase.name, err)
+		}
+		if calls > filter.MaxDepth {
+			t.Errorf("%s: fetch edges %d times, exceeds max depth %d", testCase.name, calls, filter.MaxDepth)
+		}
+
+		if len(graph.nodes) != len(testCase.nodes) {
+			t.Errorf("%s: expect nodes %v, got %v", testCase.name, testCase.nodes, graph.nodes)
+		}
+		for _, node := range graph.nodes {
+			depth, exist := testCase.nodes[node.ObjectID]
+			if !exist || depth != node.Depth {
+				t.Errorf("%s: unexpected node %v", testCase.name, node)
+			}
+		}
+
+		edgeIDs := make([]int64, 0)
+		for _, edge := range graph.edges {
+			edgeIDs = append(edgeIDs, edge.ID)
+		}
+		if len(edgeIDs) != len(testCase.edges) {
+			t.Errorf("%s: expect edges %v, got %v", testCase.name, testCase.edges, edgeIDs)
+			continue
+		}
+		for idx := range edgeIDs {
+			if edgeIDs[idx] != testCase.edges[idx] {
+				t.Errorf("%s: expect edges %v, got %v", testCase.name, testCase.edges, edgeIDs)
+				break
+			}
+		}
+
+		if graph.truncated != testCase.truncated {
+			t.Errorf("%s: expect truncated %v, got %v", testCase.name, testCase.truncated, graph.truncated)
+		}
+	}
+}
+
+func TestWalkInstGraphShortestPath(t *testing.T) {
+	testCases := []struct {
+		name   string
+		target string
+		filter metadata.InstGraphFilter
+		found  bool
+		path   []string
+		edges  []int64
+	}{
+		{
+			name:   "walk back through the cycle",
+			target: "d",
+			found:  true,
+			path:   []string{"a", "c", "d"},
+			edges:  []int64{3, 4},
+		},
+		{
+			name:   "out direction",
+			target: "d",
+			filter: metadata.InstGraphFilter{Direction: metadata.InstGraphDirectionOut},
+			found:  true,
+			path:   []string{"a", "b", "c", "d"},
+			edges:  []int64{1, 2, 4},
+		},
+		{
+			name:   "beyond max depth",
+			target: "d",
+			filter: metadata.InstGraphFilter{Direction: metadata.InstGraphDirectionOut, MaxDepth: 2},
+		},
+		{
+			name:   "start is the target",
+			target: "a",
+			found:  true,
+			path:   []string{"a"},
+			edges:  []int64{},
+		},
+		{
+			name:   "not connected",
+			target: "e",
+			filter: metadata.InstGraphFilter{Direction: metadata.InstGraphDirectionOut},
+		},
+	}
+
+	for _, testCase := range testCases {
+		filter := testCase.filter
+		if key, err := filter.Validate(); err != nil {
+			t.Fatalf("%s: validate filter failed, key: %s, err: %v", testCase.name, key, err)
+		}
+
+		target := testNode(testCase.target)
+		calls := 0
+		graph, err := walkInstGraph(testNode("a"), &target, &filter, testInstEdgeFetcher(&calls))
+		if err != nil {
+			t.Fatalf("%s: walk graph failed, err: %v", testCase.name, err)
+		}
+		if graph.found != testCase.found {
+			t.Errorf("%s: expect found %v, got %v", testCase.name, testCase.found, graph.found)
+			continue
+		}
+		if !graph.found {
+			continue
+		}
+
+		nodes, edges := graph.pathTo(target)
+		if len(nodes) != len(testCase.path) || len(edges) != len(testCase.edges) {
+			t.Errorf("%s: expect path %v with edges %v, got %v, %v", testCase.name, testCase.path,
+				testCase.edges, nodes, edges)
+			continue
+		}
+		for idx, node := range nodes {
+			if node.ObjectID != testCase.path[idx] || node.Depth != idx {
+				t.Errorf("%s: expect path %v, got %v", testCase.name, testCase.path, nodes)
+				break
+			}
+		}
+		for idx, edge := range edges {
+			if edge.ID != testCase.edges[idx] {
+				t.Errorf("%s: expect edges %v, got %v", testCase.name, testCase.edges, edges)
+				break
+			}
+		}
+	}
+}
@@ -250,6 +250,14 @@ func (a *attribute) FindObjectAttributeWithDetail(kit *rest.Kit, cond condition.
 			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "attribute.bk_property_group: "+attribute.PropertyGroup)
 		}
 		result.PropertyGroupName = grpName
+		if attribute.PropertyType == common.FieldTypeComputed {
+			// show the fields that the computed attribute depends on
+			if _, expr, err := attribute.ParseComputedExpression(kit.Ctx); err == nil {
+				result.ComputedDependencies = metadata.NewComputedDependencies(expr.References())
+			} else {
+				blog.Errorf("parse expression of computed attribute %s failed, err: %v, rid: %s", attribute.PropertyID, err, kit.Rid)
+			}
+		}
 		results = append(results, result)
 	}
 
@@ -24,6 +24,12 @@ import (
 
 type AuditOperationInterface interface {
 	Query(kit *rest.Kit, query metadata.QueryInput) (interface{}, error)
+	InstanceHistory(kit *rest.Kit, objID string, option metadata.InstanceHistoryOption) (*metadata.InstanceHistoryResult, error)
+	RestoreInstance(kit *rest.Kit, objID string, option metadata.RestoreInstanceOption) (*metadata.RestoreInstanceResult, error)
+	RevealSecret(kit *rest.Kit, objID string, instID int64, propertyID string) (*metadata.RevealSecretResult, error)
+
+	SetProxy(obj ObjectOperationInterface, inst InstOperationInterface, business BusinessOperationInterface,
+		set SetOperationInterface, module ModuleOperationInterface)
 }
 
 // NewAuditOperation create a new inst operation instance
@@ -35,6 +41,21 @@ func NewAuditOperation(client apimachinery.ClientSetInterface) AuditOperationInt
 
 type audit struct {
 	clientSet apimachinery.ClientSetInterface
+	obj       ObjectOperationInterface
+	inst      InstOperationInterface
+	business  BusinessOperationInterface
+	set       SetOperationInterface
+	module    ModuleOperationInterface
+}
+
+// SetProxy sets the operations used to restore the instances through the same path as the normal update
+func (a *audit) SetProxy(obj ObjectOperationInterface, inst InstOperationInterface, business BusinessOperationInterface,
+	set SetOperationInterface, module ModuleOperationInterface) {
+	a.obj = obj
+	a.inst = inst
+	a.business = business
+	a.set = set
+	a.module = module
 }
 
 func (a *audit) Query(kit *rest.Kit, query metadata.QueryInput) (interface{}, error) {
@@ -0,0 +1,346 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// historyIgnoredFields are the fields that are not regarded as changes of an instance
+var historyIgnoredFields = map[string]bool{
+	"_id":                true,
+	common.LastTimeField: true,
+}
+
+// InstanceHistory reconstructs the state of the instance at the time with its audit logs, the audit logs
+// record the whole instance data before and after each operation, so the state is the data after the
+// latest operation before the time.
+func (a *audit) InstanceHistory(kit *rest.Kit, objID string, option metadata.InstanceHistoryOption) (*metadata.InstanceHistoryResult, error) {
+	if option.InstID <= 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_inst_id")
+	}
+
+	logs, err := a.searchInstanceAuditLogs(kit, objID, option.InstID)
+	if err != nil {
+		return nil, err
+	}
+
+	until := time.Now()
+	if option.Timestamp > 0 {
+		until = time.Unix(option.Timestamp, 0)
+	}
+	result := buildInstanceHistory(logs, until)
+	return &result, nil
+}
+
+// RestoreInstance restores the editable fields of the instance to the version recorded in the audit log,
+// and records the restoration with a new audit log which links to the source audit log.
+func (a *audit) RestoreInstance(kit *rest.Kit, objID string, option metadata.RestoreInstanceOption) (*metadata.RestoreInstanceResult, error) {
+	if option.InstID <= 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_inst_id")
+	}
+	if option.AuditID <= 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "audit_id")
+	}
+
+	sourceLog, err := a.getInstanceAuditLog(kit, objID, option.InstID, option.AuditID)
+	if err != nil {
+		return nil, err
+	}
+	_, content := auditLogContent(sourceLog)
+	if content == nil {
+		blog.Errorf("restore instance failed, audit log %d has no instance data, rid: %s", option.AuditID, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "audit_id")
+	}
+	version := content.CurData
+	if option.Before {
+		version = content.PreData
+	}
+	if version == nil {
+		blog.Errorf("restore instance failed, instance not exist in the version, audit id: %d, before: %v, rid: %s", option.AuditID, option.Before, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "before")
+	}
+
+	current, err := a.readInstance(kit, objID, option.InstID)
+	if err != nil {
+		return nil, err
+	}
+
+	editableFields, err := a.getEditableFields(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]metadata.InstanceFieldChange, 0)
+	updateData := make(mapstr.MapStr)
+	for _, change := range diffInstanceData(current, version) {
+		if !editableFields[change.PropertyID] {
+			continue
+		}
+		changes = append(changes, change)
+		updateData[change.PropertyID] = change.CurValue
+	}
+	result := &metadata.RestoreInstanceResult{Changes: changes}
+	if len(updateData) == 0 {
+		return result, nil
+	}
+
+	// the audit log of the update is linked to the source audit log by the header
+	restoreKit := *kit
+	restoreKit.Header = util.CloneHeader(kit.Header)
+	restoreKit.Header.Set(common.BKHTTPCCAuditSourceID, strconv.FormatInt(sourceLog.ID, 10))
+	if err := a.updateInstance(&restoreKit, objID, current, option.InstID, updateData); err != nil {
+		blog.Errorf("restore instance failed, update instance failed, objID: %s, instID: %d, data: %+v, err: %v, rid: %s",
+			objID, option.InstID, updateData, err, kit.Rid)
+		return nil, err
+	}
+	return result, nil
+}
+
+// updateInstance updates the instance in the same way as the update api of the object, so that the data is
+// validated, and the audit log is saved as the normal update
+func (a *audit) updateInstance(kit *rest.Kit, objID string, current mapstr.MapStr, instID int64,
+	data mapstr.MapStr) error {
+
+	obj, err := a.obj.FindSingleObject(kit, objID, nil)
+	if err != nil {
+		return err
+	}
+
+	switch objID {
+	case common.BKInnerObjIDApp:
+		return a.business.UpdateBusiness(kit, data, obj, instID, nil)
+	case common.BKInnerObjIDSet:
+		bizID, err := current.Int64(common.BKAppIDField)
+		if err != nil {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+		}
+		return a.set.UpdateSet(kit, data, obj, bizID, instID, nil)
+	case common.BKInnerObjIDModule:
+		bizID, err := current.Int64(common.BKAppIDField)
+		if err != nil {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+		}
+		setID, err := current.Int64(common.BKSetIDField)
+		if err != nil {
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKSetIDField)
+		}
+		return a.module.UpdateModule(kit, data, obj, bizID, setID, instID)
+	default:
+		cond := condition.CreateCondition()
+		cond.Field(obj.GetInstIDFieldName()).Eq(instID)
+		return a.inst.UpdateInst(kit, data, obj, cond, instID, nil)
+	}
+}
+
+// instanceAuditCondition matches the audit logs of the instance's own data, the resource type is used to
+// exclude other audit logs with the same object id, such as the audit logs of the model's attributes
+func instanceAuditCondition(objID string, instID int64) map[string]interface{} {
+	resourceTypes := []metadata.ResourceType{metadata.GetResourceTypeByObjID(objID, false)}
+	if mainlineType := metadata.GetResourceTypeByObjID(objID, true); mainlineType != resourceTypes[0] {
+		resourceTypes = append(resourceTypes, mainlineType)
+	}
+
+	return map[string]interface{}{
+		common.BKResourceTypeField:                                     map[string]interface{}{common.BKDBIN: resourceTypes},
+		common.BKOperationDetailField + "." + common.BKObjIDField:      objID,
+		common.BKOperationDetailField + "." + common.BKResourceIDField: instID,
+	}
+}
+
+func (a *audit) searchInstanceAuditLogs(kit *rest.Kit, objID string, instID int64) ([]metadata.AuditLog, error) {
+	query := metadata.QueryInput{
+		Condition: instanceAuditCondition(objID, instID),
+		Limit:     common.BKNoLimit,
+		Sort:      common.BKOperationTimeField,
+	}
+	rsp, err := a.clientSet.CoreService().Audit().SearchAuditLog(kit.Ctx, kit.Header, query)
+	if err != nil {
+		blog.Errorf("search instance audit logs failed, objID: %s, instID: %d, err: %v, rid: %s", objID, instID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("search instance audit logs failed, objID: %s, instID: %d, err: %s, rid: %s", objID, instID, rsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrAuditSelectFailed)
+	}
+	return rsp.Data.Info, nil
+}
+
+func (a *audit) getInstanceAuditLog(kit *rest.Kit, objID string, instID int64, auditID int64) (metadata.AuditLog, error) {
+	cond := instanceAuditCondition(objID, instID)
+	cond[common.BKFieldID] = auditID
+	query := metadata.QueryInput{
+		Condition: cond,
+		Limit:     1,
+	}
+	rsp, err := a.clientSet.CoreService().Audit().SearchAuditLog(kit.Ctx, kit.Header, query)
+	if err != nil {
+		blog.Errorf("get instance audit log failed, audit id: %d, err: %v, rid: %s", auditID, err, kit.Rid)
+		return metadata.AuditLog{}, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("get instance audit log failed, audit id: %d, err: %s, rid: %s", auditID, rsp.ErrMsg, kit.Rid)
+		return metadata.AuditLog{}, kit.CCError.CCError(common.CCErrAuditSelectFailed)
+	}
+	if len(rsp.Data.Info) == 0 {
+		blog.Errorf("get instance audit log failed, audit log %d of instance %s/%d not found, rid: %s", auditID, objID, instID, kit.Rid)
+		return metadata.AuditLog{}, kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+	return rsp.Data.Info[0], nil
+}
+
+func (a *audit) readInstance(kit *rest.Kit, objID string, instID int64) (map[string]interface{}, error) {
+	query := &metadata.QueryCondition{
+		Condition: map[string]interface{}{
+			common.GetInstIDField(objID): instID,
+		},
+	}
+	rsp, err := a.clientSet.CoreService().Instance().ReadInstance(kit.Ctx, kit.Header, objID, query)
+	if err != nil {
+		blog.Errorf("read instance failed, objID: %s, instID: %d, err: %v, rid: %s", objID, instID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("read instance failed, objID: %s, instID: %d, err: %s, rid: %s", objID, instID, rsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(rsp.Code, rsp.ErrMsg)
+	}
+	if len(rsp.Data.Info) == 0 {
+		blog.Errorf("read instance failed, instance %s/%d not found, rid: %s", objID, instID, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+	return rsp.Data.Info[0], nil
+}
+
+// getEditableFields returns the fields that can be restored, which are the editable attributes of the model
+func (a *audit) getEditableFields(kit *rest.Kit, objID string) (map[string]bool, error) {
+	query := &metadata.QueryCondition{
+		Condition: map[string]interface{}{
+			common.BKObjIDField: objID,
+		},
+	}
+	rsp, err := a.clientSet.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, objID, query)
+	if err != nil {
+		blog.Errorf("read model attributes failed, objID: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("read model attributes failed, objID: %s, err: %s, rid: %s", objID, rsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(rsp.Code, rsp.ErrMsg)
+	}
+	fields := make(map[string]bool)
+	for _, attr := range rsp.Data.Info {
+		if attr.IsEditable {
+			fields[attr.PropertyID] = true
+		}
+	}
+	return fields, nil
+}
+
+// auditLogContent returns the operation detail and the instance data of an instance audit log
+func auditLogContent(log metadata.AuditLog) (*metadata.BasicOpDetail, *metadata.BasicContent) {
+	switch detail := log.OperationDetail.(type) {
+	case *metadata.InstanceOpDetail:
+		return &detail.BasicOpDetail, detail.Details
+	case *metadata.BasicOpDetail:
+		return detail, detail.Details
+	default:
+		return nil, nil
+	}
+}
+
+// buildInstanceHistory folds the audit logs before the time into the instance's state and timeline
+func buildInstanceHistory(logs []metadata.AuditLog, until time.Time) metadata.InstanceHistoryResult {
+	sorted := make([]metadata.AuditLog, len(logs))
+	copy(sorted, logs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].OperationTime.Equal(sorted[j].OperationTime.Time) {
+			return sorted[i].ID < sorted[j].ID
+		}
+		return sorted[i].OperationTime.Before(sorted[j].OperationTime.Time)
+	})
+
+	result := metadata.InstanceHistoryResult{
+		Timeline: make([]metadata.InstanceHistoryEntry, 0),
+	}
+	for _, log := range sorted {
+		if log.OperationTime.After(until) {
+			break
+		}
+		_, content := auditLogContent(log)
+		if content == nil {
+			continue
+		}
+
+		result.Timeline = append(result.Timeline, metadata.InstanceHistoryEntry{
+			AuditID:       log.ID,
+			Action:        log.Action,
+			User:          log.User,
+			OperateFrom:   log.OperateFrom,
+			OperationTime: log.OperationTime,
+			SourceAuditID: log.SourceAuditID,
+			Changes:       diffInstanceData(content.PreData, content.CurData),
+		})
+
+		if log.Action == metadata.AuditDelete || content.CurData == nil {
+			result.Exist = false
+			result.State = nil
+			continue
+		}
+		result.Exist = true
+		result.State = content.CurData
+	}
+	return result
+}
+
+// diffInstanceData returns the changes of the fields from the previous data to the current data
+func diffInstanceData(pre, cur map[string]interface{}) []metadata.InstanceFieldChange {
+	fields := make(map[string]bool)
+	for field := range pre {
+		fields[field] = true
+	}
+	for field := range cur {
+		fields[field] = true
+	}
+
+	changes := make([]metadata.InstanceFieldChange, 0)
+	for field := range fields {
+		if historyIgnoredFields[field] {
+			continue
+		}
+		preValue, curValue := pre[field], cur[field]
+		if cmp.Equal(preValue, curValue) {
+			continue
+		}
+		changes = append(changes, metadata.InstanceFieldChange{
+			PropertyID: field,
+			PreValue:   preValue,
+			CurValue:   curValue,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].PropertyID < changes[j].PropertyID
+	})
+	return changes
+}
@@ -0,0 +1,87 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package operation
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+func testInstanceAuditLog(id int64, action metadata.ActionType, at time.Time, pre, cur map[string]interface{}) metadata.AuditLog {
+	return metadata.AuditLog{
+		ID:            id,
+		Action:        action,
+		OperationTime: metadata.Time{Time: at},
+		OperationDetail: &metadata.InstanceOpDetail{
+			BasicOpDetail: metadata.BasicOpDetail{
+				ResourceID: 1,
+				Details:    &metadata.BasicContent{PreData: pre, CurData: cur},
+			},
+			ModelID: "switch",
+		},
+	}
+}
+
+func TestDiffInstanceData(t *testing.T) {
+	pre := map[string]interface{}{"_id": "a", "last_time": 1, "name": "s1", "ip": "1.1.1.1", "removed": 1}
+	cur := map[string]interface{}{"_id": "b", "last_time": 2, "name": "s1", "ip": "2.2.2.2", "added": true}
+
+	changes := diffInstanceData(pre, cur)
+	if len(changes) != 3 {
+		t.Fatalf("expect 3 changes, got %+v", changes)
+	}
+	expects := []string{"added", "ip", "removed"}
+	for idx, change := range changes {
+		if change.PropertyID != expects[idx] {
+			t.Fatalf("expect change %d of %s, got %+v", idx, expects[idx], change)
+		}
+	}
+	if changes[1].PreValue != "1.1.1.1" || changes[1].CurValue != "2.2.2.2" {
+		t.Fatalf("unexpected ip change %+v", changes[1])
+	}
+}
+
+func TestBuildInstanceHistory(t *testing.T) {
+	base := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	v1 := map[string]interface{}{"name": "s1"}
+	v2 := map[string]interface{}{"name": "s2"}
+	logs := []metadata.AuditLog{
+		testInstanceAuditLog(3, metadata.AuditDelete, base.Add(2*time.Hour), v2, nil),
+		testInstanceAuditLog(2, metadata.AuditUpdate, base.Add(time.Hour), v1, v2),
+		testInstanceAuditLog(1, metadata.AuditCreate, base, nil, v1),
+	}
+
+	history := buildInstanceHistory(logs, base.Add(30*time.Minute))
+	if !history.Exist || history.State["name"] != "s1" || len(history.Timeline) != 1 {
+		t.Fatalf("unexpected history after create: %+v", history)
+	}
+
+	history = buildInstanceHistory(logs, base.Add(time.Hour))
+	if !history.Exist || history.State["name"] != "s2" || len(history.Timeline) != 2 {
+		t.Fatalf("unexpected history after update: %+v", history)
+	}
+	if history.Timeline[1].AuditID != 2 || len(history.Timeline[1].Changes) != 1 {
+		t.Fatalf("unexpected update entry: %+v", history.Timeline[1])
+	}
+
+	history = buildInstanceHistory(logs, base.Add(3*time.Hour))
+	if history.Exist || history.State != nil || len(history.Timeline) != 3 {
+		t.Fatalf("unexpected history after delete: %+v", history)
+	}
+
+	history = buildInstanceHistory(logs, base.Add(-time.Hour))
+	if history.Exist || len(history.Timeline) != 0 {
+		t.Fatalf("unexpected history before create: %+v", history)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
+	"configcenter/src/common/util"
+)
+
+// RevealSecret returns the plaintext of the instance's secret attribute value, every reveal is recorded in
+// the audit log of the instance, and the plaintext is not returned if the audit log can not be saved.
+func (a *audit) RevealSecret(kit *rest.Kit, objID string, instID int64, propertyID string) (*metadata.RevealSecretResult, error) {
+	inst, err := a.readInstance(kit, objID, instID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, ccErr := a.clientSet.CoreService().Instance().RevealSecret(kit.Ctx, kit.Header, objID, instID, propertyID)
+	if ccErr != nil {
+		blog.Errorf("reveal secret failed, objID: %s, instID: %d, property: %s, err: %v, rid: %s", objID, instID,
+			propertyID, ccErr, kit.Rid)
+		return nil, ccErr
+	}
+
+	bizID, _ := util.GetInt64ByInterface(inst[common.BKAppIDField])
+	instName, _ := inst[common.GetInstNameField(objID)].(string)
+	auditLog := metadata.AuditLog{
+		AuditType:    metadata.GetAuditTypeByObjID(objID, false),
+		ResourceType: metadata.GetResourceTypeByObjID(objID, false),
+		Action:       metadata.AuditRevealSecret,
+		OperateFrom:  metadata.FromUser,
+		OperationDetail: &metadata.InstanceOpDetail{
+			BasicOpDetail: metadata.BasicOpDetail{
+				BusinessID:   bizID,
+				ResourceID:   instID,
+				ResourceName: instName,
+				Details: &metadata.BasicContent{
+					CurData: map[string]interface{}{propertyID: secret.Mask},
+				},
+			},
+			ModelID: objID,
+		},
+	}
+	auditResult, err := a.clientSet.CoreService().Audit().SaveAuditLog(kit.Ctx, kit.Header, auditLog)
+	if err != nil {
+		blog.Errorf("reveal secret failed, save audit log failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !auditResult.Result {
+		blog.Errorf("reveal secret failed, save audit log failed, err: %s, rid: %s", auditResult.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(auditResult.Code, auditResult.ErrMsg)
+	}
+	return result, nil
+}
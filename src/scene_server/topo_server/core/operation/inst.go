@@ -1000,8 +1000,9 @@ func (c *commonInst) FindInstByAssociationInst(kit *rest.Kit, obj model.Object,
 }
 
 func (c *commonInst) FindOriginInst(kit *rest.Kit, objID string, cond *metadata.QueryInput) (*metadata.InstResult, errors.CCError) {
-	switch objID {
-	case common.BKInnerObjIDHost:
+	switch {
+	// the host query api doesn't support the filter, it's searched as a model instance instead
+	case objID == common.BKInnerObjIDHost && cond.Filter == nil:
 		rsp, err := c.clientSet.CoreService().Host().GetHosts(kit.Ctx, kit.Header, cond)
 		if nil != err {
 			blog.Errorf("[operation-inst] failed to request object controller, err: %s, rid: %s", err.Error(), kit.Rid)
@@ -1017,7 +1018,7 @@ func (c *commonInst) FindOriginInst(kit *rest.Kit, objID string, cond *metadata.
 
 	default:
 		queryCond, err := mapstr.NewFromInterface(cond.Condition)
-		input := &metadata.QueryCondition{Condition: queryCond}
+		input := &metadata.QueryCondition{Condition: queryCond, Filter: cond.Filter}
 		input.Page.Start = cond.Start
 		input.Page.Limit = cond.Limit
 		input.Page.Sort = cond.Sort
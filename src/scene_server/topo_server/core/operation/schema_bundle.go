@@ -0,0 +1,847 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/auth/extensions"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SchemaBundleOperationInterface exports the global models as a schema bundle and applies a bundle to them
+type SchemaBundleOperationInterface interface {
+	SetProxy(cls ClassificationOperationInterface, obj ObjectOperationInterface, grp GroupOperationInterface,
+		attr AttributeOperationInterface, unique UniqueOperationInterface, asst AssociationOperationInterface)
+
+	Export(kit *rest.Kit, option *metadata.ExportSchemaBundleOption) (*metadata.SchemaBundle, error)
+	ParseBundle(kit *rest.Kit, request *metadata.SchemaBundleRequest) (*metadata.SchemaBundle, error)
+	Plan(kit *rest.Kit, bundle *metadata.SchemaBundle) (*metadata.SchemaBundlePlan, error)
+	Apply(kit *rest.Kit, bundle *metadata.SchemaBundle) (*metadata.SchemaBundlePlan, error)
+}
+
+// NewSchemaBundleOperation create a new schema bundle operation instance
+func NewSchemaBundleOperation(client apimachinery.ClientSetInterface, authManager *extensions.AuthManager) SchemaBundleOperationInterface {
+	return &schemaBundle{
+		clientSet:   client,
+		authManager: authManager,
+	}
+}
+
+type schemaBundle struct {
+	clientSet   apimachinery.ClientSetInterface
+	authManager *extensions.AuthManager
+	cls         ClassificationOperationInterface
+	obj         ObjectOperationInterface
+	grp         GroupOperationInterface
+	attr        AttributeOperationInterface
+	unique      UniqueOperationInterface
+	asst        AssociationOperationInterface
+}
+
+func (s *schemaBundle) SetProxy(cls ClassificationOperationInterface, obj ObjectOperationInterface, grp GroupOperationInterface,
+	attr AttributeOperationInterface, unique UniqueOperationInterface, asst AssociationOperationInterface) {
+	s.cls = cls
+	s.obj = obj
+	s.grp = grp
+	s.attr = attr
+	s.unique = unique
+	s.asst = asst
+}
+
+// loadState loads the global models, the business custom attributes and the uniques that contain association keys
+// are not a part of the schema bundle, so they are left out.
+func (s *schemaBundle) loadState(kit *rest.Kit) (*schemaState, error) {
+	state := newSchemaState()
+	globalCond := metadata.QueryCondition{Condition: metadata.BizLabelNotExist.Clone()}
+
+	clsRsp, err := s.clientSet.CoreService().Model().ReadModelClassification(kit.Ctx, kit.Header, &globalCond)
+	if err != nil {
+		blog.Errorf("load schema state, but read classifications failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !clsRsp.Result {
+		blog.Errorf("load schema state, but read classifications failed, err: %s, rid: %s", clsRsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(clsRsp.Code, clsRsp.ErrMsg)
+	}
+	for _, cls := range clsRsp.Data.Info {
+		state.classifications[cls.ClassificationID] = cls
+	}
+
+	objRsp, err := s.clientSet.CoreService().Model().ReadModel(kit.Ctx, kit.Header, &globalCond)
+	if err != nil {
+		blog.Errorf("load schema state, but read objects failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !objRsp.Result {
+		blog.Errorf("load schema state, but read objects failed, err: %s, rid: %s", objRsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(objRsp.Code, objRsp.ErrMsg)
+	}
+	for _, obj := range objRsp.Data.Info {
+		state.objects[obj.Spec.ObjectID] = obj.Spec
+	}
+
+	grpRsp, err := s.clientSet.CoreService().Model().ReadAttributeGroupByCondition(kit.Ctx, kit.Header, globalCond)
+	if err != nil {
+		blog.Errorf("load schema state, but read attribute groups failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !grpRsp.Result {
+		blog.Errorf("load schema state, but read attribute groups failed, err: %s, rid: %s", grpRsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(grpRsp.Code, grpRsp.ErrMsg)
+	}
+	for _, grp := range grpRsp.Data.Info {
+		state.addGroup(grp)
+	}
+
+	attrRsp, err := s.clientSet.CoreService().Model().ReadModelAttrByCondition(kit.Ctx, kit.Header, &globalCond)
+	if err != nil {
+		blog.Errorf("load schema state, but read attributes failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !attrRsp.Result {
+		blog.Errorf("load schema state, but read attributes failed, err: %s, rid: %s", attrRsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(attrRsp.Code, attrRsp.ErrMsg)
+	}
+	propertyIDs := make(map[int64]string)
+	for _, attr := range attrRsp.Data.Info {
+		if attr.BizID != 0 {
+			continue
+		}
+		state.addAttribute(attr)
+		propertyIDs[attr.ID] = attr.PropertyID
+	}
+
+	uniqueRsp, err := s.clientSet.CoreService().Model().ReadModelAttrUnique(kit.Ctx, kit.Header, globalCond)
+	if err != nil {
+		blog.Errorf("load schema state, but read uniques failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !uniqueRsp.Result {
+		blog.Errorf("load schema state, but read uniques failed, err: %s, rid: %s", uniqueRsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(uniqueRsp.Code, uniqueRsp.ErrMsg)
+	}
+	for _, unique := range uniqueRsp.Data.Info {
+		keys := make([]string, 0)
+		for _, key := range unique.Keys {
+			propertyID, exist := propertyIDs[int64(key.ID)]
+			if key.Kind != metadata.UniqueKeyKindProperty || !exist {
+				keys = nil
+				break
+			}
+			keys = append(keys, propertyID)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		sort.Strings(keys)
+		state.uniques[unique.ObjID] = append(state.uniques[unique.ObjID], schemaStateUnique{
+			id:        unique.ID,
+			keys:      keys,
+			mustCheck: unique.MustCheck,
+			isPre:     unique.Ispre,
+		})
+	}
+
+	kindRsp, err := s.clientSet.CoreService().Association().ReadAssociationType(kit.Ctx, kit.Header, &metadata.QueryCondition{})
+	if err != nil {
+		blog.Errorf("load schema state, but read association kinds failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !kindRsp.Result {
+		blog.Errorf("load schema state, but read association kinds failed, err: %s, rid: %s", kindRsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(kindRsp.Code, kindRsp.ErrMsg)
+	}
+	for _, kind := range kindRsp.Data.Info {
+		state.asstKinds[kind.AssociationKindID] = true
+	}
+
+	asstRsp, err := s.clientSet.CoreService().Association().ReadModelAssociation(kit.Ctx, kit.Header, &metadata.QueryCondition{})
+	if err != nil {
+		blog.Errorf("load schema state, but read associations failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !asstRsp.Result {
+		blog.Errorf("load schema state, but read associations failed, err: %s, rid: %s", asstRsp.ErrMsg, kit.Rid)
+		return nil, kit.CCError.New(asstRsp.Code, asstRsp.ErrMsg)
+	}
+	for _, asst := range asstRsp.Data.Info {
+		state.associations[asst.AssociationName] = asst
+	}
+
+	return state, nil
+}
+
+// Export exports the global models, the preset attributes, uniques and associations and the mainline
+// associations are created by cmdb itself, so they are not exported.
+func (s *schemaBundle) Export(kit *rest.Kit, option *metadata.ExportSchemaBundleOption) (*metadata.SchemaBundle, error) {
+	state, err := s.loadState(kit)
+	if err != nil {
+		return nil, err
+	}
+	return exportSchemaBundle(kit, state, option)
+}
+
+func exportSchemaBundle(kit *rest.Kit, state *schemaState, option *metadata.ExportSchemaBundleOption) (*metadata.SchemaBundle, error) {
+	objIDs := option.ObjectIDs
+	if len(objIDs) == 0 {
+		for objID := range state.objects {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	sort.Strings(objIDs)
+
+	bundle := &metadata.SchemaBundle{
+		APIVersion:      metadata.SchemaBundleAPIVersion,
+		Version:         option.Version,
+		Classifications: make([]metadata.SchemaClassification, 0),
+		Objects:         make([]metadata.SchemaObject, 0),
+		Associations:    make([]metadata.SchemaAssociation, 0),
+	}
+
+	exported := make(map[string]bool)
+	classifications := make(map[string]bool)
+	for _, objID := range objIDs {
+		obj, exist := state.objects[objID]
+		if !exist {
+			blog.Errorf("export schema bundle, but object %s does not exist, rid: %s", objID, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+		}
+		exported[objID] = true
+		classifications[obj.ObjCls] = true
+		bundle.Objects = append(bundle.Objects, exportSchemaObject(state, obj))
+	}
+
+	for clsID, cls := range state.classifications {
+		if len(option.ObjectIDs) != 0 && !classifications[clsID] {
+			continue
+		}
+		bundle.Classifications = append(bundle.Classifications, metadata.SchemaClassification{
+			ID:   cls.ClassificationID,
+			Name: cls.ClassificationName,
+			Type: cls.ClassificationType,
+			Icon: cls.ClassificationIcon,
+		})
+	}
+	sort.Slice(bundle.Classifications, func(i, j int) bool {
+		return bundle.Classifications[i].ID < bundle.Classifications[j].ID
+	})
+
+	for _, asst := range state.associations {
+		if asst.AsstKindID == common.AssociationKindMainline || (asst.IsPre != nil && *asst.IsPre) {
+			continue
+		}
+		if !exported[asst.ObjectID] || !exported[asst.AsstObjID] {
+			continue
+		}
+		bundle.Associations = append(bundle.Associations, metadata.SchemaAssociation{
+			ID:        asst.AssociationName,
+			Name:      asst.AssociationAliasName,
+			ObjectID:  asst.ObjectID,
+			AsstObjID: asst.AsstObjID,
+			AsstKind:  asst.AsstKindID,
+			Mapping:   asst.Mapping,
+			OnDelete:  asst.OnDelete,
+		})
+	}
+	sort.Slice(bundle.Associations, func(i, j int) bool {
+		return bundle.Associations[i].ID < bundle.Associations[j].ID
+	})
+
+	return bundle, nil
+}
+
+func exportSchemaObject(state *schemaState, obj metadata.Object) metadata.SchemaObject {
+	schemaObj := metadata.SchemaObject{
+		ID:               obj.ObjectID,
+		Name:             obj.ObjectName,
+		ClassificationID: obj.ObjCls,
+		Icon:             obj.ObjIcon,
+		Groups:           make([]metadata.SchemaAttributeGroup, 0),
+		Attributes:       make([]metadata.SchemaAttribute, 0),
+		Uniques:          make([]metadata.SchemaUnique, 0),
+	}
+
+	for _, grp := range state.groups[obj.ObjectID] {
+		schemaObj.Groups = append(schemaObj.Groups, metadata.SchemaAttributeGroup{
+			ID:         grp.GroupID,
+			Name:       grp.GroupName,
+			Index:      grp.GroupIndex,
+			IsCollapse: grp.IsCollapse,
+		})
+	}
+	sort.Slice(schemaObj.Groups, func(i, j int) bool {
+		if schemaObj.Groups[i].Index != schemaObj.Groups[j].Index {
+			return schemaObj.Groups[i].Index < schemaObj.Groups[j].Index
+		}
+		return schemaObj.Groups[i].ID < schemaObj.Groups[j].ID
+	})
+
+	for _, attr := range state.attributes[obj.ObjectID] {
+		if attr.IsPre {
+			continue
+		}
+		schemaObj.Attributes = append(schemaObj.Attributes, metadata.SchemaAttribute{
+			ID:           attr.PropertyID,
+			Name:         attr.PropertyName,
+			GroupID:      attr.PropertyGroup,
+			Index:        attr.PropertyIndex,
+			PropertyType: attr.PropertyType,
+			Unit:         attr.Unit,
+			Placeholder:  attr.Placeholder,
+			IsEditable:   attr.IsEditable,
+			IsRequired:   attr.IsRequired,
+			Option:       attr.Option,
+			Description:  attr.Description,
+		})
+	}
+	sort.Slice(schemaObj.Attributes, func(i, j int) bool {
+		if schemaObj.Attributes[i].Index != schemaObj.Attributes[j].Index {
+			return schemaObj.Attributes[i].Index < schemaObj.Attributes[j].Index
+		}
+		return schemaObj.Attributes[i].ID < schemaObj.Attributes[j].ID
+	})
+
+	for _, unique := range state.uniques[obj.ObjectID] {
+		if unique.isPre {
+			continue
+		}
+		schemaObj.Uniques = append(schemaObj.Uniques, metadata.SchemaUnique{
+			Keys:      unique.keys,
+			MustCheck: unique.mustCheck,
+		})
+	}
+	sort.Slice(schemaObj.Uniques, func(i, j int) bool {
+		return strings.Join(schemaObj.Uniques[i].Keys, ",") < strings.Join(schemaObj.Uniques[j].Keys, ",")
+	})
+
+	return schemaObj
+}
+
+// ParseBundle returns the bundle in the request, the content of a bundle is parsed as json or yaml
+func (s *schemaBundle) ParseBundle(kit *rest.Kit, request *metadata.SchemaBundleRequest) (*metadata.SchemaBundle, error) {
+	bundle := request.Bundle
+	if bundle == nil {
+		var err error
+		bundle, err = decodeSchemaBundle(request.Content)
+		if err != nil {
+			blog.Errorf("parse schema bundle failed, err: %v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrTopoSchemaBundleInvalid, err.Error())
+		}
+	}
+
+	if err := bundle.Validate(); err != nil {
+		blog.Errorf("schema bundle is invalid, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrTopoSchemaBundleInvalid, err.Error())
+	}
+	return bundle, nil
+}
+
+func decodeSchemaBundle(content string) (*metadata.SchemaBundle, error) {
+	bundle := new(metadata.SchemaBundle)
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "{") {
+		if err := json.Unmarshal([]byte(content), bundle); err != nil {
+			return nil, err
+		}
+		return bundle, nil
+	}
+
+	if err := yaml.Unmarshal([]byte(content), bundle); err != nil {
+		return nil, err
+	}
+	// yaml decodes the maps in the options as map[interface{}]interface{}, they can't be encoded as json
+	for objIdx := range bundle.Objects {
+		for attrIdx := range bundle.Objects[objIdx].Attributes {
+			attr := &bundle.Objects[objIdx].Attributes[attrIdx]
+			attr.Option = convertYAMLValue(attr.Option)
+		}
+	}
+	return bundle, nil
+}
+
+func convertYAMLValue(value interface{}) interface{} {
+	switch val := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			converted[fmt.Sprint(key)] = convertYAMLValue(item)
+		}
+		return converted
+	case []interface{}:
+		for idx := range val {
+			val[idx] = convertYAMLValue(val[idx])
+		}
+		return val
+	default:
+		return value
+	}
+}
+
+// ExportSchemaBundleYAML encodes the bundle as yaml
+func ExportSchemaBundleYAML(bundle *metadata.SchemaBundle) (string, error) {
+	content, err := yaml.Marshal(bundle)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func (s *schemaBundle) Plan(kit *rest.Kit, bundle *metadata.SchemaBundle) (*metadata.SchemaBundlePlan, error) {
+	state, err := s.loadState(kit)
+	if err != nil {
+		return nil, err
+	}
+	return planSchemaBundle(bundle, state), nil
+}
+
+// Apply applies the bundle stage by stage, the state is reloaded before each stage so that the things created
+// by the former stages can be used by the later ones. Nothing is applied if the bundle has conflicts.
+// The changes are written by several apis and registered to iam one by one, so every applied change is
+// recorded with how to undo it, and they are undone in the reverse order if a later change fails.
+func (s *schemaBundle) Apply(kit *rest.Kit, bundle *metadata.SchemaBundle) (*metadata.SchemaBundlePlan, error) {
+	plan, err := s.Plan(kit, bundle)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Conflicts) > 0 {
+		blog.Errorf("apply schema bundle %s, but it has conflicts: %#v, rid: %s", bundle.Version, plan.Conflicts, kit.Rid)
+		return plan, kit.CCError.CCError(common.CCErrTopoSchemaBundleConflict)
+	}
+
+	undo := new(schemaUndoLog)
+	for _, stage := range schemaBundleStages {
+		state, err := s.loadState(kit)
+		if err != nil {
+			s.rollback(kit, bundle, undo)
+			return nil, err
+		}
+
+		planner := newSchemaPlanner(bundle, state)
+		planner.planStage(stage)
+		if len(planner.plan.Conflicts) > 0 {
+			blog.Errorf("apply schema bundle %s, but stage %s has conflicts: %#v, rid: %s", bundle.Version, stage,
+				planner.plan.Conflicts, kit.Rid)
+			s.rollback(kit, bundle, undo)
+			return plan, kit.CCError.CCError(common.CCErrTopoSchemaBundleConflict)
+		}
+
+		for _, change := range planner.plan.Changes {
+			if err := s.applyChange(kit, bundle, state, change, undo); err != nil {
+				blog.Errorf("apply schema bundle %s change %#v failed, err: %v, rid: %s", bundle.Version, change, err, kit.Rid)
+				s.rollback(kit, bundle, undo)
+				return nil, err
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// schemaUndoLog records how to undo the applied changes of a bundle
+type schemaUndoLog struct {
+	steps []schemaUndoStep
+}
+
+type schemaUndoStep struct {
+	change metadata.SchemaBundleChange
+	undo   func() error
+}
+
+func (l *schemaUndoLog) add(change metadata.SchemaBundleChange, undo func() error) {
+	l.steps = append(l.steps, schemaUndoStep{change: change, undo: undo})
+}
+
+// rollback undoes the applied changes in the reverse order, it goes on when a step fails so that as much as
+// possible is undone, the failed steps are logged to be fixed manually.
+func (s *schemaBundle) rollback(kit *rest.Kit, bundle *metadata.SchemaBundle, undo *schemaUndoLog) {
+	for idx := len(undo.steps) - 1; idx >= 0; idx-- {
+		step := undo.steps[idx]
+		if err := step.undo(); err != nil {
+			blog.Errorf("roll back schema bundle %s change %#v failed, err: %v, rid: %s", bundle.Version, step.change,
+				err, kit.Rid)
+		}
+	}
+	undo.steps = nil
+}
+
+func (s *schemaBundle) applyChange(kit *rest.Kit, bundle *metadata.SchemaBundle, state *schemaState,
+	change metadata.SchemaBundleChange, undo *schemaUndoLog) error {
+
+	switch change.Kind {
+	case metadata.SchemaBundleKindClassification:
+		return s.applyClassification(kit, bundle, state, change, undo)
+	case metadata.SchemaBundleKindObject:
+		return s.applyObject(kit, bundle, state, change, undo)
+	case metadata.SchemaBundleKindGroup:
+		return s.applyGroup(kit, bundle, state, change, undo)
+	case metadata.SchemaBundleKindAttribute:
+		return s.applyAttribute(kit, bundle, state, change, undo)
+	case metadata.SchemaBundleKindUnique:
+		return s.applyUnique(kit, bundle, state, change, undo)
+	case metadata.SchemaBundleKindAssociation:
+		return s.applyAssociation(kit, bundle, state, change, undo)
+	}
+	return nil
+}
+
+// changedData returns the data to create, or the changed fields of the data to update
+func changedData(data mapstr.MapStr, change metadata.SchemaBundleChange) mapstr.MapStr {
+	if change.Action == metadata.SchemaBundleActionCreate {
+		return data
+	}
+	changed := mapstr.New()
+	for _, field := range change.Fields {
+		changed[field] = data[field]
+	}
+	return changed
+}
+
+func (s *schemaBundle) applyClassification(kit *rest.Kit, bundle *metadata.SchemaBundle, state *schemaState,
+	change metadata.SchemaBundleChange, undo *schemaUndoLog) error {
+
+	for _, cls := range bundle.Classifications {
+		if cls.ID != change.ID {
+			continue
+		}
+		data := mapstr.MapStr{
+			common.BKClassificationIDField:   cls.ID,
+			common.BKClassificationNameField: cls.Name,
+			"bk_classification_type":         cls.Type,
+			common.BKClassificationIconField: cls.Icon,
+			common.BKOwnerIDField:            kit.SupplierAccount,
+		}
+
+		if change.Action == metadata.SchemaBundleActionCreate {
+			created, err := s.cls.CreateClassification(kit, data)
+			if err != nil {
+				return err
+			}
+			id := created.Classify().ID
+			undo.add(change, func() error {
+				return s.cls.DeleteClassification(kit, id, nil, nil)
+			})
+			return nil
+		}
+
+		current := state.classifications[cls.ID]
+		if err := s.cls.UpdateClassification(kit, changedData(data, change), current.ID, nil); err != nil {
+			return err
+		}
+		undo.add(change, func() error {
+			return s.cls.UpdateClassification(kit, changedData(current.ToMapStr(), change), current.ID, nil)
+		})
+		return nil
+	}
+	return nil
+}
+
+func (s *schemaBundle) applyObject(kit *rest.Kit, bundle *metadata.SchemaBundle, state *schemaState,
+	change metadata.SchemaBundleChange, undo *schemaUndoLog) error {
+
+	obj, exist := findSchemaObject(bundle, change.ObjectID)
+	if !exist {
+		return nil
+	}
+	data := mapstr.MapStr{
+		common.BKObjIDField:            obj.ID,
+		common.BKObjNameField:          obj.Name,
+		common.BKClassificationIDField: obj.ClassificationID,
+		common.BKObjIconField:          obj.Icon,
+		common.BKOwnerIDField:          kit.SupplierAccount,
+		common.CreatorField:            kit.User,
+	}
+
+	if change.Action == metadata.SchemaBundleActionCreate {
+		created, err := s.obj.CreateObject(kit, false, data, nil)
+		if err != nil {
+			return err
+		}
+		id := created.Object().ID
+		undo.add(change, func() error {
+			return s.obj.DeleteObject(kit, id, false, nil)
+		})
+		return nil
+	}
+
+	current := state.objects[obj.ID]
+	if err := s.obj.UpdateObject(kit, changedData(data, change), current.ID); err != nil {
+		return err
+	}
+	undo.add(change, func() error {
+		return s.obj.UpdateObject(kit, changedData(current.ToMapStr(), change), current.ID)
+	})
+	return nil
+}
+
+func (s *schemaBundle) applyGroup(kit *rest.Kit, bundle *metadata.SchemaBundle, state *schemaState,
+	change metadata.SchemaBundleChange, undo *schemaUndoLog) error {
+
+	obj, exist := findSchemaObject(bundle, change.ObjectID)
+	if !exist {
+		return nil
+	}
+	for _, grp := range obj.Groups {
+		if grp.ID != change.ID {
+			continue
+		}
+
+		if change.Action == metadata.SchemaBundleActionCreate {
+			data := mapstr.MapStr{
+				common.BKObjIDField:              obj.ID,
+				"bk_group_id":                    grp.ID,
+				common.BKPropertyGroupNameField:  grp.Name,
+				common.BKPropertyGroupIndexField: grp.Index,
+				common.BKIsCollapseField:         grp.IsCollapse,
+				common.BKOwnerIDField:            kit.SupplierAccount,
+			}
+			created, err := s.grp.CreateObjectGroup(kit, data, nil)
+			if err != nil {
+				return err
+			}
+			id := created.Group().ID
+			undo.add(change, func() error {
+				if err := s.grp.DeleteObjectGroup(kit, id); err != nil {
+					return err
+				}
+				return s.authManager.DeregisterModelAttributeGroupByID(kit.Ctx, kit.Header, id)
+			})
+			if err := s.authManager.RegisterModelAttributeGroup(kit.Ctx, kit.Header, created.Group()); err != nil {
+				blog.Errorf("create attribute group %s success, but register it to iam failed, err: %v, rid: %s", grp.ID, err, kit.Rid)
+				return kit.CCError.Error(common.CCErrCommRegistResourceToIAMFailed)
+			}
+			return nil
+		}
+
+		previous := state.groups[obj.ID][grp.ID]
+		if err := s.updateGroup(kit, previous, grp.Name, grp.Index, grp.IsCollapse); err != nil {
+			return err
+		}
+		undo.add(change, func() error {
+			return s.updateGroup(kit, previous, previous.GroupName, previous.GroupIndex, previous.IsCollapse)
+		})
+		return nil
+	}
+	return nil
+}
+
+// updateGroup updates the group and its iam resource
+func (s *schemaBundle) updateGroup(kit *rest.Kit, group metadata.Group, name string, index int64, isCollapse bool) error {
+	cond := &metadata.UpdateGroupCondition{}
+	cond.Condition.ID = group.ID
+	cond.Data.Name = &name
+	cond.Data.Index = &index
+	cond.Data.IsCollapse = &isCollapse
+	if err := s.grp.UpdateObjectGroup(kit, cond); err != nil {
+		return err
+	}
+
+	group.GroupName = name
+	group.GroupIndex = index
+	group.IsCollapse = isCollapse
+	if err := s.authManager.UpdateRegisteredModelAttributeGroup(kit.Ctx, kit.Header, group); err != nil {
+		blog.Errorf("update attribute group %s success, but update it to iam failed, err: %v, rid: %s", group.GroupID, err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommRegistResourceToIAMFailed)
+	}
+	return nil
+}
+
+func (s *schemaBundle) applyAttribute(kit *rest.Kit, bundle *metadata.SchemaBundle, state *schemaState,
+	change metadata.SchemaBundleChange, undo *schemaUndoLog) error {
+
+	obj, exist := findSchemaObject(bundle, change.ObjectID)
+	if !exist {
+		return nil
+	}
+	for _, attr := range obj.Attributes {
+		if attr.ID != change.ID {
+			continue
+		}
+		data := mapstr.MapStr{
+			common.BKObjIDField:         obj.ID,
+			common.BKPropertyIDField:    attr.ID,
+			common.BKPropertyNameField:  attr.Name,
+			common.BKPropertyGroupField: schemaAttributeGroup(attr),
+			common.BKPropertyIndexField: attr.Index,
+			common.BKPropertyTypeField:  attr.PropertyType,
+			"unit":                      attr.Unit,
+			"placeholder":               attr.Placeholder,
+			"editable":                  attr.IsEditable,
+			common.BKIsRequiredField:    attr.IsRequired,
+			common.BKOptionField:        attr.Option,
+			common.BKDescriptionField:   attr.Description,
+			common.BKOwnerIDField:       kit.SupplierAccount,
+			common.CreatorField:         kit.User,
+		}
+
+		if change.Action == metadata.SchemaBundleActionCreate {
+			created, err := s.attr.CreateObjectAttribute(kit, data, nil)
+			if err != nil {
+				return err
+			}
+			id := created.Attribute().ID
+			undo.add(change, func() error {
+				cond := condition.CreateCondition().Field(common.BKFieldID).Eq(id)
+				if err := s.attr.DeleteObjectAttribute(kit, cond, nil); err != nil {
+					return err
+				}
+				return s.authManager.DeregisterModelAttributeByID(kit.Ctx, kit.Header, id)
+			})
+			if err := s.authManager.RegisterModelAttribute(kit.Ctx, kit.Header, *created.Attribute()); err != nil {
+				blog.Errorf("create attribute %s success, but register it to iam failed, err: %v, rid: %s", attr.ID, err, kit.Rid)
+				return kit.CCError.Error(common.CCErrCommRegistResourceToIAMFailed)
+			}
+			return nil
+		}
+
+		current := state.attributes[obj.ID][attr.ID]
+		if err := s.updateAttribute(kit, current, changedData(data, change)); err != nil {
+			return err
+		}
+		undo.add(change, func() error {
+			return s.updateAttribute(kit, current, changedData(current.ToMapStr(), change))
+		})
+		return nil
+	}
+	return nil
+}
+
+// updateAttribute updates the attribute and its iam resource
+func (s *schemaBundle) updateAttribute(kit *rest.Kit, attr metadata.Attribute, data mapstr.MapStr) error {
+	if err := s.attr.UpdateObjectAttribute(kit, data, attr.ID, 0); err != nil {
+		return err
+	}
+	if err := s.authManager.UpdateRegisteredModelAttributeByID(kit.Ctx, kit.Header, attr.ID); err != nil {
+		blog.Errorf("update attribute %s success, but update it to iam failed, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommRegistResourceToIAMFailed)
+	}
+	return nil
+}
+
+func (s *schemaBundle) applyUnique(kit *rest.Kit, bundle *metadata.SchemaBundle, state *schemaState,
+	change metadata.SchemaBundleChange, undo *schemaUndoLog) error {
+
+	obj, exist := findSchemaObject(bundle, change.ObjectID)
+	if !exist {
+		return nil
+	}
+	for _, unique := range obj.Uniques {
+		keys := sortedSchemaKeys(unique.Keys)
+		if strings.Join(keys, ",") != change.ID {
+			continue
+		}
+
+		uniqueKeys := state.uniqueKeys(obj.ID, keys)
+		if change.Action == metadata.SchemaBundleActionCreate {
+			request := &metadata.CreateUniqueRequest{MustCheck: unique.MustCheck, Keys: uniqueKeys}
+			created, err := s.unique.Create(kit, obj.ID, request, nil)
+			if err != nil {
+				return err
+			}
+			id := uint64(created.ID)
+			undo.add(change, func() error {
+				return s.unique.Delete(kit, obj.ID, id, nil)
+			})
+			return nil
+		}
+
+		for _, current := range state.uniques[obj.ID] {
+			if strings.Join(current.keys, ",") != change.ID {
+				continue
+			}
+			request := &metadata.UpdateUniqueRequest{MustCheck: unique.MustCheck, Keys: uniqueKeys}
+			if err := s.unique.Update(kit, obj.ID, current.id, request); err != nil {
+				return err
+			}
+			previous := current
+			undo.add(change, func() error {
+				request := &metadata.UpdateUniqueRequest{MustCheck: previous.mustCheck,
+					Keys: state.uniqueKeys(obj.ID, previous.keys)}
+				return s.unique.Update(kit, obj.ID, previous.id, request)
+			})
+			return nil
+		}
+		return nil
+	}
+	return nil
+}
+
+func (s *schemaBundle) applyAssociation(kit *rest.Kit, bundle *metadata.SchemaBundle, state *schemaState,
+	change metadata.SchemaBundleChange, undo *schemaUndoLog) error {
+
+	for _, asst := range bundle.Associations {
+		if asst.ID != change.ID {
+			continue
+		}
+		onDelete := asst.OnDelete
+		if len(onDelete) == 0 {
+			onDelete = metadata.NoAction
+		}
+
+		if change.Action == metadata.SchemaBundleActionCreate {
+			association := &metadata.Association{
+				OwnerID:              kit.SupplierAccount,
+				AssociationName:      asst.ID,
+				AssociationAliasName: asst.Name,
+				ObjectID:             asst.ObjectID,
+				AsstObjID:            asst.AsstObjID,
+				AsstKindID:           asst.AsstKind,
+				Mapping:              asst.Mapping,
+				OnDelete:             onDelete,
+			}
+			created, err := s.asst.CreateCommonAssociation(kit, association, nil)
+			if err != nil {
+				return err
+			}
+			id := created.ID
+			undo.add(change, func() error {
+				return s.asst.DeleteAssociationWithPreCheck(kit, id)
+			})
+			return nil
+		}
+
+		data := mapstr.MapStr{
+			"bk_obj_asst_name": asst.Name,
+			"on_delete":        onDelete,
+		}
+		current := state.associations[asst.ID]
+		if err := s.asst.UpdateAssociation(kit, changedData(data, change), current.ID, nil); err != nil {
+			return err
+		}
+		undo.add(change, func() error {
+			return s.asst.UpdateAssociation(kit, changedData(current.ToMapStr(), change), current.ID, nil)
+		})
+		return nil
+	}
+	return nil
+}
+
+func findSchemaObject(bundle *metadata.SchemaBundle, objID string) (metadata.SchemaObject, bool) {
+	for _, obj := range bundle.Objects {
+		if obj.ID == objID {
+			return obj, true
+		}
+	}
+	return metadata.SchemaObject{}, false
+}
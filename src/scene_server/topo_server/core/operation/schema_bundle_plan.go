@@ -0,0 +1,536 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+// schemaBundleStages is the order that a schema bundle is applied in, every stage depends on the ones before it.
+var schemaBundleStages = []metadata.SchemaBundleKind{
+	metadata.SchemaBundleKindClassification,
+	metadata.SchemaBundleKindObject,
+	metadata.SchemaBundleKindGroup,
+	metadata.SchemaBundleKindAttribute,
+	metadata.SchemaBundleKindUnique,
+	metadata.SchemaBundleKindAssociation,
+}
+
+// schemaState is the global models of cmdb that a schema bundle is planned against
+type schemaState struct {
+	classifications map[string]metadata.Classification
+	objects         map[string]metadata.Object
+	// groups and attributes are keyed by object id, then group id or property id
+	groups       map[string]map[string]metadata.Group
+	attributes   map[string]map[string]metadata.Attribute
+	uniques      map[string][]schemaStateUnique
+	associations map[string]metadata.Association
+	asstKinds    map[string]bool
+}
+
+// schemaStateUnique is a unique of the property keys, the keys are the sorted property ids
+type schemaStateUnique struct {
+	id        uint64
+	keys      []string
+	mustCheck bool
+	isPre     bool
+}
+
+func newSchemaState() *schemaState {
+	return &schemaState{
+		classifications: make(map[string]metadata.Classification),
+		objects:         make(map[string]metadata.Object),
+		groups:          make(map[string]map[string]metadata.Group),
+		attributes:      make(map[string]map[string]metadata.Attribute),
+		uniques:         make(map[string][]schemaStateUnique),
+		associations:    make(map[string]metadata.Association),
+		asstKinds:       make(map[string]bool),
+	}
+}
+
+func (s *schemaState) addGroup(group metadata.Group) {
+	if _, exist := s.groups[group.ObjectID]; !exist {
+		s.groups[group.ObjectID] = make(map[string]metadata.Group)
+	}
+	s.groups[group.ObjectID][group.GroupID] = group
+}
+
+func (s *schemaState) addAttribute(attr metadata.Attribute) {
+	if _, exist := s.attributes[attr.ObjectID]; !exist {
+		s.attributes[attr.ObjectID] = make(map[string]metadata.Attribute)
+	}
+	s.attributes[attr.ObjectID][attr.PropertyID] = attr
+}
+
+// uniqueKeys converts the property ids of an object to the keys of a unique
+func (s *schemaState) uniqueKeys(objID string, propertyIDs []string) []metadata.UniqueKey {
+	keys := make([]metadata.UniqueKey, 0)
+	for _, propertyID := range propertyIDs {
+		keys = append(keys, metadata.UniqueKey{
+			Kind: metadata.UniqueKeyKindProperty,
+			ID:   uint64(s.attributes[objID][propertyID].ID),
+		})
+	}
+	return keys
+}
+
+// schemaPlanner compares a bundle with the state stage by stage, after a stage is planned the state is changed
+// as if the stage has been applied, so that the later stages can be planned with the things it creates.
+type schemaPlanner struct {
+	bundle *metadata.SchemaBundle
+	state  *schemaState
+	plan   *metadata.SchemaBundlePlan
+}
+
+func newSchemaPlanner(bundle *metadata.SchemaBundle, state *schemaState) *schemaPlanner {
+	return &schemaPlanner{
+		bundle: bundle,
+		state:  state,
+		plan: &metadata.SchemaBundlePlan{
+			Changes:   make([]metadata.SchemaBundleChange, 0),
+			Conflicts: make([]metadata.SchemaBundleConflict, 0),
+		},
+	}
+}
+
+// planSchemaBundle returns all the changes and conflicts of applying the bundle to the state
+func planSchemaBundle(bundle *metadata.SchemaBundle, state *schemaState) *metadata.SchemaBundlePlan {
+	planner := newSchemaPlanner(bundle, state)
+	for _, stage := range schemaBundleStages {
+		planner.planStage(stage)
+	}
+	return planner.plan
+}
+
+func (p *schemaPlanner) planStage(stage metadata.SchemaBundleKind) {
+	switch stage {
+	case metadata.SchemaBundleKindClassification:
+		p.planClassifications()
+	case metadata.SchemaBundleKindObject:
+		p.planObjects()
+	case metadata.SchemaBundleKindGroup:
+		p.planGroups()
+	case metadata.SchemaBundleKindAttribute:
+		p.planAttributes()
+	case metadata.SchemaBundleKindUnique:
+		p.planUniques()
+	case metadata.SchemaBundleKindAssociation:
+		p.planAssociations()
+	}
+}
+
+func (p *schemaPlanner) change(kind metadata.SchemaBundleKind, objID, id string, fields []string) {
+	action := metadata.SchemaBundleActionCreate
+	if fields != nil {
+		if len(fields) == 0 {
+			return
+		}
+		action = metadata.SchemaBundleActionUpdate
+	}
+	p.plan.Changes = append(p.plan.Changes, metadata.SchemaBundleChange{
+		Kind:     kind,
+		Action:   action,
+		ObjectID: objID,
+		ID:       id,
+		Fields:   fields,
+	})
+}
+
+func (p *schemaPlanner) conflict(kind metadata.SchemaBundleKind, objID, id, reason string) {
+	p.plan.Conflicts = append(p.plan.Conflicts, metadata.SchemaBundleConflict{
+		Kind:     kind,
+		ObjectID: objID,
+		ID:       id,
+		Reason:   reason,
+	})
+}
+
+func (p *schemaPlanner) planClassifications() {
+	for _, cls := range p.bundle.Classifications {
+		current, exist := p.state.classifications[cls.ID]
+		if !exist {
+			p.change(metadata.SchemaBundleKindClassification, "", cls.ID, nil)
+			p.state.classifications[cls.ID] = metadata.Classification{
+				ClassificationID:   cls.ID,
+				ClassificationName: cls.Name,
+				ClassificationType: cls.Type,
+				ClassificationIcon: cls.Icon,
+			}
+			continue
+		}
+
+		fields := make([]string, 0)
+		if current.ClassificationName != cls.Name {
+			fields = append(fields, common.BKClassificationNameField)
+		}
+		if current.ClassificationType != cls.Type {
+			fields = append(fields, "bk_classification_type")
+		}
+		if current.ClassificationIcon != cls.Icon {
+			fields = append(fields, common.BKClassificationIconField)
+		}
+		p.change(metadata.SchemaBundleKindClassification, "", cls.ID, fields)
+	}
+}
+
+func (p *schemaPlanner) planObjects() {
+	for _, obj := range p.bundle.Objects {
+		if _, exist := p.state.classifications[obj.ClassificationID]; !exist {
+			p.conflict(metadata.SchemaBundleKindObject, obj.ID, obj.ID,
+				fmt.Sprintf("classification %s does not exist", obj.ClassificationID))
+			continue
+		}
+
+		current, exist := p.state.objects[obj.ID]
+		if !exist {
+			p.change(metadata.SchemaBundleKindObject, obj.ID, obj.ID, nil)
+			p.addCreatedObject(obj)
+			continue
+		}
+
+		// the preset objects are kept as they are, only their custom groups and attributes are applied
+		if current.IsPre {
+			continue
+		}
+
+		fields := make([]string, 0)
+		if current.ObjectName != obj.Name {
+			fields = append(fields, common.BKObjNameField)
+		}
+		if current.ObjCls != obj.ClassificationID {
+			fields = append(fields, common.BKClassificationIDField)
+		}
+		if current.ObjIcon != obj.Icon {
+			fields = append(fields, common.BKObjIconField)
+		}
+		p.change(metadata.SchemaBundleKindObject, obj.ID, obj.ID, fields)
+	}
+}
+
+// addCreatedObject adds the object and what's created along with it to the state, they are the default group,
+// the instance name attribute and the unique of it.
+func (p *schemaPlanner) addCreatedObject(obj metadata.SchemaObject) {
+	object := metadata.Object{
+		ObjectID:   obj.ID,
+		ObjectName: obj.Name,
+		ObjCls:     obj.ClassificationID,
+		ObjIcon:    obj.Icon,
+	}
+	p.state.objects[obj.ID] = object
+
+	p.state.addGroup(metadata.Group{
+		ObjectID:   obj.ID,
+		GroupID:    common.BKDefaultField,
+		GroupName:  "Default",
+		GroupIndex: -1,
+		IsDefault:  true,
+	})
+	p.state.addAttribute(metadata.Attribute{
+		ObjectID:      obj.ID,
+		PropertyID:    object.GetInstNameFieldName(),
+		PropertyGroup: common.BKDefaultField,
+		PropertyType:  common.FieldTypeSingleChar,
+		IsPre:         true,
+	})
+	p.state.uniques[obj.ID] = append(p.state.uniques[obj.ID], schemaStateUnique{
+		keys:      []string{object.GetInstNameFieldName()},
+		mustCheck: true,
+	})
+}
+
+func (p *schemaPlanner) planGroups() {
+	for _, obj := range p.bundle.Objects {
+		if _, exist := p.state.objects[obj.ID]; !exist {
+			continue
+		}
+
+		for _, grp := range obj.Groups {
+			current, exist := p.state.groups[obj.ID][grp.ID]
+			if !exist {
+				p.change(metadata.SchemaBundleKindGroup, obj.ID, grp.ID, nil)
+				p.state.addGroup(metadata.Group{
+					ObjectID:   obj.ID,
+					GroupID:    grp.ID,
+					GroupName:  grp.Name,
+					GroupIndex: grp.Index,
+					IsCollapse: grp.IsCollapse,
+				})
+				continue
+			}
+
+			if current.IsPre || current.IsDefault {
+				continue
+			}
+
+			fields := make([]string, 0)
+			if current.GroupName != grp.Name {
+				fields = append(fields, common.BKPropertyGroupNameField)
+			}
+			if current.GroupIndex != grp.Index {
+				fields = append(fields, common.BKPropertyGroupIndexField)
+			}
+			if current.IsCollapse != grp.IsCollapse {
+				fields = append(fields, common.BKIsCollapseField)
+			}
+			p.change(metadata.SchemaBundleKindGroup, obj.ID, grp.ID, fields)
+		}
+	}
+}
+
+func (p *schemaPlanner) planAttributes() {
+	for _, obj := range p.bundle.Objects {
+		if _, exist := p.state.objects[obj.ID]; !exist {
+			continue
+		}
+
+		for _, attr := range obj.Attributes {
+			groupID := schemaAttributeGroup(attr)
+			if _, exist := p.state.groups[obj.ID][groupID]; !exist {
+				p.conflict(metadata.SchemaBundleKindAttribute, obj.ID, attr.ID,
+					fmt.Sprintf("attribute group %s does not exist", groupID))
+				continue
+			}
+
+			current, exist := p.state.attributes[obj.ID][attr.ID]
+			if !exist {
+				p.change(metadata.SchemaBundleKindAttribute, obj.ID, attr.ID, nil)
+				p.state.addAttribute(metadata.Attribute{
+					ObjectID:      obj.ID,
+					PropertyID:    attr.ID,
+					PropertyName:  attr.Name,
+					PropertyGroup: groupID,
+					PropertyIndex: attr.Index,
+					PropertyType:  attr.PropertyType,
+					Unit:          attr.Unit,
+					Placeholder:   attr.Placeholder,
+					IsEditable:    attr.IsEditable,
+					IsRequired:    attr.IsRequired,
+					Option:        attr.Option,
+					Description:   attr.Description,
+				})
+				continue
+			}
+
+			if current.IsPre {
+				continue
+			}
+
+			if current.PropertyType != attr.PropertyType {
+				p.conflict(metadata.SchemaBundleKindAttribute, obj.ID, attr.ID,
+					fmt.Sprintf("property type can't be changed from %s to %s", current.PropertyType, attr.PropertyType))
+				continue
+			}
+
+			// the group and index of an attribute are changed by the attribute index api, they are only used
+			// when the attribute is created.
+			fields := make([]string, 0)
+			if current.PropertyName != attr.Name {
+				fields = append(fields, common.BKPropertyNameField)
+			}
+			if current.Unit != attr.Unit {
+				fields = append(fields, "unit")
+			}
+			if current.Placeholder != attr.Placeholder {
+				fields = append(fields, "placeholder")
+			}
+			if current.IsEditable != attr.IsEditable {
+				fields = append(fields, "editable")
+			}
+			if current.IsRequired != attr.IsRequired {
+				fields = append(fields, common.BKIsRequiredField)
+			}
+			if !sameSchemaValue(current.Option, attr.Option) {
+				fields = append(fields, common.BKOptionField)
+			}
+			if current.Description != attr.Description {
+				fields = append(fields, common.BKDescriptionField)
+			}
+			p.change(metadata.SchemaBundleKindAttribute, obj.ID, attr.ID, fields)
+		}
+	}
+}
+
+func (p *schemaPlanner) planUniques() {
+	for _, obj := range p.bundle.Objects {
+		if _, exist := p.state.objects[obj.ID]; !exist {
+			continue
+		}
+
+		// the uniques that are no longer must check are changed first, so that there is only one must check
+		// unique at any time.
+		creates := make([]string, 0)
+		updates := make([]string, 0)
+		for _, unique := range obj.Uniques {
+			keys := sortedSchemaKeys(unique.Keys)
+			id := strings.Join(keys, ",")
+
+			missing := false
+			for _, key := range keys {
+				if _, exist := p.state.attributes[obj.ID][key]; !exist {
+					p.conflict(metadata.SchemaBundleKindUnique, obj.ID, id, fmt.Sprintf("attribute %s does not exist", key))
+					missing = true
+				}
+			}
+			if missing {
+				continue
+			}
+
+			index := p.findUnique(obj.ID, keys)
+			if index < 0 {
+				creates = append(creates, id)
+				p.state.uniques[obj.ID] = append(p.state.uniques[obj.ID], schemaStateUnique{
+					keys:      keys,
+					mustCheck: unique.MustCheck,
+				})
+				continue
+			}
+
+			current := p.state.uniques[obj.ID][index]
+			if current.isPre || current.mustCheck == unique.MustCheck {
+				continue
+			}
+			if unique.MustCheck {
+				updates = append(updates, id)
+			} else {
+				updates = append([]string{id}, updates...)
+			}
+			p.state.uniques[obj.ID][index].mustCheck = unique.MustCheck
+		}
+
+		for _, id := range updates {
+			p.change(metadata.SchemaBundleKindUnique, obj.ID, id, []string{"must_check"})
+		}
+		for _, id := range creates {
+			p.change(metadata.SchemaBundleKindUnique, obj.ID, id, nil)
+		}
+
+		mustCheck := 0
+		for _, unique := range p.state.uniques[obj.ID] {
+			if unique.mustCheck {
+				mustCheck++
+			}
+		}
+		if mustCheck > 1 {
+			p.conflict(metadata.SchemaBundleKindUnique, obj.ID, obj.ID, "object can't have more than one must check unique")
+		}
+	}
+}
+
+func (p *schemaPlanner) findUnique(objID string, keys []string) int {
+	for index, unique := range p.state.uniques[objID] {
+		if strings.Join(unique.keys, ",") == strings.Join(keys, ",") {
+			return index
+		}
+	}
+	return -1
+}
+
+func (p *schemaPlanner) planAssociations() {
+	for _, asst := range p.bundle.Associations {
+		onDelete := asst.OnDelete
+		if len(onDelete) == 0 {
+			onDelete = metadata.NoAction
+		}
+
+		current, exist := p.state.associations[asst.ID]
+		if exist {
+			if current.ObjectID != asst.ObjectID || current.AsstObjID != asst.AsstObjID ||
+				current.AsstKindID != asst.AsstKind || current.Mapping != asst.Mapping {
+				p.conflict(metadata.SchemaBundleKindAssociation, asst.ObjectID, asst.ID,
+					"objects, association kind and mapping of an association can't be changed")
+				continue
+			}
+
+			if current.IsPre != nil && *current.IsPre {
+				continue
+			}
+
+			fields := make([]string, 0)
+			if current.AssociationAliasName != asst.Name {
+				fields = append(fields, "bk_obj_asst_name")
+			}
+			if current.OnDelete != onDelete {
+				fields = append(fields, "on_delete")
+			}
+			p.change(metadata.SchemaBundleKindAssociation, asst.ObjectID, asst.ID, fields)
+			continue
+		}
+
+		conflicted := false
+		for _, objID := range []string{asst.ObjectID, asst.AsstObjID} {
+			if _, exist := p.state.objects[objID]; !exist {
+				p.conflict(metadata.SchemaBundleKindAssociation, asst.ObjectID, asst.ID,
+					fmt.Sprintf("object %s does not exist", objID))
+				conflicted = true
+			}
+		}
+		if !p.state.asstKinds[asst.AsstKind] {
+			p.conflict(metadata.SchemaBundleKindAssociation, asst.ObjectID, asst.ID,
+				fmt.Sprintf("association kind %s does not exist", asst.AsstKind))
+			conflicted = true
+		}
+		for _, exist := range p.state.associations {
+			if exist.ObjectID == asst.ObjectID && exist.AsstObjID == asst.AsstObjID && exist.AsstKindID == asst.AsstKind {
+				p.conflict(metadata.SchemaBundleKindAssociation, asst.ObjectID, asst.ID,
+					fmt.Sprintf("objects are already associated by %s with the same association kind", exist.AssociationName))
+				conflicted = true
+			}
+		}
+		if conflicted {
+			continue
+		}
+
+		p.change(metadata.SchemaBundleKindAssociation, asst.ObjectID, asst.ID, nil)
+		p.state.associations[asst.ID] = metadata.Association{
+			AssociationName:      asst.ID,
+			AssociationAliasName: asst.Name,
+			ObjectID:             asst.ObjectID,
+			AsstObjID:            asst.AsstObjID,
+			AsstKindID:           asst.AsstKind,
+			Mapping:              asst.Mapping,
+			OnDelete:             onDelete,
+		}
+	}
+}
+
+// schemaAttributeGroup returns the group of the attribute, the attributes without a group are in the default group
+func schemaAttributeGroup(attr metadata.SchemaAttribute) string {
+	if len(attr.GroupID) == 0 {
+		return common.BKDefaultField
+	}
+	return attr.GroupID
+}
+
+func sortedSchemaKeys(keys []string) []string {
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// sameSchemaValue compares the values by their json encoding, the values decoded from json, yaml and
+// the database are of different types even if they are the same.
+func sameSchemaValue(a, b interface{}) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	return string(aJSON) == string(bJSON)
+}
@@ -0,0 +1,256 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+func testSchemaState() *schemaState {
+	state := newSchemaState()
+	state.classifications["bk_host_manage"] = metadata.Classification{ID: 1, ClassificationID: "bk_host_manage",
+		ClassificationName: "Host", ClassificationType: "inner", ClassificationIcon: "icon-host"}
+	state.objects["host"] = metadata.Object{ID: 1, ObjectID: "host", ObjectName: "Host", ObjCls: "bk_host_manage", IsPre: true}
+	state.addGroup(metadata.Group{ID: 1, ObjectID: "host", GroupID: "default", GroupName: "Default", GroupIndex: -1,
+		IsDefault: true, IsPre: true})
+	state.addAttribute(metadata.Attribute{ID: 1, ObjectID: "host", PropertyID: "bk_host_innerip", PropertyName: "IP",
+		PropertyGroup: "default", PropertyType: "singlechar", IsPre: true})
+	state.addAttribute(metadata.Attribute{ID: 2, ObjectID: "host", PropertyID: "owner", PropertyName: "Owner",
+		PropertyGroup: "default", PropertyIndex: 3, PropertyType: "enum", IsEditable: true,
+		Option: []interface{}{map[string]interface{}{"id": "a", "name": "A", "type": "text", "is_default": true}}})
+	state.uniques["host"] = []schemaStateUnique{{id: 1, keys: []string{"bk_host_innerip"}, mustCheck: true, isPre: true}}
+	state.asstKinds["belong"] = true
+	state.asstKinds["run"] = true
+	state.associations["host_belong_switch"] = metadata.Association{ID: 1, AssociationName: "host_belong_switch",
+		ObjectID: "host", AsstObjID: "switch", AsstKindID: "belong", Mapping: metadata.OneToManyMapping,
+		OnDelete: metadata.NoAction}
+	state.classifications["network"] = metadata.Classification{ID: 2, ClassificationID: "network", ClassificationName: "Network"}
+	state.objects["switch"] = metadata.Object{ID: 2, ObjectID: "switch", ObjectName: "Switch", ObjCls: "network"}
+	state.addGroup(metadata.Group{ID: 2, ObjectID: "switch", GroupID: "default", GroupName: "Default", GroupIndex: -1,
+		IsDefault: true})
+	state.addAttribute(metadata.Attribute{ID: 3, ObjectID: "switch", PropertyID: "bk_inst_name", PropertyGroup: "default",
+		PropertyType: "singlechar", IsPre: true})
+	state.uniques["switch"] = []schemaStateUnique{{id: 2, keys: []string{"bk_inst_name"}, mustCheck: true}}
+	return state
+}
+
+func TestPlanSchemaBundleCreate(t *testing.T) {
+	bundle := &metadata.SchemaBundle{
+		APIVersion:      metadata.SchemaBundleAPIVersion,
+		Classifications: []metadata.SchemaClassification{{ID: "database", Name: "Database"}},
+		Objects: []metadata.SchemaObject{
+			{
+				ID:               "mysql",
+				Name:             "MySQL",
+				ClassificationID: "database",
+				Groups:           []metadata.SchemaAttributeGroup{{ID: "default", Name: "Default", Index: -1}, {ID: "conn", Name: "Connection"}},
+				Attributes: []metadata.SchemaAttribute{
+					{ID: "port", Name: "Port", GroupID: "conn", PropertyType: "int"},
+					{ID: "version", Name: "Version", PropertyType: "singlechar"},
+				},
+				Uniques: []metadata.SchemaUnique{{Keys: []string{"bk_inst_name"}, MustCheck: true}, {Keys: []string{"version", "port"}}},
+			},
+		},
+		Associations: []metadata.SchemaAssociation{
+			{ID: "mysql_run_host", ObjectID: "mysql", AsstObjID: "host", AsstKind: "run", Mapping: metadata.ManyToManyMapping},
+		},
+	}
+	if err := bundle.Validate(); err != nil {
+		t.Fatalf("validate bundle failed, err: %v", err)
+	}
+
+	plan := planSchemaBundle(bundle, testSchemaState())
+	if len(plan.Conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %+v", plan.Conflicts)
+	}
+
+	expect := []metadata.SchemaBundleChange{
+		{Kind: metadata.SchemaBundleKindClassification, Action: metadata.SchemaBundleActionCreate, ID: "database"},
+		{Kind: metadata.SchemaBundleKindObject, Action: metadata.SchemaBundleActionCreate, ObjectID: "mysql", ID: "mysql"},
+		{Kind: metadata.SchemaBundleKindGroup, Action: metadata.SchemaBundleActionCreate, ObjectID: "mysql", ID: "conn"},
+		{Kind: metadata.SchemaBundleKindAttribute, Action: metadata.SchemaBundleActionCreate, ObjectID: "mysql", ID: "port"},
+		{Kind: metadata.SchemaBundleKindAttribute, Action: metadata.SchemaBundleActionCreate, ObjectID: "mysql", ID: "version"},
+		{Kind: metadata.SchemaBundleKindUnique, Action: metadata.SchemaBundleActionCreate, ObjectID: "mysql", ID: "port,version"},
+		{Kind: metadata.SchemaBundleKindAssociation, Action: metadata.SchemaBundleActionCreate, ObjectID: "mysql", ID: "mysql_run_host"},
+	}
+	if !reflect.DeepEqual(plan.Changes, expect) {
+		t.Fatalf("unexpected changes: %+v", plan.Changes)
+	}
+}
+
+func TestPlanSchemaBundleIdempotent(t *testing.T) {
+	bundle, err := exportSchemaBundle(&rest.Kit{}, testSchemaState(), &metadata.ExportSchemaBundleOption{})
+	if err != nil {
+		t.Fatalf("export bundle failed, err: %v", err)
+	}
+	if len(bundle.Objects) != 2 || len(bundle.Classifications) != 2 || len(bundle.Associations) != 1 {
+		t.Fatalf("unexpected bundle: %+v", bundle)
+	}
+	// the preset attributes and uniques are not exported
+	if len(bundle.Objects[0].Attributes) != 1 || len(bundle.Objects[0].Uniques) != 0 {
+		t.Fatalf("unexpected host schema: %+v", bundle.Objects[0])
+	}
+
+	// the bundle is transferred as yaml, then it's applied to the same models
+	content, err := ExportSchemaBundleYAML(bundle)
+	if err != nil {
+		t.Fatalf("encode bundle as yaml failed, err: %v", err)
+	}
+	decoded, err := decodeSchemaBundle(content)
+	if err != nil {
+		t.Fatalf("decode bundle failed, err: %v", err)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Fatalf("validate bundle failed, err: %v", err)
+	}
+
+	plan := planSchemaBundle(decoded, testSchemaState())
+	if len(plan.Changes) != 0 || len(plan.Conflicts) != 0 {
+		t.Fatalf("applying an exported bundle should change nothing, plan: %+v", plan)
+	}
+
+	decoded.Objects[0].Attributes[0].Name = "Host Owner"
+	decoded.Associations[0].OnDelete = metadata.DeleteSource
+	plan = planSchemaBundle(decoded, testSchemaState())
+	expect := []metadata.SchemaBundleChange{
+		{Kind: metadata.SchemaBundleKindAttribute, Action: metadata.SchemaBundleActionUpdate, ObjectID: "host", ID: "owner",
+			Fields: []string{"bk_property_name"}},
+		{Kind: metadata.SchemaBundleKindAssociation, Action: metadata.SchemaBundleActionUpdate, ObjectID: "host",
+			ID: "host_belong_switch", Fields: []string{"on_delete"}},
+	}
+	if !reflect.DeepEqual(plan.Changes, expect) {
+		t.Fatalf("unexpected changes: %+v", plan.Changes)
+	}
+}
+
+func TestPlanSchemaBundleConflicts(t *testing.T) {
+	bundle := &metadata.SchemaBundle{
+		APIVersion: metadata.SchemaBundleAPIVersion,
+		Objects: []metadata.SchemaObject{
+			{
+				ID:               "host",
+				Name:             "Host",
+				ClassificationID: "bk_host_manage",
+				Attributes:       []metadata.SchemaAttribute{{ID: "owner", Name: "Owner", PropertyType: "singlechar"}},
+				Uniques:          []metadata.SchemaUnique{{Keys: []string{"not_exist"}}},
+			},
+			{
+				ID:               "switch",
+				Name:             "Switch",
+				ClassificationID: "network",
+				Uniques:          []metadata.SchemaUnique{{Keys: []string{"bk_inst_name"}, MustCheck: true}},
+			},
+			{ID: "router", Name: "Router", ClassificationID: "not_exist"},
+		},
+		Associations: []metadata.SchemaAssociation{
+			{ID: "host_belong_switch", ObjectID: "host", AsstObjID: "switch", AsstKind: "belong",
+				Mapping: metadata.ManyToManyMapping},
+			{ID: "host_belong_router", ObjectID: "host", AsstObjID: "router", AsstKind: "belong",
+				Mapping: metadata.ManyToManyMapping},
+		},
+	}
+
+	plan := planSchemaBundle(bundle, testSchemaState())
+	conflicts := make(map[metadata.SchemaBundleKind][]string)
+	for _, conflict := range plan.Conflicts {
+		conflicts[conflict.Kind] = append(conflicts[conflict.Kind], conflict.ID)
+	}
+	expect := map[metadata.SchemaBundleKind][]string{
+		metadata.SchemaBundleKindObject:      {"router"},
+		metadata.SchemaBundleKindAttribute:   {"owner"},
+		metadata.SchemaBundleKindUnique:      {"not_exist"},
+		metadata.SchemaBundleKindAssociation: {"host_belong_switch", "host_belong_router"},
+	}
+	if !reflect.DeepEqual(conflicts, expect) {
+		t.Fatalf("unexpected conflicts: %+v", plan.Conflicts)
+	}
+}
+
+func TestPlanSchemaBundleMustCheck(t *testing.T) {
+	bundle := &metadata.SchemaBundle{
+		APIVersion: metadata.SchemaBundleAPIVersion,
+		Objects: []metadata.SchemaObject{
+			{
+				ID:               "switch",
+				Name:             "Switch",
+				ClassificationID: "network",
+				Attributes:       []metadata.SchemaAttribute{{ID: "sn", Name: "SN", PropertyType: "singlechar"}},
+				Uniques: []metadata.SchemaUnique{
+					{Keys: []string{"sn"}, MustCheck: true},
+					{Keys: []string{"bk_inst_name"}, MustCheck: false},
+				},
+			},
+		},
+	}
+
+	// the must check unique is moved to sn, so the old one is changed before sn's is created
+	plan := planSchemaBundle(bundle, testSchemaState())
+	if len(plan.Conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %+v", plan.Conflicts)
+	}
+	expect := []metadata.SchemaBundleChange{
+		{Kind: metadata.SchemaBundleKindAttribute, Action: metadata.SchemaBundleActionCreate, ObjectID: "switch", ID: "sn"},
+		{Kind: metadata.SchemaBundleKindUnique, Action: metadata.SchemaBundleActionUpdate, ObjectID: "switch",
+			ID: "bk_inst_name", Fields: []string{"must_check"}},
+		{Kind: metadata.SchemaBundleKindUnique, Action: metadata.SchemaBundleActionCreate, ObjectID: "switch", ID: "sn"},
+	}
+	if !reflect.DeepEqual(plan.Changes, expect) {
+		t.Fatalf("unexpected changes: %+v", plan.Changes)
+	}
+
+	bundle.Objects[0].Uniques = bundle.Objects[0].Uniques[:1]
+	plan = planSchemaBundle(bundle, testSchemaState())
+	if len(plan.Conflicts) != 1 || plan.Conflicts[0].Kind != metadata.SchemaBundleKindUnique {
+		t.Fatalf("two must check uniques should conflict, plan: %+v", plan)
+	}
+}
+
+func TestDecodeSchemaBundleYAML(t *testing.T) {
+	content := `
+api_version: v1
+version: "1.0"
+objects:
+- bk_obj_id: switch
+  bk_obj_name: Switch
+  bk_classification_id: network
+  attributes:
+  - bk_property_id: level
+    bk_property_name: Level
+    bk_property_type: enum
+    option:
+    - id: high
+      name: High
+      type: text
+      is_default: true
+`
+	bundle, err := decodeSchemaBundle(content)
+	if err != nil {
+		t.Fatalf("decode bundle failed, err: %v", err)
+	}
+	if err := bundle.Validate(); err != nil {
+		t.Fatalf("validate bundle failed, err: %v", err)
+	}
+
+	option, err := json.Marshal(bundle.Objects[0].Attributes[0].Option)
+	if err != nil {
+		t.Fatalf("option should be encoded as json, err: %v", err)
+	}
+	if string(option) != `[{"id":"high","is_default":true,"name":"High","type":"text"}]` {
+		t.Fatalf("unexpected option: %s", option)
+	}
+}
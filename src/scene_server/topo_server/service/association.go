@@ -523,3 +523,48 @@ func (s *Service) SearchTopoPath(ctx *rest.Contexts) {
 
 	ctx.RespEntity(result)
 }
+
+// SearchInstAssociationGraph search the association graph around an instance, it is used for
+// change impact analysis, the instances are walked hop by hop until max_depth or max_nodes is reached.
+func (s *Service) SearchInstAssociationGraph(ctx *rest.Contexts) {
+	request := new(metadata.SearchInstGraphRequest)
+	if err := ctx.DecodeInto(request); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if key, err := request.Validate(); err != nil {
+		blog.Errorf("search instance association graph, but request is invalid, key: %s, err: %v, rid: %s", key, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, key))
+		return
+	}
+
+	result, err := s.Core.AssociationOperation().SearchInstGraph(ctx.Kit, request)
+	if err != nil {
+		blog.Errorf("search instance association graph failed, request: %#v, err: %v, rid: %s", request, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// SearchInstAssociationShortestPath search the path with the fewest associations between two instances.
+func (s *Service) SearchInstAssociationShortestPath(ctx *rest.Contexts) {
+	request := new(metadata.SearchInstShortestPathRequest)
+	if err := ctx.DecodeInto(request); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if key, err := request.Validate(); err != nil {
+		blog.Errorf("search instance association shortest path, but request is invalid, key: %s, err: %v, rid: %s", key, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, key))
+		return
+	}
+
+	result, err := s.Core.AssociationOperation().SearchInstShortestPath(ctx.Kit, request)
+	if err != nil {
+		blog.Errorf("search instance association shortest path failed, request: %#v, err: %v, rid: %s", request, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
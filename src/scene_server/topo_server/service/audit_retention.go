@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// ListAuditRetentionPolicy lists the retention policies of the audit types
+func (s *Service) ListAuditRetentionPolicy(ctx *rest.Contexts) {
+	policies, err := s.Engine.CoreAPI.CoreService().Audit().ListAuditRetentionPolicy(ctx.Kit.Ctx, ctx.Kit.Header)
+	if err != nil {
+		blog.Errorf("ListAuditRetentionPolicy failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(policies)
+}
+
+// UpdateAuditRetentionPolicy sets the retention policy of an audit type, the policy is removed if its retention days is 0
+func (s *Service) UpdateAuditRetentionPolicy(ctx *rest.Contexts) {
+	policy := metadata.AuditRetentionPolicy{}
+	if err := ctx.DecodeInto(&policy); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, ok := policy.Validate(); !ok {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	if err := s.Engine.CoreAPI.CoreService().Audit().UpdateAuditRetentionPolicy(ctx.Kit.Ctx, ctx.Kit.Header, policy); err != nil {
+		blog.Errorf("UpdateAuditRetentionPolicy failed, policy: %+v, err: %v, rid: %s", policy, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// ListAuditLogArchive lists the archives of the expired audit logs
+func (s *Service) ListAuditLogArchive(ctx *rest.Contexts) {
+	option := metadata.ListAuditLogArchiveOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, ok := option.Validate(); !ok {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().Audit().ListAuditLogArchive(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("ListAuditLogArchive failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// ImportAuditLogArchive imports the archived audit logs back, so that they can be searched again
+func (s *Service) ImportAuditLogArchive(ctx *rest.Contexts) {
+	archiveID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || archiveID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return
+	}
+
+	result, ccErr := s.Engine.CoreAPI.CoreService().Audit().ImportAuditLogArchive(ctx.Kit.Ctx, ctx.Kit.Header, archiveID)
+	if ccErr != nil {
+		blog.Errorf("ImportAuditLogArchive failed, archive: %d, err: %v, rid: %s", archiveID, ccErr, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+	ctx.RespEntity(result)
+}
@@ -382,7 +382,109 @@ func (s *Service) InstanceAuditQuery(ctx *rest.Contexts) {
 	cond[common.BKDBAND] = andCond
 	query.Condition = cond
 
-	action := meta.Find
+	if !s.authorizeInstanceAudit(ctx, meta.Find, objectID, instanceID, businessID) {
+		return
+	}
+
+	blog.V(4).Infof("InstanceAuditQuery failed, AuditOperation parameter: %+v, rid: %s", query, ctx.Kit.Rid)
+	resp, err := s.Core.AuditOperation().Query(ctx.Kit, query)
+	if nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(resp)
+}
+
+// InstanceAuditHistory reconstructs the instance's state at a point in time from its audit logs
+func (s *Service) InstanceAuditHistory(ctx *rest.Contexts) {
+	option := metadata.InstanceHistoryOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	objectID := ctx.Request.PathParameter(common.BKObjIDField)
+	if len(objectID) == 0 {
+		blog.Errorf("InstanceAuditHistory failed, object ID can't be empty, rid: %s", ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField))
+		return
+	}
+
+	if option.InstID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKInstIDField))
+		return
+	}
+
+	if !s.authorizeInstanceAudit(ctx, meta.Find, objectID, option.InstID, 0) {
+		return
+	}
+
+	result, err := s.Core.AuditOperation().InstanceHistory(ctx.Kit, objectID, option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// RestoreInstanceFromAudit restores the instance's editable fields to the version recorded in an audit log
+func (s *Service) RestoreInstanceFromAudit(ctx *rest.Contexts) {
+	option := metadata.RestoreInstanceOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	objectID := ctx.Request.PathParameter(common.BKObjIDField)
+	if len(objectID) == 0 {
+		blog.Errorf("RestoreInstanceFromAudit failed, object ID can't be empty, rid: %s", ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField))
+		return
+	}
+
+	if option.InstID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKInstIDField))
+		return
+	}
+
+	if !s.authorizeInstanceAudit(ctx, meta.Update, objectID, option.InstID, 0) {
+		return
+	}
+
+	var result *metadata.RestoreInstanceResult
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
+		var err error
+		result, err = s.Core.AuditOperation().RestoreInstance(ctx.Kit, objectID, option)
+		if err != nil {
+			return err
+		}
+		if len(result.Changes) == 0 {
+			return nil
+		}
+
+		// the business and module are registered again when they are updated
+		if objectID == common.BKInnerObjIDApp || objectID == common.BKInnerObjIDModule {
+			return nil
+		}
+		if err := s.AuthManager.UpdateRegisteredInstanceByID(ctx.Kit.Ctx, ctx.Kit.Header, objectID, option.InstID); err != nil {
+			blog.Errorf("restore instance failed, update registered instance failed, objID: %s, instID: %d, err: %v, rid: %s",
+				objectID, option.InstID, err, ctx.Kit.Rid)
+			return ctx.Kit.CCError.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// authorizeInstanceAudit authorizes the action on the instance whose audit logs are operated, it responds
+// the request and returns false if the authorization is not passed
+func (s *Service) authorizeInstanceAudit(ctx *rest.Contexts, action meta.Action, objectID string, instanceID, businessID int64) bool {
+	var err error
 	switch objectID {
 	case common.BKInnerObjIDHost:
 		err = s.AuthManager.AuthorizeByHostsIDs(ctx.Kit.Ctx, ctx.Kit.Header, action, instanceID)
@@ -392,22 +494,22 @@ func (s *Service) InstanceAuditQuery(ctx *rest.Contexts) {
 			resp, err := s.AuthManager.GenProcessNoPermissionResp(ctx.Kit.Ctx, ctx.Kit.Header, businessID)
 			if err != nil {
 				ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrTopoGetAppFailed, businessID))
-				return
+				return false
 			}
 			ctx.RespEntityWithError(resp, auth.NoAuthorizeError)
-			return
+			return false
 		}
 	case common.BKInnerObjIDModule:
 		err = s.AuthManager.AuthorizeByModuleID(ctx.Kit.Ctx, ctx.Kit.Header, action, instanceID)
 		if err != nil && err == auth.NoAuthorizeError {
 			ctx.RespEntityWithError(s.AuthManager.GenModuleSetNoPermissionResp(), auth.NoAuthorizeError)
-			return
+			return false
 		}
 	case common.BKInnerObjIDSet:
 		err = s.AuthManager.AuthorizeBySetID(ctx.Kit.Ctx, ctx.Kit.Header, action, instanceID)
 		if err != nil && err == auth.NoAuthorizeError {
 			ctx.RespEntityWithError(s.AuthManager.GenModuleSetNoPermissionResp(), auth.NoAuthorizeError)
-			return
+			return false
 		}
 	case common.BKInnerObjIDApp:
 		err = s.AuthManager.AuthorizeByBusinessID(ctx.Kit.Ctx, ctx.Kit.Header, action, instanceID)
@@ -415,25 +517,18 @@ func (s *Service) InstanceAuditQuery(ctx *rest.Contexts) {
 			resp, err := s.AuthManager.GenBusinessAuditNoPermissionResp(ctx.Kit.Ctx, ctx.Kit.Header, businessID)
 			if err != nil {
 				ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrTopoGetAppFailed))
-				return
+				return false
 			}
 			ctx.RespEntityWithError(resp, auth.NoAuthorizeError)
-			return
+			return false
 		}
 	default:
 		err = s.AuthManager.AuthorizeByInstanceID(ctx.Kit.Ctx, ctx.Kit.Header, action, objectID, instanceID)
 	}
 	if err != nil {
-		blog.Errorf("InstanceAuditQuery failed, query instance audit log failed, authorization on instance of model %s failed, err: %+v, rid: %s", objectID, err, ctx.Kit.Rid)
+		blog.Errorf("authorize instance audit failed, authorization on instance of model %s failed, err: %+v, rid: %s", objectID, err, ctx.Kit.Rid)
 		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommAuthorizeFailed))
-		return
+		return false
 	}
-
-	blog.V(4).Infof("InstanceAuditQuery failed, AuditOperation parameter: %+v, rid: %s", query, ctx.Kit.Rid)
-	resp, err := s.Core.AuditOperation().Query(ctx.Kit, query)
-	if nil != err {
-		ctx.RespAutoError(err)
-		return
-	}
-	ctx.RespEntity(resp)
+	return true
 }
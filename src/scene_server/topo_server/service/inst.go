@@ -17,6 +17,7 @@ import (
 	"strings"
 
 	"configcenter/src/auth/extensions"
+	"configcenter/src/auth/meta"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
@@ -24,8 +25,10 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	paraparse "configcenter/src/common/paraparse"
+	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/topo_server/core/inst"
+	"configcenter/src/scene_server/topo_server/core/model"
 	"configcenter/src/scene_server/topo_server/core/operation"
 )
 
@@ -493,12 +496,14 @@ func (s *Service) UpdateInst(ctx *rest.Contexts) {
 	ctx.RespEntity(nil)
 }
 
-// SearchInst search the inst
+// SearchInst search the inst, the instances can be filtered by the condition or a text query
+// like `bk_inst_name begins_with "test" AND create_time >= "now-7d"`.
 func (s *Service) SearchInsts(ctx *rest.Contexts) {
 	objID := ctx.Request.PathParameter("bk_obj_id")
 	data := struct {
 		paraparse.SearchParams `json:",inline"`
 		Metadata               *metadata.Metadata `json:"metadata"`
+		Query                  string             `json:"query"`
 	}{}
 	if err := ctx.DecodeInto(&data); nil != err {
 		ctx.RespAutoError(err)
@@ -519,8 +524,15 @@ func (s *Service) SearchInsts(ctx *rest.Contexts) {
 	if queryCond.Condition == nil {
 		queryCond.Condition = mapstr.New()
 	}
-	page := metadata.ParsePage(queryCond.Page)
 	query := &metadata.QueryInput{}
+	if len(data.Query) != 0 {
+		query.Filter, err = s.parseInstQuery(ctx.Kit, obj, data.Query)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+	page := metadata.ParsePage(queryCond.Page)
 	query.Condition = queryCond.Condition
 	query.Fields = strings.Join(queryCond.Fields, ",")
 	query.Limit = page.Limit
@@ -540,6 +552,32 @@ func (s *Service) SearchInsts(ctx *rest.Contexts) {
 	ctx.RespEntity(result)
 }
 
+// parseInstQuery parse the text query of the object's instances, the filter is converted to db condition by
+// coreservice, so that the datetime values are compared as dates instead of strings.
+func (s *Service) parseInstQuery(kit *rest.Kit, obj model.Object, query string) (*querybuilder.QueryFilter, error) {
+	attributes, err := obj.GetAttributes()
+	if err != nil {
+		blog.Errorf("get object(%s) attributes failed, err: %v, rid: %s", obj.GetObjectID(), err, kit.Rid)
+		return nil, err
+	}
+
+	attrs := make([]metadata.Attribute, len(attributes))
+	for idx, attribute := range attributes {
+		attrs[idx] = *attribute.Attribute()
+	}
+	fieldTypes := metadata.GetQueryFieldTypes(attrs)
+	if _, exist := fieldTypes[obj.GetInstIDFieldName()]; !exist {
+		fieldTypes[obj.GetInstIDFieldName()] = common.FieldTypeInt
+	}
+
+	filter, err := querybuilder.ParseQuery(query, fieldTypes)
+	if err != nil {
+		blog.Errorf("parse object(%s) instance query failed, query: %s, err: %v, rid: %s", obj.GetObjectID(), query, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommQueryInvalid, "query", err.Error())
+	}
+	return filter, nil
+}
+
 // SearchInstAndAssociationDetail search the inst with association details
 func (s *Service) SearchInstAndAssociationDetail(ctx *rest.Contexts) {
 	objID := ctx.Request.PathParameter("bk_obj_id")
@@ -938,3 +976,27 @@ func (s *Service) SearchInstAssociationWithOtherObject(ctx *rest.Contexts) {
 		"page":  input.Page,
 	})
 }
+
+// RevealInstanceSecret returns the plaintext of the instance's secret attribute value, which requires the
+// reveal secret permission of the instance rather than the find permission.
+func (s *Service) RevealInstanceSecret(ctx *rest.Contexts) {
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	instID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKInstIDField), 10, 64)
+	if err != nil || instID <= 0 {
+		blog.Errorf("RevealInstanceSecret failed, invalid instance id, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKInstIDField))
+		return
+	}
+	propertyID := ctx.Request.PathParameter(common.BKPropertyIDField)
+
+	if !s.authorizeInstanceAudit(ctx, meta.RevealSecret, objID, instID, 0) {
+		return
+	}
+
+	result, err := s.Core.AuditOperation().RevealSecret(ctx.Kit, objID, instID, propertyID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
@@ -349,7 +349,7 @@ func (s *Service) ListHostModelAttribute(ctx *rest.Contexts) {
 		if item == nil {
 			continue
 		}
-		hostApplyEnabled := metadata.CheckAllowHostApplyOnField(item.PropertyID)
+		hostApplyEnabled := metadata.CheckAllowHostApplyOnAttribute(item.Attribute)
 		hostAttribute := metadata.HostObjAttDes{
 			ObjAttDes:        *item,
 			HostApplyEnabled: hostApplyEnabled,
@@ -0,0 +1,98 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// ListRecycleBin lists the deleted instances, hosts and topology nodes in the recycle bin
+func (s *Service) ListRecycleBin(ctx *rest.Contexts) {
+	option := metadata.ListRecycleBinOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, ok := option.Validate(); !ok {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().RecycleBin().ListRecycleBin(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("ListRecycleBin failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// RestoreRecycleBin restores the deleted instance of the recycle bin item and registers it to iam again
+func (s *Service) RestoreRecycleBin(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return
+	}
+
+	var item *metadata.RecycleBinItem
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
+		var ccErr errors.CCErrorCoder
+		item, ccErr = s.Engine.CoreAPI.CoreService().RecycleBin().RestoreRecycleBin(ctx.Kit.Ctx, ctx.Kit.Header, id)
+		if ccErr != nil {
+			blog.Errorf("RestoreRecycleBin failed, id: %d, err: %v, rid: %s", id, ccErr, ctx.Kit.Rid)
+			return ccErr
+		}
+
+		// auth: register the restored instance to iam again, it's deregistered when it's deleted
+		if err := s.AuthManager.RegisterInstancesByID(ctx.Kit.Ctx, ctx.Kit.Header, item.ObjectID, item.InstID); err != nil {
+			blog.Errorf("restore instance success, but register instance to iam failed, object: %s, instance: %d, err: %+v, rid: %s",
+				item.ObjectID, item.InstID, err, ctx.Kit.Rid)
+			return ctx.Kit.CCError.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(item)
+}
+
+// PurgeRecycleBin deletes the recycle bin items permanently
+func (s *Service) PurgeRecycleBin(ctx *rest.Contexts) {
+	option := metadata.PurgeRecycleBinOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, ok := option.Validate(); !ok {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().RecycleBin().PurgeRecycleBin(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("PurgeRecycleBin failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/operation"
+)
+
+// ExportSchemaBundle exports the global models as a schema bundle, the bundle is returned as a yaml string
+// if the yaml format is required.
+func (s *Service) ExportSchemaBundle(ctx *rest.Contexts) {
+	option := metadata.ExportSchemaBundleOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	if len(option.Format) == 0 {
+		option.Format = metadata.SchemaBundleFormatJSON
+	}
+	if option.Format != metadata.SchemaBundleFormatJSON && option.Format != metadata.SchemaBundleFormatYAML {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "format"))
+		return
+	}
+
+	bundle, err := s.Core.SchemaBundleOperation().Export(ctx.Kit, &option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if option.Format == metadata.SchemaBundleFormatJSON {
+		ctx.RespEntity(bundle)
+		return
+	}
+	content, err := operation.ExportSchemaBundleYAML(bundle)
+	if err != nil {
+		blog.Errorf("encode schema bundle as yaml failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommJSONMarshalFailed))
+		return
+	}
+	ctx.RespEntity(content)
+}
+
+// PlanSchemaBundle returns the changes and conflicts of applying a schema bundle without applying it
+func (s *Service) PlanSchemaBundle(ctx *rest.Contexts) {
+	request := metadata.SchemaBundleRequest{}
+	if err := ctx.DecodeInto(&request); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	bundle, err := s.Core.SchemaBundleOperation().ParseBundle(ctx.Kit, &request)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	plan, err := s.Core.SchemaBundleOperation().Plan(ctx.Kit, bundle)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(plan)
+}
+
+// ApplySchemaBundle creates and updates the models to match a schema bundle, applying the same bundle again
+// changes nothing. The bundle is not applied if it has conflicts with the current models.
+func (s *Service) ApplySchemaBundle(ctx *rest.Contexts) {
+	request := metadata.SchemaBundleRequest{}
+	if err := ctx.DecodeInto(&request); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	bundle, err := s.Core.SchemaBundleOperation().ParseBundle(ctx.Kit, &request)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	var plan *metadata.SchemaBundlePlan
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, s.EnableTxn, ctx.Kit.Header, func() error {
+		var err error
+		plan, err = s.Core.SchemaBundleOperation().Apply(ctx.Kit, bundle)
+		return err
+	})
+
+	if txnErr != nil {
+		if plan != nil {
+			ctx.RespEntityWithError(plan, txnErr)
+			return
+		}
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(plan)
+}
@@ -125,6 +125,14 @@ func (s *Service) initBusinessAssociation(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/topoinst/bk_biz_id/{bk_biz_id}/host_apply_rule_related", Handler: s.SearchRuleRelatedTopoNodes})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/topopath/biz/{bk_biz_id}", Handler: s.SearchTopoPath})
 
+	// business topo snapshot methods
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/topo/snapshot/bk_biz_id/{bk_biz_id}", Handler: s.CreateTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/topo/snapshot/bk_biz_id/{bk_biz_id}", Handler: s.ListTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/topo/snapshot/{id}/bk_biz_id/{bk_biz_id}", Handler: s.DeleteTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/topo/snapshot/diff/bk_biz_id/{bk_biz_id}", Handler: s.DiffTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/find/topo/snapshot/schedule/bk_biz_id/{bk_biz_id}", Handler: s.GetTopoSnapshotSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/topo/snapshot/schedule/bk_biz_id/{bk_biz_id}", Handler: s.UpdateTopoSnapshotSchedule})
+
 	// association type methods ,NOT SUPPORT BUSINESS
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/topoassociationtype", Handler: s.SearchObjectAssocWithAssocKindList})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/associationtype", Handler: s.SearchAssociationType})
@@ -146,6 +154,8 @@ func (s *Service) initBusinessAssociation(web *restful.WebService) {
 	// topo search methods
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/instassociation/object/{bk_obj_id}", Handler: s.SearchInstByAssociation})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/instassttopo/object/{bk_obj_id}/inst/{inst_id}", Handler: s.SearchInstTopo})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/instassociation/graph", Handler: s.SearchInstAssociationGraph})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/instassociation/shortest_path", Handler: s.SearchInstAssociationShortestPath})
 
 	// ATTENTION: the following methods is not recommended
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/insttopo/object/{bk_obj_id}/inst/{inst_id}", Handler: s.SearchInstChildTopo})
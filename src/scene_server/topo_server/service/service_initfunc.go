@@ -73,6 +73,25 @@ func (s *Service) initAuditLog(web *restful.WebService) {
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/audit/search", Handler: s.AuditQuery})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/object/{bk_obj_id}/audit/search", Handler: s.InstanceAuditQuery})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/object/{bk_obj_id}/audit/history", Handler: s.InstanceAuditHistory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/object/{bk_obj_id}/audit/restore", Handler: s.RestoreInstanceFromAudit})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/audit/retention_policy", Handler: s.ListAuditRetentionPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/audit/retention_policy", Handler: s.UpdateAuditRetentionPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/audit/archive", Handler: s.ListAuditLogArchive})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/import/audit/archive/{id}", Handler: s.ImportAuditLogArchive})
+
+	utility.AddToRestfulWebService(web)
+}
+
+func (s *Service) initRecycleBin(web *restful.WebService) {
+	utility := rest.NewRestUtility(rest.Config{
+		ErrorIf:  s.Engine.CCErr,
+		Language: s.Engine.Language,
+	})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/recycle_bin/item", Handler: s.ListRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/restore/recycle_bin/item/{id}", Handler: s.RestoreRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/deletemany/recycle_bin/item", Handler: s.PurgeRecycleBin})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -153,6 +172,7 @@ func (s *Service) initInst(web *restful.WebService) {
 	// utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/inst/association/object/{bk_obj_id}/inst_id/{id}/offset/{start}/limit/{limit}", Handler: s.SearchInstAssociation})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/inst/association/object/{bk_obj_id}/inst_id/{id}/offset/{start}/limit/{limit}/web", Handler: s.SearchInstAssociationUI})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/inst/association/association_object/inst_base_info", Handler: s.SearchInstAssociationWithOtherObject})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/inst/secret/object/{bk_obj_id}/inst_id/{bk_inst_id}/property/{bk_property_id}", Handler: s.RevealInstanceSecret})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -232,6 +252,10 @@ func (s *Service) initObject(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/object/{id}", Handler: s.DeleteObject})
 	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/object/statistics", Handler: s.GetModelStatistics})
 
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/schema/bundle", Handler: s.ExportSchemaBundle})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/schema/bundle/plan", Handler: s.PlanSchemaBundle})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/schema/bundle", Handler: s.ApplySchemaBundle})
+
 	utility.AddToRestfulWebService(web)
 }
 
@@ -296,4 +320,5 @@ func (s *Service) initService(web *restful.WebService) {
 	s.initFullTextSearch(web)
 	s.initSetTemplate(web)
 	s.initInternalTask(web)
+	s.initRecycleBin(web)
 }
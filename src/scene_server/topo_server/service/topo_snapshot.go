@@ -0,0 +1,167 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+func parseTopoSnapshotBizID(ctx *rest.Contexts) (int64, bool) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil || bizID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return 0, false
+	}
+	return bizID, true
+}
+
+// CreateTopoSnapshot takes a snapshot of the business topology, including its hosts and their attributes
+func (s *Service) CreateTopoSnapshot(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	option := metadata.CreateTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, ok := option.Validate(); !ok {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	snapshot, err := s.Engine.CoreAPI.CoreService().Mainline().CreateTopoSnapshot(ctx.Kit.Ctx, ctx.Kit.Header, bizID, option)
+	if err != nil {
+		blog.Errorf("CreateTopoSnapshot failed, business: %d, err: %v, rid: %s", bizID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(snapshot)
+}
+
+// ListTopoSnapshot lists the snapshots of the business, the latest snapshots come first
+func (s *Service) ListTopoSnapshot(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	option := metadata.ListTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, ok := option.Validate(); !ok {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	result, err := s.Engine.CoreAPI.CoreService().Mainline().ListTopoSnapshot(ctx.Kit.Ctx, ctx.Kit.Header, bizID, option)
+	if err != nil {
+		blog.Errorf("ListTopoSnapshot failed, business: %d, option: %+v, err: %v, rid: %s", bizID, option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// DeleteTopoSnapshot deletes a snapshot of the business
+func (s *Service) DeleteTopoSnapshot(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	snapshotID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || snapshotID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return
+	}
+
+	if err := s.Engine.CoreAPI.CoreService().Mainline().DeleteTopoSnapshot(ctx.Kit.Ctx, ctx.Kit.Header, bizID, snapshotID); err != nil {
+		blog.Errorf("DeleteTopoSnapshot failed, business: %d, snapshot: %d, err: %v, rid: %s", bizID, snapshotID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// DiffTopoSnapshot compares two snapshots of the business into the added, removed, moved and modified nodes,
+// the from snapshot is compared with the current topology if the to snapshot is not set.
+func (s *Service) DiffTopoSnapshot(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	option := metadata.DiffTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, ok := option.Validate(); !ok {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	diff, err := s.Engine.CoreAPI.CoreService().Mainline().DiffTopoSnapshot(ctx.Kit.Ctx, ctx.Kit.Header, bizID, option)
+	if err != nil {
+		blog.Errorf("DiffTopoSnapshot failed, business: %d, option: %+v, err: %v, rid: %s", bizID, option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(diff)
+}
+
+// GetTopoSnapshotSchedule gets the snapshot schedule of the business
+func (s *Service) GetTopoSnapshotSchedule(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+
+	schedule, err := s.Engine.CoreAPI.CoreService().Mainline().GetTopoSnapshotSchedule(ctx.Kit.Ctx, ctx.Kit.Header, bizID)
+	if err != nil {
+		blog.Errorf("GetTopoSnapshotSchedule failed, business: %d, err: %v, rid: %s", bizID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(schedule)
+}
+
+// UpdateTopoSnapshotSchedule sets the snapshot schedule of the business, the schedule is removed if its interval is 0
+func (s *Service) UpdateTopoSnapshotSchedule(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	schedule := metadata.TopoSnapshotSchedule{}
+	if err := ctx.DecodeInto(&schedule); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, ok := schedule.Validate(); !ok {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	if err := s.Engine.CoreAPI.CoreService().Mainline().UpdateTopoSnapshotSchedule(ctx.Kit.Ctx, ctx.Kit.Header, bizID, schedule); err != nil {
+		blog.Errorf("UpdateTopoSnapshotSchedule failed, business: %d, schedule: %+v, err: %v, rid: %s", bizID, schedule, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
@@ -14,6 +14,8 @@ package options
 
 import (
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/source_controller/coreservice/core/auditlog"
+	"configcenter/src/source_controller/coreservice/core/recyclebin"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
 
@@ -29,6 +31,10 @@ type ServerOption struct {
 type Config struct {
 	Mongo mongo.Config
 	Redis redis.Config
+	// AuditSinks is the sinks that the audit logs are streamed to
+	AuditSinks []auditlog.SinkConfig
+	// RecycleBin is the config of the recycle bin of the deleted instances
+	RecycleBin recyclebin.Config
 }
 
 //NewServerOption create a ServerOption object
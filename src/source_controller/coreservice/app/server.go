@@ -21,8 +21,12 @@ import (
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/secret"
 	"configcenter/src/common/types"
 	"configcenter/src/source_controller/coreservice/app/options"
+	"configcenter/src/source_controller/coreservice/core/auditlog"
+	"configcenter/src/source_controller/coreservice/core/instances"
+	"configcenter/src/source_controller/coreservice/core/recyclebin"
 	coresvr "configcenter/src/source_controller/coreservice/service"
 )
 
@@ -31,6 +35,12 @@ type CoreServer struct {
 	Core    *backbone.Engine
 	Config  *options.Config
 	Service coresvr.CoreServiceInterface
+	// auditSinkErr is the error of parsing the audit sink configs
+	auditSinkErr error
+	// recycleBinErr is the error of parsing the recycle bin config
+	recycleBinErr error
+	// secretErr is the error of parsing the keys of secret attributes
+	secretErr error
 }
 
 func (t *CoreServer) onCoreServiceConfigUpdate(previous, current cc.ProcessConfig) {
@@ -38,6 +48,25 @@ func (t *CoreServer) onCoreServiceConfigUpdate(previous, current cc.ProcessConfi
 		t.Config = new(options.Config)
 	}
 
+	t.Config.AuditSinks, t.auditSinkErr = auditlog.ParseSinkConfigs(current.ConfigMap)
+	if t.auditSinkErr != nil {
+		blog.Errorf("parse audit sink configs failed, err: %v", t.auditSinkErr)
+	}
+
+	t.Config.RecycleBin, t.recycleBinErr = recyclebin.ParseConfig(current.ConfigMap)
+	if t.recycleBinErr != nil {
+		blog.Errorf("parse recycle bin config failed, err: %v", t.recycleBinErr)
+	}
+
+	// the previous keys are kept if the new keys are invalid, so that the secret values can still be read
+	var keyring *secret.Keyring
+	keyring, t.secretErr = instances.ParseSecretConfig(current.ConfigMap)
+	if t.secretErr != nil {
+		blog.Errorf("parse secret keys config failed, err: %v", t.secretErr)
+	} else {
+		secret.SetDefault(keyring)
+	}
+
 	blog.V(3).Infof("the new cfg:%#v the origin cfg:%#v", t.Config, current.ConfigMap)
 
 }
@@ -80,6 +109,15 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	if false == configReady {
 		return fmt.Errorf("configuration item not found")
 	}
+	if coreSvr.auditSinkErr != nil {
+		return fmt.Errorf("parse audit sink configs failed, err: %v", coreSvr.auditSinkErr)
+	}
+	if coreSvr.recycleBinErr != nil {
+		return fmt.Errorf("parse recycle bin config failed, err: %v", coreSvr.recycleBinErr)
+	}
+	if coreSvr.secretErr != nil {
+		return fmt.Errorf("parse secret keys config failed, err: %v", coreSvr.secretErr)
+	}
 
 	coreSvr.Config.Mongo, err = engine.WithMongo()
 	if err != nil {
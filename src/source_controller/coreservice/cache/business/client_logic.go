@@ -24,9 +24,23 @@ import (
 	"configcenter/src/common/json"
 	"configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
+	"configcenter/src/source_controller/coreservice/cache/tools"
+
 	"gopkg.in/redis.v5"
 )
 
+// maskSecretValues masks the secret values of the object's instance before it's cached
+func (c *Client) maskSecretValues(objID string, inst map[string]interface{}) error {
+	secretFields, err := tools.GetSecretPropertyIDs(c.db, objID)
+	if err != nil {
+		blog.Errorf("get %s secret attributes from mongodb for cache failed, err: %v", objID, err)
+		return err
+	}
+	secret.MaskMapStr(inst, secretFields...)
+	return nil
+}
+
 func (c *Client) getBusinessFromMongo(bizID int64) (string, error) {
 	biz := make(map[string]interface{})
 	filter := mapstr.MapStr{
@@ -37,6 +51,9 @@ func (c *Client) getBusinessFromMongo(bizID int64) (string, error) {
 		blog.Errorf("get business %d info from db, but failed, err: %v", bizID, err)
 		return "", ccError.New(common.CCErrCommDBSelectFailed, err.Error())
 	}
+	if err := c.maskSecretValues(common.BKInnerObjIDApp, biz); err != nil {
+		return "", ccError.New(common.CCErrCommDBSelectFailed, err.Error())
+	}
 	js, _ := json.Marshal(biz)
 	return string(js), nil
 }
@@ -404,6 +421,9 @@ func (c *Client) getModuleDetailFromMongo(id int64) (string, error) {
 		blog.Errorf("get module %d update from mongo failed, err: %v", id, err)
 		return "", err
 	}
+	if err := c.maskSecretValues(common.BKInnerObjIDModule, mod); err != nil {
+		return "", err
+	}
 	js, _ := json.Marshal(mod)
 	return string(js), nil
 }
@@ -418,6 +438,9 @@ func (c *Client) getSetDetailFromMongo(id int64) (string, error) {
 		blog.Errorf("get module %d update from mongo failed, err: %v", id, err)
 		return "", err
 	}
+	if err := c.maskSecretValues(common.BKInnerObjIDSet, set); err != nil {
+		return "", err
+	}
 	js, _ := json.Marshal(set)
 	return string(js), nil
 }
@@ -498,6 +521,9 @@ func (c *Client) getCustomLevelDetail(objID string, instID int64) (string, error
 		blog.Errorf("get custom level object: %s, inst: %d from mongodb failed, err: %v", objID, instID, err)
 		return "", err
 	}
+	if err := c.maskSecretValues(objID, instance); err != nil {
+		return "", err
+	}
 	js, err := json.Marshal(instance)
 	if err != nil {
 		return "", err
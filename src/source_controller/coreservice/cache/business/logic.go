@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"configcenter/src/common/blog"
+	"configcenter/src/common/secret"
 )
 
 func upsertListCache(ms *forUpsertCache) {
@@ -79,7 +80,7 @@ func upsertListCache(ms *forUpsertCache) {
 
 	// set the new data
 	// TODO: get the detailed info from db and set the key with the latest data
-	pipeline.Set(ms.detailKey, string(ms.doc), 0)
+	pipeline.Set(ms.detailKey, string(secret.MaskJSON(ms.doc)), 0)
 
 	// set the expire key
 	pipeline.Set(ms.listExpireKey, time.Now().Unix(), 0)
@@ -24,7 +24,9 @@ import (
 	"configcenter/src/common/json"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
 	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/cache/tools"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/reflector"
 	"configcenter/src/storage/stream/types"
@@ -212,6 +214,12 @@ func getHostDetailsFromMongoWithHostID(db dal.DB, hostID int64) (ips string, clo
 		return "", 0, nil, fmt.Errorf("invalid host: %d innerip", hostID)
 	}
 
+	secretFields, err := tools.GetSecretPropertyIDs(db, common.BKInnerObjIDHost)
+	if err != nil {
+		blog.Errorf("get host secret attributes from mongodb for cache failed, err: %v", err)
+		return "", 0, nil, err
+	}
+	secret.MaskMapStr(host, secretFields...)
 	js, _ := json.Marshal(host)
 
 	ele := gjson.GetBytes(js, common.BKCloudIDField)
@@ -242,6 +250,12 @@ func listHostDetailsFromMongoWithHostID(db dal.DB, hostID []int64) (list []*host
 		return nil, err
 	}
 
+	secretFields, err := tools.GetSecretPropertyIDs(db, common.BKInnerObjIDHost)
+	if err != nil {
+		blog.Errorf("get host secret attributes from mongodb for cache failed, err: %v", err)
+		return nil, err
+	}
+
 	for _, h := range host {
 		ips, ok := h[common.BKHostInnerIPField].(string)
 		if !ok {
@@ -249,6 +263,7 @@ func listHostDetailsFromMongoWithHostID(db dal.DB, hostID []int64) (list []*host
 			return nil, errors.New("invalid host innerip")
 		}
 
+		secret.MaskMapStr(h, secretFields...)
 		js, _ := json.Marshal(h)
 		ele := gjson.GetManyBytes(js, common.BKCloudIDField, common.BKHostIDField)
 		if !ele[0].Exists() {
@@ -298,6 +313,12 @@ func getHostDetailsFromMongoWithIP(db dal.DB, innerIP string, cloudID int64) (ho
 			innerIP, cloudID, host[common.BKHostIDField], err)
 	}
 
+	secretFields, err := tools.GetSecretPropertyIDs(db, common.BKInnerObjIDHost)
+	if err != nil {
+		blog.Errorf("get host secret attributes from mongodb for cache failed, err: %v", err)
+		return 0, nil, err
+	}
+	secret.MaskMapStr(host, secretFields...)
 	js, _ := json.Marshal(host)
 	return id, js, nil
 }
@@ -0,0 +1,38 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+)
+
+// GetSecretPropertyIDs returns the property ids of the object's secret attributes, the instances are masked by
+// them before they are cached. the cache is shared by all the supplier accounts, so the secret attributes of
+// all the supplier accounts are returned, masking more fields is always safe.
+func GetSecretPropertyIDs(db dal.DB, objID string) ([]string, error) {
+	cond := map[string]interface{}{
+		common.BKObjIDField:        objID,
+		common.BKPropertyTypeField: common.FieldTypeSecret,
+	}
+	attrs := make([]metadata.Attribute, 0)
+	err := db.Table(common.BKTableNameObjAttDes).Find(cond).
+		Fields(common.BKPropertyIDField, common.BKPropertyTypeField).All(context.Background(), &attrs)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.GetSecretPropertyIDs(attrs), nil
+}
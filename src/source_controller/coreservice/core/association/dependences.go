@@ -20,4 +20,7 @@ import "configcenter/src/common/http/rest"
 type OperationDependencies interface {
 	// IsInstanceExist used to check if the  instances exist
 	IsInstanceExist(kit *rest.Kit, objID string, instID uint64) (exists bool, err error)
+
+	// RefreshComputedValues queues a task to re-evaluate the computed attributes' values of the instances
+	RefreshComputedValues(kit *rest.Kit, objID string, instIDs []int64) error
 }
@@ -128,11 +128,19 @@ func (m *associationInstance) CreateOneInstanceAssociation(kit *rest.Kit, inputP
 		return nil, kit.CCError.Error(common.CCErrorInstToAsstIsNotExist)
 	}
 	id, err := m.save(kit, inputParam.Data)
-	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
+	if err != nil {
+		return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
+	}
+
+	if err := m.refreshComputedValues(kit, []metadata.InstAsst{inputParam.Data}); err != nil {
+		return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
+	}
+	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, nil
 }
 
 func (m *associationInstance) CreateManyInstanceAssociation(kit *rest.Kit, inputParam metadata.CreateManyInstanceAssociation) (*metadata.CreateManyDataResult, error) {
 	dataResult := &metadata.CreateManyDataResult{}
+	createdAssts := make([]metadata.InstAsst, 0)
 	for itemIdx, item := range inputParam.Datas {
 		item.OwnerID = kit.SupplierAccount
 		//check is exist
@@ -229,9 +237,12 @@ func (m *associationInstance) CreateManyInstanceAssociation(kit *rest.Kit, input
 		dataResult.Created = append(dataResult.Created, metadata.CreatedDataResult{
 			ID: id,
 		})
-
+		createdAssts = append(createdAssts, item)
 	}
 
+	if err := m.refreshComputedValues(kit, createdAssts); err != nil {
+		return dataResult, err
+	}
 	return dataResult, nil
 }
 
@@ -265,10 +276,41 @@ func (m *associationInstance) DeleteInstanceAssociation(kit *rest.Kit, inputPara
 		return &metadata.DeletedCount{}, err
 	}
 
+	// the associated instances' computed values may depend on each other, they need to be refreshed after deletion
+	assts := make([]metadata.InstAsst, 0)
+	if cnt > 0 {
+		err = m.dbProxy.Table(common.BKTableNameInstAsst).Find(inputParam.Condition).All(kit.Ctx, &assts)
+		if nil != err {
+			blog.Errorf("delete inst association get inst [%#v] err [%#v], rid: %s", inputParam.Condition, err, kit.Rid)
+			return &metadata.DeletedCount{}, err
+		}
+	}
+
 	err = m.dbProxy.Table(common.BKTableNameInstAsst).Delete(kit.Ctx, inputParam.Condition)
 	if nil != err {
 		blog.Errorf("delete inst association [%#v] err [%#v], rid: %s", inputParam.Condition, err, kit.Rid)
 		return &metadata.DeletedCount{}, err
 	}
+
+	if err := m.refreshComputedValues(kit, assts); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
+
+// refreshComputedValues queues the refresh of the computed values of the instances on both sides of the associations
+func (m *associationInstance) refreshComputedValues(kit *rest.Kit, assts []metadata.InstAsst) error {
+	objInstIDs := make(map[string][]int64)
+	for _, asst := range assts {
+		objInstIDs[asst.ObjectID] = append(objInstIDs[asst.ObjectID], asst.InstID)
+		objInstIDs[asst.AsstObjectID] = append(objInstIDs[asst.AsstObjectID], asst.AsstInstID)
+	}
+
+	for objID, instIDs := range objInstIDs {
+		if err := m.dependent.RefreshComputedValues(kit, objID, util.IntArrayUnique(instIDs)); err != nil {
+			blog.Errorf("refresh computed values of %s instances %v failed, err: %v, rid: %s", objID, instIDs, err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// auditArchiveIDField is the field set on the imported audit logs, it links the audit logs to their archive
+const auditArchiveIDField = "archive_id"
+
+// auditArchiveData is an audit archive with its compressed audit logs, the data is not returned in listing
+type auditArchiveData struct {
+	metadata.AuditLogArchive `bson:",inline"`
+	Data                     []byte `bson:"data"`
+}
+
+// encodeAuditArchive compresses the raw audit log documents, the documents are concatenated as they are,
+// because each bson document starts with its length.
+func encodeAuditArchive(docs []bson.Raw) ([]byte, error) {
+	buffer := bytes.Buffer{}
+	writer := gzip.NewWriter(&buffer)
+	for _, doc := range docs {
+		if _, err := writer.Write(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// decodeAuditArchive decompresses the archive data to the raw audit log documents
+func decodeAuditArchive(data []byte) ([]bson.Raw, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]bson.Raw, 0)
+	for len(content) > 0 {
+		if len(content) < 4 {
+			return nil, fmt.Errorf("archive data is truncated")
+		}
+		length := int(binary.LittleEndian.Uint32(content))
+		if length < 5 || length > len(content) {
+			return nil, fmt.Errorf("archive document length %d is invalid", length)
+		}
+		doc := bson.Raw(content[:length])
+		if err := doc.Validate(); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+		content = content[length:]
+	}
+	return docs, nil
+}
+
+// ListAuditArchive lists the audit archives without their audit logs, the latest archives come first
+func (m *auditManager) ListAuditArchive(kit *rest.Kit, option metadata.ListAuditLogArchiveOption) (*metadata.MultipleAuditLogArchive, errors.CCErrorCoder) {
+	if field, ok := option.Validate(); !ok {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{}
+	if len(option.AuditType) > 0 {
+		filter[common.BKAuditTypeField] = option.AuditType
+	}
+	filter = util.SetQueryOwner(filter, kit.SupplierAccount)
+
+	count, err := m.dbProxy.Table(common.BKTableNameAuditArchive).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("ListAuditArchive failed, db count failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	archives := make([]metadata.AuditLogArchive, 0)
+	fields := []string{common.BKFieldID, "key", common.BKAuditTypeField, "start_time", "end_time", "count", "size",
+		common.CreateTimeField, "imported", "import_time", common.BkSupplierAccount}
+	err = m.dbProxy.Table(common.BKTableNameAuditArchive).Find(filter).Fields(fields...).Sort("-"+common.BKFieldID).
+		Start(uint64(option.Page.Start)).Limit(uint64(option.Page.Limit)).All(kit.Ctx, &archives)
+	if err != nil {
+		blog.Errorf("ListAuditArchive failed, db select failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return &metadata.MultipleAuditLogArchive{Count: int64(count), Info: archives}, nil
+}
+
+// ImportAuditArchive imports the archived audit logs back to the audit logs, they are linked to the archive,
+// and removed again when the retention days of their audit type have passed since the import.
+func (m *auditManager) ImportAuditArchive(kit *rest.Kit, archiveID int64) (*metadata.ImportAuditLogArchiveResult, errors.CCErrorCoder) {
+	filter := util.SetQueryOwner(map[string]interface{}{common.BKFieldID: archiveID}, kit.SupplierAccount)
+	archives := make([]auditArchiveData, 0)
+	if err := m.dbProxy.Table(common.BKTableNameAuditArchive).Find(filter).All(kit.Ctx, &archives); err != nil {
+		blog.Errorf("ImportAuditArchive failed, db select failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(archives) == 0 {
+		return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+	archive := archives[0]
+
+	rawDocs, err := decodeAuditArchive(archive.Data)
+	if err != nil {
+		blog.Errorf("ImportAuditArchive failed, decode archive %d failed, err: %+v, rid: %s", archiveID, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+
+	docs := make([]bsonx.Doc, 0, len(rawDocs))
+	ids := make([]interface{}, 0, len(rawDocs))
+	for _, rawDoc := range rawDocs {
+		doc, err := bsonx.ReadDoc(rawDoc)
+		if err != nil {
+			blog.Errorf("ImportAuditArchive failed, read archived audit log failed, err: %+v, rid: %s", err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+		}
+		docs = append(docs, doc.Set(auditArchiveIDField, bsonx.Int64(archiveID)))
+		ids = append(ids, rawDoc.Lookup("_id"))
+	}
+
+	// remove the audit logs imported before, or left by an interrupted archiving, to avoid duplicated audit logs
+	deleteFilter := map[string]interface{}{
+		"_id": map[string]interface{}{common.BKDBIN: ids},
+	}
+	if err := m.dbProxy.Table(common.BKTableNameAuditLog).Delete(kit.Ctx, deleteFilter); err != nil {
+		blog.Errorf("ImportAuditArchive failed, db delete failed, archive: %d, err: %+v, rid: %s", archiveID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	if len(docs) > 0 {
+		if err := m.dbProxy.Table(common.BKTableNameAuditLog).Insert(kit.Ctx, docs); err != nil {
+			blog.Errorf("ImportAuditArchive failed, db insert failed, archive: %d, err: %+v, rid: %s", archiveID, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+		}
+	}
+
+	now := metadata.Now()
+	updateData := map[string]interface{}{
+		"imported":    true,
+		"import_time": now,
+	}
+	if err := m.dbProxy.Table(common.BKTableNameAuditArchive).Update(kit.Ctx, filter, updateData); err != nil {
+		blog.Errorf("ImportAuditArchive failed, db update failed, archive: %d, err: %+v, rid: %s", archiveID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	archive.Imported = true
+	archive.ImportTime = &now
+	auditLog := newAuditArchiveLog(metadata.AuditRecover, archive.AuditLogArchive, metadata.FromUser)
+	if err := m.CreateAuditLog(kit, auditLog); err != nil {
+		blog.Errorf("ImportAuditArchive failed, save audit log failed, archive: %d, err: %+v, rid: %s", archiveID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrAuditSaveLogFailed)
+	}
+	return &metadata.ImportAuditLogArchiveResult{Count: int64(len(docs))}, nil
+}
+
+// newAuditArchiveLog records the archiving or importing of the audit logs
+func newAuditArchiveLog(action metadata.ActionType, archive metadata.AuditLogArchive, from metadata.OperateFromType) metadata.AuditLog {
+	details := map[string]interface{}{
+		common.BKFieldID:         archive.ID,
+		common.BKAuditTypeField:  archive.AuditType,
+		"count":                  archive.Count,
+		"start_time":             archive.StartTime.Time,
+		"end_time":               archive.EndTime.Time,
+		common.BkSupplierAccount: archive.SupplierAccount,
+	}
+	return metadata.AuditLog{
+		AuditType:    metadata.AuditLogType,
+		ResourceType: metadata.AuditArchiveRes,
+		Action:       action,
+		OperateFrom:  from,
+		OperationDetail: &metadata.BasicOpDetail{
+			ResourceID:   archive.ID,
+			ResourceName: string(archive.AuditType),
+			Details: &metadata.BasicContent{
+				CurData: details,
+			},
+		},
+	}
+}
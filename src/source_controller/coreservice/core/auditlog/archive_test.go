@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func newRawAuditLog(t *testing.T, id int64, operationTime time.Time) bson.Raw {
+	data, err := bson.Marshal(bson.M{
+		common.BKFieldID:            id,
+		common.BKAuditTypeField:     metadata.HostType,
+		common.BKOperationTimeField: operationTime,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func TestAuditArchiveEncoding(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	docs := []bson.Raw{
+		newRawAuditLog(t, 1, now.Add(-2*time.Hour)),
+		newRawAuditLog(t, 2, now.Add(-time.Hour)),
+		newRawAuditLog(t, 3, now),
+	}
+
+	data, err := encodeAuditArchive(docs)
+	require.NoError(t, err)
+
+	decoded, err := decodeAuditArchive(data)
+	require.NoError(t, err)
+	require.Equal(t, docs, decoded)
+
+	empty, err := encodeAuditArchive(nil)
+	require.NoError(t, err)
+	decoded, err = decodeAuditArchive(empty)
+	require.NoError(t, err)
+	require.Empty(t, decoded)
+
+	_, err = decodeAuditArchive([]byte("not gzip"))
+	require.Error(t, err)
+
+	truncated, err := encodeAuditArchive([]bson.Raw{docs[0][:len(docs[0])-3]})
+	require.NoError(t, err)
+	_, err = decodeAuditArchive(truncated)
+	require.Error(t, err)
+}
+
+func TestNewAuditArchive(t *testing.T) {
+	start := time.Now().UTC().Add(-48 * time.Hour).Truncate(time.Millisecond)
+	end := start.Add(time.Hour)
+	policy := metadata.AuditRetentionPolicy{
+		AuditType:       metadata.HostType,
+		RetentionDays:   1,
+		Archive:         true,
+		SupplierAccount: "0",
+	}
+
+	archive := newAuditArchive(policy, []bson.Raw{newRawAuditLog(t, 1, start), newRawAuditLog(t, 2, end)})
+	require.Equal(t, metadata.HostType, archive.AuditType)
+	require.Equal(t, "0", archive.SupplierAccount)
+	require.EqualValues(t, 2, archive.Count)
+	require.True(t, archive.StartTime.Equal(start))
+	require.True(t, archive.EndTime.Equal(end))
+	require.False(t, archive.Imported)
+	require.Equal(t, "0:host:1:2:2", archive.Key)
+
+	again := newAuditArchive(policy, []bson.Raw{newRawAuditLog(t, 1, start), newRawAuditLog(t, 2, end)})
+	require.Equal(t, archive.Key, again.Key)
+}
+
+func TestLimitAuditArchiveSize(t *testing.T) {
+	now := time.Now().UTC()
+	docs := []bson.Raw{newRawAuditLog(t, 1, now), newRawAuditLog(t, 2, now), newRawAuditLog(t, 3, now)}
+	size := len(docs[0])
+
+	require.Len(t, limitAuditArchiveSize(docs, 3*size), 3)
+	require.Len(t, limitAuditArchiveSize(docs, 2*size+1), 2)
+	require.Len(t, limitAuditArchiveSize(docs, size), 1)
+	require.Len(t, limitAuditArchiveSize(docs, 1), 1)
+}
+
+func TestAuditRetentionPolicyValidate(t *testing.T) {
+	policy := metadata.AuditRetentionPolicy{AuditType: metadata.HostType, RetentionDays: 30}
+	_, ok := policy.Validate()
+	require.True(t, ok)
+
+	policy.RetentionDays = -1
+	field, ok := policy.Validate()
+	require.False(t, ok)
+	require.Equal(t, "retention_days", field)
+
+	policy = metadata.AuditRetentionPolicy{AuditType: "unknown", RetentionDays: 30}
+	field, ok = policy.Validate()
+	require.False(t, ok)
+	require.Equal(t, "audit_type", field)
+}
@@ -14,12 +14,14 @@ package auditlog
 
 import (
 	"context"
+	"strconv"
 	"strings"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/dal"
@@ -32,33 +34,108 @@ var _ core.AuditOperation = (*auditManager)(nil)
 
 type auditManager struct {
 	dbProxy dal.RDB
+	sinks   *SinkManager
 }
 
-// New create a new instance manager instance
-func New(dbProxy dal.RDB) core.AuditOperation {
+// auditResourceObjIDs is the object ids of the resources whose audit logs use the basic operation detail
+var auditResourceObjIDs = map[metadata.ResourceType]string{
+	metadata.HostRes:     common.BKInnerObjIDHost,
+	metadata.BusinessRes: common.BKInnerObjIDApp,
+	metadata.SetRes:      common.BKInnerObjIDSet,
+	metadata.ModuleRes:   common.BKInnerObjIDModule,
+}
+
+// New create a new instance manager instance, the saved audit logs are streamed to the sinks if it's not nil
+func New(dbProxy dal.RDB, sinks *SinkManager) core.AuditOperation {
 	return &auditManager{
 		dbProxy: dbProxy,
+		sinks:   sinks,
 	}
 }
 
 func (m *auditManager) CreateAuditLog(kit *rest.Kit, logs ...metadata.AuditLog) error {
 	var logRows []interface{}
+	savedLogs := make([]metadata.AuditLog, 0, len(logs))
+	secretFields := make(map[string][]string)
+	// the logs of the operations that restore from an audit log are linked to it
+	sourceAuditID, _ := strconv.ParseInt(kit.Header.Get(common.BKHTTPCCAuditSourceID), 10, 64)
 	for _, log := range logs {
 		if log.OperationDetail == nil || instNotChange(kit.Ctx, log.OperationDetail) {
 			continue
 		}
+		// the change is determined before the secret values are masked, otherwise the change of secret is lost
+		if err := m.maskSecretValues(kit, &log, secretFields); err != nil {
+			return err
+		}
 		if log.OperateFrom == "" {
 			log.OperateFrom = metadata.FromUser
 		}
+		if log.SourceAuditID == 0 {
+			log.SourceAuditID = sourceAuditID
+		}
+		id, err := m.dbProxy.NextSequence(kit.Ctx, common.BKTableNameAuditLog)
+		if err != nil {
+			blog.Errorf("generate audit log id failed, err: %v, rid: %s", err, kit.Rid)
+			return err
+		}
+		log.ID = int64(id)
 		log.SupplierAccount = kit.SupplierAccount
 		log.User = kit.User
 		log.OperationTime = metadata.Now()
 		logRows = append(logRows, log)
+		savedLogs = append(savedLogs, log)
 	}
 	if len(logRows) == 0 {
 		return nil
 	}
-	return m.dbProxy.Table(common.BKTableNameAuditLog).Insert(kit.Ctx, logRows)
+	if err := m.dbProxy.Table(common.BKTableNameAuditLog).Insert(kit.Ctx, logRows); err != nil {
+		return err
+	}
+
+	m.sinks.Publish(kit.Rid, savedLogs)
+	return nil
+}
+
+// maskSecretValues masks the secret values in the audit log's data. the encrypted values are masked by value, the
+// plaintext values from the request are masked by the secret attributes of the model, which are cached in secretFields.
+func (m *auditManager) maskSecretValues(kit *rest.Kit, log *metadata.AuditLog, secretFields map[string][]string) error {
+	var objID string
+	var basicDetail *metadata.BasicOpDetail
+	switch detail := log.OperationDetail.(type) {
+	case *metadata.InstanceOpDetail:
+		objID = detail.ModelID
+		basicDetail = &detail.BasicOpDetail
+	case *metadata.BasicOpDetail:
+		objID = auditResourceObjIDs[log.ResourceType]
+		basicDetail = detail
+	}
+	if basicDetail == nil || basicDetail.Details == nil {
+		return nil
+	}
+
+	propertyIDs, exist := secretFields[objID]
+	if !exist && objID != "" {
+		cond := map[string]interface{}{
+			common.BKObjIDField:        objID,
+			common.BKPropertyTypeField: common.FieldTypeSecret,
+		}
+		cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+		attrs := make([]metadata.Attribute, 0)
+		err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).Fields(common.BKPropertyIDField).All(kit.Ctx, &attrs)
+		if err != nil {
+			blog.Errorf("search secret attributes of %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+			return err
+		}
+		for _, attr := range attrs {
+			propertyIDs = append(propertyIDs, attr.PropertyID)
+		}
+		secretFields[objID] = propertyIDs
+	}
+
+	for _, data := range []map[string]interface{}{basicDetail.Details.PreData, basicDetail.Details.CurData} {
+		secret.MaskMapStr(data, propertyIDs...)
+	}
+	return nil
 }
 
 func (m *auditManager) SearchAuditLog(kit *rest.Kit, param metadata.QueryInput) ([]metadata.AuditLog, uint64, error) {
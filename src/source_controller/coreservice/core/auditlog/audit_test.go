@@ -16,6 +16,8 @@ import (
 	"context"
 	"testing"
 
+	"configcenter/src/common/metadata"
+
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/require"
@@ -132,7 +134,13 @@ func TestInstNotChange(t *testing.T) {
 
 	for _, item := range testDataArr {
 
-		bl := instNotChange(context.Background(), item.content, "")
+		detail := &metadata.BasicOpDetail{
+			Details: &metadata.BasicContent{
+				PreData: item.content["pre_data"].(map[string]interface{}),
+				CurData: item.content["cur_data"].(map[string]interface{}),
+			},
+		}
+		bl := instNotChange(context.Background(), detail)
 		require.Equal(t, item.result, bl)
 
 	}
@@ -0,0 +1,346 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// auditRetentionInterval is the interval to enforce the audit retention policies
+	auditRetentionInterval = time.Hour
+	// auditArchiveBatchSize is the max number of audit logs in an archive
+	auditArchiveBatchSize = 5000
+	// auditArchiveMaxSize is the max total size of the audit logs in an archive before compressing, it is kept
+	// well below the 16MB limit of a mongodb document, which the archive is saved as.
+	auditArchiveMaxSize = 8 << 20
+	// auditRetentionMaxBatches is the max batches of a policy handled in a round, so that a policy with lots of
+	// expired audit logs won't block the others, the rest audit logs are handled in the next round.
+	auditRetentionMaxBatches = 100
+)
+
+// ListAuditRetentionPolicy lists the audit retention policies of the supplier account
+func (m *auditManager) ListAuditRetentionPolicy(kit *rest.Kit) ([]metadata.AuditRetentionPolicy, errors.CCErrorCoder) {
+	filter := util.SetQueryOwner(map[string]interface{}{}, kit.SupplierAccount)
+	policies := make([]metadata.AuditRetentionPolicy, 0)
+	if err := m.dbProxy.Table(common.BKTableNameAuditRetentionPolicy).Find(filter).All(kit.Ctx, &policies); err != nil {
+		blog.Errorf("ListAuditRetentionPolicy failed, db select failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return policies, nil
+}
+
+// UpdateAuditRetentionPolicy sets the retention policy of the audit type, the policy is removed if its retention
+// days is 0, and the audit logs of the audit type are kept forever.
+func (m *auditManager) UpdateAuditRetentionPolicy(kit *rest.Kit, policy metadata.AuditRetentionPolicy) errors.CCErrorCoder {
+	if field, ok := policy.Validate(); !ok {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: kit.SupplierAccount,
+		common.BKAuditTypeField:  policy.AuditType,
+	}
+	if policy.RetentionDays == 0 {
+		if err := m.dbProxy.Table(common.BKTableNameAuditRetentionPolicy).Delete(kit.Ctx, filter); err != nil {
+			blog.Errorf("UpdateAuditRetentionPolicy failed, db delete failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+		}
+		return nil
+	}
+
+	policy.SupplierAccount = kit.SupplierAccount
+	policy.Modifier = kit.User
+	policy.LastTime = metadata.Now()
+	if err := m.dbProxy.Table(common.BKTableNameAuditRetentionPolicy).Upsert(kit.Ctx, filter, policy); err != nil {
+		blog.Errorf("UpdateAuditRetentionPolicy failed, db upsert failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// RetentionJob enforces the audit retention policies of all the supplier accounts on the master coreservice,
+// the expired audit logs are archived or deleted in batches, and each batch is recorded by an audit log.
+type RetentionJob struct {
+	dbProxy  dal.RDB
+	audit    core.AuditOperation
+	isMaster discovery.ServiceManageInterface
+}
+
+// NewRetentionJob creates the audit retention job, the audit operation is used to record the archiving
+func NewRetentionJob(dbProxy dal.RDB, audit core.AuditOperation, isMaster discovery.ServiceManageInterface) *RetentionJob {
+	return &RetentionJob{
+		dbProxy:  dbProxy,
+		audit:    audit,
+		isMaster: isMaster,
+	}
+}
+
+// Run starts the job in background
+func (j *RetentionJob) Run() {
+	go func() {
+		ticker := time.NewTicker(auditRetentionInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !j.isMaster.IsMaster() {
+				blog.V(4).Infof("enforce audit retention policies, but not master, skip.")
+				continue
+			}
+			j.enforce()
+		}
+	}()
+}
+
+// newRetentionKit creates the kit of the supplier account for the job
+func newRetentionKit(supplierAccount string) *rest.Kit {
+	header := make(http.Header)
+	header.Set(common.BKHTTPOwnerID, supplierAccount)
+	header.Set(common.BKHTTPHeaderUser, common.CCSystemOperatorUserName)
+	header.Set(common.BKHTTPCCRequestID, util.GenerateRID())
+
+	return &rest.Kit{
+		Rid:             util.GetHTTPCCRequestID(header),
+		Header:          header,
+		Ctx:             util.NewContextFromHTTPHeader(header),
+		CCError:         util.GetDefaultCCError(header),
+		User:            common.CCSystemOperatorUserName,
+		SupplierAccount: supplierAccount,
+	}
+}
+
+func (j *RetentionJob) enforce() {
+	kit := newRetentionKit(common.BKSuperOwnerID)
+	policies := make([]metadata.AuditRetentionPolicy, 0)
+	if err := j.dbProxy.Table(common.BKTableNameAuditRetentionPolicy).Find(map[string]interface{}{}).All(kit.Ctx, &policies); err != nil {
+		blog.Errorf("enforce audit retention policies failed, db select failed, err: %+v, rid: %s", err, kit.Rid)
+		return
+	}
+
+	for _, policy := range policies {
+		if policy.RetentionDays <= 0 {
+			continue
+		}
+		kit := newRetentionKit(policy.SupplierAccount)
+		cutoff := time.Now().AddDate(0, 0, -int(policy.RetentionDays))
+
+		count, err := j.enforcePolicy(kit, policy, cutoff)
+		if err != nil {
+			blog.Errorf("enforce audit retention policy failed, policy: %+v, err: %+v, rid: %s", policy, err, kit.Rid)
+		}
+		if count > 0 {
+			blog.Infof("enforce audit retention policy, %d audit logs are removed, policy: %+v, rid: %s", count, policy, kit.Rid)
+		}
+
+		if err := j.removeImportedArchives(kit, policy, cutoff); err != nil {
+			blog.Errorf("remove expired imported audit archives failed, policy: %+v, err: %+v, rid: %s", policy, err, kit.Rid)
+		}
+	}
+}
+
+// enforcePolicy archives or deletes the audit logs of the policy which are older than the cutoff, the audit logs
+// imported from archives are excluded, they are removed with their archives. It returns the removed count.
+func (j *RetentionJob) enforcePolicy(kit *rest.Kit, policy metadata.AuditRetentionPolicy, cutoff time.Time) (int64, error) {
+	filter := map[string]interface{}{
+		common.BkSupplierAccount:    policy.SupplierAccount,
+		common.BKAuditTypeField:     policy.AuditType,
+		common.BKOperationTimeField: map[string]interface{}{common.BKDBLT: cutoff},
+		auditArchiveIDField:         map[string]interface{}{common.BKDBExists: false},
+	}
+
+	var removed int64
+	for batch := 0; batch < auditRetentionMaxBatches; batch++ {
+		docs := make([]bson.Raw, 0)
+		err := j.dbProxy.Table(common.BKTableNameAuditLog).Find(filter).Sort(common.BKOperationTimeField).
+			Limit(auditArchiveBatchSize).All(kit.Ctx, &docs)
+		if err != nil {
+			return removed, err
+		}
+		if len(docs) == 0 {
+			return removed, nil
+		}
+		fetched := len(docs)
+		docs = limitAuditArchiveSize(docs, auditArchiveMaxSize)
+
+		archive := newAuditArchive(policy, docs)
+		action := metadata.AuditDelete
+		if policy.Archive {
+			if err := j.saveArchive(kit, &archive, docs); err != nil {
+				return removed, err
+			}
+			action = metadata.AuditArchive
+		}
+
+		ids := make([]interface{}, len(docs))
+		for idx, doc := range docs {
+			ids[idx] = doc.Lookup("_id")
+		}
+		deleteFilter := map[string]interface{}{
+			"_id": map[string]interface{}{common.BKDBIN: ids},
+		}
+		if err := j.dbProxy.Table(common.BKTableNameAuditLog).Delete(kit.Ctx, deleteFilter); err != nil {
+			return removed, err
+		}
+		removed += int64(len(docs))
+
+		auditLog := newAuditArchiveLog(action, archive, metadata.FromCCSystem)
+		if err := j.audit.CreateAuditLog(kit, auditLog); err != nil {
+			blog.Errorf("save audit log of audit retention failed, archive: %+v, err: %+v, rid: %s", archive, err, kit.Rid)
+		}
+
+		if fetched < auditArchiveBatchSize && len(docs) == fetched {
+			return removed, nil
+		}
+	}
+	return removed, nil
+}
+
+// limitAuditArchiveSize returns the leading audit logs whose total size is within the max size, the first audit
+// log is always returned, so that the retention always makes progress.
+func limitAuditArchiveSize(docs []bson.Raw, maxSize int) []bson.Raw {
+	size := 0
+	for idx, doc := range docs {
+		size += len(doc)
+		if size > maxSize && idx > 0 {
+			return docs[:idx]
+		}
+	}
+	return docs
+}
+
+// auditArchiveKey returns the key of the archive of the audit logs, the same audit logs always get the same key
+func auditArchiveKey(policy metadata.AuditRetentionPolicy, docs []bson.Raw) string {
+	return fmt.Sprintf("%s:%s:%s:%s:%d", policy.SupplierAccount, policy.AuditType, auditLogKey(docs[0]),
+		auditLogKey(docs[len(docs)-1]), len(docs))
+}
+
+// auditLogKey returns the id of the raw audit log, the mongodb _id is used if the audit log has no valid id
+func auditLogKey(doc bson.Raw) string {
+	value := doc.Lookup(common.BKFieldID)
+	if id, ok := value.Int64OK(); ok {
+		return strconv.FormatInt(id, 10)
+	}
+	if id, ok := value.Int32OK(); ok {
+		return strconv.FormatInt(int64(id), 10)
+	}
+	return doc.Lookup("_id").String()
+}
+
+// newAuditArchive creates the archive of the audit logs, the audit logs are sorted by operation time
+func newAuditArchive(policy metadata.AuditRetentionPolicy, docs []bson.Raw) metadata.AuditLogArchive {
+	archive := metadata.AuditLogArchive{
+		Key:             auditArchiveKey(policy, docs),
+		AuditType:       policy.AuditType,
+		Count:           int64(len(docs)),
+		CreateTime:      metadata.Now(),
+		SupplierAccount: policy.SupplierAccount,
+	}
+	if startTime, ok := docs[0].Lookup(common.BKOperationTimeField).TimeOK(); ok {
+		archive.StartTime = metadata.Time{Time: startTime}
+	}
+	if endTime, ok := docs[len(docs)-1].Lookup(common.BKOperationTimeField).TimeOK(); ok {
+		archive.EndTime = metadata.Time{Time: endTime}
+	}
+	return archive
+}
+
+// saveArchive compresses the audit logs and saves them as an archive, if the archive of the audit logs is already
+// saved, which means the audit logs failed to be deleted after it is saved in a previous round, it is reused.
+func (j *RetentionJob) saveArchive(kit *rest.Kit, archive *metadata.AuditLogArchive, docs []bson.Raw) error {
+	saved, err := j.findArchive(kit, archive.Key)
+	if err != nil {
+		return err
+	}
+	if saved != nil {
+		*archive = *saved
+		return nil
+	}
+
+	data, err := encodeAuditArchive(docs)
+	if err != nil {
+		return err
+	}
+
+	id, err := j.dbProxy.NextSequence(kit.Ctx, common.BKTableNameAuditArchive)
+	if err != nil {
+		return err
+	}
+	archive.ID = int64(id)
+	archive.Size = int64(len(data))
+
+	err = j.dbProxy.Table(common.BKTableNameAuditArchive).Insert(kit.Ctx, auditArchiveData{AuditLogArchive: *archive, Data: data})
+	if err != nil && j.dbProxy.IsDuplicatedError(err) {
+		if saved, findErr := j.findArchive(kit, archive.Key); findErr == nil && saved != nil {
+			*archive = *saved
+			return nil
+		}
+	}
+	return err
+}
+
+// findArchive finds the archive by its key without the archived data, it returns nil if the archive is not found
+func (j *RetentionJob) findArchive(kit *rest.Kit, key string) (*metadata.AuditLogArchive, error) {
+	archives := make([]metadata.AuditLogArchive, 0)
+	filter := map[string]interface{}{"key": key}
+	if err := j.dbProxy.Table(common.BKTableNameAuditArchive).Find(filter).Fields(common.BKFieldID, "key",
+		common.BKAuditTypeField, "start_time", "end_time", "count", "size", common.CreateTimeField,
+		common.BkSupplierAccount).Limit(1).All(kit.Ctx, &archives); err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return nil, nil
+	}
+	return &archives[0], nil
+}
+
+// removeImportedArchives removes the audit logs imported from the archives of the policy's audit type, if the
+// retention days have passed since they were imported.
+func (j *RetentionJob) removeImportedArchives(kit *rest.Kit, policy metadata.AuditRetentionPolicy, cutoff time.Time) error {
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: policy.SupplierAccount,
+		common.BKAuditTypeField:  policy.AuditType,
+		"imported":               true,
+		"import_time":            map[string]interface{}{common.BKDBLT: cutoff},
+	}
+	archives := make([]metadata.AuditLogArchive, 0)
+	if err := j.dbProxy.Table(common.BKTableNameAuditArchive).Find(filter).Fields(common.BKFieldID).All(kit.Ctx, &archives); err != nil {
+		return err
+	}
+
+	for _, archive := range archives {
+		logFilter := map[string]interface{}{auditArchiveIDField: archive.ID}
+		if err := j.dbProxy.Table(common.BKTableNameAuditLog).Delete(kit.Ctx, logFilter); err != nil {
+			return err
+		}
+
+		archiveFilter := map[string]interface{}{common.BKFieldID: archive.ID}
+		if err := j.dbProxy.Table(common.BKTableNameAuditArchive).Update(kit.Ctx, archiveFilter, map[string]interface{}{"imported": false}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,514 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// SinkTypeSyslog streams audit logs to a syslog server in RFC5424 format.
+	SinkTypeSyslog = "syslog"
+	// SinkTypeFile streams audit logs to a local file in newline delimited json format.
+	SinkTypeFile = "file"
+	// SinkTypeWebhook streams audit logs to a http webhook in json array format.
+	SinkTypeWebhook = "webhook"
+)
+
+const (
+	// sinkConfigPrefix is the prefix of audit sink configs, eg: auditSink.names=siem
+	sinkConfigPrefix = "auditSink."
+
+	defaultSinkBufferDir      = "./auditsink"
+	defaultSinkMaxBufferSize  = 1024 << 20
+	defaultSinkFileMaxSize    = 100 << 20
+	defaultSinkFileMaxBackups = 10
+	defaultSinkTimeout        = 10 * time.Second
+	defaultSinkMaxAttempts    = 10
+	// defaultSyslogFacility is local0
+	defaultSyslogFacility = 16
+
+	// sinkFlushInterval is the interval to seal the buffered audit logs and deliver them
+	sinkFlushInterval = time.Second
+	// sinkMaxRetryInterval is the max interval to retry the failed delivery
+	sinkMaxRetryInterval = time.Minute
+	// sinkBatchSize is the max number of audit logs delivered to the sink at a time
+	sinkBatchSize = 500
+)
+
+// SinkConfig is the config of an audit log sink, the audit logs matching the filters are buffered on disk
+// after they are saved, then delivered to the sink asynchronously.
+type SinkConfig struct {
+	Name string
+	Type string
+
+	// filters of the audit logs, empty filter matches all the audit logs
+	AuditTypes    []metadata.AuditType
+	ResourceTypes []metadata.ResourceType
+	Actions       []metadata.ActionType
+
+	// Network is tcp or udp, Address is the address of the syslog server
+	Network  string
+	Address  string
+	Facility int
+
+	// Path is the file to write, it's rotated when its size exceeds MaxSize bytes,
+	// and at most MaxBackups rotated files are kept.
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+
+	// URL is the webhook address, Token is sent in the Authorization header if it's set
+	URL   string
+	Token string
+
+	// Timeout is the timeout to connect and send to syslog servers and webhooks
+	Timeout time.Duration
+
+	// BufferDir is the directory to buffer the audit logs of the sink, MaxBufferSize is the max bytes of
+	// the buffer, audit logs are dropped when the buffer is full because the sink is unavailable for long.
+	BufferDir     string
+	MaxBufferSize int64
+
+	// MaxAttempts is the max times to deliver a segment of the buffer, the segment is moved to the dead letter
+	// dir of the buffer after that, so that the following audit logs are delivered. 0 means retry forever.
+	MaxAttempts int
+}
+
+// ParseSinkConfigs parses the audit sink configs from the coreservice configs, eg:
+// [auditSink]
+// names=siem
+// bufferDir=./auditsink
+// siem.type=syslog
+// siem.network=tcp
+// siem.address=127.0.0.1:514
+// siem.auditType=host,business
+func ParseSinkConfigs(configMap map[string]string) ([]SinkConfig, error) {
+	names := splitSinkConfigList(configMap[sinkConfigPrefix+"names"])
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	bufferDir := configMap[sinkConfigPrefix+"bufferDir"]
+	if len(bufferDir) == 0 {
+		bufferDir = defaultSinkBufferDir
+	}
+	maxBufferSize, err := parseSinkConfigInt(configMap, sinkConfigPrefix+"maxBufferSize", defaultSinkMaxBufferSize>>20)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]SinkConfig, 0, len(names))
+	for _, name := range names {
+		prefix := sinkConfigPrefix + name + "."
+		config := SinkConfig{
+			Name:          name,
+			Type:          configMap[prefix+"type"],
+			Network:       configMap[prefix+"network"],
+			Address:       configMap[prefix+"address"],
+			Path:          configMap[prefix+"path"],
+			URL:           configMap[prefix+"url"],
+			Token:         configMap[prefix+"token"],
+			BufferDir:     bufferDir,
+			MaxBufferSize: maxBufferSize << 20,
+		}
+
+		for _, auditType := range splitSinkConfigList(configMap[prefix+"auditType"]) {
+			config.AuditTypes = append(config.AuditTypes, metadata.AuditType(auditType))
+		}
+		for _, resourceType := range splitSinkConfigList(configMap[prefix+"resourceType"]) {
+			config.ResourceTypes = append(config.ResourceTypes, metadata.ResourceType(resourceType))
+		}
+		for _, action := range splitSinkConfigList(configMap[prefix+"action"]) {
+			config.Actions = append(config.Actions, metadata.ActionType(action))
+		}
+
+		facility, err := parseSinkConfigInt(configMap, prefix+"facility", defaultSyslogFacility)
+		if err != nil {
+			return nil, err
+		}
+		config.Facility = int(facility)
+
+		maxSize, err := parseSinkConfigInt(configMap, prefix+"maxSize", defaultSinkFileMaxSize>>20)
+		if err != nil {
+			return nil, err
+		}
+		config.MaxSize = maxSize << 20
+
+		maxBackups, err := parseSinkConfigInt(configMap, prefix+"maxBackups", defaultSinkFileMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		config.MaxBackups = int(maxBackups)
+
+		timeout, err := parseSinkConfigInt(configMap, prefix+"timeout", int64(defaultSinkTimeout/time.Second))
+		if err != nil {
+			return nil, err
+		}
+		config.Timeout = time.Duration(timeout) * time.Second
+
+		maxAttempts, err := parseSinkConfigInt(configMap, prefix+"maxAttempts", defaultSinkMaxAttempts)
+		if err != nil {
+			return nil, err
+		}
+		config.MaxAttempts = int(maxAttempts)
+
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+func splitSinkConfigList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseSinkConfigInt(configMap map[string]string, key string, defaultValue int64) (int64, error) {
+	value, exists := configMap[key]
+	if !exists || len(value) == 0 {
+		return defaultValue, nil
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid audit sink config %s: %s", key, value)
+	}
+	return number, nil
+}
+
+// Validate validates the audit sink config
+func (c *SinkConfig) Validate() error {
+	if len(c.Name) == 0 || strings.ContainsAny(c.Name, `/\.`) {
+		return fmt.Errorf("invalid audit sink name %s", c.Name)
+	}
+	if len(c.BufferDir) == 0 || c.MaxBufferSize <= 0 {
+		return fmt.Errorf("audit sink %s buffer is not set", c.Name)
+	}
+
+	switch c.Type {
+	case SinkTypeSyslog:
+		if c.Network != "tcp" && c.Network != "udp" {
+			return fmt.Errorf("audit sink %s network %s is not tcp or udp", c.Name, c.Network)
+		}
+		if len(c.Address) == 0 {
+			return fmt.Errorf("audit sink %s address is not set", c.Name)
+		}
+		if c.Facility < 0 || c.Facility > 23 {
+			return fmt.Errorf("audit sink %s facility %d is invalid", c.Name, c.Facility)
+		}
+	case SinkTypeFile:
+		if len(c.Path) == 0 {
+			return fmt.Errorf("audit sink %s path is not set", c.Name)
+		}
+		if c.MaxSize <= 0 || c.MaxBackups < 0 {
+			return fmt.Errorf("audit sink %s rotation is invalid", c.Name)
+		}
+	case SinkTypeWebhook:
+		if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+			return fmt.Errorf("audit sink %s url %s is invalid", c.Name, c.URL)
+		}
+	default:
+		return fmt.Errorf("audit sink %s type %s is not supported", c.Name, c.Type)
+	}
+
+	if c.Type != SinkTypeFile && c.Timeout <= 0 {
+		return fmt.Errorf("audit sink %s timeout is invalid", c.Name)
+	}
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("audit sink %s max attempts %d is invalid", c.Name, c.MaxAttempts)
+	}
+	return nil
+}
+
+// Match returns if the audit log matches the filters of the sink
+func (c *SinkConfig) Match(log *metadata.AuditLog) bool {
+	if len(c.AuditTypes) > 0 {
+		matched := false
+		for _, auditType := range c.AuditTypes {
+			if auditType == log.AuditType {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(c.ResourceTypes) > 0 {
+		matched := false
+		for _, resourceType := range c.ResourceTypes {
+			if resourceType == log.ResourceType {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(c.Actions) > 0 {
+		matched := false
+		for _, action := range c.Actions {
+			if action == log.Action {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// SinkRecord is an audit log buffered for the sinks, the operation time of the audit log is kept
+// in Time with the time zone, because the json format of the operation time loses it.
+type SinkRecord struct {
+	Time time.Time       `json:"time"`
+	Log  json.RawMessage `json:"log"`
+}
+
+// Sink is the destination that the audit logs are streamed to.
+type Sink interface {
+	// Send delivers the audit logs to the destination, the audit logs are resent if it returns an error,
+	// so the audit logs are delivered at least once.
+	Send(records []SinkRecord) error
+	// Close releases the connection or file of the sink
+	Close() error
+}
+
+// NewSink creates the sink of the audit sink config
+func NewSink(config SinkConfig) (Sink, error) {
+	switch config.Type {
+	case SinkTypeSyslog:
+		return newSyslogSink(config), nil
+	case SinkTypeFile:
+		return newFileSink(config), nil
+	case SinkTypeWebhook:
+		return newWebhookSink(config), nil
+	default:
+		return nil, fmt.Errorf("audit sink type %s is not supported", config.Type)
+	}
+}
+
+// SinkManager streams the saved audit logs to the sinks, the audit logs are appended to the disk buffer
+// of each sink on the write path, and delivered by the background goroutine of each sink, so that
+// the sinks never block the write path.
+type SinkManager struct {
+	streams  []*sinkStream
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+type sinkStream struct {
+	config SinkConfig
+	sink   Sink
+	buffer *sinkBuffer
+
+	// failedSegment is the segment failed to deliver last time, attempts is the times it's delivered
+	failedSegment string
+	attempts      int
+}
+
+// NewSinkManager creates the sinks and their disk buffers, it returns nil if there's no sink.
+func NewSinkManager(configs []SinkConfig) (*SinkManager, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	manager := &SinkManager{stop: make(chan struct{})}
+	names := make(map[string]bool)
+	for _, config := range configs {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		if names[config.Name] {
+			return nil, fmt.Errorf("audit sink %s is duplicated", config.Name)
+		}
+		names[config.Name] = true
+
+		buffer, err := newSinkBuffer(config.BufferDir, config.Name, config.MaxBufferSize)
+		if err != nil {
+			return nil, err
+		}
+		sink, err := NewSink(config)
+		if err != nil {
+			return nil, err
+		}
+		manager.streams = append(manager.streams, &sinkStream{config: config, sink: sink, buffer: buffer})
+	}
+	return manager, nil
+}
+
+// Run starts to deliver the buffered audit logs to the sinks
+func (m *SinkManager) Run() {
+	if m == nil {
+		return
+	}
+	for _, stream := range m.streams {
+		go stream.deliverLoop(m.stop)
+	}
+}
+
+// Stop stops the delivery, the audit logs not delivered are kept in the disk buffer
+func (m *SinkManager) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+// Publish appends the saved audit logs to the buffers of the sinks they match
+func (m *SinkManager) Publish(rid string, logs []metadata.AuditLog) {
+	if m == nil || len(logs) == 0 {
+		return
+	}
+
+	records := make([]*SinkRecord, len(logs))
+	for _, stream := range m.streams {
+		matched := make([]SinkRecord, 0)
+		for idx := range logs {
+			if !stream.config.Match(&logs[idx]) {
+				continue
+			}
+			if records[idx] == nil {
+				content, err := json.Marshal(logs[idx])
+				if err != nil {
+					blog.Errorf("marshal audit log for sinks failed, log: %+v, err: %v, rid: %s", logs[idx], err, rid)
+					continue
+				}
+				records[idx] = &SinkRecord{Time: logs[idx].OperationTime.Time, Log: content}
+			}
+			matched = append(matched, *records[idx])
+		}
+
+		if len(matched) == 0 {
+			continue
+		}
+		if err := stream.buffer.Append(matched); err != nil {
+			blog.Errorf("buffer %d audit logs for sink %s failed, err: %v, rid: %s", len(matched), stream.config.Name, err, rid)
+		}
+	}
+}
+
+// deliverLoop seals the buffer periodically and delivers the sealed segments in order, a segment is
+// removed after all its audit logs are delivered, and the failed delivery is retried with backoff.
+func (s *sinkStream) deliverLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(sinkFlushInterval)
+	defer ticker.Stop()
+	defer s.sink.Close()
+
+	retryInterval := sinkFlushInterval
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.buffer.Seal(); err != nil {
+			blog.Errorf("seal audit sink %s buffer failed, err: %v", s.config.Name, err)
+		}
+
+		if err := s.deliver(stop); err != nil {
+			blog.Errorf("deliver audit logs to sink %s failed, retry after %s, err: %v", s.config.Name, retryInterval, err)
+			select {
+			case <-stop:
+				return
+			case <-time.After(retryInterval):
+			}
+			retryInterval *= 2
+			if retryInterval > sinkMaxRetryInterval {
+				retryInterval = sinkMaxRetryInterval
+			}
+			continue
+		}
+		retryInterval = sinkFlushInterval
+	}
+}
+
+// deliver delivers all the sealed segments, and stops at the first failure, the segment that still fails after
+// the max attempts is moved to the dead letters, and the delivery goes on with the next segment.
+func (s *sinkStream) deliver(stop <-chan struct{}) error {
+	segments, err := s.buffer.Segments()
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		select {
+		case <-stop:
+			return errors.New("audit sink is stopped")
+		default:
+		}
+
+		if err := s.deliverSegment(segment); err != nil {
+			if segment != s.failedSegment {
+				s.failedSegment, s.attempts = segment, 0
+			}
+			s.attempts++
+			if s.config.MaxAttempts == 0 || s.attempts < s.config.MaxAttempts {
+				return err
+			}
+
+			blog.Errorf("deliver audit sink %s segment %s failed %d times, move it to dead letters, err: %v",
+				s.config.Name, segment, s.attempts, err)
+			if err := s.buffer.DeadLetter(segment); err != nil {
+				return err
+			}
+			s.failedSegment, s.attempts = "", 0
+			continue
+		}
+		if segment == s.failedSegment {
+			s.failedSegment, s.attempts = "", 0
+		}
+	}
+	return nil
+}
+
+// deliverSegment delivers the audit logs of the segment in batches, and removes it after all are delivered
+func (s *sinkStream) deliverSegment(segment string) error {
+	records, err := s.buffer.Read(segment)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(records); start += sinkBatchSize {
+		end := start + sinkBatchSize
+		if end > len(records) {
+			end = len(records)
+		}
+		if err := s.sink.Send(records[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return s.buffer.Remove(segment)
+}
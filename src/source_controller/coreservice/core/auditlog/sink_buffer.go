@@ -0,0 +1,234 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+const (
+	// sinkSegmentSuffix is the suffix of the sealed segments that are ready to deliver
+	sinkSegmentSuffix = ".log"
+	// sinkWritingSuffix is the suffix of the segment that is being written
+	sinkWritingSuffix = ".writing"
+	// sinkDeadLetterDir is the sub dir of the buffer that keeps the segments failed to deliver, they can
+	// be moved back to the buffer dir to deliver again.
+	sinkDeadLetterDir = "deadletter"
+)
+
+// sinkBuffer buffers the audit logs of a sink on disk, the audit logs are appended to the writing segment,
+// which is sealed periodically, and the sealed segments are delivered in the order of their names.
+type sinkBuffer struct {
+	dir     string
+	maxSize int64
+
+	lock sync.Mutex
+	// size is the total size of the segments
+	size    int64
+	lastSeq int64
+	writing *os.File
+}
+
+func newSinkBuffer(baseDir, name string, maxSize int64) (*sinkBuffer, error) {
+	dir := filepath.Join(baseDir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create audit sink buffer dir %s failed, err: %v", dir, err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read audit sink buffer dir %s failed, err: %v", dir, err)
+	}
+
+	buffer := &sinkBuffer{dir: dir, maxSize: maxSize}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		// the writing segment left by the last process is sealed, so that it's delivered
+		name := file.Name()
+		if strings.HasSuffix(name, sinkWritingSuffix) {
+			sealed := strings.TrimSuffix(name, sinkWritingSuffix) + sinkSegmentSuffix
+			if err := os.Rename(filepath.Join(dir, name), filepath.Join(dir, sealed)); err != nil {
+				return nil, fmt.Errorf("seal audit sink buffer segment %s failed, err: %v", name, err)
+			}
+			name = sealed
+		}
+		if !strings.HasSuffix(name, sinkSegmentSuffix) {
+			continue
+		}
+
+		buffer.size += file.Size()
+		var seq int64
+		if _, err := fmt.Sscanf(strings.TrimSuffix(name, sinkSegmentSuffix), "%d", &seq); err == nil && seq > buffer.lastSeq {
+			buffer.lastSeq = seq
+		}
+	}
+	return buffer, nil
+}
+
+// Append appends the audit logs to the writing segment, it returns an error if the buffer is full
+func (b *sinkBuffer) Append(records []SinkRecord) error {
+	content := bytes.Buffer{}
+	encoder := json.NewEncoder(&content)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.size+int64(content.Len()) > b.maxSize {
+		return fmt.Errorf("audit sink buffer %s is full, size: %d", b.dir, b.size)
+	}
+
+	if b.writing == nil {
+		b.lastSeq++
+		if now := time.Now().UnixNano(); now > b.lastSeq {
+			b.lastSeq = now
+		}
+		name := filepath.Join(b.dir, fmt.Sprintf("%020d%s", b.lastSeq, sinkWritingSuffix))
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("create audit sink buffer segment %s failed, err: %v", name, err)
+		}
+		b.writing = file
+	}
+
+	n, err := b.writing.Write(content.Bytes())
+	b.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write audit sink buffer segment %s failed, err: %v", b.writing.Name(), err)
+	}
+	return nil
+}
+
+// Seal closes the writing segment and makes it ready to deliver
+func (b *sinkBuffer) Seal() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.writing == nil {
+		return nil
+	}
+
+	name := b.writing.Name()
+	err := b.writing.Close()
+	b.writing = nil
+	if err != nil {
+		return fmt.Errorf("close audit sink buffer segment %s failed, err: %v", name, err)
+	}
+	return os.Rename(name, strings.TrimSuffix(name, sinkWritingSuffix)+sinkSegmentSuffix)
+}
+
+// Segments returns the sealed segments in order
+func (b *sinkBuffer) Segments() ([]string, error) {
+	files, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]string, 0)
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), sinkSegmentSuffix) {
+			segments = append(segments, file.Name())
+		}
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// Read reads the audit logs in the segment, the broken lines are skipped
+func (b *sinkBuffer) Read(segment string) ([]SinkRecord, error) {
+	file, err := os.Open(filepath.Join(b.dir, segment))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make([]SinkRecord, 0)
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			record := SinkRecord{}
+			if jsonErr := json.Unmarshal(line, &record); jsonErr != nil {
+				blog.Errorf("skip broken audit log in sink buffer segment %s, line: %s, err: %v", segment, line, jsonErr)
+			} else {
+				records = append(records, record)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read audit sink buffer segment %s failed, err: %v", segment, err)
+		}
+	}
+	return records, nil
+}
+
+// Remove removes the delivered segment
+func (b *sinkBuffer) Remove(segment string) error {
+	path := filepath.Join(b.dir, segment)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	b.size -= info.Size()
+	b.lock.Unlock()
+	return nil
+}
+
+// DeadLetter moves the segment that can't be delivered to the dead letter dir
+func (b *sinkBuffer) DeadLetter(segment string) error {
+	path := filepath.Join(b.dir, segment)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	deadDir := filepath.Join(b.dir, sinkDeadLetterDir)
+	if err := os.MkdirAll(deadDir, 0755); err != nil {
+		return fmt.Errorf("create audit sink dead letter dir %s failed, err: %v", deadDir, err)
+	}
+	if err := os.Rename(path, filepath.Join(deadDir, segment)); err != nil {
+		return fmt.Errorf("move audit sink buffer segment %s to dead letters failed, err: %v", segment, err)
+	}
+
+	b.lock.Lock()
+	b.size -= info.Size()
+	b.lock.Unlock()
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// fileSink writes audit logs to a file in newline delimited json format, the file is rotated when its size
+// exceeds the max size, the rotated files are named as path.1, path.2... and path.1 is the latest one.
+type fileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newFileSink(config SinkConfig) *fileSink {
+	return &fileSink{
+		path:       config.Path,
+		maxSize:    config.MaxSize,
+		maxBackups: config.MaxBackups,
+	}
+}
+
+// Send writes the audit logs and syncs the file
+func (s *fileSink) Send(records []SinkRecord) error {
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	content := bytes.Buffer{}
+	for _, record := range records {
+		content.Write(record.Log)
+		content.WriteByte('\n')
+	}
+
+	n, err := s.file.Write(content.Bytes())
+	s.size += int64(n)
+	if err != nil {
+		s.Close()
+		return fmt.Errorf("write audit log file %s failed, err: %v", s.path, err)
+	}
+	if err := s.file.Sync(); err != nil {
+		s.Close()
+		return fmt.Errorf("sync audit log file %s failed, err: %v", s.path, err)
+	}
+
+	if s.size >= s.maxSize {
+		return s.rotate()
+	}
+	return nil
+}
+
+func (s *fileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open audit log file %s failed, err: %v", s.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat audit log file %s failed, err: %v", s.path, err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate renames the file to path.1 after shifting the rotated files, the oldest one is removed
+// if there are max backups already.
+func (s *fileSink) rotate() error {
+	if err := s.Close(); err != nil {
+		return fmt.Errorf("close audit log file %s failed, err: %v", s.path, err)
+	}
+
+	if s.maxBackups == 0 {
+		return os.Remove(s.path)
+	}
+
+	oldest := fmt.Sprintf("%s.%d", s.path, s.maxBackups)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for idx := s.maxBackups - 1; idx > 0; idx-- {
+		from := fmt.Sprintf("%s.%d", s.path, idx)
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", s.path, idx+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(s.path, s.path+".1")
+}
+
+// Close closes the file
+func (s *fileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	s.size = 0
+	return err
+}
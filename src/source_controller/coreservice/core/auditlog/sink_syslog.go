@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+const (
+	// syslogAppName is the APP-NAME of the syslog messages
+	syslogAppName = "cmdb_coreservice"
+	// syslogMsgID is the MSGID of the syslog messages
+	syslogMsgID = "audit"
+	// syslogSeverityInfo is the severity of the syslog messages, audit logs are informational messages
+	syslogSeverityInfo = 6
+)
+
+// syslogSink sends audit logs to syslog servers in RFC5424 format, the messages sent over tcp are framed by
+// octet counting as RFC6587 describes, and each message sent over udp is a datagram.
+type syslogSink struct {
+	network  string
+	address  string
+	facility int
+	timeout  time.Duration
+	hostname string
+	pid      int
+	conn     net.Conn
+}
+
+func newSyslogSink(config SinkConfig) *syslogSink {
+	hostname, err := os.Hostname()
+	if err != nil || len(hostname) == 0 {
+		hostname = "-"
+	}
+	return &syslogSink{
+		network:  config.Network,
+		address:  config.Address,
+		facility: config.Facility,
+		timeout:  config.Timeout,
+		hostname: hostname,
+		pid:      os.Getpid(),
+	}
+}
+
+// Send sends the audit logs, the connection is rebuilt in the next sending if it fails
+func (s *syslogSink) Send(records []SinkRecord) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+		if err != nil {
+			return fmt.Errorf("connect syslog server %s://%s failed, err: %v", s.network, s.address, err)
+		}
+		s.conn = conn
+	}
+
+	for _, record := range records {
+		message := s.format(record)
+		if s.network == "tcp" {
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+
+		if err := s.conn.SetWriteDeadline(time.Now().Add(s.timeout)); err != nil {
+			s.Close()
+			return err
+		}
+		if _, err := s.conn.Write([]byte(message)); err != nil {
+			s.Close()
+			return fmt.Errorf("send to syslog server %s://%s failed, err: %v", s.network, s.address, err)
+		}
+	}
+	return nil
+}
+
+// format formats the audit log as a RFC5424 message without structured data, the message is the audit log json
+func (s *syslogSink) format(record SinkRecord) string {
+	timestamp := "-"
+	if !record.Time.IsZero() {
+		timestamp = record.Time.Format("2006-01-02T15:04:05.000000Z07:00")
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s - %s", s.facility*8+syslogSeverityInfo, timestamp, s.hostname,
+		syslogAppName, s.pid, syslogMsgID, record.Log)
+}
+
+// Close closes the connection to the syslog server
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,289 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSinkConfigs(t *testing.T) {
+	configs, err := ParseSinkConfigs(map[string]string{
+		"auditSink.names":               "siem, archive",
+		"auditSink.maxBufferSize":       "10",
+		"auditSink.siem.type":           "syslog",
+		"auditSink.siem.network":        "tcp",
+		"auditSink.siem.address":        "127.0.0.1:514",
+		"auditSink.siem.auditType":      "host,business",
+		"auditSink.siem.action":         "delete",
+		"auditSink.archive.type":        "file",
+		"auditSink.archive.path":        "/tmp/audit.log",
+		"auditSink.archive.maxSize":     "1",
+		"auditSink.archive.facility":    "",
+		"auditSink.archive.maxAttempts": "3",
+	})
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+
+	require.Equal(t, "siem", configs[0].Name)
+	require.Equal(t, []metadata.AuditType{"host", "business"}, configs[0].AuditTypes)
+	require.Equal(t, []metadata.ActionType{"delete"}, configs[0].Actions)
+	require.Equal(t, defaultSyslogFacility, configs[0].Facility)
+	require.Equal(t, int64(10<<20), configs[0].MaxBufferSize)
+	require.Equal(t, defaultSinkBufferDir, configs[0].BufferDir)
+	require.Equal(t, defaultSinkMaxAttempts, configs[0].MaxAttempts)
+	require.NoError(t, configs[0].Validate())
+
+	require.Equal(t, int64(1<<20), configs[1].MaxSize)
+	require.Equal(t, defaultSinkFileMaxBackups, configs[1].MaxBackups)
+	require.Equal(t, 3, configs[1].MaxAttempts)
+	require.NoError(t, configs[1].Validate())
+
+	_, err = ParseSinkConfigs(map[string]string{"auditSink.names": "siem", "auditSink.siem.timeout": "abc"})
+	require.Error(t, err)
+
+	configs, err = ParseSinkConfigs(map[string]string{"auditSink.names": "siem", "auditSink.siem.type": "kafka"})
+	require.NoError(t, err)
+	require.Error(t, configs[0].Validate())
+
+	configs, err = ParseSinkConfigs(map[string]string{})
+	require.NoError(t, err)
+	require.Len(t, configs, 0)
+}
+
+func TestSinkConfigMatch(t *testing.T) {
+	config := SinkConfig{
+		AuditTypes: []metadata.AuditType{metadata.HostType},
+		Actions:    []metadata.ActionType{metadata.AuditCreate, metadata.AuditDelete},
+	}
+
+	require.True(t, config.Match(&metadata.AuditLog{AuditType: metadata.HostType, Action: metadata.AuditDelete}))
+	require.False(t, config.Match(&metadata.AuditLog{AuditType: metadata.HostType, Action: metadata.AuditUpdate}))
+	require.False(t, config.Match(&metadata.AuditLog{AuditType: metadata.BusinessType, Action: metadata.AuditDelete}))
+	require.True(t, (&SinkConfig{}).Match(&metadata.AuditLog{AuditType: metadata.BusinessType}))
+}
+
+func TestSinkBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditsink")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buffer, err := newSinkBuffer(dir, "test", 1<<20)
+	require.NoError(t, err)
+
+	require.NoError(t, buffer.Append([]SinkRecord{{Log: json.RawMessage(`{"id":1}`)}}))
+	segments, err := buffer.Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 0, "the writing segment is not ready")
+
+	require.NoError(t, buffer.Seal())
+	require.NoError(t, buffer.Append([]SinkRecord{{Log: json.RawMessage(`{"id":2}`)}, {Log: json.RawMessage(`{"id":3}`)}}))
+
+	// the writing segment is sealed when the buffer is reopened
+	buffer, err = newSinkBuffer(dir, "test", 1<<20)
+	require.NoError(t, err)
+	segments, err = buffer.Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	require.True(t, buffer.size > 0)
+
+	records, err := buffer.Read(segments[0])
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.JSONEq(t, `{"id":1}`, string(records[0].Log))
+
+	records, err = buffer.Read(segments[1])
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	for _, segment := range segments {
+		require.NoError(t, buffer.Remove(segment))
+	}
+	require.Equal(t, int64(0), buffer.size)
+
+	full, err := newSinkBuffer(dir, "full", 10)
+	require.NoError(t, err)
+	require.Error(t, full.Append([]SinkRecord{{Log: json.RawMessage(`{"id":1}`)}}))
+}
+
+type testSink struct {
+	failures int
+	records  []SinkRecord
+}
+
+func (s *testSink) Send(records []SinkRecord) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink is unavailable")
+	}
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *testSink) Close() error {
+	return nil
+}
+
+func TestSinkStreamDeliver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditsink")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buffer, err := newSinkBuffer(dir, "test", 1<<20)
+	require.NoError(t, err)
+	sink := &testSink{failures: 1}
+	manager := &SinkManager{
+		streams: []*sinkStream{{
+			config: SinkConfig{Name: "test", Actions: []metadata.ActionType{metadata.AuditDelete}},
+			sink:   sink,
+			buffer: buffer,
+		}},
+		stop: make(chan struct{}),
+	}
+
+	manager.Publish("", []metadata.AuditLog{
+		{ID: 1, Action: metadata.AuditCreate},
+		{ID: 2, Action: metadata.AuditDelete},
+	})
+	stream := manager.streams[0]
+	require.NoError(t, stream.buffer.Seal())
+
+	require.Error(t, stream.deliver(manager.stop))
+	segments, err := buffer.Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 1, "the segment is kept when the delivery fails")
+
+	require.NoError(t, stream.deliver(manager.stop))
+	require.Len(t, sink.records, 1)
+	log := metadata.AuditLog{}
+	require.NoError(t, json.Unmarshal(sink.records[0].Log, &log))
+	require.Equal(t, int64(2), log.ID)
+
+	segments, err = buffer.Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 0)
+}
+
+func TestSinkStreamDeadLetter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditsink")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buffer, err := newSinkBuffer(dir, "test", 1<<20)
+	require.NoError(t, err)
+	sink := &testSink{failures: 3}
+	stream := &sinkStream{config: SinkConfig{Name: "test", MaxAttempts: 3}, sink: sink, buffer: buffer}
+
+	require.NoError(t, buffer.Append([]SinkRecord{{Log: json.RawMessage(`{"id":1}`)}}))
+	require.NoError(t, buffer.Seal())
+	require.NoError(t, buffer.Append([]SinkRecord{{Log: json.RawMessage(`{"id":2}`)}}))
+	require.NoError(t, buffer.Seal())
+	segments, err := buffer.Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+
+	// the first segment is retried until the max attempts
+	require.Error(t, stream.deliver(nil))
+	require.Error(t, stream.deliver(nil))
+	require.Len(t, sink.records, 0)
+
+	// then it's moved to the dead letters, and the next segment is delivered
+	require.NoError(t, stream.deliver(nil))
+	require.Len(t, sink.records, 1)
+	require.JSONEq(t, `{"id":2}`, string(sink.records[0].Log))
+
+	remains, err := buffer.Segments()
+	require.NoError(t, err)
+	require.Len(t, remains, 0)
+	require.Equal(t, int64(0), buffer.size)
+	_, err = os.Stat(filepath.Join(dir, "test", sinkDeadLetterDir, segments[0]))
+	require.NoError(t, err)
+
+	// the dead letters are not delivered when the buffer is reopened
+	buffer, err = newSinkBuffer(dir, "test", 1<<20)
+	require.NoError(t, err)
+	remains, err = buffer.Segments()
+	require.NoError(t, err)
+	require.Len(t, remains, 0)
+}
+
+func TestFileSinkRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditsink")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	sink := newFileSink(SinkConfig{Path: path, MaxSize: 10, MaxBackups: 2})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, sink.Send([]SinkRecord{{Log: json.RawMessage(`{"id":1234567}`)}}))
+	}
+	require.NoError(t, sink.Send([]SinkRecord{{Log: json.RawMessage(`{"id":1}`)}}))
+	require.NoError(t, sink.Close())
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "{\"id\":1}\n", string(content))
+	for _, backup := range []string{path + ".1", path + ".2"} {
+		content, err = ioutil.ReadFile(backup)
+		require.NoError(t, err)
+		require.Equal(t, "{\"id\":1234567}\n", string(content))
+	}
+	_, err = os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSyslogSinkFormat(t *testing.T) {
+	sink := newSyslogSink(SinkConfig{Network: "udp", Address: "127.0.0.1:514", Facility: 16})
+	sink.hostname = "cmdb"
+	sink.pid = 100
+
+	message := sink.format(SinkRecord{
+		Time: time.Date(2020, 7, 22, 10, 0, 0, 0, time.UTC),
+		Log:  json.RawMessage(`{"id":1}`),
+	})
+	require.Equal(t, `<134>1 2020-07-22T10:00:00.000000Z cmdb cmdb_coreservice 100 audit - {"id":1}`, message)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []map[string]interface{}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ := ioutil.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := newWebhookSink(SinkConfig{URL: server.URL, Token: "token", Timeout: time.Second})
+	records := []SinkRecord{{Log: json.RawMessage(`{"id":1}`)}, {Log: json.RawMessage(`{"id":2}`)}}
+	require.NoError(t, sink.Send(records))
+	require.Len(t, received, 2)
+
+	status = http.StatusInternalServerError
+	err := sink.Send(records)
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "500"))
+}
@@ -0,0 +1,76 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auditlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// webhookSink posts audit logs to a http webhook as a json array
+type webhookSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func newWebhookSink(config SinkConfig) *webhookSink {
+	return &webhookSink{
+		url:    config.URL,
+		token:  config.Token,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Send posts the audit logs, the delivery fails if the webhook doesn't respond with 2xx status
+func (s *webhookSink) Send(records []SinkRecord) error {
+	body := bytes.Buffer{}
+	body.WriteByte('[')
+	for idx, record := range records {
+		if idx > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(record.Log)
+	}
+	body.WriteByte(']')
+
+	req, err := http.NewRequest(http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post audit logs to webhook %s failed, err: %v", s.url, err)
+	}
+	defer resp.Body.Close()
+	// drain the body so that the connection can be reused
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("post audit logs to webhook %s failed, status: %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+// Close does nothing, the http client needs no closing
+func (s *webhookSink) Close() error {
+	return nil
+}
@@ -90,6 +90,12 @@ type InstanceOperation interface {
 	SearchModelInstance(kit *rest.Kit, objID string, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
 	DeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	CascadeDeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	RestoreModelInstance(kit *rest.Kit, objID string, data mapstr.MapStr) error
+	// RefreshComputedValues queues a task to re-evaluate the computed attributes' values of the instances in background,
+	// nil instIDs means all instances
+	RefreshComputedValues(kit *rest.Kit, objID string, instIDs []int64) error
+	// RevealSecretValue decrypts the secret attribute value of the instance
+	RevealSecretValue(kit *rest.Kit, objID string, instID int64, propertyID string) (*metadata.RevealSecretResult, error)
 }
 
 // AssociationKind association kind methods
@@ -137,6 +143,13 @@ type DataSynchronizeOperation interface {
 type TopoOperation interface {
 	SearchMainlineModelTopo(ctx context.Context, header http.Header, withDetail bool) (*metadata.TopoModelNode, error)
 	SearchMainlineInstanceTopo(ctx context.Context, header http.Header, objID int64, withDetail bool) (*metadata.TopoInstanceNode, error)
+
+	CreateTopoSnapshot(kit *rest.Kit, bizID int64, option metadata.CreateTopoSnapshotOption) (*metadata.TopoSnapshot, errors.CCErrorCoder)
+	ListTopoSnapshot(kit *rest.Kit, bizID int64, option metadata.ListTopoSnapshotOption) (*metadata.MultipleTopoSnapshot, errors.CCErrorCoder)
+	DeleteTopoSnapshot(kit *rest.Kit, bizID int64, snapshotID int64) errors.CCErrorCoder
+	DiffTopoSnapshot(kit *rest.Kit, bizID int64, option metadata.DiffTopoSnapshotOption) (*metadata.TopoSnapshotDiff, errors.CCErrorCoder)
+	GetTopoSnapshotSchedule(kit *rest.Kit, bizID int64) (*metadata.TopoSnapshotSchedule, errors.CCErrorCoder)
+	UpdateTopoSnapshotSchedule(kit *rest.Kit, bizID int64, schedule metadata.TopoSnapshotSchedule) errors.CCErrorCoder
 }
 
 // HostOperation methods
@@ -171,6 +184,10 @@ type AssociationOperation interface {
 type AuditOperation interface {
 	CreateAuditLog(kit *rest.Kit, logs ...metadata.AuditLog) error
 	SearchAuditLog(kit *rest.Kit, param metadata.QueryInput) ([]metadata.AuditLog, uint64, error)
+	ListAuditRetentionPolicy(kit *rest.Kit) ([]metadata.AuditRetentionPolicy, errors.CCErrorCoder)
+	UpdateAuditRetentionPolicy(kit *rest.Kit, policy metadata.AuditRetentionPolicy) errors.CCErrorCoder
+	ListAuditArchive(kit *rest.Kit, option metadata.ListAuditLogArchiveOption) (*metadata.MultipleAuditLogArchive, errors.CCErrorCoder)
+	ImportAuditArchive(kit *rest.Kit, archiveID int64) (*metadata.ImportAuditLogArchiveResult, errors.CCErrorCoder)
 }
 
 type StatisticOperation interface {
@@ -200,6 +217,7 @@ type Core interface {
 	SetTemplateOperation() SetTemplateOperation
 	HostApplyRuleOperation() HostApplyRuleOperation
 	SystemOperation() SystemOperation
+	RecycleBinOperation() RecycleBinOperation
 }
 
 // ProcessOperation methods
@@ -280,6 +298,13 @@ type HostApplyRuleOperation interface {
 	SearchRuleRelatedModules(kit *rest.Kit, bizID int64, option metadata.SearchRuleRelatedModulesOption) ([]metadata.Module, errors.CCErrorCoder)
 	BatchUpdateHostApplyRule(kit *rest.Kit, bizID int64, option metadata.BatchCreateOrUpdateApplyRuleOption) (metadata.BatchCreateOrUpdateHostApplyRuleResult, errors.CCErrorCoder)
 	RunHostApplyOnHosts(kit *rest.Kit, bizID int64, option metadata.UpdateHostByHostApplyRuleOption) (metadata.MultipleHostApplyResult, errors.CCErrorCoder)
+
+	// drift of host apply rules
+	GetHostApplyDriftConfig(kit *rest.Kit, bizID int64) (metadata.HostApplyDriftConfig, errors.CCErrorCoder)
+	UpdateHostApplyDriftConfig(kit *rest.Kit, bizID int64, option metadata.UpdateHostApplyDriftConfigOption) (metadata.HostApplyDriftConfig, errors.CCErrorCoder)
+	ListHostApplyDriftConfig(kit *rest.Kit, option metadata.ListHostApplyDriftConfigOption) ([]metadata.HostApplyDriftConfig, errors.CCErrorCoder)
+	SaveHostApplyDrift(kit *rest.Kit, option metadata.SaveHostApplyDriftOption) errors.CCErrorCoder
+	ListHostApplyDrift(kit *rest.Kit, bizID int64, option metadata.ListHostApplyDriftOption) (metadata.MultipleHostApplyDrift, errors.CCErrorCoder)
 }
 
 type SystemOperation interface {
@@ -287,6 +312,14 @@ type SystemOperation interface {
 	SearchConfigAdmin(kit *rest.Kit) (*metadata.ConfigAdmin, errors.CCErrorCoder)
 }
 
+// RecycleBinOperation recycle bin methods
+type RecycleBinOperation interface {
+	RecycleInstances(kit *rest.Kit, objID string, origins []mapstr.MapStr) errors.CCErrorCoder
+	ListRecycleBin(kit *rest.Kit, option metadata.ListRecycleBinOption) (*metadata.MultipleRecycleBinItem, errors.CCErrorCoder)
+	RestoreRecycleBin(kit *rest.Kit, id int64) (*metadata.RecycleBinItem, errors.CCErrorCoder)
+	PurgeRecycleBin(kit *rest.Kit, option metadata.PurgeRecycleBinOption) (*metadata.PurgeRecycleBinResult, errors.CCErrorCoder)
+}
+
 type core struct {
 	model           ModelOperation
 	instance        InstanceOperation
@@ -301,6 +334,7 @@ type core struct {
 	sys             SystemOperation
 	setTemplate     SetTemplateOperation
 	hostApplyRule   HostApplyRuleOperation
+	recycleBin      RecycleBinOperation
 }
 
 // New create core
@@ -317,6 +351,7 @@ func New(
 	operation StatisticOperation,
 	hostApplyRule HostApplyRuleOperation,
 	sys SystemOperation,
+	recycleBin RecycleBinOperation,
 ) Core {
 	return &core{
 		model:           model,
@@ -332,6 +367,7 @@ func New(
 		sys:             sys,
 		setTemplate:     setTemplate,
 		hostApplyRule:   hostApplyRule,
+		recycleBin:      recycleBin,
 	}
 }
 
@@ -386,3 +422,7 @@ func (m *core) SystemOperation() SystemOperation {
 func (m *core) HostApplyRuleOperation() HostApplyRuleOperation {
 	return m.hostApplyRule
 }
+
+func (m *core) RecycleBinOperation() RecycleBinOperation {
+	return m.recycleBin
+}
@@ -14,25 +14,64 @@ package searcher
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
 	"configcenter/src/common/util"
 	"configcenter/src/storage/dal"
 
 	"gopkg.in/redis.v5"
 )
 
+// hostAttributeCacheTTL is how long the host attributes of a supplier account are cached, the host attributes are
+// rarely changed, so they are not read from db in every ListHosts, a changed attribute takes effect after the ttl.
+const hostAttributeCacheTTL = 30 * time.Second
+
 type Searcher struct {
-	DbProxy dal.RDB
-	Cache   *redis.Client
+	DbProxy    dal.RDB
+	Cache      *redis.Client
+	attributes *hostAttributeCache
 }
 
 func New(db dal.RDB, cache *redis.Client) Searcher {
 	return Searcher{
-		DbProxy: db,
-		Cache:   cache,
+		DbProxy:    db,
+		Cache:      cache,
+		attributes: &hostAttributeCache{items: make(map[string]hostAttributeCacheItem)},
+	}
+}
+
+// hostAttributeCache caches the host attributes of the supplier accounts
+type hostAttributeCache struct {
+	lock  sync.RWMutex
+	items map[string]hostAttributeCacheItem
+}
+
+type hostAttributeCacheItem struct {
+	attributes []metadata.Attribute
+	expireTime time.Time
+}
+
+func (c *hostAttributeCache) get(supplierAccount string) ([]metadata.Attribute, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	item, exists := c.items[supplierAccount]
+	if !exists || time.Now().After(item.expireTime) {
+		return nil, false
+	}
+	return item.attributes, true
+}
+
+func (c *hostAttributeCache) set(supplierAccount string, attributes []metadata.Attribute) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.items[supplierAccount] = hostAttributeCacheItem{
+		attributes: attributes,
+		expireTime: time.Now().Add(hostAttributeCacheTTL),
 	}
 }
 
@@ -86,7 +125,13 @@ func (s *Searcher) ListHosts(ctx context.Context, option metadata.ListHosts) (se
 		filters = append(filters, hostIDFilter)
 	}
 
-	propertyFilter, err := option.GetHostPropertyFilter(ctx)
+	attributes, err := s.getHostAttributes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fieldTypes := metadata.GetQueryFieldTypes(attributes)
+
+	propertyFilter, err := option.GetHostPropertyFilter(ctx, fieldTypes)
 	if err != nil {
 		blog.Errorf("ListHosts failed, db select failed, filter: %+v, err: %+v, rid: %s", hostIDFilter, err, rid)
 		return nil, err
@@ -122,9 +167,41 @@ func (s *Searcher) ListHosts(ctx context.Context, option metadata.ListHosts) (se
 		blog.Errorf("ListHosts failed, db select hosts failed, filter: %+v, err: %+v, rid: %s", finalFilter, err, rid)
 		return nil, err
 	}
+	secretFields := metadata.GetSecretPropertyIDs(attributes)
 	searchResult.Info = make([]map[string]interface{}, len(hosts))
 	for index, host := range hosts {
+		secret.MaskMapStr(host, secretFields...)
+		metadata.DenormalizeNetworkValues(attributes, host)
 		searchResult.Info[index] = host
 	}
 	return searchResult, nil
 }
+
+// getHostAttributes get the host attributes, which are used to convert the values of datetime and range
+// operators in the host property filter, and the network and secret values of the searched hosts. the attributes
+// are cached for a while, the cached attributes are shared, so they must not be changed.
+func (s *Searcher) getHostAttributes(ctx context.Context) ([]metadata.Attribute, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	supplierAccount := util.ExtractOwnerFromContext(ctx)
+	if s.attributes != nil {
+		if attributes, ok := s.attributes.get(supplierAccount); ok {
+			return attributes, nil
+		}
+	}
+
+	cond := map[string]interface{}{
+		common.BKObjIDField: common.BKInnerObjIDHost,
+	}
+	cond = util.SetQueryOwner(cond, supplierAccount)
+	attributes := make([]metadata.Attribute, 0)
+	err := s.DbProxy.Table(common.BKTableNameObjAttDes).Find(cond).
+		Fields(common.BKPropertyIDField, common.BKPropertyTypeField, common.BKOptionField).All(ctx, &attributes)
+	if err != nil {
+		blog.Errorf("ListHosts failed, get host attributes failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+	if s.attributes != nil {
+		s.attributes.set(supplierAccount, attributes)
+	}
+	return attributes, nil
+}
@@ -158,6 +158,15 @@ func (t *genericTransfer) deleteHost(kit *rest.Kit, hostID int64) (mapstr.MapStr
 		return nil, kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
 	}
 
+	err = t.dependent.RecycleInstances(kit, common.BKInnerObjIDHost, []mapstr.MapStr{mapstr.MapStr(hostInfoArr[0])})
+	if err != nil {
+		blog.ErrorJSON("deleteHost recycle host error. err:%s, host:%s, rid:%s", err.Error(), hostInfoArr[0], kit.Rid)
+		if ccErr, ok := err.(errors.CCErrorCoder); ok {
+			return nil, ccErr
+		}
+		return nil, kit.CCError.CCErrorf(common.CCErrCommDBInsertFailed)
+	}
+
 	err = t.dbProxy.Table(common.BKTableNameBaseHost).Delete(kit.Ctx, hostCondMap)
 	if err != nil {
 		blog.ErrorJSON("deleteHost delete host error. err:%s, cond:%s, rid:%s", err.Error(), hostCondMap, kit.Rid)
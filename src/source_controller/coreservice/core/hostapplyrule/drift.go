@@ -0,0 +1,192 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostapplyrule
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// GetHostApplyDriftConfig returns the drift config of the business, the default config is returned if it's not set
+func (p *hostApplyRule) GetHostApplyDriftConfig(kit *rest.Kit, bizID int64) (metadata.HostApplyDriftConfig, errors.CCErrorCoder) {
+	configs, err := p.ListHostApplyDriftConfig(kit, metadata.ListHostApplyDriftConfigOption{BizIDs: []int64{bizID}})
+	if err != nil {
+		return metadata.HostApplyDriftConfig{}, err
+	}
+	return configs[0], nil
+}
+
+func (p *hostApplyRule) UpdateHostApplyDriftConfig(kit *rest.Kit, bizID int64, option metadata.UpdateHostApplyDriftConfigOption) (metadata.HostApplyDriftConfig, errors.CCErrorCoder) {
+	config := metadata.HostApplyDriftConfig{
+		BizID:           bizID,
+		Mode:            option.Mode,
+		Modifier:        kit.User,
+		LastTime:        time.Now(),
+		SupplierAccount: kit.SupplierAccount,
+	}
+	if err := option.Mode.Validate(); err != nil {
+		blog.Errorf("UpdateHostApplyDriftConfig failed, validate mode failed, bizID: %d, option: %+v, err: %+v, rid: %s", bizID, option, err, kit.Rid)
+		return config, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "mode")
+	}
+	if err := p.validateBizID(kit, bizID); err != nil {
+		return config, err
+	}
+
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: kit.SupplierAccount,
+		common.BKAppIDField:      bizID,
+	}
+	if err := p.dbProxy.Table(common.BKTableNameHostApplyDriftConfig).Upsert(kit.Ctx, filter, config); err != nil {
+		blog.Errorf("UpdateHostApplyDriftConfig failed, db upsert failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return config, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return config, nil
+}
+
+// ListHostApplyDriftConfig returns the drift configs of the businesses in the same order as the option's business ids
+func (p *hostApplyRule) ListHostApplyDriftConfig(kit *rest.Kit, option metadata.ListHostApplyDriftConfigOption) ([]metadata.HostApplyDriftConfig, errors.CCErrorCoder) {
+	if len(option.BizIDs) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_biz_ids")
+	}
+
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: kit.SupplierAccount,
+		common.BKAppIDField: map[string]interface{}{
+			common.BKDBIN: option.BizIDs,
+		},
+	}
+	configs := make([]metadata.HostApplyDriftConfig, 0)
+	if err := p.dbProxy.Table(common.BKTableNameHostApplyDriftConfig).Find(filter).All(kit.Ctx, &configs); err != nil {
+		blog.Errorf("ListHostApplyDriftConfig failed, db select failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	configMap := make(map[int64]metadata.HostApplyDriftConfig)
+	for _, config := range configs {
+		configMap[config.BizID] = config
+	}
+
+	result := make([]metadata.HostApplyDriftConfig, 0)
+	for _, bizID := range option.BizIDs {
+		config, exist := configMap[bizID]
+		if !exist {
+			config = metadata.HostApplyDriftConfig{
+				BizID:           bizID,
+				Mode:            metadata.DefaultHostApplyDriftMode,
+				SupplierAccount: kit.SupplierAccount,
+			}
+		}
+		result = append(result, config)
+	}
+	return result, nil
+}
+
+// SaveHostApplyDrift records the drifts of the non-compliant hosts, a host has only one drift record which is
+// replaced by its latest drift, and the records of the compliant hosts are removed.
+func (p *hostApplyRule) SaveHostApplyDrift(kit *rest.Kit, option metadata.SaveHostApplyDriftOption) errors.CCErrorCoder {
+	for _, drift := range option.Drifts {
+		if drift.HostID == 0 || drift.BizID == 0 {
+			blog.ErrorJSON("SaveHostApplyDrift failed, host id or biz id not set, drift: %s, rid: %s", drift, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "drifts")
+		}
+		drift.SupplierAccount = kit.SupplierAccount
+		filter := map[string]interface{}{
+			common.BkSupplierAccount: kit.SupplierAccount,
+			common.BKHostIDField:     drift.HostID,
+		}
+		if err := p.dbProxy.Table(common.BKTableNameHostApplyDrift).Upsert(kit.Ctx, filter, drift); err != nil {
+			blog.Errorf("SaveHostApplyDrift failed, db upsert failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+		}
+	}
+
+	if len(option.CompliantHostIDs) == 0 {
+		return nil
+	}
+	filter := map[string]interface{}{
+		common.BKHostIDField: map[string]interface{}{
+			common.BKDBIN: option.CompliantHostIDs,
+		},
+	}
+	filter = util.SetQueryOwner(filter, kit.SupplierAccount)
+	if err := p.dbProxy.Table(common.BKTableNameHostApplyDrift).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("SaveHostApplyDrift failed, db remove failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// ListHostApplyDrift returns the non-compliant hosts of the business
+func (p *hostApplyRule) ListHostApplyDrift(kit *rest.Kit, bizID int64, option metadata.ListHostApplyDriftOption) (metadata.MultipleHostApplyDrift, errors.CCErrorCoder) {
+	result := metadata.MultipleHostApplyDrift{}
+	if option.Page.Limit > common.BKMaxPageSize && option.Page.Limit != common.BKNoLimit {
+		return result, kit.CCError.CCError(common.CCErrCommPageLimitIsExceeded)
+	}
+
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: kit.SupplierAccount,
+		common.BKAppIDField:      bizID,
+	}
+	if option.HostIDs != nil {
+		filter[common.BKHostIDField] = map[string]interface{}{
+			common.BKDBIN: option.HostIDs,
+		}
+	}
+	query := p.dbProxy.Table(common.BKTableNameHostApplyDrift).Find(filter)
+	total, err := query.Count(kit.Ctx)
+	if err != nil {
+		blog.ErrorJSON("ListHostApplyDrift failed, db count failed, filter: %s, err: %s, rid: %s", filter, err.Error(), kit.Rid)
+		return result, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	result.Count = int64(total)
+
+	if len(option.Page.Sort) > 0 {
+		query = query.Sort(option.Page.Sort)
+	} else {
+		query = query.Sort(common.BKHostIDField)
+	}
+	if option.Page.Limit > 0 {
+		query = query.Limit(uint64(option.Page.Limit))
+	}
+	if option.Page.Start > 0 {
+		query = query.Start(uint64(option.Page.Start))
+	}
+
+	drifts := make([]metadata.HostApplyDrift, 0)
+	if err := query.All(kit.Ctx, &drifts); err != nil {
+		blog.ErrorJSON("ListHostApplyDrift failed, db select failed, filter: %s, err: %s, rid: %s", filter, err.Error(), kit.Rid)
+		return result, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	result.Info = drifts
+	return result, nil
+}
+
+func (p *hostApplyRule) validateBizID(kit *rest.Kit, bizID int64) errors.CCErrorCoder {
+	filter := map[string]interface{}{
+		common.BKAppIDField: bizID,
+	}
+	count, err := p.dbProxy.Table(common.BKTableNameBaseApp).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("validateBizID failed, db select failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstruct"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/util"
 	"github.com/google/go-cmp/cmp"
 )
@@ -99,6 +100,11 @@ func (p *hostApplyRule) GenerateApplyPlan(kit *rest.Kit, bizID int64, option met
 			attributeIDs, err.Error(), rid)
 		return result, err
 	}
+	planCtx, err := p.newApplyPlanContext(kit, bizID, option.Rules, attributes)
+	if err != nil {
+		blog.Errorf("GenerateApplyPlan failed, newApplyPlanContext failed, bizID: %d, err: %s, rid: %s", bizID, err.Error(), rid)
+		return result, err
+	}
 
 	// compute apply plan one by one
 	hostApplyPlans := make([]metadata.OneHostApplyPlan, 0)
@@ -117,7 +123,7 @@ func (p *hostApplyRule) GenerateApplyPlan(kit *rest.Kit, bizID int64, option met
 			hostApplyPlans = append(hostApplyPlans, hostApplyPlan)
 			continue
 		}
-		hostApplyPlan, err = p.generateOneHostApplyPlan(kit, hostModule.HostID, host, hostModule.ModuleIDs, option.Rules, attributes, option.ConflictResolvers)
+		hostApplyPlan, err = p.generateOneHostApplyPlan(kit, hostModule.HostID, host, hostModule.ModuleIDs, option.Rules, planCtx, option.ConflictResolvers)
 		if err != nil {
 			blog.ErrorJSON("generateOneHostApplyPlan failed, host: %s, moduleIDs: %s, rules: %s, err: %s, rid: %s", host, hostModule.ModuleIDs, option.Rules, err.Error(), rid)
 			return result, err
@@ -160,7 +166,7 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 	host map[string]interface{},
 	moduleIDs []int64,
 	rules []metadata.HostApplyRule,
-	attributes []metadata.Attribute,
+	planCtx *applyPlanContext,
 	resolvers []metadata.HostApplyConflictResolver,
 ) (metadata.OneHostApplyPlan, errors.CCErrorCoder) {
 	rid := util.ExtractRequestUserFromContext(kit.Ctx)
@@ -173,10 +179,16 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 		resolverMap[item.AttributeID] = item.PropertyValue
 	}
 
+	// the values are computed with the original host, so the expect host is a copy of it
+	expectHost := make(map[string]interface{}, len(host))
+	for key, value := range host {
+		expectHost[key] = value
+	}
+
 	plan := metadata.OneHostApplyPlan{
 		HostID:                  hostID,
 		ModuleIDs:               moduleIDs,
-		ExpectHost:              host,
+		ExpectHost:              expectHost,
 		ConflictFields:          make([]metadata.HostApplyConflictField, 0),
 		UpdateFields:            make([]metadata.HostApplyUpdateField, 0),
 		UnresolvedConflictCount: 0,
@@ -191,28 +203,26 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 		if _, exist := moduleIDSet[rule.ModuleID]; !exist {
 			continue
 		}
+		if rule.Condition != nil && !querybuilder.MatchDocument(rule.Condition.Rule, host, planCtx.fieldTypes) {
+			continue
+		}
 		if _, exist := attributeRules[rule.AttributeID]; !exist {
 			attributeRules[rule.AttributeID] = make([]metadata.HostApplyRule, 0)
 		}
 		attributeRules[rule.AttributeID] = append(attributeRules[rule.AttributeID], rule)
 	}
 
-	attributeMap := make(map[int64]metadata.Attribute)
-	for _, attribute := range attributes {
-		attributeMap[attribute.ID] = attribute
-	}
-
 	// update host if conflicts not exist
 	for attributeID, targetRules := range attributeRules {
 		if len(targetRules) == 0 {
 			continue
 		}
-		attribute, exist := attributeMap[attributeID]
+		attribute, exist := planCtx.attributes[attributeID]
 		if !exist {
 			blog.Infof("generateOneHostApplyPlan attribute id filed not exist, attributeID: %s, rid: %s", attributeID, rid)
 			continue
 		}
-		if metadata.CheckAllowHostApplyOnField(attribute.PropertyID) == false {
+		if metadata.CheckAllowHostApplyOnAttribute(attribute) == false {
 			continue
 		}
 		propertyIDField := attribute.PropertyID
@@ -221,10 +231,18 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 			originalValue = nil
 		}
 
-		// check conflicts
-		firstValue := targetRules[0].PropertyValue
+		// compute the values of the rules, so that the values of the template rules can be previewed
+		for index := range targetRules {
+			targetRules[index].PropertyValue = planCtx.ruleValue(targetRules[index], host)
+		}
+
+		// check conflicts, only the rules with the highest priority take effect
+		candidates := highestPriorityRules(targetRules)
+		firstValue := candidates[0].PropertyValue
+		// the rule that the value comes from, it's empty if the value comes from resolver
+		sourceRule := candidates[0]
 		conflictedStillExist := false
-		for _, rule := range targetRules {
+		for _, rule := range candidates {
 			if cmp.Equal(firstValue, rule.PropertyValue) {
 				continue
 			}
@@ -233,6 +251,7 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 			if propertyValue, exist := resolverMap[attribute.ID]; exist {
 				conflictedStillExist = false
 				firstValue = propertyValue
+				sourceRule = metadata.HostApplyRule{}
 			}
 
 			plan.ConflictFields = append(plan.ConflictFields, metadata.HostApplyConflictField{
@@ -253,7 +272,6 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 		// validate property value before update to host
 		if value, ok := firstValue.(string); ok {
 			firstValue = strings.TrimSpace(value)
-			targetRules[0].PropertyValue = firstValue
 		}
 		rawErr := attribute.Validate(kit.Ctx, firstValue, propertyIDField)
 		if rawErr.ErrCode != 0 {
@@ -271,6 +289,10 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 			AttributeID:   attributeID,
 			PropertyID:    propertyIDField,
 			PropertyValue: firstValue,
+			RuleID:        sourceRule.ID,
+			Level:         sourceRule.Level,
+			OriginalValue: originalValue,
+			Drifted:       !isSameHostValue(originalValue, firstValue),
 		})
 	}
 
@@ -285,6 +307,152 @@ func (p *hostApplyRule) generateOneHostApplyPlan(
 	return plan, nil
 }
 
+// applyPlanContext is the data that is shared by the apply plans of the hosts.
+type applyPlanContext struct {
+	attributes map[int64]metadata.Attribute
+	// fieldTypes is the property types of the host fields, which is used to match the rules' conditions
+	fieldTypes querybuilder.FieldTypes
+	templates  map[string]*valueTemplate
+	// moduleData is the module, set and business of the template rules' modules
+	moduleData map[int64]templateData
+}
+
+func (p *hostApplyRule) newApplyPlanContext(kit *rest.Kit, bizID int64, rules []metadata.HostApplyRule,
+	attributes []metadata.Attribute) (*applyPlanContext, errors.CCErrorCoder) {
+
+	planCtx := &applyPlanContext{
+		attributes: make(map[int64]metadata.Attribute),
+		templates:  make(map[string]*valueTemplate),
+		moduleData: make(map[int64]templateData),
+	}
+	for _, attribute := range attributes {
+		planCtx.attributes[attribute.ID] = attribute
+	}
+
+	hasCondition := false
+	templateModuleIDs := make([]int64, 0)
+	for _, rule := range rules {
+		if rule.Condition != nil && rule.Condition.Rule != nil {
+			hasCondition = true
+		}
+		if !rule.IsTemplate() {
+			continue
+		}
+		templateModuleIDs = append(templateModuleIDs, rule.ModuleID)
+		if _, exist := planCtx.templates[rule.ValueTemplate]; exist {
+			continue
+		}
+		tpl, err := parseValueTemplate(rule.ValueTemplate)
+		if err != nil {
+			blog.Errorf("parse value template %s failed, err: %v, rid: %s", rule.ValueTemplate, err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_value_template")
+		}
+		planCtx.templates[rule.ValueTemplate] = tpl
+	}
+
+	if hasCondition {
+		fieldTypes, err := p.getHostFieldTypes(kit, bizID)
+		if err != nil {
+			return nil, err
+		}
+		planCtx.fieldTypes = fieldTypes
+	}
+
+	if len(templateModuleIDs) == 0 {
+		return planCtx, nil
+	}
+
+	modules, err := p.listTopoInstances(kit, common.BKTableNameBaseModule, common.BKModuleIDField, templateModuleIDs)
+	if err != nil {
+		return nil, err
+	}
+	setIDs := make([]int64, 0)
+	bizIDs := make([]int64, 0)
+	for _, module := range modules {
+		setID, _ := util.GetInt64ByInterface(module[common.BKSetIDField])
+		appID, _ := util.GetInt64ByInterface(module[common.BKAppIDField])
+		setIDs = append(setIDs, setID)
+		bizIDs = append(bizIDs, appID)
+	}
+	sets, err := p.listTopoInstances(kit, common.BKTableNameBaseSet, common.BKSetIDField, setIDs)
+	if err != nil {
+		return nil, err
+	}
+	bizs, err := p.listTopoInstances(kit, common.BKTableNameBaseApp, common.BKAppIDField, bizIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for moduleID, module := range modules {
+		setID, _ := util.GetInt64ByInterface(module[common.BKSetIDField])
+		appID, _ := util.GetInt64ByInterface(module[common.BKAppIDField])
+		planCtx.moduleData[moduleID] = templateData{
+			module: module,
+			set:    sets[setID],
+			biz:    bizs[appID],
+		}
+	}
+	return planCtx, nil
+}
+
+// listTopoInstances list the instances of the topology table by ids, the result is a map of id to instance.
+func (p *hostApplyRule) listTopoInstances(kit *rest.Kit, table, idField string, ids []int64) (
+	map[int64]map[string]interface{}, errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{
+		idField: map[string]interface{}{
+			common.BKDBIN: util.IntArrayUnique(ids),
+		},
+	}
+	instances := make([]map[string]interface{}, 0)
+	if err := p.dbProxy.Table(table).Find(filter).All(kit.Ctx, &instances); err != nil {
+		blog.ErrorJSON("list topo instances failed, table: %s, filter: %s, err: %s, rid: %s", table, filter, err.Error(), kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	result := make(map[int64]map[string]interface{})
+	for _, instance := range instances {
+		id, err := util.GetInt64ByInterface(instance[idField])
+		if err != nil {
+			blog.ErrorJSON("list topo instances failed, parse %s failed, instance: %s, err: %s, rid: %s", idField, instance, err.Error(), kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommParseDBFailed)
+		}
+		result[id] = instance
+	}
+	return result, nil
+}
+
+// ruleValue returns the property value of the rule applied to the host, the value of template rule is computed
+// with the host and the topology of the rule's module.
+func (planCtx *applyPlanContext) ruleValue(rule metadata.HostApplyRule, host map[string]interface{}) interface{} {
+	tpl, exist := planCtx.templates[rule.ValueTemplate]
+	if !rule.IsTemplate() || !exist {
+		if value, ok := rule.PropertyValue.(string); ok {
+			return strings.TrimSpace(value)
+		}
+		return rule.PropertyValue
+	}
+
+	data := planCtx.moduleData[rule.ModuleID]
+	data.host = host
+	return strings.TrimSpace(tpl.render(data))
+}
+
+// highestPriorityRules returns the rules with the highest priority.
+func highestPriorityRules(rules []metadata.HostApplyRule) []metadata.HostApplyRule {
+	result := make([]metadata.HostApplyRule, 0)
+	for _, rule := range rules {
+		if len(result) > 0 && rule.Priority < result[0].Priority {
+			continue
+		}
+		if len(result) > 0 && rule.Priority > result[0].Priority {
+			result = result[:0]
+		}
+		result = append(result, rule)
+	}
+	return result
+}
+
 func (p *hostApplyRule) RunHostApplyOnHosts(kit *rest.Kit, bizID int64, option metadata.UpdateHostByHostApplyRuleOption) (metadata.MultipleHostApplyResult, errors.CCErrorCoder) {
 	rid := kit.Rid
 	result := metadata.MultipleHostApplyResult{
@@ -342,6 +510,7 @@ func (p *hostApplyRule) RunHostApplyOnHosts(kit *rest.Kit, bizID int64, option m
 		Page: metadata.BasePage{
 			Limit: common.BKNoLimit,
 		},
+		WithInherited: true,
 	}
 	rules, ccErr := p.ListHostApplyRule(kit, bizID, listHostApplyRuleOption)
 	if ccErr != nil {
@@ -395,3 +564,30 @@ func (p *hostApplyRule) RunHostApplyOnHosts(kit *rest.Kit, bizID int64, option m
 	}
 	return result, result.GetError()
 }
+
+// isSameHostValue checks whether the host's value is the same as the value of the rule, the numbers are
+// compared by their values as the host and the rule may store them with different types.
+func isSameHostValue(hostValue, ruleValue interface{}) bool {
+	if cmp.Equal(hostValue, ruleValue) {
+		return true
+	}
+	if hostValue == nil || ruleValue == nil {
+		return false
+	}
+	// the strings are parsed as numbers by GetFloat64ByInterface, they must be equal exactly
+	if _, ok := hostValue.(string); ok {
+		return false
+	}
+	if _, ok := ruleValue.(string); ok {
+		return false
+	}
+	hostNumber, err := util.GetFloat64ByInterface(hostValue)
+	if err != nil {
+		return false
+	}
+	ruleNumber, err := util.GetFloat64ByInterface(ruleValue)
+	if err != nil {
+		return false
+	}
+	return hostNumber == ruleNumber
+}
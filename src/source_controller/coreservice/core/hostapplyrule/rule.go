@@ -14,6 +14,7 @@ package hostapplyrule
 
 import (
 	"context"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -62,26 +63,74 @@ func (p *hostApplyRule) validateModuleID(kit *rest.Kit, bizID int64, moduleID in
 	return nil
 }
 
-func (p *hostApplyRule) listHostAttributes(kit *rest.Kit, bizID int64, hostAttributeIDs ...int64) ([]metadata.Attribute, errors.CCErrorCoder) {
-	filter := map[string]interface{}{
-		common.BKDBOR: []map[string]interface{}{
-			{
-				// business private attribute
-				metadata.MetadataBizField: map[string]interface{}{
-					common.BKDBEQ: strconv.FormatInt(bizID, 10),
-				},
-			}, {
-				// global attribute
-				metadata.MetadataBizField: map[string]interface{}{
-					common.BKDBExists: false,
-				},
-			}, {
-				// global attribute
-				metadata.BKMetadata: map[string]interface{}{
-					common.BKDBExists: false,
-				},
+// hostAttributeBizFilter returns the filter of the global attributes and the business private attributes.
+func hostAttributeBizFilter(bizID int64) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			// business private attribute
+			metadata.MetadataBizField: map[string]interface{}{
+				common.BKDBEQ: strconv.FormatInt(bizID, 10),
+			},
+		}, {
+			// global attribute
+			metadata.MetadataBizField: map[string]interface{}{
+				common.BKDBExists: false,
+			},
+		}, {
+			// global attribute
+			metadata.BKMetadata: map[string]interface{}{
+				common.BKDBExists: false,
 			},
 		},
+	}
+}
+
+// validateRuleTarget validate that the module, service template or set template that the rule is attached to
+// belongs to the business.
+func (p *hostApplyRule) validateRuleTarget(kit *rest.Kit, bizID int64, rule metadata.HostApplyRule) errors.CCErrorCoder {
+	var table, idField string
+	var id int64
+	switch rule.Level {
+	case metadata.HostApplyRuleLevelModule:
+		return p.validateModuleID(kit, bizID, rule.ModuleID)
+	case metadata.HostApplyRuleLevelServiceTemplate:
+		table, idField, id = common.BKTableNameServiceTemplate, common.BKServiceTemplateIDField, rule.ServiceTemplateID
+	case metadata.HostApplyRuleLevelSetTemplate:
+		table, idField, id = common.BKTableNameSetTemplate, common.BKSetTemplateIDField, rule.SetTemplateID
+	default:
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "level")
+	}
+
+	filter := map[string]interface{}{
+		common.BKAppIDField: bizID,
+		common.BKFieldID:    id,
+	}
+	count, err := p.dbProxy.Table(table).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("validate rule target failed, db select failed, table: %s, filter: %+v, err: %+v, rid: %s", table, filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, idField)
+	}
+	return nil
+}
+
+// ruleTargetFilter returns the filter of the rule with the same target and attribute.
+func ruleTargetFilter(kit *rest.Kit, bizID int64, rule metadata.HostApplyRule) map[string]interface{} {
+	return map[string]interface{}{
+		common.BKAppIDField:             bizID,
+		common.BkSupplierAccount:        kit.SupplierAccount,
+		common.BKAttributeIDField:       rule.AttributeID,
+		common.BKModuleIDField:          rule.ModuleID,
+		common.BKServiceTemplateIDField: rule.ServiceTemplateID,
+		common.BKSetTemplateIDField:     rule.SetTemplateID,
+	}
+}
+
+func (p *hostApplyRule) listHostAttributes(kit *rest.Kit, bizID int64, hostAttributeIDs ...int64) ([]metadata.Attribute, errors.CCErrorCoder) {
+	filter := map[string]interface{}{
+		common.BKDBOR: hostAttributeBizFilter(bizID),
 		common.BKFieldID: map[string]interface{}{
 			common.BKDBIN: hostAttributeIDs,
 		},
@@ -99,6 +148,86 @@ func (p *hostApplyRule) listHostAttributes(kit *rest.Kit, bizID int64, hostAttri
 	return attributes, nil
 }
 
+// getHostFieldTypes returns the property types of all the host attributes that can be used in the business.
+func (p *hostApplyRule) getHostFieldTypes(kit *rest.Kit, bizID int64) (querybuilder.FieldTypes, errors.CCErrorCoder) {
+	filter := map[string]interface{}{
+		common.BKDBOR:       hostAttributeBizFilter(bizID),
+		common.BKObjIDField: common.BKInnerObjIDHost,
+	}
+	attributes := make([]metadata.Attribute, 0)
+	if err := p.dbProxy.Table(common.BKTableNameObjAttDes).Find(filter).All(kit.Ctx, &attributes); err != nil {
+		blog.Errorf("get host field types failed, db select failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return metadata.GetQueryFieldTypes(attributes), nil
+}
+
+// validateRuleValue validate the property value, value template and condition of the rule, the string value
+// is trimmed, and the property value of the template rule is cleared as it is computed when the rule is applied.
+func (p *hostApplyRule) validateRuleValue(kit *rest.Kit, bizID int64, attribute metadata.Attribute, rule *metadata.HostApplyRule) errors.CCErrorCoder {
+	if !metadata.CheckAllowHostApplyOnAttribute(attribute) {
+		blog.Errorf("host apply is not allowed on %s attribute %s, rid: %s", attribute.PropertyType, attribute.PropertyID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAttributeIDField)
+	}
+	if key, err := rule.Validate(); err != nil {
+		blog.Errorf("validate host apply rule failed, key: %s, err: %+v, rid: %s", key, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+
+	hasCondition := rule.Condition != nil && rule.Condition.Rule != nil
+	var fieldTypes querybuilder.FieldTypes
+	if hasCondition || rule.IsTemplate() {
+		var ccErr errors.CCErrorCoder
+		if fieldTypes, ccErr = p.getHostFieldTypes(kit, bizID); ccErr != nil {
+			return ccErr
+		}
+	}
+
+	if hasCondition {
+		if key, err := querybuilder.ValidateFieldTypes(rule.Condition.Rule, fieldTypes); err != nil {
+			blog.Errorf("validate host apply rule condition failed, key: %s, err: %+v, rid: %s", key, err, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_condition."+key)
+		}
+	}
+
+	if !rule.IsTemplate() {
+		if value, ok := rule.PropertyValue.(string); ok {
+			rule.PropertyValue = strings.TrimSpace(value)
+		}
+		rawError := attribute.Validate(kit.Ctx, rule.PropertyValue, common.BKPropertyValueField)
+		if rawError.ErrCode != 0 {
+			ccErr := rawError.ToCCError(kit.CCError)
+			blog.Errorf("validate host attribute value failed, attribute: %+v, value: %+v, err: %+v, rid: %s", attribute, rule.PropertyValue, ccErr, kit.Rid)
+			return ccErr
+		}
+		return nil
+	}
+
+	// only the string attributes' value can be computed from template
+	if attribute.PropertyType != common.FieldTypeSingleChar && attribute.PropertyType != common.FieldTypeLongChar {
+		blog.Errorf("value template can not be used on %s attribute %s, rid: %s", attribute.PropertyType, attribute.PropertyID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_value_template")
+	}
+	tpl, err := parseValueTemplate(rule.ValueTemplate)
+	if err != nil {
+		blog.Errorf("parse value template %s failed, err: %v, rid: %s", rule.ValueTemplate, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_value_template")
+	}
+	for _, field := range tpl.hostFields() {
+		// the value computed from itself changes every time the rule is applied
+		if field == attribute.PropertyID {
+			blog.Errorf("value template %s references the rule's own field %s, rid: %s", rule.ValueTemplate, field, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_value_template")
+		}
+		if _, exist := fieldTypes[field]; !exist {
+			blog.Errorf("value template %s uses unknown host field %s, rid: %s", rule.ValueTemplate, field, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_value_template")
+		}
+	}
+	rule.PropertyValue = nil
+	return nil
+}
+
 func (p *hostApplyRule) getHostAttribute(kit *rest.Kit, bizID int64, hostAttributeID int64) (metadata.Attribute, errors.CCErrorCoder) {
 	attribute := metadata.Attribute{}
 	attributes, err := p.listHostAttributes(kit, bizID, hostAttributeID)
@@ -118,25 +247,36 @@ func (p *hostApplyRule) getHostAttribute(kit *rest.Kit, bizID int64, hostAttribu
 func (p *hostApplyRule) CreateHostApplyRule(kit *rest.Kit, bizID int64, option metadata.CreateHostApplyRuleOption) (metadata.HostApplyRule, errors.CCErrorCoder) {
 	now := time.Now()
 	rule := metadata.HostApplyRule{
-		ID:              0,
-		BizID:           bizID,
-		AttributeID:     option.AttributeID,
-		ModuleID:        option.ModuleID,
-		PropertyValue:   option.PropertyValue,
-		Creator:         kit.User,
-		Modifier:        kit.User,
-		CreateTime:      now,
-		LastTime:        now,
-		SupplierAccount: kit.SupplierAccount,
+		ID:                0,
+		BizID:             bizID,
+		AttributeID:       option.AttributeID,
+		ModuleID:          option.ModuleID,
+		ServiceTemplateID: option.ServiceTemplateID,
+		SetTemplateID:     option.SetTemplateID,
+		PropertyValue:     option.PropertyValue,
+		ValueTemplate:     option.ValueTemplate,
+		Condition:         option.Condition,
+		Priority:          option.Priority,
+		Creator:           kit.User,
+		Modifier:          kit.User,
+		CreateTime:        now,
+		LastTime:          now,
+		SupplierAccount:   kit.SupplierAccount,
+	}
+	level, key, err := metadata.GetHostApplyRuleLevel(rule.ModuleID, rule.ServiceTemplateID, rule.SetTemplateID)
+	if err != nil {
+		blog.Errorf("CreateHostApplyRule failed, parameter invalid, key: %s, err: %+v, rid: %s", key, err, kit.Rid)
+		return rule, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, key)
 	}
+	rule.Level = level
 	if key, err := rule.Validate(); err != nil {
 		blog.Errorf("CreateHostApplyRule failed, parameter invalid, key: %s, err: %+v, rid: %s", key, err, kit.Rid)
 		return rule, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, key)
 	}
 
-	// validate bk_module_id
-	if err := p.validateModuleID(kit, bizID, rule.ModuleID); err != nil {
-		blog.Errorf("CreateHostApplyRule failed, validate bk_module_id failed, bizID: %d, moduleID: %d, err: %s, rid: %s", bizID, err.Error(), kit.Rid)
+	// validate the module, service template or set template
+	if err := p.validateRuleTarget(kit, bizID, rule); err != nil {
+		blog.Errorf("CreateHostApplyRule failed, validate rule target failed, bizID: %d, rule: %+v, err: %s, rid: %s", bizID, rule, err.Error(), kit.Rid)
 		return rule, err
 	}
 
@@ -146,13 +286,8 @@ func (p *hostApplyRule) CreateHostApplyRule(kit *rest.Kit, bizID int64, option m
 		return rule, ccErr
 	}
 
-	if value, ok := option.PropertyValue.(string); ok {
-		option.PropertyValue = strings.TrimSpace(value)
-	}
-	rawError := attribute.Validate(kit.Ctx, option.PropertyValue, common.BKPropertyValueField)
-	if rawError.ErrCode != 0 {
-		ccErr := rawError.ToCCError(kit.CCError)
-		blog.Errorf("CreateHostApplyRule failed, validate host attribute value failed,  attribute: %+v, value: %+v, err: %+v, rid: %s", attribute, option.PropertyValue, ccErr, kit.Rid)
+	if ccErr := p.validateRuleValue(kit, bizID, attribute, &rule); ccErr != nil {
+		blog.Errorf("CreateHostApplyRule failed, validate rule value failed, attribute: %+v, option: %+v, err: %+v, rid: %s", attribute, option, ccErr, kit.Rid)
 		return rule, ccErr
 	}
 
@@ -188,19 +323,36 @@ func (p *hostApplyRule) UpdateHostApplyRule(kit *rest.Kit, bizID int64, ruleID i
 		blog.Errorf("UpdateHostApplyRule failed, getHostAttribute failed, bizID: %d, attributeID: %d, err: %s, rid: %s", bizID, rule.AttributeID, ccErr.Error(), kit.Rid)
 		return rule, ccErr
 	}
-	if value, ok := option.PropertyValue.(string); ok {
-		option.PropertyValue = strings.TrimSpace(value)
+
+	if option.ClearCondition && option.Condition != nil {
+		blog.Errorf("UpdateHostApplyRule failed, condition is set while clear condition, rid: %s", kit.Rid)
+		return rule, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "clear_condition")
+	}
+
+	// a value is given without template means the rule is changed to a fixed value rule
+	if option.PropertyValue != nil {
+		rule.PropertyValue = option.PropertyValue
+		rule.ValueTemplate = ""
+	}
+	if option.ValueTemplate != nil {
+		rule.ValueTemplate = *option.ValueTemplate
+	}
+	if option.Condition != nil {
+		rule.Condition = option.Condition
+	}
+	if option.ClearCondition {
+		rule.Condition = nil
 	}
-	rawError := attribute.Validate(kit.Ctx, option.PropertyValue, common.BKPropertyValueField)
-	if rawError.ErrCode != 0 {
-		ccErr := rawError.ToCCError(kit.CCError)
-		blog.Errorf("UpdateHostApplyRule failed, validate host attribute value failed, attribute: %+v, value: %+v, err: %+v, rid: %s", attribute, option.PropertyValue, ccErr, kit.Rid)
+	if option.Priority != nil {
+		rule.Priority = *option.Priority
+	}
+	if ccErr := p.validateRuleValue(kit, bizID, attribute, &rule); ccErr != nil {
+		blog.Errorf("UpdateHostApplyRule failed, validate rule value failed, attribute: %+v, option: %+v, err: %+v, rid: %s", attribute, option, ccErr, kit.Rid)
 		return rule, ccErr
 	}
 
 	rule.LastTime = time.Now()
 	rule.Modifier = kit.User
-	rule.PropertyValue = option.PropertyValue
 
 	filter := map[string]interface{}{
 		common.BKFieldID: ruleID,
@@ -209,6 +361,13 @@ func (p *hostApplyRule) UpdateHostApplyRule(kit *rest.Kit, bizID int64, ruleID i
 		blog.ErrorJSON("UpdateHostApplyRule failed, db update failed, filter: %s, doc: %s, err: %s, rid: %s", filter, rule, err, kit.Rid)
 		return rule, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
 	}
+	// the empty condition is omitted when update, so it should be removed explicitly
+	if rule.Condition == nil {
+		if err := p.dbProxy.Table(common.BKTableNameHostApplyRule).DropDocsColumn(kit.Ctx, "bk_condition", filter); err != nil {
+			blog.ErrorJSON("UpdateHostApplyRule failed, remove condition failed, filter: %s, err: %s, rid: %s", filter, err, kit.Rid)
+			return rule, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+		}
+	}
 
 	return rule, nil
 }
@@ -254,13 +413,17 @@ func (p *hostApplyRule) GetHostApplyRule(kit *rest.Kit, bizID int64, ruleID int6
 }
 
 func (p *hostApplyRule) GetHostApplyRuleByAttributeID(kit *rest.Kit, bizID, moduleID, attributeID int64) (metadata.HostApplyRule, errors.CCErrorCoder) {
-	rule := metadata.HostApplyRule{}
 	filter := map[string]interface{}{
 		common.BkSupplierAccount:  kit.SupplierAccount,
 		common.BKAppIDField:       bizID,
 		common.BKModuleIDField:    moduleID,
 		common.BKAttributeIDField: attributeID,
 	}
+	return p.getHostApplyRuleByFilter(kit, filter)
+}
+
+func (p *hostApplyRule) getHostApplyRuleByFilter(kit *rest.Kit, filter map[string]interface{}) (metadata.HostApplyRule, errors.CCErrorCoder) {
+	rule := metadata.HostApplyRule{}
 	if err := p.dbProxy.Table(common.BKTableNameHostApplyRule).Find(filter).One(kit.Ctx, &rule); err != nil {
 		if p.dbProxy.IsNotFoundError(err) {
 			blog.Errorf("GetHostApplyRuleByAttributeID failed, db select failed, not found, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
@@ -285,10 +448,40 @@ func (p *hostApplyRule) ListHostApplyRule(kit *rest.Kit, bizID int64, option met
 	if bizID != 0 {
 		filter[common.BKAppIDField] = bizID
 	}
+	if option.WithInherited {
+		return p.listInheritedHostApplyRule(kit, filter, option)
+	}
+
+	targetFilters := make([]map[string]interface{}, 0)
 	if option.ModuleIDs != nil {
-		filter[common.BKModuleIDField] = map[string]interface{}{
-			common.BKDBIN: option.ModuleIDs,
+		targetFilters = append(targetFilters, map[string]interface{}{
+			common.BKModuleIDField: map[string]interface{}{
+				common.BKDBIN: option.ModuleIDs,
+			},
+		})
+	}
+	if option.ServiceTemplateIDs != nil {
+		targetFilters = append(targetFilters, map[string]interface{}{
+			common.BKServiceTemplateIDField: map[string]interface{}{
+				common.BKDBIN: option.ServiceTemplateIDs,
+			},
+		})
+	}
+	if option.SetTemplateIDs != nil {
+		targetFilters = append(targetFilters, map[string]interface{}{
+			common.BKSetTemplateIDField: map[string]interface{}{
+				common.BKDBIN: option.SetTemplateIDs,
+			},
+		})
+	}
+	switch len(targetFilters) {
+	case 0:
+	case 1:
+		for key, value := range targetFilters[0] {
+			filter[key] = value
 		}
+	default:
+		filter[common.BKDBOR] = targetFilters
 	}
 	if len(option.AttributeIDs) != 0 {
 		filter[common.BKAttributeIDField] = map[string]interface{}{
@@ -323,6 +516,120 @@ func (p *hostApplyRule) ListHostApplyRule(kit *rest.Kit, bizID int64, option met
 	return result, nil
 }
 
+// listInheritedHostApplyRule list the effective rules of the modules, for each attribute of a module, the module's
+// rule overrides the rule of its service template, which overrides the rule of its set template.
+func (p *hostApplyRule) listInheritedHostApplyRule(kit *rest.Kit, filter map[string]interface{},
+	option metadata.ListHostApplyRuleOption) (metadata.MultipleHostApplyRuleResult, errors.CCErrorCoder) {
+
+	result := metadata.MultipleHostApplyRuleResult{Info: make([]metadata.HostApplyRule, 0)}
+	if len(option.ModuleIDs) == 0 {
+		return result, nil
+	}
+
+	moduleFilter := map[string]interface{}{
+		common.BKModuleIDField: map[string]interface{}{
+			common.BKDBIN: option.ModuleIDs,
+		},
+	}
+	modules := make([]metadata.ModuleInst, 0)
+	if err := p.dbProxy.Table(common.BKTableNameBaseModule).Find(moduleFilter).All(kit.Ctx, &modules); err != nil {
+		blog.ErrorJSON("list inherited host apply rule failed, find modules failed, filter: %s, err: %s, rid: %s", moduleFilter, err.Error(), kit.Rid)
+		return result, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	serviceTemplateIDs := make([]int64, 0)
+	setTemplateIDs := make([]int64, 0)
+	for _, module := range modules {
+		if module.ServiceTemplateID != 0 {
+			serviceTemplateIDs = append(serviceTemplateIDs, module.ServiceTemplateID)
+		}
+		if module.SetTemplateID != 0 {
+			setTemplateIDs = append(setTemplateIDs, module.SetTemplateID)
+		}
+	}
+
+	filter[common.BKDBOR] = []map[string]interface{}{
+		{common.BKModuleIDField: map[string]interface{}{common.BKDBIN: option.ModuleIDs}},
+		{common.BKServiceTemplateIDField: map[string]interface{}{common.BKDBIN: util.IntArrayUnique(serviceTemplateIDs)}},
+		{common.BKSetTemplateIDField: map[string]interface{}{common.BKDBIN: util.IntArrayUnique(setTemplateIDs)}},
+	}
+	if len(option.AttributeIDs) != 0 {
+		filter[common.BKAttributeIDField] = map[string]interface{}{
+			common.BKDBIN: option.AttributeIDs,
+		}
+	}
+	rules := make([]metadata.HostApplyRule, 0)
+	if err := p.dbProxy.Table(common.BKTableNameHostApplyRule).Find(filter).All(kit.Ctx, &rules); err != nil {
+		blog.ErrorJSON("list inherited host apply rule failed, db select failed, filter: %s, err: %s, rid: %s", filter, err.Error(), kit.Rid)
+		return result, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	result.Info = inheritHostApplyRules(modules, rules)
+	result.Count = int64(len(result.Info))
+	return result, nil
+}
+
+// inheritHostApplyRules computes the effective rules of the modules, the rule on a nearer level wins, and the rule
+// with the smaller id wins if there are several rules of the same attribute on the same target.
+func inheritHostApplyRules(modules []metadata.ModuleInst, rules []metadata.HostApplyRule) []metadata.HostApplyRule {
+	// level -> target id -> attribute id -> rule
+	levelRules := make(map[metadata.HostApplyRuleLevel]map[int64]map[int64]metadata.HostApplyRule)
+	for _, level := range metadata.HostApplyRuleLevels {
+		levelRules[level] = make(map[int64]map[int64]metadata.HostApplyRule)
+	}
+	for _, rule := range rules {
+		// the level is derived from the target, as the rules created before the levels are supported have no level
+		level, targetID := metadata.HostApplyRuleLevelModule, rule.ModuleID
+		switch {
+		case rule.ServiceTemplateID != 0:
+			level, targetID = metadata.HostApplyRuleLevelServiceTemplate, rule.ServiceTemplateID
+		case rule.SetTemplateID != 0:
+			level, targetID = metadata.HostApplyRuleLevelSetTemplate, rule.SetTemplateID
+		}
+		rule.Level = level
+		if _, exist := levelRules[level][targetID]; !exist {
+			levelRules[level][targetID] = make(map[int64]metadata.HostApplyRule)
+		}
+		if exist, ok := levelRules[level][targetID][rule.AttributeID]; ok && exist.ID < rule.ID {
+			continue
+		}
+		levelRules[level][targetID][rule.AttributeID] = rule
+	}
+
+	effective := make([]metadata.HostApplyRule, 0)
+	for _, module := range modules {
+		moduleRules := make(map[int64]metadata.HostApplyRule)
+		targetIDs := map[metadata.HostApplyRuleLevel]int64{
+			metadata.HostApplyRuleLevelModule:          module.ModuleID,
+			metadata.HostApplyRuleLevelServiceTemplate: module.ServiceTemplateID,
+			metadata.HostApplyRuleLevelSetTemplate:     module.SetTemplateID,
+		}
+		for _, level := range metadata.HostApplyRuleLevels {
+			if targetIDs[level] == 0 {
+				continue
+			}
+			for attributeID, rule := range levelRules[level][targetIDs[level]] {
+				if _, exist := moduleRules[attributeID]; exist {
+					continue
+				}
+				// the inherited rule is applied to the module
+				rule.ModuleID = module.ModuleID
+				moduleRules[attributeID] = rule
+			}
+		}
+		for _, rule := range moduleRules {
+			effective = append(effective, rule)
+		}
+	}
+
+	sort.SliceStable(effective, func(i, j int) bool {
+		if effective[i].ModuleID != effective[j].ModuleID {
+			return effective[i].ModuleID < effective[j].ModuleID
+		}
+		return effective[i].AttributeID < effective[j].AttributeID
+	})
+	return effective
+}
+
 // SearchRuleRelatedModules 用于过滤主机应用规则相关的模块
 /*
 支持场景：
@@ -435,10 +742,14 @@ func matchModule(ctx context.Context, module metadata.Module, option metadata.Se
 func matchRule(ctx context.Context, rule metadata.HostApplyRule, attribute metadata.Attribute, option metadata.SearchRuleRelatedModulesOption) bool {
 	rid := util.ExtractRequestIDFromContext(ctx)
 
-	prettyValue, err := attribute.PrettyValue(ctx, rule.PropertyValue)
-	if err != nil {
-		blog.Errorf("matchRule failed, PrettyValue failed, err: %s, rid: %s", err.Error(), rid)
-		return false
+	prettyValue := rule.ValueTemplate
+	if !rule.IsTemplate() {
+		var err error
+		prettyValue, err = attribute.PrettyValue(ctx, rule.PropertyValue)
+		if err != nil {
+			blog.Errorf("matchRule failed, PrettyValue failed, err: %s, rid: %s", err.Error(), rid)
+			return false
+		}
 	}
 
 	return option.QueryFilter.Match(func(r querybuilder.AtomRule) bool {
@@ -471,12 +782,38 @@ func (p *hostApplyRule) BatchUpdateHostApplyRule(kit *rest.Kit, bizID int64, opt
 		itemResult := metadata.CreateOrUpdateHostApplyRuleResult{
 			Index: index,
 		}
-		ruleFilter := map[string]interface{}{
-			common.BKAppIDField:       bizID,
-			common.BkSupplierAccount:  kit.SupplierAccount,
-			common.BKAttributeIDField: item.AttributeID,
-			common.BKModuleIDField:    item.ModuleID,
+		rule := metadata.HostApplyRule{
+			BizID:             bizID,
+			ModuleID:          item.ModuleID,
+			ServiceTemplateID: item.ServiceTemplateID,
+			SetTemplateID:     item.SetTemplateID,
+			AttributeID:       item.AttributeID,
+			PropertyValue:     item.PropertyValue,
+			ValueTemplate:     item.ValueTemplate,
+			Condition:         item.Condition,
+			Priority:          item.Priority,
+			Creator:           kit.User,
+			Modifier:          kit.User,
+			CreateTime:        now,
+			LastTime:          now,
+			SupplierAccount:   kit.SupplierAccount,
+		}
+		level, key, err := metadata.GetHostApplyRuleLevel(item.ModuleID, item.ServiceTemplateID, item.SetTemplateID)
+		if err != nil {
+			blog.Errorf("BatchUpdateHostApplyRule failed, parameter invalid, key: %s, err: %+v, rid: %s", key, err, rid)
+			itemResult.SetError(kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, key))
+			batchResult.Items = append(batchResult.Items, itemResult)
+			continue
 		}
+		rule.Level = level
+		if ccErr := p.validateRuleTarget(kit, bizID, rule); ccErr != nil {
+			blog.Errorf("BatchUpdateHostApplyRule failed, validate rule target failed, rule: %+v, err: %s, rid: %s", rule, ccErr.Error(), rid)
+			itemResult.SetError(ccErr)
+			batchResult.Items = append(batchResult.Items, itemResult)
+			continue
+		}
+
+		ruleFilter := ruleTargetFilter(kit, bizID, rule)
 		count, err := p.dbProxy.Table(common.BKTableNameHostApplyRule).Find(ruleFilter).Count(kit.Ctx)
 		if err != nil {
 			blog.ErrorJSON("BatchUpdateHostApplyRule failed, find rule failed, filter: %s, err: %s, rid: %s", ruleFilter, err.Error(), rid)
@@ -494,13 +831,8 @@ func (p *hostApplyRule) BatchUpdateHostApplyRule(kit *rest.Kit, bizID int64, opt
 			batchResult.Items = append(batchResult.Items, itemResult)
 			continue
 		}
-		if value, ok := item.PropertyValue.(string); ok {
-			item.PropertyValue = strings.TrimSpace(value)
-		}
-		rawError := attribute.Validate(kit.Ctx, item.PropertyValue, common.BKPropertyValueField)
-		if rawError.ErrCode != 0 {
-			ccErr := rawError.ToCCError(kit.CCError)
-			blog.ErrorJSON("BatchUpdateHostApplyRule failed, validate host attribute value failed, attribute: %s, value: %s, err: %s, rid: %s", attribute, item.PropertyValue, ccErr, kit.Rid)
+		if ccErr := p.validateRuleValue(kit, bizID, attribute, &rule); ccErr != nil {
+			blog.ErrorJSON("BatchUpdateHostApplyRule failed, validate rule value failed, attribute: %s, rule: %s, err: %s, rid: %s", attribute, item, ccErr, kit.Rid)
 			itemResult.SetError(ccErr)
 			batchResult.Items = append(batchResult.Items, itemResult)
 			continue
@@ -509,14 +841,25 @@ func (p *hostApplyRule) BatchUpdateHostApplyRule(kit *rest.Kit, bizID int64, opt
 		// update rule
 		if count > 0 {
 			updateData := map[string]interface{}{
-				common.BKPropertyValueField: item.PropertyValue,
+				common.BKPropertyValueField: rule.PropertyValue,
+				"bk_value_template":         rule.ValueTemplate,
+				"bk_priority":               rule.Priority,
 				common.LastTimeField:        now,
 				common.ModifierField:        kit.User,
 			}
+			if rule.Condition != nil {
+				updateData["bk_condition"] = rule.Condition
+			}
 			if err := p.dbProxy.Table(common.BKTableNameHostApplyRule).Update(kit.Ctx, ruleFilter, updateData); err != nil {
 				blog.ErrorJSON("BatchUpdateHostApplyRule failed, update rule failed, filter: %s, doc: %s, err: %s, rid: %s", ruleFilter, updateData, err.Error(), rid)
 				ccErr := kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
 				itemResult.SetError(ccErr)
+			} else if rule.Condition == nil {
+				if err := p.dbProxy.Table(common.BKTableNameHostApplyRule).DropDocsColumn(kit.Ctx, "bk_condition", ruleFilter); err != nil {
+					blog.ErrorJSON("BatchUpdateHostApplyRule failed, remove condition failed, filter: %s, err: %s, rid: %s", ruleFilter, err.Error(), rid)
+					ccErr := kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+					itemResult.SetError(ccErr)
+				}
 			}
 			batchResult.Items = append(batchResult.Items, itemResult)
 			continue
@@ -531,18 +874,7 @@ func (p *hostApplyRule) BatchUpdateHostApplyRule(kit *rest.Kit, bizID int64, opt
 			batchResult.Items = append(batchResult.Items, itemResult)
 			continue
 		}
-		rule := metadata.HostApplyRule{
-			ID:              int64(newRuleID),
-			BizID:           bizID,
-			ModuleID:        item.ModuleID,
-			AttributeID:     item.AttributeID,
-			PropertyValue:   item.PropertyValue,
-			Creator:         kit.User,
-			Modifier:        kit.User,
-			CreateTime:      now,
-			LastTime:        now,
-			SupplierAccount: kit.SupplierAccount,
-		}
+		rule.ID = int64(newRuleID)
 		if err := p.dbProxy.Table(common.BKTableNameHostApplyRule).Insert(kit.Ctx, rule); err != nil {
 			blog.ErrorJSON("BatchUpdateHostApplyRule failed, insert rule failed, doc: %s, err: %s, rid: %s", rule, err.Error(), rid)
 			ccErr := kit.CCError.CCError(common.CCErrCommDBInsertFailed)
@@ -554,9 +886,15 @@ func (p *hostApplyRule) BatchUpdateHostApplyRule(kit *rest.Kit, bizID int64, opt
 	}
 
 	for index, item := range option.Rules {
-		rule, ccErr := p.GetHostApplyRuleByAttributeID(kit, bizID, item.ModuleID, item.AttributeID)
+		target := metadata.HostApplyRule{
+			ModuleID:          item.ModuleID,
+			ServiceTemplateID: item.ServiceTemplateID,
+			SetTemplateID:     item.SetTemplateID,
+			AttributeID:       item.AttributeID,
+		}
+		rule, ccErr := p.getHostApplyRuleByFilter(kit, ruleTargetFilter(kit, bizID, target))
 		if ccErr != nil {
-			blog.Errorf("GetHostApplyRuleByAttributeID failed, bizID: %d, moduleID: %d, attribute: %d, err: %s, rid: %s", bizID, item.ModuleID, item.AttributeID, ccErr.Error(), rid)
+			blog.Errorf("getHostApplyRuleByFilter failed, bizID: %d, rule: %+v, err: %s, rid: %s", bizID, item, ccErr.Error(), rid)
 			if err := batchResult.Items[index].GetError(); err == nil {
 				batchResult.Items[index].SetError(ccErr)
 			}
@@ -0,0 +1,160 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostapplyrule
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type effectiveRule struct {
+	id          int64
+	moduleID    int64
+	attributeID int64
+	level       metadata.HostApplyRuleLevel
+}
+
+func TestInheritHostApplyRules(t *testing.T) {
+	moduleRule := func(id, moduleID, attributeID int64) metadata.HostApplyRule {
+		return metadata.HostApplyRule{ID: id, ModuleID: moduleID, AttributeID: attributeID}
+	}
+	serviceTemplateRule := func(id, templateID, attributeID int64) metadata.HostApplyRule {
+		return metadata.HostApplyRule{ID: id, ServiceTemplateID: templateID, AttributeID: attributeID}
+	}
+	setTemplateRule := func(id, templateID, attributeID int64) metadata.HostApplyRule {
+		return metadata.HostApplyRule{ID: id, SetTemplateID: templateID, AttributeID: attributeID}
+	}
+
+	cases := []struct {
+		name    string
+		modules []metadata.ModuleInst
+		rules   []metadata.HostApplyRule
+		expect  []effectiveRule
+	}{
+		{
+			name:    "module rule only",
+			modules: []metadata.ModuleInst{{ModuleID: 1}},
+			rules:   []metadata.HostApplyRule{moduleRule(1, 1, 10), moduleRule(2, 2, 10)},
+			expect:  []effectiveRule{{1, 1, 10, metadata.HostApplyRuleLevelModule}},
+		},
+		{
+			name:    "module overrides service template and set template",
+			modules: []metadata.ModuleInst{{ModuleID: 1, ServiceTemplateID: 5, SetTemplateID: 7}},
+			rules: []metadata.HostApplyRule{
+				setTemplateRule(1, 7, 10),
+				serviceTemplateRule(2, 5, 10),
+				moduleRule(3, 1, 10),
+			},
+			expect: []effectiveRule{{3, 1, 10, metadata.HostApplyRuleLevelModule}},
+		},
+		{
+			name:    "service template overrides set template",
+			modules: []metadata.ModuleInst{{ModuleID: 1, ServiceTemplateID: 5, SetTemplateID: 7}},
+			rules:   []metadata.HostApplyRule{setTemplateRule(1, 7, 10), serviceTemplateRule(2, 5, 10)},
+			expect:  []effectiveRule{{2, 1, 10, metadata.HostApplyRuleLevelServiceTemplate}},
+		},
+		{
+			name:    "inherited rules of different attributes are merged",
+			modules: []metadata.ModuleInst{{ModuleID: 1, ServiceTemplateID: 5, SetTemplateID: 7}},
+			rules: []metadata.HostApplyRule{
+				setTemplateRule(1, 7, 12),
+				serviceTemplateRule(2, 5, 11),
+				moduleRule(3, 1, 10),
+			},
+			expect: []effectiveRule{
+				{3, 1, 10, metadata.HostApplyRuleLevelModule},
+				{2, 1, 11, metadata.HostApplyRuleLevelServiceTemplate},
+				{1, 1, 12, metadata.HostApplyRuleLevelSetTemplate},
+			},
+		},
+		{
+			name: "template rule is applied to every module of the template",
+			modules: []metadata.ModuleInst{
+				{ModuleID: 2, ServiceTemplateID: 5},
+				{ModuleID: 1, ServiceTemplateID: 5},
+				{ModuleID: 3, ServiceTemplateID: 6},
+			},
+			rules: []metadata.HostApplyRule{serviceTemplateRule(1, 5, 10), moduleRule(2, 2, 10)},
+			expect: []effectiveRule{
+				{1, 1, 10, metadata.HostApplyRuleLevelServiceTemplate},
+				{2, 2, 10, metadata.HostApplyRuleLevelModule},
+			},
+		},
+		{
+			name:    "module without template ignores template rules",
+			modules: []metadata.ModuleInst{{ModuleID: 1}},
+			rules:   []metadata.HostApplyRule{serviceTemplateRule(1, 5, 10), setTemplateRule(2, 7, 10)},
+			expect:  []effectiveRule{},
+		},
+		{
+			name:    "tie on the same target is won by the smaller id",
+			modules: []metadata.ModuleInst{{ModuleID: 1, ServiceTemplateID: 5}},
+			rules: []metadata.HostApplyRule{
+				serviceTemplateRule(9, 5, 10),
+				serviceTemplateRule(4, 5, 10),
+				serviceTemplateRule(6, 5, 10),
+			},
+			expect: []effectiveRule{{4, 1, 10, metadata.HostApplyRuleLevelServiceTemplate}},
+		},
+		{
+			name:    "tie on the module level does not fall back to the template",
+			modules: []metadata.ModuleInst{{ModuleID: 1, ServiceTemplateID: 5}},
+			rules: []metadata.HostApplyRule{
+				moduleRule(8, 1, 10),
+				serviceTemplateRule(1, 5, 10),
+				moduleRule(3, 1, 10),
+			},
+			expect: []effectiveRule{{3, 1, 10, metadata.HostApplyRuleLevelModule}},
+		},
+	}
+
+	for _, c := range cases {
+		result := inheritHostApplyRules(c.modules, c.rules)
+		actual := make([]effectiveRule, len(result))
+		for idx, rule := range result {
+			actual[idx] = effectiveRule{rule.ID, rule.ModuleID, rule.AttributeID, rule.Level}
+		}
+		assert.Equal(t, c.expect, actual, c.name)
+	}
+}
+
+func TestValidateRuleValueOfNotAllowedAttribute(t *testing.T) {
+	errFactory, err := errors.NewFactory("../../../../../resources/errors/")
+	assert.NoError(t, err)
+	kit := &rest.Kit{
+		Rid:     "test_req_id",
+		Ctx:     context.Background(),
+		CCError: errFactory.CreateDefaultCCErrorIf("en"),
+	}
+
+	attributes := []metadata.Attribute{
+		{ID: 1, PropertyID: "bmc_password", PropertyType: common.FieldTypeSecret},
+		{ID: 2, PropertyID: "score", PropertyType: common.FieldTypeComputed},
+		{ID: 3, PropertyID: common.BKHostInnerIPField, PropertyType: common.FieldTypeSingleChar},
+	}
+	p := &hostApplyRule{}
+	for _, attribute := range attributes {
+		rule := &metadata.HostApplyRule{AttributeID: attribute.ID, ModuleID: 1, PropertyValue: "value"}
+		ccErr := p.validateRuleValue(kit, 1, attribute, rule)
+		if assert.NotNil(t, ccErr, attribute.PropertyID) {
+			assert.Equal(t, common.CCErrCommParamsInvalid, ccErr.GetCode(), attribute.PropertyID)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostapplyrule
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// the scopes of the fields that can be referenced in a value template, fields without scope are the host's fields,
+// fields of the module, set and business that the rule's module belongs to are referenced like ${set.bk_set_name}.
+const (
+	templateScopeHost   = ""
+	templateScopeModule = "module"
+	templateScopeSet    = "set"
+	templateScopeBiz    = "biz"
+)
+
+// templatePipeArgs is the supported pipes of the value template and the number of their arguments, pipes are
+// appended to the field with "|", and the arguments are separated by ":", like ${bk_host_name|replace:.:-|upper}.
+var templatePipeArgs = map[string]int{
+	"upper":   0,
+	"lower":   0,
+	"trim":    0,
+	"first":   0,
+	"replace": 2,
+	"default": 1,
+}
+
+var templateFieldRegexp = regexp.MustCompile(`^((module|set|biz)\.)?([a-zA-Z0-9][\w\-]*)$`)
+
+// valueTemplate is a parsed value template of the host apply rule, like "${bk_host_innerip|first}-${set.bk_set_name}".
+type valueTemplate struct {
+	parts []templatePart
+}
+
+type templatePart struct {
+	// text is the literal text, it is used only if field is empty
+	text  string
+	scope string
+	field string
+	pipes []templatePipe
+}
+
+type templatePipe struct {
+	name string
+	args []string
+}
+
+// templateData is the documents that the fields of the template are read from.
+type templateData struct {
+	host   map[string]interface{}
+	module map[string]interface{}
+	set    map[string]interface{}
+	biz    map[string]interface{}
+}
+
+func parseValueTemplate(tpl string) (*valueTemplate, error) {
+	result := &valueTemplate{parts: make([]templatePart, 0)}
+	rest := tpl
+	for len(rest) > 0 {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			result.parts = append(result.parts, templatePart{text: rest})
+			break
+		}
+		if start > 0 {
+			result.parts = append(result.parts, templatePart{text: rest[:start]})
+		}
+
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed placeholder at %d", len(tpl)-len(rest)+start)
+		}
+		part, err := parseTemplatePlaceholder(rest[start+2 : start+end])
+		if err != nil {
+			return nil, err
+		}
+		result.parts = append(result.parts, part)
+		rest = rest[start+end+1:]
+	}
+	return result, nil
+}
+
+func parseTemplatePlaceholder(placeholder string) (templatePart, error) {
+	items := strings.Split(placeholder, "|")
+	field := strings.TrimSpace(items[0])
+	matches := templateFieldRegexp.FindStringSubmatch(field)
+	if matches == nil {
+		return templatePart{}, fmt.Errorf("invalid field %q in placeholder ${%s}", field, placeholder)
+	}
+
+	part := templatePart{
+		scope: matches[2],
+		field: matches[3],
+		pipes: make([]templatePipe, 0),
+	}
+	for _, item := range items[1:] {
+		args := strings.Split(strings.TrimSpace(item), ":")
+		pipe := templatePipe{name: args[0], args: args[1:]}
+		argNum, exist := templatePipeArgs[pipe.name]
+		if !exist {
+			return templatePart{}, fmt.Errorf("unsupported pipe %q in placeholder ${%s}", pipe.name, placeholder)
+		}
+		if len(pipe.args) != argNum {
+			return templatePart{}, fmt.Errorf("pipe %s requires %d arguments, but got %d", pipe.name, argNum,
+				len(pipe.args))
+		}
+		part.pipes = append(part.pipes, pipe)
+	}
+	return part, nil
+}
+
+// hostFields returns the host's fields referenced by the template.
+func (t *valueTemplate) hostFields() []string {
+	fields := make([]string, 0)
+	for _, part := range t.parts {
+		if len(part.field) > 0 && part.scope == templateScopeHost {
+			fields = append(fields, part.field)
+		}
+	}
+	return fields
+}
+
+// render compute the value of the template, the missing fields are rendered as empty strings.
+func (t *valueTemplate) render(data templateData) string {
+	var builder strings.Builder
+	for _, part := range t.parts {
+		if len(part.field) == 0 {
+			builder.WriteString(part.text)
+			continue
+		}
+
+		var doc map[string]interface{}
+		switch part.scope {
+		case templateScopeHost:
+			doc = data.host
+		case templateScopeModule:
+			doc = data.module
+		case templateScopeSet:
+			doc = data.set
+		case templateScopeBiz:
+			doc = data.biz
+		}
+
+		value := formatTemplateValue(doc[part.field])
+		for _, pipe := range part.pipes {
+			value = applyTemplatePipe(value, pipe)
+		}
+		builder.WriteString(value)
+	}
+	return builder.String()
+}
+
+func formatTemplateValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, formatTemplateValue(item))
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func applyTemplatePipe(value string, pipe templatePipe) string {
+	switch pipe.name {
+	case "upper":
+		return strings.ToUpper(value)
+	case "lower":
+		return strings.ToLower(value)
+	case "trim":
+		return strings.TrimSpace(value)
+	case "first":
+		// multiple values such as ips are separated by comma
+		return strings.TrimSpace(strings.Split(value, ",")[0])
+	case "replace":
+		return strings.Replace(value, pipe.args[0], pipe.args[1], -1)
+	case "default":
+		if len(value) == 0 {
+			return pipe.args[0]
+		}
+		return value
+	default:
+		return value
+	}
+}
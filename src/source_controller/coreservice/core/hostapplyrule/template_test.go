@@ -0,0 +1,89 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostapplyrule
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueTemplate(t *testing.T) {
+	data := templateData{
+		host: map[string]interface{}{
+			"bk_host_innerip": "10.0.0.1,10.0.0.2",
+			"bk_cpu":          int64(8),
+			"bk_os_type":      "Linux",
+		},
+		set: map[string]interface{}{"bk_set_name": "Game Server"},
+		biz: map[string]interface{}{"bk_biz_name": "demo"},
+	}
+
+	cases := map[string]string{
+		"static": "static",
+		"${bk_host_innerip|first}-${set.bk_set_name}":        "10.0.0.1-Game Server",
+		"${biz.bk_biz_name|upper}/${bk_os_type|lower}":       "DEMO/linux",
+		"${set.bk_set_name|replace: :_|lower}-${bk_cpu}c":    "game_server-8c",
+		"${module.bk_module_name|default:none}":              "none",
+		"${ bk_host_innerip | first | replace:.:- }.${oops}": "10-0-0-1.",
+	}
+	for tplText, expect := range cases {
+		tpl, err := parseValueTemplate(tplText)
+		if !assert.Nil(t, err, tplText) {
+			continue
+		}
+		assert.Equal(t, expect, tpl.render(data), tplText)
+	}
+
+	tpl, err := parseValueTemplate("${bk_host_innerip}-${set.bk_set_name}-${bk_cpu}")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bk_host_innerip", "bk_cpu"}, tpl.hostFields())
+
+	invalid := []string{
+		"${bk_host_innerip",
+		"${}",
+		"${host.bk_host_innerip}",
+		"${bk_host_innerip|unknown}",
+		"${bk_host_innerip|replace:a}",
+	}
+	for _, tplText := range invalid {
+		_, err := parseValueTemplate(tplText)
+		assert.NotNil(t, err, tplText)
+	}
+}
+
+func TestHighestPriorityRules(t *testing.T) {
+	rules := []metadata.HostApplyRule{
+		{ID: 1, Priority: 1},
+		{ID: 2, Priority: 3},
+		{ID: 3, Priority: 0},
+		{ID: 4, Priority: 3},
+	}
+	result := highestPriorityRules(rules)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, int64(2), result[0].ID)
+	assert.Equal(t, int64(4), result[1].ID)
+}
+
+func TestIsSameHostValue(t *testing.T) {
+	assert.True(t, isSameHostValue(int64(8), int32(8)))
+	assert.True(t, isSameHostValue(float64(8), int64(8)))
+	assert.True(t, isSameHostValue("linux", "linux"))
+	assert.True(t, isSameHostValue(nil, nil))
+	assert.False(t, isSameHostValue("01", "1"))
+	assert.False(t, isSameHostValue("8", int64(8)))
+	assert.False(t, isSameHostValue(nil, ""))
+	assert.False(t, isSameHostValue(int64(8), int64(16)))
+}
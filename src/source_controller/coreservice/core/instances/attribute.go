@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// objectAttributes is the attributes of an object, which are searched once in a request and shared by the
+// handling of the secret, computed and network values of the request's instances.
+type objectAttributes struct {
+	attributes []metadata.Attribute
+	// secret is the property ids of the secret attributes
+	secret   []string
+	computed []computedAttribute
+}
+
+// getObjectAttributes returns the attributes of the object, the attributes of all the businesses are included,
+// because the instances may be of any business.
+func (m *instanceManager) getObjectAttributes(kit *rest.Kit, objID string) (*objectAttributes, error) {
+	cond := mapstr.MapStr{common.BKObjIDField: objID}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	attributes := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &attributes); err != nil {
+		blog.Errorf("search attributes of %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	return &objectAttributes{
+		attributes: attributes,
+		secret:     metadata.GetSecretPropertyIDs(attributes),
+		computed:   parseComputedAttributes(kit, attributes),
+	}, nil
+}
+
+// denormalizeNetworkValues converts the normalized network values of the searched instances back to their
+// common forms.
+func (a *objectAttributes) denormalizeNetworkValues(insts []mapstr.MapStr) {
+	for _, inst := range insts {
+		metadata.DenormalizeNetworkValues(a.attributes, inst)
+	}
+}
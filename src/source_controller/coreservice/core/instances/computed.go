@@ -0,0 +1,465 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"reflect"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// computedAttribute is a computed attribute with its parsed expression
+type computedAttribute struct {
+	propertyID string
+	resultType string
+	expr       *expression.Expression
+}
+
+// parseComputedAttributes returns the computed attributes in the attributes with their parsed expressions
+func parseComputedAttributes(kit *rest.Kit, attrs []metadata.Attribute) []computedAttribute {
+	computedAttrs := make([]computedAttribute, 0)
+	for _, attr := range attrs {
+		if attr.PropertyType != common.FieldTypeComputed {
+			continue
+		}
+		option, expr, err := attr.ParseComputedExpression(kit.Ctx)
+		if err != nil {
+			blog.Warnf("parse expression of computed attribute %s failed, skip it, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+			continue
+		}
+		computedAttrs = append(computedAttrs, computedAttribute{
+			propertyID: attr.PropertyID,
+			resultType: option.ResultType,
+			expr:       expr,
+		})
+	}
+	return computedAttrs
+}
+
+// getComputedAttributes returns the computed attributes of all the objects grouped by object id
+func (m *instanceManager) getComputedAttributes(kit *rest.Kit) (map[string][]computedAttribute, error) {
+	cond := mapstr.MapStr{common.BKPropertyTypeField: common.FieldTypeComputed}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	attrs := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &attrs); err != nil {
+		blog.Errorf("search computed attributes failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	objAttrs := make(map[string][]metadata.Attribute)
+	for _, attr := range attrs {
+		objAttrs[attr.ObjectID] = append(objAttrs[attr.ObjectID], attr)
+	}
+	computedAttrs := make(map[string][]computedAttribute)
+	for objID, attrs := range objAttrs {
+		if parsed := parseComputedAttributes(kit, attrs); len(parsed) > 0 {
+			computedAttrs[objID] = parsed
+		}
+	}
+	return computedAttrs, nil
+}
+
+// computedEvaluator evaluates the computed attributes of an object's instances, the instances referenced by
+// the expressions are fetched in batch when the evaluator is created.
+type computedEvaluator struct {
+	kit   *rest.Kit
+	objID string
+	attrs []computedAttribute
+	// parents and bizs are the referenced mainline parents and businesses by their instance ids
+	parents map[int64]mapstr.MapStr
+	bizs    map[int64]mapstr.MapStr
+	// assts is the associated instances of each instance, grouped by the associated object id
+	assts map[string]map[int64][]mapstr.MapStr
+}
+
+// newComputedEvaluator creates the evaluator of the instances, the referenced parents, businesses and associated
+// instances of all the instances are fetched with one query for each of them.
+func (m *instanceManager) newComputedEvaluator(kit *rest.Kit, objID string, attrs []computedAttribute,
+	insts []mapstr.MapStr) (*computedEvaluator, error) {
+
+	e := &computedEvaluator{
+		kit:     kit,
+		objID:   objID,
+		attrs:   attrs,
+		parents: make(map[int64]mapstr.MapStr),
+		bizs:    make(map[int64]mapstr.MapStr),
+		assts:   make(map[string]map[int64][]mapstr.MapStr),
+	}
+
+	var byParent, byBiz bool
+	asstObjIDs := make([]string, 0)
+	for _, attr := range attrs {
+		for _, ref := range attr.expr.References() {
+			switch ref.Scope {
+			case expression.ScopeParent:
+				byParent = true
+			case expression.ScopeBiz:
+				byBiz = true
+			case expression.ScopeAsst:
+				asstObjIDs = append(asstObjIDs, ref.ObjectID)
+			}
+		}
+	}
+
+	var err error
+	if byParent {
+		var parentObjID string
+		if parentObjID, err = m.getMainlineParentObjID(kit, objID); err != nil {
+			return nil, err
+		}
+		if parentObjID != "" {
+			if e.parents, err = m.getReferencedInsts(kit, parentObjID, insts, common.BKParentIDField); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if byBiz {
+		if e.bizs, err = m.getReferencedInsts(kit, common.BKInnerObjIDApp, insts, common.BKAppIDField); err != nil {
+			return nil, err
+		}
+	}
+	for _, asstObjID := range util.StrArrayUnique(asstObjIDs) {
+		if e.assts[asstObjID], err = m.getAssociatedInsts(kit, objID, insts, asstObjID); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// evaluate returns the computed values of the instance, the value is nil if the expression can not be evaluated
+func (e *computedEvaluator) evaluate(inst mapstr.MapStr) mapstr.MapStr {
+	resolve := func(ref expression.Reference) (interface{}, error) {
+		switch ref.Scope {
+		case expression.ScopeParent:
+			return metadata.GetInstanceFieldValue(e.parents[getInt64Field(inst, common.BKParentIDField)], ref.Field), nil
+		case expression.ScopeBiz:
+			return metadata.GetInstanceFieldValue(e.bizs[getInt64Field(inst, common.BKAppIDField)], ref.Field), nil
+		case expression.ScopeAsst:
+			asstInsts := e.assts[ref.ObjectID][getInt64Field(inst, common.GetInstIDField(e.objID))]
+			values := make([]interface{}, 0, len(asstInsts))
+			for _, asstInst := range asstInsts {
+				values = append(values, metadata.GetInstanceFieldValue(asstInst, ref.Field))
+			}
+			return values, nil
+		default:
+			return metadata.GetInstanceFieldValue(inst, ref.Field), nil
+		}
+	}
+
+	values := make(mapstr.MapStr)
+	for _, attr := range e.attrs {
+		value, err := attr.expr.Evaluate(resolve)
+		if err == nil {
+			value, err = expression.Convert(value, attr.resultType)
+		}
+		if err != nil {
+			blog.Warnf("evaluate computed attribute %s of %s failed, set it to null, err: %v, rid: %s", attr.propertyID,
+				e.objID, err, e.kit.Rid)
+			value = nil
+		}
+		values[attr.propertyID] = value
+	}
+	return values
+}
+
+// getInt64Field returns the int64 value of the instance's field, returns 0 if it's not set or not an integer
+func getInt64Field(inst mapstr.MapStr, field string) int64 {
+	value, err := util.GetInt64ByInterface(inst[field])
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// getReferencedInsts returns the instances of objID whose ids are the values of the field in the instances
+func (m *instanceManager) getReferencedInsts(kit *rest.Kit, objID string, insts []mapstr.MapStr, field string) (
+	map[int64]mapstr.MapStr, error) {
+
+	ids := make([]int64, 0)
+	for _, inst := range insts {
+		if id := getInt64Field(inst, field); id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	refInsts := make(map[int64]mapstr.MapStr)
+	if len(ids) == 0 {
+		return refInsts, nil
+	}
+
+	instIDField := common.GetInstIDField(objID)
+	cond := mapstr.MapStr{instIDField: mapstr.MapStr{common.BKDBIN: util.IntArrayUnique(ids)}}
+	results, _, err := m.getInsts(kit, objID, cond)
+	if err != nil {
+		blog.Errorf("get referenced instances of %s failed, cond: %#v, err: %v, rid: %s", objID, cond, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+	for _, refInst := range results {
+		refInsts[getInt64Field(refInst, instIDField)] = refInst
+	}
+	return refInsts, nil
+}
+
+// getAssociatedInsts returns the instances of asstObjID that are associated with each of the instances in
+// either direction
+func (m *instanceManager) getAssociatedInsts(kit *rest.Kit, objID string, insts []mapstr.MapStr, asstObjID string) (
+	map[int64][]mapstr.MapStr, error) {
+
+	instIDs := make([]int64, 0, len(insts))
+	for _, inst := range insts {
+		// the instance that is not created yet has no associations
+		if instID := getInt64Field(inst, common.GetInstIDField(objID)); instID != 0 {
+			instIDs = append(instIDs, instID)
+		}
+	}
+	asstInsts := make(map[int64][]mapstr.MapStr)
+	if len(instIDs) == 0 {
+		return asstInsts, nil
+	}
+
+	asstInstIDs, err := m.getAssociatedInstIDs(kit, objID, instIDs, asstObjID)
+	if err != nil {
+		return nil, err
+	}
+	allAsstInstIDs := make([]int64, 0)
+	for _, ids := range asstInstIDs {
+		allAsstInstIDs = append(allAsstInstIDs, ids...)
+	}
+	if len(allAsstInstIDs) == 0 {
+		return asstInsts, nil
+	}
+
+	asstInstIDField := common.GetInstIDField(asstObjID)
+	cond := mapstr.MapStr{asstInstIDField: mapstr.MapStr{common.BKDBIN: util.IntArrayUnique(allAsstInstIDs)}}
+	results, _, err := m.getInsts(kit, asstObjID, cond)
+	if err != nil {
+		blog.Errorf("get associated instances of %s failed, cond: %#v, err: %v, rid: %s", asstObjID, cond, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+	resultMap := make(map[int64]mapstr.MapStr, len(results))
+	for _, result := range results {
+		resultMap[getInt64Field(result, asstInstIDField)] = result
+	}
+
+	for instID, ids := range asstInstIDs {
+		for _, id := range ids {
+			if asstInst, exists := resultMap[id]; exists {
+				asstInsts[instID] = append(asstInsts[instID], asstInst)
+			}
+		}
+	}
+	return asstInsts, nil
+}
+
+// getAssociatedInstIDs returns the ids of asstObjID's instances that are associated with each of the instances
+// in either direction
+func (m *instanceManager) getAssociatedInstIDs(kit *rest.Kit, objID string, instIDs []int64, asstObjID string) (
+	map[int64][]int64, error) {
+
+	cond := mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{
+				common.BKObjIDField:     objID,
+				common.BKInstIDField:    mapstr.MapStr{common.BKDBIN: instIDs},
+				common.BKAsstObjIDField: asstObjID,
+			},
+			{
+				common.BKObjIDField:      asstObjID,
+				common.BKAsstObjIDField:  objID,
+				common.BKAsstInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+			},
+		},
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	assts := make([]metadata.InstAsst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(cond).All(kit.Ctx, &assts); err != nil {
+		blog.Errorf("search instance associations failed, cond: %#v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	// an association of an object to itself matches the instances in both directions
+	asstInstIDs := make(map[int64][]int64)
+	for _, asst := range assts {
+		if asst.ObjectID == objID && asst.AsstObjectID == asstObjID && util.InArray(asst.InstID, instIDs) {
+			asstInstIDs[asst.InstID] = append(asstInstIDs[asst.InstID], asst.AsstInstID)
+		}
+		if asst.ObjectID == asstObjID && asst.AsstObjectID == objID && util.InArray(asst.AsstInstID, instIDs) {
+			asstInstIDs[asst.AsstInstID] = append(asstInstIDs[asst.AsstInstID], asst.InstID)
+		}
+	}
+	for instID, ids := range asstInstIDs {
+		asstInstIDs[instID] = util.IntArrayUnique(ids)
+	}
+	return asstInstIDs, nil
+}
+
+// getMainlineParentObjID returns the mainline parent object id of the object, returns empty if it's not a mainline child.
+func (m *instanceManager) getMainlineParentObjID(kit *rest.Kit, objID string) (string, error) {
+	cond := mapstr.MapStr{
+		common.BKObjIDField:           objID,
+		common.AssociationKindIDField: common.AssociationKindMainline,
+	}
+	assts := make([]metadata.Association, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond).All(kit.Ctx, &assts); err != nil {
+		blog.Errorf("search mainline association of object %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return "", kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(assts) == 0 {
+		return "", nil
+	}
+	return assts[0].AsstObjID, nil
+}
+
+// fillComputedValues evaluates the computed attributes' values of the instance to create
+func (m *instanceManager) fillComputedValues(kit *rest.Kit, objID string, attrs []computedAttribute,
+	inst mapstr.MapStr) error {
+
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	evaluator, err := m.newComputedEvaluator(kit, objID, attrs, []mapstr.MapStr{inst})
+	if err != nil {
+		return err
+	}
+	for key, value := range evaluator.evaluate(inst) {
+		inst[key] = value
+	}
+	return nil
+}
+
+// fillMissingComputedValues evaluates the computed attributes that are not stored in the searched instances,
+// the values are returned without saving.
+func (m *instanceManager) fillMissingComputedValues(kit *rest.Kit, objID string, attrs []computedAttribute,
+	insts []mapstr.MapStr) error {
+
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	missingInsts := make([]mapstr.MapStr, 0)
+	for _, inst := range insts {
+		for _, attr := range attrs {
+			if _, exists := inst[attr.propertyID]; !exists {
+				missingInsts = append(missingInsts, inst)
+				break
+			}
+		}
+	}
+	if len(missingInsts) == 0 {
+		return nil
+	}
+
+	evaluator, err := m.newComputedEvaluator(kit, objID, attrs, missingInsts)
+	if err != nil {
+		return err
+	}
+	for _, inst := range missingInsts {
+		for key, value := range evaluator.evaluate(inst) {
+			if _, exists := inst[key]; !exists {
+				inst[key] = value
+			}
+		}
+	}
+	return nil
+}
+
+// computedChange is the computed values changed by the refresh, with the instance data before the change
+type computedChange struct {
+	instID int64
+	origin mapstr.MapStr
+	values mapstr.MapStr
+}
+
+// refreshComputedValues re-evaluate the computed attributes' values of the instances matching the condition page
+// by page, only the changed values are saved, and the changes of each page are passed to afterSave if it's not nil.
+func (m *instanceManager) refreshComputedValues(kit *rest.Kit, objID string, attrs []computedAttribute,
+	cond mapstr.MapStr, afterSave func(changes []computedChange) error) error {
+
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	tableName := common.GetInstTableName(objID)
+	instIDField := common.GetInstIDField(objID)
+	if tableName == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	for start := uint64(0); ; start += common.BKMaxPageSize {
+		insts := make([]mapstr.MapStr, 0)
+		query := m.dbProxy.Table(tableName).Find(cond).Start(start).Limit(common.BKMaxPageSize).Sort(instIDField)
+		if objID == common.BKInnerObjIDHost {
+			hosts := make([]metadata.HostMapStr, 0)
+			if err := query.All(kit.Ctx, &hosts); err != nil {
+				blog.Errorf("search hosts to refresh computed values failed, err: %v, rid: %s", err, kit.Rid)
+				return kit.CCError.Error(common.CCErrCommDBSelectFailed)
+			}
+			for _, host := range hosts {
+				insts = append(insts, mapstr.MapStr(host))
+			}
+		} else if err := query.All(kit.Ctx, &insts); err != nil {
+			blog.Errorf("search %s instances to refresh computed values failed, err: %v, rid: %s", objID, err, kit.Rid)
+			return kit.CCError.Error(common.CCErrCommDBSelectFailed)
+		}
+
+		evaluator, err := m.newComputedEvaluator(kit, objID, attrs, insts)
+		if err != nil {
+			return err
+		}
+		changes := make([]computedChange, 0)
+		for _, inst := range insts {
+			changed := make(mapstr.MapStr)
+			for key, value := range evaluator.evaluate(inst) {
+				if origin, exists := inst[key]; !exists || !reflect.DeepEqual(origin, value) {
+					changed[key] = value
+				}
+			}
+			if len(changed) == 0 {
+				continue
+			}
+
+			updateCond := mapstr.MapStr{instIDField: inst[instIDField]}
+			if tableName == common.BKTableNameBaseInst {
+				updateCond[common.BKObjIDField] = objID
+			}
+			if err := m.dbProxy.Table(tableName).Update(kit.Ctx, updateCond, changed); err != nil {
+				blog.Errorf("update computed values of %s instance %v failed, err: %v, rid: %s", objID,
+					inst[instIDField], err, kit.Rid)
+				return kit.CCError.Error(common.CCErrCommDBUpdateFailed)
+			}
+			changes = append(changes, computedChange{
+				instID: getInt64Field(inst, instIDField),
+				origin: inst,
+				values: changed,
+			})
+		}
+
+		if afterSave != nil && len(changes) > 0 {
+			if err := afterSave(changes); err != nil {
+				return err
+			}
+		}
+
+		if len(insts) < common.BKMaxPageSize {
+			return nil
+		}
+	}
+}
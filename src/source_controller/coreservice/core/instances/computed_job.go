@@ -0,0 +1,346 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"net/http"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+
+	redis "gopkg.in/redis.v5"
+)
+
+const (
+	// computedRefreshInterval is the interval to run the queued computed refresh tasks
+	computedRefreshInterval = 10 * time.Second
+	// computedRefreshBatchSize is the max number of the tasks run in one round
+	computedRefreshBatchSize = 100
+	// computedRefreshMaxAttempts is the max number of times a task is run, the task is dropped if it still fails
+	computedRefreshMaxAttempts = 5
+	// computedRefreshRetryInterval is the interval to retry a failed task for the first time, it doubles with
+	// each failed attempt.
+	computedRefreshRetryInterval = time.Minute
+)
+
+// computedRefreshTask is a queued task to re-evaluate the computed attributes' values of an object's instances
+type computedRefreshTask struct {
+	ID       int64  `bson:"id"`
+	ObjectID string `bson:"bk_obj_id"`
+	// InstIDs is the instances to refresh, all the instances of the object are refreshed if it's empty
+	InstIDs []int64 `bson:"bk_inst_ids"`
+	// Dependent means the instances depend on the instances are refreshed, instead of the instances themselves
+	Dependent       bool      `bson:"dependent"`
+	SupplierAccount string    `bson:"bk_supplier_account"`
+	User            string    `bson:"user"`
+	CreateTime      time.Time `bson:"create_time"`
+	// Attempts is the number of the failed runs of the task, it's retried at the NextRunTime
+	Attempts    int       `bson:"attempts"`
+	NextRunTime time.Time `bson:"next_run_time"`
+}
+
+// queueComputedRefresh saves a task to refresh the computed values in background, the task is saved in the
+// transaction of the request, so that it's dropped if the request fails.
+func (m *instanceManager) queueComputedRefresh(kit *rest.Kit, objID string, instIDs []int64, dependent bool) error {
+	id, err := m.dbProxy.NextSequence(kit.Ctx, common.BKTableNameComputedRefreshTask)
+	if err != nil {
+		blog.Errorf("generate computed refresh task id failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBInsertFailed)
+	}
+
+	now := time.Now()
+	task := computedRefreshTask{
+		ID:              int64(id),
+		ObjectID:        objID,
+		InstIDs:         instIDs,
+		Dependent:       dependent,
+		SupplierAccount: kit.SupplierAccount,
+		User:            kit.User,
+		CreateTime:      now,
+		NextRunTime:     now,
+	}
+	if err := m.dbProxy.Table(common.BKTableNameComputedRefreshTask).Insert(kit.Ctx, task); err != nil {
+		blog.Errorf("save computed refresh task failed, task: %+v, err: %v, rid: %s", task, err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBInsertFailed)
+	}
+	return nil
+}
+
+// RefreshComputedValues queues a task to re-evaluate the computed attributes' values of the instances in
+// background, all the instances of the object are refreshed if instIDs is nil.
+func (m *instanceManager) RefreshComputedValues(kit *rest.Kit, objID string, instIDs []int64) error {
+	if instIDs != nil && len(instIDs) == 0 {
+		return nil
+	}
+	return m.queueComputedRefresh(kit, objID, instIDs, false)
+}
+
+// ComputedRefreshJob runs the queued computed refresh tasks on the master coreservice, the values are refreshed
+// page by page, and the changes are pushed as events and recorded by audit logs.
+type ComputedRefreshJob struct {
+	instance *instanceManager
+	audit    core.AuditOperation
+	isMaster discovery.ServiceManageInterface
+}
+
+// NewComputedRefreshJob creates the computed refresh job
+func NewComputedRefreshJob(dbProxy dal.RDB, cache *redis.Client, audit core.AuditOperation,
+	isMaster discovery.ServiceManageInterface) *ComputedRefreshJob {
+
+	return &ComputedRefreshJob{
+		instance: &instanceManager{
+			dbProxy:  dbProxy,
+			EventCli: eventclient.NewClientViaRedis(cache, dbProxy),
+		},
+		audit:    audit,
+		isMaster: isMaster,
+	}
+}
+
+// Run starts the job in background
+func (j *ComputedRefreshJob) Run() {
+	go func() {
+		ticker := time.NewTicker(computedRefreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !j.isMaster.IsMaster() {
+				blog.V(4).Infof("run computed refresh tasks, but not master, skip.")
+				continue
+			}
+			j.runTasks()
+		}
+	}()
+}
+
+// newJobKit creates the kit of the supplier account and user for the background jobs
+func newJobKit(supplierAccount, user string) *rest.Kit {
+	header := make(http.Header)
+	header.Set(common.BKHTTPOwnerID, supplierAccount)
+	header.Set(common.BKHTTPHeaderUser, user)
+	header.Set(common.BKHTTPCCRequestID, util.GenerateRID())
+
+	return &rest.Kit{
+		Rid:             util.GetHTTPCCRequestID(header),
+		Header:          header,
+		Ctx:             util.NewContextFromHTTPHeader(header),
+		CCError:         util.GetDefaultCCError(header),
+		User:            user,
+		SupplierAccount: supplierAccount,
+	}
+}
+
+// newJobUpdateAuditLog creates the audit log of the instance updated by the background jobs
+func newJobUpdateAuditLog(objID string, isMainline bool, instID int64, preData, curData mapstr.MapStr) metadata.AuditLog {
+	return metadata.AuditLog{
+		AuditType:    metadata.GetAuditTypeByObjID(objID, isMainline),
+		ResourceType: metadata.GetResourceTypeByObjID(objID, isMainline),
+		Action:       metadata.AuditUpdate,
+		OperateFrom:  metadata.FromCCSystem,
+		OperationDetail: &metadata.InstanceOpDetail{
+			BasicOpDetail: metadata.BasicOpDetail{
+				BusinessID:   getInt64Field(preData, common.BKAppIDField),
+				ResourceID:   instID,
+				ResourceName: util.GetStrByInterface(preData[common.GetInstNameField(objID)]),
+				Details: &metadata.BasicContent{
+					PreData: preData,
+					CurData: curData,
+				},
+			},
+			ModelID: objID,
+		},
+	}
+}
+
+func (j *ComputedRefreshJob) runTasks() {
+	kit := newJobKit(common.BKSuperOwnerID, common.CCSystemOperatorUserName)
+	cond := mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{"next_run_time": mapstr.MapStr{common.BKDBLTE: time.Now()}},
+			{"next_run_time": mapstr.MapStr{common.BKDBExists: false}},
+		},
+	}
+	tasks := make([]computedRefreshTask, 0)
+	if err := j.instance.dbProxy.Table(common.BKTableNameComputedRefreshTask).Find(cond).
+		Sort(common.BKFieldID).Limit(computedRefreshBatchSize).All(kit.Ctx, &tasks); err != nil {
+		blog.Errorf("search computed refresh tasks failed, err: %v, rid: %s", err, kit.Rid)
+		return
+	}
+
+	for _, task := range tasks {
+		taskKit := newJobKit(task.SupplierAccount, task.User)
+		taskCond := mapstr.MapStr{common.BKFieldID: task.ID}
+		err := j.runTask(taskKit, task)
+		if err == nil || task.Attempts+1 >= computedRefreshMaxAttempts {
+			if err != nil {
+				blog.Errorf("computed refresh task failed %d times, drop it, task: %+v, err: %v, rid: %s",
+					task.Attempts+1, task, err, taskKit.Rid)
+			}
+			if err := j.instance.dbProxy.Table(common.BKTableNameComputedRefreshTask).Delete(kit.Ctx, taskCond); err != nil {
+				blog.Errorf("delete computed refresh task %d failed, err: %v, rid: %s", task.ID, err, kit.Rid)
+				return
+			}
+			continue
+		}
+
+		// the failed task is retried later, so that it won't block the tasks after it
+		blog.Errorf("run computed refresh task failed, retry it later, task: %+v, err: %v, rid: %s", task, err, taskKit.Rid)
+		retry := mapstr.MapStr{
+			"attempts":      task.Attempts + 1,
+			"next_run_time": nextComputedRefreshTime(task.Attempts+1, time.Now()),
+		}
+		if err := j.instance.dbProxy.Table(common.BKTableNameComputedRefreshTask).Update(kit.Ctx, taskCond, retry); err != nil {
+			blog.Errorf("update computed refresh task %d failed, err: %v, rid: %s", task.ID, err, kit.Rid)
+			return
+		}
+	}
+}
+
+// nextComputedRefreshTime returns the time to retry a task which has failed for the attempts, the retry interval
+// doubles with each failed attempt.
+func nextComputedRefreshTime(attempts int, now time.Time) time.Time {
+	return now.Add(computedRefreshRetryInterval << uint(attempts-1))
+}
+
+func (j *ComputedRefreshJob) runTask(kit *rest.Kit, task computedRefreshTask) error {
+	if task.Dependent {
+		return j.refreshDependents(kit, task.ObjectID, task.InstIDs)
+	}
+
+	attrs, err := j.instance.getObjectAttributes(kit, task.ObjectID)
+	if err != nil {
+		return err
+	}
+	cond := mapstr.MapStr{}
+	if len(task.InstIDs) > 0 {
+		cond[common.GetInstIDField(task.ObjectID)] = mapstr.MapStr{common.BKDBIN: task.InstIDs}
+	}
+	return j.refresh(kit, task.ObjectID, attrs.computed, cond)
+}
+
+// refreshDependents re-evaluate the computed values of the instances that depend on the updated instances,
+// which are the mainline children, the instances of the business and the associated instances.
+func (j *ComputedRefreshJob) refreshDependents(kit *rest.Kit, objID string, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return nil
+	}
+	computedAttrs, err := j.instance.getComputedAttributes(kit)
+	if err != nil {
+		return err
+	}
+
+	for computedObjID, attrs := range computedAttrs {
+		var byParent, byBiz, byAsst bool
+		for _, attr := range attrs {
+			for _, ref := range attr.expr.References() {
+				switch ref.Scope {
+				case expression.ScopeParent:
+					byParent = true
+				case expression.ScopeBiz:
+					byBiz = byBiz || objID == common.BKInnerObjIDApp
+				case expression.ScopeAsst:
+					byAsst = byAsst || ref.ObjectID == objID
+				}
+			}
+		}
+
+		if byParent {
+			parentObjID, err := j.instance.getMainlineParentObjID(kit, computedObjID)
+			if err != nil {
+				return err
+			}
+			byParent = parentObjID == objID
+		}
+
+		orCond := make([]mapstr.MapStr, 0)
+		if byParent {
+			orCond = append(orCond, mapstr.MapStr{common.BKParentIDField: mapstr.MapStr{common.BKDBIN: instIDs}})
+		}
+		if byBiz {
+			orCond = append(orCond, mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBIN: instIDs}})
+		}
+		if byAsst {
+			asstInstIDs, err := j.instance.getAssociatedInstIDs(kit, objID, instIDs, computedObjID)
+			if err != nil {
+				return err
+			}
+			ids := make([]int64, 0)
+			for _, asstIDs := range asstInstIDs {
+				ids = append(ids, asstIDs...)
+			}
+			if len(ids) > 0 {
+				orCond = append(orCond, mapstr.MapStr{
+					common.GetInstIDField(computedObjID): mapstr.MapStr{common.BKDBIN: util.IntArrayUnique(ids)},
+				})
+			}
+		}
+		if len(orCond) == 0 {
+			continue
+		}
+
+		if err := j.refresh(kit, computedObjID, attrs, mapstr.MapStr{common.BKDBOR: orCond}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refresh re-evaluate the computed values of the instances matching the condition, the changes of each page
+// are pushed as the update events and recorded by the audit logs.
+func (j *ComputedRefreshJob) refresh(kit *rest.Kit, objID string, attrs []computedAttribute,
+	cond mapstr.MapStr) error {
+
+	if len(attrs) == 0 {
+		return nil
+	}
+	parentObjID, err := j.instance.getMainlineParentObjID(kit, objID)
+	if err != nil {
+		return err
+	}
+	isMainline := parentObjID != ""
+	instIDField := common.GetInstIDField(objID)
+
+	return j.instance.refreshComputedValues(kit, objID, attrs, cond, func(changes []computedChange) error {
+		eh := j.instance.NewEventClient(objID)
+		instIDs := make([]int64, 0, len(changes))
+		auditLogs := make([]metadata.AuditLog, 0, len(changes))
+		for _, change := range changes {
+			eh.SetPreData(change.instID, change.origin)
+			instIDs = append(instIDs, change.instID)
+
+			curData := change.origin.Clone()
+			curData.Merge(change.values)
+			auditLogs = append(auditLogs, newJobUpdateAuditLog(objID, isMainline, change.instID, change.origin, curData))
+		}
+
+		eventCond := mapstr.MapStr{instIDField: mapstr.MapStr{common.BKDBIN: instIDs}}
+		if err := eh.SetCurDataAndPush(kit, objID, metadata.EventActionUpdate, eventCond); err != nil {
+			blog.Errorf("push computed values update events of %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+			return err
+		}
+		if err := j.audit.CreateAuditLog(kit, auditLogs...); err != nil {
+			blog.Errorf("save computed values audit logs of %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+			return err
+		}
+		return nil
+	})
+}
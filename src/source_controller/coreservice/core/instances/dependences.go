@@ -14,6 +14,7 @@ package instances
 
 import (
 	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 )
 
@@ -32,4 +33,7 @@ type OperationDependences interface {
 
 	// SearchUnique search unique attribute
 	SearchUnique(kit *rest.Kit, objID string) (uniqueAttr []metadata.ObjectUnique, err error)
+
+	// RecycleInstances moves the instances to be deleted into the recycle bin
+	RecycleInstances(kit *rest.Kit, objID string, origins []mapstr.MapStr) error
 }
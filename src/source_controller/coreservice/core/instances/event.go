@@ -19,6 +19,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
 )
 
 // EventClient save event data to cache temporarily and push to event server at calling push
@@ -38,8 +39,20 @@ func (m *instanceManager) NewEventClient(objID string) *EventClient {
 	}
 }
 
+// maskEventData masks the secret values of the instance, they are never pushed with the events
+func maskEventData(data interface{}) interface{} {
+	inst, ok := data.(mapstr.MapStr)
+	if !ok {
+		return data
+	}
+	masked := inst.Clone()
+	secret.MaskMapStr(masked)
+	return masked
+}
+
 // SetPreData set inst before data
 func (eh *EventClient) SetPreData(eventID int64, data interface{}) {
+	data = maskEventData(data)
 
 	item, ok := eh.cache[eventID]
 	if !ok {
@@ -54,6 +67,7 @@ func (eh *EventClient) SetPreData(eventID int64, data interface{}) {
 
 // SetCurData set inst current data
 func (eh *EventClient) SetCurData(eventID int64, data interface{}) {
+	data = maskEventData(data)
 	item, ok := eh.cache[eventID]
 	if !ok {
 		item = metadata.EventData{
@@ -13,6 +13,9 @@
 package instances
 
 import (
+	"strconv"
+	"time"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
@@ -22,6 +25,7 @@ import (
 	"configcenter/src/common/language"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/dal"
@@ -64,6 +68,17 @@ func (m *instanceManager) CreateModelInstance(kit *rest.Kit, objID string, input
 		blog.Errorf("CreateModelInstance failed, valid error: %+v, rid: %s", err, rid)
 		return nil, err
 	}
+	attrs, err := m.getObjectAttributes(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+	if err := encryptSecretValues(kit, attrs.secret, inputParam.Data); err != nil {
+		return nil, err
+	}
+	if err := m.fillComputedValues(kit, objID, attrs.computed, inputParam.Data); err != nil {
+		blog.Errorf("CreateModelInstance failed, evaluate computed values failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
 	id, err := m.save(kit, objID, inputParam.Data)
 	if err != nil {
 		blog.ErrorJSON("CreateModelInstance create objID(%s) instance error. err:%s, data:%s, rid:%s", objID, err.Error(), inputParam.Data, kit.Rid)
@@ -84,6 +99,10 @@ func (m *instanceManager) CreateModelInstance(kit *rest.Kit, objID string, input
 func (m *instanceManager) CreateManyModelInstance(kit *rest.Kit, objID string, inputParam metadata.CreateManyModelInstance) (*metadata.CreateManyDataResult, error) {
 	var newIDs []uint64
 	dataResult := &metadata.CreateManyDataResult{}
+	attrs, err := m.getObjectAttributes(kit, objID)
+	if err != nil {
+		return nil, err
+	}
 	for itemIdx, item := range inputParam.Datas {
 		item.Set(common.BKOwnerIDField, kit.SupplierAccount)
 		err := m.validCreateInstanceData(kit, objID, item)
@@ -96,6 +115,24 @@ func (m *instanceManager) CreateManyModelInstance(kit *rest.Kit, objID string, i
 			})
 			continue
 		}
+		if err := encryptSecretValues(kit, attrs.secret, item); err != nil {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
+		if err := m.fillComputedValues(kit, objID, attrs.computed, item); err != nil {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
 		item.Set(common.BKOwnerIDField, kit.SupplierAccount)
 		id, err := m.save(kit, objID, item)
 		if nil != err {
@@ -117,7 +154,7 @@ func (m *instanceManager) CreateManyModelInstance(kit *rest.Kit, objID string, i
 	instIDFieldName := common.GetInstIDField(objID)
 	// 处理事件数据的
 	eh := m.NewEventClient(objID)
-	err := eh.SetCurDataAndPush(kit, objID, metadata.EventActionCreate, condition.CreateCondition().Field(instIDFieldName).In(newIDs).ToMapStr())
+	err = eh.SetCurDataAndPush(kit, objID, metadata.EventActionCreate, condition.CreateCondition().Field(instIDFieldName).In(newIDs).ToMapStr())
 	if err != nil {
 		blog.ErrorJSON("CreateManyModelInstance  event push instance current data error. err:%s, objID:%s inst id:%s, rid:%s", err, objID, newIDs, kit.Rid)
 		return dataResult, err
@@ -157,6 +194,7 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 		inputParam.Condition.Set(metadata.BKMetadata, instMedataData)
 	}
 
+	instIDs := make([]int64, 0, len(origins))
 	for _, origin := range origins {
 		instIDI := origin[instIDFieldName]
 		instID, _ := util.GetInt64ByInterface(instIDI)
@@ -167,6 +205,15 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 		}
 		// 设置实例变更前数据
 		eh.SetPreData(instID, origin)
+		instIDs = append(instIDs, instID)
+	}
+
+	attrs, err := m.getObjectAttributes(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+	if err := encryptSecretValues(kit, attrs.secret, inputParam.Data); err != nil {
+		return nil, err
 	}
 
 	err = m.update(kit, objID, inputParam.Data, inputParam.Condition)
@@ -174,6 +221,18 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 		blog.ErrorJSON("UpdateModelInstance update objID(%s) inst error. err:%s, condition:%s, rid:%s", objID, inputParam.Condition, kit.Rid)
 		return nil, err
 	}
+
+	// re-evaluate the computed values of the updated instances before the event is pushed, while the instances
+	// depend on them are refreshed in background, since there may be a large number of them.
+	refreshCond := mapstr.MapStr{instIDFieldName: mapstr.MapStr{common.BKDBIN: instIDs}}
+	if err := m.refreshComputedValues(kit, objID, attrs.computed, refreshCond, nil); err != nil {
+		blog.Errorf("UpdateModelInstance refresh computed values failed, objID: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+	if err := m.queueComputedRefresh(kit, objID, instIDs, true); err != nil {
+		blog.Errorf("UpdateModelInstance queue dependent computed values refresh failed, objID: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
 	err = eh.SetCurDataAndPush(kit, objID, metadata.EventActionUpdate, inputParam.Condition)
 	if err != nil {
 		blog.ErrorJSON("UpdateModelInstance  event push instance current data error. err:%s, condition:%s, rid:%s", err, inputParam.Condition, kit.Rid)
@@ -198,6 +257,13 @@ func (m *instanceManager) SearchModelInstance(kit *rest.Kit, objID string, input
 		}
 		inputParam.Condition[common.BKObjIDField] = objID
 	}
+	if inputParam.Filter != nil {
+		filterCond, err := m.filterToCondition(kit, objID, inputParam.Filter)
+		if err != nil {
+			return nil, err
+		}
+		inputParam.Condition = mapstr.MapStr{common.BKDBAND: []interface{}{inputParam.Condition, filterCond}}
+	}
 	inputParam.Condition = util.SetQueryOwner(inputParam.Condition, kit.SupplierAccount)
 
 	instItems := make([]mapstr.MapStr, 0)
@@ -220,6 +286,22 @@ func (m *instanceManager) SearchModelInstance(kit *rest.Kit, objID string, input
 		return nil, instErr
 	}
 
+	if len(instItems) > 0 {
+		attrs, err := m.getObjectAttributes(kit, objID)
+		if err != nil {
+			return nil, err
+		}
+		// the computed values that are not saved yet are evaluated on read, which needs all the fields of the instance
+		if len(inputParam.Fields) == 0 {
+			if err := m.fillMissingComputedValues(kit, objID, attrs.computed, instItems); err != nil {
+				blog.Errorf("search instance evaluate computed values failed, err: %v, rid: %s", err, kit.Rid)
+				return nil, err
+			}
+		}
+		maskSecretValues(attrs.secret, instItems)
+		attrs.denormalizeNetworkValues(instItems)
+	}
+
 	count, countErr := m.dbProxy.Table(tableName).Find(inputParam.Condition).Count(kit.Ctx)
 	if countErr != nil {
 		blog.Errorf("count instance error [%v], rid: %s", countErr, kit.Rid)
@@ -234,6 +316,34 @@ func (m *instanceManager) SearchModelInstance(kit *rest.Kit, objID string, input
 	return dataResult, nil
 }
 
+// filterToCondition convert the query filter to db condition with the model's attribute types
+func (m *instanceManager) filterToCondition(kit *rest.Kit, objID string, filter *querybuilder.QueryFilter) (
+	map[string]interface{}, error) {
+
+	attributes, err := m.dependent.SelectObjectAttWithParams(kit, objID, 0)
+	if err != nil {
+		blog.Errorf("search model %s attributes failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+
+	if key, err := filter.Validate(); err != nil {
+		blog.Errorf("search instance filter is invalid, key: %s, err: %v, rid: %s", key, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "filter."+key)
+	}
+
+	fieldTypes := metadata.GetQueryFieldTypes(attributes)
+	if _, exist := fieldTypes[common.GetInstIDField(objID)]; !exist {
+		fieldTypes[common.GetInstIDField(objID)] = common.FieldTypeInt
+	}
+
+	cond, key, err := filter.ToMgoWithFieldTypes(fieldTypes)
+	if err != nil {
+		blog.Errorf("convert instance filter failed, key: %s, err: %v, rid: %s", key, err, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "filter."+key)
+	}
+	return cond, nil
+}
+
 func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error) {
 	tableName := common.GetInstTableName(objID)
 	instIDFieldName := common.GetInstIDField(objID)
@@ -262,6 +372,10 @@ func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string, input
 		}
 		eh.SetPreData(instID, origin)
 	}
+	if err := m.dependent.RecycleInstances(kit, objID, origins); err != nil {
+		blog.Errorf("DeleteModelInstance recycle objID(%s) instances failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return &metadata.DeletedCount{}, err
+	}
 	err = m.dbProxy.Table(tableName).Delete(kit.Ctx, inputParam.Condition)
 	if nil != err {
 		blog.ErrorJSON("DeleteModelInstance delete objID(%s) instance error. err:%s, coniditon:%s, rid:%s", objID, err.Error(), inputParam.Condition, kit.Rid)
@@ -303,3 +417,53 @@ func (m *instanceManager) CascadeDeleteModelInstance(kit *rest.Kit, objID string
 	}
 	return &metadata.DeletedCount{Count: uint64(len(origins))}, nil
 }
+
+// RestoreModelInstance saves the deleted instance back with its original id, the instance is validated against
+// the unique constraints again, since other instances may have taken its unique values after it's deleted.
+func (m *instanceManager) RestoreModelInstance(kit *rest.Kit, objID string, data mapstr.MapStr) error {
+	bizID, err := FetchBizIDFromInstance(objID, data)
+	if err != nil {
+		blog.Errorf("RestoreModelInstance failed, FetchBizIDFromInstance failed, err: %+v, rid: %s", err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, "bk_biz_id")
+	}
+	valid, err := NewValidator(kit, m.dependent, objID, bizID, m.language)
+	if nil != err {
+		blog.Errorf("RestoreModelInstance failed, init validator failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+	var instMetadata metadata.Metadata
+	instMetadata.Label = make(metadata.Label)
+	if _, exist := data[metadata.BKMetadata]; exist && bizID != 0 {
+		instMetadata.Label.Set(metadata.LabelBusinessID, strconv.FormatInt(bizID, 10))
+	}
+	if err := valid.validCreateUnique(kit, data, instMetadata, m); err != nil {
+		return err
+	}
+
+	attrs, err := m.getObjectAttributes(kit, objID)
+	if err != nil {
+		return err
+	}
+	if err := m.fillComputedValues(kit, objID, attrs.computed, data); err != nil {
+		blog.Errorf("RestoreModelInstance evaluate computed values failed, err: %v, rid: %s", err, kit.Rid)
+		return err
+	}
+
+	if objID == common.BKInnerObjIDHost {
+		data = metadata.ConvertHostSpecialStringToArray(data)
+	}
+	data.Set(common.LastTimeField, time.Now())
+	if err := m.dbProxy.Table(common.GetInstTableName(objID)).Insert(kit.Ctx, data); err != nil {
+		blog.ErrorJSON("RestoreModelInstance restore objID(%s) instance error. err:%s, data:%s, rid:%s", objID, err.Error(), data, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	instIDFieldName := common.GetInstIDField(objID)
+	eh := m.NewEventClient(objID)
+	err = eh.SetCurDataAndPush(kit, objID, metadata.EventActionCreate, mapstr.MapStr{instIDFieldName: data[instIDFieldName]})
+	if err != nil {
+		blog.ErrorJSON("RestoreModelInstance event push instance current data error. err:%s, objID:%s, data:%s, rid:%s", err, objID, data, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCoreServiceEventPushEventFailed)
+	}
+	return nil
+}
@@ -108,6 +108,7 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 		blog.Errorf("init validator failed %s, rid: %s", err.Error(), kit.Rid)
 		return err
 	}
+	FillDefaultValues(instanceData, valid.propertySlice)
 	for _, key := range valid.requireFields {
 		if _, ok := instanceData[key]; !ok {
 			blog.Errorf("field [%s] in required for model [%s], input data: %+v, rid: %s", key, objID, instanceData, kit.Rid)
@@ -144,6 +145,11 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 			// blog.Errorf("field [%s] is not a valid property for model [%s], rid: %s", key, objID, kit.Rid)
 			// return valid.errif.CCErrorf(common.CCErrCommParamsIsInvalid, key)
 		}
+		if property.PropertyType == common.FieldTypeComputed || isMaskedSecret(property, val) {
+			// the computed value is evaluated by server, and the masked secret means it is not changed
+			delete(instanceData, key)
+			continue
+		}
 		if value, ok := val.(string); ok {
 			val = strings.TrimSpace(value)
 			instanceData[key] = val
@@ -154,11 +160,16 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 			blog.Errorf("validCreateInstanceData failed, key: %s, value: %s, err: %s, rid: %s", key, val, kit.CCError.Error(rawErr.ErrCode), kit.Rid)
 			return rawErr.ToCCError(kit.CCError)
 		}
+		instanceData[key] = normalizePropertyValue(kit.Ctx, property, val)
 	}
 	if instanceData.Exists(metadata.BKMetadata) {
 		instanceData.Set(metadata.BKMetadata, instMedataData)
 	}
 
+	if err := m.validInstanceRules(kit, objID, instanceData); err != nil {
+		return err
+	}
+
 	// module instance's name must coincide with template
 	if objID == common.BKInnerObjIDModule {
 		if err := m.validateModuleCreate(kit, instanceData, valid); err != nil {
@@ -277,7 +288,8 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, i
 		}
 
 		property, ok := valid.properties[key]
-		if !ok || (!property.IsEditable && !canEditAll) {
+		if !ok || (!property.IsEditable && !canEditAll) || property.PropertyType == common.FieldTypeComputed ||
+			isMaskedSecret(property, val) {
 			delete(instanceData, key)
 			continue
 		}
@@ -289,11 +301,10 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, i
 		if rawErr.ErrCode != 0 {
 			return rawErr.ToCCError(kit.CCError)
 		}
+		instanceData[key] = normalizePropertyValue(kit.Ctx, property, val)
 	}
 
-	for key, val := range instanceData {
-		updateData[key] = val
-	}
+	updateData = mergeUpdateData(updateData, instanceData)
 	bizID, err = FetchBizIDFromInstance(objID, updateData)
 	if err != nil {
 		blog.ErrorJSON("validUpdateInstanceData failed, FetchBizIDFromInstance failed, err: %s, data: %s, rid: %s", err, updateData, kit.Rid)
@@ -307,9 +318,49 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, i
 		}
 	}
 
+	if err := m.validInstanceRules(kit, objID, updateData); err != nil {
+		return err
+	}
+
 	return valid.validUpdateUnique(kit, updateData, instMetaData, instID, m)
 }
 
+// mergeUpdateData merges the update data into the origin instance, the validation rules and the unique constraints
+// are checked with the merged instance, since the update data may only contain part of the fields.
+func mergeUpdateData(origin, data mapstr.MapStr) mapstr.MapStr {
+	merged := origin.Clone()
+	merged.Merge(data)
+	return merged
+}
+
+// validInstanceRules validates the instance with the model's validation rules
+func (m *instanceManager) validInstanceRules(kit *rest.Kit, objID string, instanceData mapstr.MapStr) error {
+	cond := util.SetQueryOwner(mapstr.MapStr{common.BKObjIDField: objID}, kit.SupplierAccount)
+	models := make([]metadata.Object, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjDes).Find(cond).Fields(metadata.ModelFieldValidationRules).
+		All(kit.Ctx, &models); err != nil {
+		blog.Errorf("search model %s validation rules failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	rules := make([]metadata.ValidationRule, 0)
+	for _, model := range models {
+		rules = append(rules, model.ValidationRules...)
+	}
+	return checkInstanceRules(kit, objID, rules, instanceData)
+}
+
+// checkInstanceRules checks the instance with the validation rules, the error of the first unsatisfied rule is returned
+func checkInstanceRules(kit *rest.Kit, objID string, rules []metadata.ValidationRule, instanceData mapstr.MapStr) error {
+	for _, rule := range rules {
+		if rawErr := rule.Check(kit.Ctx, instanceData); rawErr.ErrCode != 0 {
+			blog.Errorf("instance does not satisfy the validation rule %#v of %s, rid: %s", rule, objID, kit.Rid)
+			return rawErr.ToCCError(kit.CCError)
+		}
+	}
+	return nil
+}
+
 func (m *instanceManager) validMainlineInstanceName(kit *rest.Kit, objID string, instanceData mapstr.MapStr) error {
 	mainlineCond := map[string]interface{}{common.AssociationKindIDField: common.AssociationKindMainline}
 	mainlineAsst := make([]*metadata.Association, 0)
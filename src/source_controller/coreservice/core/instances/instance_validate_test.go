@@ -0,0 +1,97 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckInstanceRulesOnUpdate(t *testing.T) {
+	errFactory, err := errors.NewFactory("../../../../../resources/errors/")
+	require.NoError(t, err)
+	kit := &rest.Kit{
+		Rid:     "test_req_id",
+		Ctx:     context.Background(),
+		CCError: errFactory.CreateDefaultCCErrorIf("en"),
+	}
+
+	rules := []metadata.ValidationRule{
+		{Condition: `bk_state == "Production"`, Required: []string{"bk_bak_operator"}},
+		{Assert: "end_date > start_date"},
+	}
+	origin := mapstr.MapStr{
+		common.BKInstIDField: int64(1),
+		"bk_state":           "Testing",
+		"start_date":         int64(10),
+		"end_date":           int64(20),
+	}
+
+	testCases := []struct {
+		name    string
+		update  mapstr.MapStr
+		errCode int
+	}{
+		{name: "unrelated field", update: mapstr.MapStr{"bk_comment": "test"}},
+		{name: "valid end date", update: mapstr.MapStr{"end_date": int64(30)}},
+		{name: "valid both dates", update: mapstr.MapStr{"start_date": int64(30), "end_date": int64(40)}},
+		{
+			name:    "start date after the origin end date",
+			update:  mapstr.MapStr{"start_date": int64(30)},
+			errCode: common.CCErrCommValidationRuleFailed,
+		},
+		{
+			name:    "end date before the origin start date",
+			update:  mapstr.MapStr{"end_date": int64(5)},
+			errCode: common.CCErrCommValidationRuleFailed,
+		},
+		{
+			name:    "condition is satisfied by the update but the origin lacks the required field",
+			update:  mapstr.MapStr{"bk_state": "Production"},
+			errCode: common.CCErrCommValidationRuleRequired,
+		},
+		{
+			name:   "condition is satisfied and the required field is set",
+			update: mapstr.MapStr{"bk_state": "Production", "bk_bak_operator": "admin"},
+		},
+		{
+			name:    "required field is cleared",
+			update:  mapstr.MapStr{"bk_state": "Production", "bk_bak_operator": ""},
+			errCode: common.CCErrCommValidationRuleRequired,
+		},
+		{name: "assert is skipped when the field is cleared", update: mapstr.MapStr{"end_date": nil}},
+	}
+
+	for _, testCase := range testCases {
+		merged := mergeUpdateData(origin, testCase.update)
+		err := checkInstanceRules(kit, "test_obj", rules, merged)
+		if testCase.errCode == 0 {
+			require.NoError(t, err, testCase.name)
+			continue
+		}
+		require.Error(t, err, testCase.name)
+		require.Equal(t, testCase.errCode, err.(errors.CCErrorCoder).GetCode(), testCase.name)
+	}
+
+	// the origin instance is not changed by the merge
+	require.Equal(t, int64(10), origin["start_date"])
+	require.Equal(t, "Testing", origin["bk_state"])
+}
@@ -14,15 +14,18 @@ package instances_test
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
+	"configcenter/src/common"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
 	"configcenter/src/common/language"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/source_controller/coreservice/core/instances"
-	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 
 	"github.com/stretchr/testify/require"
@@ -32,41 +35,70 @@ type mockDependences struct {
 }
 
 // IsInstanceExist used to check if the  instances  asst exist
-func (s *mockDependences) IsInstAsstExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error) {
+func (s *mockDependences) IsInstAsstExist(kit *rest.Kit, objID string, instID uint64) (exists bool, err error) {
 	return false, nil
 }
 
 // DeleteInstAsst used to delete inst asst
-func (s *mockDependences) DeleteInstAsst(ctx core.ContextParams, objID string, instID uint64) error {
+func (s *mockDependences) DeleteInstAsst(kit *rest.Kit, objID string, instID uint64) error {
 	return nil
 }
 
 // SelectObjectAttWithParams select object att with params
-func (s *mockDependences) SelectObjectAttWithParams(ctx core.ContextParams, objID string) (attribute []metadata.Attribute, err error) {
+func (s *mockDependences) SelectObjectAttWithParams(kit *rest.Kit, objID string, bizID int64) (attribute []metadata.Attribute, err error) {
 	return nil, nil
 }
 
 // SearchUnique search unique attribute
-func (s *mockDependences) SearchUnique(ctx core.ContextParams, objID string) (uniqueAttr []metadata.ObjectUnique, err error) {
+func (s *mockDependences) SearchUnique(kit *rest.Kit, objID string) (uniqueAttr []metadata.ObjectUnique, err error) {
 	return nil, nil
 }
 
+// RecycleInstances moves the instances to be deleted into the recycle bin
+func (s *mockDependences) RecycleInstances(kit *rest.Kit, objID string, origins []mapstr.MapStr) error {
+	return nil
+}
+
+var (
+	testDB        *local.Mongo
+	testDBErr     error
+	testDBOnce    sync.Once
+	testMongoConf = local.MongoConf{
+		MaxOpenConns: 10,
+		MaxIdleConns: 1,
+		URI:          "mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb",
+		RsName:       "rs0",
+	}
+)
+
+// newInstances returns the instance operation on the test db, the test is skipped if the db is not reachable
 func newInstances(t *testing.T) core.InstanceOperation {
+	testDBOnce.Do(func() {
+		testDB, testDBErr = local.NewMgo(testMongoConf, time.Minute)
+		if testDBErr != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		_, testDBErr = testDB.Table(common.BKTableNameObjDes).Find(nil).Count(ctx)
+	})
+	if testDBErr != nil {
+		t.Skipf("test db is not reachable, err: %v", testDBErr)
+	}
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
+	lang, err := language.New("../../../../../resources/language/")
 	require.NoError(t, err)
-	return instances.New(db, &mockDependences{})
+	return instances.New(testDB, &mockDependences{}, nil, lang)
 }
 
-var defaultCtx = func() core.ContextParams {
-	err, _ := errors.New("../../../../../resources/errors/")
-	lan, _ := language.New("../../../../../resources/language/")
-	return core.ContextParams{
-		Context:         context.Background(),
-		ReqID:           "test_req_id",
-		SupplierAccount: "test_owner",
+var defaultKit = func() *rest.Kit {
+	errIf, _ := errors.NewFactory("../../../../../resources/errors/")
+	return &rest.Kit{
+		Rid:             "test_req_id",
+		Header:          make(map[string][]string),
+		Ctx:             context.Background(),
+		CCError:         errIf.CreateDefaultCCErrorIf("en"),
 		User:            "test_user",
-		Error:           err.CreateDefaultCCErrorIf("en"),
-		Lang:            lan.CreateDefaultCCLanguageIf("en"),
+		SupplierAccount: "test_owner",
 	}
 }()
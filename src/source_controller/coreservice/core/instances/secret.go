@@ -0,0 +1,349 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"regexp"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+
+	redis "gopkg.in/redis.v5"
+)
+
+const (
+	secretConfigPrefix = "secret."
+	// secretRotateInterval is the interval to re-encrypt the secret values with the current key
+	secretRotateInterval = time.Hour
+	// secretRotateBatchSize is the number of instances re-encrypted in one batch
+	secretRotateBatchSize = 200
+)
+
+// ParseSecretConfig parses the keyring of the secret attributes from the config map of coreservice, the keys
+// are configured like "v1:<base64 key>,v2:<base64 key>", and the current key is the one to encrypt the new
+// values. it returns nil if the keys are not configured, then the secret values can't be saved.
+func ParseSecretConfig(configMap map[string]string) (*secret.Keyring, error) {
+	keysConfig := configMap[secretConfigPrefix+"keys"]
+	if len(keysConfig) == 0 {
+		return nil, nil
+	}
+
+	keys, err := secret.ParseKeys(keysConfig)
+	if err != nil {
+		return nil, err
+	}
+	return secret.NewKeyring(configMap[secretConfigPrefix+"currentKey"], keys)
+}
+
+// encryptSecretValues encrypts the secret values of the instance before it's saved, the empty value is
+// saved as it is.
+func encryptSecretValues(kit *rest.Kit, propertyIDs []string, data mapstr.MapStr) error {
+	for _, propertyID := range propertyIDs {
+		value, ok := data[propertyID].(string)
+		if !ok || len(value) == 0 {
+			continue
+		}
+
+		ciphertext, err := secret.Default().Encrypt(value)
+		if err != nil {
+			blog.Errorf("encrypt secret field %s failed, err: %v, rid: %s", propertyID, err, kit.Rid)
+			if err == secret.ErrNoKey {
+				return kit.CCError.CCError(common.CCErrCoreServiceSecretKeyNotConfigured)
+			}
+			return kit.CCError.CCErrorf(common.CCErrCoreServiceSecretEncryptFailed, propertyID)
+		}
+		data[propertyID] = ciphertext
+	}
+	return nil
+}
+
+// isMaskedSecret returns true if the value is the mask of a secret attribute, it's returned by the search, such as
+// the exported instances are imported back, which means the secret is not changed, so it should not be saved.
+func isMaskedSecret(property metadata.Attribute, val interface{}) bool {
+	return property.PropertyType == common.FieldTypeSecret && val == secret.Mask
+}
+
+// maskSecretValues replaces the secret values of the instances with the mask, the plaintext can only
+// be got by RevealSecretValue.
+func maskSecretValues(propertyIDs []string, insts []mapstr.MapStr) {
+	for _, inst := range insts {
+		secret.MaskMapStr(inst, propertyIDs...)
+	}
+}
+
+// RevealSecretValue decrypts the secret attribute value of the instance
+func (m *instanceManager) RevealSecretValue(kit *rest.Kit, objID string, instID int64, propertyID string) (
+	*metadata.RevealSecretResult, error) {
+
+	attrCond := mapstr.MapStr{
+		common.BKObjIDField:      objID,
+		common.BKPropertyIDField: propertyID,
+	}
+	attrCond = util.SetQueryOwner(attrCond, kit.SupplierAccount)
+	attrs := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(attrCond).All(kit.Ctx, &attrs); err != nil {
+		blog.Errorf("search attribute %s of %s failed, err: %v, rid: %s", propertyID, objID, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(attrs) == 0 || attrs[0].PropertyType != common.FieldTypeSecret {
+		blog.Errorf("attribute %s of %s is not a secret attribute, rid: %s", propertyID, objID, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKPropertyIDField)
+	}
+
+	instCond := mapstr.MapStr{common.GetInstIDField(objID): instID}
+	instCond = util.SetQueryOwner(instCond, kit.SupplierAccount)
+	insts, _, err := m.getInsts(kit, objID, instCond)
+	if err != nil {
+		blog.Errorf("search instance %d of %s failed, err: %v, rid: %s", instID, objID, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(insts) == 0 {
+		blog.Errorf("instance %d of %s is not found, rid: %s", instID, objID, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+
+	result := &metadata.RevealSecretResult{
+		ObjectID:   objID,
+		InstID:     instID,
+		PropertyID: propertyID,
+	}
+	ciphertext, ok := insts[0][propertyID].(string)
+	if !ok || len(ciphertext) == 0 {
+		return result, nil
+	}
+
+	result.Value, err = secret.Default().Decrypt(ciphertext)
+	if err != nil {
+		blog.Errorf("decrypt secret field %s of %s instance %d failed, err: %v, rid: %s", propertyID, objID, instID,
+			err, kit.Rid)
+		if err == secret.ErrNoKey {
+			return nil, kit.CCError.CCError(common.CCErrCoreServiceSecretKeyNotConfigured)
+		}
+		return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceSecretDecryptFailed, propertyID)
+	}
+	return result, nil
+}
+
+// SecretRotateJob re-encrypts the secret values that are not encrypted by the current key on the master
+// coreservice, so that the old keys can be removed from the config after all the values are rotated. the
+// rotated instances are pushed as the update events and recorded by the audit logs.
+type SecretRotateJob struct {
+	instance *instanceManager
+	audit    core.AuditOperation
+	isMaster discovery.ServiceManageInterface
+}
+
+// NewSecretRotateJob creates the secret rotate job
+func NewSecretRotateJob(dbProxy dal.RDB, cache *redis.Client, audit core.AuditOperation,
+	isMaster discovery.ServiceManageInterface) *SecretRotateJob {
+
+	return &SecretRotateJob{
+		instance: &instanceManager{
+			dbProxy:  dbProxy,
+			EventCli: eventclient.NewClientViaRedis(cache, dbProxy),
+		},
+		audit:    audit,
+		isMaster: isMaster,
+	}
+}
+
+// Run starts the job in background
+func (j *SecretRotateJob) Run() {
+	go func() {
+		ticker := time.NewTicker(secretRotateInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !j.isMaster.IsMaster() {
+				blog.V(4).Infof("rotate secret values, but not master, skip.")
+				continue
+			}
+			j.rotate()
+		}
+	}()
+}
+
+func (j *SecretRotateJob) rotate() {
+	keyring := secret.Default()
+	if keyring == nil {
+		return
+	}
+
+	kit := newJobKit(common.BKSuperOwnerID, common.CCSystemOperatorUserName)
+	cond := mapstr.MapStr{common.BKPropertyTypeField: common.FieldTypeSecret}
+	attrs := make([]metadata.Attribute, 0)
+	if err := j.instance.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).
+		Fields(common.BKObjIDField, common.BKPropertyIDField).All(kit.Ctx, &attrs); err != nil {
+		blog.Errorf("rotate secret values, but search secret attributes failed, err: %v, rid: %s", err, kit.Rid)
+		return
+	}
+
+	// the attributes of the supplier accounts may be the same one
+	rotated := make(map[string]bool)
+	for _, attr := range attrs {
+		if rotated[attr.ObjectID+"."+attr.PropertyID] {
+			continue
+		}
+		rotated[attr.ObjectID+"."+attr.PropertyID] = true
+
+		count, err := j.rotateAttribute(kit, keyring, attr.ObjectID, attr.PropertyID)
+		if err != nil {
+			blog.Errorf("rotate secret values of %s.%s failed, err: %v, rid: %s", attr.ObjectID, attr.PropertyID,
+				err, kit.Rid)
+			continue
+		}
+		if count > 0 {
+			blog.Infof("rotated %d secret values of %s.%s to key %s, rid: %s", count, attr.ObjectID,
+				attr.PropertyID, keyring.CurrentKey(), kit.Rid)
+		}
+	}
+}
+
+// rotateAttribute re-encrypts the values of the secret attribute with the current key, it returns the
+// number of the rotated values.
+func (j *SecretRotateJob) rotateAttribute(kit *rest.Kit, keyring *secret.Keyring, objID, propertyID string) (
+	int, error) {
+
+	parentObjID, err := j.instance.getMainlineParentObjID(kit, objID)
+	if err != nil {
+		return 0, err
+	}
+	isMainline := parentObjID != ""
+
+	tableName := common.GetInstTableName(objID)
+	instIDField := common.GetInstIDField(objID)
+	filter := mapstr.MapStr{
+		propertyID: mapstr.MapStr{common.BKDBLIKE: "^" + regexp.QuoteMeta(secret.CipherPrefix)},
+	}
+	if tableName == common.BKTableNameBaseInst {
+		filter[common.BKObjIDField] = objID
+	}
+
+	count := 0
+	var lastID int64
+	for {
+		filter[instIDField] = mapstr.MapStr{common.BKDBGT: lastID}
+		query := j.instance.dbProxy.Table(tableName).Find(filter).Sort(instIDField).Limit(secretRotateBatchSize)
+		insts := make([]mapstr.MapStr, 0)
+		if objID == common.BKInnerObjIDHost {
+			hosts := make([]metadata.HostMapStr, 0)
+			if err := query.All(kit.Ctx, &hosts); err != nil {
+				return count, err
+			}
+			for _, host := range hosts {
+				insts = append(insts, mapstr.MapStr(host))
+			}
+		} else if err := query.All(kit.Ctx, &insts); err != nil {
+			return count, err
+		}
+
+		// the rotated instances are grouped by their supplier accounts, whose events and audit logs are saved
+		// with the kit of the supplier account.
+		changes := make(map[string][]mapstr.MapStr)
+		for _, inst := range insts {
+			instID, err := util.GetInt64ByInterface(inst[instIDField])
+			if err != nil {
+				return count, err
+			}
+			lastID = instID
+
+			ciphertext, _ := inst[propertyID].(string)
+			if !keyring.NeedRotate(ciphertext) {
+				continue
+			}
+			rotated, err := keyring.Rotate(ciphertext)
+			if err != nil {
+				return count, err
+			}
+
+			// the value is only replaced if it's not changed after it's read
+			cond := mapstr.MapStr{
+				instIDField: instID,
+				propertyID:  ciphertext,
+			}
+			if err := j.instance.dbProxy.Table(tableName).Update(kit.Ctx, cond,
+				mapstr.MapStr{propertyID: rotated}); err != nil {
+				return count, err
+			}
+			supplierAccount := util.GetStrByInterface(inst[common.BKOwnerIDField])
+			changes[supplierAccount] = append(changes[supplierAccount], inst)
+			count++
+		}
+
+		for supplierAccount, origins := range changes {
+			ownerKit := newJobKit(supplierAccount, common.CCSystemOperatorUserName)
+			if err := j.saveRotateChanges(ownerKit, objID, propertyID, isMainline, origins); err != nil {
+				return count, err
+			}
+		}
+
+		if len(insts) < secretRotateBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// saveRotateChanges pushes the update events of the rotated instances and records their audit logs
+func (j *SecretRotateJob) saveRotateChanges(kit *rest.Kit, objID, propertyID string, isMainline bool,
+	origins []mapstr.MapStr) error {
+
+	instIDField := common.GetInstIDField(objID)
+	eh := j.instance.NewEventClient(objID)
+	instIDs := make([]int64, 0, len(origins))
+	for _, origin := range origins {
+		instID := getInt64Field(origin, instIDField)
+		eh.SetPreData(instID, origin)
+		instIDs = append(instIDs, instID)
+	}
+
+	cond := mapstr.MapStr{instIDField: mapstr.MapStr{common.BKDBIN: instIDs}}
+	insts, _, err := j.instance.getInsts(kit, objID, cond)
+	if err != nil {
+		blog.Errorf("search rotated instances of %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return err
+	}
+	curInsts := make(map[int64]mapstr.MapStr)
+	for _, inst := range insts {
+		curInsts[getInt64Field(inst, instIDField)] = inst
+	}
+
+	auditLogs := make([]metadata.AuditLog, 0, len(origins))
+	for _, origin := range origins {
+		instID := getInt64Field(origin, instIDField)
+		curData, exist := curInsts[instID]
+		if !exist {
+			continue
+		}
+		auditLogs = append(auditLogs, newJobUpdateAuditLog(objID, isMainline, instID, origin, curData))
+	}
+
+	if err := eh.SetCurDataAndPush(kit, objID, metadata.EventActionUpdate, cond); err != nil {
+		blog.Errorf("push secret %s.%s rotate events failed, err: %v, rid: %s", objID, propertyID, err, kit.Rid)
+		return err
+	}
+	if err := j.audit.CreateAuditLog(kit, auditLogs...); err != nil {
+		blog.Errorf("save secret %s.%s rotate audit logs failed, err: %v, rid: %s", objID, propertyID, err,
+			kit.Rid)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyring(t *testing.T) *secret.Keyring {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	keyring, err := secret.NewKeyring("v1", map[string]string{"v1": key})
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestEncryptSecretValues(t *testing.T) {
+	errFactory, err := errors.NewFactory("../../../../../resources/errors/")
+	require.NoError(t, err)
+	kit := &rest.Kit{
+		Rid:     "test_req_id",
+		Ctx:     context.Background(),
+		CCError: errFactory.CreateDefaultCCErrorIf("en"),
+	}
+
+	keyring := newTestKeyring(t)
+	secret.SetDefault(keyring)
+	defer secret.SetDefault(nil)
+
+	data := mapstr.MapStr{
+		common.BKInstNameField: "server",
+		"password":             "plaintext",
+		"token":                "",
+	}
+	require.NoError(t, encryptSecretValues(kit, []string{"password", "token", "not_exist"}, data))
+	require.Equal(t, "server", data[common.BKInstNameField])
+	require.Equal(t, "", data["token"])
+	require.NotContains(t, data, "not_exist")
+	require.True(t, secret.IsEncrypted(data["password"]))
+	plaintext, err := keyring.Decrypt(data["password"].(string))
+	require.NoError(t, err)
+	require.Equal(t, "plaintext", plaintext)
+
+	// the secret values can't be saved without the keys
+	secret.SetDefault(nil)
+	err = encryptSecretValues(kit, []string{"password"}, mapstr.MapStr{"password": "plaintext"})
+	require.Error(t, err)
+	require.Equal(t, common.CCErrCoreServiceSecretKeyNotConfigured, err.(errors.CCErrorCoder).GetCode())
+}
+
+func TestMaskSecretValues(t *testing.T) {
+	keyring := newTestKeyring(t)
+	ciphertext, err := keyring.Encrypt("plaintext")
+	require.NoError(t, err)
+
+	insts := []mapstr.MapStr{
+		{
+			common.BKInstNameField: "server",
+			"password":             ciphertext,
+			"token":                "",
+			"rows":                 []interface{}{mapstr.MapStr{"key": ciphertext}},
+		},
+		// the plaintext of the secret field is masked too, such as the ones saved before the attribute is secret
+		{common.BKInstNameField: "router", "password": "plaintext"},
+	}
+	maskSecretValues([]string{"password", "token"}, insts)
+
+	require.Equal(t, "server", insts[0][common.BKInstNameField])
+	require.Equal(t, secret.Mask, insts[0]["password"])
+	require.Equal(t, "", insts[0]["token"])
+	require.Equal(t, secret.Mask, insts[0]["rows"].([]interface{})[0].(mapstr.MapStr)["key"])
+	require.Equal(t, secret.Mask, insts[1]["password"])
+}
+
+func TestIsMaskedSecretOnImport(t *testing.T) {
+	keyring := newTestKeyring(t)
+	ciphertext, err := keyring.Encrypt("plaintext")
+	require.NoError(t, err)
+
+	secretAttr := metadata.Attribute{PropertyID: "password", PropertyType: common.FieldTypeSecret}
+	charAttr := metadata.Attribute{PropertyID: "comment", PropertyType: common.FieldTypeSingleChar}
+
+	// the exported instance is imported back, the masked secret is not changed, so it's not saved
+	exported := []mapstr.MapStr{{"password": ciphertext, "comment": secret.Mask}}
+	maskSecretValues([]string{secretAttr.PropertyID}, exported)
+	require.True(t, isMaskedSecret(secretAttr, exported[0]["password"]))
+
+	// the same string of the other attributes is a normal value
+	require.False(t, isMaskedSecret(charAttr, exported[0]["comment"]))
+	// the changed secret is saved
+	require.False(t, isMaskedSecret(secretAttr, "new password"))
+	require.False(t, isMaskedSecret(secretAttr, ""))
+}
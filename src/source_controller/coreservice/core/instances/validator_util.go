@@ -16,6 +16,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
@@ -190,6 +191,29 @@ func parseFloatOption(ctx context.Context, val interface{}) FloatOption {
 	return floatOption
 }
 
+// FillDefaultValues set the default values of the attributes that are not set in the instance to create, the literal
+// default values are set first so that they can be used by the templates.
+func FillDefaultValues(valData mapstr.MapStr, propertys []metadata.Attribute) {
+	templates := make([]metadata.Attribute, 0)
+	for _, field := range propertys {
+		if field.Default == nil {
+			continue
+		}
+		if val, ok := valData[field.PropertyID]; ok && val != nil {
+			continue
+		}
+		if metadata.IsDefaultTemplate(field.Default) {
+			templates = append(templates, field)
+			continue
+		}
+		valData[field.PropertyID] = field.Default
+	}
+
+	for _, field := range templates {
+		valData[field.PropertyID] = field.GetDefaultValue(valData)
+	}
+}
+
 // FillLostedFieldValue fill the value in inst map data
 func FillLostedFieldValue(ctx context.Context, valData mapstr.MapStr, propertys []metadata.Attribute) {
 	rid := util.ExtractRequestIDFromContext(ctx)
@@ -229,6 +253,26 @@ func FillLostedFieldValue(ctx context.Context, valData mapstr.MapStr, propertys
 				} else {
 					valData[field.PropertyID] = nil
 				}
+			case common.FieldTypeMultiEnum:
+				enumOptions, err := metadata.ParseEnumOption(ctx, field.Option)
+				if err != nil {
+					blog.Warnf("ParseEnumOption failed: %v, rid: %s", err, rid)
+					valData[field.PropertyID] = nil
+					continue
+				}
+				defaultIDs := make([]interface{}, 0)
+				for _, k := range enumOptions {
+					if k.IsDefault {
+						defaultIDs = append(defaultIDs, k.ID)
+					}
+				}
+				if len(defaultIDs) > 0 {
+					valData[field.PropertyID] = defaultIDs
+				} else {
+					valData[field.PropertyID] = nil
+				}
+			case common.FieldTypeTable:
+				valData[field.PropertyID] = nil
 			case common.FieldTypeDate:
 				valData[field.PropertyID] = nil
 			case common.FieldTypeTime:
@@ -248,6 +292,66 @@ func FillLostedFieldValue(ctx context.Context, valData mapstr.MapStr, propertys
 	}
 }
 
+// normalizePropertyValue convert the validated value of the attribute to the form which it's stored in.
+// the value of ipv4, ipv6 and cidr attribute is normalized so that the values can be compared and sorted
+// as strings, and the lost sub-columns of each table row is filled like the instance's lost fields.
+func normalizePropertyValue(ctx context.Context, property metadata.Attribute, val interface{}) interface{} {
+	switch property.PropertyType {
+	case common.FieldTypeIPv4, common.FieldTypeIPv6, common.FieldTypeCIDR:
+		valStr, ok := val.(string)
+		if !ok || valStr == "" {
+			return val
+		}
+		normalized, err := util.NormalizeNetworkValue(property.PropertyType, valStr)
+		if err != nil {
+			return val
+		}
+		return normalized
+	case common.FieldTypeTable:
+		return normalizeTableValue(ctx, property, val)
+	default:
+		return val
+	}
+}
+
+// normalizeTableValue fill the lost sub-columns of the table rows, and normalize the value of each sub-column
+func normalizeTableValue(ctx context.Context, property metadata.Attribute, val interface{}) interface{} {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	rows, ok := val.([]interface{})
+	if !ok || len(rows) == 0 {
+		return val
+	}
+
+	columns, err := metadata.ParseTableOption(ctx, property.Option)
+	if err != nil {
+		blog.Warnf("ParseTableOption failed: %v, rid: %s", err, rid)
+		return val
+	}
+	columnAttrs := make([]metadata.Attribute, len(columns))
+	for idx, column := range columns {
+		columnAttrs[idx] = column.ToAttribute()
+	}
+
+	normalized := make([]interface{}, 0, len(rows))
+	for _, item := range rows {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			normalized = append(normalized, item)
+			continue
+		}
+		rowData := mapstr.MapStr(row)
+		FillLostedFieldValue(ctx, rowData, columnAttrs)
+		for _, columnAttr := range columnAttrs {
+			if value, ok := rowData[columnAttr.PropertyID].(string); ok {
+				rowData[columnAttr.PropertyID] = strings.TrimSpace(value)
+			}
+			rowData[columnAttr.PropertyID] = normalizePropertyValue(ctx, columnAttr, rowData[columnAttr.PropertyID])
+		}
+		normalized = append(normalized, rowData)
+	}
+	return normalized
+}
+
 func isEmpty(value interface{}) bool {
 	return value == nil || value == ""
 }
@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTableValue(t *testing.T) {
+	property := metadata.Attribute{
+		PropertyID:   "disks",
+		PropertyType: common.FieldTypeTable,
+		Option: []interface{}{
+			map[string]interface{}{
+				common.BKPropertyIDField:   "name",
+				common.BKPropertyNameField: "Name",
+				common.BKPropertyTypeField: common.FieldTypeSingleChar,
+			},
+			map[string]interface{}{
+				common.BKPropertyIDField:   "size",
+				common.BKPropertyNameField: "Size",
+				common.BKPropertyTypeField: common.FieldTypeInt,
+			},
+			map[string]interface{}{
+				common.BKPropertyIDField:   "ip",
+				common.BKPropertyNameField: "IP",
+				common.BKPropertyTypeField: common.FieldTypeIPv4,
+			},
+		},
+	}
+	normalizedIP, err := util.NormalizeIPv4("10.0.0.1")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{name: "nil", value: nil, expected: nil},
+		{name: "not array", value: "sda", expected: "sda"},
+		{name: "empty", value: []interface{}{}, expected: []interface{}{}},
+		{
+			name: "rows",
+			value: []interface{}{
+				map[string]interface{}{"name": " sda ", "size": 100, "ip": " 10.0.0.1"},
+				map[string]interface{}{"name": "sdb"},
+			},
+			expected: []interface{}{
+				mapstr.MapStr{"name": "sda", "size": 100, "ip": normalizedIP},
+				mapstr.MapStr{"name": "sdb", "size": nil, "ip": nil},
+			},
+		},
+		{
+			name:     "invalid network value is kept",
+			value:    []interface{}{map[string]interface{}{"name": "sda", "size": 1, "ip": "10.0.0.256"}},
+			expected: []interface{}{mapstr.MapStr{"name": "sda", "size": 1, "ip": "10.0.0.256"}},
+		},
+		{
+			name:     "not object row is kept",
+			value:    []interface{}{"sda", map[string]interface{}{"name": "sdb", "size": 1, "ip": ""}},
+			expected: []interface{}{"sda", mapstr.MapStr{"name": "sdb", "size": 1, "ip": ""}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		actual := normalizeTableValue(context.Background(), property, testCase.value)
+		require.Equal(t, testCase.expected, actual, testCase.name)
+	}
+
+	property.Option = "not a table option"
+	rows := []interface{}{map[string]interface{}{"name": " sda "}}
+	require.Equal(t, rows, normalizeTableValue(context.Background(), property, rows))
+}
+
+func TestFillDefaultValues(t *testing.T) {
+	attributes := []metadata.Attribute{
+		{PropertyID: "bk_inst_name", PropertyType: common.FieldTypeSingleChar},
+		{PropertyID: "bk_env", PropertyType: common.FieldTypeSingleChar, Default: "prod"},
+		{PropertyID: "bk_cpu", PropertyType: common.FieldTypeInt, Default: int64(8)},
+		{PropertyID: "bk_alias", PropertyType: common.FieldTypeSingleChar, Default: "{{bk_inst_name}}-{{bk_env}}"},
+		{PropertyID: "bk_comment", PropertyType: common.FieldTypeLongChar, Default: "{{bk_cpu}} cores, {{bk_unknown}}"},
+	}
+
+	testCases := []struct {
+		name     string
+		data     mapstr.MapStr
+		expected mapstr.MapStr
+	}{
+		{
+			name: "all defaults",
+			data: mapstr.MapStr{"bk_inst_name": "web"},
+			expected: mapstr.MapStr{"bk_inst_name": "web", "bk_env": "prod", "bk_cpu": int64(8),
+				"bk_alias": "web-prod", "bk_comment": "8 cores, "},
+		},
+		{
+			name: "the set values are kept and used by the templates",
+			data: mapstr.MapStr{"bk_inst_name": "db", "bk_env": "test", "bk_cpu": 16, "bk_alias": "db01"},
+			expected: mapstr.MapStr{"bk_inst_name": "db", "bk_env": "test", "bk_cpu": 16, "bk_alias": "db01",
+				"bk_comment": "16 cores, "},
+		},
+		{
+			name: "the null value is filled",
+			data: mapstr.MapStr{"bk_env": nil, "bk_alias": nil},
+			expected: mapstr.MapStr{"bk_env": "prod", "bk_cpu": int64(8), "bk_alias": "-prod",
+				"bk_comment": "8 cores, "},
+		},
+	}
+
+	for _, testCase := range testCases {
+		FillDefaultValues(testCase.data, attributes)
+		require.Equal(t, testCase.expected, testCase.data, testCase.name)
+	}
+}
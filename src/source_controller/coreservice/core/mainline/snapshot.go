@@ -0,0 +1,397 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mainline
+
+import (
+	"encoding/json"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
+	"configcenter/src/common/util"
+)
+
+const (
+	// topoSnapshotHostBatchSize is the max number of hosts read from db at a time when taking a snapshot
+	topoSnapshotHostBatchSize = 500
+	// topoSnapshotNodeBatchSize is the max number of snapshot nodes saved to db at a time
+	topoSnapshotNodeBatchSize = 1000
+)
+
+// CreateTopoSnapshot takes a snapshot of the business topology on demand
+func (m *topoManager) CreateTopoSnapshot(kit *rest.Kit, bizID int64, option metadata.CreateTopoSnapshotOption) (*metadata.TopoSnapshot, errors.CCErrorCoder) {
+	if field, ok := option.Validate(); !ok {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+	return m.takeTopoSnapshot(kit, bizID, option.Name, metadata.TopoSnapshotManual)
+}
+
+func (m *topoManager) takeTopoSnapshot(kit *rest.Kit, bizID int64, name string, trigger metadata.TopoSnapshotTrigger) (*metadata.TopoSnapshot, errors.CCErrorCoder) {
+	nodes, ccErr := m.captureTopoNodes(kit, bizID)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+
+	id, err := m.DbProxy.NextSequence(kit.Ctx, common.BKTableNameTopoSnapshot)
+	if err != nil {
+		blog.Errorf("take topo snapshot failed, generate id failed, err: %+v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	snapshot := &metadata.TopoSnapshot{
+		ID:              int64(id),
+		BizID:           bizID,
+		Name:            name,
+		Trigger:         trigger,
+		NodeCount:       int64(len(nodes)),
+		Creator:         kit.User,
+		CreateTime:      metadata.Now(),
+		SupplierAccount: kit.SupplierAccount,
+	}
+
+	// the nodes are saved before the snapshot, so that a snapshot is never listed with part of its nodes
+	for idx := range nodes {
+		nodes[idx].SnapshotID = snapshot.ID
+	}
+	for start := 0; start < len(nodes); start += topoSnapshotNodeBatchSize {
+		end := start + topoSnapshotNodeBatchSize
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+		if err := m.DbProxy.Table(common.BKTableNameTopoSnapshotNode).Insert(kit.Ctx, nodes[start:end]); err != nil {
+			blog.Errorf("take topo snapshot of business %d failed, db insert nodes failed, err: %+v, rid: %s", bizID, err, kit.Rid)
+			m.removeTopoSnapshotNodes(kit, snapshot.ID)
+			return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+		}
+	}
+
+	if err := m.DbProxy.Table(common.BKTableNameTopoSnapshot).Insert(kit.Ctx, snapshot); err != nil {
+		blog.Errorf("take topo snapshot of business %d failed, db insert failed, err: %+v, rid: %s", bizID, err, kit.Rid)
+		m.removeTopoSnapshotNodes(kit, snapshot.ID)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return snapshot, nil
+}
+
+// captureTopoNodes reads the current mainline topology of the business and the hosts in its modules
+func (m *topoManager) captureTopoNodes(kit *rest.Kit, bizID int64) ([]metadata.TopoSnapshotNode, errors.CCErrorCoder) {
+	root, err := m.SearchMainlineInstanceTopo(kit.Ctx, kit.Header, bizID, true)
+	if err != nil {
+		blog.Errorf("capture topo of business %d failed, search mainline instance topo failed, err: %+v, rid: %s", bizID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrTopoMainlineSelectFailed)
+	}
+
+	nodes := make([]metadata.TopoSnapshotNode, 0)
+	var walk func(node, parent *metadata.TopoInstanceNode) error
+	walk = func(node, parent *metadata.TopoInstanceNode) error {
+		data, err := normalizeSnapshotData(node.Detail)
+		if err != nil {
+			return err
+		}
+		snapshotNode := metadata.TopoSnapshotNode{
+			ObjectID:      node.ObjectID,
+			InstID:        node.InstanceID,
+			InstName:      node.InstanceName,
+			ParentInstIDs: make([]int64, 0),
+			Data:          data,
+		}
+		if parent != nil {
+			snapshotNode.ParentObjectID = parent.ObjectID
+			snapshotNode.ParentInstIDs = append(snapshotNode.ParentInstIDs, parent.InstanceID)
+		}
+		nodes = append(nodes, snapshotNode)
+
+		for _, child := range node.Children {
+			if err := walk(child, node); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root, nil); err != nil {
+		blog.Errorf("capture topo of business %d failed, normalize instance failed, err: %+v, rid: %s", bizID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommJSONMarshalFailed)
+	}
+
+	hostNodes, ccErr := m.captureTopoHostNodes(kit, bizID)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+	return append(nodes, hostNodes...), nil
+}
+
+// captureTopoHostNodes reads the hosts of the business, the parents of a host node are all its modules
+func (m *topoManager) captureTopoHostNodes(kit *rest.Kit, bizID int64) ([]metadata.TopoSnapshotNode, errors.CCErrorCoder) {
+	filter := util.SetQueryOwner(map[string]interface{}{common.BKAppIDField: bizID}, kit.SupplierAccount)
+	relations := make([]metadata.ModuleHost, 0)
+	err := m.DbProxy.Table(common.BKTableNameModuleHostConfig).Find(filter).
+		Fields(common.BKHostIDField, common.BKModuleIDField).All(kit.Ctx, &relations)
+	if err != nil {
+		blog.Errorf("capture hosts of business %d failed, db select failed, err: %+v, rid: %s", bizID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	hostModules := make(map[int64][]int64)
+	hostIDs := make([]int64, 0)
+	for _, relation := range relations {
+		if _, exist := hostModules[relation.HostID]; !exist {
+			hostIDs = append(hostIDs, relation.HostID)
+		}
+		hostModules[relation.HostID] = append(hostModules[relation.HostID], relation.ModuleID)
+	}
+	sort.Slice(hostIDs, func(i, j int) bool { return hostIDs[i] < hostIDs[j] })
+
+	nodes := make([]metadata.TopoSnapshotNode, 0, len(hostIDs))
+	for start := 0; start < len(hostIDs); start += topoSnapshotHostBatchSize {
+		end := start + topoSnapshotHostBatchSize
+		if end > len(hostIDs) {
+			end = len(hostIDs)
+		}
+		hostFilter := map[string]interface{}{
+			common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs[start:end]},
+		}
+		hosts := make([]mapstr.MapStr, 0)
+		if err := m.DbProxy.Table(common.BKTableNameBaseHost).Find(hostFilter).Sort(common.BKHostIDField).All(kit.Ctx, &hosts); err != nil {
+			blog.Errorf("capture hosts of business %d failed, db select failed, err: %+v, rid: %s", bizID, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		for _, host := range hosts {
+			hostID, err := host.Int64(common.BKHostIDField)
+			if err != nil {
+				blog.Errorf("capture hosts of business %d failed, parse host id failed, host: %+v, err: %+v, rid: %s", bizID, host, err, kit.Rid)
+				return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKHostIDField)
+			}
+			data, err := normalizeSnapshotData(host)
+			if err != nil {
+				blog.Errorf("capture hosts of business %d failed, normalize host failed, err: %+v, rid: %s", bizID, err, kit.Rid)
+				return nil, kit.CCError.CCError(common.CCErrCommJSONMarshalFailed)
+			}
+			moduleIDs := hostModules[hostID]
+			sort.Slice(moduleIDs, func(i, j int) bool { return moduleIDs[i] < moduleIDs[j] })
+			nodes = append(nodes, metadata.TopoSnapshotNode{
+				ObjectID:       common.BKInnerObjIDHost,
+				InstID:         hostID,
+				InstName:       util.GetStrByInterface(host[common.BKHostInnerIPField]),
+				ParentObjectID: common.BKInnerObjIDModule,
+				ParentInstIDs:  moduleIDs,
+				Data:           data,
+			})
+		}
+	}
+	return nodes, nil
+}
+
+// normalizeSnapshotData converts the instance to its json form, so that the values read from the snapshot
+// and from the current topology can be compared, the db id is dropped since it's meaningless and the secret
+// values are masked since the snapshots can be read by everyone who can read the business.
+func normalizeSnapshotData(detail map[string]interface{}) (map[string]interface{}, error) {
+	content, err := json.Marshal(detail)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, err
+	}
+	delete(data, "_id")
+	secret.MaskMapStr(data)
+	return data, nil
+}
+
+// ListTopoSnapshot lists the snapshots of the business without their nodes, the latest snapshots come first
+func (m *topoManager) ListTopoSnapshot(kit *rest.Kit, bizID int64, option metadata.ListTopoSnapshotOption) (*metadata.MultipleTopoSnapshot, errors.CCErrorCoder) {
+	if field, ok := option.Validate(); !ok {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{common.BKAppIDField: bizID}
+	if len(option.Trigger) > 0 {
+		filter["trigger"] = option.Trigger
+	}
+	filter = util.SetQueryOwner(filter, kit.SupplierAccount)
+
+	count, err := m.DbProxy.Table(common.BKTableNameTopoSnapshot).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("ListTopoSnapshot failed, db count failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	snapshots := make([]metadata.TopoSnapshot, 0)
+	err = m.DbProxy.Table(common.BKTableNameTopoSnapshot).Find(filter).Sort("-"+common.BKFieldID).
+		Start(uint64(option.Page.Start)).Limit(uint64(option.Page.Limit)).All(kit.Ctx, &snapshots)
+	if err != nil {
+		blog.Errorf("ListTopoSnapshot failed, db select failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return &metadata.MultipleTopoSnapshot{Count: int64(count), Info: snapshots}, nil
+}
+
+// getTopoSnapshot gets a snapshot of the business, it returns not found error if the snapshot doesn't exist
+func (m *topoManager) getTopoSnapshot(kit *rest.Kit, bizID int64, snapshotID int64) (*metadata.TopoSnapshot, errors.CCErrorCoder) {
+	filter := util.SetQueryOwner(map[string]interface{}{
+		common.BKFieldID:    snapshotID,
+		common.BKAppIDField: bizID,
+	}, kit.SupplierAccount)
+	snapshots := make([]metadata.TopoSnapshot, 0)
+	if err := m.DbProxy.Table(common.BKTableNameTopoSnapshot).Find(filter).All(kit.Ctx, &snapshots); err != nil {
+		blog.Errorf("get topo snapshot failed, db select failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(snapshots) == 0 {
+		return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+	return &snapshots[0], nil
+}
+
+// DeleteTopoSnapshot deletes a snapshot of the business and its nodes
+func (m *topoManager) DeleteTopoSnapshot(kit *rest.Kit, bizID int64, snapshotID int64) errors.CCErrorCoder {
+	if _, ccErr := m.getTopoSnapshot(kit, bizID, snapshotID); ccErr != nil {
+		return ccErr
+	}
+	return m.removeTopoSnapshot(kit, snapshotID)
+}
+
+// removeTopoSnapshot removes the snapshot first, so that a snapshot is never listed with part of its nodes
+func (m *topoManager) removeTopoSnapshot(kit *rest.Kit, snapshotID int64) errors.CCErrorCoder {
+	filter := map[string]interface{}{common.BKFieldID: snapshotID}
+	if err := m.DbProxy.Table(common.BKTableNameTopoSnapshot).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("remove topo snapshot %d failed, db delete failed, err: %+v, rid: %s", snapshotID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	if err := m.removeTopoSnapshotNodes(kit, snapshotID); err != nil {
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+func (m *topoManager) removeTopoSnapshotNodes(kit *rest.Kit, snapshotID int64) error {
+	filter := map[string]interface{}{"snapshot_id": snapshotID}
+	if err := m.DbProxy.Table(common.BKTableNameTopoSnapshotNode).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("remove nodes of topo snapshot %d failed, db delete failed, err: %+v, rid: %s", snapshotID, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// purgeDeletedBizTopoSnapshots removes the snapshots and the snapshot schedules of the deleted businesses
+func (m *topoManager) purgeDeletedBizTopoSnapshots(kit *rest.Kit) error {
+	bizIDs := make(map[int64]bool)
+	for _, table := range []string{common.BKTableNameTopoSnapshot, common.BKTableNameTopoSnapshotSchedule} {
+		values, err := m.DbProxy.Table(table).Distinct(kit.Ctx, common.BKAppIDField, map[string]interface{}{})
+		if err != nil {
+			blog.Errorf("get businesses of table %s failed, err: %v, rid: %s", table, err, kit.Rid)
+			return err
+		}
+		for _, value := range values {
+			bizID, err := util.GetInt64ByInterface(value)
+			if err != nil {
+				blog.Errorf("parse business id %v of table %s failed, err: %v, rid: %s", value, table, err, kit.Rid)
+				continue
+			}
+			bizIDs[bizID] = true
+		}
+	}
+	if len(bizIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(bizIDs))
+	for bizID := range bizIDs {
+		ids = append(ids, bizID)
+	}
+	filter := map[string]interface{}{common.BKAppIDField: map[string]interface{}{common.BKDBIN: ids}}
+	existBizs := make([]metadata.BizInst, 0)
+	if err := m.DbProxy.Table(common.BKTableNameBaseApp).Find(filter).Fields(common.BKAppIDField).All(kit.Ctx, &existBizs); err != nil {
+		blog.Errorf("find businesses %v failed, err: %v, rid: %s", ids, err, kit.Rid)
+		return err
+	}
+	for _, biz := range existBizs {
+		delete(bizIDs, biz.BizID)
+	}
+
+	for bizID := range bizIDs {
+		if err := m.removeBizTopoSnapshots(kit, bizID); err != nil {
+			return err
+		}
+		blog.Infof("business %d is deleted, its topo snapshots are purged, rid: %s", bizID, kit.Rid)
+	}
+	return nil
+}
+
+// removeBizTopoSnapshots removes the schedule first, so that no snapshot is taken while the snapshots are removed
+func (m *topoManager) removeBizTopoSnapshots(kit *rest.Kit, bizID int64) error {
+	filter := map[string]interface{}{common.BKAppIDField: bizID}
+	if err := m.DbProxy.Table(common.BKTableNameTopoSnapshotSchedule).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("remove topo snapshot schedule of business %d failed, err: %v, rid: %s", bizID, err, kit.Rid)
+		return err
+	}
+
+	snapshots := make([]metadata.TopoSnapshot, 0)
+	if err := m.DbProxy.Table(common.BKTableNameTopoSnapshot).Find(filter).Fields(common.BKFieldID).All(kit.Ctx, &snapshots); err != nil {
+		blog.Errorf("find topo snapshots of business %d failed, err: %v, rid: %s", bizID, err, kit.Rid)
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if ccErr := m.removeTopoSnapshot(kit, snapshot.ID); ccErr != nil {
+			return ccErr
+		}
+	}
+	return nil
+}
+
+// DiffTopoSnapshot compares two snapshots of the business, the current topology is compared with the from
+// snapshot if the to snapshot is not set.
+func (m *topoManager) DiffTopoSnapshot(kit *rest.Kit, bizID int64, option metadata.DiffTopoSnapshotOption) (*metadata.TopoSnapshotDiff, errors.CCErrorCoder) {
+	if field, ok := option.Validate(); !ok {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	fromNodes, ccErr := m.getTopoSnapshotNodes(kit, bizID, option.From)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+
+	var toNodes []metadata.TopoSnapshotNode
+	if option.To == 0 {
+		toNodes, ccErr = m.captureTopoNodes(kit, bizID)
+	} else {
+		toNodes, ccErr = m.getTopoSnapshotNodes(kit, bizID, option.To)
+	}
+	if ccErr != nil {
+		return nil, ccErr
+	}
+
+	diff := diffTopoSnapshotNodes(fromNodes, toNodes)
+	diff.From, diff.To = option.From, option.To
+	return diff, nil
+}
+
+func (m *topoManager) getTopoSnapshotNodes(kit *rest.Kit, bizID int64, snapshotID int64) ([]metadata.TopoSnapshotNode, errors.CCErrorCoder) {
+	if _, ccErr := m.getTopoSnapshot(kit, bizID, snapshotID); ccErr != nil {
+		return nil, ccErr
+	}
+
+	filter := map[string]interface{}{"snapshot_id": snapshotID}
+	nodes := make([]metadata.TopoSnapshotNode, 0)
+	if err := m.DbProxy.Table(common.BKTableNameTopoSnapshotNode).Find(filter).All(kit.Ctx, &nodes); err != nil {
+		blog.Errorf("get nodes of topo snapshot %d failed, db select failed, err: %+v, rid: %s", snapshotID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,153 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mainline
+
+import (
+	"encoding/json"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+// topoSnapshotIgnoredFields is the fields not compared between snapshots, they change with any update
+var topoSnapshotIgnoredFields = map[string]bool{
+	common.LastTimeField:   true,
+	common.CreateTimeField: true,
+}
+
+type topoSnapshotNodeKey struct {
+	objectID string
+	instID   int64
+}
+
+// diffTopoSnapshotNodes compares the nodes of two snapshots, the nodes are matched by their object and
+// instance id, so a node is moved if its parents are changed, and modified if its data is changed.
+func diffTopoSnapshotNodes(from, to []metadata.TopoSnapshotNode) *metadata.TopoSnapshotDiff {
+	diff := &metadata.TopoSnapshotDiff{
+		Added:    make([]metadata.TopoSnapshotNode, 0),
+		Removed:  make([]metadata.TopoSnapshotNode, 0),
+		Moved:    make([]metadata.TopoSnapshotNodeMove, 0),
+		Modified: make([]metadata.TopoSnapshotNodeChange, 0),
+	}
+
+	fromNodes := make(map[topoSnapshotNodeKey]metadata.TopoSnapshotNode, len(from))
+	for _, node := range from {
+		fromNodes[topoSnapshotNodeKey{objectID: node.ObjectID, instID: node.InstID}] = node
+	}
+
+	toKeys := make(map[topoSnapshotNodeKey]bool, len(to))
+	for _, node := range to {
+		key := topoSnapshotNodeKey{objectID: node.ObjectID, instID: node.InstID}
+		toKeys[key] = true
+
+		before, exist := fromNodes[key]
+		if !exist {
+			diff.Added = append(diff.Added, node)
+			continue
+		}
+
+		if before.ParentObjectID != node.ParentObjectID || !sameSnapshotParents(before.ParentInstIDs, node.ParentInstIDs) {
+			diff.Moved = append(diff.Moved, metadata.TopoSnapshotNodeMove{
+				ObjectID:           node.ObjectID,
+				InstID:             node.InstID,
+				InstName:           node.InstName,
+				FromParentObjectID: before.ParentObjectID,
+				FromParentInstIDs:  before.ParentInstIDs,
+				ToParentObjectID:   node.ParentObjectID,
+				ToParentInstIDs:    node.ParentInstIDs,
+			})
+		}
+
+		if fields := diffTopoSnapshotData(before.Data, node.Data); len(fields) > 0 {
+			diff.Modified = append(diff.Modified, metadata.TopoSnapshotNodeChange{
+				ObjectID: node.ObjectID,
+				InstID:   node.InstID,
+				InstName: node.InstName,
+				Fields:   fields,
+			})
+		}
+	}
+
+	for _, node := range from {
+		if !toKeys[topoSnapshotNodeKey{objectID: node.ObjectID, instID: node.InstID}] {
+			diff.Removed = append(diff.Removed, node)
+		}
+	}
+
+	sort.SliceStable(diff.Added, func(i, j int) bool {
+		return lessTopoSnapshotNode(diff.Added[i].ObjectID, diff.Added[i].InstID, diff.Added[j].ObjectID, diff.Added[j].InstID)
+	})
+	sort.SliceStable(diff.Removed, func(i, j int) bool {
+		return lessTopoSnapshotNode(diff.Removed[i].ObjectID, diff.Removed[i].InstID, diff.Removed[j].ObjectID, diff.Removed[j].InstID)
+	})
+	sort.SliceStable(diff.Moved, func(i, j int) bool {
+		return lessTopoSnapshotNode(diff.Moved[i].ObjectID, diff.Moved[i].InstID, diff.Moved[j].ObjectID, diff.Moved[j].InstID)
+	})
+	sort.SliceStable(diff.Modified, func(i, j int) bool {
+		return lessTopoSnapshotNode(diff.Modified[i].ObjectID, diff.Modified[i].InstID, diff.Modified[j].ObjectID, diff.Modified[j].InstID)
+	})
+	return diff
+}
+
+func lessTopoSnapshotNode(objectID1 string, instID1 int64, objectID2 string, instID2 int64) bool {
+	if objectID1 != objectID2 {
+		return objectID1 < objectID2
+	}
+	return instID1 < instID2
+}
+
+// sameSnapshotParents checks whether the parents are the same, the parents are sorted when they are captured
+func sameSnapshotParents(before, after []int64) bool {
+	if len(before) != len(after) {
+		return false
+	}
+	for idx := range before {
+		if before[idx] != after[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// diffTopoSnapshotData compares the fields of the node by their json form, so that the values read from db
+// and from the current topology are compared in the same way.
+func diffTopoSnapshotData(before, after map[string]interface{}) []metadata.TopoSnapshotFieldChange {
+	fields := make(map[string]bool)
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	changes := make([]metadata.TopoSnapshotFieldChange, 0)
+	for field := range fields {
+		if topoSnapshotIgnoredFields[field] {
+			continue
+		}
+		beforeValue, afterValue := before[field], after[field]
+		beforeJSON, beforeErr := json.Marshal(beforeValue)
+		afterJSON, afterErr := json.Marshal(afterValue)
+		if beforeErr == nil && afterErr == nil && string(beforeJSON) == string(afterJSON) {
+			continue
+		}
+		changes = append(changes, metadata.TopoSnapshotFieldChange{
+			Field:  field,
+			Before: beforeValue,
+			After:  afterValue,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
@@ -0,0 +1,125 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mainline
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newSnapshotNode(objectID string, instID int64, parentObjectID string, parents []int64, data map[string]interface{}) metadata.TopoSnapshotNode {
+	return metadata.TopoSnapshotNode{
+		ObjectID:       objectID,
+		InstID:         instID,
+		InstName:       objectID,
+		ParentObjectID: parentObjectID,
+		ParentInstIDs:  parents,
+		Data:           data,
+	}
+}
+
+func TestDiffTopoSnapshotNodes(t *testing.T) {
+	from := []metadata.TopoSnapshotNode{
+		newSnapshotNode(common.BKInnerObjIDApp, 1, "", []int64{}, map[string]interface{}{"bk_biz_name": "biz"}),
+		newSnapshotNode(common.BKInnerObjIDSet, 2, common.BKInnerObjIDApp, []int64{1}, map[string]interface{}{"bk_set_name": "set"}),
+		newSnapshotNode(common.BKInnerObjIDModule, 3, common.BKInnerObjIDSet, []int64{2}, map[string]interface{}{"bk_module_name": "old"}),
+		newSnapshotNode(common.BKInnerObjIDModule, 4, common.BKInnerObjIDSet, []int64{2}, map[string]interface{}{"bk_module_name": "removed"}),
+		newSnapshotNode(common.BKInnerObjIDHost, 10, common.BKInnerObjIDModule, []int64{3}, map[string]interface{}{
+			"bk_host_innerip":    "127.0.0.1",
+			"bk_cpu":             float64(4),
+			common.LastTimeField: "2020-07-20T00:00:00Z",
+		}),
+	}
+	to := []metadata.TopoSnapshotNode{
+		newSnapshotNode(common.BKInnerObjIDApp, 1, "", []int64{}, map[string]interface{}{"bk_biz_name": "biz"}),
+		newSnapshotNode(common.BKInnerObjIDSet, 2, common.BKInnerObjIDApp, []int64{1}, map[string]interface{}{"bk_set_name": "set"}),
+		newSnapshotNode(common.BKInnerObjIDModule, 3, common.BKInnerObjIDSet, []int64{2}, map[string]interface{}{"bk_module_name": "new"}),
+		newSnapshotNode(common.BKInnerObjIDModule, 5, common.BKInnerObjIDSet, []int64{2}, map[string]interface{}{"bk_module_name": "added"}),
+		newSnapshotNode(common.BKInnerObjIDHost, 10, common.BKInnerObjIDModule, []int64{3, 5}, map[string]interface{}{
+			"bk_host_innerip":    "127.0.0.1",
+			"bk_cpu":             float64(4),
+			common.LastTimeField: "2020-07-21T00:00:00Z",
+		}),
+	}
+
+	diff := diffTopoSnapshotNodes(from, to)
+
+	require.Len(t, diff.Added, 1)
+	require.Equal(t, int64(5), diff.Added[0].InstID)
+
+	require.Len(t, diff.Removed, 1)
+	require.Equal(t, int64(4), diff.Removed[0].InstID)
+
+	require.Len(t, diff.Moved, 1)
+	require.Equal(t, common.BKInnerObjIDHost, diff.Moved[0].ObjectID)
+	require.Equal(t, []int64{3}, diff.Moved[0].FromParentInstIDs)
+	require.Equal(t, []int64{3, 5}, diff.Moved[0].ToParentInstIDs)
+
+	// the host is moved but not modified, the last time is ignored
+	require.Len(t, diff.Modified, 1)
+	require.Equal(t, int64(3), diff.Modified[0].InstID)
+	require.Equal(t, []metadata.TopoSnapshotFieldChange{{Field: "bk_module_name", Before: "old", After: "new"}},
+		diff.Modified[0].Fields)
+}
+
+func TestDiffTopoSnapshotNodesUnchanged(t *testing.T) {
+	nodes := []metadata.TopoSnapshotNode{
+		newSnapshotNode(common.BKInnerObjIDApp, 1, "", []int64{}, map[string]interface{}{
+			"bk_biz_name": "biz",
+			"labels":      map[string]interface{}{"env": "prod"},
+		}),
+	}
+
+	diff := diffTopoSnapshotNodes(nodes, nodes)
+	require.Empty(t, diff.Added)
+	require.Empty(t, diff.Removed)
+	require.Empty(t, diff.Moved)
+	require.Empty(t, diff.Modified)
+}
+
+func TestNormalizeSnapshotData(t *testing.T) {
+	data, err := normalizeSnapshotData(map[string]interface{}{
+		"_id":         "5f1a",
+		"bk_biz_id":   int64(3),
+		"bk_biz_name": "biz",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"bk_biz_id": float64(3), "bk_biz_name": "biz"}, data)
+
+	fields := diffTopoSnapshotData(data, map[string]interface{}{"bk_biz_id": int64(3), "bk_biz_name": "biz"})
+	require.Empty(t, fields)
+}
+
+func TestTopoSnapshotOptionValidate(t *testing.T) {
+	diffOption := metadata.DiffTopoSnapshotOption{From: 1}
+	_, ok := diffOption.Validate()
+	require.True(t, ok)
+
+	diffOption.To = 1
+	field, ok := diffOption.Validate()
+	require.False(t, ok)
+	require.Equal(t, "to", field)
+
+	schedule := metadata.TopoSnapshotSchedule{IntervalHours: 24}
+	field, ok = schedule.Validate()
+	require.False(t, ok)
+	require.Equal(t, "max_snapshots", field)
+
+	schedule.MaxSnapshots = 7
+	_, ok = schedule.Validate()
+	require.True(t, ok)
+}
@@ -0,0 +1,190 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mainline
+
+import (
+	"net/http"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/language"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// topoSnapshotCheckInterval is the interval to check whether the scheduled snapshots are due
+const topoSnapshotCheckInterval = 10 * time.Minute
+
+// GetTopoSnapshotSchedule gets the snapshot schedule of the business, the interval is 0 if there is no schedule
+func (m *topoManager) GetTopoSnapshotSchedule(kit *rest.Kit, bizID int64) (*metadata.TopoSnapshotSchedule, errors.CCErrorCoder) {
+	filter := util.SetQueryOwner(map[string]interface{}{common.BKAppIDField: bizID}, kit.SupplierAccount)
+	schedules := make([]metadata.TopoSnapshotSchedule, 0)
+	if err := m.DbProxy.Table(common.BKTableNameTopoSnapshotSchedule).Find(filter).All(kit.Ctx, &schedules); err != nil {
+		blog.Errorf("GetTopoSnapshotSchedule failed, db select failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(schedules) == 0 {
+		return &metadata.TopoSnapshotSchedule{BizID: bizID, SupplierAccount: kit.SupplierAccount}, nil
+	}
+	return &schedules[0], nil
+}
+
+// UpdateTopoSnapshotSchedule sets the snapshot schedule of the business, the schedule is removed if its interval
+// is 0, the scheduled snapshots already taken are kept until they are deleted.
+func (m *topoManager) UpdateTopoSnapshotSchedule(kit *rest.Kit, bizID int64, schedule metadata.TopoSnapshotSchedule) errors.CCErrorCoder {
+	if field, ok := schedule.Validate(); !ok {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: kit.SupplierAccount,
+		common.BKAppIDField:      bizID,
+	}
+	if schedule.IntervalHours == 0 {
+		if err := m.DbProxy.Table(common.BKTableNameTopoSnapshotSchedule).Delete(kit.Ctx, filter); err != nil {
+			blog.Errorf("UpdateTopoSnapshotSchedule failed, db delete failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+		}
+		return nil
+	}
+
+	schedule.BizID = bizID
+	schedule.SupplierAccount = kit.SupplierAccount
+	schedule.Modifier = kit.User
+	schedule.LastTime = metadata.Now()
+	if err := m.DbProxy.Table(common.BKTableNameTopoSnapshotSchedule).Upsert(kit.Ctx, filter, schedule); err != nil {
+		blog.Errorf("UpdateTopoSnapshotSchedule failed, db upsert failed, filter: %+v, err: %+v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// TopoSnapshotJob takes the scheduled snapshots of all the businesses on the master coreservice, and removes
+// the oldest scheduled snapshots beyond the max count of the schedule.
+type TopoSnapshotJob struct {
+	topo     *topoManager
+	isMaster discovery.ServiceManageInterface
+}
+
+// NewTopoSnapshotJob creates the topology snapshot job
+func NewTopoSnapshotJob(dbProxy dal.RDB, lang language.CCLanguageIf, isMaster discovery.ServiceManageInterface) *TopoSnapshotJob {
+	return &TopoSnapshotJob{
+		topo: &topoManager{
+			DbProxy: dbProxy,
+			lang:    lang,
+		},
+		isMaster: isMaster,
+	}
+}
+
+// Run starts the job in background
+func (j *TopoSnapshotJob) Run() {
+	go func() {
+		ticker := time.NewTicker(topoSnapshotCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !j.isMaster.IsMaster() {
+				blog.V(4).Infof("take scheduled topo snapshots, but not master, skip.")
+				continue
+			}
+			j.purgeDeletedBizSnapshots()
+			j.takeScheduledSnapshots()
+		}
+	}()
+}
+
+// newTopoSnapshotKit creates the kit of the supplier account for the job
+func newTopoSnapshotKit(supplierAccount string) *rest.Kit {
+	header := make(http.Header)
+	header.Set(common.BKHTTPOwnerID, supplierAccount)
+	header.Set(common.BKHTTPHeaderUser, common.CCSystemOperatorUserName)
+	header.Set(common.BKHTTPCCRequestID, util.GenerateRID())
+
+	return &rest.Kit{
+		Rid:             util.GetHTTPCCRequestID(header),
+		Header:          header,
+		Ctx:             util.NewContextFromHTTPHeader(header),
+		CCError:         util.GetDefaultCCError(header),
+		User:            common.CCSystemOperatorUserName,
+		SupplierAccount: supplierAccount,
+	}
+}
+
+// purgeDeletedBizSnapshots removes the snapshots of the deleted businesses, which can't be viewed any more
+func (j *TopoSnapshotJob) purgeDeletedBizSnapshots() {
+	kit := newTopoSnapshotKit(common.BKSuperOwnerID)
+	if err := j.topo.purgeDeletedBizTopoSnapshots(kit); err != nil {
+		blog.Errorf("purge topo snapshots of deleted businesses failed, err: %v, rid: %s", err, kit.Rid)
+	}
+}
+
+func (j *TopoSnapshotJob) takeScheduledSnapshots() {
+	kit := newTopoSnapshotKit(common.BKSuperOwnerID)
+	schedules := make([]metadata.TopoSnapshotSchedule, 0)
+	if err := j.topo.DbProxy.Table(common.BKTableNameTopoSnapshotSchedule).Find(map[string]interface{}{}).All(kit.Ctx, &schedules); err != nil {
+		blog.Errorf("take scheduled topo snapshots failed, db select failed, err: %+v, rid: %s", err, kit.Rid)
+		return
+	}
+
+	for _, schedule := range schedules {
+		if schedule.IntervalHours <= 0 {
+			continue
+		}
+		kit := newTopoSnapshotKit(schedule.SupplierAccount)
+		if err := j.takeScheduledSnapshot(kit, schedule); err != nil {
+			blog.Errorf("take scheduled topo snapshot failed, schedule: %+v, err: %+v, rid: %s", schedule, err, kit.Rid)
+		}
+	}
+}
+
+// takeScheduledSnapshot takes a snapshot of the business if the interval has passed since its latest scheduled
+// snapshot, then removes the scheduled snapshots beyond the max count.
+func (j *TopoSnapshotJob) takeScheduledSnapshot(kit *rest.Kit, schedule metadata.TopoSnapshotSchedule) error {
+	filter := map[string]interface{}{
+		common.BkSupplierAccount: schedule.SupplierAccount,
+		common.BKAppIDField:      schedule.BizID,
+		"trigger":                metadata.TopoSnapshotScheduled,
+	}
+	snapshots := make([]metadata.TopoSnapshot, 0)
+	err := j.topo.DbProxy.Table(common.BKTableNameTopoSnapshot).Find(filter).Fields(common.BKFieldID, common.CreateTimeField).
+		Sort("-"+common.BKFieldID).All(kit.Ctx, &snapshots)
+	if err != nil {
+		return err
+	}
+
+	interval := time.Duration(schedule.IntervalHours) * time.Hour
+	if len(snapshots) == 0 || time.Since(snapshots[0].CreateTime.Time) >= interval {
+		snapshot, ccErr := j.topo.takeTopoSnapshot(kit, schedule.BizID, "", metadata.TopoSnapshotScheduled)
+		if ccErr != nil {
+			return ccErr
+		}
+		blog.Infof("take scheduled topo snapshot %d of business %d, %d nodes, rid: %s", snapshot.ID, schedule.BizID,
+			snapshot.NodeCount, kit.Rid)
+		snapshots = append([]metadata.TopoSnapshot{*snapshot}, snapshots...)
+	}
+
+	if int64(len(snapshots)) <= schedule.MaxSnapshots {
+		return nil
+	}
+	for _, snapshot := range snapshots[schedule.MaxSnapshots:] {
+		if ccErr := j.topo.removeTopoSnapshot(kit, snapshot.ID); ccErr != nil {
+			return ccErr
+		}
+	}
+	return nil
+}
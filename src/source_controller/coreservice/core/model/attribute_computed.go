@@ -0,0 +1,248 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// normalizeComputedAttribute the value of computed attribute is evaluated by server, so it's read-only,
+// and can not be required or unique.
+func normalizeComputedAttribute(attribute *metadata.Attribute) {
+	if attribute.PropertyType != common.FieldTypeComputed {
+		return
+	}
+	attribute.IsEditable = false
+	attribute.IsRequired = false
+	attribute.IsOnly = false
+}
+
+// checkComputedAttribute check if the fields referenced by the computed attribute's expression are valid.
+// computed attribute can only reference the fields that are not computed, so that there is no cascade evaluation,
+// and the secret fields can not be referenced either.
+func (m *modelAttribute) checkComputedAttribute(kit *rest.Kit, objID string, option interface{}) error {
+	attribute := metadata.Attribute{ObjectID: objID, PropertyType: common.FieldTypeComputed, Option: option}
+	_, expr, err := attribute.ParseComputedExpression(kit.Ctx)
+	if err != nil {
+		blog.Errorf("parse computed expression failed, option: %#v, err: %v, rid: %s", option, err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommParamsInvalid, "option expression")
+	}
+
+	attrsMap := make(map[string]map[string]metadata.Attribute)
+	getAttrs := func(objID string) (map[string]metadata.Attribute, error) {
+		if attrs, exists := attrsMap[objID]; exists {
+			return attrs, nil
+		}
+		attrs, err := m.searchObjectAttributesMap(kit, objID)
+		if err != nil {
+			return nil, err
+		}
+		attrsMap[objID] = attrs
+		return attrs, nil
+	}
+
+	for _, ref := range expr.References() {
+		refObjID := objID
+		switch ref.Scope {
+		case expression.ScopeParent:
+			parentObjID, err := m.getMainlineParentObjID(kit, objID)
+			if err != nil {
+				return err
+			}
+			if parentObjID == "" {
+				blog.Errorf("object %s has no mainline parent, can not reference %s, rid: %s", objID, ref, kit.Rid)
+				return kit.CCError.Errorf(common.CCErrCommParamsInvalid, "option expression")
+			}
+			refObjID = parentObjID
+		case expression.ScopeBiz:
+			parentObjID, err := m.getMainlineParentObjID(kit, objID)
+			if err != nil {
+				return err
+			}
+			if objID != common.BKInnerObjIDProc && parentObjID == "" {
+				blog.Errorf("object %s does not belong to business, can not reference %s, rid: %s", objID, ref, kit.Rid)
+				return kit.CCError.Errorf(common.CCErrCommParamsInvalid, "option expression")
+			}
+			refObjID = common.BKInnerObjIDApp
+		case expression.ScopeAsst:
+			exists, err := m.hasModelAssociation(kit, objID, ref.ObjectID)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				blog.Errorf("object %s is not associated with %s, can not reference %s, rid: %s", objID, ref.ObjectID, ref, kit.Rid)
+				return kit.CCError.Errorf(common.CCErrCommParamsInvalid, "option expression")
+			}
+			refObjID = ref.ObjectID
+		}
+
+		attrs, err := getAttrs(refObjID)
+		if err != nil {
+			return err
+		}
+		// the secret can't be referenced, or its value would be revealed by the computed value
+		attr, exists := attrs[ref.PropertyID()]
+		if !exists || attr.PropertyType == common.FieldTypeComputed || attr.PropertyType == common.FieldTypeSecret {
+			blog.Errorf("reference %s of object %s is not a valid field of %s, rid: %s", ref, objID, refObjID, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsInvalid, "option expression")
+		}
+
+		if ref.PropertyID() == ref.Field {
+			continue
+		}
+		// the field is a table column like "disks.size"
+		if attr.PropertyType != common.FieldTypeTable {
+			blog.Errorf("reference %s of object %s is not a table column, rid: %s", ref, objID, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsInvalid, "option expression")
+		}
+		tableOption, err := metadata.ParseTableOption(kit.Ctx, attr.Option)
+		if err != nil {
+			blog.Errorf("parse table option of %s failed, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsInvalid, "option expression")
+		}
+		columnExists := false
+		for _, column := range tableOption {
+			if attr.PropertyID+"."+column.PropertyID == ref.Field {
+				columnExists = true
+				break
+			}
+		}
+		if !columnExists {
+			blog.Errorf("reference %s of object %s is not a column of %s, rid: %s", ref, objID, attr.PropertyID, kit.Rid)
+			return kit.CCError.Errorf(common.CCErrCommParamsInvalid, "option expression")
+		}
+	}
+
+	return nil
+}
+
+// checkAttributeInComputed check if the attributes to delete are referenced by any computed attribute.
+func (m *modelAttribute) checkAttributeInComputed(kit *rest.Kit, attrs []metadata.Attribute) error {
+	cond := mapstr.MapStr{common.BKPropertyTypeField: common.FieldTypeComputed}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	computedAttrs := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &computedAttrs); err != nil {
+		blog.Errorf("search computed attributes failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(computedAttrs) == 0 {
+		return nil
+	}
+
+	deleted := make(map[string]map[string]bool)
+	for _, attr := range attrs {
+		if deleted[attr.ObjectID] == nil {
+			deleted[attr.ObjectID] = make(map[string]bool)
+		}
+		deleted[attr.ObjectID][attr.PropertyID] = true
+	}
+
+	for _, computed := range computedAttrs {
+		// the computed attribute itself is being deleted
+		if deleted[computed.ObjectID][computed.PropertyID] {
+			continue
+		}
+		_, expr, err := computed.ParseComputedExpression(kit.Ctx)
+		if err != nil {
+			blog.Errorf("parse expression of computed attribute %s failed, err: %v, rid: %s", computed.PropertyID, err, kit.Rid)
+			continue
+		}
+		for _, ref := range expr.References() {
+			refObjID := computed.ObjectID
+			switch ref.Scope {
+			case expression.ScopeParent:
+				if refObjID, err = m.getMainlineParentObjID(kit, computed.ObjectID); err != nil {
+					return err
+				}
+			case expression.ScopeBiz:
+				refObjID = common.BKInnerObjIDApp
+			case expression.ScopeAsst:
+				refObjID = ref.ObjectID
+			}
+			if deleted[refObjID][ref.PropertyID()] {
+				blog.Errorf("attribute %s of %s is referenced by computed attribute %s of %s, rid: %s", ref.PropertyID(),
+					refObjID, computed.PropertyID, computed.ObjectID, kit.Rid)
+				return kit.CCError.Errorf(common.CCErrCoreServiceAttributeReferencedByComputed, ref.PropertyID(),
+					computed.PropertyID)
+			}
+		}
+	}
+	return nil
+}
+
+// refreshComputedValues queues the tasks to re-evaluate the computed attributes' values of the objects' instances
+func (m *modelAttribute) refreshComputedValues(kit *rest.Kit, objIDs []string) error {
+	for _, objID := range util.StrArrayUnique(objIDs) {
+		if err := m.model.dependent.RefreshModelComputedValues(kit, objID); err != nil {
+			blog.Errorf("refresh computed values of object %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *modelAttribute) searchObjectAttributesMap(kit *rest.Kit, objID string) (map[string]metadata.Attribute, error) {
+	cond := mapstr.MapStr{common.BKObjIDField: objID}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	attrs := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &attrs); err != nil {
+		blog.Errorf("search attributes of object %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	attrsMap := make(map[string]metadata.Attribute, len(attrs))
+	for _, attr := range attrs {
+		attrsMap[attr.PropertyID] = attr
+	}
+	return attrsMap, nil
+}
+
+// getMainlineParentObjID returns the mainline parent object id of the object, returns empty if it's not a mainline child.
+func (m *modelAttribute) getMainlineParentObjID(kit *rest.Kit, objID string) (string, error) {
+	cond := mapstr.MapStr{
+		common.BKObjIDField:           objID,
+		common.AssociationKindIDField: common.AssociationKindMainline,
+	}
+	asst := make([]metadata.Association, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond).All(kit.Ctx, &asst); err != nil {
+		blog.Errorf("search mainline association of object %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return "", kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(asst) == 0 {
+		return "", nil
+	}
+	return asst[0].AsstObjID, nil
+}
+
+// hasModelAssociation check if there is a none mainline association between the two objects in either direction.
+func (m *modelAttribute) hasModelAssociation(kit *rest.Kit, objID, asstObjID string) (bool, error) {
+	cond := mapstr.MapStr{
+		common.AssociationKindIDField: mapstr.MapStr{common.BKDBNE: common.AssociationKindMainline},
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKObjIDField: objID, common.BKAsstObjIDField: asstObjID},
+			{common.BKObjIDField: asstObjID, common.BKAsstObjIDField: objID},
+		},
+	}
+	cnt, err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count association between %s and %s failed, err: %v, rid: %s", objID, asstObjID, err, kit.Rid)
+		return false, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+	return cnt > 0, nil
+}
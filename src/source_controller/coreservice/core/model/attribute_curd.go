@@ -86,12 +86,23 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 		attribute.LastTime.Time = time.Now()
 	}
 
+	normalizeComputedAttribute(&attribute)
 	if err = m.saveCheck(kit, attribute); err != nil {
 		return 0, err
 	}
 
 	err = m.dbProxy.Table(common.BKTableNameObjAttDes).Insert(kit.Ctx, attribute)
-	return id, err
+	if err != nil {
+		return id, err
+	}
+
+	// the values of the new computed attribute for the existing instances are evaluated in background
+	if attribute.PropertyType == common.FieldTypeComputed {
+		if err = m.refreshComputedValues(kit, []string{attribute.ObjectID}); err != nil {
+			return id, err
+		}
+	}
+	return id, nil
 }
 
 func (m *modelAttribute) checkUnique(kit *rest.Kit, isCreate bool, objID, propertyID, propertyName string, meta metadata.Metadata) error {
@@ -210,7 +221,9 @@ func (m *modelAttribute) checkAttributeValidity(kit *rest.Kit, attribute metadat
 	if attribute.PropertyType != "" {
 		switch attribute.PropertyType {
 		case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeEnum,
-			common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeUser, common.FieldTypeOrganization, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList:
+			common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeUser, common.FieldTypeOrganization, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList,
+			common.FieldTypeIPv4, common.FieldTypeIPv6, common.FieldTypeCIDR, common.FieldTypeMultiEnum, common.FieldTypeTable,
+			common.FieldTypeComputed, common.FieldTypeSecret:
 		default:
 			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldPropertyType)
 		}
@@ -237,6 +250,24 @@ func (m *modelAttribute) update(kit *rest.Kit, data mapstr.MapStr, cond universa
 		return 0, err
 	}
 
+	// the expression of computed attributes is changed, re-evaluate the values of the instances in background
+	if _, exists := data.Get(metadata.AttributeFieldOption); exists {
+		computedAttrs, err := m.search(kit, cond)
+		if err != nil {
+			blog.Errorf("search updated attributes failed, cond: %#v, err: %v, rid: %s", cond.ToMapStr(), err, kit.Rid)
+			return 0, err
+		}
+		objIDs := make([]string, 0)
+		for _, attr := range computedAttrs {
+			if attr.PropertyType == common.FieldTypeComputed {
+				objIDs = append(objIDs, attr.ObjectID)
+			}
+		}
+		if err := m.refreshComputedValues(kit, objIDs); err != nil {
+			return 0, err
+		}
+	}
+
 	return cnt, err
 }
 
@@ -271,7 +302,8 @@ func (m *modelAttribute) searchReturnMapStr(kit *rest.Kit, cond universalsql.Con
 func (m *modelAttribute) delete(kit *rest.Kit, cond universalsql.Condition) (cnt uint64, err error) {
 
 	resultAttrs := make([]metadata.Attribute, 0)
-	fields := []string{common.BKFieldID, common.BKPropertyIDField, common.BKObjIDField, common.MetadataField}
+	fields := []string{common.BKFieldID, common.BKPropertyIDField, common.BKObjIDField, common.MetadataField,
+		common.BKPropertyTypeField}
 
 	condMap := util.SetQueryOwner(cond.ToMapStr(), kit.SupplierAccount)
 	err = m.dbProxy.Table(common.BKTableNameObjAttDes).Find(condMap).Fields(fields...).All(kit.Ctx, &resultAttrs)
@@ -290,6 +322,16 @@ func (m *modelAttribute) delete(kit *rest.Kit, cond universalsql.Condition) (cnt
 		objIDArrMap[attr.ObjectID] = append(objIDArrMap[attr.ObjectID], attr.ID)
 	}
 
+	// the attributes referenced by computed attributes can not be deleted
+	if err := m.checkAttributeInComputed(kit, resultAttrs); err != nil {
+		return 0, err
+	}
+
+	// the attributes used by the model's validation rules can not be deleted
+	if err := m.checkAttributeInValidationRules(kit, resultAttrs); err != nil {
+		return 0, err
+	}
+
 	if err := m.cleanAttributeFieldInInstances(kit.Ctx, kit.SupplierAccount, resultAttrs); err != nil {
 		blog.ErrorJSON("delete object attributes with cond: %s, but delete these attribute in instance failed, err: %v, rid:%s", condMap, err, kit.Rid)
 		return 0, err
@@ -578,6 +620,17 @@ func (m *modelAttribute) saveCheck(kit *rest.Kit, attribute metadata.Attribute)
 		return err
 	}
 
+	if rawErr := attribute.ValidDefault(kit.Ctx); rawErr.ErrCode != 0 {
+		blog.Errorf("attribute %s default value %#v is invalid, rid: %s", attribute.PropertyID, attribute.Default, kit.Rid)
+		return rawErr.ToCCError(kit.CCError)
+	}
+
+	if attribute.PropertyType == common.FieldTypeComputed {
+		if err := m.checkComputedAttribute(kit, attribute.ObjectID, attribute.Option); err != nil {
+			return err
+		}
+	}
+
 	// check name duplicate
 	if err := m.checkUnique(kit, true, attribute.ObjectID, attribute.PropertyID, attribute.PropertyName, attribute.Metadata); err != nil {
 		blog.ErrorJSON("save attribute check unique err:%s, input:%s, rid:%s", err.Error(), attribute, kit.Rid)
@@ -609,7 +662,7 @@ func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond uni
 		}
 	}
 
-	// 预定义字段，只能更新分组、分组内排序、名称、单位、提示语和option
+	// 预定义字段，只能更新分组、分组内排序、名称、单位、提示语、option和默认值
 	if hasIsPreProperty {
 		_ = data.ForEach(func(key string, val interface{}) error {
 			if key != metadata.AttributeFieldPropertyGroup &&
@@ -617,7 +670,8 @@ func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond uni
 				key != metadata.AttributeFieldPropertyName &&
 				key != metadata.AttributeFieldUnit &&
 				key != metadata.AttributeFieldPlaceHolder &&
-				key != metadata.AttributeFieldOption {
+				key != metadata.AttributeFieldOption &&
+				key != metadata.AttributeFieldDefault {
 				data.Remove(key)
 			}
 			return nil
@@ -636,6 +690,41 @@ func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond uni
 			blog.ErrorJSON("valid property option failed, err: %s, data: %s, rid:%s", err, data, kit.Ctx)
 			return changeRow, err
 		}
+		if propertyType == common.FieldTypeComputed {
+			for _, dbAttribute := range dbAttributeArr {
+				if err := m.checkComputedAttribute(kit, dbAttribute.ObjectID, option); err != nil {
+					return changeRow, err
+				}
+			}
+		}
+	}
+
+	// the default value should be valid with the updated option
+	defaultVal, defaultExists := data.Get(metadata.AttributeFieldDefault)
+	option, optionExists := data.Get(metadata.AttributeFieldOption)
+	if defaultExists || optionExists {
+		for _, dbAttribute := range dbAttributeArr {
+			if defaultExists {
+				dbAttribute.Default = defaultVal
+			}
+			if optionExists {
+				dbAttribute.Option = option
+			}
+			if rawErr := dbAttribute.ValidDefault(kit.Ctx); rawErr.ErrCode != 0 {
+				blog.Errorf("attribute %s default value %#v is invalid, rid: %s", dbAttribute.PropertyID, dbAttribute.Default, kit.Rid)
+				return changeRow, rawErr.ToCCError(kit.CCError)
+			}
+		}
+	}
+
+	// computed attribute is always read-only, and can not be required or unique
+	for _, dbAttribute := range dbAttributeArr {
+		if dbAttribute.PropertyType == common.FieldTypeComputed {
+			data.Remove(metadata.AttributeFieldIsEditable)
+			data.Remove(metadata.AttributeFieldIsRequired)
+			data.Remove(metadata.AttributeFieldIsOnly)
+			break
+		}
 	}
 
 	// 删除不可更新字段， 避免由于传入数据，修改字段
@@ -729,6 +818,34 @@ func (m *modelAttribute) checkAttributeInUnique(kit *rest.Kit, objIDPropertyIDAr
 	return false, nil
 }
 
+// checkAttributeInValidationRules 检查属性是否被模型的校验规则使用
+func (m *modelAttribute) checkAttributeInValidationRules(kit *rest.Kit, attrs []metadata.Attribute) error {
+	objAttrs := make(map[string][]string)
+	for _, attr := range attrs {
+		objAttrs[attr.ObjectID] = append(objAttrs[attr.ObjectID], attr.PropertyID)
+	}
+
+	for objID, propertyIDs := range objAttrs {
+		cond := util.SetQueryOwner(mapstr.MapStr{common.BKObjIDField: objID}, kit.SupplierAccount)
+		models := make([]metadata.Object, 0)
+		if err := m.dbProxy.Table(common.BKTableNameObjDes).Find(cond).All(kit.Ctx, &models); err != nil {
+			blog.Errorf("search model %s failed, err: %v, rid: %s", objID, err, kit.Rid)
+			return kit.CCError.Error(common.CCErrCommDBSelectFailed)
+		}
+		for _, model := range models {
+			for _, rule := range model.ValidationRules {
+				for _, field := range rule.Fields() {
+					if util.InStrArr(propertyIDs, field) {
+						blog.Errorf("attribute %s is used by validation rule %#v of %s, rid: %s", field, rule, objID, kit.Rid)
+						return kit.CCError.Errorf(common.CCErrCoreServiceAttributeUsedByValidationRule, field, objID)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // checkAddRequireField 新加模型属性的时候，如果新加的是必填字段，需要判断是否可以新加必填字段
 func (m *modelAttribute) checkAddField(kit *rest.Kit, attribute metadata.Attribute) error {
 	langObjID := m.getLangObjID(kit, attribute.ObjectID)
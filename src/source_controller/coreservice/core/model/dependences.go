@@ -32,4 +32,7 @@ type OperationDependences interface {
 
 	// CascadeDeleteInstances cascade delete all instances(included instances, instance association) associated with modelObjID
 	CascadeDeleteInstances(kit *rest.Kit, objIDS []string) error
+
+	// RefreshModelComputedValues queues a task to re-evaluate the computed attributes' values of all the instances of the model
+	RefreshModelComputedValues(kit *rest.Kit, objID string) error
 }
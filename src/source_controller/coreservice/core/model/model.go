@@ -87,6 +87,11 @@ func (m *modelManager) CreateModel(kit *rest.Kit, inputParam metadata.CreateMode
 		return dataResult, kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.ModelFieldObjectID)
 	}
 
+	// the validation rules reference the model's attributes, they are checked and saved after the attributes
+	// are created.
+	validationRules := inputParam.Spec.ValidationRules
+	inputParam.Spec.ValidationRules = nil
+
 	// check the input classification ID
 	isValid, err := m.modelClassification.isValid(kit, inputParam.Spec.ObjCls)
 	if nil != err {
@@ -155,7 +160,18 @@ func (m *modelManager) CreateModel(kit *rest.Kit, inputParam metadata.CreateMode
 			return dataResult, err
 		}
 	}
-	
+
+	if len(validationRules) != 0 {
+		updateCondMap := util.SetModOwner(make(map[string]interface{}), kit.SupplierAccount)
+		updateCond, _ := mongo.NewConditionFromMapStr(updateCondMap)
+		updateCond.Element(&mongo.Eq{Key: metadata.ModelFieldObjectID, Val: inputParam.Spec.ObjectID})
+		data := mapstr.MapStr{metadata.ModelFieldValidationRules: validationRules}
+		if _, err := m.update(kit, data, updateCond); err != nil {
+			blog.Errorf("request(%s): it is failed to set the validation rules (%#v) for the model (%s), err: %v", kit.Rid, validationRules, inputParam.Spec.ObjectID, err)
+			return dataResult, err
+		}
+	}
+
 	dataResult.Created.ID = id
 	return dataResult, nil
 }
@@ -78,6 +78,16 @@ func (m *modelManager) update(kit *rest.Kit, data mapstr.MapStr, cond universals
 		return 0, kit.CCError.New(common.CCErrObjectDBOpErrno, err.Error())
 	}
 
+	if val, exist := data.Get(metadata.ModelFieldValidationRules); exist {
+		var rules []metadata.ValidationRule
+		for _, model := range models {
+			if rules, err = m.checkValidationRules(kit, model.ObjectID, val); err != nil {
+				return 0, err
+			}
+		}
+		data.Set(metadata.ModelFieldValidationRules, rules)
+	}
+
 	if objName, exist := data[common.BKObjNameField]; exist == true && len(util.GetStrByInterface(objName)) > 0 {
 		for _, model := range models {
 			modelName := data[common.BKObjNameField]
@@ -13,6 +13,8 @@
 package model
 
 import (
+	"fmt"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
@@ -76,3 +78,37 @@ func (m *modelManager) deleteModelAndAttributes(kit *rest.Kit, targetObjIDS []st
 
 	return cnt, nil
 }
+
+// checkValidationRules check if the validation rules of the model are valid, the fields used by the rules must be
+// the model's attributes.
+func (m *modelManager) checkValidationRules(kit *rest.Kit, objID string, val interface{}) ([]metadata.ValidationRule, error) {
+	rules, err := metadata.ParseValidationRules(val)
+	if err != nil {
+		blog.Errorf("parse validation rules failed, rules: %#v, err: %v, rid: %s", val, err, kit.Rid)
+		return nil, kit.CCError.Errorf(common.CCErrCommParamsInvalid, metadata.ModelFieldValidationRules)
+	}
+
+	attrs, err := m.searchObjectAttributesMap(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, rule := range rules {
+		if key, err := rule.Validate(); err != nil {
+			blog.Errorf("validation rule %#v is invalid, err: %v, rid: %s", rule, err, kit.Rid)
+			return nil, kit.CCError.Errorf(common.CCErrCommParamsInvalid,
+				fmt.Sprintf("%s[%d].%s", metadata.ModelFieldValidationRules, idx, key))
+		}
+		for _, field := range rule.Fields() {
+			// the computed value is evaluated after validation, and only the ciphertext of secret is stored,
+			// so they can not be used
+			attr, exists := attrs[field]
+			if !exists || attr.PropertyType == common.FieldTypeComputed || attr.PropertyType == common.FieldTypeSecret {
+				blog.Errorf("field %s of validation rule %#v is not an attribute of %s, rid: %s", field, rule, objID, kit.Rid)
+				return nil, kit.CCError.Errorf(common.CCErrCommParamsInvalid,
+					fmt.Sprintf("%s[%d]: %s", metadata.ModelFieldValidationRules, idx, field))
+			}
+		}
+	}
+	return rules, nil
+}
@@ -433,6 +433,8 @@ func getBasicDataType(propertyType string) (interface{}, error) {
 		return 0.0, nil
 	case common.FieldTypeUser:
 		return "", nil
+	case common.FieldTypeIPv4, common.FieldTypeIPv6, common.FieldTypeCIDR:
+		return "", nil
 	case common.FieldTypeList:
 		return nil, nil
 	case common.FieldTypeOrganization:
@@ -259,6 +259,19 @@ func (p *processOperation) ListServiceInstance(kit *rest.Kit, option metadata.Li
 		filter = util.MergeMaps(filter, labelFilter)
 	}
 
+	if option.Filter != nil {
+		if key, err := option.Filter.Validate(); err != nil {
+			blog.Errorf("ListServiceInstance failed, filter validate failed, key: %s, err: %+v, rid: %s", key, err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "filter."+key)
+		}
+		instanceFilter, key, err := option.Filter.ToMgoWithFieldTypes(metadata.ServiceInstanceFieldTypes)
+		if err != nil {
+			blog.Errorf("ListServiceInstance failed, filter to mongo failed, key: %s, err: %+v, rid: %s", key, err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "filter."+key)
+		}
+		filter = map[string]interface{}{common.BKDBAND: []interface{}{filter, instanceFilter}}
+	}
+
 	var total uint64
 	var err error
 	if total, err = p.dbProxy.Table(common.BKTableNameServiceInstance).Find(filter).Count(kit.Ctx); nil != err {
@@ -366,5 +366,11 @@ func (p *processOperation) DeleteServiceTemplate(kit *rest.Kit, serviceTemplateI
 		blog.Errorf("DeleteServiceTemplate failed, mongodb failed, table: %s, deleteFilter: %+v, err: %+v, rid: %s", common.BKTableNameServiceTemplate, deleteFilter, err, kit.Rid)
 		return kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
 	}
+
+	// delete host apply rules of the service template
+	if err := p.dbProxy.Table(common.BKTableNameHostApplyRule).Delete(kit.Ctx, usageFilter); nil != err {
+		blog.Errorf("DeleteServiceTemplate failed, mongodb failed, table: %s, filter: %+v, err: %+v, rid: %s", common.BKTableNameHostApplyRule, usageFilter, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
+	}
 	return nil
 }
@@ -0,0 +1,342 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recyclebin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/instances"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	recycleBinConfigPrefix = "recycleBin."
+	// defaultTTLDays is the days the deleted instances are kept in the recycle bin if it's not configured
+	defaultTTLDays = 7
+	// recycleBinPurgeInterval is the interval to purge the expired recycle bin items
+	recycleBinPurgeInterval = time.Hour
+)
+
+// Config is the config of the recycle bin
+type Config struct {
+	// TTL is how long the deleted instances are kept in the recycle bin, the instances are deleted
+	// permanently without being recycled if it's 0.
+	TTL time.Duration
+}
+
+// ParseConfig parses the recycle bin config from the config map of coreservice
+func ParseConfig(configMap map[string]string) (Config, error) {
+	ttlDays := int64(defaultTTLDays)
+	if val, ok := configMap[recycleBinConfigPrefix+"ttlDays"]; ok && len(val) != 0 {
+		days, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || days < 0 {
+			return Config{}, fmt.Errorf("invalid %sttlDays value %s", recycleBinConfigPrefix, val)
+		}
+		ttlDays = days
+	}
+	return Config{TTL: time.Duration(ttlDays) * 24 * time.Hour}, nil
+}
+
+// OperationDependences methods definition
+type OperationDependences interface {
+	// RestoreModelInstance saves the deleted instance back with its original id
+	RestoreModelInstance(kit *rest.Kit, objID string, data mapstr.MapStr) error
+
+	// TransferToInnerModule transfers the restored hosts to the idle module of the resource pool
+	TransferToInnerModule(kit *rest.Kit, input *metadata.TransferHostToInnerModule) ([]metadata.ExceptionResult, error)
+}
+
+type recycleBinManager struct {
+	dbProxy   dal.RDB
+	dependent OperationDependences
+	config    Config
+}
+
+// New create a new recycle bin manager instance
+func New(dbProxy dal.RDB, dependent OperationDependences, config Config) core.RecycleBinOperation {
+	return &recycleBinManager{
+		dbProxy:   dbProxy,
+		dependent: dependent,
+		config:    config,
+	}
+}
+
+// isRecyclable checks whether the deleted instances of the object are moved into the recycle bin, the processes
+// are deleted along with their service instances, so they are not recycled.
+func isRecyclable(objID string) bool {
+	return objID != common.BKInnerObjIDProc
+}
+
+// RecycleInstances moves the instances to be deleted into the recycle bin with their associations
+func (m *recycleBinManager) RecycleInstances(kit *rest.Kit, objID string, origins []mapstr.MapStr) errors.CCErrorCoder {
+	if m.config.TTL <= 0 || !isRecyclable(objID) || len(origins) == 0 {
+		return nil
+	}
+
+	instIDField := common.GetInstIDField(objID)
+	instIDs := make([]int64, len(origins))
+	for idx, origin := range origins {
+		instID, err := util.GetInt64ByInterface(origin[instIDField])
+		if err != nil {
+			blog.Errorf("RecycleInstances failed, parse %s instance id failed, data: %+v, err: %v, rid: %s", objID, origin, err, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, instIDField)
+		}
+		instIDs[idx] = instID
+	}
+
+	assts, ccErr := m.searchInstAssociations(kit, objID, instIDs)
+	if ccErr != nil {
+		return ccErr
+	}
+
+	now := time.Now().UTC()
+	items := make([]metadata.RecycleBinItem, len(origins))
+	for idx, origin := range origins {
+		id, err := m.dbProxy.NextSequence(kit.Ctx, common.BKTableNameRecycleBin)
+		if err != nil {
+			blog.Errorf("RecycleInstances failed, generate id failed, err: %v, rid: %s", err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+		}
+		items[idx] = newRecycleBinItem(objID, origin, assts[instIDs[idx]], now, m.config.TTL)
+		items[idx].ID = int64(id)
+		items[idx].InstID = instIDs[idx]
+		items[idx].Operator = kit.User
+		items[idx].SupplierAccount = kit.SupplierAccount
+	}
+
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Insert(kit.Ctx, items); err != nil {
+		blog.Errorf("RecycleInstances failed, db insert failed, objID: %s, err: %v, rid: %s", objID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return nil
+}
+
+// newRecycleBinItem creates the recycle bin item of the deleted instance
+func newRecycleBinItem(objID string, origin mapstr.MapStr, assts []metadata.InstAsst, deleteTime time.Time,
+	ttl time.Duration) metadata.RecycleBinItem {
+
+	item := metadata.RecycleBinItem{
+		ObjectID:     objID,
+		InstName:     util.GetStrByInterface(origin[metadata.GetInstNameFieldName(objID)]),
+		Data:         origin,
+		Associations: assts,
+		DeleteTime:   metadata.Time{Time: deleteTime},
+		ExpireTime:   metadata.Time{Time: deleteTime.Add(ttl)},
+	}
+	if item.Associations == nil {
+		item.Associations = make([]metadata.InstAsst, 0)
+	}
+	// the business id is only used to filter the items, the instance is restored even if it can't be parsed
+	if bizID, err := instances.FetchBizIDFromInstance(objID, origin); err == nil {
+		item.BizID = bizID
+	}
+	return item
+}
+
+// searchInstAssociations searches the associations of the instances, the result is keyed by instance id
+func (m *recycleBinManager) searchInstAssociations(kit *rest.Kit, objID string, instIDs []int64) (
+	map[int64][]metadata.InstAsst, errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{
+		common.BKDBOR: []map[string]interface{}{
+			{
+				common.BKObjIDField:  objID,
+				common.BKInstIDField: map[string]interface{}{common.BKDBIN: instIDs},
+			},
+			{
+				common.BKAsstObjIDField:  objID,
+				common.BKAsstInstIDField: map[string]interface{}{common.BKDBIN: instIDs},
+			},
+		},
+	}
+	filter = util.SetQueryOwner(filter, kit.SupplierAccount)
+
+	assts := make([]metadata.InstAsst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(filter).All(kit.Ctx, &assts); err != nil {
+		blog.Errorf("search instance associations failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	result := make(map[int64][]metadata.InstAsst)
+	for _, asst := range assts {
+		if asst.ObjectID == objID {
+			result[asst.InstID] = append(result[asst.InstID], asst)
+		}
+		// the self association belongs to both instances
+		if asst.AsstObjectID == objID && (asst.ObjectID != objID || asst.AsstInstID != asst.InstID) {
+			result[asst.AsstInstID] = append(result[asst.AsstInstID], asst)
+		}
+	}
+	return result, nil
+}
+
+// ListRecycleBin lists the items in the recycle bin, the latest deleted items come first
+func (m *recycleBinManager) ListRecycleBin(kit *rest.Kit, option metadata.ListRecycleBinOption) (
+	*metadata.MultipleRecycleBinItem, errors.CCErrorCoder) {
+
+	if field, ok := option.Validate(); !ok {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{}
+	if len(option.ObjectID) != 0 {
+		filter[common.BKObjIDField] = option.ObjectID
+	}
+	if option.BizID != 0 {
+		filter[common.BKAppIDField] = option.BizID
+	}
+	filter = util.SetQueryOwner(filter, kit.SupplierAccount)
+
+	count, err := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("ListRecycleBin failed, db count failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := option.Page.Sort
+	if len(sort) == 0 {
+		sort = "-delete_time"
+	}
+	items := make([]metadata.RecycleBinItem, 0)
+	err = m.dbProxy.Table(common.BKTableNameRecycleBin).Find(filter).Sort(sort).Start(uint64(option.Page.Start)).
+		Limit(uint64(option.Page.Limit)).All(kit.Ctx, &items)
+	if err != nil {
+		blog.Errorf("ListRecycleBin failed, db select failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	// the restore reads the items from db again, so the secret values are only masked in the response
+	if ccErr := m.maskSecretValues(kit, items); ccErr != nil {
+		return nil, ccErr
+	}
+
+	return &metadata.MultipleRecycleBinItem{
+		Count: int64(count),
+		Info:  items,
+	}, nil
+}
+
+// maskSecretValues masks the secret values of the deleted instances by the secret attributes of their models
+func (m *recycleBinManager) maskSecretValues(kit *rest.Kit, items []metadata.RecycleBinItem) errors.CCErrorCoder {
+	if len(items) == 0 {
+		return nil
+	}
+
+	objIDs := make([]string, 0)
+	for _, item := range items {
+		objIDs = append(objIDs, item.ObjectID)
+	}
+	cond := map[string]interface{}{
+		common.BKObjIDField:        map[string]interface{}{common.BKDBIN: util.StrArrayUnique(objIDs)},
+		common.BKPropertyTypeField: common.FieldTypeSecret,
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	attrs := make([]metadata.Attribute, 0)
+	err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).
+		Fields(common.BKObjIDField, common.BKPropertyIDField, common.BKPropertyTypeField).All(kit.Ctx, &attrs)
+	if err != nil {
+		blog.Errorf("search secret attributes of %v failed, err: %v, rid: %s", objIDs, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	maskItemSecrets(items, attrs)
+	return nil
+}
+
+// maskItemSecrets masks the secret values of the items' instances, the attributes are the secret ones of their models
+func maskItemSecrets(items []metadata.RecycleBinItem, attrs []metadata.Attribute) {
+	objAttrs := make(map[string][]metadata.Attribute)
+	for _, attr := range attrs {
+		objAttrs[attr.ObjectID] = append(objAttrs[attr.ObjectID], attr)
+	}
+	for _, item := range items {
+		secret.MaskMapStr(item.Data, metadata.GetSecretPropertyIDs(objAttrs[item.ObjectID])...)
+	}
+}
+
+// PurgeRecycleBin deletes the items from the recycle bin permanently
+func (m *recycleBinManager) PurgeRecycleBin(kit *rest.Kit, option metadata.PurgeRecycleBinOption) (
+	*metadata.PurgeRecycleBinResult, errors.CCErrorCoder) {
+
+	if field, ok := option.Validate(); !ok {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{
+		common.BKFieldID: map[string]interface{}{common.BKDBIN: option.IDs},
+	}
+	filter = util.SetModOwner(filter, kit.SupplierAccount)
+
+	count, err := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("PurgeRecycleBin failed, db count failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("PurgeRecycleBin failed, db delete failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return &metadata.PurgeRecycleBinResult{Count: int64(count)}, nil
+}
+
+// PurgeJob purges the expired items of the recycle bin on the master coreservice
+type PurgeJob struct {
+	dbProxy  dal.RDB
+	isMaster discovery.ServiceManageInterface
+}
+
+// NewPurgeJob creates the recycle bin purge job
+func NewPurgeJob(dbProxy dal.RDB, isMaster discovery.ServiceManageInterface) *PurgeJob {
+	return &PurgeJob{
+		dbProxy:  dbProxy,
+		isMaster: isMaster,
+	}
+}
+
+// Run starts the job in background
+func (j *PurgeJob) Run() {
+	go func() {
+		ticker := time.NewTicker(recycleBinPurgeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !j.isMaster.IsMaster() {
+				blog.V(4).Infof("purge expired recycle bin items, but not master, skip.")
+				continue
+			}
+			j.purge()
+		}
+	}()
+}
+
+func (j *PurgeJob) purge() {
+	rid := util.GenerateRID()
+	ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+	filter := map[string]interface{}{
+		"expire_time": map[string]interface{}{common.BKDBLT: time.Now().UTC()},
+	}
+	if err := j.dbProxy.Table(common.BKTableNameRecycleBin).Delete(ctx, filter); err != nil {
+		blog.Errorf("purge expired recycle bin items failed, err: %v, rid: %s", err, rid)
+	}
+}
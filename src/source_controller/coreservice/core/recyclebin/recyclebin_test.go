@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recyclebin
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(map[string]string{})
+	require.NoError(t, err)
+	require.Equal(t, defaultTTLDays*24*time.Hour, config.TTL)
+
+	config, err = ParseConfig(map[string]string{"recycleBin.ttlDays": "30"})
+	require.NoError(t, err)
+	require.Equal(t, 30*24*time.Hour, config.TTL)
+
+	// the recycle bin is disabled with a zero ttl
+	config, err = ParseConfig(map[string]string{"recycleBin.ttlDays": "0"})
+	require.NoError(t, err)
+	require.Zero(t, config.TTL)
+
+	_, err = ParseConfig(map[string]string{"recycleBin.ttlDays": "-1"})
+	require.Error(t, err)
+	_, err = ParseConfig(map[string]string{"recycleBin.ttlDays": "a week"})
+	require.Error(t, err)
+}
+
+func TestNewRecycleBinItem(t *testing.T) {
+	now := time.Now().UTC()
+	ttl := 24 * time.Hour
+
+	set := mapstr.MapStr{
+		common.BKSetIDField:    int64(3),
+		common.BKSetNameField:  "set",
+		common.BKAppIDField:    int64(2),
+		common.BKParentIDField: int64(2),
+	}
+	item := newRecycleBinItem(common.BKInnerObjIDSet, set, nil, now, ttl)
+	require.Equal(t, common.BKInnerObjIDSet, item.ObjectID)
+	require.Equal(t, "set", item.InstName)
+	require.Equal(t, int64(2), item.BizID)
+	require.Equal(t, set, item.Data)
+	require.NotNil(t, item.Associations)
+	require.Equal(t, now, item.DeleteTime.Time)
+	require.Equal(t, now.Add(ttl), item.ExpireTime.Time)
+
+	assts := []metadata.InstAsst{{ID: 1, ObjectID: "switch", InstID: 5, AsstObjectID: "router", AsstInstID: 6}}
+	inst := mapstr.MapStr{
+		common.BKInstIDField:   int64(5),
+		common.BKInstNameField: "switch-1",
+		common.BKObjIDField:    "switch",
+	}
+	item = newRecycleBinItem("switch", inst, assts, now, ttl)
+	require.Equal(t, "switch-1", item.InstName)
+	require.Zero(t, item.BizID)
+	require.Equal(t, assts, item.Associations)
+}
+
+func TestIsRecyclable(t *testing.T) {
+	require.True(t, isRecyclable(common.BKInnerObjIDHost))
+	require.True(t, isRecyclable(common.BKInnerObjIDModule))
+	require.True(t, isRecyclable("switch"))
+	require.False(t, isRecyclable(common.BKInnerObjIDProc))
+}
+
+func TestMaskItemSecrets(t *testing.T) {
+	items := []metadata.RecycleBinItem{
+		{ObjectID: "switch", Data: mapstr.MapStr{common.BKInstNameField: "switch-1", "password": "plaintext"}},
+		{ObjectID: "router", Data: mapstr.MapStr{common.BKInstNameField: "router-1", "password": "plaintext"}},
+		{
+			ObjectID: common.BKInnerObjIDHost,
+			Data: mapstr.MapStr{
+				common.BKHostInnerIPField: "127.0.0.1",
+				"token":                   "{secret}v1:abc",
+				"rows":                    []interface{}{mapstr.MapStr{"token": "{secret}v1:abc"}},
+			},
+		},
+	}
+	attrs := []metadata.Attribute{
+		{ObjectID: "switch", PropertyID: "password", PropertyType: common.FieldTypeSecret},
+		{ObjectID: common.BKInnerObjIDHost, PropertyID: "token", PropertyType: common.FieldTypeSecret},
+	}
+	maskItemSecrets(items, attrs)
+
+	require.Equal(t, "switch-1", items[0].Data[common.BKInstNameField])
+	require.Equal(t, secret.Mask, items[0].Data["password"])
+	// the field is not a secret attribute of router
+	require.Equal(t, "plaintext", items[1].Data["password"])
+	require.Equal(t, "127.0.0.1", items[2].Data[common.BKHostInnerIPField])
+	require.Equal(t, secret.Mask, items[2].Data["token"])
+	require.Equal(t, secret.Mask, items[2].Data["rows"].([]interface{})[0].(mapstr.MapStr)["token"])
+}
@@ -0,0 +1,232 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recyclebin
+
+import (
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// RestoreRecycleBin restores the instance of the recycle bin item with its original id, the instance is checked
+// against the unique constraints and its parent topology again, since they may have changed after the deletion.
+// the associations whose instances on both sides exist are restored along with the instance, and the hosts are
+// restored to the idle module of the resource pool.
+func (m *recycleBinManager) RestoreRecycleBin(kit *rest.Kit, id int64) (*metadata.RecycleBinItem, errors.CCErrorCoder) {
+	filter := map[string]interface{}{common.BKFieldID: id}
+	filter = util.SetQueryOwner(filter, kit.SupplierAccount)
+	item := new(metadata.RecycleBinItem)
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(filter).One(kit.Ctx, item); err != nil {
+		if m.dbProxy.IsNotFoundError(err) {
+			return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceRecycleBinItemNotFound, id)
+		}
+		blog.Errorf("RestoreRecycleBin failed, db select failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	exists, ccErr := m.instanceExists(kit, item.ObjectID, item.InstID)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+	if exists {
+		return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceRecycleBinInstExist, item.InstName)
+	}
+
+	if ccErr := m.validateParent(kit, item); ccErr != nil {
+		return nil, ccErr
+	}
+
+	if err := m.dependent.RestoreModelInstance(kit, item.ObjectID, item.Data); err != nil {
+		blog.Errorf("RestoreRecycleBin failed, restore %s instance %d failed, err: %v, rid: %s", item.ObjectID,
+			item.InstID, err, kit.Rid)
+		if ccErr, ok := err.(errors.CCErrorCoder); ok {
+			return nil, ccErr
+		}
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+
+	if item.ObjectID == common.BKInnerObjIDHost {
+		if ccErr := m.restoreHostRelation(kit, item.InstID); ccErr != nil {
+			return nil, ccErr
+		}
+	}
+
+	if ccErr := m.restoreAssociations(kit, item); ccErr != nil {
+		return nil, ccErr
+	}
+
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("RestoreRecycleBin failed, db delete failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	if ccErr := m.maskSecretValues(kit, []metadata.RecycleBinItem{*item}); ccErr != nil {
+		return nil, ccErr
+	}
+	return item, nil
+}
+
+// instanceExists checks whether the instance of the object exists
+func (m *recycleBinManager) instanceExists(kit *rest.Kit, objID string, instID int64) (bool, errors.CCErrorCoder) {
+	filter := map[string]interface{}{common.GetInstIDField(objID): instID}
+	if common.GetInstTableName(objID) == common.BKTableNameBaseInst {
+		filter[common.BKObjIDField] = objID
+	}
+	filter = util.SetQueryOwner(filter, kit.SupplierAccount)
+
+	count, err := m.dbProxy.Table(common.GetInstTableName(objID)).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count %s instance failed, filter: %+v, err: %v, rid: %s", objID, filter, err, kit.Rid)
+		return false, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return count > 0, nil
+}
+
+// validateParent checks the business and the mainline parent of the instance to restore still exist
+func (m *recycleBinManager) validateParent(kit *rest.Kit, item *metadata.RecycleBinItem) errors.CCErrorCoder {
+	if item.ObjectID == common.BKInnerObjIDHost || item.ObjectID == common.BKInnerObjIDPlat {
+		return nil
+	}
+
+	if item.BizID != 0 {
+		filter := map[string]interface{}{
+			common.BKAppIDField:      item.BizID,
+			common.BKDataStatusField: map[string]interface{}{common.BKDBNE: common.DataStatusDisabled},
+		}
+		exists, ccErr := m.countExists(kit, common.BKTableNameBaseApp, filter)
+		if ccErr != nil {
+			return ccErr
+		}
+		if !exists {
+			return kit.CCError.CCErrorf(common.CCErrCoreServiceRecycleBinParentNotExist,
+				fmt.Sprintf("%s: %d", common.BKAppIDField, item.BizID))
+		}
+	}
+
+	mainlineFilter := map[string]interface{}{
+		common.AssociationKindIDField: common.AssociationKindMainline,
+		common.BKObjIDField:           item.ObjectID,
+	}
+	mainlineAssts := make([]metadata.Association, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(mainlineFilter).All(kit.Ctx, &mainlineAssts); err != nil {
+		blog.Errorf("search mainline association failed, filter: %+v, err: %v, rid: %s", mainlineFilter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(mainlineAssts) == 0 {
+		return nil
+	}
+
+	parentObjID := mainlineAssts[0].AsstObjID
+	parentID, err := util.GetInt64ByInterface(item.Data[common.BKParentIDField])
+	if err != nil {
+		blog.Errorf("parse %s instance %d parent id failed, err: %v, rid: %s", item.ObjectID, item.InstID, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKParentIDField)
+	}
+	// the business is checked above
+	if parentObjID == common.BKInnerObjIDApp && parentID == item.BizID {
+		return nil
+	}
+	exists, ccErr := m.instanceExists(kit, parentObjID, parentID)
+	if ccErr != nil {
+		return ccErr
+	}
+	if !exists {
+		return kit.CCError.CCErrorf(common.CCErrCoreServiceRecycleBinParentNotExist,
+			fmt.Sprintf("%s: %d", common.GetInstIDField(parentObjID), parentID))
+	}
+	return nil
+}
+
+func (m *recycleBinManager) countExists(kit *rest.Kit, table string, filter map[string]interface{}) (bool,
+	errors.CCErrorCoder) {
+
+	filter = util.SetQueryOwner(filter, kit.SupplierAccount)
+	count, err := m.dbProxy.Table(table).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count %s failed, filter: %+v, err: %v, rid: %s", table, filter, err, kit.Rid)
+		return false, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return count > 0, nil
+}
+
+// restoreHostRelation transfers the restored host to the idle module of the resource pool
+func (m *recycleBinManager) restoreHostRelation(kit *rest.Kit, hostID int64) errors.CCErrorCoder {
+	bizFilter := map[string]interface{}{common.BKDefaultField: common.DefaultAppFlag}
+	bizFilter = util.SetQueryOwner(bizFilter, kit.SupplierAccount)
+	biz := metadata.BizInst{}
+	if err := m.dbProxy.Table(common.BKTableNameBaseApp).Find(bizFilter).One(kit.Ctx, &biz); err != nil {
+		blog.Errorf("get resource pool business failed, filter: %+v, err: %v, rid: %s", bizFilter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	moduleFilter := map[string]interface{}{
+		common.BKAppIDField:   biz.BizID,
+		common.BKDefaultField: common.DefaultResModuleFlag,
+	}
+	moduleFilter = util.SetQueryOwner(moduleFilter, kit.SupplierAccount)
+	module := metadata.ModuleInst{}
+	if err := m.dbProxy.Table(common.BKTableNameBaseModule).Find(moduleFilter).One(kit.Ctx, &module); err != nil {
+		blog.Errorf("get resource pool idle module failed, filter: %+v, err: %v, rid: %s", moduleFilter, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCoreServiceDefaultModuleNotExist, biz.BizID)
+	}
+
+	input := &metadata.TransferHostToInnerModule{
+		ApplicationID: biz.BizID,
+		ModuleID:      module.ModuleID,
+		HostID:        []int64{hostID},
+	}
+	if _, err := m.dependent.TransferToInnerModule(kit, input); err != nil {
+		blog.Errorf("transfer restored host to idle module failed, input: %+v, err: %v, rid: %s", input, err, kit.Rid)
+		if ccErr, ok := err.(errors.CCErrorCoder); ok {
+			return ccErr
+		}
+		return kit.CCError.CCError(common.CCErrCoreServiceTransferHostModuleErr)
+	}
+	return nil
+}
+
+// restoreAssociations restores the associations of the item whose instances on the other side still exist
+func (m *recycleBinManager) restoreAssociations(kit *rest.Kit, item *metadata.RecycleBinItem) errors.CCErrorCoder {
+	for _, asst := range item.Associations {
+		objID, instID := asst.ObjectID, asst.InstID
+		if objID == item.ObjectID && instID == item.InstID {
+			objID, instID = asst.AsstObjectID, asst.AsstInstID
+		}
+		exists, ccErr := m.instanceExists(kit, objID, instID)
+		if ccErr != nil {
+			return ccErr
+		}
+		if !exists {
+			blog.V(4).Infof("skip restoring association %d, %s instance %d not exists, rid: %s", asst.ID, objID,
+				instID, kit.Rid)
+			continue
+		}
+
+		exists, ccErr = m.countExists(kit, common.BKTableNameInstAsst, map[string]interface{}{common.BKFieldID: asst.ID})
+		if ccErr != nil {
+			return ccErr
+		}
+		if exists {
+			continue
+		}
+		if err := m.dbProxy.Table(common.BKTableNameInstAsst).Insert(kit.Ctx, asst); err != nil {
+			blog.Errorf("restore association %d failed, err: %v, rid: %s", asst.ID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+		}
+	}
+	return nil
+}
@@ -321,6 +321,12 @@ func (p *setTemplateOperation) DeleteSetTemplate(kit *rest.Kit, bizID int64, opt
 		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
 	}
 
+	// delete host apply rules of the set templates
+	if err := p.dbProxy.Table(common.BKTableNameHostApplyRule).Delete(kit.Ctx, relationFilter); err != nil {
+		blog.Errorf("DeleteSetTemplate failed, db remove host apply rules failed, filter: %+v, err: %+v, rid: %s", relationFilter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
 	return nil
 }
 
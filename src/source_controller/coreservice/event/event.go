@@ -56,6 +56,26 @@ func NewEvent(db dal.DB, rds *redis.Client, watch stream.Interface, isMaster dis
 		return err
 	}
 
+	if err := e.runObjectInstance(context.Background()); err != nil {
+		blog.Errorf("run object instance event flow failed, err: %v", err)
+		return err
+	}
+
+	if err := e.runInstAsst(context.Background()); err != nil {
+		blog.Errorf("run instance association event flow failed, err: %v", err)
+		return err
+	}
+
+	if err := e.runServiceInstance(context.Background()); err != nil {
+		blog.Errorf("run service instance event flow failed, err: %v", err)
+		return err
+	}
+
+	if err := e.runProcess(context.Background()); err != nil {
+		blog.Errorf("run process event flow failed, err: %v", err)
+		return err
+	}
+
 	return nil
 }
 
@@ -130,3 +150,55 @@ func (e *Event) runModule(ctx context.Context) error {
 
 	return newFlow(ctx, opts)
 }
+
+func (e *Event) runObjectInstance(ctx context.Context) error {
+	opts := FlowOptions{
+		Collection: common.BKTableNameBaseInst,
+		key:        ObjInstKey,
+		rds:        e.rds,
+		watch:      e.watch,
+		db:         e.db,
+		isMaster:   e.isMaster,
+	}
+
+	return newFlow(ctx, opts)
+}
+
+func (e *Event) runInstAsst(ctx context.Context) error {
+	opts := FlowOptions{
+		Collection: common.BKTableNameInstAsst,
+		key:        InstAsstKey,
+		rds:        e.rds,
+		watch:      e.watch,
+		db:         e.db,
+		isMaster:   e.isMaster,
+	}
+
+	return newFlow(ctx, opts)
+}
+
+func (e *Event) runServiceInstance(ctx context.Context) error {
+	opts := FlowOptions{
+		Collection: common.BKTableNameServiceInstance,
+		key:        ServiceInstanceKey,
+		rds:        e.rds,
+		watch:      e.watch,
+		db:         e.db,
+		isMaster:   e.isMaster,
+	}
+
+	return newFlow(ctx, opts)
+}
+
+func (e *Event) runProcess(ctx context.Context) error {
+	opts := FlowOptions{
+		Collection: common.BKTableNameBaseProcess,
+		key:        ProcessKey,
+		rds:        e.rds,
+		watch:      e.watch,
+		db:         e.db,
+		isMaster:   e.isMaster,
+	}
+
+	return newFlow(ctx, opts)
+}
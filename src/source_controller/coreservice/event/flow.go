@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common/json"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
 	"configcenter/src/common/watch"
 	"configcenter/src/storage/stream/types"
 	"github.com/tidwall/gjson"
@@ -213,6 +214,8 @@ func (f *Flow) doUpsert(e *types.Event) (retry bool, err error) {
 
 func (f *Flow) do(e *types.Event) (retry bool, err error) {
 	blog.Infof("run flow, received %s %s event, oid: %s", f.Collection, e.OperationType, e.Oid)
+	// the event details are stored in redis and pushed to the watchers as they are, so the secrets are masked here.
+	e.DocBytes = secret.MaskJSON(e.DocBytes)
 	blog.V(5).Infof("event doc detail: %s, oid: %s", e.DocBytes, e.Oid)
 
 	// validate the event is valid or not.
@@ -308,6 +311,7 @@ func (f *Flow) initializeHeadTailNode(e *types.Event) (bool, error) {
 		Token:       e.Token.Data,
 		Cursor:      currentCursor,
 		NextCursor:  f.key.TailKey(),
+		ObjectID:    f.getObjectID(e),
 	}
 
 	nByte, err := json.Marshal(newNode)
@@ -463,6 +467,7 @@ func (f *Flow) insertNewNode(prevCursor string, prevNode *watch.ChainNode, e *ty
 		Token:       e.Token.Data,
 		Cursor:      currentCursor,
 		NextCursor:  f.key.TailKey(),
+		ObjectID:    f.getObjectID(e),
 	}
 
 	nBytes, err := json.Marshal(newNode)
@@ -524,6 +529,14 @@ func (f *Flow) insertNewNode(prevCursor string, prevNode *watch.ChainNode, e *ty
 	return false, nil
 }
 
+// getObjectID get the event document's object id, which is used to filter the object instance events.
+func (f *Flow) getObjectID(e *types.Event) string {
+	if f.Collection != common.BKTableNameBaseInst {
+		return ""
+	}
+	return gjson.GetBytes(e.DocBytes, common.BKObjIDField).String()
+}
+
 func (f *Flow) getLockWithRetry(name string, oid string) bool {
 	getLock := false
 	for retry := 0; retry < 10; retry++ {
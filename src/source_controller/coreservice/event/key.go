@@ -108,6 +108,84 @@ var ModuleKey = Key{
 	},
 }
 
+var objInstFields = []string{common.BKInstIDField, common.BKInstNameField, common.BKObjIDField}
+var ObjInstKey = Key{
+	namespace:  watchCacheNamespace + common.BKInnerObjIDObject,
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, objInstFields...)
+		for idx := range objInstFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", objInstFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		fields := gjson.GetManyBytes(doc, objInstFields...)
+		return fields[2].String() + ":" + fields[1].String()
+	},
+}
+
+var instAsstFields = []string{common.BKFieldID, common.BKObjIDField, common.BKInstIDField, common.BKAsstObjIDField,
+	common.BKAsstInstIDField}
+var InstAsstKey = Key{
+	namespace:  watchCacheNamespace + "inst_asst",
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, instAsstFields...)
+		for idx := range instAsstFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", instAsstFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		fields := gjson.GetManyBytes(doc, instAsstFields...)
+		return fmt.Sprintf("%s:%s -> %s:%s", fields[1].String(), fields[2].String(), fields[3].String(),
+			fields[4].String())
+	},
+}
+
+var serviceInstanceFields = []string{common.BKFieldID, common.BKFieldName}
+var ServiceInstanceKey = Key{
+	namespace:  watchCacheNamespace + "service_instance",
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, serviceInstanceFields...)
+		for idx := range serviceInstanceFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", serviceInstanceFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		fields := gjson.GetManyBytes(doc, serviceInstanceFields...)
+		return fields[1].String()
+	},
+}
+
+var processFields = []string{common.BKProcessIDField, common.BKProcessNameField}
+var ProcessKey = Key{
+	namespace:  watchCacheNamespace + common.BKInnerObjIDProc,
+	ttlSeconds: 6 * 60 * 60,
+	validator: func(doc []byte) error {
+		fields := gjson.GetManyBytes(doc, processFields...)
+		for idx := range processFields {
+			if !fields[idx].Exists() {
+				return fmt.Errorf("field %s not exist", processFields[idx])
+			}
+		}
+		return nil
+	},
+	instName: func(doc []byte) string {
+		fields := gjson.GetManyBytes(doc, processFields...)
+		return fields[1].String()
+	},
+}
+
 type Key struct {
 	namespace string
 	// the valid event's life time.
@@ -37,6 +37,14 @@ func GetResourceKeyWithCursorType(res watch.CursorType) (Key, error) {
 		key = SetKey
 	case watch.Module:
 		key = ModuleKey
+	case watch.ObjectBase:
+		key = ObjInstKey
+	case watch.InstAsst:
+		key = InstAsstKey
+	case watch.ServiceInstance:
+		key = ServiceInstanceKey
+	case watch.Process:
+		key = ProcessKey
 	default:
 		return key, fmt.Errorf("unsupported cursor type %s", res)
 	}
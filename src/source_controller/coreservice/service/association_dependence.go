@@ -35,3 +35,8 @@ func (s *coreService) IsInstanceExist(kit *rest.Kit, objID string, instID uint64
 	}
 	return true, nil
 }
+
+// RefreshComputedValues queues a task to re-evaluate the computed attributes' values of the instances
+func (s *coreService) RefreshComputedValues(kit *rest.Kit, objID string, instIDs []int64) error {
+	return s.core.InstanceOperation().RefreshComputedValues(kit, objID, instIDs)
+}
@@ -13,7 +13,10 @@
 package service
 
 import (
+	"strconv"
+
 	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
 )
@@ -52,3 +55,60 @@ func (s *coreService) SearchAuditLog(ctx *rest.Contexts) {
 		Info:  auditLogs,
 	})
 }
+
+func (s *coreService) ListAuditRetentionPolicy(ctx *rest.Contexts) {
+	result, err := s.core.AuditOperation().ListAuditRetentionPolicy(ctx.Kit)
+	if err != nil {
+		blog.Errorf("ListAuditRetentionPolicy failed, err: %+v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) UpdateAuditRetentionPolicy(ctx *rest.Contexts) {
+	policy := metadata.AuditRetentionPolicy{}
+	if err := ctx.DecodeInto(&policy); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.AuditOperation().UpdateAuditRetentionPolicy(ctx.Kit, policy); err != nil {
+		blog.Errorf("UpdateAuditRetentionPolicy failed, policy: %+v, err: %+v, rid: %s", policy, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) ListAuditLogArchive(ctx *rest.Contexts) {
+	option := metadata.ListAuditLogArchiveOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuditOperation().ListAuditArchive(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("ListAuditLogArchive failed, option: %+v, err: %+v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) ImportAuditLogArchive(ctx *rest.Contexts) {
+	archiveID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return
+	}
+
+	result, ccErr := s.core.AuditOperation().ImportAuditArchive(ctx.Kit, archiveID)
+	if ccErr != nil {
+		blog.Errorf("ImportAuditLogArchive failed, archive: %d, err: %+v, rid: %s", archiveID, ccErr, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+	ctx.RespEntity(result)
+}
@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/secret"
 	"configcenter/src/common/util"
 
 	"gopkg.in/mgo.v2/bson"
@@ -161,9 +162,38 @@ func (s *coreService) GetHostByID(ctx *rest.Contexts) {
 		return
 	}
 
+	if err := s.formatHostValues(ctx.Kit, result); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
 	ctx.RespEntity(result)
 }
 
+// formatHostValues masks the secret values of the hosts, and converts their normalized network values back to
+// their common forms.
+func (s *coreService) formatHostValues(kit *rest.Kit, hosts ...metadata.HostMapStr) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	cond := map[string]interface{}{common.BKObjIDField: common.BKInnerObjIDHost}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	attributes := make([]metadata.Attribute, 0)
+	err := s.db.Table(common.BKTableNameObjAttDes).Find(cond).
+		Fields(common.BKPropertyIDField, common.BKPropertyTypeField, common.BKOptionField).All(kit.Ctx, &attributes)
+	if err != nil {
+		blog.Errorf("get host attributes failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	secretFields := metadata.GetSecretPropertyIDs(attributes)
+	for _, host := range hosts {
+		secret.MaskMapStr(host, secretFields...)
+		metadata.DenormalizeNetworkValues(attributes, host)
+	}
+	return nil
+}
+
 func (s *coreService) GetHosts(ctx *rest.Contexts) {
 	var dat metadata.ObjQueryInput
 	if err := ctx.DecodeInto(&dat); err != nil {
@@ -211,6 +241,10 @@ func (s *coreService) GetHosts(ctx *rest.Contexts) {
 		return
 	}
 
+	if err := s.formatHostValues(ctx.Kit, result...); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
 	info := make([]mapstr.MapStr, len(result))
 	for index, host := range result {
 		info[index] = mapstr.MapStr(host)
@@ -71,6 +71,8 @@ func (s *coreService) AddUserConfig(ctx *rest.Contexts) {
 		AppID:      addQuery.AppID,
 		Info:       addQuery.Info,
 		Name:       addQuery.Name,
+		ObjID:      addQuery.ObjID,
+		Filter:     addQuery.Filter,
 		ID:         id,
 		CreateTime: time.Now().UTC(),
 		CreateUser: addQuery.CreateUser,
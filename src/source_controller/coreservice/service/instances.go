@@ -13,6 +13,8 @@
 package service
 
 import (
+	"strconv"
+
 	"configcenter/src/common"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
@@ -112,3 +114,16 @@ func (s *coreService) CascadeDeleteModelInstances(ctx *rest.Contexts) {
 	}
 	ctx.RespEntityWithError(s.core.InstanceOperation().CascadeDeleteModelInstance(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), inputData))
 }
+
+// RevealModelInstanceSecret returns the plaintext of the instance's secret attribute value
+func (s *coreService) RevealModelInstanceSecret(ctx *rest.Contexts) {
+	instID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKInstIDField), 10, 64)
+	if err != nil || instID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKInstIDField))
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	propertyID := ctx.Request.PathParameter(common.BKPropertyIDField)
+	ctx.RespEntityWithError(s.core.InstanceOperation().RevealSecretValue(ctx.Kit, objID, instID, propertyID))
+}
@@ -95,3 +95,8 @@ func (s *coreService) CascadeDeleteInstances(kit *rest.Kit, objIDS []string) err
 
 	return nil
 }
+
+// RefreshModelComputedValues queues a task to re-evaluate the computed attributes' values of all the instances of the model
+func (s *coreService) RefreshModelComputedValues(kit *rest.Kit, objID string) error {
+	return s.core.InstanceOperation().RefreshComputedValues(kit, objID, nil)
+}
@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func (s *coreService) ListRecycleBin(ctx *rest.Contexts) {
+	option := metadata.ListRecycleBinOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.RecycleBinOperation().ListRecycleBin(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("ListRecycleBin failed, option: %+v, err: %+v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) RestoreRecycleBin(ctx *rest.Contexts) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return
+	}
+
+	item, ccErr := s.core.RecycleBinOperation().RestoreRecycleBin(ctx.Kit, id)
+	if ccErr != nil {
+		blog.Errorf("RestoreRecycleBin failed, id: %d, err: %+v, rid: %s", id, ccErr, ctx.Kit.Rid)
+		ctx.RespAutoError(ccErr)
+		return
+	}
+	ctx.RespEntity(item)
+}
+
+func (s *coreService) PurgeRecycleBin(ctx *rest.Contexts) {
+	option := metadata.PurgeRecycleBinOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.RecycleBinOperation().PurgeRecycleBin(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("PurgeRecycleBin failed, option: %+v, err: %+v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+// RecycleInstances moves the instances to be deleted into the recycle bin
+func (s *coreService) RecycleInstances(kit *rest.Kit, objID string, origins []mapstr.MapStr) error {
+	if err := s.core.RecycleBinOperation().RecycleInstances(kit, objID, origins); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreModelInstance saves the deleted instance back with its original id
+func (s *coreService) RestoreModelInstance(kit *rest.Kit, objID string, data mapstr.MapStr) error {
+	return s.core.InstanceOperation().RestoreModelInstance(kit, objID, data)
+}
+
+// TransferToInnerModule transfers the hosts to the inner module of the business
+func (s *coreService) TransferToInnerModule(kit *rest.Kit, input *metadata.TransferHostToInnerModule) (
+	[]metadata.ExceptionResult, error) {
+	return s.core.HostOperation().TransferToInnerModule(kit, input)
+}
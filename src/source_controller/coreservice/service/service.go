@@ -37,6 +37,7 @@ import (
 	"configcenter/src/source_controller/coreservice/core/model"
 	"configcenter/src/source_controller/coreservice/core/operation"
 	"configcenter/src/source_controller/coreservice/core/process"
+	"configcenter/src/source_controller/coreservice/core/recyclebin"
 	"configcenter/src/source_controller/coreservice/core/settemplate"
 	dbSystem "configcenter/src/source_controller/coreservice/core/system"
 	watchEvent "configcenter/src/source_controller/coreservice/event"
@@ -110,6 +111,16 @@ func (s *coreService) SetConfig(cfg options.Config, engine *backbone.Engine, err
 	s.db = db
 	s.rds = cache
 
+	auditSinks, sinkErr := auditlog.NewSinkManager(cfg.AuditSinks)
+	if sinkErr != nil {
+		blog.Errorf("new audit sinks failed, err: %v", sinkErr)
+		return sinkErr
+	}
+	auditSinks.Run()
+
+	auditOperation := auditlog.New(db, auditSinks)
+	auditlog.NewRetentionJob(db, auditOperation, engine.ServiceManageInterface).Run()
+
 	// connect the remote mongodb
 	instance := instances.New(db, s, cache, lang)
 	hostApplyRuleCore := hostapplyrule.New(db, instance)
@@ -120,14 +131,19 @@ func (s *coreService) SetConfig(cfg options.Config, engine *backbone.Engine, err
 		datasynchronize.New(db, s),
 		mainline.New(db, lang),
 		host.New(db, cache, s, hostApplyRuleCore),
-		auditlog.New(db),
+		auditOperation,
 		process.New(db, s, cache),
 		label.New(db),
 		settemplate.New(db),
 		operation.New(db),
 		hostApplyRuleCore,
 		dbSystem.New(db),
+		recyclebin.New(db, s, cfg.RecycleBin),
 	)
+	mainline.NewTopoSnapshotJob(db, lang, engine.ServiceManageInterface).Run()
+	recyclebin.NewPurgeJob(db, engine.ServiceManageInterface).Run()
+	instances.NewSecretRotateJob(db, cache, auditOperation, engine.ServiceManageInterface).Run()
+	instances.NewComputedRefreshJob(db, cache, auditOperation, engine.ServiceManageInterface).Run()
 
 	event, eventErr := reflector.NewReflector(s.cfg.Mongo.GetMongoConf())
 	if eventErr != nil {
@@ -36,5 +36,11 @@ func (s *coreService) initHostApplyRule(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/modules/bk_biz_id/{bk_biz_id}/host_apply_rule_related", Handler: s.SearchRuleRelatedModules})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/updatemany/host/bk_biz_id/{bk_biz_id}/update_by_host_apply", Handler: s.UpdateHostByHostApplyRule})
 
+	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/find/host_apply_drift_config/bk_biz_id/{bk_biz_id}/", Handler: s.GetHostApplyDriftConfig})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/host_apply_drift_config/bk_biz_id/{bk_biz_id}/", Handler: s.UpdateHostApplyDriftConfig})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_apply_drift_config", Handler: s.ListHostApplyDriftConfig})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/updatemany/host_apply_drift", Handler: s.SaveHostApplyDrift})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host_apply_drift/bk_biz_id/{bk_biz_id}/", Handler: s.ListHostApplyDrift})
+
 	utility.AddToRestfulWebService(web)
 }
@@ -100,6 +100,7 @@ func (s *coreService) initModelInstances(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/{bk_obj_id}/instances", Handler: s.SearchModelInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/model/{bk_obj_id}/instance", Handler: s.DeleteModelInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/model/{bk_obj_id}/instance/cascade", Handler: s.CascadeDeleteModelInstances})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/{bk_obj_id}/instance/{bk_inst_id}/secret/{bk_property_id}", Handler: s.RevealModelInstanceSecret})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -163,6 +164,14 @@ func (s *coreService) initMainline(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/mainline/model", Handler: s.SearchMainlineModelTopo})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/mainline/instance/{bk_biz_id}", Handler: s.SearchMainlineInstanceTopo})
 
+	// add handler for business topo snapshots
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/topo/snapshot/bk_biz_id/{bk_biz_id}", Handler: s.CreateTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/topo/snapshot/bk_biz_id/{bk_biz_id}", Handler: s.ListTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/topo/snapshot/{id}/bk_biz_id/{bk_biz_id}", Handler: s.DeleteTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/topo/snapshot/diff/bk_biz_id/{bk_biz_id}", Handler: s.DiffTopoSnapshot})
+	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/find/topo/snapshot/schedule/bk_biz_id/{bk_biz_id}", Handler: s.GetTopoSnapshotSchedule})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/topo/snapshot/schedule/bk_biz_id/{bk_biz_id}", Handler: s.UpdateTopoSnapshotSchedule})
+
 	utility.AddToRestfulWebService(web)
 }
 
@@ -225,6 +234,10 @@ func (s *coreService) audit(web *restful.WebService) {
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/auditlog", Handler: s.CreateAuditLog})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/auditlog", Handler: s.SearchAuditLog})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/auditlog/retention_policy", Handler: s.ListAuditRetentionPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/auditlog/retention_policy", Handler: s.UpdateAuditRetentionPolicy})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/auditlog/archive", Handler: s.ListAuditLogArchive})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/import/auditlog/archive/{id}", Handler: s.ImportAuditLogArchive})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -326,6 +339,19 @@ func (s *coreService) initCount(web *restful.WebService) {
 	utility.AddToRestfulWebService(web)
 }
 
+func (s *coreService) initRecycleBin(web *restful.WebService) {
+	utility := rest.NewRestUtility(rest.Config{
+		ErrorIf:  s.engine.CCErr,
+		Language: s.engine.Language,
+	})
+
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/recycle_bin", Handler: s.ListRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/restore/recycle_bin/{id}", Handler: s.RestoreRecycleBin})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/recycle_bin", Handler: s.PurgeRecycleBin})
+
+	utility.AddToRestfulWebService(web)
+}
+
 func (s *coreService) initService(web *restful.WebService) {
 	s.initModelClassification(web)
 	s.initModel(web)
@@ -348,4 +374,5 @@ func (s *coreService) initService(web *restful.WebService) {
 	s.transaction(web)
 	s.initCount(web)
 	s.initCache(web)
+	s.initRecycleBin(web)
 }
@@ -0,0 +1,144 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+func parseTopoSnapshotBizID(ctx *rest.Contexts) (int64, bool) {
+	bizID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKAppIDField), 10, 64)
+	if err != nil || bizID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return 0, false
+	}
+	return bizID, true
+}
+
+func (s *coreService) CreateTopoSnapshot(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	option := metadata.CreateTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	snapshot, err := s.core.TopoOperation().CreateTopoSnapshot(ctx.Kit, bizID, option)
+	if err != nil {
+		blog.Errorf("CreateTopoSnapshot failed, business: %d, err: %+v, rid: %s", bizID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(snapshot)
+}
+
+func (s *coreService) ListTopoSnapshot(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	option := metadata.ListTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.TopoOperation().ListTopoSnapshot(ctx.Kit, bizID, option)
+	if err != nil {
+		blog.Errorf("ListTopoSnapshot failed, business: %d, option: %+v, err: %+v, rid: %s", bizID, option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) DeleteTopoSnapshot(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	snapshotID, err := strconv.ParseInt(ctx.Request.PathParameter(common.BKFieldID), 10, 64)
+	if err != nil || snapshotID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return
+	}
+
+	if err := s.core.TopoOperation().DeleteTopoSnapshot(ctx.Kit, bizID, snapshotID); err != nil {
+		blog.Errorf("DeleteTopoSnapshot failed, business: %d, snapshot: %d, err: %+v, rid: %s", bizID, snapshotID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) DiffTopoSnapshot(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	option := metadata.DiffTopoSnapshotOption{}
+	if err := ctx.DecodeInto(&option); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	diff, err := s.core.TopoOperation().DiffTopoSnapshot(ctx.Kit, bizID, option)
+	if err != nil {
+		blog.Errorf("DiffTopoSnapshot failed, business: %d, option: %+v, err: %+v, rid: %s", bizID, option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(diff)
+}
+
+func (s *coreService) GetTopoSnapshotSchedule(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+
+	schedule, err := s.core.TopoOperation().GetTopoSnapshotSchedule(ctx.Kit, bizID)
+	if err != nil {
+		blog.Errorf("GetTopoSnapshotSchedule failed, business: %d, err: %+v, rid: %s", bizID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(schedule)
+}
+
+func (s *coreService) UpdateTopoSnapshotSchedule(ctx *rest.Contexts) {
+	bizID, ok := parseTopoSnapshotBizID(ctx)
+	if !ok {
+		return
+	}
+	schedule := metadata.TopoSnapshotSchedule{}
+	if err := ctx.DecodeInto(&schedule); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.TopoOperation().UpdateTopoSnapshotSchedule(ctx.Kit, bizID, schedule); err != nil {
+		blog.Errorf("UpdateTopoSnapshotSchedule failed, business: %d, schedule: %+v, err: %+v, rid: %s", bizID, schedule, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
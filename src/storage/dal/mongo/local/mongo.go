@@ -503,6 +503,10 @@ func (c *Collection) tryArchiveDeletedDoc(ctx context.Context, filter types.Filt
 	case common.BKTableNameBaseApp:
 	case common.BKTableNameBaseSet:
 	case common.BKTableNameBaseModule:
+	case common.BKTableNameBaseInst:
+	case common.BKTableNameInstAsst:
+	case common.BKTableNameServiceInstance:
+	case common.BKTableNameBaseProcess:
 	default:
 		// do not archive the delete docs
 		return nil
@@ -140,7 +140,7 @@ func newAuthService(c *authConf) (*authService, error) {
 		AppSecret: c.appSecret,
 		SystemID:  authcenter.SystemIDCMDB,
 	}
-	authorize, err := auth.NewAuthorize(nil, authConf, nil)
+	authorize, err := auth.NewAuthorize(context.Background(), nil, authConf, nil)
 	if err != nil {
 		return nil, err
 	}
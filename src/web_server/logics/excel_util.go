@@ -14,6 +14,7 @@ package logics
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -127,6 +128,22 @@ func setExcelRowDataByIndex(rowMap mapstr.MapStr, sheet *xlsx.Sheet, rowIndex in
 				cell.SetString(cellVal)
 			}
 
+		case common.FieldTypeMultiEnum:
+			arrVal, ok := property.Option.([]interface{})
+			enumIDs, enumIDOk := val.([]interface{})
+			if ok && enumIDOk {
+				cell.SetString(getMultiEnumNamesByIDs(enumIDs, arrVal))
+			}
+
+		case common.FieldTypeTable:
+			if val == nil {
+				continue
+			}
+			tableVal, err := json.Marshal(val)
+			if nil == err {
+				cell.SetString(string(tableVal))
+			}
+
 		case common.FieldTypeBool:
 			bl, ok := val.(bool)
 			if ok {
@@ -244,6 +261,19 @@ func getDataFromByExcelRow(ctx context.Context, row *xlsx.Row, rowIndex int, fie
 			if optionOk {
 				host[fieldName] = getEnumIDByName(cell.Value, option)
 			}
+		case common.FieldTypeMultiEnum:
+			option, optionOk := field.Option.([]interface{})
+			if optionOk {
+				host[fieldName] = getMultiEnumIDsByNames(cell.Value, option)
+			}
+		case common.FieldTypeTable:
+			// table value is exported as json, keep the raw value if it's invalid so that validation reports it
+			var rows []interface{}
+			if err := json.Unmarshal([]byte(strings.TrimSpace(cell.Value)), &rows); nil == err {
+				host[fieldName] = rows
+			} else {
+				blog.Debug("get excel cell value error, field:%s, value:%s, error:%s, rid: %s", fieldName, host[fieldName], err.Error(), rid)
+			}
 		case common.FieldTypeInt:
 			intVal, err := util.GetInt64ByInterface(host[fieldName])
 			// convertor int not err , set field value to correct type
@@ -315,16 +345,22 @@ func productExcelHealer(ctx context.Context, fields map[string]Property, filter
 		}
 		isRequire := ""
 
-		if field.IsRequire {
+		// the field with default value can be left empty when creating instance, the default value is used
+		required := field.IsRequire && field.Default == nil
+		if required {
 			// "(必填)"
 			isRequire = defLang.Language("web_excel_header_required")
 		}
+		if field.Default != nil {
+			// "(默认值: xxx)"
+			isRequire = defLang.Languagef("web_excel_header_default", field.Default)
+		}
 		if util.Contains(filter, field.ID) {
 			continue
 		}
 		cellName := sheet.Cell(0, index)
 		cellName.Value = field.Name + isRequire
-		cellName.SetStyle(getHeaderFirstRowCellStyle(field.IsRequire))
+		cellName.SetStyle(getHeaderFirstRowCellStyle(required))
 
 		cellType := sheet.Cell(1, index)
 		cellType.Value = fieldTypeName
@@ -354,6 +390,10 @@ func productExcelHealer(ctx context.Context, fields map[string]Property, filter
 			}
 			sheet.Col(index).SetType(xlsx.CellTypeString)
 
+		case common.FieldTypeMultiEnum:
+			// multiple options can't be selected in drop list, the option names are separated by multiEnumSeparator
+			sheet.Col(index).SetType(xlsx.CellTypeString)
+
 		case common.FieldTypeBool:
 			dd := xlsx.NewXlsxCellDataValidation(true, true, true)
 			if err := dd.SetDropList([]string{fieldTypeBoolTrue, fieldTypeBoolFalse}); err != nil {
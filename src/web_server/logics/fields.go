@@ -31,6 +31,7 @@ type Property struct {
 	Name          string
 	PropertyType  string
 	Option        interface{}
+	Default       interface{}
 	IsPre         bool
 	IsRequire     bool
 	Group         string
@@ -252,6 +253,7 @@ func (lgc *Logics) getObjFieldIDsBySort(objID, sort string, header http.Header,
 			IsRequire:     attr.IsRequired,
 			IsPre:         attr.IsPre,
 			Option:        attr.Option,
+			Default:       attr.Default,
 			Group:         attr.PropertyGroup,
 			ExcelColIndex: int(attr.PropertyIndex),
 			IsOnly:        keyIDs[uint64(attr.ID)],
@@ -277,6 +279,13 @@ func getPropertyTypeAliasName(propertyType string, defLang lang.DefaultCCLanguag
 	case common.FieldTypeOrganization:
 	case common.FieldTypeBool:
 	case common.FieldTypeTimeZone:
+	case common.FieldTypeIPv4:
+	case common.FieldTypeIPv6:
+	case common.FieldTypeCIDR:
+	case common.FieldTypeMultiEnum:
+	case common.FieldTypeTable:
+	case common.FieldTypeComputed:
+	case common.FieldTypeSecret:
 
 	}
 	if "" == name {
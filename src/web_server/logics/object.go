@@ -97,7 +97,9 @@ func ConvAttrOption(attrItems map[int]map[string]interface{}) {
 			continue
 		}
 		fieldType, _ := attr[common.BKPropertyTypeField].(string)
-		if common.FieldTypeEnum != fieldType && common.FieldTypeInt != fieldType && common.FieldTypeList != fieldType {
+		switch fieldType {
+		case common.FieldTypeEnum, common.FieldTypeInt, common.FieldTypeList, common.FieldTypeMultiEnum, common.FieldTypeTable:
+		default:
 			continue
 		}
 
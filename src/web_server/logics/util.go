@@ -24,6 +24,9 @@ import (
 const (
 	fieldTypeBoolTrue  = "true"
 	fieldTypeBoolFalse = "false"
+
+	// multiEnumSeparator separate the option names of the multi_enum value in excel cell
+	multiEnumSeparator = ","
 )
 
 // getFieldsIDIndexMap get field property index
@@ -97,6 +100,34 @@ func getEnumIDByName(name string, items []interface{}) string {
 	return id
 }
 
+// getMultiEnumNamesByIDs get the joined enum names of the multi_enum value from option
+func getMultiEnumNamesByIDs(ids []interface{}, items []interface{}) string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strID, ok := id.(string)
+		if !ok {
+			continue
+		}
+		names = append(names, getEnumNameByID(strID, items))
+	}
+
+	return strings.Join(names, multiEnumSeparator)
+}
+
+// getMultiEnumIDsByNames get the multi_enum value from the joined enum names
+func getMultiEnumIDsByNames(names string, items []interface{}) []interface{} {
+	ids := make([]interface{}, 0)
+	for _, name := range strings.Split(names, multiEnumSeparator) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		ids = append(ids, getEnumIDByName(name, items))
+	}
+
+	return ids
+}
+
 // getEnumNames get enum name from option
 func getEnumNames(items []interface{}) []string {
 	var names []string